@@ -25,6 +25,7 @@ var (
 // Global flags
 var (
 	configFile string
+	address    string
 	envOnly    bool
 	production bool
 	verbose    bool
@@ -59,6 +60,9 @@ It validates service names, trust domains, socket paths, and other security sett
   # JSON output for CI:
   config-validator --env-only --format json --production
 
+  # Include the address the server sets in code with WithAddress:
+  config-validator --config config/production.yaml --address :9443
+
   # Verbose validation:
   config-validator --env-only --verbose`,
 	RunE: runValidator,
@@ -97,6 +101,7 @@ func init() {
 
 	// Root command flags
 	rootCmd.Flags().StringVar(&configFile, "config", "", "Path to configuration file")
+	rootCmd.Flags().StringVar(&address, "address", "", "Listen address set in code with WithAddress, if any")
 	rootCmd.Flags().BoolVar(&envOnly, "env-only", false, "Validate environment variables only (most secure)")
 	rootCmd.Flags().BoolVar(&production, "production", false, "Perform production readiness validation")
 	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose output")
@@ -139,6 +144,8 @@ func runValidator(cmd *cobra.Command, args []string) error {
 		Configuration: &Config{
 			ServiceName: cfg.Service.Name.Value(),
 			TrustDomain: cfg.Service.Domain,
+			FIPSMode:    cfg.FIPSMode,
+			Settings:    withOptions(cfg.Settings(), map[string]string{"server.address": address}),
 		},
 	}
 
@@ -152,6 +159,12 @@ func runValidator(cmd *cobra.Command, args []string) error {
 		printer.Newline()
 	}
 
+	// Show where each effective setting came from
+	if format == "text" && !quiet {
+		displaySettings(printer, result.Configuration.Settings)
+		printer.Newline()
+	}
+
 	// Perform basic validation
 	if format == "text" {
 		printer.Info("Performing basic validation...")
@@ -181,6 +194,12 @@ func runValidator(cmd *cobra.Command, args []string) error {
 			printer.Production("Performing production readiness validation...")
 		}
 
+		fipsStatus := ports.CurrentFIPSStatus()
+		result.FIPS = &fipsStatus
+		if format == "text" {
+			printer.Lock("FIPS 140-3 module: " + fipsStatus.String())
+		}
+
 		if err := cfg.IsProductionReady(); err != nil {
 			result.ProductionValid = false
 			tips := getProductionTips(err)
@@ -279,6 +298,23 @@ func displayConfigurationCobra(printer *Printer, cfg *ports.Configuration) {
 	}
 }
 
+// displaySettings displays the effective settings and the layer each came from
+func displaySettings(printer *Printer, settings []ports.Setting) {
+	printer.Section("Effective Settings (default < file < env < option):")
+	for _, setting := range settings {
+		printer.Infof("   %s: %s (%s)", setting.Name, setting.Value, setting.Source)
+	}
+}
+
+// withOptions overrides the settings with the values the application sets in code,
+// keyed by setting name
+func withOptions(settings []ports.Setting, options map[string]string) []ports.Setting {
+	for i, setting := range settings {
+		settings[i] = setting.WithOption(options[setting.Name])
+	}
+	return settings
+}
+
 // handleLoadErrorCobra handles configuration load errors
 func handleLoadErrorCobra(printer *Printer, format string, err error) {
 	if format == "json" {
@@ -323,6 +323,30 @@ func TestJSONOutput(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "effective settings with sources",
+			result: &Result{
+				BasicValid:      true,
+				ProductionValid: true,
+				Configuration: &Config{
+					ServiceName: "test-service",
+					Settings: []ports.Setting{
+						{Name: "server.address", Value: ":9443", Source: ports.SettingSourceFile},
+						{Name: "logging.level", Value: "warn", Source: ports.SettingSourceEnv},
+					},
+				},
+			},
+			validate: func(t *testing.T, output map[string]interface{}) {
+				settings := output["configuration"].(map[string]interface{})["settings"].([]interface{})
+				if len(settings) != 2 {
+					t.Fatalf("expected 2 settings, got %d", len(settings))
+				}
+				level := settings[1].(map[string]interface{})
+				if level["name"] != "logging.level" || level["value"] != "warn" || level["source"] != "env" {
+					t.Errorf("unexpected setting: %v", level)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -370,3 +394,18 @@ func TestExitCodes(t *testing.T) {
 		t.Error("ExitUsageError should not equal ExitSuccess")
 	}
 }
+
+func TestWithOptions(t *testing.T) {
+	t.Setenv(ports.EnvBindAddress, "")
+	settings := withOptions((&ports.Configuration{}).Settings(), map[string]string{"server.address": ":9443"})
+
+	for _, setting := range settings {
+		if setting.Name == "server.address" {
+			if setting.Value != ":9443" || setting.Source != ports.SettingSourceOption {
+				t.Errorf("server.address = %s (%s), want :9443 (option)", setting.Value, setting.Source)
+			}
+		} else if setting.Source == ports.SettingSourceOption {
+			t.Errorf("%s reported as an option without one", setting.Name)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package main
 
+import "github.com/sufield/ephemos/internal/core/ports"
+
 // Result represents the validation result for JSON output
 type Result struct {
 	BasicValid      bool     `json:"basic_valid"`
@@ -8,6 +10,8 @@ type Result struct {
 	Messages        []string `json:"messages,omitempty"`
 	Errors          []string `json:"errors,omitempty"`
 	Configuration   *Config  `json:"configuration,omitempty"`
+	// FIPS is the Go FIPS 140-3 module status, reported with --production
+	FIPS *ports.FIPSStatus `json:"fips140,omitempty"`
 }
 
 // Config represents the configuration details for JSON output
@@ -15,4 +19,8 @@ type Config struct {
 	ServiceName string `json:"service_name"`
 	TrustDomain string `json:"trust_domain"`
 	AgentSocket string `json:"agent_socket,omitempty"`
+	FIPSMode    bool   `json:"fips_mode,omitempty"`
+	// Settings lists the effective server, logging and auth settings and the
+	// layer (default, file or env) each came from
+	Settings []ports.Setting `json:"settings,omitempty"`
 }
@@ -52,6 +52,26 @@ func getProductionTips(err error) []string {
 		tips = append(tips, "Set EPHEMOS_SPIFFE_SOCKET to a secure path like '/run/spire/sockets/api.sock'")
 	}
 
+	if stderrors.Is(err, errors.ErrWeakTLSVersion) {
+		tips = append(tips, "Set "+ports.EnvTLSMinVersion+" (tls.min_version) to 1.2 or 1.3")
+	}
+
+	if stderrors.Is(err, errors.ErrWeakCipherSuite) {
+		tips = append(tips, "Limit tls.cipher_suites to ECDHE suites with AES-GCM or ChaCha20-Poly1305")
+	}
+
+	if stderrors.Is(err, errors.ErrAuthenticationNotRequired) {
+		tips = append(tips, "Set "+ports.EnvRequireAuth+"=true (auth.require) so servers reject clients without an SVID")
+	}
+
+	if stderrors.Is(err, errors.ErrDevelopmentCA) {
+		tips = append(tips, "Set identity.source to workload_api or files; the dev_ca source is for tests and local development")
+	}
+
+	if stderrors.Is(err, errors.ErrFIPSModuleDisabled) {
+		tips = append(tips, "Build with GOFIPS140=v1.0.0 or run with GODEBUG=fips140=on to enable the Go FIPS 140-3 module")
+	}
+
 	// Handle any other ProductionValidationError
 	var prodErr *errors.ProductionValidationError
 	if stderrors.As(err, &prodErr) && len(tips) == 0 {
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"path"
 	"strings"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -30,14 +31,14 @@ type ServiceIdentity struct {
 type IdentityConfig struct {
 	// ConfigPath is the path to the ephemos configuration file
 	ConfigPath string
-	
+
 	// RequireClientCert determines if client certificates are required
 	// Default: true (mutual TLS required)
 	RequireClientCert bool
-	
+
 	// TrustDomains specifies allowed trust domains. Empty means allow all.
 	TrustDomains []string
-	
+
 	// Logger for middleware events
 	Logger *slog.Logger
 }
@@ -46,11 +47,12 @@ type IdentityConfig struct {
 // and injects service identity into the request context.
 //
 // Usage:
-//   config := &chi.IdentityConfig{
-//       ConfigPath: "/etc/ephemos/config.yaml",
-//       RequireClientCert: true,
-//   }
-//   r.Use(chi.IdentityMiddleware(config))
+//
+//	config := &chi.IdentityConfig{
+//	    ConfigPath: "/etc/ephemos/config.yaml",
+//	    RequireClientCert: true,
+//	}
+//	r.Use(chi.IdentityMiddleware(config))
 func IdentityMiddleware(config *IdentityConfig) func(http.Handler) http.Handler {
 	if config == nil {
 		panic("IdentityConfig cannot be nil")
@@ -196,11 +198,12 @@ func IdentityFromContext(ctx context.Context) *ServiceIdentity {
 // Use this after IdentityMiddleware when you want to require authentication for specific routes.
 //
 // Usage:
-//   r.Route("/api", func(r chi.Router) {
-//       r.Use(chi.IdentityMiddleware(config))
-//       r.Use(chi.RequireIdentity)
-//       r.Get("/secure", handler)
-//   })
+//
+//	r.Route("/api", func(r chi.Router) {
+//	    r.Use(chi.IdentityMiddleware(config))
+//	    r.Use(chi.RequireIdentity)
+//	    r.Get("/secure", handler)
+//	})
 func RequireIdentity(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		identity := IdentityFromContext(r.Context())
@@ -216,7 +219,8 @@ func RequireIdentity(next http.Handler) http.Handler {
 // one of the specified service names.
 //
 // Usage:
-//   r.Use(chi.RequireService("payment-service", "order-service"))
+//
+//	r.Use(chi.RequireService("payment-service", "order-service"))
 func RequireService(allowedServices ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -247,4 +251,38 @@ func RequireService(allowedServices ...string) func(http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 		})
 	}
-}
\ No newline at end of file
+}
+
+// RequirePolicy creates a middleware that authorizes requests with an ephemos
+// authorization policy. The client identity, method and path are evaluated against
+// the policy rules, and denied requests are rejected with the name of the deciding rule.
+//
+// Usage:
+//
+//	policy, err := ephemos.NewPolicy(config)
+//	r.Use(chi.IdentityMiddleware(identityConfig))
+//	r.Use(chi.RequirePolicy(policy))
+func RequirePolicy(policy *ephemos.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := IdentityFromContext(r.Context())
+			if identity == nil {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			// Match the path the request is routed to, not its raw spelling
+			decision := policy.AuthorizeHTTP(identity.ID, r.Method, path.Clean("/"+r.URL.Path))
+			if !decision.Allowed {
+				slog.Warn("Policy access denied",
+					slog.String("spiffe_id", identity.ID),
+					slog.String("rule", decision.Rule),
+					slog.String("reason", decision.Reason))
+				http.Error(w, fmt.Sprintf("Access denied by policy rule %q", decision.Rule), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
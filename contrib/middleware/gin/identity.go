@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"path"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -31,14 +32,14 @@ type ServiceIdentity struct {
 type IdentityConfig struct {
 	// ConfigPath is the path to the ephemos configuration file
 	ConfigPath string
-	
+
 	// RequireClientCert determines if client certificates are required
 	// Default: true (mutual TLS required)
 	RequireClientCert bool
-	
+
 	// TrustDomains specifies allowed trust domains. Empty means allow all.
 	TrustDomains []string
-	
+
 	// Logger for middleware events
 	Logger *slog.Logger
 }
@@ -47,11 +48,12 @@ type IdentityConfig struct {
 // and injects service identity into the request context.
 //
 // Usage:
-//   config := &gin.IdentityConfig{
-//       ConfigPath: "/etc/ephemos/config.yaml",
-//       RequireClientCert: true,
-//   }
-//   r.Use(gin.IdentityMiddleware(config))
+//
+//	config := &gin.IdentityConfig{
+//	    ConfigPath: "/etc/ephemos/config.yaml",
+//	    RequireClientCert: true,
+//	}
+//	r.Use(gin.IdentityMiddleware(config))
 func IdentityMiddleware(config *IdentityConfig) gin.HandlerFunc {
 	if config == nil {
 		panic("IdentityConfig cannot be nil")
@@ -108,10 +110,10 @@ func IdentityMiddleware(config *IdentityConfig) gin.HandlerFunc {
 		if identity != nil {
 			ctx := context.WithValue(c.Request.Context(), IdentityContextKey{}, identity)
 			c.Request = c.Request.WithContext(ctx)
-			
+
 			// Also set in Gin context for easy access
 			c.Set("spiffe_identity", identity)
-			
+
 			config.Logger.Debug("Client identity authenticated",
 				slog.String("spiffe_id", identity.ID),
 				slog.String("service_name", identity.Name),
@@ -213,10 +215,11 @@ func IdentityFromGinContext(c *gin.Context) *ServiceIdentity {
 // Use this after IdentityMiddleware when you want to require authentication for specific routes.
 //
 // Usage:
-//   authenticated := r.Group("/api")
-//   authenticated.Use(gin.IdentityMiddleware(config))
-//   authenticated.Use(gin.RequireIdentity)
-//   authenticated.GET("/secure", handler)
+//
+//	authenticated := r.Group("/api")
+//	authenticated.Use(gin.IdentityMiddleware(config))
+//	authenticated.Use(gin.RequireIdentity)
+//	authenticated.GET("/secure", handler)
 func RequireIdentity(c *gin.Context) {
 	identity := IdentityFromGinContext(c)
 	if identity == nil {
@@ -231,7 +234,8 @@ func RequireIdentity(c *gin.Context) {
 // one of the specified service names.
 //
 // Usage:
-//   r.Use(gin.RequireService("payment-service", "order-service"))
+//
+//	r.Use(gin.RequireService("payment-service", "order-service"))
 func RequireService(allowedServices ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		identity := IdentityFromGinContext(c)
@@ -268,7 +272,8 @@ func RequireService(allowedServices ...string) gin.HandlerFunc {
 // belongs to one of the specified trust domains.
 //
 // Usage:
-//   r.Use(gin.RequireTrustDomain("prod.company.com", "staging.company.com"))
+//
+//	r.Use(gin.RequireTrustDomain("prod.company.com", "staging.company.com"))
 func RequireTrustDomain(allowedDomains ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		identity := IdentityFromGinContext(c)
@@ -299,4 +304,38 @@ func RequireTrustDomain(allowedDomains ...string) gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequirePolicy creates a Gin middleware that authorizes requests with an ephemos
+// authorization policy. The client identity, method and path are evaluated against
+// the policy rules, and denied requests are rejected with the name of the deciding rule.
+//
+// Usage:
+//
+//	policy, err := ephemos.NewPolicy(config)
+//	r.Use(gin.IdentityMiddleware(identityConfig))
+//	r.Use(gin.RequirePolicy(policy))
+func RequirePolicy(policy *ephemos.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := IdentityFromGinContext(c)
+		if identity == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		// Match the path the request is routed to, not its raw spelling
+		decision := policy.AuthorizeHTTP(identity.ID, c.Request.Method, path.Clean("/"+c.Request.URL.Path))
+		if !decision.Allowed {
+			slog.Warn("Policy access denied",
+				slog.String("spiffe_id", identity.ID),
+				slog.String("rule", decision.Rule),
+				slog.String("reason", decision.Reason))
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied", "rule": decision.Rule})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
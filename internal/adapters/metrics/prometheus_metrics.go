@@ -2,82 +2,241 @@
 package metrics
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
 	"github.com/sufield/ephemos/internal/core/services"
 )
 
-var (
-	// Certificate cache metrics
-	certCacheHitsCounter = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "ephemos_cert_cache_hits_total",
-		Help: "Total number of certificate cache hits",
-	})
-
-	certCacheMissesCounter = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "ephemos_cert_cache_misses_total",
-		Help: "Total number of certificate cache misses",
-	})
-
-	// Trust bundle cache metrics
-	bundleCacheHitsCounter = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "ephemos_bundle_cache_hits_total",
-		Help: "Total number of trust bundle cache hits",
-	})
-
-	bundleCacheMissesCounter = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "ephemos_bundle_cache_misses_total",
-		Help: "Total number of trust bundle cache misses",
-	})
-
-	// Certificate refresh metrics
-	certRefreshCounter = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "ephemos_cert_refresh_total",
-		Help: "Total number of certificate refreshes",
-	}, []string{"reason"}) // reason: expired, proactive, cache_miss
-
-	certRefreshDuration = promauto.NewHistogram(prometheus.HistogramOpts{
-		Name:    "ephemos_cert_refresh_duration_seconds",
-		Help:    "Duration of certificate refresh operations",
-		Buckets: prometheus.DefBuckets,
-	})
-
-	// Certificate expiry gauge
-	certExpiryTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "ephemos_cert_expiry_timestamp_seconds",
-		Help: "Unix timestamp when the cached certificate will expire",
-	}, []string{"service_name"})
-
-	// Certificate validation metrics
-	certValidationCounter = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "ephemos_cert_validation_total",
-		Help: "Total number of certificate validations",
-	}, []string{"result"}) // result: success, failure
-
-	// Retry metrics
-	providerRetryCounter = promauto.NewCounterVec(prometheus.CounterOpts{
-		Name: "ephemos_provider_retry_total",
-		Help: "Total number of provider retry attempts",
-	}, []string{"provider_type", "attempt"})
-)
+// Config configures a PrometheusMetrics.
+type Config struct {
+	// Registerer registers the collectors. Default: prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+	// ConstLabels are added to every metric, e.g. {"service": "payments"}, so that
+	// services embedded in one process can share a registry. Optional.
+	ConstLabels prometheus.Labels
+}
 
 // PrometheusMetrics implements services.MetricsReporter using Prometheus.
-type PrometheusMetrics struct{}
+// It is safe for concurrent use.
+type PrometheusMetrics struct {
+	// Certificate and trust bundle caches
+	certCacheHits     prometheus.Counter
+	certCacheMisses   prometheus.Counter
+	bundleCacheHits   prometheus.Counter
+	bundleCacheMisses prometheus.Counter
+
+	// Certificates
+	certRefresh         *prometheus.CounterVec
+	certRefreshDuration prometheus.Histogram
+	certExpiry          *prometheus.GaugeVec
+	certValidation      *prometheus.CounterVec
+	providerRetry       *prometheus.CounterVec
+
+	// Configuration and revocation list reloads
+	configReload      *prometheus.CounterVec
+	revokedPeers      *prometheus.CounterVec
+	revocationReload  *prometheus.CounterVec
+	revocationEntries prometheus.Gauge
+
+	// Audit trail
+	auditDropped prometheus.Counter
+
+	// mTLS
+	handshakeDuration *prometheus.HistogramVec
+	handshakeFailures *prometheus.CounterVec
+	activeConnections *prometheus.GaugeVec
+	peerSVIDLifetime  prometheus.Histogram
+	svidLifetime      *svidLifetimeCollector
+	rotations         *prometheus.CounterVec
+	authorizations    *prometheus.CounterVec
+}
 
-// NewPrometheusMetrics creates a new Prometheus metrics reporter.
-func NewPrometheusMetrics() services.MetricsReporter {
-	return &PrometheusMetrics{}
+var _ services.MetricsReporter = (*PrometheusMetrics)(nil)
+
+// lifetimeBuckets spans SVID lifetimes from a minute to a day.
+var lifetimeBuckets = []float64{60, 300, 600, 1800, 3600, 4 * 3600, 12 * 3600, 24 * 3600}
+
+// NewPrometheusMetrics creates a reporter and registers its collectors. Collectors
+// already registered with the same name and labels, e.g. by an earlier reporter on
+// the same registry, are shared rather than reported as an error. If a collector
+// cannot be registered, the error is returned along with a usable reporter whose
+// unregistered collectors record values that are not exported.
+func NewPrometheusMetrics(config Config) (*PrometheusMetrics, error) {
+	r := &registrar{registerer: config.Registerer}
+	if r.registerer == nil {
+		r.registerer = prometheus.DefaultRegisterer
+	}
+	labels := config.ConstLabels
+
+	m := &PrometheusMetrics{
+		certCacheHits: register(r, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "ephemos_cert_cache_hits_total",
+			Help:        "Total number of certificate cache hits",
+			ConstLabels: labels,
+		})),
+		certCacheMisses: register(r, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "ephemos_cert_cache_misses_total",
+			Help:        "Total number of certificate cache misses",
+			ConstLabels: labels,
+		})),
+		bundleCacheHits: register(r, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "ephemos_bundle_cache_hits_total",
+			Help:        "Total number of trust bundle cache hits",
+			ConstLabels: labels,
+		})),
+		bundleCacheMisses: register(r, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "ephemos_bundle_cache_misses_total",
+			Help:        "Total number of trust bundle cache misses",
+			ConstLabels: labels,
+		})),
+		certRefresh: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_cert_refresh_total",
+			Help:        "Total number of certificate refreshes",
+			ConstLabels: labels,
+		}, []string{"reason"})), // reason: expired, proactive, cache_miss
+		certRefreshDuration: register(r, prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "ephemos_cert_refresh_duration_seconds",
+			Help:        "Duration of certificate refresh operations",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: labels,
+		})),
+		certExpiry: register(r, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "ephemos_cert_expiry_timestamp_seconds",
+			Help:        "Unix timestamp when the cached certificate will expire",
+			ConstLabels: labels,
+		}, []string{"service_name"})),
+		certValidation: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_cert_validation_total",
+			Help:        "Total number of certificate validations",
+			ConstLabels: labels,
+		}, []string{"result"})), // result: success, failure
+		providerRetry: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_provider_retry_total",
+			Help:        "Total number of provider retry attempts",
+			ConstLabels: labels,
+		}, []string{"provider_type", "attempt"})),
+		configReload: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_config_reload_total",
+			Help:        "Total number of configuration reloads",
+			ConstLabels: labels,
+		}, []string{"result"})), // result: applied, unchanged, rejected
+		revokedPeers: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_revocation_rejections_total",
+			Help:        "Total number of peer certificates rejected by the revocation list",
+			ConstLabels: labels,
+		}, []string{"match"})), // match: spiffe_id, id_prefix, serial, spki_hash
+		revocationReload: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_revocation_reload_total",
+			Help:        "Total number of revocation list reloads",
+			ConstLabels: labels,
+		}, []string{"result"})), // result: applied, unchanged, rejected
+		revocationEntries: register(r, prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "ephemos_revocation_entries",
+			Help:        "Number of entries in the revocation list in effect",
+			ConstLabels: labels,
+		})),
+		auditDropped: register(r, prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "ephemos_audit_events_dropped_total",
+			Help:        "Total number of audit events dropped because the audit queue was full",
+			ConstLabels: labels,
+		})),
+		handshakeDuration: register(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "ephemos_tls_handshake_duration_seconds",
+			Help:        "Duration of mTLS handshakes",
+			Buckets:     prometheus.ExponentialBuckets(0.001, 2, 12), // 1ms to ~2s
+			ConstLabels: labels,
+		}, []string{"side", "result"})), // side: client, server; result: success, failure
+		handshakeFailures: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_tls_handshake_failures_total",
+			Help:        "Total number of failed mTLS handshakes",
+			ConstLabels: labels,
+		}, []string{"side", "reason"})), // reason: timeout, unknown_authority, expired_certificate, ...
+		activeConnections: register(r, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "ephemos_active_connections",
+			Help:        "Number of established mTLS connections",
+			ConstLabels: labels,
+		}, []string{"trust_domain"})), // trust domain of the peer
+		peerSVIDLifetime: register(r, prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "ephemos_peer_svid_remaining_lifetime_seconds",
+			Help:        "Remaining lifetime of the SVIDs presented by peers at handshake",
+			Buckets:     lifetimeBuckets,
+			ConstLabels: labels,
+		})),
+		svidLifetime: register(r, newSVIDLifetimeCollector(labels)),
+		rotations: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_svid_rotations_total",
+			Help:        "Total number of SVID rotations",
+			ConstLabels: labels,
+		}, []string{"outcome"})), // outcome: success, failure
+		authorizations: register(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ephemos_authz_decisions_total",
+			Help:        "Total number of authorization policy decisions",
+			ConstLabels: labels,
+		}, []string{"rule", "decision"})), // decision: allow, deny
+	}
+	if r.err != nil {
+		return m, fmt.Errorf("failed to register metrics: %w", r.err)
+	}
+	return m, nil
+}
+
+// defaultMetrics is the reporter registered with prometheus.DefaultRegisterer.
+var defaultMetrics = sync.OnceValue(func() *PrometheusMetrics {
+	m, err := NewPrometheusMetrics(Config{})
+	if err != nil {
+		slog.Warn("some ephemos metrics are not exported by the default Prometheus registry", "error", err)
+	}
+	return m
+})
+
+// Default returns the reporter registered with prometheus.DefaultRegisterer without
+// constant labels. Collectors already registered by another reporter are shared.
+// A collector that conflicts with a different one of the same name is left
+// unregistered and the conflict is logged, so Default never panics.
+func Default() *PrometheusMetrics {
+	return defaultMetrics()
+}
+
+// registrar registers collectors and keeps the first error.
+type registrar struct {
+	registerer prometheus.Registerer
+	err        error
+}
+
+// register registers collector, returning the collector already registered in its
+// place, if any.
+func register[C prometheus.Collector](r *registrar, collector C) C {
+	err := r.registerer.Register(collector)
+	if err == nil {
+		return collector
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	if r.err == nil {
+		r.err = err
+	}
+	return collector
 }
 
 // RecordCacheHit records a cache hit.
 func (m *PrometheusMetrics) RecordCacheHit(cacheType string) {
 	switch cacheType {
 	case "certificate":
-		certCacheHitsCounter.Inc()
+		m.certCacheHits.Inc()
 	case "bundle":
-		bundleCacheHitsCounter.Inc()
+		m.bundleCacheHits.Inc()
 	}
 }
 
@@ -85,21 +244,21 @@ func (m *PrometheusMetrics) RecordCacheHit(cacheType string) {
 func (m *PrometheusMetrics) RecordCacheMiss(cacheType string) {
 	switch cacheType {
 	case "certificate":
-		certCacheMissesCounter.Inc()
+		m.certCacheMisses.Inc()
 	case "bundle":
-		bundleCacheMissesCounter.Inc()
+		m.bundleCacheMisses.Inc()
 	}
 }
 
 // RecordRefresh records a certificate refresh.
 func (m *PrometheusMetrics) RecordRefresh(reason string, duration float64) {
-	certRefreshCounter.WithLabelValues(reason).Inc()
-	certRefreshDuration.Observe(duration)
+	m.certRefresh.WithLabelValues(reason).Inc()
+	m.certRefreshDuration.Observe(duration)
 }
 
 // UpdateCertExpiry updates the certificate expiry timestamp.
 func (m *PrometheusMetrics) UpdateCertExpiry(serviceName string, expiryTime float64) {
-	certExpiryTimestamp.WithLabelValues(serviceName).Set(expiryTime)
+	m.certExpiry.WithLabelValues(serviceName).Set(expiryTime)
 }
 
 // RecordValidation records a certificate validation result.
@@ -108,10 +267,117 @@ func (m *PrometheusMetrics) RecordValidation(success bool) {
 	if success {
 		result = "success"
 	}
-	certValidationCounter.WithLabelValues(result).Inc()
+	m.certValidation.WithLabelValues(result).Inc()
 }
 
 // RecordRetry records a provider retry attempt.
 func (m *PrometheusMetrics) RecordRetry(providerType string, attempt int) {
-	providerRetryCounter.WithLabelValues(providerType, strconv.Itoa(attempt)).Inc()
+	m.providerRetry.WithLabelValues(providerType, strconv.Itoa(attempt)).Inc()
+}
+
+// RecordConfigReload records the outcome of a configuration reload.
+func (m *PrometheusMetrics) RecordConfigReload(result string) {
+	m.configReload.WithLabelValues(result).Inc()
+}
+
+// RecordRevokedPeer records a peer rejected by the revocation list.
+func (m *PrometheusMetrics) RecordRevokedPeer(match string) {
+	m.revokedPeers.WithLabelValues(match).Inc()
+}
+
+// RecordRevocationReload records the outcome of a revocation list reload and the
+// number of entries in effect.
+func (m *PrometheusMetrics) RecordRevocationReload(result string, entries int) {
+	m.revocationReload.WithLabelValues(result).Inc()
+	m.revocationEntries.Set(float64(entries))
+}
+
+// RecordAuditEventDropped counts an audit event dropped because the audit queue
+// was full.
+func (m *PrometheusMetrics) RecordAuditEventDropped() {
+	m.auditDropped.Inc()
+}
+
+// RecordHandshake records the duration of a handshake and, for failed handshakes,
+// the failure reason.
+func (m *PrometheusMetrics) RecordHandshake(side, reason string, duration time.Duration) {
+	result := "success"
+	if reason != "" {
+		result = "failure"
+		m.handshakeFailures.WithLabelValues(side, reason).Inc()
+	}
+	m.handshakeDuration.WithLabelValues(side, result).Observe(duration.Seconds())
+}
+
+// RecordConnectionOpened counts an established connection as active.
+func (m *PrometheusMetrics) RecordConnectionOpened(trustDomain string) {
+	m.activeConnections.WithLabelValues(trustDomain).Inc()
+}
+
+// RecordConnectionClosed counts a closed connection as no longer active.
+func (m *PrometheusMetrics) RecordConnectionClosed(trustDomain string) {
+	m.activeConnections.WithLabelValues(trustDomain).Dec()
+}
+
+// RecordPeerSVIDLifetime records the remaining lifetime of a peer SVID.
+func (m *PrometheusMetrics) RecordPeerSVIDLifetime(remaining time.Duration) {
+	m.peerSVIDLifetime.Observe(remaining.Seconds())
+}
+
+// RecordSVIDExpiry records when the local SVID expires. Its remaining lifetime is
+// computed when the metrics are scraped.
+func (m *PrometheusMetrics) RecordSVIDExpiry(id spiffeid.ID, expiry time.Time) {
+	m.svidLifetime.set(id.String(), expiry)
+}
+
+// RecordRotation counts an SVID rotation.
+func (m *PrometheusMetrics) RecordRotation(outcome string) {
+	m.rotations.WithLabelValues(outcome).Inc()
+}
+
+// RecordAuthorization counts a policy decision.
+func (m *PrometheusMetrics) RecordAuthorization(rule string, allowed bool) {
+	decision := "deny"
+	if allowed {
+		decision = "allow"
+	}
+	m.authorizations.WithLabelValues(rule, decision).Inc()
+}
+
+// svidLifetimeCollector reports the remaining lifetime of local SVIDs, computed
+// from their expiry at scrape time so that it keeps falling between rotations.
+type svidLifetimeCollector struct {
+	desc *prometheus.Desc
+
+	mu       sync.Mutex
+	expiries map[string]time.Time // by SPIFFE ID
+}
+
+func newSVIDLifetimeCollector(labels prometheus.Labels) *svidLifetimeCollector {
+	return &svidLifetimeCollector{
+		desc: prometheus.NewDesc("ephemos_svid_remaining_lifetime_seconds",
+			"Remaining lifetime of the local SVID; negative once it has expired",
+			[]string{"spiffe_id"}, labels),
+		expiries: make(map[string]time.Time),
+	}
+}
+
+func (c *svidLifetimeCollector) set(id string, expiry time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expiries[id] = expiry
+}
+
+// Describe implements prometheus.Collector.
+func (c *svidLifetimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *svidLifetimeCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, expiry := range c.expiries {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, time.Until(expiry).Seconds(), id)
+	}
 }
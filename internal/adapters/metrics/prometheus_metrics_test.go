@@ -0,0 +1,186 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/metrics"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// gather returns the metrics of a family by name.
+func gather(t *testing.T, registry *prometheus.Registry, name string) []*dto.Metric {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()
+		}
+	}
+	return nil
+}
+
+// find returns the metric with the given label values.
+func find(metrics []*dto.Metric, labels map[string]string) *dto.Metric {
+	for _, metric := range metrics {
+		matched := 0
+		for _, pair := range metric.GetLabel() {
+			if value, ok := labels[pair.GetName()]; ok && value == pair.GetValue() {
+				matched++
+			}
+		}
+		if matched == len(labels) {
+			return metric
+		}
+	}
+	return nil
+}
+
+func TestPrometheusMetrics_MTLS(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.NewPrometheusMetrics(metrics.Config{Registerer: registry})
+	require.NoError(t, err)
+
+	m.RecordHandshake(ports.HandshakeSideServer, "", 5*time.Millisecond)
+	m.RecordHandshake(ports.HandshakeSideServer, ports.HandshakeFailureUnknownAuthority, time.Millisecond)
+	m.RecordHandshake(ports.HandshakeSideClient, ports.HandshakeFailureTimeout, time.Second)
+
+	failures := gather(t, registry, "ephemos_tls_handshake_failures_total")
+	assert.Len(t, failures, 2)
+	failure := find(failures, map[string]string{"side": "server", "reason": "unknown_authority"})
+	require.NotNil(t, failure)
+	assert.Equal(t, 1.0, failure.GetCounter().GetValue())
+
+	durations := gather(t, registry, "ephemos_tls_handshake_duration_seconds")
+	success := find(durations, map[string]string{"side": "server", "result": "success"})
+	require.NotNil(t, success)
+	assert.Equal(t, uint64(1), success.GetHistogram().GetSampleCount())
+
+	m.RecordConnectionOpened("example.org")
+	m.RecordConnectionOpened("example.org")
+	m.RecordConnectionOpened("partner.org")
+	m.RecordConnectionClosed("example.org")
+	active := gather(t, registry, "ephemos_active_connections")
+	assert.Equal(t, 1.0, find(active, map[string]string{"trust_domain": "example.org"}).GetGauge().GetValue())
+	assert.Equal(t, 1.0, find(active, map[string]string{"trust_domain": "partner.org"}).GetGauge().GetValue())
+
+	m.RecordRotation(ports.RotationOutcomeSuccess)
+	m.RecordRotation(ports.RotationOutcomeFailure)
+	m.RecordRotation(ports.RotationOutcomeSuccess)
+	rotations := gather(t, registry, "ephemos_svid_rotations_total")
+	assert.Equal(t, 2.0, find(rotations, map[string]string{"outcome": "success"}).GetCounter().GetValue())
+
+	m.RecordAuthorization("admin-only", false)
+	m.RecordAuthorization("default", true)
+	decisions := gather(t, registry, "ephemos_authz_decisions_total")
+	assert.Equal(t, 1.0, find(decisions, map[string]string{"rule": "admin-only", "decision": "deny"}).GetCounter().GetValue())
+	assert.Equal(t, 1.0, find(decisions, map[string]string{"rule": "default", "decision": "allow"}).GetCounter().GetValue())
+
+	m.RecordPeerSVIDLifetime(30 * time.Minute)
+	peers := gather(t, registry, "ephemos_peer_svid_remaining_lifetime_seconds")
+	require.Len(t, peers, 1)
+	assert.Equal(t, 1800.0, peers[0].GetHistogram().GetSampleSum())
+}
+
+func TestPrometheusMetrics_SVIDLifetimeFallsUntilRotation(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m, err := metrics.NewPrometheusMetrics(metrics.Config{Registerer: registry})
+	require.NoError(t, err)
+
+	id := spiffeid.RequireFromString("spiffe://example.org/api")
+	m.RecordSVIDExpiry(id, time.Now().Add(time.Hour))
+
+	lifetime := func() float64 {
+		values := gather(t, registry, "ephemos_svid_remaining_lifetime_seconds")
+		require.Len(t, values, 1)
+		return values[0].GetGauge().GetValue()
+	}
+	first := lifetime()
+	assert.InDelta(t, 3600, first, 5)
+	time.Sleep(20 * time.Millisecond)
+	assert.Less(t, lifetime(), first)
+
+	m.RecordSVIDExpiry(id, time.Now().Add(-time.Minute))
+	assert.InDelta(t, -60, lifetime(), 5, "an expired SVID has a negative lifetime")
+}
+
+func TestPrometheusMetrics_ConstLabelsShareRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	payments, err := metrics.NewPrometheusMetrics(metrics.Config{
+		Registerer:  registry,
+		ConstLabels: prometheus.Labels{"service": "payments"},
+	})
+	require.NoError(t, err)
+	billing, err := metrics.NewPrometheusMetrics(metrics.Config{
+		Registerer:  registry,
+		ConstLabels: prometheus.Labels{"service": "billing"},
+	})
+	require.NoError(t, err)
+
+	payments.RecordRotation(ports.RotationOutcomeSuccess)
+	billing.RecordRotation(ports.RotationOutcomeFailure)
+
+	rotations := gather(t, registry, "ephemos_svid_rotations_total")
+	assert.Len(t, rotations, 2)
+	assert.NotNil(t, find(rotations, map[string]string{"service": "payments", "outcome": "success"}))
+	assert.NotNil(t, find(rotations, map[string]string{"service": "billing", "outcome": "failure"}))
+}
+
+func TestPrometheusMetrics_RegisteringTwiceSharesCollectors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first, err := metrics.NewPrometheusMetrics(metrics.Config{Registerer: registry})
+	require.NoError(t, err)
+	second, err := metrics.NewPrometheusMetrics(metrics.Config{Registerer: registry})
+	require.NoError(t, err, "registering the same collectors again must not fail")
+
+	first.RecordRevokedPeer("serial")
+	second.RecordRevokedPeer("serial")
+	rejections := gather(t, registry, "ephemos_revocation_rejections_total")
+	require.Len(t, rejections, 1)
+	assert.Equal(t, 2.0, rejections[0].GetCounter().GetValue())
+}
+
+func TestPrometheusMetrics_ConflictingRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ephemos_svid_rotations_total",
+		Help: "Something else",
+	}))
+
+	m, err := metrics.NewPrometheusMetrics(metrics.Config{Registerer: registry})
+	assert.Error(t, err)
+	require.NotNil(t, m, "no reporter returned with the error")
+	assert.NotPanics(t, func() { m.RecordRotation("success") })
+}
+
+func TestDefault_ConflictingRegistration(t *testing.T) {
+	conflicting := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ephemos_authz_decisions_total",
+		Help: "Something else",
+	})
+	prometheus.MustRegister(conflicting)
+	t.Cleanup(func() { prometheus.Unregister(conflicting) })
+
+	var first, second *metrics.PrometheusMetrics
+	require.NotPanics(t, func() { first = metrics.Default() })
+	require.NotPanics(t, func() { second = metrics.Default() })
+	assert.Same(t, first, second)
+
+	// The conflicting collector still records, and the others are exported
+	first.RecordAuthorization("rule", true)
+	first.RecordRevokedPeer("serial")
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	assert.Contains(t, names, "ephemos_revocation_rejections_total")
+}
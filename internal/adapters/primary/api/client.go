@@ -7,6 +7,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
@@ -32,8 +33,14 @@ type clientOpts struct {
 	transportProvider ports.TransportProvider
 	authorizer        tlsconfig.Authorizer
 	trustDomain       spiffeid.TrustDomain
+	tlsDialer         TLSDialerFactory
 }
 
+// TLSDialerFactory creates the function HTTP clients dial TLS connections with. The
+// dialer reports every connection to tracker, so that mTLS enforcement can close
+// it; localChain returns the client's current SVID chain.
+type TLSDialerFactory func(config *tls.Config, tracker ports.ConnectionTrackerPort, localChain func() []*x509.Certificate) func(ctx context.Context, network, addr string) (net.Conn, error)
+
 // WithTransportProvider sets the transport provider for the client.
 func WithTransportProvider(p ports.TransportProvider) ClientOption {
 	return func(o *clientOpts) { o.transportProvider = p }
@@ -49,12 +56,21 @@ func WithTrustDomain(td spiffeid.TrustDomain) ClientOption {
 	return func(o *clientOpts) { o.trustDomain = td }
 }
 
+// WithTLSDialer sets how HTTP clients dial, so that their connections are tracked.
+// Without it, HTTP connections are not subject to mTLS enforcement.
+func WithTLSDialer(f TLSDialerFactory) ClientOption {
+	return func(o *clientOpts) { o.tlsDialer = f }
+}
+
 // Client provides a high-level API for connecting to SPIFFE-secured services.
 type Client struct {
 	identityService *services.IdentityService
 	domainClient    ports.ClientPort
 	trustDomain     spiffeid.TrustDomain
 	authorizer      tlsconfig.Authorizer
+	tlsSettings     *ports.TLSConfig
+	tlsDialer       TLSDialerFactory
+	stopEnforcement context.CancelFunc
 	mu              sync.Mutex
 }
 
@@ -74,7 +90,12 @@ func NewClient(identityProvider ports.IdentityProvider, cfg *ports.Configuration
 	}
 
 	// Use provided authorizer and trust domain from options
-	return IdentityClient(identityProvider, o.transportProvider, cfg, o.authorizer, o.trustDomain)
+	client, err := IdentityClient(identityProvider, o.transportProvider, cfg, o.authorizer, o.trustDomain)
+	if err != nil {
+		return nil, err
+	}
+	client.tlsDialer = o.tlsDialer
+	return client, nil
 }
 
 // IdentityClient creates a new identity client with injected dependencies.
@@ -125,9 +146,27 @@ func IdentityClient(
 		identityService: identityService,
 		trustDomain:     trustDomain,
 		authorizer:      authorizer,
+		tlsSettings:     cfg.EffectiveTLS(),
 	}, nil
 }
 
+// SetMetrics replaces the no-op metrics reporter of the identity service. Call it
+// before Connect.
+func (c *Client) SetMetrics(metrics services.MetricsReporter) {
+	c.identityService.SetMetrics(metrics)
+}
+
+// SetRevocationList rejects revoked peers in the HTTP clients of connections made
+// afterwards and, with enforceExisting, closes established connections to revoked
+// peers. gRPC handshakes are checked by the transport provider. Call it before Connect.
+func (c *Client) SetRevocationList(list ports.RevocationListPort, enforceExisting bool) {
+	c.mu.Lock()
+	c.tlsSettings = c.tlsSettings.WithRevocation(list)
+	c.mu.Unlock()
+
+	c.identityService.SetRevocationList(list, enforceExisting)
+}
+
 // Connect establishes a secure connection to a remote service using SPIFFE identities.
 func (c *Client) Connect(ctx context.Context, serviceNameStr, addressStr string) (*ClientConnection, error) {
 	// Input validation
@@ -139,13 +178,26 @@ func (c *Client) Connect(ctx context.Context, serviceNameStr, addressStr string)
 		}
 	}
 
-	// Use ServiceName value object for validation
-	serviceName, err := domain.NewServiceName(serviceNameStr)
-	if err != nil {
-		return nil, &errors.ValidationError{
-			Field:   "serviceName",
-			Value:   serviceNameStr,
-			Message: fmt.Sprintf("invalid service name: %v", err),
+	// A SPIFFE ID names the exact server to expect; anything else must be a valid
+	// service name, whose server is only checked for trust domain membership
+	var serviceName domain.ServiceName
+	if strings.HasPrefix(serviceNameStr, "spiffe://") {
+		if _, err := spiffeid.FromString(serviceNameStr); err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "serviceName",
+				Value:   serviceNameStr,
+				Message: fmt.Sprintf("invalid server SPIFFE ID: %v", err),
+			}
+		}
+		serviceName = domain.NewServiceNameUnsafe(serviceNameStr)
+	} else {
+		var err error
+		if serviceName, err = domain.NewServiceName(serviceNameStr); err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "serviceName",
+				Value:   serviceNameStr,
+				Message: fmt.Sprintf("invalid service name: %v", err),
+			}
 		}
 	}
 
@@ -169,6 +221,16 @@ func (c *Client) Connect(ctx context.Context, serviceNameStr, addressStr string)
 		}
 		c.domainClient = client
 	}
+	if c.stopEnforcement == nil {
+		// Connections outlive ctx, so enforcement runs until the client is closed
+		enforceCtx, cancel := context.WithCancel(context.Background())
+		if err := c.identityService.StartMTLSEnforcement(enforceCtx); err != nil {
+			cancel()
+			c.mu.Unlock()
+			return nil, fmt.Errorf("failed to start mTLS enforcement: %w", err)
+		}
+		c.stopEnforcement = cancel
+	}
 	c.mu.Unlock()
 
 	domainConn, err := c.domainClient.Connect(serviceName.Value(), address.Value())
@@ -198,6 +260,9 @@ func (c *Client) Connect(ctx context.Context, serviceNameStr, addressStr string)
 		identityService: c.identityService,
 		authorizer:      authorizer,
 		trustDomain:     c.trustDomain,
+		tlsSettings:     c.tlsSettings,
+		tlsDialer:       c.tlsDialer,
+		tracker:         c.identityService.ConnectionTracker(),
 	}, nil
 }
 
@@ -206,6 +271,11 @@ func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.stopEnforcement != nil {
+		c.stopEnforcement()
+		c.stopEnforcement = nil
+	}
+
 	if c.domainClient != nil {
 		if err := c.domainClient.Close(); err != nil {
 			return fmt.Errorf("failed to close domain client: %w", err)
@@ -229,6 +299,9 @@ type ClientConnection struct {
 	identityService CertificateProvider
 	authorizer      tlsconfig.Authorizer
 	trustDomain     spiffeid.TrustDomain
+	tlsSettings     *ports.TLSConfig
+	tlsDialer       TLSDialerFactory
+	tracker         ports.ConnectionTrackerPort
 
 	tlsOnce sync.Once
 	tlsCfg  *tls.Config
@@ -271,6 +344,9 @@ func (c *ClientConnection) HTTPClient() (*http.Client, error) {
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 	}
+	if c.tlsDialer != nil && c.tracker != nil {
+		tr.DialTLSContext = c.tlsDialer(tlsCfg, c.tracker, c.localChain)
+	}
 	return &http.Client{
 		Transport: tr,
 		Timeout:   30 * time.Second,
@@ -283,6 +359,16 @@ func (c *ClientConnection) HTTPClient() (*http.Client, error) {
 	}, nil
 }
 
+// localChain returns the client's current certificate chain, or nil if it cannot
+// be fetched.
+func (c *ClientConnection) localChain() []*x509.Certificate {
+	cert, err := c.identityService.GetCertificate()
+	if err != nil || cert.Cert == nil {
+		return nil
+	}
+	return append([]*x509.Certificate{cert.Cert}, cert.Chain...)
+}
+
 // buildAuthorizer creates a deterministic, config-driven authorizer.
 // If caller passes a full SPIFFE ID, enforce exact match.
 // Otherwise, require membership in the configured trust domain.
@@ -319,8 +405,12 @@ func (c *ClientConnection) extractTLSConfig(trustDomain spiffeid.TrustDomain) (*
 		// This provides the standard SPIFFE peer verification with proper authorizers
 		tlsConfig := tlsconfig.MTLSClientConfig(svidSource, bundleSource, c.authorizer)
 
-		// Ensure TLS 1.3 minimum version (go-spiffe uses 1.2 by default)
-		tlsConfig.MinVersion = tls.VersionTLS13
+		// Apply the configured TLS settings; the default is a TLS 1.3 minimum
+		// (go-spiffe uses 1.2 by default)
+		if err := c.tlsSettings.Apply(tlsConfig); err != nil {
+			c.tlsErr = fmt.Errorf("invalid TLS settings: %w", err)
+			return
+		}
 
 		c.tlsCfg = tlsConfig
 	})
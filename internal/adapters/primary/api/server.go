@@ -21,6 +21,7 @@ type Server struct {
 	configProvider  ports.ConfigurationProvider
 	serviceName     string
 	domainServer    ports.ServerPort
+	logger          *slog.Logger
 	mu              sync.Mutex
 }
 
@@ -82,6 +83,7 @@ func WorkloadServer(
 		identityService: identityService,
 		configProvider:  configProvider,
 		serviceName:     cfg.Service.Name.Value(),
+		logger:          slog.Default(),
 	}, nil
 }
 
@@ -116,7 +118,7 @@ func (s *Server) RegisterService(ctx context.Context, serviceRegistrar ServiceRe
 		return fmt.Errorf("failed to register service: %w", err)
 	}
 
-	slog.Info("Service registered successfully", "service", s.serviceName)
+	s.logger.Info("Service registered successfully", "service", s.serviceName)
 	return nil
 }
 
@@ -137,7 +139,14 @@ func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
 	}
 	s.mu.Unlock()
 
-	slog.Info("Server ready", "service", s.serviceName, "address", listener.Addr().String())
+	// Close connections that stop satisfying the mTLS invariants while serving
+	enforceCtx, stopEnforcement := context.WithCancel(ctx)
+	defer stopEnforcement()
+	if err := s.identityService.StartMTLSEnforcement(enforceCtx); err != nil {
+		return fmt.Errorf("failed to start mTLS enforcement: %w", err)
+	}
+
+	s.logger.Info("Server ready", "service", s.serviceName, "address", listener.Addr().String())
 
 	errCh := make(chan error, 1)
 
@@ -154,6 +163,43 @@ func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
 	}
 }
 
+// SetAuditRecorder sets the recorder of connections closed for failing the mTLS
+// invariants. May be nil.
+func (s *Server) SetAuditRecorder(audit ports.AuditRecorderPort) {
+	s.identityService.SetAuditRecorder(audit)
+}
+
+// SetMetrics replaces the no-op metrics reporter of the identity service. Call it
+// before Serve.
+func (s *Server) SetMetrics(metrics services.MetricsReporter) {
+	s.identityService.SetMetrics(metrics)
+}
+
+// SetLogger replaces the default logger of the server and its identity service.
+// Call it before Serve.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		return
+	}
+	s.mu.Lock()
+	s.logger = logger
+	s.mu.Unlock()
+	s.identityService.SetLogger(logger)
+}
+
+// SetRevocationList closes established connections to revoked peers when
+// enforceExisting is set; new handshakes are checked by the transport provider.
+// Call it before Serve.
+func (s *Server) SetRevocationList(list ports.RevocationListPort, enforceExisting bool) {
+	s.identityService.SetRevocationList(list, enforceExisting)
+}
+
+// SetCacheConfig applies new certificate and trust bundle cache settings to the
+// identity service, e.g. after the cache section changed at runtime.
+func (s *Server) SetCacheConfig(cache *ports.CacheConfig) {
+	s.identityService.SetCacheConfig(cache)
+}
+
 // Close gracefully shuts down the identity server.
 func (s *Server) Close() error {
 	s.mu.Lock()
@@ -163,7 +209,7 @@ func (s *Server) Close() error {
 			return fmt.Errorf("failed to stop server: %w", err)
 		}
 		s.domainServer = nil
-		slog.Info("Server stopped gracefully", "service", s.serviceName)
+		s.logger.Info("Server stopped gracefully", "service", s.serviceName)
 	}
 	return nil
 }
@@ -174,7 +220,7 @@ func (s *Server) initializeServer(_ context.Context) error {
 		return fmt.Errorf("failed to create server identity: %w", err)
 	}
 	s.domainServer = server
-	slog.Info("Server identity created", "service", s.serviceName)
+	s.logger.Info("Server identity created", "service", s.serviceName)
 	return nil
 }
 
@@ -209,3 +255,8 @@ func (a *networkListenerAdapter) Addr() string {
 func (a *networkListenerAdapter) Close() error {
 	return a.listener.Close()
 }
+
+// NetListener returns the adapted listener, which the gRPC transport serves on.
+func (a *networkListenerAdapter) NetListener() net.Listener {
+	return a.listener
+}
@@ -0,0 +1,336 @@
+// Package bundleendpoint serves the local trust bundle as a SPIFFE bundle endpoint,
+// so partner trust domains can federate with this one.
+package bundleendpoint
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// DefaultRefreshHint is the spiffe_refresh_hint advertised when none is configured.
+const DefaultRefreshHint = 5 * time.Minute
+
+// Config configures a bundle endpoint server.
+type Config struct {
+	// TrustDomain is the trust domain whose bundle is served. Required.
+	TrustDomain spiffeid.TrustDomain
+	// Bundles provides the current bundle and its updates. Required.
+	Bundles ports.BundleProviderPort
+	// JWTBundles provides the JWT authorities served next to the X.509 authorities.
+	// Optional: when nil, only X.509 authorities are served. JWT authorities are
+	// reloaded on every X.509 bundle update and once per refresh hint.
+	JWTBundles ports.JWTBundleProviderPort
+	// RefreshHint tells federated peers how often to poll. Default: 5 minutes.
+	RefreshHint time.Duration
+
+	// Profile selects how Serve authenticates the endpoint. Default: https_spiffe.
+	Profile ports.BundleEndpointProfile
+	// SVIDSource provides the endpoint certificate for the https_spiffe profile.
+	SVIDSource x509svid.Source
+	// WebTLSConfig provides the Web PKI server certificate for the https_web profile.
+	WebTLSConfig *tls.Config
+	// TLS overrides the minimum version, cipher suites and curves of either profile.
+	// Optional: when nil, the profile's own defaults are kept so that federated
+	// peers limited to TLS 1.2 can still fetch the bundle.
+	TLS *ports.TLSConfig
+
+	Logger *slog.Logger
+}
+
+// Server publishes the local trust bundle in SPIFFE bundle (JWKS) format.
+// It implements http.Handler, so it can be mounted on an existing mux, or it can
+// serve standalone on its own listener with Serve.
+type Server struct {
+	config  Config
+	handler http.Handler
+	logger  *slog.Logger
+
+	mu       sync.RWMutex
+	bundle   *spiffebundle.Bundle
+	sequence uint64
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// New creates a bundle endpoint server. No bundle is served until Start succeeds.
+func New(config Config) (*Server, error) {
+	if config.TrustDomain.IsZero() {
+		return nil, fmt.Errorf("trust domain is required")
+	}
+	if config.Bundles == nil {
+		return nil, fmt.Errorf("bundle provider is required")
+	}
+	if config.RefreshHint <= 0 {
+		config.RefreshHint = DefaultRefreshHint
+	}
+	if config.Profile == "" {
+		config.Profile = ports.BundleEndpointProfileHTTPSSPIFFE
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Server{config: config, logger: logger}
+	handler, err := federation.NewHandler(config.TrustDomain, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle handler: %w", err)
+	}
+	s.handler = handler
+	return s, nil
+}
+
+// Start loads the current bundle and follows trust bundle changes until Close is called.
+// The context bounds only the initial load.
+func (s *Server) Start(ctx context.Context) error {
+	bundle, err := s.config.Bundles.GetTrustBundleForDomain(ctx, s.config.TrustDomain)
+	if err != nil {
+		return fmt.Errorf("failed to load trust bundle for %s: %w", s.config.TrustDomain, err)
+	}
+	jwtBundle, err := s.jwtBundle(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("bundle endpoint already started")
+	}
+	watchCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	s.update(bundle.X509Authorities(), jwtBundle)
+
+	updates, err := s.config.Bundles.WatchTrustBundleChanges(watchCtx)
+	if err != nil {
+		// Keep serving the loaded X.509 authorities; they just will not follow rotations
+		s.logger.Warn("trust bundle watch unavailable, serving static bundle", "error", err)
+		updates = nil
+	}
+
+	go s.watch(watchCtx, updates)
+	return nil
+}
+
+// watch applies bundle updates until the context is cancelled, and reloads the
+// JWT authorities with every update and once per refresh hint.
+func (s *Server) watch(ctx context.Context, updates <-chan *x509bundle.Bundle) {
+	defer close(s.done)
+
+	var reload <-chan time.Time
+	if s.config.JWTBundles != nil {
+		ticker := time.NewTicker(s.config.RefreshHint)
+		defer ticker.Stop()
+		reload = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case bundle, ok := <-updates:
+			if !ok {
+				updates = nil
+				continue
+			}
+			if bundle != nil && bundle.TrustDomain() == s.config.TrustDomain {
+				s.refresh(ctx, bundle.X509Authorities())
+			}
+		case <-reload:
+			s.refresh(ctx, s.x509Authorities())
+		}
+	}
+}
+
+// refresh publishes the X.509 authorities with freshly loaded JWT authorities.
+// The last JWT authorities are kept if they cannot be loaded.
+func (s *Server) refresh(ctx context.Context, x509Authorities []*x509.Certificate) {
+	jwtBundle, err := s.jwtBundle(ctx)
+	if err != nil {
+		s.logger.Warn("keeping previous JWT authorities", "error", err)
+		jwtBundle = s.jwtAuthorities()
+	}
+	s.update(x509Authorities, jwtBundle)
+}
+
+// jwtBundle loads the JWT authorities, if a JWT bundle provider is configured.
+func (s *Server) jwtBundle(ctx context.Context) (*jwtbundle.Bundle, error) {
+	if s.config.JWTBundles == nil {
+		return nil, nil
+	}
+	bundle, err := s.config.JWTBundles.GetJWTBundleForTrustDomain(ctx, s.config.TrustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT bundle for %s: %w", s.config.TrustDomain, err)
+	}
+	return bundle, nil
+}
+
+// x509Authorities returns the X.509 authorities being served.
+func (s *Server) x509Authorities() []*x509.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.bundle == nil {
+		return nil
+	}
+	return s.bundle.X509Authorities()
+}
+
+// jwtAuthorities returns the JWT authorities being served.
+func (s *Server) jwtAuthorities() *jwtbundle.Bundle {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.bundle == nil {
+		return nil
+	}
+	return jwtbundle.FromJWTAuthorities(s.config.TrustDomain, s.bundle.JWTAuthorities())
+}
+
+// update publishes a new bundle. The sequence number only advances when the
+// authorities change, so peers can tell real rotations from SVID renewals.
+func (s *Server) update(x509Authorities []*x509.Certificate, jwtBundle *jwtbundle.Bundle) {
+	next := spiffebundle.FromX509Authorities(s.config.TrustDomain, x509Authorities)
+	if jwtBundle != nil {
+		next.SetJWTAuthorities(jwtBundle.JWTAuthorities())
+	}
+	next.SetRefreshHint(s.config.RefreshHint)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bundle != nil {
+		next.SetSequenceNumber(s.sequence)
+		if s.bundle.Equal(next) {
+			return
+		}
+	}
+
+	s.sequence = nextSequence(s.sequence, time.Now())
+	next.SetSequenceNumber(s.sequence)
+	s.bundle = next
+
+	s.logger.Info("bundle endpoint updated",
+		"trust_domain", s.config.TrustDomain.String(),
+		"sequence", s.sequence,
+		"ca_count", len(x509Authorities),
+		"jwt_authority_count", len(next.JWTAuthorities()))
+}
+
+// nextSequence returns a sequence number above previous. It is derived from the
+// current time so that it keeps increasing when the endpoint restarts.
+func nextSequence(previous uint64, now time.Time) uint64 {
+	sequence := uint64(0)
+	if unix := now.Unix(); unix > 0 {
+		sequence = uint64(unix)
+	}
+	if sequence <= previous {
+		sequence = previous + 1
+	}
+	return sequence
+}
+
+// GetBundleForTrustDomain implements spiffebundle.Source.
+func (s *Server) GetBundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*spiffebundle.Bundle, error) {
+	if trustDomain != s.config.TrustDomain {
+		return nil, fmt.Errorf("no bundle for trust domain %s", trustDomain)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.bundle == nil {
+		return nil, fmt.Errorf("bundle for %s is not loaded", trustDomain)
+	}
+	return s.bundle.Clone(), nil
+}
+
+// ServeHTTP serves the current bundle.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// TLSConfig returns the server TLS configuration for the configured profile.
+func (s *Server) TLSConfig() (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	switch s.config.Profile {
+	case ports.BundleEndpointProfileHTTPSSPIFFE:
+		if s.config.SVIDSource == nil {
+			return nil, fmt.Errorf("https_spiffe profile requires an SVID source")
+		}
+		// Clients authenticate the endpoint by SPIFFE ID; they present no certificate
+		tlsConfig = tlsconfig.TLSServerConfig(s.config.SVIDSource)
+	case ports.BundleEndpointProfileHTTPSWeb:
+		if s.config.WebTLSConfig == nil {
+			return nil, fmt.Errorf("https_web profile requires a TLS configuration")
+		}
+		tlsConfig = s.config.WebTLSConfig.Clone()
+	default:
+		return nil, fmt.Errorf("unsupported bundle endpoint profile %q", s.config.Profile)
+	}
+
+	if s.config.TLS != nil {
+		if err := s.config.TLS.Apply(tlsConfig); err != nil {
+			return nil, fmt.Errorf("invalid TLS settings: %w", err)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// Serve serves the bundle endpoint on the listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	tlsConfig, err := s.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Handler:           s,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = httpServer.Close()
+	})
+	defer stop()
+
+	err = httpServer.ServeTLS(listener, "", "")
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops following bundle updates. It is safe to call Close multiple times.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package bundleendpoint_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/primary/bundleendpoint"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+var td = spiffeid.RequireTrustDomainFromString("example.org")
+
+// fakeBundles is a BundleProviderPort whose updates are pushed by the test.
+type fakeBundles struct {
+	current *x509bundle.Bundle
+	updates chan *x509bundle.Bundle
+}
+
+func newFakeBundles(authorities ...*x509.Certificate) *fakeBundles {
+	return &fakeBundles{
+		current: x509bundle.FromX509Authorities(td, authorities),
+		updates: make(chan *x509bundle.Bundle, 1),
+	}
+}
+
+func (f *fakeBundles) GetTrustBundle(context.Context) (*x509bundle.Bundle, error) {
+	return f.current, nil
+}
+
+func (f *fakeBundles) GetTrustBundleForDomain(context.Context, spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return f.current, nil
+}
+
+func (f *fakeBundles) RefreshTrustBundle(context.Context) error { return nil }
+
+func (f *fakeBundles) WatchTrustBundleChanges(context.Context) (<-chan *x509bundle.Bundle, error) {
+	return f.updates, nil
+}
+
+func (f *fakeBundles) ValidateCertificateAgainstBundle(context.Context, *domain.Certificate) error {
+	return nil
+}
+
+func (f *fakeBundles) Close() error { return nil }
+
+// newCA returns a self-signed CA certificate and its key.
+func newCA(t *testing.T, serial int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newSVID issues an X509-SVID for id signed by the CA.
+func newSVID(t *testing.T, id spiffeid.ID, ca *x509.Certificate, caKey *ecdsa.PrivateKey) *x509svid.SVID {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	u, err := url.Parse(id.String())
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		URIs:         []*url.URL{u},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{cert}, PrivateKey: key}
+}
+
+// fetch parses the bundle served by the handler.
+func fetch(t *testing.T, handler http.Handler) *spiffebundle.Bundle {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	bundle, err := spiffebundle.Parse(td, rec.Body.Bytes())
+	require.NoError(t, err)
+	return bundle
+}
+
+func TestServer_ServesBundle(t *testing.T) {
+	root, _ := newCA(t, 1)
+	bundles := newFakeBundles(root)
+
+	server, err := bundleendpoint.New(bundleendpoint.Config{
+		TrustDomain: td,
+		Bundles:     bundles,
+		RefreshHint: time.Minute,
+	})
+	require.NoError(t, err)
+	defer server.Close()
+
+	t.Run("not served before start", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+	})
+
+	started := time.Now()
+	require.NoError(t, server.Start(context.Background()))
+
+	var first uint64
+	t.Run("includes sequence and refresh hint", func(t *testing.T) {
+		bundle := fetch(t, server)
+		assert.True(t, bundle.HasX509Authority(root))
+
+		var ok bool
+		first, ok = bundle.SequenceNumber()
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, first, uint64(started.Unix()), "the sequence is derived from the time")
+
+		hint, ok := bundle.RefreshHint()
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, hint)
+	})
+
+	t.Run("unchanged authorities keep the sequence", func(t *testing.T) {
+		bundles.updates <- x509bundle.FromX509Authorities(td, []*x509.Certificate{root})
+		assert.Never(t, func() bool {
+			sequence, _ := fetch(t, server).SequenceNumber()
+			return sequence != first
+		}, 100*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("rotation bumps the sequence", func(t *testing.T) {
+		rotated, _ := newCA(t, 2)
+		bundles.updates <- x509bundle.FromX509Authorities(td, []*x509.Certificate{root, rotated})
+
+		assert.Eventually(t, func() bool {
+			bundle := fetch(t, server)
+			sequence, _ := bundle.SequenceNumber()
+			return sequence > first && bundle.HasX509Authority(rotated)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	require.NoError(t, server.Close())
+	require.NoError(t, server.Close())
+
+	t.Run("restart does not reset the sequence", func(t *testing.T) {
+		restarted, err := bundleendpoint.New(bundleendpoint.Config{TrustDomain: td, Bundles: newFakeBundles(root)})
+		require.NoError(t, err)
+		defer restarted.Close()
+		require.NoError(t, restarted.Start(context.Background()))
+
+		sequence, _ := fetch(t, restarted).SequenceNumber()
+		assert.GreaterOrEqual(t, sequence, first)
+	})
+}
+
+// fakeJWTBundles is a JWTBundleProviderPort with replaceable authorities.
+type fakeJWTBundles struct {
+	mu     sync.Mutex
+	bundle *jwtbundle.Bundle
+}
+
+func (f *fakeJWTBundles) set(authorities map[string]crypto.PublicKey) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bundle = jwtbundle.FromJWTAuthorities(td, authorities)
+}
+
+func (f *fakeJWTBundles) GetJWTBundleForTrustDomain(context.Context, spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bundle, nil
+}
+
+func (f *fakeJWTBundles) Close() error { return nil }
+
+func TestServer_ServesJWTAuthorities(t *testing.T) {
+	root, rootKey := newCA(t, 1)
+	jwtBundles := &fakeJWTBundles{}
+	jwtBundles.set(map[string]crypto.PublicKey{"key-1": rootKey.Public()})
+
+	server, err := bundleendpoint.New(bundleendpoint.Config{
+		TrustDomain: td,
+		Bundles:     newFakeBundles(root),
+		JWTBundles:  jwtBundles,
+		RefreshHint: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer server.Close()
+	require.NoError(t, server.Start(context.Background()))
+
+	bundle := fetch(t, server)
+	assert.True(t, bundle.HasX509Authority(root))
+	assert.True(t, bundle.HasJWTAuthority("key-1"))
+	first, _ := bundle.SequenceNumber()
+
+	// JWT key rotations are picked up once per refresh hint
+	_, rotatedKey := newCA(t, 2)
+	jwtBundles.set(map[string]crypto.PublicKey{"key-1": rootKey.Public(), "key-2": rotatedKey.Public()})
+	assert.Eventually(t, func() bool {
+		bundle := fetch(t, server)
+		sequence, _ := bundle.SequenceNumber()
+		return bundle.HasJWTAuthority("key-2") && sequence > first
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestServer_Serve(t *testing.T) {
+	root, rootKey := newCA(t, 1)
+
+	t.Run("https_spiffe", func(t *testing.T) {
+		endpointID := spiffeid.RequireFromPath(td, "/bundle-endpoint")
+		server, err := bundleendpoint.New(bundleendpoint.Config{
+			TrustDomain: td,
+			Bundles:     newFakeBundles(root),
+			Profile:     ports.BundleEndpointProfileHTTPSSPIFFE,
+			SVIDSource:  newSVID(t, endpointID, root, rootKey),
+		})
+		require.NoError(t, err)
+		defer server.Close()
+		require.NoError(t, server.Start(context.Background()))
+
+		address := serve(t, server)
+		bundle, err := federation.FetchBundle(context.Background(), td, "https://"+address,
+			federation.WithSPIFFEAuth(x509bundle.FromX509Authorities(td, []*x509.Certificate{root}), endpointID))
+		require.NoError(t, err)
+		assert.True(t, bundle.HasX509Authority(root))
+	})
+
+	t.Run("https_web", func(t *testing.T) {
+		// Borrow the httptest certificate, which is valid for 127.0.0.1
+		web := httptest.NewTLSServer(http.NotFoundHandler())
+		defer web.Close()
+		roots := x509.NewCertPool()
+		roots.AddCert(web.Certificate())
+
+		server, err := bundleendpoint.New(bundleendpoint.Config{
+			TrustDomain:  td,
+			Bundles:      newFakeBundles(root),
+			Profile:      ports.BundleEndpointProfileHTTPSWeb,
+			WebTLSConfig: &tls.Config{Certificates: web.TLS.Certificates, MinVersion: tls.VersionTLS12},
+		})
+		require.NoError(t, err)
+		defer server.Close()
+		require.NoError(t, server.Start(context.Background()))
+
+		address := serve(t, server)
+		bundle, err := federation.FetchBundle(context.Background(), td, "https://"+address,
+			federation.WithWebPKIRoots(roots))
+		require.NoError(t, err)
+		assert.True(t, bundle.HasX509Authority(root))
+	})
+
+	t.Run("profile without credentials", func(t *testing.T) {
+		server, err := bundleendpoint.New(bundleendpoint.Config{
+			TrustDomain: td,
+			Bundles:     newFakeBundles(root),
+			Profile:     ports.BundleEndpointProfileHTTPSWeb,
+		})
+		require.NoError(t, err)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		assert.Error(t, server.Serve(context.Background(), listener))
+	})
+}
+
+// serve runs the server standalone until the test ends and returns its address.
+func serve(t *testing.T, server *bundleendpoint.Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return listener.Addr().String()
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := bundleendpoint.New(bundleendpoint.Config{Bundles: newFakeBundles()})
+	assert.Error(t, err)
+
+	_, err = bundleendpoint.New(bundleendpoint.Config{TrustDomain: td})
+	assert.Error(t, err)
+}
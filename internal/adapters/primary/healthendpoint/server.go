@@ -0,0 +1,318 @@
+// Package healthendpoint exposes the results of the health monitor over HTTP, as
+// /livez, /readyz and /healthz probes, and over the gRPC health checking protocol.
+package healthendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// DefaultTimeout bounds the checks run for a single probe when none is configured.
+const DefaultTimeout = 5 * time.Second
+
+// Probe paths served by the HTTP handler.
+const (
+	LivenessPath  = "/livez"
+	ReadinessPath = "/readyz"
+	HealthPath    = "/healthz"
+)
+
+// Config configures a health endpoint server.
+type Config struct {
+	// Monitor runs the health checks. Required.
+	Monitor *services.HealthMonitorService
+	// Timeout bounds the checks run for a single HTTP probe. Default: 5 seconds.
+	// SetTimeout changes it at runtime.
+	Timeout time.Duration
+	// MaxAge is how old a monitoring result served by /healthz may be before its
+	// component is reported as unknown, e.g. after monitoring stopped.
+	// Default: twice the monitor's current interval.
+	MaxAge time.Duration
+
+	Logger *slog.Logger
+}
+
+// Server serves component health over HTTP and gRPC.
+//
+// It implements http.Handler, so the probes can be mounted on an existing mux, and
+// RegisterGRPC mounts grpc.health.v1.Health on an existing gRPC server such as the
+// identity server. For kubelet probes, which present no client certificate, Serve
+// and ServeGRPC serve on a separate plain listener.
+type Server struct {
+	config  Config
+	timeout atomic.Int64 // time.Duration
+	handler http.Handler
+	grpc    *health.Server
+	logger  *slog.Logger
+}
+
+// New creates a health endpoint server and registers it as a reporter with the
+// monitor, so the gRPC serving status follows every round of checks.
+func New(config Config) (*Server, error) {
+	if config.Monitor == nil {
+		return nil, fmt.Errorf("health monitor is required")
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Server{config: config, grpc: health.NewServer(), logger: logger}
+	s.SetTimeout(config.Timeout)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+LivenessPath, s.serveLiveness)
+	mux.HandleFunc("GET "+ReadinessPath, s.serveReadiness)
+	mux.HandleFunc("GET "+HealthPath, s.serveHealth)
+	s.handler = mux
+
+	reporter := &grpcReporter{server: s.grpc}
+	if results := config.Monitor.GetResults(); len(results) > 0 {
+		_ = reporter.ReportOverallHealth(results)
+	}
+	if err := config.Monitor.RegisterReporter(reporter); err != nil {
+		return nil, fmt.Errorf("failed to register health reporter: %w", err)
+	}
+	return s, nil
+}
+
+// SetTimeout changes the bound of the checks run for a single HTTP probe. A
+// non-positive timeout restores DefaultTimeout.
+func (s *Server) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	s.timeout.Store(int64(timeout))
+}
+
+// probeTimeout returns the bound of the checks run for a single HTTP probe.
+func (s *Server) probeTimeout() time.Duration {
+	return time.Duration(s.timeout.Load())
+}
+
+// maxAge returns how old a monitoring result served by /healthz may be.
+func (s *Server) maxAge() time.Duration {
+	if s.config.MaxAge > 0 {
+		return s.config.MaxAge
+	}
+	return 2 * s.config.Monitor.GetInterval()
+}
+
+// ServeHTTP serves the /livez, /readyz and /healthz probes.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// RegisterGRPC registers the grpc.health.v1.Health service on a gRPC server.
+// The overall status is served for the empty service name and the status of
+// each component under its component name.
+func (s *Server) RegisterGRPC(registrar grpc.ServiceRegistrar) {
+	healthpb.RegisterHealthServer(registrar, s.grpc)
+}
+
+// Serve serves the HTTP probes on a plain listener until the context is cancelled.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	httpServer := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = httpServer.Close()
+	})
+	defer stop()
+
+	err := httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServeGRPC serves the gRPC health service on a plain listener until the context
+// is cancelled.
+func (s *Server) ServeGRPC(ctx context.Context, listener net.Listener) error {
+	grpcServer := grpc.NewServer()
+	s.RegisterGRPC(grpcServer)
+
+	stop := context.AfterFunc(ctx, grpcServer.Stop)
+	defer stop()
+
+	err := grpcServer.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// response is the JSON body of every probe.
+type response struct {
+	Status     ports.HealthStatus             `json:"status"`
+	Components map[string]*ports.HealthResult `json:"components"`
+	Error      string                         `json:"error,omitempty"`
+}
+
+// serveLiveness runs the liveness checks. Only a component that reports itself
+// unhealthy fails the probe: one whose liveness cannot be determined, including
+// checks that did not finish in time, is no reason for the kubelet to restart
+// the workload.
+func (s *Server) serveLiveness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.probeTimeout())
+	defer cancel()
+
+	results, err := s.config.Monitor.CheckLiveness(ctx)
+	status := ports.HealthStatusHealthy
+	for _, result := range results {
+		if result != nil && result.Status == ports.HealthStatusUnhealthy {
+			status = ports.HealthStatusUnhealthy
+		}
+	}
+	s.write(w, status, results, err)
+}
+
+// serveReadiness runs the readiness checks. Every component must be ready, and
+// checks that did not finish make the probe fail.
+func (s *Server) serveReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.probeTimeout())
+	defer cancel()
+
+	results, err := s.config.Monitor.CheckReadiness(ctx)
+	status := overallStatus(results)
+	if err != nil {
+		status = ports.HealthStatusUnhealthy
+	}
+	s.write(w, status, results, err)
+}
+
+// serveHealth serves the latest results of periodic monitoring, running the
+// checks only if monitoring has not produced any yet. Every component must be
+// healthy; a result older than MaxAge is reported as unknown.
+func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
+	results := s.config.Monitor.GetResults()
+	var err error
+	if len(results) == 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), s.probeTimeout())
+		defer cancel()
+		results, err = s.config.Monitor.CheckAll(ctx)
+	} else {
+		results = markStale(results, time.Now().Add(-s.maxAge()))
+	}
+	status := overallStatus(results)
+	if err != nil {
+		status = ports.HealthStatusUnhealthy
+	}
+	s.write(w, status, results, err)
+}
+
+// markStale returns results with those checked before cutoff replaced by unknown
+// results that keep the time of the last check.
+func markStale(results map[string]*ports.HealthResult, cutoff time.Time) map[string]*ports.HealthResult {
+	for name, result := range results {
+		if result == nil || !result.CheckedAt.Before(cutoff) {
+			continue
+		}
+		results[name] = &ports.HealthResult{
+			Status:    ports.HealthStatusUnknown,
+			Component: result.Component,
+			Message: fmt.Sprintf("stale result: last checked at %s with status %s",
+				result.CheckedAt.Format(time.RFC3339), result.Status),
+			CheckedAt: result.CheckedAt,
+		}
+	}
+	return results
+}
+
+// write responds 200 when status is healthy and 503 otherwise. The error of
+// checks that did not finish is reported in the body.
+func (s *Server) write(w http.ResponseWriter, status ports.HealthStatus, results map[string]*ports.HealthResult, err error) {
+	body := response{Status: status, Components: results}
+	if err != nil {
+		body.Error = err.Error()
+	}
+	if body.Components == nil {
+		body.Components = map[string]*ports.HealthResult{}
+	}
+
+	code := http.StatusOK
+	if body.Status != ports.HealthStatusHealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Debug("failed to write health response", "error", err)
+	}
+}
+
+// overallStatus is healthy when every component is healthy.
+func overallStatus(results map[string]*ports.HealthResult) ports.HealthStatus {
+	for _, result := range results {
+		if result == nil || result.Status != ports.HealthStatusHealthy {
+			return ports.HealthStatusUnhealthy
+		}
+	}
+	return ports.HealthStatusHealthy
+}
+
+// grpcReporter maps health results onto the serving status of the gRPC health
+// service.
+type grpcReporter struct {
+	server *health.Server
+}
+
+// ReportHealth sets the serving status of the result's component.
+func (r *grpcReporter) ReportHealth(result *ports.HealthResult) error {
+	if result == nil || result.Component == "" {
+		return nil
+	}
+	r.server.SetServingStatus(result.Component, servingStatus(result.Status))
+	return nil
+}
+
+// ReportOverallHealth sets the serving status of every component and of the
+// server as a whole, which is serving only when every component is healthy.
+func (r *grpcReporter) ReportOverallHealth(results map[string]*ports.HealthResult) error {
+	for _, result := range results {
+		_ = r.ReportHealth(result)
+	}
+	overall := healthpb.HealthCheckResponse_SERVING
+	if overallStatus(results) != ports.HealthStatusHealthy {
+		overall = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	r.server.SetServingStatus("", overall)
+	return nil
+}
+
+// Close reports every service as not serving; the monitor calls it when it closes.
+func (r *grpcReporter) Close() error {
+	r.server.Shutdown()
+	return nil
+}
+
+// servingStatus maps a component health status to a gRPC serving status.
+func servingStatus(status ports.HealthStatus) healthpb.HealthCheckResponse_ServingStatus {
+	switch status {
+	case ports.HealthStatusHealthy:
+		return healthpb.HealthCheckResponse_SERVING
+	case ports.HealthStatusUnhealthy:
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	default:
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
+}
@@ -0,0 +1,244 @@
+package healthendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// fakeChecker reports fixed statuses for each kind of check.
+type fakeChecker struct {
+	name                     string
+	liveness, readiness, all ports.HealthStatus
+	block                    bool
+}
+
+func (c *fakeChecker) result(ctx context.Context, status ports.HealthStatus) (*ports.HealthResult, error) {
+	if c.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &ports.HealthResult{Status: status, Component: c.name, CheckedAt: time.Now()}, nil
+}
+
+func (c *fakeChecker) CheckLiveness(ctx context.Context) (*ports.HealthResult, error) {
+	return c.result(ctx, c.liveness)
+}
+
+func (c *fakeChecker) CheckReadiness(ctx context.Context) (*ports.HealthResult, error) {
+	return c.result(ctx, c.readiness)
+}
+
+func (c *fakeChecker) CheckHealth(ctx context.Context) (*ports.HealthResult, error) {
+	return c.result(ctx, c.all)
+}
+
+func (c *fakeChecker) GetComponentName() string { return c.name }
+
+func newServer(t *testing.T, checkers ...ports.HealthCheckerPort) (*Server, *services.HealthMonitorService) {
+	t.Helper()
+	monitor, err := services.NewHealthMonitorService(&ports.HealthConfig{Enabled: true}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = monitor.Close() })
+	for _, checker := range checkers {
+		require.NoError(t, monitor.RegisterChecker(checker))
+	}
+	server, err := New(Config{Monitor: monitor, Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	return server, monitor
+}
+
+func probe(t *testing.T, handler http.Handler, method, path string) (int, response) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+
+	var body response
+	if method != http.MethodHead && recorder.Code != http.StatusMethodNotAllowed {
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	}
+	return recorder.Code, body
+}
+
+func TestNew_RequiresMonitor(t *testing.T) {
+	_, err := New(Config{})
+	assert.Error(t, err)
+}
+
+func TestServer_HTTPProbes(t *testing.T) {
+	server, _ := newServer(t,
+		&fakeChecker{name: "svid", liveness: ports.HealthStatusHealthy, readiness: ports.HealthStatusHealthy, all: ports.HealthStatusHealthy},
+		&fakeChecker{name: "agent", liveness: ports.HealthStatusUnknown, readiness: ports.HealthStatusUnhealthy, all: ports.HealthStatusUnhealthy},
+	)
+
+	code, body := probe(t, server, http.MethodGet, LivenessPath)
+	assert.Equal(t, http.StatusOK, code, "an unknown liveness does not fail the probe")
+	assert.Equal(t, ports.HealthStatusHealthy, body.Status)
+	assert.Len(t, body.Components, 2)
+
+	code, body = probe(t, server, http.MethodGet, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ports.HealthStatusUnhealthy, body.Status)
+	require.Contains(t, body.Components, "agent")
+	assert.Equal(t, ports.HealthStatusUnhealthy, body.Components["agent"].Status)
+	assert.Equal(t, ports.HealthStatusHealthy, body.Components["svid"].Status)
+
+	code, body = probe(t, server, http.MethodGet, HealthPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Len(t, body.Components, 2)
+
+	code, _ = probe(t, server, http.MethodHead, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, _ = probe(t, server, http.MethodPost, HealthPath)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestServer_HealthzServesMonitoringResults(t *testing.T) {
+	checker := &fakeChecker{name: "svid", all: ports.HealthStatusHealthy}
+	server, monitor := newServer(t, checker)
+
+	_, err := monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	// The stored result is served until the next round of monitoring
+	checker.all = ports.HealthStatusUnhealthy
+	code, body := probe(t, server, http.MethodGet, HealthPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ports.HealthStatusHealthy, body.Components["svid"].Status)
+}
+
+func TestServer_NoCheckersIsHealthy(t *testing.T) {
+	server, _ := newServer(t)
+	for _, path := range []string{LivenessPath, ReadinessPath, HealthPath} {
+		code, body := probe(t, server, http.MethodGet, path)
+		assert.Equal(t, http.StatusOK, code, path)
+		assert.Empty(t, body.Components, path)
+	}
+}
+
+func TestServer_ProbeTimeout(t *testing.T) {
+	server, _ := newServer(t, &fakeChecker{name: "stuck", block: true})
+
+	code, body := probe(t, server, http.MethodGet, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, ports.HealthStatusUnhealthy, body.Status)
+	assert.NotEmpty(t, body.Error)
+
+	// A liveness check that does not finish is no reason to restart the workload
+	code, body = probe(t, server, http.MethodGet, LivenessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ports.HealthStatusHealthy, body.Status)
+	assert.NotEmpty(t, body.Error)
+}
+
+func TestServer_HealthzReportsStaleResultsAsUnknown(t *testing.T) {
+	monitor, err := services.NewHealthMonitorService(&ports.HealthConfig{Enabled: true}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = monitor.Close() })
+	require.NoError(t, monitor.RegisterChecker(&fakeChecker{name: "svid", all: ports.HealthStatusHealthy}))
+	server, err := New(Config{Monitor: monitor, MaxAge: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+	code, body := probe(t, server, http.MethodGet, HealthPath)
+	assert.Equal(t, http.StatusOK, code)
+	checkedAt := body.Components["svid"].CheckedAt
+
+	// Without another round of monitoring the result goes stale
+	time.Sleep(100 * time.Millisecond)
+	code, body = probe(t, server, http.MethodGet, HealthPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body.Components, "svid")
+	assert.Equal(t, ports.HealthStatusUnknown, body.Components["svid"].Status)
+	assert.True(t, checkedAt.Equal(body.Components["svid"].CheckedAt), "the time of the last check is kept")
+	assert.Contains(t, body.Components["svid"].Message, "stale")
+}
+
+func TestServer_GRPCHealth(t *testing.T) {
+	server, monitor := newServer(t,
+		&fakeChecker{name: "svid", all: ports.HealthStatusHealthy},
+		&fakeChecker{name: "agent", all: ports.HealthStatusUnknown},
+	)
+
+	listener := bufconn.Listen(1 << 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.ServeGRPC(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-served)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+
+	check := func(service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	_, err = check("svid")
+	assert.Equal(t, codes.NotFound, status.Code(err), "components are unknown until checked")
+
+	_, err = monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	serving, err := check("svid")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, serving)
+
+	serving, err = check("agent")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_UNKNOWN, serving)
+
+	serving, err = check("")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, serving)
+
+	// Closing the monitor reports every service as not serving
+	require.NoError(t, monitor.Close())
+	serving, err = check("svid")
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, serving)
+}
+
+func TestNew_SeedsGRPCStatusFromMonitor(t *testing.T) {
+	monitor, err := services.NewHealthMonitorService(&ports.HealthConfig{Enabled: true}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = monitor.Close() })
+	require.NoError(t, monitor.RegisterChecker(&fakeChecker{name: "svid", all: ports.HealthStatusUnhealthy}))
+	_, err = monitor.CheckAll(context.Background())
+	require.NoError(t, err)
+
+	server, err := New(Config{Monitor: monitor})
+	require.NoError(t, err)
+
+	resp, err := server.grpc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ""})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
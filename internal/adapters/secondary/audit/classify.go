@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// ClassifyError maps a handshake or authorization error to an audit error class.
+// Causes are recognized by the typed errors they wrap: the domain peer verification
+// errors, domain.ErrRevoked and crypto/x509's verification errors. go-spiffe reports
+// its rejections as plain messages, so verifiers must be built with WrapBundleSource,
+// WrapAuthorizer and WrapVerifyPeerCertificate for them to be classified.
+// It returns an empty string for a nil error.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var invalid x509.CertificateInvalidError
+	var unknownAuthority x509.UnknownAuthorityError
+	switch {
+	case errors.Is(err, domain.ErrRevoked):
+		return ports.AuditErrorRevoked
+	case errors.Is(err, domain.ErrNoPeerCertificate):
+		return ports.AuditErrorNoCertificate
+	case errors.Is(err, domain.ErrInvalidPeerIdentity):
+		return ports.AuditErrorInvalidIdentity
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return ports.AuditErrorExpired
+	case errors.As(err, &invalid), errors.As(err, &unknownAuthority), errors.Is(err, domain.ErrUntrustedPeer):
+		return ports.AuditErrorUntrusted
+	case errors.Is(err, domain.ErrUnauthorizedPeer):
+		return ports.AuditErrorUnauthorized
+	default:
+		return ports.AuditErrorOther
+	}
+}
+
+// WrapBundleSource returns a bundle source whose lookup failures wrap
+// domain.ErrUntrustedPeer.
+func WrapBundleSource(source x509bundle.Source) x509bundle.Source {
+	return untrustedBundleSource{source: source}
+}
+
+type untrustedBundleSource struct {
+	source x509bundle.Source
+}
+
+func (s untrustedBundleSource) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	bundle, err := s.source.GetX509BundleForTrustDomain(trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUntrustedPeer, err)
+	}
+	return bundle, nil
+}
+
+// WrapAuthorizer returns an authorizer whose rejections wrap domain.ErrUnauthorizedPeer.
+func WrapAuthorizer(authorizer tlsconfig.Authorizer) tlsconfig.Authorizer {
+	return func(id spiffeid.ID, chains [][]*x509.Certificate) error {
+		if err := authorizer(id, chains); err != nil {
+			return fmt.Errorf("%w: %w", domain.ErrUnauthorizedPeer, err)
+		}
+		return nil
+	}
+}
+
+// WrapVerifyPeerCertificate returns a VerifyPeerCertificate callback that runs verify
+// and wraps its rejections of empty chains in domain.ErrNoPeerCertificate, and of
+// leaves that are not X.509-SVIDs in domain.ErrInvalidPeerIdentity.
+func WrapVerifyPeerCertificate(verify func([][]byte, [][]*x509.Certificate) error) func([][]byte, [][]*x509.Certificate) error {
+	return func(raw [][]byte, chains [][]*x509.Certificate) error {
+		if verify == nil {
+			return nil
+		}
+		err := verify(raw, chains)
+		if err == nil {
+			return nil
+		}
+		if len(raw) == 0 {
+			return fmt.Errorf("%w: %w", domain.ErrNoPeerCertificate, err)
+		}
+		if !isSVIDLeaf(raw[0]) {
+			return fmt.Errorf("%w: %w", domain.ErrInvalidPeerIdentity, err)
+		}
+		return err
+	}
+}
+
+// isSVIDLeaf reports whether the certificate has the shape go-spiffe requires of an
+// X.509-SVID leaf: one SPIFFE ID URI SAN and no CA capabilities.
+func isSVIDLeaf(raw []byte) bool {
+	leaf, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return false
+	}
+	if _, err := x509svid.IDFromCert(leaf); err != nil {
+		return false
+	}
+	return !leaf.IsCA && leaf.KeyUsage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) == 0
+}
@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+const (
+	// DefaultMaxFileSize is the size at which the audit file is rotated.
+	DefaultMaxFileSize = 100 * 1024 * 1024
+	// DefaultMaxBackups is the number of rotated audit files kept.
+	DefaultMaxBackups = 5
+)
+
+// FileSinkConfig provides configuration for the JSON-lines file sink.
+type FileSinkConfig struct {
+	// Path is the active audit file. Rotated files are Path.1 (newest) to Path.N. Required.
+	Path string
+	// MaxSizeBytes rotates the file once it reaches this size. Default: 100 MiB.
+	MaxSizeBytes int64
+	// MaxBackups is the number of rotated files to keep. Default: 5.
+	MaxBackups int
+}
+
+// FileSink appends audit events to a file, one JSON object per line, rotating it by size.
+// The chain continues across rotations, so rotated files verify together.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	last *ports.AuditEvent
+}
+
+var _ ports.AuditSinkPort = (*FileSink)(nil)
+
+// NewFileSink opens or creates the audit file with owner-only permissions.
+func NewFileSink(config FileSinkConfig) (*FileSink, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("audit file path is required")
+	}
+
+	sink := &FileSink{
+		path:       filepath.Clean(config.Path),
+		maxSize:    config.MaxSizeBytes,
+		maxBackups: config.MaxBackups,
+	}
+	if sink.maxSize <= 0 {
+		sink.maxSize = DefaultMaxFileSize
+	}
+	if sink.maxBackups <= 0 {
+		sink.maxBackups = DefaultMaxBackups
+	}
+
+	last, err := lastEvent(sink.path, sink.path+".1")
+	if err != nil {
+		return nil, err
+	}
+	sink.last = last
+
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// LastAuditEvent returns the newest event in the file, so a new trail can continue the chain.
+func (s *FileSink) LastAuditEvent() (ports.AuditEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return ports.AuditEvent{}, false
+	}
+	return *s.last, true
+}
+
+// WriteAuditEvent appends the event and rotates the file when it is full.
+func (s *FileSink) WriteAuditEvent(_ context.Context, event ports.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("audit file %s is closed", s.path)
+	}
+
+	if s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	s.last = &event
+	return nil
+}
+
+// Close syncs and closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := errors.Join(s.file.Sync(), s.file.Close())
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat audit file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate shifts Path.N-1 to Path.N, ..., Path to Path.1 and opens a fresh Path.
+func (s *FileSink) rotate() error {
+	if err := errors.Join(s.file.Sync(), s.file.Close()); err != nil {
+		return fmt.Errorf("failed to close audit file for rotation: %w", err)
+	}
+	s.file = nil
+
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", s.path, i)
+		to := fmt.Sprintf("%s.%d", s.path, i+1)
+		if err := os.Rename(from, to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	return s.open()
+}
+
+// ReadFile reads the events of a JSON-lines audit file.
+func ReadFile(path string) ([]ports.AuditEvent, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer file.Close()
+
+	var events []ports.AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event ports.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid audit event: %w", path, line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file: %w", err)
+	}
+	return events, nil
+}
+
+// lastEvent returns the newest event of the first existing, non-empty file.
+func lastEvent(paths ...string) (*ports.AuditEvent, error) {
+	for _, path := range paths {
+		events, err := ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(events) > 0 {
+			return &events[len(events)-1], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,103 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func newFileTrail(t *testing.T, path string, maxSize int64) (*audit.Trail, *audit.FileSink) {
+	t.Helper()
+	sink, err := audit.NewFileSink(audit.FileSinkConfig{Path: path, MaxSizeBytes: maxSize, MaxBackups: 2})
+	require.NoError(t, err)
+	trail, err := audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{sink}, Key: testKey})
+	require.NoError(t, err)
+	return trail, sink
+}
+
+func TestFileSink_WritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	trail, _ := newFileTrail(t, path, 0)
+
+	trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+	require.NoError(t, trail.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(data, []byte("\n")))
+	assert.Contains(t, string(data), `"peer_id":"spiffe://example.org/client"`)
+	assert.Contains(t, string(data), `"error_class":"policy_denied"`)
+
+	events, err := audit.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.NoError(t, audit.VerifyChain(events, testKey))
+}
+
+func TestFileSink_RotatesAndKeepsChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	trail, _ := newFileTrail(t, path, 1024)
+
+	for i := 0; i < 12; i++ {
+		trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+	}
+	require.NoError(t, trail.Close())
+
+	_, err := os.Stat(path + ".3")
+	assert.ErrorIs(t, err, os.ErrNotExist, "only MaxBackups rotated files are kept")
+
+	var all []ports.AuditEvent
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		events, err := audit.ReadFile(name)
+		require.NoError(t, err)
+		all = append(all, events...)
+	}
+	require.NotEmpty(t, all)
+	assert.Equal(t, uint64(12), all[len(all)-1].Sequence)
+	require.NoError(t, audit.VerifyChain(all, testKey))
+}
+
+func TestFileSink_ResumesChainAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	first, _ := newFileTrail(t, path, 0)
+	first.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/a"))
+	first.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/b"))
+	require.NoError(t, first.Close())
+
+	second, _ := newFileTrail(t, path, 0)
+	second.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/c"))
+	require.NoError(t, second.Close())
+
+	events, err := audit.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, uint64(3), events[2].Sequence)
+	require.NoError(t, audit.VerifyChain(events, testKey))
+}
+
+func TestFileSink_RejectsResumeUnderAnotherKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	first, _ := newFileTrail(t, path, 0)
+	first.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/a"))
+	require.NoError(t, first.Close())
+
+	sink, err := audit.NewFileSink(audit.FileSinkConfig{Path: path})
+	require.NoError(t, err)
+	defer sink.Close()
+	_, err = audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{sink}, Key: []byte("another key of thirty-two bytes!")})
+	assert.ErrorIs(t, err, audit.ErrChainBroken)
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// SlogSink writes audit events as structured log records. Allowed decisions are
+// logged at Info and denied decisions at Warn.
+type SlogSink struct {
+	logger *slog.Logger
+}
+
+var _ ports.AuditSinkPort = (*SlogSink)(nil)
+
+// NewSlogSink creates a sink that logs to logger, or to slog.Default() if nil.
+func NewSlogSink(logger *slog.Logger) *SlogSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogSink{logger: logger}
+}
+
+// WriteAuditEvent logs the event.
+func (s *SlogSink) WriteAuditEvent(ctx context.Context, event ports.AuditEvent) error {
+	level := slog.LevelInfo
+	if event.Decision == ports.AuditDecisionDeny {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.Uint64("seq", event.Sequence),
+		slog.String("kind", string(event.Kind)),
+		slog.String("decision", string(event.Decision)),
+		slog.String("transport", event.Transport),
+		slog.String("operation", event.Operation),
+		slog.String("peer_id", event.PeerID),
+		slog.String("peer_serial", event.PeerSerial),
+		slog.String("local_id", event.LocalID),
+		slog.String("remote_addr", event.RemoteAddr),
+		slog.String("rule", event.Rule),
+		slog.String("error_class", event.ErrorClass),
+		slog.String("reason", event.Reason),
+		slog.String("hash", event.Hash),
+	}
+	if !event.PeerExpiry.IsZero() {
+		attrs = append(attrs, slog.Time("peer_expiry", event.PeerExpiry))
+	}
+
+	s.logger.LogAttrs(ctx, level, "audit", attrs...)
+	return nil
+}
+
+// Close does nothing; the logger is owned by the caller.
+func (s *SlogSink) Close() error {
+	return nil
+}
+
+// RingBuffer keeps the most recent audit events in memory. It is intended for
+// tests and debugging endpoints.
+type RingBuffer struct {
+	mu     sync.Mutex
+	events []ports.AuditEvent
+	next   int
+	full   bool
+}
+
+var _ ports.AuditSinkPort = (*RingBuffer)(nil)
+
+// NewRingBuffer creates a buffer holding up to capacity events. A capacity
+// below one is treated as one.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBuffer{events: make([]ports.AuditEvent, capacity)}
+}
+
+// WriteAuditEvent stores the event, evicting the oldest when full.
+func (r *RingBuffer) WriteAuditEvent(_ context.Context, event ports.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Events returns the buffered events, oldest first.
+func (r *RingBuffer) Events() []ports.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]ports.AuditEvent(nil), r.events[:r.next]...)
+	}
+	events := make([]ports.AuditEvent, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// Close does nothing; buffered events remain readable.
+func (r *RingBuffer) Close() error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// errNoClientCertificate rejects clients that present no certificate.
+var errNoClientCertificate = fmt.Errorf("tls: client didn't provide a certificate: %w", domain.ErrNoPeerCertificate)
+
+// HandshakeAuditConfig describes the server whose handshakes are audited.
+type HandshakeAuditConfig struct {
+	// Recorder receives one authentication event per handshake. Required.
+	Recorder ports.AuditRecorderPort
+	// Transport is "http" or "grpc".
+	Transport string
+	// Rule names the handshake authorizer, if known.
+	Rule string
+	// LocalID is the server SPIFFE ID.
+	LocalID string
+}
+
+// ServerTLSConfig returns a copy of a server TLS config that records every client
+// certificate verification, accepted or rejected, as an authentication event.
+//
+// crypto/tls rejects clients that present no certificate before VerifyPeerCertificate
+// runs, so the copy only requests the certificate and rejects its absence in the
+// callback, where it is recorded. Clients are still required to present one.
+func ServerTLSConfig(base *tls.Config, config HandshakeAuditConfig) *tls.Config {
+	verify := WrapVerifyPeerCertificate(base.VerifyPeerCertificate)
+
+	requested := base.Clone()
+	switch base.ClientAuth {
+	case tls.RequireAnyClientCert:
+		requested.ClientAuth = tls.RequestClientCert
+	case tls.RequireAndVerifyClientCert:
+		requested.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if requested.ClientAuth != base.ClientAuth {
+		verify = requireCertificate(verify)
+	}
+
+	audited := requested.Clone()
+	audited.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		remoteAddr := ""
+		if hello.Conn != nil {
+			remoteAddr = hello.Conn.RemoteAddr().String()
+		}
+
+		perConn := requested.Clone()
+		perConn.VerifyPeerCertificate = func(raw [][]byte, chains [][]*x509.Certificate) error {
+			err := verify(raw, chains)
+			config.Recorder.RecordAuditEvent(hello.Context(), handshakeEvent(config, remoteAddr, raw, err))
+			return err
+		}
+		return perConn, nil
+	}
+	return audited
+}
+
+// requireCertificate rejects empty chains before calling verify.
+func requireCertificate(verify func([][]byte, [][]*x509.Certificate) error) func([][]byte, [][]*x509.Certificate) error {
+	return func(raw [][]byte, chains [][]*x509.Certificate) error {
+		if len(raw) == 0 {
+			return errNoClientCertificate
+		}
+		return verify(raw, chains)
+	}
+}
+
+// handshakeEvent describes a client certificate verification.
+func handshakeEvent(config HandshakeAuditConfig, remoteAddr string, raw [][]byte, err error) ports.AuditEvent {
+	event := ports.AuditEvent{
+		Kind:       ports.AuditKindAuthentication,
+		Decision:   ports.AuditDecisionAllow,
+		Transport:  config.Transport,
+		LocalID:    config.LocalID,
+		RemoteAddr: remoteAddr,
+		Rule:       config.Rule,
+	}
+	if len(raw) > 0 {
+		if leaf, parseErr := x509.ParseCertificate(raw[0]); parseErr == nil {
+			SetPeerCertificate(&event, leaf)
+		}
+	}
+	if err != nil {
+		event.Decision = ports.AuditDecisionDeny
+		event.ErrorClass = ClassifyError(err)
+		event.Reason = err.Error()
+	}
+	return event
+}
+
+// SetPeerCertificate fills the peer fields of event from the leaf certificate.
+func SetPeerCertificate(event *ports.AuditEvent, leaf *x509.Certificate) {
+	if leaf == nil {
+		return
+	}
+	if id, err := x509svid.IDFromCert(leaf); err == nil {
+		event.PeerID = id.String()
+	}
+	if leaf.SerialNumber != nil {
+		event.PeerSerial = leaf.SerialNumber.Text(16)
+	}
+	event.PeerExpiry = leaf.NotAfter
+}
@@ -0,0 +1,289 @@
+// Package audit provides a tamper-evident audit trail of authentication and
+// authorization decisions with slog, JSON-lines file and in-memory sinks.
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// ErrChainBroken is returned by VerifyChain when events were altered, removed or reordered.
+var ErrChainBroken = errors.New("audit chain broken")
+
+const (
+	// MinKeySize is the minimum length of the key that authenticates the chain.
+	MinKeySize = 32
+	// DefaultQueueSize is the number of events buffered for the sinks.
+	DefaultQueueSize = 1024
+)
+
+// resumableSink is implemented by sinks that persist events across restarts, so a
+// new trail continues the chain of the previous process.
+type resumableSink interface {
+	LastAuditEvent() (ports.AuditEvent, bool)
+}
+
+// Metrics counts events the trail could not queue for its sinks.
+type Metrics interface {
+	RecordAuditEventDropped()
+}
+
+// TrailConfig provides configuration for the audit trail.
+type TrailConfig struct {
+	// Sinks receive every event. At least one sink is required.
+	Sinks []ports.AuditSinkPort
+	// Key authenticates the chain with HMAC-SHA256. Without it anyone able to
+	// edit the sinks could rewrite and rehash the whole chain. It must be at
+	// least MinKeySize bytes, kept outside the audit storage, and the same key
+	// is needed to verify the events. Required.
+	Key []byte
+	// QueueSize is the number of events buffered while sinks are written.
+	// Events recorded while the queue is full are dropped, logged and counted,
+	// and the gap shows in VerifyChain. Default: DefaultQueueSize.
+	QueueSize int
+	// BlockTimeout is how long recording waits for room in a full queue before
+	// the event is dropped. Other events wait behind it, which keeps the chain in
+	// order. Default: 0, drop at once so recording never waits for a sink.
+	BlockTimeout time.Duration
+	// Metrics counts dropped events. Optional.
+	Metrics Metrics
+	// Logger reports sink failures and dropped events. Default: slog.Default().
+	Logger *slog.Logger
+}
+
+// Trail chains audit events with HMAC-SHA256 and fans them out to sinks.
+// Events are written by a background goroutine, so recording only waits for a
+// sink when BlockTimeout is set and the queue is full. It is safe for concurrent use.
+type Trail struct {
+	sinks        []ports.AuditSinkPort
+	key          []byte
+	logger       *slog.Logger
+	metrics      Metrics
+	blockTimeout time.Duration
+	queue        chan trailItem
+	done         chan struct{}
+
+	mu       sync.Mutex
+	sequence uint64
+	lastHash string
+	closed   bool
+}
+
+// trailItem is a queued event, or a flush marker when flushed is set.
+type trailItem struct {
+	ctx     context.Context
+	event   ports.AuditEvent
+	flushed chan struct{}
+}
+
+var _ ports.AuditRecorderPort = (*Trail)(nil)
+
+// NewTrail creates an audit trail. When a sink holds events from an earlier run,
+// the trail continues its sequence and hash chain, provided the last event was
+// written with the same key.
+func NewTrail(config TrailConfig) (*Trail, error) {
+	if len(config.Sinks) == 0 {
+		return nil, fmt.Errorf("at least one audit sink is required")
+	}
+	if len(config.Key) < MinKeySize {
+		return nil, fmt.Errorf("audit chain key must be at least %d bytes", MinKeySize)
+	}
+
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	trail := &Trail{
+		sinks:        config.Sinks,
+		key:          append([]byte(nil), config.Key...),
+		logger:       logger,
+		metrics:      config.Metrics,
+		blockTimeout: config.BlockTimeout,
+		queue:        make(chan trailItem, queueSize),
+		done:         make(chan struct{}),
+	}
+	for _, sink := range config.Sinks {
+		if resumable, ok := sink.(resumableSink); ok {
+			last, found := resumable.LastAuditEvent()
+			if !found {
+				continue
+			}
+			if !hmac.Equal([]byte(HashEvent(last, trail.key)), []byte(last.Hash)) {
+				return nil, fmt.Errorf("%w: event %d was not written with this key", ErrChainBroken, last.Sequence)
+			}
+			if last.Sequence >= trail.sequence {
+				trail.sequence = last.Sequence
+				trail.lastHash = last.Hash
+			}
+		}
+	}
+
+	go trail.write()
+	return trail, nil
+}
+
+// RecordAuditEvent assigns the event its sequence number and hashes, then queues it
+// for the sinks. When the queue is full it waits up to BlockTimeout, or until ctx
+// is done, and then drops the event, logging and counting it.
+func (t *Trail) RecordAuditEvent(ctx context.Context, event ports.AuditEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+	if !event.PeerExpiry.IsZero() {
+		event.PeerExpiry = event.PeerExpiry.UTC()
+	}
+
+	t.sequence++
+	event.Sequence = t.sequence
+	event.PrevHash = t.lastHash
+	event.Hash = HashEvent(event, t.key)
+	t.lastHash = event.Hash
+
+	// Queueing under the lock keeps the queue in chain order. The event outlives
+	// the handshake or request that recorded it, so its context is not cancelled.
+	item := trailItem{ctx: context.WithoutCancel(ctx), event: event}
+	select {
+	case t.queue <- item:
+		return
+	default:
+	}
+	if t.blockTimeout > 0 {
+		timer := time.NewTimer(t.blockTimeout)
+		defer timer.Stop()
+		select {
+		case t.queue <- item:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	if t.metrics != nil {
+		t.metrics.RecordAuditEventDropped()
+	}
+	t.logger.Error("audit queue full, dropping event", "sequence", event.Sequence,
+		"kind", event.Kind, "decision", event.Decision)
+}
+
+// write sends queued events to every sink. Sink failures are logged and do not
+// stop other sinks.
+func (t *Trail) write() {
+	defer close(t.done)
+
+	for item := range t.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		for _, sink := range t.sinks {
+			if err := sink.WriteAuditEvent(item.ctx, item.event); err != nil {
+				t.logger.Error("failed to write audit event", "sequence", item.event.Sequence, "error", err)
+			}
+		}
+	}
+}
+
+// Flush waits until every event recorded before the call has been written to the
+// sinks, or until ctx is done.
+func (t *Trail) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	select {
+	case t.queue <- trailItem{flushed: flushed}:
+		t.mu.Unlock()
+	case <-ctx.Done():
+		t.mu.Unlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the queued events, then closes all sinks. Events recorded after
+// Close are dropped.
+func (t *Trail) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.queue)
+	t.mu.Unlock()
+
+	<-t.done
+
+	var errs []error
+	for _, sink := range t.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HashEvent returns the hex HMAC-SHA256 under key of the event's JSON encoding
+// with Hash cleared.
+func HashEvent(event ports.AuditEvent, key []byte) string {
+	event.Hash = ""
+	// Encoding a struct of strings, numbers and times cannot fail
+	data, _ := json.Marshal(event)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyChain checks that events form an unbroken chain under key: sequence numbers
+// are consecutive, every hash matches its event, and every event links to its
+// predecessor. The first event may link to an earlier, rotated-out event.
+func VerifyChain(events []ports.AuditEvent, key []byte) error {
+	for i, event := range events {
+		if !hmac.Equal([]byte(HashEvent(event, key)), []byte(event.Hash)) {
+			return fmt.Errorf("%w: event %d was modified", ErrChainBroken, event.Sequence)
+		}
+		if i == 0 {
+			continue
+		}
+		previous := events[i-1]
+		if event.Sequence != previous.Sequence+1 {
+			return fmt.Errorf("%w: event %d follows event %d", ErrChainBroken, event.Sequence, previous.Sequence)
+		}
+		if event.PrevHash != previous.Hash {
+			return fmt.Errorf("%w: event %d does not link to event %d", ErrChainBroken, event.Sequence, previous.Sequence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func denyEvent(peer string) ports.AuditEvent {
+	return ports.AuditEvent{
+		Kind:       ports.AuditKindAuthorization,
+		Decision:   ports.AuditDecisionDeny,
+		Transport:  "http",
+		Operation:  "GET /admin",
+		PeerID:     peer,
+		PeerExpiry: time.Now().Add(time.Hour),
+		Rule:       "admin-only",
+		ErrorClass: ports.AuditErrorPolicyDenied,
+	}
+}
+
+func TestTrail_ChainsEvents(t *testing.T) {
+	buffer := audit.NewRingBuffer(10)
+	trail, err := audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{buffer}, Key: testKey})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = trail.Close() })
+
+	for i := 0; i < 3; i++ {
+		trail.RecordAuditEvent(context.Background(), denyEvent(fmt.Sprintf("spiffe://example.org/client-%d", i)))
+	}
+	require.NoError(t, trail.Flush(context.Background()))
+
+	events := buffer.Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, uint64(1), events[0].Sequence)
+	assert.Empty(t, events[0].PrevHash)
+	assert.Equal(t, events[0].Hash, events[1].PrevHash)
+	assert.Equal(t, time.UTC, events[0].Time.Location())
+	require.NoError(t, audit.VerifyChain(events, testKey))
+
+	t.Run("wrong key", func(t *testing.T) {
+		assert.ErrorIs(t, audit.VerifyChain(events, []byte("another key of thirty-two bytes!")), audit.ErrChainBroken)
+	})
+
+	t.Run("modified event", func(t *testing.T) {
+		tampered := append([]ports.AuditEvent(nil), events...)
+		tampered[1].Decision = ports.AuditDecisionAllow
+		assert.ErrorIs(t, audit.VerifyChain(tampered, testKey), audit.ErrChainBroken)
+	})
+
+	t.Run("removed event", func(t *testing.T) {
+		assert.ErrorIs(t, audit.VerifyChain([]ports.AuditEvent{events[0], events[2]}, testKey), audit.ErrChainBroken)
+	})
+
+	t.Run("rehashed event without relinking", func(t *testing.T) {
+		tampered := append([]ports.AuditEvent(nil), events...)
+		tampered[1].PeerID = "spiffe://example.org/someone-else"
+		tampered[1].Hash = audit.HashEvent(tampered[1], testKey)
+		assert.ErrorIs(t, audit.VerifyChain(tampered, testKey), audit.ErrChainBroken)
+	})
+}
+
+func TestTrail_RequiresSinkAndKey(t *testing.T) {
+	_, err := audit.NewTrail(audit.TrailConfig{Key: testKey})
+	assert.Error(t, err)
+
+	_, err = audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{audit.NewRingBuffer(1)}, Key: []byte("short")})
+	assert.Error(t, err)
+}
+
+// blockingSink holds every write until release is closed.
+type blockingSink struct {
+	*audit.RingBuffer
+	release chan struct{}
+}
+
+func (s blockingSink) WriteAuditEvent(ctx context.Context, event ports.AuditEvent) error {
+	<-s.release
+	return s.RingBuffer.WriteAuditEvent(ctx, event)
+}
+
+func TestTrail_RecordingDoesNotWaitForSinks(t *testing.T) {
+	sink := blockingSink{RingBuffer: audit.NewRingBuffer(10), release: make(chan struct{})}
+	trail, err := audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{sink}, Key: testKey, QueueSize: 1})
+	require.NoError(t, err)
+
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		// The writer holds the first event, the queue the second; the third is dropped
+		for i := 0; i < 3; i++ {
+			trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+		}
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("recording blocked on a slow sink")
+	}
+
+	close(sink.release)
+	require.NoError(t, trail.Close())
+
+	events := sink.Events()
+	require.NotEmpty(t, events)
+	assert.Less(t, len(events), 3)
+	if len(events) > 1 {
+		assert.NoError(t, audit.VerifyChain(events, testKey))
+	}
+}
+
+// droppedCounter implements audit.Metrics.
+type droppedCounter struct {
+	dropped atomic.Int64
+}
+
+func (c *droppedCounter) RecordAuditEventDropped() {
+	c.dropped.Add(1)
+}
+
+func TestTrail_CountsDroppedEvents(t *testing.T) {
+	sink := blockingSink{RingBuffer: audit.NewRingBuffer(10), release: make(chan struct{})}
+	metrics := &droppedCounter{}
+	trail, err := audit.NewTrail(audit.TrailConfig{
+		Sinks:     []ports.AuditSinkPort{sink},
+		Key:       testKey,
+		QueueSize: 1,
+		Metrics:   metrics,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+	}
+	close(sink.release)
+	require.NoError(t, trail.Close())
+
+	dropped := metrics.dropped.Load()
+	assert.Positive(t, dropped)
+	assert.Equal(t, int64(3), dropped+int64(len(sink.Events())))
+}
+
+func TestTrail_BlockTimeoutWaitsForRoom(t *testing.T) {
+	sink := blockingSink{RingBuffer: audit.NewRingBuffer(10), release: make(chan struct{})}
+	metrics := &droppedCounter{}
+	trail, err := audit.NewTrail(audit.TrailConfig{
+		Sinks:        []ports.AuditSinkPort{sink},
+		Key:          testKey,
+		QueueSize:    1,
+		BlockTimeout: 5 * time.Second,
+		Metrics:      metrics,
+	})
+	require.NoError(t, err)
+
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		for i := 0; i < 3; i++ {
+			trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+		}
+	}()
+	select {
+	case <-recorded:
+		t.Fatal("recording did not wait for room in the queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.release)
+	<-recorded
+	require.NoError(t, trail.Close())
+
+	events := sink.Events()
+	assert.Len(t, events, 3)
+	assert.NoError(t, audit.VerifyChain(events, testKey))
+	assert.Zero(t, metrics.dropped.Load())
+}
+
+func TestTrail_BlockTimeoutIsBounded(t *testing.T) {
+	sink := blockingSink{RingBuffer: audit.NewRingBuffer(10), release: make(chan struct{})}
+	metrics := &droppedCounter{}
+	trail, err := audit.NewTrail(audit.TrailConfig{
+		Sinks:        []ports.AuditSinkPort{sink},
+		Key:          testKey,
+		QueueSize:    1,
+		BlockTimeout: 20 * time.Millisecond,
+		Metrics:      metrics,
+	})
+	require.NoError(t, err)
+
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		for i := 0; i < 3; i++ {
+			trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/client"))
+		}
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("recording waited past the block timeout")
+	}
+
+	close(sink.release)
+	require.NoError(t, trail.Close())
+	assert.Positive(t, metrics.dropped.Load())
+}
+
+func TestTrail_CloseDropsLaterEvents(t *testing.T) {
+	buffer := audit.NewRingBuffer(10)
+	trail, err := audit.NewTrail(audit.TrailConfig{Sinks: []ports.AuditSinkPort{buffer}, Key: testKey})
+	require.NoError(t, err)
+
+	require.NoError(t, trail.Close())
+	require.NoError(t, trail.Close())
+	trail.RecordAuditEvent(context.Background(), denyEvent("spiffe://example.org/late"))
+	require.NoError(t, trail.Flush(context.Background()))
+	assert.Empty(t, buffer.Events())
+}
+
+func TestRingBuffer_KeepsNewestEvents(t *testing.T) {
+	buffer := audit.NewRingBuffer(2)
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, buffer.WriteAuditEvent(context.Background(), ports.AuditEvent{Sequence: i}))
+	}
+
+	events := buffer.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, uint64(2), events[0].Sequence)
+	assert.Equal(t, uint64(3), events[1].Sequence)
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "expired", err: fmt.Errorf("x509svid: could not verify leaf certificate: %w",
+			x509.CertificateInvalidError{Reason: x509.Expired}), want: ports.AuditErrorExpired},
+		{name: "unknown authority", err: fmt.Errorf("x509svid: could not verify leaf certificate: %w",
+			x509.UnknownAuthorityError{}), want: ports.AuditErrorUntrusted},
+		{name: "unknown trust domain", err: fmt.Errorf("x509svid: could not get X509 bundle: %w",
+			fmt.Errorf("%w: no bundle", domain.ErrUntrustedPeer)), want: ports.AuditErrorUntrusted},
+		{name: "no certificate", err: fmt.Errorf("%w: tls: client didn't provide a certificate", domain.ErrNoPeerCertificate),
+			want: ports.AuditErrorNoCertificate},
+		{name: "missing SPIFFE ID", err: fmt.Errorf("%w: certificate contains no URI SAN", domain.ErrInvalidPeerIdentity),
+			want: ports.AuditErrorInvalidIdentity},
+		{name: "unauthorized", err: fmt.Errorf("%w: unexpected ID", domain.ErrUnauthorizedPeer), want: ports.AuditErrorUnauthorized},
+		{name: "revoked", err: fmt.Errorf("handshake: %w", domain.ErrRevoked), want: ports.AuditErrorRevoked},
+		{name: "message only", err: errors.New("x509svid: could not get X509 bundle: no bundle"), want: ports.AuditErrorOther},
+		{name: "other", err: errors.New("connection reset"), want: ports.AuditErrorOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, audit.ClassifyError(tt.err))
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"path/filepath"
@@ -55,38 +56,100 @@ func (p *FileProvider) LoadConfiguration(ctx context.Context, path string) (*por
 	}
 
 	// Use Viper for multi-format configuration loading
-	v := viper.New()
+	v := p.newViper()
 	v.SetConfigFile(cleanPath)
 
+	// Read the config file
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	config, err := decodeConfiguration(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Validate the loaded configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// LoadConfigurationFromBytes loads config from an in-memory YAML document.
+// Defaults and EPHEMOS_* environment overrides apply as for files.
+func (p *FileProvider) LoadConfigurationFromBytes(ctx context.Context, data []byte) (*ports.Configuration, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "data",
+			Value:   "",
+			Message: "configuration document cannot be empty",
+		}
+	}
+
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("configuration loading canceled: %w", err)
+		}
+	}
+
+	v := p.newViper()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to read configuration document: %w", err)
+	}
+
+	config, err := decodeConfiguration(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration document: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration document: %w", err)
+	}
+
+	return config, nil
+}
+
+// newViper creates a viper instance with defaults and environment overrides.
+func (p *FileProvider) newViper() *viper.Viper {
+	v := viper.New()
+
 	// Also read from environment (env vars take precedence)
 	v.SetEnvPrefix("EPHEMOS")
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
+	// AutomaticEnv only overrides keys viper already knows; the tls, server, logging
+	// and auth sections and fips_mode are optional, so bind their variables explicitly
+	_ = v.BindEnv("tls.min_version", ports.EnvTLSMinVersion)
+	_ = v.BindEnv("tls.cipher_suites", ports.EnvTLSCipherSuites)
+	_ = v.BindEnv("tls.curve_preferences", ports.EnvTLSCurves)
+	_ = v.BindEnv("fips_mode", ports.EnvFIPSMode)
+	_ = v.BindEnv("server.address", ports.EnvBindAddress)
+	_ = v.BindEnv("logging.level", ports.EnvLogLevel)
+	_ = v.BindEnv("logging.format", ports.EnvLogFormat)
+	_ = v.BindEnv("auth.require", ports.EnvRequireAuth)
+
 	// Set defaults
 	p.setConfigDefaults(v)
+	return v
+}
 
-	// Read the config file
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
-	}
-
-	// Unmarshal configuration
+// decodeConfiguration unmarshals the viper state into a configuration.
+func decodeConfiguration(v *viper.Viper) (*ports.Configuration, error) {
 	var config ports.Configuration
 	if err := v.Unmarshal(&config, viper.DecodeHook(
 		mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
 			mapstructure.StringToSliceHookFunc(","),
+			domain.SocketPathDecodeHook(),
+			domain.ServiceNameDecodeHook(),
 		),
 	)); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		return nil, err
 	}
-
-	// Validate the loaded configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration in file %s: %w", path, err)
-	}
-
 	return &config, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sufield/ephemos/internal/adapters/secondary/config"
 	"github.com/sufield/ephemos/internal/core/ports"
@@ -181,6 +182,128 @@ func TestFileProvider_GetDefaultConfiguration(t *testing.T) {
 	}
 }
 
+func TestFileProvider_LoadConfiguration_Federation(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+service:
+  name: "test-service"
+  domain: "example.org"
+
+federation:
+  refresh_interval: "2m"
+  trust_domains:
+    - trust_domain: "partner.example"
+      bundle_endpoint_url: "https://bundle.partner.example/bundle"
+      profile: "https_web"
+    - trust_domain: "vendor.example"
+      bundle_endpoint_url: "https://spire.vendor.example:8443"
+      profile: "https_spiffe"
+      endpoint_spiffe_id: "spiffe://vendor.example/spire/server"
+      bootstrap_bundle: "/etc/ephemos/vendor.example.pem"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	cfg, err := config.NewFileProvider().LoadConfiguration(t.Context(), configPath)
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+
+	if cfg.Federation == nil {
+		t.Fatal("federation section was not loaded")
+	}
+	if got := cfg.Federation.GetRefreshInterval(); got != 2*time.Minute {
+		t.Errorf("refresh interval = %v, want 2m", got)
+	}
+	if len(cfg.Federation.TrustDomains) != 2 {
+		t.Fatalf("got %d federated trust domains, want 2", len(cfg.Federation.TrustDomains))
+	}
+
+	vendor := cfg.Federation.TrustDomains[1]
+	if vendor.TrustDomain != "vendor.example" ||
+		vendor.Profile != ports.BundleEndpointProfileHTTPSSPIFFE ||
+		vendor.EndpointSPIFFEID != "spiffe://vendor.example/spire/server" ||
+		vendor.BootstrapBundle != "/etc/ephemos/vendor.example.pem" {
+		t.Errorf("unexpected federated trust domain: %+v", vendor)
+	}
+}
+
+func TestFileProvider_LoadConfigurationFromBytes(t *testing.T) {
+	provider := config.NewFileProvider()
+
+	cfg, err := provider.LoadConfigurationFromBytes(t.Context(), []byte(`
+service:
+  name: "inline-service"
+  domain: "example.org"
+`))
+	if err != nil {
+		t.Fatalf("LoadConfigurationFromBytes() error = %v", err)
+	}
+	if cfg.Service.Name.Value() != "inline-service" || cfg.Service.Domain != "example.org" {
+		t.Errorf("unexpected service config: %+v", cfg.Service)
+	}
+	if cfg.Agent == nil || cfg.Agent.SocketPath.IsEmpty() {
+		t.Error("default agent socket path was not applied")
+	}
+
+	for name, data := range map[string]string{
+		"empty document":   "  \n",
+		"malformed yaml":   "service: [",
+		"invalid contents": "service:\n  name: \"\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := provider.LoadConfigurationFromBytes(t.Context(), []byte(data)); err == nil {
+				t.Error("LoadConfigurationFromBytes() expected error")
+			}
+		})
+	}
+}
+
+func TestFileProvider_LoadConfigurationFromBytes_TLS(t *testing.T) {
+	provider := config.NewFileProvider()
+
+	cfg, err := provider.LoadConfigurationFromBytes(t.Context(), []byte(`
+service:
+  name: "tls-service"
+  domain: "example.org"
+tls:
+  min_version: "1.3"
+  curve_preferences: ["X25519MLKEM768", "X25519"]
+`))
+	if err != nil {
+		t.Fatalf("LoadConfigurationFromBytes() error = %v", err)
+	}
+	if cfg.TLS == nil || cfg.TLS.MinVersion != "1.3" || len(cfg.TLS.CurvePreferences) != 2 {
+		t.Errorf("unexpected tls section: %+v", cfg.TLS)
+	}
+
+	t.Run("environment overrides without a tls section", func(t *testing.T) {
+		t.Setenv(ports.EnvTLSMinVersion, "1.2")
+		t.Setenv(ports.EnvTLSCipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+
+		cfg, err := provider.LoadConfigurationFromBytes(t.Context(), []byte("service:\n  name: \"tls-service\"\n"))
+		if err != nil {
+			t.Fatalf("LoadConfigurationFromBytes() error = %v", err)
+		}
+		if cfg.TLS == nil || cfg.TLS.MinVersion != "1.2" || len(cfg.TLS.CipherSuites) != 2 {
+			t.Errorf("unexpected tls section: %+v", cfg.TLS)
+		}
+	})
+
+	t.Run("unknown curve", func(t *testing.T) {
+		_, err := provider.LoadConfigurationFromBytes(t.Context(), []byte(`
+service:
+  name: "tls-service"
+tls:
+  curve_preferences: ["P224"]
+`))
+		if err == nil {
+			t.Error("LoadConfigurationFromBytes() expected error")
+		}
+	})
+}
+
 func TestFileProvider_Integration(t *testing.T) {
 	// Integration test showing typical usage pattern
 	provider := config.NewFileProvider()
@@ -16,6 +16,12 @@ type TrustDomainCapability interface {
 	ShouldSkipCertificateValidation() bool
 }
 
+// FederatedTrustDomainCapability is implemented by capabilities that also know the
+// federated trust domains whose members are authorized by default.
+type FederatedTrustDomainCapability interface {
+	GetAuthorizedFederatedTrustDomains() []string
+}
+
 // TrustDomainAdapter provides trust domain capabilities by adapting from configuration.
 // This adapter encapsulates configuration access and provides a clean interface.
 type TrustDomainAdapter struct {
@@ -34,6 +40,21 @@ func (c *configCapability) GetServiceDomain() string {
 	return c.config.Service.Domain
 }
 
+// GetAuthorizedFederatedTrustDomains returns the federated trust domains that opted in
+// with authorize_members. Federation alone only makes peers verifiable.
+func (c *configCapability) GetAuthorizedFederatedTrustDomains() []string {
+	if c.config == nil || c.config.Federation == nil {
+		return nil
+	}
+	var domains []string
+	for _, td := range c.config.Federation.TrustDomains {
+		if td.AuthorizeMembers {
+			domains = append(domains, td.TrustDomain)
+		}
+	}
+	return domains
+}
+
 func (c *configCapability) ShouldSkipCertificateValidation() bool {
 	if c.config == nil {
 		return false
@@ -82,9 +103,43 @@ func (t *TrustDomainAdapter) CreateDefaultAuthorizer() (tlsconfig.Authorizer, er
 		return nil, fmt.Errorf("invalid trust domain %q: %w", trustDomainStr, err)
 	}
 
-	// Create secure authorizer that only allows members of this trust domain
-	authorizer := tlsconfig.AuthorizeMemberOf(trustDomain)
-	return authorizer, nil
+	federated, err := t.federatedTrustDomains()
+	if err != nil {
+		return nil, err
+	}
+	if len(federated) == 0 {
+		// Create secure authorizer that only allows members of this trust domain
+		return tlsconfig.AuthorizeMemberOf(trustDomain), nil
+	}
+
+	// Members of opted-in federated trust domains are verified against their own bundles
+	allowed := append([]spiffeid.TrustDomain{trustDomain}, federated...)
+	return tlsconfig.AdaptMatcher(func(id spiffeid.ID) error {
+		for _, td := range allowed {
+			if id.MemberOf(td) {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected trust domain %q", id.TrustDomain())
+	}), nil
+}
+
+// federatedTrustDomains parses the authorized federated trust domains of the capability, if any.
+func (t *TrustDomainAdapter) federatedTrustDomains() ([]spiffeid.TrustDomain, error) {
+	capability, ok := t.capability.(FederatedTrustDomainCapability)
+	if !ok {
+		return nil, nil
+	}
+
+	var domains []spiffeid.TrustDomain
+	for _, name := range capability.GetAuthorizedFederatedTrustDomains() {
+		td, err := spiffeid.TrustDomainFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid federated trust domain %q: %w", name, err)
+		}
+		domains = append(domains, td)
+	}
+	return domains, nil
 }
 
 // IsConfigured returns true if a trust domain has been properly configured.
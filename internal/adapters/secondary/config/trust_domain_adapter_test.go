@@ -0,0 +1,46 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/config"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestTrustDomainAdapter_CreateDefaultAuthorizer_Federation(t *testing.T) {
+	cfg := &ports.Configuration{
+		Service: ports.ServiceConfig{Domain: "example.org"},
+		Federation: &ports.FederationConfig{
+			TrustDomains: []ports.FederatedTrustDomainConfig{
+				{TrustDomain: "partner.example", BundleEndpointURL: "https://bundle.partner.example", Profile: ports.BundleEndpointProfileHTTPSWeb},
+				{TrustDomain: "vendor.example", BundleEndpointURL: "https://bundle.vendor.example", Profile: ports.BundleEndpointProfileHTTPSWeb, AuthorizeMembers: true},
+			},
+		},
+	}
+
+	authorizer, err := config.NewTrustDomainAdapter(cfg).CreateDefaultAuthorizer()
+	if err != nil {
+		t.Fatalf("CreateDefaultAuthorizer() error = %v", err)
+	}
+
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"spiffe://example.org/client", false},
+		{"spiffe://vendor.example/client", false},
+		// Federation without authorize_members only makes the peer verifiable
+		{"spiffe://partner.example/client", true},
+		{"spiffe://unknown.example/client", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			err := authorizer(spiffeid.RequireFromString(tt.id), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("authorizer(%s) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
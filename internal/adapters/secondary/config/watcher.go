@@ -0,0 +1,397 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/filewatch"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// DefaultReloadDebounce is how long the watcher waits after the last file event
+// before reloading, so editors that write in several steps trigger one reload.
+const DefaultReloadDebounce = 200 * time.Millisecond
+
+// Reload results reported to ReloadMetrics.
+const (
+	ReloadResultApplied   = "applied"
+	ReloadResultUnchanged = "unchanged"
+	ReloadResultRejected  = "rejected"
+)
+
+// ReloadMetrics records the outcome of configuration reloads.
+type ReloadMetrics interface {
+	RecordConfigReload(result string)
+}
+
+// WatchingProviderConfig provides configuration for the watching provider.
+type WatchingProviderConfig struct {
+	// Path is the configuration file to watch. Required.
+	Path string
+	// Debounce delays reloads after file events. Default: 200ms.
+	Debounce time.Duration
+	// Metrics records reload outcomes. Optional.
+	Metrics ReloadMetrics
+	// Logger logs reloads. Default: slog.Default().
+	Logger *slog.Logger
+}
+
+// WatchingProvider keeps a configuration file loaded and re-reads it when it changes.
+//
+// Every edit is validated with Configuration.Validate, and an edit that would make a
+// production-ready configuration fail IsProductionReady is rejected as well. Rejected
+// edits keep the last good configuration. Only sections that are safe to change at
+// runtime are applied: service.cache, health, the federated trust domains and the
+// revocation entries; servers subscribe to them through factory.WithConfigWatcher.
+// Changes to other sections, including policy, tls, fips_mode, keys, server,
+// logging, auth, transport, identity and the revocation file, are reported as
+// requiring a restart. The SVID files named by the identity section are
+// watched by the identity provider itself.
+type WatchingProvider struct {
+	provider *FileProvider
+	path     string
+	debounce time.Duration
+	metrics  ReloadMetrics
+	logger   *slog.Logger
+
+	// productionReady records whether the initial configuration passed
+	// IsProductionReady; reloads must not lose that property.
+	productionReady bool
+
+	mu          sync.RWMutex
+	current     *ports.Configuration
+	subscribers map[chan ports.ConfigChangeEvent]struct{}
+	closed      bool
+
+	reloadMu sync.Mutex
+	watcher  *filewatch.Watcher
+}
+
+var _ ports.ConfigWatcherPort = (*WatchingProvider)(nil)
+
+// NewWatchingProvider loads the configuration file. The file must be valid.
+// Watching starts with Start.
+func NewWatchingProvider(ctx context.Context, config WatchingProviderConfig) (*WatchingProvider, error) {
+	provider := NewFileProvider()
+	initial, err := provider.LoadConfiguration(ctx, config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	absPath, err := filepath.Abs(filepath.Clean(config.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config file path: %w", err)
+	}
+
+	debounce := config.Debounce
+	if debounce <= 0 {
+		debounce = DefaultReloadDebounce
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &WatchingProvider{
+		provider:        provider,
+		path:            absPath,
+		debounce:        debounce,
+		metrics:         config.Metrics,
+		logger:          logger,
+		productionReady: initial.IsProductionReady() == nil,
+		current:         initial,
+		subscribers:     make(map[chan ports.ConfigChangeEvent]struct{}),
+	}, nil
+}
+
+// SetLogger replaces the logger of reloads, for loggers built from the loaded
+// configuration. Call it before Start.
+func (w *WatchingProvider) SetLogger(logger *slog.Logger) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.logger = logger
+}
+
+// Start watches the configuration file until Close is called.
+func (w *WatchingProvider) Start() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("watching provider is closed")
+	}
+	if w.watcher != nil {
+		return fmt.Errorf("watching provider already started")
+	}
+
+	watcher, err := filewatch.Start([]string{w.path}, w.debounce,
+		func() {
+			// Errors are logged and counted by Reload
+			_ = w.Reload(context.Background())
+		},
+		func(err error) {
+			w.logger.Warn("configuration file watcher error", "path", w.path, "error", err)
+		})
+	if err != nil {
+		return err
+	}
+	w.watcher = watcher
+	return nil
+}
+
+// Reload re-reads the configuration file and applies its safe changes.
+// An invalid file is rejected and the last good configuration is kept.
+func (w *WatchingProvider) Reload(ctx context.Context) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next, err := w.provider.LoadConfiguration(ctx, w.path)
+	if err != nil {
+		return w.reject(err)
+	}
+
+	if w.productionReady {
+		if err := next.IsProductionReady(); err != nil {
+			return w.reject(fmt.Errorf("configuration is no longer production ready: %w", err))
+		}
+	}
+
+	previous := w.Current()
+	merged, changed, restartRequired := applySafeChanges(previous, next)
+	if len(restartRequired) > 0 {
+		w.logger.Warn("configuration changes require a restart and were not applied",
+			"path", w.path, "fields", restartRequired)
+	}
+
+	if len(changed) == 0 {
+		w.record(ReloadResultUnchanged)
+		return nil
+	}
+
+	if err := merged.Validate(); err != nil {
+		return w.reject(err)
+	}
+
+	event := ports.ConfigChangeEvent{
+		Previous:        previous,
+		Current:         merged,
+		ChangedFields:   changed,
+		RestartRequired: restartRequired,
+		Time:            time.Now(),
+	}
+
+	w.mu.Lock()
+	w.current = merged
+	for ch := range w.subscribers {
+		publish(ch, event)
+	}
+	w.mu.Unlock()
+
+	w.record(ReloadResultApplied)
+	w.logger.Info("configuration reloaded", "path", w.path, "changed", changed)
+	return nil
+}
+
+// reject logs and counts a rejected reload.
+func (w *WatchingProvider) reject(err error) error {
+	w.record(ReloadResultRejected)
+	w.logger.Error("configuration reload rejected, keeping last good configuration",
+		"path", w.path, "error", err)
+	return fmt.Errorf("configuration reload rejected: %w", err)
+}
+
+func (w *WatchingProvider) record(result string) {
+	if w.metrics != nil {
+		w.metrics.RecordConfigReload(result)
+	}
+}
+
+// publish delivers the event, replacing an undelivered older event if the
+// subscriber is behind. Callers hold w.mu, so there is a single sender.
+func publish(ch chan ports.ConfigChangeEvent, event ports.ConfigChangeEvent) {
+	select {
+	case ch <- event:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- event
+}
+
+// Current returns the configuration in effect.
+func (w *WatchingProvider) Current() *ports.Configuration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe returns a channel of applied changes and a function that ends the subscription.
+func (w *WatchingProvider) Subscribe() (<-chan ports.ConfigChangeEvent, func()) {
+	ch := make(chan ports.ConfigChangeEvent, 1)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	w.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if _, ok := w.subscribers[ch]; ok {
+				delete(w.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Close stops watching and closes all subscription channels.
+// It is safe to call Close multiple times.
+func (w *WatchingProvider) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	watcher := w.watcher
+	for ch := range w.subscribers {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
+	w.mu.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
+
+// authorizedMembers returns the federated trust domains whose members are authorized
+// by default, in configuration order.
+func authorizedMembers(federation ports.FederationConfig) []string {
+	var domains []string
+	for _, entry := range federation.TrustDomains {
+		if entry.AuthorizeMembers {
+			domains = append(domains, entry.TrustDomain)
+		}
+	}
+	return domains
+}
+
+// applySafeChanges returns previous with the runtime-safe sections of next applied,
+// along with the applied sections and the changed sections that need a restart.
+func applySafeChanges(previous, next *ports.Configuration) (*ports.Configuration, []string, []string) {
+	merged := *previous
+	var changed, restartRequired []string
+
+	if !reflect.DeepEqual(previous.Service.Cache, next.Service.Cache) {
+		merged.Service.Cache = next.Service.Cache
+		changed = append(changed, "service.cache")
+	}
+
+	if !reflect.DeepEqual(previous.Health, next.Health) {
+		merged.Health = next.Health
+		changed = append(changed, "health")
+	}
+
+	var prevFed, nextFed ports.FederationConfig
+	if previous.Federation != nil {
+		prevFed = *previous.Federation
+	}
+	if next.Federation != nil {
+		nextFed = *next.Federation
+	}
+	if prevFed.RefreshInterval != nextFed.RefreshInterval ||
+		!reflect.DeepEqual(prevFed.TrustDomains, nextFed.TrustDomains) {
+		federation := prevFed
+		federation.RefreshInterval = nextFed.RefreshInterval
+		federation.TrustDomains = nextFed.TrustDomains
+		merged.Federation = &federation
+		changed = append(changed, "federation.trust_domains")
+		// The default authorizer is built once at startup
+		if !slices.Equal(authorizedMembers(prevFed), authorizedMembers(nextFed)) {
+			restartRequired = append(restartRequired, "federation.authorize_members")
+		}
+	}
+
+	var prevRev, nextRev ports.RevocationConfig
+	if previous.Revocation != nil {
+		prevRev = *previous.Revocation
+	}
+	if next.Revocation != nil {
+		nextRev = *next.Revocation
+	}
+	if !reflect.DeepEqual(prevRev.Entries(), nextRev.Entries()) {
+		revocation := prevRev
+		revocation.SPIFFEIDs = nextRev.SPIFFEIDs
+		revocation.IDPrefixes = nextRev.IDPrefixes
+		revocation.Serials = nextRev.Serials
+		revocation.SPKIHashes = nextRev.SPKIHashes
+		merged.Revocation = &revocation
+		changed = append(changed, "revocation")
+	}
+
+	if previous.Service.Name != next.Service.Name {
+		restartRequired = append(restartRequired, "service.name")
+	}
+	if previous.Service.Domain != next.Service.Domain {
+		restartRequired = append(restartRequired, "service.domain")
+	}
+	if !reflect.DeepEqual(previous.Agent, next.Agent) {
+		restartRequired = append(restartRequired, "agent")
+	}
+	if !reflect.DeepEqual(previous.Identity, next.Identity) {
+		restartRequired = append(restartRequired, "identity")
+	}
+	if !reflect.DeepEqual(prevFed.BundleEndpoint, nextFed.BundleEndpoint) {
+		restartRequired = append(restartRequired, "federation.bundle_endpoint")
+	}
+	if !reflect.DeepEqual(previous.Policy, next.Policy) {
+		restartRequired = append(restartRequired, "policy")
+	}
+	if !reflect.DeepEqual(previous.TLS, next.TLS) {
+		restartRequired = append(restartRequired, "tls")
+	}
+	if previous.FIPSMode != next.FIPSMode {
+		restartRequired = append(restartRequired, "fips_mode")
+	}
+	if !reflect.DeepEqual(previous.Keys, next.Keys) {
+		restartRequired = append(restartRequired, "keys")
+	}
+	if !reflect.DeepEqual(previous.Server, next.Server) {
+		restartRequired = append(restartRequired, "server")
+	}
+	if !reflect.DeepEqual(previous.Logging, next.Logging) {
+		restartRequired = append(restartRequired, "logging")
+	}
+	if !reflect.DeepEqual(previous.Auth, next.Auth) {
+		restartRequired = append(restartRequired, "auth")
+	}
+	if !reflect.DeepEqual(previous.Transport, next.Transport) {
+		restartRequired = append(restartRequired, "transport")
+	}
+	if prevRev.File != nextRev.File {
+		restartRequired = append(restartRequired, "revocation.file")
+	}
+	if prevRev.EnforceExisting != nextRev.EnforceExisting {
+		restartRequired = append(restartRequired, "revocation.enforce_existing")
+	}
+
+	return &merged, changed, restartRequired
+}
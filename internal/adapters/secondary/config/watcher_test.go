@@ -0,0 +1,166 @@
+package config_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/config"
+)
+
+// reloadRecorder counts reload results.
+type reloadRecorder struct {
+	mu      sync.Mutex
+	results map[string]int
+}
+
+func (r *reloadRecorder) RecordConfigReload(result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.results == nil {
+		r.results = make(map[string]int)
+	}
+	r.results[result]++
+}
+
+func (r *reloadRecorder) count(result string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.results[result]
+}
+
+const watchedConfig = `
+service:
+  name: "watched-service"
+  domain: "%s"
+  cache:
+    ttl_minutes: %d
+    proactive_refresh_minutes: 5
+`
+
+func writeWatchedConfig(t *testing.T, path, trustDomain string, ttl int) {
+	t.Helper()
+	content := fmt.Sprintf(watchedConfig, trustDomain, ttl)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newWatchingProvider(t *testing.T, trustDomain string) (*config.WatchingProvider, string, *reloadRecorder) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeWatchedConfig(t, path, trustDomain, 30)
+
+	metrics := &reloadRecorder{}
+	watcher, err := config.NewWatchingProvider(context.Background(), config.WatchingProviderConfig{
+		Path:     path,
+		Debounce: 10 * time.Millisecond,
+		Metrics:  metrics,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = watcher.Close() })
+	return watcher, path, metrics
+}
+
+func TestWatchingProvider_AppliesFileChanges(t *testing.T) {
+	watcher, path, metrics := newWatchingProvider(t, "example.org")
+	events, unsubscribe := watcher.Subscribe()
+	defer unsubscribe()
+	require.NoError(t, watcher.Start())
+
+	writeWatchedConfig(t, path, "example.org", 45)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, []string{"service.cache"}, event.ChangedFields)
+		assert.Equal(t, 30, event.Previous.Service.Cache.TTLMinutes)
+		assert.Equal(t, 45, event.Current.Service.Cache.TTLMinutes)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change event after editing the configuration file")
+	}
+
+	assert.Equal(t, 45, watcher.Current().Service.Cache.TTLMinutes)
+	assert.Equal(t, 1, metrics.count(config.ReloadResultApplied))
+}
+
+func TestWatchingProvider_RejectsInvalidEdits(t *testing.T) {
+	watcher, path, metrics := newWatchingProvider(t, "example.org")
+	before := watcher.Current()
+
+	require.NoError(t, os.WriteFile(path, []byte("service: ["), 0o600))
+	assert.Error(t, watcher.Reload(context.Background()))
+
+	// Proactive refresh must stay below the TTL
+	writeWatchedConfig(t, path, "example.org", 2)
+	assert.Error(t, watcher.Reload(context.Background()))
+
+	assert.Same(t, before, watcher.Current())
+	assert.Equal(t, 2, metrics.count(config.ReloadResultRejected))
+}
+
+func TestWatchingProvider_RestartRequiredFieldsAreNotApplied(t *testing.T) {
+	watcher, path, metrics := newWatchingProvider(t, "example.org")
+	events, unsubscribe := watcher.Subscribe()
+	defer unsubscribe()
+
+	writeWatchedConfig(t, path, "other.example.org", 30)
+	require.NoError(t, watcher.Reload(context.Background()))
+	assert.Equal(t, "example.org", watcher.Current().Service.Domain)
+	assert.Equal(t, 1, metrics.count(config.ReloadResultUnchanged))
+
+	writeWatchedConfig(t, path, "other.example.org", 40)
+	require.NoError(t, watcher.Reload(context.Background()))
+	event := <-events
+	assert.Equal(t, []string{"service.cache"}, event.ChangedFields)
+	assert.Equal(t, []string{"service.domain"}, event.RestartRequired)
+	assert.Equal(t, "example.org", event.Current.Service.Domain)
+}
+
+func TestWatchingProvider_AppliesRevocationEntries(t *testing.T) {
+	watcher, path, _ := newWatchingProvider(t, "example.org")
+	events, unsubscribe := watcher.Subscribe()
+	defer unsubscribe()
+
+	content := fmt.Sprintf(watchedConfig, "example.org", 30) + `
+revocation:
+  spiffe_ids: ["spiffe://example.org/compromised"]
+  file: "/etc/ephemos/revoked.yaml"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, watcher.Reload(context.Background()))
+
+	event := <-events
+	assert.Equal(t, []string{"revocation"}, event.ChangedFields)
+	assert.Equal(t, []string{"revocation.file"}, event.RestartRequired)
+	require.NotNil(t, event.Current.Revocation)
+	assert.Equal(t, []string{"spiffe://example.org/compromised"}, event.Current.Revocation.SPIFFEIDs)
+	assert.Empty(t, event.Current.Revocation.File)
+}
+
+func TestWatchingProvider_KeepsProductionReadiness(t *testing.T) {
+	watcher, path, metrics := newWatchingProvider(t, "prod.company.internal")
+	require.NoError(t, watcher.Current().IsProductionReady())
+
+	t.Setenv("EPHEMOS_DEBUG_ENABLED", "true")
+	writeWatchedConfig(t, path, "prod.company.internal", 45)
+	assert.Error(t, watcher.Reload(context.Background()))
+	assert.Equal(t, 30, watcher.Current().Service.Cache.TTLMinutes)
+	assert.Equal(t, 1, metrics.count(config.ReloadResultRejected))
+}
+
+func TestWatchingProvider_CloseEndsSubscriptions(t *testing.T) {
+	watcher, _, _ := newWatchingProvider(t, "example.org")
+	events, _ := watcher.Subscribe()
+	require.NoError(t, watcher.Start())
+
+	require.NoError(t, watcher.Close())
+	require.NoError(t, watcher.Close())
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
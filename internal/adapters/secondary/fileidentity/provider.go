@@ -0,0 +1,381 @@
+// Package fileidentity provides an IdentityProvider that reads the X.509 SVID and
+// trust bundle from PEM files, as written by spiffe-helper, cert-manager's
+// csi-driver-spiffe or a Vault agent, and follows changes to them.
+package fileidentity
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/filewatch"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// DefaultReloadDebounce is how long the provider waits after the last file event
+// before reloading. Sidecars write the certificate, key and bundle one after the
+// other, and the files are only consistent once all three are written.
+const DefaultReloadDebounce = 500 * time.Millisecond
+
+// Config provides configuration for the file identity provider.
+type Config struct {
+	// CertFile holds the SVID certificate followed by its intermediates. Required.
+	CertFile string
+	// KeyFile holds the SVID private key in PKCS#8, PKCS#1 or SEC 1 form. Required.
+	KeyFile string
+	// BundleFile holds the CA certificates of the trust domain. Required.
+	BundleFile string
+	// KeyPolicy restricts the key algorithms of the SVID and its chain. Optional.
+	KeyPolicy *domain.KeyPolicy
+	// FIPS rejects SVIDs and bundles with algorithms that are not FIPS 140-3 approved.
+	FIPS bool
+	// Debounce delays reloads after file events. Default: 500ms.
+	Debounce time.Duration
+	Logger   *slog.Logger
+}
+
+// Provider serves an SVID and trust bundle loaded from PEM files.
+//
+// Every load is validated with domain.Certificate.Validate against the bundle, so the
+// certificate, key and bundle must match. Files that fail validation, e.g. because a
+// sidecar has written the new certificate but not yet the new key, are rejected and
+// the last good identity is kept.
+//
+// Provider implements x509svid.Source and x509bundle.Source, so TLS configs built
+// from it pick up rotated files on the next handshake.
+type Provider struct {
+	certFile   string
+	keyFile    string
+	bundleFile string
+	keyPolicy  *domain.KeyPolicy
+	fips       bool
+	debounce   time.Duration
+	logger     *slog.Logger
+
+	mu       sync.RWMutex
+	svid     *x509svid.SVID
+	bundle   *x509bundle.Bundle
+	watchers map[chan *x509svid.SVID]struct{}
+	closed   bool
+	closing  chan struct{}
+
+	reloadMu sync.Mutex
+	watcher  *filewatch.Watcher
+}
+
+var (
+	_ ports.IdentityProvider = (*Provider)(nil)
+	_ x509svid.Source        = (*Provider)(nil)
+	_ x509bundle.Source      = (*Provider)(nil)
+)
+
+// New loads the SVID and bundle. The files must exist and be valid.
+// Watching starts with Start.
+func New(config Config) (*Provider, error) {
+	if config.CertFile == "" || config.KeyFile == "" || config.BundleFile == "" {
+		return nil, fmt.Errorf("certificate, key and bundle files are required")
+	}
+
+	debounce := config.Debounce
+	if debounce <= 0 {
+		debounce = DefaultReloadDebounce
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	p := &Provider{
+		certFile:   filepath.Clean(config.CertFile),
+		keyFile:    filepath.Clean(config.KeyFile),
+		bundleFile: filepath.Clean(config.BundleFile),
+		keyPolicy:  config.KeyPolicy,
+		fips:       config.FIPS,
+		debounce:   debounce,
+		logger:     logger,
+		watchers:   make(map[chan *x509svid.SVID]struct{}),
+		closing:    make(chan struct{}),
+	}
+
+	svid, bundle, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	p.svid, p.bundle = svid, bundle
+	return p, nil
+}
+
+// load reads and validates the files.
+func (p *Provider) load() (*x509svid.SVID, *x509bundle.Bundle, error) {
+	certPEM, err := os.ReadFile(p.certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SVID certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(p.keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SVID key: %w", err)
+	}
+
+	certDER, err := decodeCertificates(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SVID certificate file %s: %w", p.certFile, err)
+	}
+	keyDER, err := decodePrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SVID key file %s: %w", p.keyFile, err)
+	}
+	svid, err := x509svid.ParseRaw(certDER, keyDER)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid SVID: %w", err)
+	}
+
+	bundle, err := x509bundle.Load(svid.ID.TrustDomain(), p.bundleFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid trust bundle file %s: %w", p.bundleFile, err)
+	}
+	trustBundle, err := domain.NewTrustBundle(bundle.X509Authorities())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid trust bundle file %s: %w", p.bundleFile, err)
+	}
+
+	cert := &domain.Certificate{
+		Cert:       svid.Certificates[0],
+		PrivateKey: svid.PrivateKey,
+		Chain:      svid.Certificates[1:],
+	}
+	if err := cert.Validate(domain.CertValidationOptions{
+		TrustBundle: trustBundle,
+		KeyPolicy:   p.keyPolicy,
+		FIPS:        p.fips,
+		Logger:      p.logger,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("SVID validation failed: %w", err)
+	}
+	return svid, bundle, nil
+}
+
+// decodeCertificates returns the concatenated DER of the CERTIFICATE blocks.
+func decodeCertificates(data []byte) ([]byte, error) {
+	var der []byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			der = append(der, block.Bytes...)
+		}
+	}
+	if len(der) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return der, nil
+}
+
+// decodePrivateKey returns the first private key in PKCS#8 DER form.
+func decodePrivateKey(data []byte) ([]byte, error) {
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		var key any
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			return block.Bytes, nil
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(key)
+	}
+	return nil, fmt.Errorf("no PEM private key found")
+}
+
+// Start watches the files until Close is called. Atomic replaces and Kubernetes
+// volume symlink swaps are picked up, see filewatch.Watcher.
+func (p *Provider) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("file identity provider is closed")
+	}
+	if p.watcher != nil {
+		return fmt.Errorf("file identity provider already started")
+	}
+
+	// Errors are logged by Reload
+	files := []string{p.certFile, p.keyFile, p.bundleFile}
+	watcher, err := filewatch.Start(files, p.debounce, func() { _ = p.Reload() }, func(err error) {
+		p.logger.Warn("identity file watcher error", "error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch identity files: %w", err)
+	}
+	p.watcher = watcher
+	return nil
+}
+
+// Reload re-reads the files. Invalid files are rejected and the last good identity
+// is kept. Watchers are sent the SVID if the SVID or the bundle changed.
+func (p *Provider) Reload() error {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	svid, bundle, err := p.load()
+	if err != nil {
+		p.logger.Error("identity files rejected, keeping last good SVID",
+			"cert_file", p.certFile, "error", err)
+		return fmt.Errorf("identity reload rejected: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if bytes.Equal(p.svid.Certificates[0].Raw, svid.Certificates[0].Raw) && p.bundle.Equal(bundle) {
+		return nil
+	}
+	p.svid, p.bundle = svid, bundle
+
+	p.logger.Info("identity files reloaded",
+		"spiffe_id", svid.ID.String(),
+		"expires_at", svid.Certificates[0].NotAfter)
+
+	for ch := range p.watchers {
+		// Send SVID update to channel (non-blocking)
+		select {
+		case ch <- svid:
+		default:
+			p.logger.Warn("SVID update channel full, dropping update")
+		}
+	}
+	return nil
+}
+
+// RefreshIdentity re-reads the files.
+func (p *Provider) RefreshIdentity(_ context.Context) error {
+	return p.Reload()
+}
+
+// WatchIdentityChanges returns a channel that receives the SVID after the files
+// change. The channel is closed when ctx is done or the provider is closed.
+func (p *Provider) WatchIdentityChanges(ctx context.Context) (<-chan *x509svid.SVID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, fmt.Errorf("file identity provider is closed")
+	}
+
+	ch := make(chan *x509svid.SVID, 10) // Buffer for updates
+	p.watchers[ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.closing:
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.watchers[ch]; ok {
+			delete(p.watchers, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// GetServiceIdentity returns the SPIFFE ID of the current SVID.
+func (p *Provider) GetServiceIdentity() (spiffeid.ID, error) {
+	svid, err := p.GetSVID()
+	if err != nil {
+		return spiffeid.ID{}, err
+	}
+	return svid.ID, nil
+}
+
+// GetCertificate returns the current SVID as a certificate.
+func (p *Provider) GetCertificate() (*domain.Certificate, error) {
+	svid, err := p.GetSVID()
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Certificate{
+		Cert:       svid.Certificates[0],
+		PrivateKey: svid.PrivateKey,
+		Chain:      svid.Certificates[1:],
+	}, nil
+}
+
+// GetTrustBundle returns the current trust bundle.
+func (p *Provider) GetTrustBundle() (*x509bundle.Bundle, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+	return p.bundle, nil
+}
+
+// GetSVID returns the current SVID.
+func (p *Provider) GetSVID() (*x509svid.SVID, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+	return p.svid, nil
+}
+
+// GetX509SVID implements x509svid.Source.
+func (p *Provider) GetX509SVID() (*x509svid.SVID, error) {
+	return p.GetSVID()
+}
+
+// GetX509BundleForTrustDomain implements x509bundle.Source. Only the bundle of the
+// SVID's trust domain is available.
+func (p *Provider) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	bundle, err := p.GetTrustBundle()
+	if err != nil {
+		return nil, err
+	}
+	return bundle.GetX509BundleForTrustDomain(trustDomain)
+}
+
+// Close stops watching and closes all watcher channels.
+// It is safe to call Close multiple times.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.closing)
+	watcher := p.watcher
+	for ch := range p.watchers {
+		delete(p.watchers, ch)
+		close(ch)
+	}
+	p.mu.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
@@ -0,0 +1,211 @@
+package fileidentity_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/fileidentity"
+)
+
+// testCA issues SVIDs for example.org.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example.org CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns the PEM certificate and SEC 1 key of an SVID.
+func (ca *testCA) issue(t *testing.T, serial int64, id string) ([]byte, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key
+}
+
+// identityFiles are the PEM files of an SVID in a temporary directory.
+type identityFiles struct {
+	cert, key, bundle string
+}
+
+func newIdentityFiles(t *testing.T) identityFiles {
+	dir := t.TempDir()
+	return identityFiles{
+		cert:   filepath.Join(dir, "svid.pem"),
+		key:    filepath.Join(dir, "svid_key.pem"),
+		bundle: filepath.Join(dir, "bundle.pem"),
+	}
+}
+
+func (f identityFiles) write(t *testing.T, ca *testCA, certPEM []byte, key *ecdsa.PrivateKey) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(f.cert, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(f.key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(f.bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600))
+}
+
+func (f identityFiles) config() fileidentity.Config {
+	return fileidentity.Config{
+		CertFile:   f.cert,
+		KeyFile:    f.key,
+		BundleFile: f.bundle,
+		Debounce:   10 * time.Millisecond,
+	}
+}
+
+func TestProvider_LoadsFiles(t *testing.T) {
+	ca := newTestCA(t)
+	files := newIdentityFiles(t)
+	certPEM, key := ca.issue(t, 10, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, key)
+
+	provider, err := fileidentity.New(files.config())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Close() })
+
+	id, err := provider.GetServiceIdentity()
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/api", id.String())
+
+	cert, err := provider.GetCertificate()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), cert.Cert.SerialNumber.Int64())
+
+	bundle, err := provider.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("example.org"))
+	require.NoError(t, err)
+	assert.True(t, bundle.HasX509Authority(ca.cert))
+
+	_, err = provider.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("other.org"))
+	assert.Error(t, err)
+}
+
+func TestProvider_RejectsInvalidFiles(t *testing.T) {
+	ca := newTestCA(t)
+	files := newIdentityFiles(t)
+
+	_, err := fileidentity.New(files.config())
+	assert.Error(t, err, "missing files")
+
+	certPEM, _ := ca.issue(t, 10, "spiffe://example.org/api")
+	_, otherKey := ca.issue(t, 11, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, otherKey)
+	_, err = fileidentity.New(files.config())
+	assert.Error(t, err, "key does not match the certificate")
+
+	certPEM, key := newTestCA(t).issue(t, 12, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, key)
+	_, err = fileidentity.New(files.config())
+	assert.Error(t, err, "certificate not issued by the bundle")
+}
+
+func TestProvider_WatchesFiles(t *testing.T) {
+	ca := newTestCA(t)
+	files := newIdentityFiles(t)
+	certPEM, key := ca.issue(t, 10, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, key)
+
+	provider, err := fileidentity.New(files.config())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := provider.WatchIdentityChanges(ctx)
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+
+	certPEM, key = ca.issue(t, 20, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, key)
+
+	select {
+	case svid := <-updates:
+		assert.Equal(t, int64(20), svid.Certificates[0].SerialNumber.Int64())
+	case <-time.After(5 * time.Second):
+		t.Fatal("rotated SVID was not published")
+	}
+	svid, err := provider.GetX509SVID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), svid.Certificates[0].SerialNumber.Int64())
+
+	// A certificate written without its key is rejected until the key follows
+	certPEM, _ = ca.issue(t, 30, "spiffe://example.org/api")
+	require.NoError(t, os.WriteFile(files.cert, certPEM, 0o600))
+	assert.Error(t, provider.Reload())
+	svid, err = provider.GetX509SVID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), svid.Certificates[0].SerialNumber.Int64())
+
+	cancel()
+	for range updates {
+	}
+}
+
+func TestProvider_CloseEndsWatches(t *testing.T) {
+	ca := newTestCA(t)
+	files := newIdentityFiles(t)
+	certPEM, key := ca.issue(t, 10, "spiffe://example.org/api")
+	files.write(t, ca, certPEM, key)
+
+	provider, err := fileidentity.New(files.config())
+	require.NoError(t, err)
+	updates, err := provider.WatchIdentityChanges(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+
+	require.NoError(t, provider.Close())
+	require.NoError(t, provider.Close())
+
+	_, ok := <-updates
+	assert.False(t, ok)
+	_, err = provider.GetSVID()
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+// Package filewatch reports settled changes to files that adapters reload at runtime.
+package filewatch
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher calls a function once changes to a set of files have settled.
+//
+// The parent directories are watched rather than the files, so atomic replaces by
+// editors and Kubernetes volume symlink swaps are picked up. Events for other files
+// in those directories are ignored, except when they change what a watched path
+// resolves to, which is how a ConfigMap "..data" symlink swap shows up.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	files    map[string]string // watched path -> resolved path
+	debounce time.Duration
+	onChange func()
+	onError  func(error)
+	done     chan struct{}
+}
+
+// Start watches files until Close is called. onChange runs on the watcher's goroutine
+// after events for the files have been quiet for debounce; onError receives watcher
+// errors and may be nil.
+func Start(files []string, debounce time.Duration, onChange func(), onError func(error)) (*Watcher, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to watch")
+	}
+	if onChange == nil {
+		return nil, fmt.Errorf("change callback is required")
+	}
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	w := &Watcher{
+		watcher:  watcher,
+		files:    make(map[string]string, len(files)),
+		debounce: debounce,
+		onChange: onChange,
+		onError:  onError,
+		done:     make(chan struct{}),
+	}
+
+	watched := make(map[string]bool)
+	for _, file := range files {
+		path, err := filepath.Abs(filepath.Clean(file))
+		if err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to resolve %s: %w", file, err)
+		}
+		w.files[path] = resolve(path)
+
+		dir := filepath.Dir(path)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// watch runs onChange after relevant events settle for the debounce period.
+func (w *Watcher) watch() {
+	defer close(w.done)
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			if w.relevant(event) {
+				timer.Reset(w.debounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.onError(err)
+		case <-timer.C:
+			w.onChange()
+		}
+	}
+}
+
+// relevant reports whether the event touches a watched file or changes what one
+// resolves to.
+func (w *Watcher) relevant(event fsnotify.Event) bool {
+	name := filepath.Clean(event.Name)
+	relevant := false
+	for path, resolved := range w.files {
+		if name == path {
+			relevant = true
+		}
+		if current := resolve(path); current != resolved {
+			w.files[path] = current
+			relevant = true
+		}
+	}
+	return relevant
+}
+
+// resolve returns the path with symlinks evaluated, or the path itself if it
+// cannot be resolved, e.g. while an editor replaces it.
+func resolve(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// Close stops watching and waits for a running onChange to return.
+// It must not be called from onChange.
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
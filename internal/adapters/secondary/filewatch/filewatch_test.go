@@ -0,0 +1,79 @@
+package filewatch_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/filewatch"
+)
+
+const testDebounce = 20 * time.Millisecond
+
+func startCounting(t *testing.T, files ...string) *atomic.Int32 {
+	t.Helper()
+	var changes atomic.Int32
+	w, err := filewatch.Start(files, testDebounce, func() { changes.Add(1) }, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = w.Close() })
+	return &changes
+}
+
+func TestWatcher_IgnoresOtherFilesInDirectory(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(target, []byte("a"), 0o600))
+	changes := startCounting(t, target)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("b"), 0o600))
+	time.Sleep(10 * testDebounce)
+	assert.Zero(t, changes.Load(), "an unrelated file triggered a change")
+
+	require.NoError(t, os.WriteFile(target, []byte("c"), 0o600))
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, 2*time.Second, testDebounce)
+}
+
+func TestWatcher_DebouncesWrites(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(target, []byte("a"), 0o600))
+	changes := startCounting(t, target)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, os.WriteFile(target, []byte{byte('a' + i)}, 0o600))
+	}
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, 2*time.Second, testDebounce)
+	time.Sleep(10 * testDebounce)
+	assert.Equal(t, int32(1), changes.Load())
+}
+
+func TestWatcher_FollowsSymlinkSwap(t *testing.T) {
+	// Kubernetes mounts ConfigMaps as config.yaml -> ..data/config.yaml and
+	// swaps the ..data symlink to publish a new version
+	dir := t.TempDir()
+	for _, version := range []string{"v1", "v2"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "config.yaml"), []byte(version), 0o600))
+	}
+	require.NoError(t, os.Symlink("v1", filepath.Join(dir, "..data")))
+	target := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), target))
+	changes := startCounting(t, target)
+
+	require.NoError(t, os.Symlink("v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, 2*time.Second, testDebounce)
+}
+
+func TestStart_Errors(t *testing.T) {
+	_, err := filewatch.Start(nil, testDebounce, func() {}, nil)
+	assert.Error(t, err)
+
+	_, err = filewatch.Start([]string{filepath.Join(t.TempDir(), "missing", "config.yaml")}, testDebounce, func() {}, nil)
+	assert.Error(t, err)
+}
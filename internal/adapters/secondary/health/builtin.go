@@ -0,0 +1,78 @@
+package health
+
+import (
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// BuiltinSources provides what the built-in checkers inspect.
+type BuiltinSources struct {
+	// SVIDs provides the workload's current SVID, for the svid check.
+	SVIDs x509svid.Source
+	// Bundles provides the trust bundles, for the bundle check.
+	Bundles x509bundle.Source
+	// TrustDomain is the local trust domain whose bundle is checked.
+	TrustDomain spiffeid.TrustDomain
+	// SocketPath is the Workload API socket called when health.workload_api
+	// does not name one.
+	SocketPath string
+}
+
+// NewBuiltinCheckers creates the built-in checkers enabled in the svid, bundle and
+// workload_api subsections of the health configuration.
+func NewBuiltinCheckers(config *ports.HealthConfig, sources BuiltinSources) ([]ports.HealthCheckerPort, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var checkers []ports.HealthCheckerPort
+	if config.SVID != nil && config.SVID.Enabled {
+		checker, err := NewSVIDExpiryChecker(sources.SVIDs, config.SVID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SVID health checker: %w", err)
+		}
+		checkers = append(checkers, checker)
+	}
+
+	if config.Bundle != nil && config.Bundle.Enabled {
+		checker, err := NewBundleFreshnessChecker(sources.Bundles, sources.TrustDomain, config.Bundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trust bundle health checker: %w", err)
+		}
+		checkers = append(checkers, checker)
+	}
+
+	if config.WorkloadAPI != nil && config.WorkloadAPI.Enabled {
+		socketPath := config.WorkloadAPI.SocketPath
+		if socketPath == "" {
+			socketPath = sources.SocketPath
+		}
+		checker, err := NewWorkloadAPIChecker(socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workload API health checker: %w", err)
+		}
+		checkers = append(checkers, checker)
+	}
+
+	return checkers, nil
+}
+
+// RegisterBuiltinCheckers registers the built-in checkers enabled in the health
+// configuration with the monitor.
+func RegisterBuiltinCheckers(monitor ports.HealthMonitorPort, config *ports.HealthConfig, sources BuiltinSources) error {
+	checkers, err := NewBuiltinCheckers(config, sources)
+	if err != nil {
+		return err
+	}
+	for _, checker := range checkers {
+		if err := monitor.RegisterChecker(checker); err != nil {
+			return fmt.Errorf("failed to register %s health checker: %w", checker.GetComponentName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+func TestNewBuiltinCheckers(t *testing.T) {
+	sources := BuiltinSources{
+		SVIDs:       svidExpiringIn(time.Hour),
+		Bundles:     &mutableBundleSource{bundle: newCABundle(t)},
+		TrustDomain: exampleOrg,
+		SocketPath:  "/run/spire/sockets/agent.sock",
+	}
+
+	checkers, err := NewBuiltinCheckers(nil, sources)
+	require.NoError(t, err)
+	assert.Empty(t, checkers)
+
+	checkers, err = NewBuiltinCheckers(&ports.HealthConfig{
+		SVID:        &ports.SVIDHealthConfig{Enabled: true},
+		Bundle:      &ports.BundleHealthConfig{Enabled: false},
+		WorkloadAPI: &ports.WorkloadAPIHealthConfig{Enabled: true},
+	}, sources)
+	require.NoError(t, err)
+	require.Len(t, checkers, 2)
+	assert.Equal(t, ComponentSVID, checkers[0].GetComponentName())
+	assert.Equal(t, "unix:///run/spire/sockets/agent.sock", checkers[1].(*WorkloadAPIChecker).addr)
+
+	checkers, err = NewBuiltinCheckers(&ports.HealthConfig{
+		WorkloadAPI: &ports.WorkloadAPIHealthConfig{Enabled: true, SocketPath: "/tmp/other.sock"},
+	}, sources)
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///tmp/other.sock", checkers[0].(*WorkloadAPIChecker).addr)
+
+	// An enabled check without its source is a configuration error
+	_, err = NewBuiltinCheckers(&ports.HealthConfig{Bundle: &ports.BundleHealthConfig{Enabled: true}}, BuiltinSources{})
+	assert.Error(t, err)
+}
+
+func TestRegisterBuiltinCheckers(t *testing.T) {
+	config := &ports.HealthConfig{
+		Enabled: true,
+		SVID:    &ports.SVIDHealthConfig{Enabled: true},
+		Bundle:  &ports.BundleHealthConfig{Enabled: true},
+	}
+	monitor, err := services.NewHealthMonitorService(config, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = monitor.Close() })
+
+	require.NoError(t, RegisterBuiltinCheckers(monitor, config, BuiltinSources{
+		SVIDs:       svidExpiringIn(time.Hour),
+		Bundles:     &mutableBundleSource{bundle: newCABundle(t)},
+		TrustDomain: exampleOrg,
+	}))
+
+	results, err := monitor.CheckAll(t.Context())
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, ports.HealthStatusHealthy, results[ComponentSVID].Status)
+	assert.Equal(t, ports.HealthStatusHealthy, results[ComponentTrustBundle].Status)
+}
@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// bundleUpdateTimes is implemented by bundle sources that record when they last
+// updated a bundle, such as the federated bundle set.
+type bundleUpdateTimes interface {
+	LastUpdated(trustDomain spiffeid.TrustDomain) (time.Time, bool)
+}
+
+// BundleFreshnessChecker checks how long ago the trust bundle of a trust domain
+// was last updated. Bundles pushed by the Workload API change whenever the SPIRE
+// server prepares a new CA, so a bundle that has not changed for longer than the
+// CA TTL means updates no longer reach the workload. When the source records
+// update times itself, those are used instead of observed changes.
+//
+// A bundle without authorities, or whose authorities have all expired, is
+// unhealthy regardless of its age.
+type BundleFreshnessChecker struct {
+	source      x509bundle.Source
+	trustDomain spiffeid.TrustDomain
+	maxAge      time.Duration
+
+	mu      sync.Mutex
+	last    *x509bundle.Bundle
+	changed time.Time
+}
+
+// NewBundleFreshnessChecker creates a checker for the bundle of trustDomain served
+// by source. A nil config selects the default maximum age.
+func NewBundleFreshnessChecker(source x509bundle.Source, trustDomain spiffeid.TrustDomain, config *ports.BundleHealthConfig) (*BundleFreshnessChecker, error) {
+	if source == nil {
+		return nil, fmt.Errorf("bundle source cannot be nil")
+	}
+	if trustDomain.IsZero() {
+		return nil, fmt.Errorf("trust domain is required")
+	}
+	return &BundleFreshnessChecker{
+		source:      source,
+		trustDomain: trustDomain,
+		maxAge:      config.GetMaxAge(),
+	}, nil
+}
+
+// GetComponentName returns the name of the component being monitored
+func (c *BundleFreshnessChecker) GetComponentName() string {
+	return ComponentTrustBundle
+}
+
+// CheckLiveness reports the checker as alive: a stale bundle makes the workload
+// unready, but restarting it does not bring updates back.
+func (c *BundleFreshnessChecker) CheckLiveness(_ context.Context) (*ports.HealthResult, error) {
+	return alive(ComponentTrustBundle), nil
+}
+
+// CheckReadiness verifies that the bundle is usable and recently updated
+func (c *BundleFreshnessChecker) CheckReadiness(ctx context.Context) (*ports.HealthResult, error) {
+	return c.CheckHealth(ctx)
+}
+
+// CheckHealth checks the bundle's authorities and the time since its last update
+func (c *BundleFreshnessChecker) CheckHealth(_ context.Context) (*ports.HealthResult, error) {
+	start := time.Now()
+	result := &ports.HealthResult{
+		Component: ComponentTrustBundle,
+		CheckedAt: start,
+		Details: map[string]interface{}{
+			"trust_domain": c.trustDomain.String(),
+			"max_age":      c.maxAge.String(),
+		},
+	}
+	defer func() { result.ResponseTime = time.Since(start) }()
+
+	bundle, err := c.source.GetX509BundleForTrustDomain(c.trustDomain)
+	if err != nil {
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("No trust bundle for %s: %v", c.trustDomain, err)
+		result.Details["error"] = err.Error()
+		return result, nil
+	}
+
+	updated := c.lastUpdated(bundle, start)
+	age := start.Sub(updated)
+	authorities := bundle.X509Authorities()
+	valid := 0
+	for _, authority := range authorities {
+		if start.Before(authority.NotAfter) {
+			valid++
+		}
+	}
+	result.Details["authorities"] = len(authorities)
+	result.Details["valid_authorities"] = valid
+	result.Details["last_updated"] = updated.UTC().Format(time.RFC3339)
+	result.Details["age"] = age.Round(time.Second).String()
+
+	switch {
+	case len(authorities) == 0:
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("Trust bundle for %s has no authorities", c.trustDomain)
+	case valid == 0:
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("Every authority in the trust bundle for %s has expired", c.trustDomain)
+	case age > c.maxAge:
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("Trust bundle for %s not updated for %s, longer than %s",
+			c.trustDomain, age.Round(time.Second), c.maxAge)
+	default:
+		result.Status = ports.HealthStatusHealthy
+		result.Message = fmt.Sprintf("Trust bundle for %s updated %s ago", c.trustDomain, age.Round(time.Second))
+	}
+	return result, nil
+}
+
+// lastUpdated returns when the bundle was last updated: as recorded by the
+// source if it can tell, otherwise when this checker first saw its current content.
+func (c *BundleFreshnessChecker) lastUpdated(bundle *x509bundle.Bundle, now time.Time) time.Time {
+	if times, ok := c.source.(bundleUpdateTimes); ok {
+		if updated, ok := times.LastUpdated(c.trustDomain); ok {
+			return updated
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil || !c.last.Equal(bundle) {
+		c.last = bundle.Clone()
+		c.changed = now
+	}
+	return c.changed
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// mutableBundleSource serves a bundle that tests can replace.
+type mutableBundleSource struct {
+	mu     sync.Mutex
+	bundle *x509bundle.Bundle
+	err    error
+}
+
+func (s *mutableBundleSource) set(bundle *x509bundle.Bundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundle = bundle
+}
+
+func (s *mutableBundleSource) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.bundle == nil || s.bundle.TrustDomain() != trustDomain {
+		return nil, errors.New("no bundle")
+	}
+	return s.bundle, nil
+}
+
+// timedBundleSource also records when its bundle was updated.
+type timedBundleSource struct {
+	mutableBundleSource
+	updated time.Time
+}
+
+func (s *timedBundleSource) LastUpdated(spiffeid.TrustDomain) (time.Time, bool) {
+	return s.updated, true
+}
+
+var exampleOrg = spiffeid.RequireTrustDomainFromString("example.org")
+
+func newCABundle(t *testing.T) *x509bundle.Bundle {
+	t.Helper()
+	ca, err := memidentity.NewCA(exampleOrg, false)
+	require.NoError(t, err)
+	return ca.Bundle()
+}
+
+func TestNewBundleFreshnessChecker(t *testing.T) {
+	_, err := NewBundleFreshnessChecker(nil, exampleOrg, nil)
+	assert.Error(t, err)
+	_, err = NewBundleFreshnessChecker(&mutableBundleSource{}, spiffeid.TrustDomain{}, nil)
+	assert.Error(t, err)
+
+	checker, err := NewBundleFreshnessChecker(&mutableBundleSource{}, exampleOrg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ComponentTrustBundle, checker.GetComponentName())
+	assert.Equal(t, ports.DefaultBundleHealthMaxAge, checker.maxAge)
+}
+
+func TestBundleFreshnessChecker_AgesUntilBundleChanges(t *testing.T) {
+	source := &mutableBundleSource{bundle: newCABundle(t)}
+	checker, err := NewBundleFreshnessChecker(source, exampleOrg, &ports.BundleHealthConfig{Enabled: true, MaxAge: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	result, err := checker.CheckHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, result.Status, result.Message)
+	assert.Equal(t, 1, result.Details["authorities"])
+
+	// The same content is not an update
+	time.Sleep(100 * time.Millisecond)
+	source.set(source.bundle.Clone())
+	result, err = checker.CheckHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusUnhealthy, result.Status)
+	assert.Contains(t, result.Message, "not updated")
+
+	// A new CA is
+	source.set(newCABundle(t))
+	result, err = checker.CheckReadiness(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, result.Status, result.Message)
+
+	liveness, err := checker.CheckLiveness(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, liveness.Status)
+}
+
+func TestBundleFreshnessChecker_UsesSourceUpdateTimes(t *testing.T) {
+	source := &timedBundleSource{
+		mutableBundleSource: mutableBundleSource{bundle: newCABundle(t)},
+		updated:             time.Now().Add(-2 * time.Hour),
+	}
+	checker, err := NewBundleFreshnessChecker(source, exampleOrg, &ports.BundleHealthConfig{Enabled: true, MaxAge: time.Hour})
+	require.NoError(t, err)
+
+	result, err := checker.CheckHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusUnhealthy, result.Status)
+
+	source.updated = time.Now()
+	result, err = checker.CheckHealth(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, result.Status)
+}
+
+func TestBundleFreshnessChecker_UnusableBundles(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *mutableBundleSource
+	}{
+		{"unavailable", &mutableBundleSource{err: errors.New("agent unavailable")}},
+		{"no authorities", &mutableBundleSource{bundle: x509bundle.New(exampleOrg)}},
+		{"expired authorities", &mutableBundleSource{bundle: x509bundle.FromX509Authorities(exampleOrg,
+			[]*x509.Certificate{{Raw: []byte{1}, NotAfter: time.Now().Add(-time.Hour)}})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewBundleFreshnessChecker(tt.source, exampleOrg, nil)
+			require.NoError(t, err)
+
+			result, err := checker.CheckHealth(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, ports.HealthStatusUnhealthy, result.Status)
+			assert.NotEmpty(t, result.Message)
+		})
+	}
+}
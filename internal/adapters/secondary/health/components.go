@@ -0,0 +1,60 @@
+package health
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// ComponentProvider implements ports.HealthComponentProvider. It creates SPIRE
+// health clients for the configured server and agent, the built-in checks that
+// are enabled, and a log reporter.
+type ComponentProvider struct {
+	sources BuiltinSources
+	logger  *slog.Logger
+}
+
+// NewComponentProvider creates a component provider whose built-in checks
+// inspect sources.
+func NewComponentProvider(sources BuiltinSources, logger *slog.Logger) *ComponentProvider {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ComponentProvider{sources: sources, logger: logger}
+}
+
+// CreateCheckers creates the checkers selected by the health configuration
+func (p *ComponentProvider) CreateCheckers(config *ports.HealthConfig) ([]ports.HealthCheckerPort, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var checkers []ports.HealthCheckerPort
+	if config.Server != nil {
+		checker, err := NewSpireHealthClient(domain.ComponentSpireServer.String(), config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SPIRE server health checker: %w", err)
+		}
+		checkers = append(checkers, checker)
+	}
+	if config.Agent != nil {
+		checker, err := NewSpireHealthClient(domain.ComponentSpireAgent.String(), config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SPIRE agent health checker: %w", err)
+		}
+		checkers = append(checkers, checker)
+	}
+
+	builtin, err := NewBuiltinCheckers(config, p.sources)
+	if err != nil {
+		return nil, err
+	}
+	return append(checkers, builtin...), nil
+}
+
+// CreateReporters creates a reporter that logs health results
+func (p *ComponentProvider) CreateReporters(_ *ports.HealthConfig) ([]ports.HealthReporterPort, error) {
+	return []ports.HealthReporterPort{NewLogHealthReporter(p.logger)}, nil
+}
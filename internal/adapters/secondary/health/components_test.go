@@ -0,0 +1,39 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestComponentProvider(t *testing.T) {
+	var _ ports.HealthComponentProvider = (*ComponentProvider)(nil)
+
+	provider := NewComponentProvider(BuiltinSources{SVIDs: svidExpiringIn(time.Hour)}, nil)
+
+	checkers, err := provider.CreateCheckers(nil)
+	require.NoError(t, err)
+	assert.Empty(t, checkers)
+
+	checkers, err = provider.CreateCheckers(&ports.HealthConfig{
+		Server: &ports.SpireServerHealthConfig{Address: "localhost:8080"},
+		Agent:  &ports.SpireAgentHealthConfig{Address: "localhost:8081"},
+		SVID:   &ports.SVIDHealthConfig{Enabled: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, checkers, 3)
+	assert.Equal(t, "spire-server", checkers[0].GetComponentName())
+	assert.Equal(t, "spire-agent", checkers[1].GetComponentName())
+	assert.Equal(t, ComponentSVID, checkers[2].GetComponentName())
+
+	_, err = provider.CreateCheckers(&ports.HealthConfig{Bundle: &ports.BundleHealthConfig{Enabled: true}})
+	assert.Error(t, err, "the bundle check has no source")
+
+	reporters, err := provider.CreateReporters(nil)
+	require.NoError(t, err)
+	assert.Len(t, reporters, 1)
+}
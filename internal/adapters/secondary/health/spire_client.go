@@ -119,6 +119,9 @@ type SpireHealthClient struct {
 
 // NewSpireHealthClient creates a new SPIRE health checker client
 func NewSpireHealthClient(component string, config *ports.HealthConfig) (*SpireHealthClient, error) {
+	if config == nil {
+		return nil, fmt.Errorf("health configuration cannot be nil")
+	}
 	capability := &configHealthCapability{config: config}
 	return NewSpireHealthClientWithCapability(component, capability)
 }
@@ -351,7 +354,7 @@ func (c *SpireHealthClient) UpdateConfig(config *ports.HealthConfig) error {
 		return fmt.Errorf("health config cannot be nil")
 	}
 
-	c.config = config
+	c.capability = &configHealthCapability{config: config}
 
 	// Update HTTP client timeout if needed
 	if config.Timeout > 0 {
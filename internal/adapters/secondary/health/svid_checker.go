@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// Component names of the built-in checkers.
+const (
+	ComponentSVID        = "svid"
+	ComponentTrustBundle = "trust-bundle"
+	ComponentWorkloadAPI = "workload-api"
+)
+
+// SVIDExpiryChecker checks the remaining lifetime of the workload's X.509 SVID.
+// Below the warning threshold the SVID is still healthy but the result carries a
+// warning; below the critical threshold rotation has stalled and the SVID is
+// unhealthy, since peers will soon reject it.
+type SVIDExpiryChecker struct {
+	source   x509svid.Source
+	warning  time.Duration
+	critical time.Duration
+}
+
+// NewSVIDExpiryChecker creates a checker for the SVID served by source.
+// A nil config selects the default thresholds.
+func NewSVIDExpiryChecker(source x509svid.Source, config *ports.SVIDHealthConfig) (*SVIDExpiryChecker, error) {
+	if source == nil {
+		return nil, fmt.Errorf("SVID source cannot be nil")
+	}
+	return &SVIDExpiryChecker{
+		source:   source,
+		warning:  config.GetWarning(),
+		critical: config.GetCritical(),
+	}, nil
+}
+
+// GetComponentName returns the name of the component being monitored
+func (c *SVIDExpiryChecker) GetComponentName() string {
+	return ComponentSVID
+}
+
+// CheckLiveness reports the checker as alive: an expiring SVID makes the
+// workload unready, but restarting it does not bring rotation back.
+func (c *SVIDExpiryChecker) CheckLiveness(_ context.Context) (*ports.HealthResult, error) {
+	return alive(ComponentSVID), nil
+}
+
+// CheckReadiness verifies that the SVID is not closer to expiry than the
+// critical threshold
+func (c *SVIDExpiryChecker) CheckReadiness(ctx context.Context) (*ports.HealthResult, error) {
+	return c.CheckHealth(ctx)
+}
+
+// CheckHealth checks the remaining lifetime of the SVID against the thresholds
+func (c *SVIDExpiryChecker) CheckHealth(_ context.Context) (*ports.HealthResult, error) {
+	start := time.Now()
+	result := &ports.HealthResult{
+		Component: ComponentSVID,
+		CheckedAt: start,
+		Details: map[string]interface{}{
+			"warning_threshold":  c.warning.String(),
+			"critical_threshold": c.critical.String(),
+		},
+	}
+	defer func() { result.ResponseTime = time.Since(start) }()
+
+	svid, err := c.source.GetX509SVID()
+	if err != nil || len(svid.Certificates) == 0 {
+		if err == nil {
+			err = fmt.Errorf("SVID has no certificates")
+		}
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("No SVID available: %v", err)
+		result.Details["error"] = err.Error()
+		return result, nil
+	}
+
+	leaf := svid.Certificates[0]
+	remaining := leaf.NotAfter.Sub(start)
+	result.Details["spiffe_id"] = svid.ID.String()
+	result.Details["expires_at"] = leaf.NotAfter.UTC().Format(time.RFC3339)
+	result.Details["remaining_lifetime"] = remaining.Round(time.Second).String()
+
+	switch {
+	case remaining <= 0:
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("SVID expired %s ago", (-remaining).Round(time.Second))
+	case remaining < c.critical:
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("SVID expires in %s, below the critical threshold of %s",
+			remaining.Round(time.Second), c.critical)
+	case remaining < c.warning:
+		result.Status = ports.HealthStatusHealthy
+		result.Message = fmt.Sprintf("SVID expires in %s, below the warning threshold of %s",
+			remaining.Round(time.Second), c.warning)
+		result.Details["warning"] = true
+	default:
+		result.Status = ports.HealthStatusHealthy
+		result.Message = fmt.Sprintf("SVID valid for %s", remaining.Round(time.Second))
+	}
+	return result, nil
+}
+
+// alive is the liveness result of the built-in checkers, which inspect the
+// workload's identity rather than a separate process.
+func alive(component string) *ports.HealthResult {
+	return &ports.HealthResult{
+		Status:    ports.HealthStatusHealthy,
+		Component: component,
+		Message:   "Identity checks do not affect liveness",
+		CheckedAt: time.Now(),
+	}
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// staticSVIDSource serves a fixed SVID or error.
+type staticSVIDSource struct {
+	svid *x509svid.SVID
+	err  error
+}
+
+func (s *staticSVIDSource) GetX509SVID() (*x509svid.SVID, error) {
+	return s.svid, s.err
+}
+
+func svidExpiringIn(remaining time.Duration) *staticSVIDSource {
+	return &staticSVIDSource{svid: &x509svid.SVID{
+		ID:           spiffeid.RequireFromString("spiffe://example.org/api"),
+		Certificates: []*x509.Certificate{{NotAfter: time.Now().Add(remaining)}},
+	}}
+}
+
+func TestNewSVIDExpiryChecker(t *testing.T) {
+	_, err := NewSVIDExpiryChecker(nil, nil)
+	assert.Error(t, err)
+
+	checker, err := NewSVIDExpiryChecker(svidExpiringIn(time.Hour), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ComponentSVID, checker.GetComponentName())
+	assert.Equal(t, ports.DefaultSVIDHealthWarning, checker.warning)
+	assert.Equal(t, ports.DefaultSVIDHealthCritical, checker.critical)
+}
+
+func TestSVIDExpiryChecker_CheckHealth(t *testing.T) {
+	config := &ports.SVIDHealthConfig{Enabled: true, Warning: 20 * time.Minute, Critical: 10 * time.Minute}
+
+	tests := []struct {
+		name    string
+		source  *staticSVIDSource
+		status  ports.HealthStatus
+		warning bool
+	}{
+		{"fresh", svidExpiringIn(time.Hour), ports.HealthStatusHealthy, false},
+		{"below warning", svidExpiringIn(15 * time.Minute), ports.HealthStatusHealthy, true},
+		{"below critical", svidExpiringIn(5 * time.Minute), ports.HealthStatusUnhealthy, false},
+		{"expired", svidExpiringIn(-time.Minute), ports.HealthStatusUnhealthy, false},
+		{"unavailable", &staticSVIDSource{err: errors.New("no identity issued")}, ports.HealthStatusUnhealthy, false},
+		{"no certificates", &staticSVIDSource{svid: &x509svid.SVID{}}, ports.HealthStatusUnhealthy, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewSVIDExpiryChecker(tt.source, config)
+			require.NoError(t, err)
+
+			result, err := checker.CheckHealth(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, result.Status, result.Message)
+			assert.Equal(t, ComponentSVID, result.Component)
+			assert.NotEmpty(t, result.Message)
+			_, warned := result.Details["warning"]
+			assert.Equal(t, tt.warning, warned)
+
+			readiness, err := checker.CheckReadiness(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, readiness.Status)
+
+			liveness, err := checker.CheckLiveness(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, ports.HealthStatusHealthy, liveness.Status, "SVID expiry does not affect liveness")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc/status"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// WorkloadAPIChecker checks that the agent socket accepts a Workload API call.
+// Unlike the SPIRE agent's HTTP health endpoint, which many deployments do not
+// expose, it needs nothing beyond the socket the workload already uses. A call
+// the agent refuses, e.g. because no registration entry matches the workload,
+// is unhealthy as well.
+type WorkloadAPIChecker struct {
+	addr string
+}
+
+// NewWorkloadAPIChecker creates a checker for the Workload API socket, given as a
+// path or as a unix:// address.
+func NewWorkloadAPIChecker(socketPath string) (*WorkloadAPIChecker, error) {
+	if socketPath == "" {
+		return nil, fmt.Errorf("workload API socket path is required")
+	}
+	addr := socketPath
+	if !strings.HasPrefix(addr, "unix://") {
+		addr = "unix://" + addr
+	}
+	return &WorkloadAPIChecker{addr: addr}, nil
+}
+
+// GetComponentName returns the name of the component being monitored
+func (c *WorkloadAPIChecker) GetComponentName() string {
+	return ComponentWorkloadAPI
+}
+
+// CheckLiveness reports the checker as alive: an unreachable agent makes the
+// workload unready, but restarting it does not bring the agent back.
+func (c *WorkloadAPIChecker) CheckLiveness(_ context.Context) (*ports.HealthResult, error) {
+	return alive(ComponentWorkloadAPI), nil
+}
+
+// CheckReadiness verifies that the Workload API answers
+func (c *WorkloadAPIChecker) CheckReadiness(ctx context.Context) (*ports.HealthResult, error) {
+	return c.CheckHealth(ctx)
+}
+
+// CheckHealth fetches the X.509 bundles from the Workload API
+func (c *WorkloadAPIChecker) CheckHealth(ctx context.Context) (*ports.HealthResult, error) {
+	start := time.Now()
+	result := &ports.HealthResult{
+		Component: ComponentWorkloadAPI,
+		CheckedAt: start,
+		Details: map[string]interface{}{
+			"address": c.addr,
+		},
+	}
+
+	bundles, err := workloadapi.FetchX509Bundles(ctx, workloadapi.WithAddr(c.addr))
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Status = ports.HealthStatusUnhealthy
+		result.Message = fmt.Sprintf("Workload API call failed: %v", err)
+		result.Details["error"] = err.Error()
+		result.Details["code"] = status.Code(err).String()
+		return result, nil
+	}
+
+	result.Status = ports.HealthStatusHealthy
+	result.Message = "Workload API is reachable"
+	result.Details["trust_domains"] = bundles.Len()
+	return result, nil
+}
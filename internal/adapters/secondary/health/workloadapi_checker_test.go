@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/testing/workloadapitest"
+)
+
+func TestNewWorkloadAPIChecker(t *testing.T) {
+	_, err := NewWorkloadAPIChecker("")
+	assert.Error(t, err)
+
+	checker, err := NewWorkloadAPIChecker("/run/spire/sockets/agent.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///run/spire/sockets/agent.sock", checker.addr)
+	assert.Equal(t, ComponentWorkloadAPI, checker.GetComponentName())
+
+	checker, err = NewWorkloadAPIChecker("unix:///tmp/agent.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///tmp/agent.sock", checker.addr)
+}
+
+func TestWorkloadAPIChecker_CheckHealth(t *testing.T) {
+	server := workloadapitest.New(t)
+	server.SetX509Bundles(newCABundle(t))
+
+	checker, err := NewWorkloadAPIChecker(server.SocketPath())
+	require.NoError(t, err)
+
+	check := func() *ports.HealthResult {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := checker.CheckHealth(ctx)
+		require.NoError(t, err)
+		return result
+	}
+
+	result := check()
+	assert.Equal(t, ports.HealthStatusHealthy, result.Status, result.Message)
+	assert.Equal(t, 1, result.Details["trust_domains"])
+	assert.Equal(t, 1, server.Calls(workloadapitest.FetchX509Bundles))
+
+	// The agent refuses the call
+	server.SetError(workloadapitest.FetchX509Bundles, status.Error(codes.PermissionDenied, "no identity issued"))
+	result = check()
+	assert.Equal(t, ports.HealthStatusUnhealthy, result.Status)
+	assert.Equal(t, codes.PermissionDenied.String(), result.Details["code"])
+	server.SetError(workloadapitest.FetchX509Bundles, nil)
+
+	// The agent is gone
+	server.Stop()
+	result = check()
+	assert.Equal(t, ports.HealthStatusUnhealthy, result.Status)
+
+	liveness, err := checker.CheckLiveness(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, liveness.Status)
+
+	require.NoError(t, server.Start())
+	readiness, err := checker.CheckReadiness(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ports.HealthStatusHealthy, readiness.Status, readiness.Message)
+}
@@ -0,0 +1,506 @@
+package memidentity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+const (
+	// DefaultSVIDTTL is the lifetime of X.509-SVIDs issued by a CA.
+	DefaultSVIDTTL = 10 * time.Minute
+
+	// caTTL is the lifetime of the root and intermediate. The CA only lives as
+	// long as the process, so it just has to outlast it.
+	caTTL = 30 * 24 * time.Hour
+
+	// clockSkew backdates certificates so that peers with a slow clock accept them.
+	clockSkew = time.Minute
+)
+
+// CA is an in-memory development certificate authority for one trust domain.
+//
+// Unlike the X.509 fake, it issues real ECDSA P-256 X.509-SVIDs, so the full mTLS
+// stack, including handshakes, chain verification and authorization, can run
+// without SPIRE. Its root key never leaves the process and nothing outside it
+// trusts the root; it must not be used in production.
+type CA struct {
+	trustDomain spiffeid.TrustDomain
+	bundle      *x509bundle.Bundle
+
+	// signer and signerKey issue SVIDs; chain holds the intermediates that are
+	// sent with them.
+	signer    *x509.Certificate
+	signerKey *ecdsa.PrivateKey
+	chain     []*x509.Certificate
+}
+
+// NewCA creates a CA with a fresh root for the trust domain. With intermediate,
+// SVIDs are signed by an intermediate CA below the root and carry it in their
+// chain.
+func NewCA(trustDomain spiffeid.TrustDomain, intermediate bool) (*CA, error) {
+	if trustDomain.IsZero() {
+		return nil, fmt.Errorf("trust domain cannot be empty")
+	}
+
+	root, rootKey, err := createCACertificate(trustDomain, "root", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root CA: %w", err)
+	}
+
+	ca := &CA{
+		trustDomain: trustDomain,
+		bundle:      x509bundle.FromX509Authorities(trustDomain, []*x509.Certificate{root}),
+		signer:      root,
+		signerKey:   rootKey,
+	}
+	if intermediate {
+		cert, key, err := createCACertificate(trustDomain, "intermediate", root, rootKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create intermediate CA: %w", err)
+		}
+		ca.signer, ca.signerKey = cert, key
+		ca.chain = []*x509.Certificate{cert}
+	}
+	return ca, nil
+}
+
+var (
+	sharedCAsMu sync.Mutex
+	sharedCAs   = make(map[string]*CA)
+)
+
+// SharedCA returns the process-wide CA for the trust domain, creating it on first
+// use, so that clients and servers created separately in one process trust each
+// other.
+func SharedCA(trustDomain spiffeid.TrustDomain, intermediate bool) (*CA, error) {
+	key := fmt.Sprintf("%s/%t", trustDomain, intermediate)
+
+	sharedCAsMu.Lock()
+	defer sharedCAsMu.Unlock()
+
+	if ca, ok := sharedCAs[key]; ok {
+		return ca, nil
+	}
+	ca, err := NewCA(trustDomain, intermediate)
+	if err != nil {
+		return nil, err
+	}
+	sharedCAs[key] = ca
+	return ca, nil
+}
+
+// createCACertificate creates a CA certificate signed by parent, or a self-signed
+// one if parent is nil.
+func createCACertificate(
+	trustDomain spiffeid.TrustDomain, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Ephemos development CA"}, CommonName: trustDomain.Name() + " " + name},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caTTL),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		URIs:                  []*url.URL{trustDomain.ID().URL()},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, key, nil
+}
+
+// TrustDomain returns the CA's trust domain.
+func (ca *CA) TrustDomain() spiffeid.TrustDomain {
+	return ca.trustDomain
+}
+
+// Bundle returns the trust bundle holding the CA's root.
+func (ca *CA) Bundle() *x509bundle.Bundle {
+	return ca.bundle
+}
+
+// Issue issues an X.509-SVID for id that is valid for ttl, or DefaultSVIDTTL if
+// ttl is not positive. The ID must belong to the CA's trust domain.
+func (ca *CA) Issue(id spiffeid.ID, ttl time.Duration) (*x509svid.SVID, error) {
+	if !id.MemberOf(ca.trustDomain) {
+		return nil, fmt.Errorf("SPIFFE ID %q is not a member of trust domain %q", id, ca.trustDomain)
+	}
+	if ttl <= 0 {
+		ttl = DefaultSVIDTTL
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate SVID key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	// An SVID cannot outlive the certificate that signed it
+	now := time.Now()
+	notAfter := now.Add(ttl)
+	if notAfter.After(ca.signer.NotAfter) {
+		notAfter = ca.signer.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Ephemos development CA"}},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              notAfter,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:                  []*url.URL{id.URL()},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.signer, &key.PublicKey, ca.signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign SVID: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SVID: %w", err)
+	}
+
+	return &x509svid.SVID{
+		ID:           id,
+		Certificates: append([]*x509.Certificate{cert}, ca.chain...),
+		PrivateKey:   key,
+	}, nil
+}
+
+// randomSerial returns a random 128-bit certificate serial number.
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// CAProviderConfig provides configuration for the development CA provider.
+type CAProviderConfig struct {
+	// ID is the SPIFFE ID of the issued SVIDs. Required.
+	ID spiffeid.ID
+	// CA issues the SVIDs. Default: a new CA for the ID's trust domain.
+	CA *CA
+	// Intermediate gives a new CA an intermediate. Ignored if CA is set.
+	Intermediate bool
+	// TTL is the lifetime of issued SVIDs. Default: 10m.
+	TTL    time.Duration
+	Logger *slog.Logger
+}
+
+// CAProvider is an IdentityProvider whose SVIDs are issued by a development CA.
+// A new SVID is issued when half of the current one's lifetime has passed, as
+// SPIRE agents do, so rotation is exercised within minutes rather than hours.
+//
+// CAProvider implements x509svid.Source and x509bundle.Source, so TLS configs
+// built from it pick up rotated SVIDs on the next handshake.
+type CAProvider struct {
+	ca     *CA
+	id     spiffeid.ID
+	ttl    time.Duration
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	svid     *x509svid.SVID
+	watchers map[chan *x509svid.SVID]struct{}
+	started  bool
+	closed   bool
+	closing  chan struct{}
+	done     chan struct{}
+
+	rotateMu sync.Mutex
+}
+
+var (
+	_ ports.IdentityProvider = (*CAProvider)(nil)
+	_ x509svid.Source        = (*CAProvider)(nil)
+	_ x509bundle.Source      = (*CAProvider)(nil)
+)
+
+// NewCAProvider issues the first SVID. Rotation starts with Start.
+func NewCAProvider(config CAProviderConfig) (*CAProvider, error) {
+	if config.ID.IsZero() {
+		return nil, fmt.Errorf("SPIFFE ID cannot be empty")
+	}
+
+	ca := config.CA
+	if ca == nil {
+		var err error
+		ca, err = NewCA(config.ID.TrustDomain(), config.Intermediate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ttl := config.TTL
+	if ttl <= 0 {
+		ttl = DefaultSVIDTTL
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	svid, err := ca.Issue(config.ID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CAProvider{
+		ca:       ca,
+		id:       config.ID,
+		ttl:      ttl,
+		logger:   logger,
+		svid:     svid,
+		watchers: make(map[chan *x509svid.SVID]struct{}),
+		closing:  make(chan struct{}),
+	}, nil
+}
+
+// CA returns the CA that issues the provider's SVIDs, e.g. to issue SVIDs for
+// peers that should trust this provider.
+func (p *CAProvider) CA() *CA {
+	return p.ca
+}
+
+// Start rotates the SVID until Close is called.
+func (p *CAProvider) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("development CA provider is closed")
+	}
+	if p.started {
+		return fmt.Errorf("development CA provider already started")
+	}
+	p.started = true
+	p.done = make(chan struct{})
+	go p.rotate(p.done)
+	return nil
+}
+
+// rotate issues a new SVID whenever half of the current one's lifetime has passed.
+func (p *CAProvider) rotate(done chan struct{}) {
+	defer close(done)
+
+	for {
+		timer := time.NewTimer(p.rotationDelay())
+		select {
+		case <-p.closing:
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := p.Rotate(); err != nil {
+				p.logger.Error("development CA failed to rotate SVID", "error", err)
+			}
+		}
+	}
+}
+
+// rotationDelay returns the time until half of the current SVID's lifetime has
+// passed.
+func (p *CAProvider) rotationDelay() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	cert := p.svid.Certificates[0]
+	issuedAt := cert.NotBefore.Add(clockSkew)
+	delay := time.Until(issuedAt.Add(cert.NotAfter.Sub(issuedAt) / 2))
+	if delay < time.Second {
+		// Retry failed rotations without spinning
+		delay = time.Second
+	}
+	return delay
+}
+
+// Rotate issues a new SVID and sends it to watchers.
+func (p *CAProvider) Rotate() error {
+	p.rotateMu.Lock()
+	defer p.rotateMu.Unlock()
+
+	svid, err := p.ca.Issue(p.id, p.ttl)
+	if err != nil {
+		return fmt.Errorf("failed to issue SVID: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("development CA provider is closed")
+	}
+	p.svid = svid
+
+	p.logger.Debug("development CA issued SVID",
+		"spiffe_id", svid.ID.String(),
+		"expires_at", svid.Certificates[0].NotAfter)
+
+	for ch := range p.watchers {
+		// Send SVID update to channel (non-blocking)
+		select {
+		case ch <- svid:
+		default:
+			p.logger.Warn("SVID update channel full, dropping update")
+		}
+	}
+	return nil
+}
+
+// RefreshIdentity issues a new SVID.
+func (p *CAProvider) RefreshIdentity(_ context.Context) error {
+	return p.Rotate()
+}
+
+// WatchIdentityChanges returns a channel that receives each newly issued SVID.
+// The channel is closed when ctx is done or the provider is closed.
+func (p *CAProvider) WatchIdentityChanges(ctx context.Context) (<-chan *x509svid.SVID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, fmt.Errorf("development CA provider is closed")
+	}
+
+	ch := make(chan *x509svid.SVID, 10) // Buffer for updates
+	p.watchers[ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-p.closing:
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if _, ok := p.watchers[ch]; ok {
+			delete(p.watchers, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// GetServiceIdentity returns the SPIFFE ID of the issued SVIDs.
+func (p *CAProvider) GetServiceIdentity() (spiffeid.ID, error) {
+	svid, err := p.GetSVID()
+	if err != nil {
+		return spiffeid.ID{}, err
+	}
+	return svid.ID, nil
+}
+
+// GetCertificate returns the current SVID as a certificate.
+func (p *CAProvider) GetCertificate() (*domain.Certificate, error) {
+	svid, err := p.GetSVID()
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Certificate{
+		Cert:       svid.Certificates[0],
+		PrivateKey: svid.PrivateKey,
+		Chain:      svid.Certificates[1:],
+	}, nil
+}
+
+// GetTrustBundle returns the CA's trust bundle.
+func (p *CAProvider) GetTrustBundle() (*x509bundle.Bundle, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+	return p.ca.Bundle(), nil
+}
+
+// GetSVID returns the current SVID.
+func (p *CAProvider) GetSVID() (*x509svid.SVID, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+	return p.svid, nil
+}
+
+// GetX509SVID implements x509svid.Source.
+func (p *CAProvider) GetX509SVID() (*x509svid.SVID, error) {
+	return p.GetSVID()
+}
+
+// GetX509BundleForTrustDomain implements x509bundle.Source. Only the CA's trust
+// domain is available.
+func (p *CAProvider) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	bundle, err := p.GetTrustBundle()
+	if err != nil {
+		return nil, err
+	}
+	return bundle.GetX509BundleForTrustDomain(trustDomain)
+}
+
+// Close stops rotation and closes all watcher channels.
+// It is safe to call Close multiple times.
+func (p *CAProvider) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.closing)
+	done := p.done
+	for ch := range p.watchers {
+		delete(p.watchers, ch)
+		close(ch)
+	}
+	p.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+	return nil
+}
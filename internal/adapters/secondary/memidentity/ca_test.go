@@ -0,0 +1,181 @@
+package memidentity_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/domain"
+)
+
+func TestCA_IssuesValidSVIDs(t *testing.T) {
+	t.Parallel()
+
+	for _, intermediate := range []bool{false, true} {
+		ca, err := memidentity.NewCA(spiffeid.RequireTrustDomainFromString("example.org"), intermediate)
+		require.NoError(t, err)
+
+		id := spiffeid.RequireFromString("spiffe://example.org/api")
+		svid, err := ca.Issue(id, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, id, svid.ID)
+		if intermediate {
+			assert.Len(t, svid.Certificates, 2)
+		} else {
+			assert.Len(t, svid.Certificates, 1)
+		}
+		assert.WithinDuration(t, time.Now().Add(time.Minute), svid.Certificates[0].NotAfter, 5*time.Second)
+
+		trustBundle, err := domain.NewTrustBundle(ca.Bundle().X509Authorities())
+		require.NoError(t, err)
+		cert := &domain.Certificate{
+			Cert:       svid.Certificates[0],
+			PrivateKey: svid.PrivateKey,
+			Chain:      svid.Certificates[1:],
+		}
+		assert.NoError(t, cert.Validate(domain.CertValidationOptions{TrustBundle: trustBundle, FIPS: true}))
+
+		_, err = ca.Issue(spiffeid.RequireFromString("spiffe://other.org/api"), time.Minute)
+		assert.Error(t, err, "ID outside the trust domain")
+	}
+}
+
+func TestCAProvider_MutualTLS(t *testing.T) {
+	t.Parallel()
+
+	ca, err := memidentity.NewCA(spiffeid.RequireTrustDomainFromString("example.org"), true)
+	require.NoError(t, err)
+	serverID := spiffeid.RequireFromString("spiffe://example.org/server")
+	clientID := spiffeid.RequireFromString("spiffe://example.org/client")
+
+	server, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: serverID, CA: ca})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	client, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: clientID, CA: ca})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0",
+		tlsconfig.MTLSServerConfig(server, server, tlsconfig.AuthorizeID(clientID)))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	assert.NoError(t, ping(listener.Addr().String(), client, serverID))
+
+	// A provider with its own CA is not trusted
+	stranger, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: clientID})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stranger.Close() })
+	assert.Error(t, ping(listener.Addr().String(), stranger, serverID))
+}
+
+// ping sends a message over mTLS and reads back the echo.
+func ping(addr string, provider *memidentity.CAProvider, serverID spiffeid.ID) error {
+	conn, err := tls.Dial("tcp", addr, tlsconfig.MTLSClientConfig(provider, provider, tlsconfig.AuthorizeID(serverID)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		return err
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return err
+	}
+	if string(buf) != "ping" {
+		return fmt.Errorf("unexpected echo %q", buf)
+	}
+	return nil
+}
+
+func TestCAProvider_Rotates(t *testing.T) {
+	t.Parallel()
+
+	provider, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{
+		ID:  spiffeid.RequireFromString("spiffe://example.org/api"),
+		TTL: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Close() })
+
+	first, err := provider.GetX509SVID()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := provider.WatchIdentityChanges(ctx)
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+	assert.Error(t, provider.Start())
+
+	select {
+	case svid := <-updates:
+		assert.NotEqual(t, first.Certificates[0].SerialNumber, svid.Certificates[0].SerialNumber)
+		assert.True(t, svid.Certificates[0].NotAfter.After(first.Certificates[0].NotAfter))
+	case <-time.After(5 * time.Second):
+		t.Fatal("SVID was not rotated")
+	}
+
+	require.NoError(t, provider.RefreshIdentity(ctx))
+	<-updates
+}
+
+func TestCAProvider_CloseEndsWatches(t *testing.T) {
+	t.Parallel()
+
+	provider, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{
+		ID: spiffeid.RequireFromString("spiffe://example.org/api"),
+	})
+	require.NoError(t, err)
+	updates, err := provider.WatchIdentityChanges(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+
+	require.NoError(t, provider.Close())
+	require.NoError(t, provider.Close())
+
+	_, ok := <-updates
+	assert.False(t, ok)
+	_, err = provider.GetSVID()
+	assert.Error(t, err)
+	assert.Error(t, provider.Rotate())
+}
+
+func TestSharedCA(t *testing.T) {
+	t.Parallel()
+
+	td := spiffeid.RequireTrustDomainFromString("shared.example.org")
+	first, err := memidentity.SharedCA(td, false)
+	require.NoError(t, err)
+	second, err := memidentity.SharedCA(td, false)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	withIntermediate, err := memidentity.SharedCA(td, true)
+	require.NoError(t, err)
+	assert.NotSame(t, first, withIntermediate)
+}
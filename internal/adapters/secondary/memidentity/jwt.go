@@ -0,0 +1,180 @@
+package memidentity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// DefaultJWTTTL is the lifetime of JWT-SVIDs minted by JWTProvider, matching SPIRE's default.
+const DefaultJWTTTL = 5 * time.Minute
+
+// JWTProvider is an in-memory JWT-SVID provider for testing.
+// Unlike the X.509 fake, it mints real ES256-signed tokens so that they can be
+// validated by the same code paths that validate tokens from SPIRE.
+type JWTProvider struct {
+	mu      sync.RWMutex
+	id      spiffeid.ID
+	ttl     time.Duration
+	key     *ecdsa.PrivateKey
+	keyID   string
+	bundles *jwtbundle.Set
+	fetches int
+	closed  bool
+}
+
+// NewJWTProvider creates an in-memory JWT-SVID provider that mints tokens for the given SPIFFE ID.
+// A fresh signing key is generated and published in the provider's JWT bundle.
+func NewJWTProvider(id spiffeid.ID) (*JWTProvider, error) {
+	if id.IsZero() {
+		return nil, fmt.Errorf("SPIFFE ID cannot be empty")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JWT signing key: %w", err)
+	}
+
+	keyID, err := randomKeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := jwtbundle.New(id.TrustDomain())
+	if err := bundle.AddJWTAuthority(keyID, key.Public()); err != nil {
+		return nil, fmt.Errorf("failed to add JWT authority: %w", err)
+	}
+
+	return &JWTProvider{
+		id:      id,
+		ttl:     DefaultJWTTTL,
+		key:     key,
+		keyID:   keyID,
+		bundles: jwtbundle.NewSet(bundle),
+	}, nil
+}
+
+// WithTTL sets the lifetime of minted tokens for testing.
+func (p *JWTProvider) WithTTL(ttl time.Duration) *JWTProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ttl = ttl
+	return p
+}
+
+// WithID changes the SPIFFE ID placed in the subject of minted tokens.
+// The ID must belong to the provider's trust domain for tokens to validate.
+func (p *JWTProvider) WithID(id spiffeid.ID) *JWTProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.id = id
+	return p
+}
+
+// AddBundle adds or replaces the JWT bundle of another trust domain, e.g. to simulate federation.
+func (p *JWTProvider) AddBundle(bundle *jwtbundle.Bundle) *JWTProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bundles.Add(bundle)
+	return p
+}
+
+// Bundle returns the JWT bundle of the provider's own trust domain.
+func (p *JWTProvider) Bundle() *jwtbundle.Bundle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	bundle, _ := p.bundles.Get(p.id.TrustDomain())
+	return bundle
+}
+
+// FetchCount returns how many tokens have been minted, for cache assertions in tests.
+func (p *JWTProvider) FetchCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.fetches
+}
+
+// FetchJWTSVID mints a signed JWT-SVID for the given audiences.
+func (p *JWTProvider) FetchJWTSVID(_ context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: p.key, KeyID: p.keyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT signer: %w", err)
+	}
+
+	now := time.Now()
+	audiences := append([]string{audience}, extraAudiences...)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Subject:  p.id.String(),
+		Audience: audiences,
+		Expiry:   jwt.NewNumericDate(now.Add(p.ttl)),
+		IssuedAt: jwt.NewNumericDate(now),
+	}).Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign JWT-SVID: %w", err)
+	}
+
+	svid, err := jwtsvid.ParseInsecure(token, audiences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse minted JWT-SVID: %w", err)
+	}
+
+	p.fetches++
+	return svid, nil
+}
+
+// GetJWTBundleForTrustDomain returns the JWT bundle for the given trust domain.
+func (p *JWTProvider) GetJWTBundleForTrustDomain(_ context.Context, trustDomain spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return nil, ports.ErrIdentityNotFound
+	}
+
+	return p.bundles.GetJWTBundleForTrustDomain(trustDomain)
+}
+
+// Close marks the provider as closed.
+func (p *JWTProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// randomKeyID returns a random hex key ID for a JWT authority.
+func randomKeyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Ensure provider implements the port interfaces
+var (
+	_ ports.JWTSVIDProviderPort   = (*JWTProvider)(nil)
+	_ ports.JWTBundleProviderPort = (*JWTProvider)(nil)
+)
@@ -0,0 +1,320 @@
+// Package revocation keeps the revocation list of revoked SPIFFE IDs, certificate
+// serials and keys current, combining the configured entries with a watched file.
+package revocation
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/filewatch"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// DefaultReloadDebounce is how long the list waits after the last file event
+// before reloading, so editors that write in several steps trigger one reload.
+const DefaultReloadDebounce = 200 * time.Millisecond
+
+// Reload results reported to Metrics.
+const (
+	ReloadResultApplied   = "applied"
+	ReloadResultUnchanged = "unchanged"
+	ReloadResultRejected  = "rejected"
+)
+
+// Metrics records rejected peers and list reloads.
+type Metrics interface {
+	// RecordRevokedPeer counts a peer rejected because it matched an entry of the
+	// given kind, e.g. domain.RevocationMatchSerial.
+	RecordRevokedPeer(match string)
+	// RecordRevocationReload records the outcome of a reload and the resulting
+	// number of entries.
+	RecordRevocationReload(result string, entries int)
+}
+
+// ListConfig provides configuration for a revocation list.
+type ListConfig struct {
+	// Config holds the entries and the optional file. Required.
+	Config *ports.RevocationConfig
+	// Debounce delays reloads after file events. Default: 200ms.
+	Debounce time.Duration
+	// Metrics records rejections and reloads. Optional.
+	Metrics Metrics
+	Logger  *slog.Logger
+}
+
+// List is a revocation list that may change at runtime. It holds the entries of
+// the revocation configuration section and of the revocation file, and reloads the
+// file when it changes. An invalid edit is rejected and the last good list is kept.
+type List struct {
+	file     string
+	debounce time.Duration
+	metrics  Metrics
+	logger   *slog.Logger
+
+	current atomic.Pointer[domain.RevocationList]
+
+	mu          sync.Mutex
+	entries     ports.RevocationEntries
+	subscribers map[chan struct{}]struct{}
+	closed      bool
+
+	reloadMu sync.Mutex
+	watcher  *filewatch.Watcher
+}
+
+var _ ports.RevocationListPort = (*List)(nil)
+
+// NewList loads the revocation list. The configured entries and the file, if any,
+// must be valid. Watching the file starts with Start.
+func NewList(config ListConfig) (*List, error) {
+	if config.Config == nil {
+		return nil, fmt.Errorf("revocation configuration is required")
+	}
+
+	debounce := config.Debounce
+	if debounce <= 0 {
+		debounce = DefaultReloadDebounce
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	l := &List{
+		debounce:    debounce,
+		metrics:     config.Metrics,
+		logger:      logger,
+		entries:     config.Config.Entries(),
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+
+	if config.Config.File != "" {
+		file, err := filepath.Abs(filepath.Clean(config.Config.File))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve revocation file path: %w", err)
+		}
+		l.file = file
+	}
+
+	list, err := l.load(l.entries)
+	if err != nil {
+		return nil, err
+	}
+	l.current.Store(list)
+	l.record(ReloadResultApplied, list.Len())
+	return l, nil
+}
+
+// load builds the list from the given entries and the file.
+func (l *List) load(entries ports.RevocationEntries) (*domain.RevocationList, error) {
+	if l.file != "" {
+		fileEntries, err := readFile(l.file)
+		if err != nil {
+			return nil, err
+		}
+		entries = entries.Merge(fileEntries)
+	}
+
+	list, err := entries.List()
+	if err != nil {
+		return nil, fmt.Errorf("invalid revocation list: %w", err)
+	}
+	return list, nil
+}
+
+// readFile reads the entries of a YAML or JSON revocation file.
+func readFile(path string) (ports.RevocationEntries, error) {
+	var entries ports.RevocationEntries
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entries, fmt.Errorf("failed to read revocation file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return entries, fmt.Errorf("failed to parse revocation file %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Start watches the revocation file until Close is called. It does nothing if no
+// file is configured. Atomic replaces and Kubernetes ConfigMap symlink swaps are
+// picked up, see filewatch.Watcher.
+func (l *List) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return fmt.Errorf("revocation list is closed")
+	}
+	if l.file == "" {
+		return nil
+	}
+	if l.watcher != nil {
+		return fmt.Errorf("revocation list already started")
+	}
+
+	// Errors are logged and counted by Reload
+	watcher, err := filewatch.Start([]string{l.file}, l.debounce, func() { _ = l.Reload() }, func(err error) {
+		l.logger.Warn("revocation file watcher error", "path", l.file, "error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch revocation file: %w", err)
+	}
+	l.watcher = watcher
+	return nil
+}
+
+// Reload re-reads the revocation file.
+func (l *List) Reload() error {
+	l.mu.Lock()
+	entries := l.entries
+	l.mu.Unlock()
+	return l.apply(entries)
+}
+
+// Update replaces the configured entries, e.g. after the revocation section of the
+// configuration changed. The file is re-read as well.
+func (l *List) Update(config *ports.RevocationConfig) error {
+	return l.apply(config.Entries())
+}
+
+// WatchConfig applies changes to the revocation section published by the
+// configuration watcher. It returns a function that stops watching.
+func (l *List) WatchConfig(watcher ports.ConfigWatcherPort) func() {
+	events, unsubscribe := watcher.Subscribe()
+	go func() {
+		for event := range events {
+			for _, field := range event.ChangedFields {
+				if field == "revocation" {
+					// Errors are logged and counted by apply
+					_ = l.Update(event.Current.Revocation)
+				}
+			}
+		}
+	}()
+	return unsubscribe
+}
+
+// apply rebuilds the list from entries and the file, and notifies subscribers if
+// it changed.
+func (l *List) apply(entries ports.RevocationEntries) error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	list, err := l.load(entries)
+	if err != nil {
+		l.record(ReloadResultRejected, l.current.Load().Len())
+		l.logger.Error("revocation list reload rejected, keeping last good list",
+			"path", l.file, "error", err)
+		return fmt.Errorf("revocation list reload rejected: %w", err)
+	}
+
+	l.mu.Lock()
+	l.entries = entries
+	if reflect.DeepEqual(l.current.Load(), list) {
+		l.mu.Unlock()
+		l.record(ReloadResultUnchanged, list.Len())
+		return nil
+	}
+	l.current.Store(list)
+	for ch := range l.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	l.mu.Unlock()
+
+	l.record(ReloadResultApplied, list.Len())
+	l.logger.Info("revocation list reloaded", "path", l.file, "entries", list.Len())
+	return nil
+}
+
+func (l *List) record(result string, entries int) {
+	if l.metrics != nil {
+		l.metrics.RecordRevocationReload(result, entries)
+	}
+}
+
+// Len returns the number of entries in the current list.
+func (l *List) Len() int {
+	return l.current.Load().Len()
+}
+
+// CheckRevocation checks a certificate chain against the current list and counts
+// rejected peers.
+func (l *List) CheckRevocation(chain []*x509.Certificate) error {
+	err := l.current.Load().CheckChain(chain)
+	if err == nil {
+		return nil
+	}
+
+	var revoked *domain.RevokedError
+	if errors.As(err, &revoked) {
+		if l.metrics != nil {
+			l.metrics.RecordRevokedPeer(revoked.Match)
+		}
+		l.logger.Warn("rejected revoked peer certificate", "match", revoked.Match, "entry", revoked.Entry)
+	}
+	return err
+}
+
+// Subscribe returns a channel that receives a value after the list changes, and a
+// function that ends the subscription.
+func (l *List) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	l.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if _, ok := l.subscribers[ch]; ok {
+				delete(l.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Close stops watching and closes all subscription channels. The list keeps
+// rejecting the revoked entries it holds. It is safe to call Close multiple times.
+func (l *List) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	watcher := l.watcher
+	for ch := range l.subscribers {
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+	l.mu.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
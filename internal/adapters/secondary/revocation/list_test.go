@@ -0,0 +1,177 @@
+package revocation_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/revocation"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// metricsRecorder counts rejections and reload results.
+type metricsRecorder struct {
+	mu      sync.Mutex
+	revoked map[string]int
+	reloads map[string]int
+	entries int
+}
+
+func (m *metricsRecorder) RecordRevokedPeer(match string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.revoked == nil {
+		m.revoked = make(map[string]int)
+	}
+	m.revoked[match]++
+}
+
+func (m *metricsRecorder) RecordRevocationReload(result string, entries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reloads == nil {
+		m.reloads = make(map[string]int)
+	}
+	m.reloads[result]++
+	m.entries = entries
+}
+
+func (m *metricsRecorder) counts() (map[string]int, map[string]int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revoked, m.reloads, m.entries
+}
+
+func newPeerChain(t *testing.T, serial int64, id string) []*x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return []*x509.Certificate{cert}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestList_CombinesConfigAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked.yaml")
+	writeFile(t, path, "serials: [\"example.org/2a\"]\n")
+
+	metrics := &metricsRecorder{}
+	list, err := revocation.NewList(revocation.ListConfig{
+		Config: &ports.RevocationConfig{
+			SPIFFEIDs: []string{"spiffe://example.org/compromised"},
+			File:      path,
+		},
+		Metrics: metrics,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = list.Close() })
+
+	assert.Equal(t, 2, list.Len())
+	assert.ErrorIs(t, list.CheckRevocation(newPeerChain(t, 1, "spiffe://example.org/compromised")), domain.ErrRevoked)
+	assert.ErrorIs(t, list.CheckRevocation(newPeerChain(t, 42, "spiffe://example.org/api")), domain.ErrRevoked)
+	assert.NoError(t, list.CheckRevocation(newPeerChain(t, 1, "spiffe://example.org/api")))
+
+	revoked, reloads, entries := metrics.counts()
+	assert.Equal(t, map[string]int{domain.RevocationMatchSPIFFEID: 1, domain.RevocationMatchSerial: 1}, revoked)
+	assert.Equal(t, 1, reloads[revocation.ReloadResultApplied])
+	assert.Equal(t, 2, entries)
+}
+
+func TestList_ReloadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked.json")
+	writeFile(t, path, `{"spiffe_ids": []}`)
+
+	metrics := &metricsRecorder{}
+	list, err := revocation.NewList(revocation.ListConfig{
+		Config:   &ports.RevocationConfig{File: path},
+		Debounce: 10 * time.Millisecond,
+		Metrics:  metrics,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = list.Close() })
+
+	changes, unsubscribe := list.Subscribe()
+	defer unsubscribe()
+	require.NoError(t, list.Start())
+
+	peer := newPeerChain(t, 7, "spiffe://example.org/ns/dev/api")
+	require.NoError(t, list.CheckRevocation(peer))
+
+	writeFile(t, path, `{"id_prefixes": ["spiffe://example.org/ns/dev"]}`)
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("revocation list was not reloaded")
+	}
+	assert.ErrorIs(t, list.CheckRevocation(peer), domain.ErrRevoked)
+
+	// An invalid edit keeps the last good list
+	writeFile(t, path, `{"serials": ["example.org/not hex"]}`)
+	assert.Error(t, list.Reload())
+	assert.ErrorIs(t, list.CheckRevocation(peer), domain.ErrRevoked)
+
+	_, reloads, entries := metrics.counts()
+	assert.GreaterOrEqual(t, reloads[revocation.ReloadResultRejected], 1)
+	assert.Equal(t, 1, entries)
+}
+
+func TestList_Update(t *testing.T) {
+	list, err := revocation.NewList(revocation.ListConfig{Config: &ports.RevocationConfig{}})
+	require.NoError(t, err)
+	changes, _ := list.Subscribe()
+
+	peer := newPeerChain(t, 7, "spiffe://example.org/api")
+	require.NoError(t, list.CheckRevocation(peer))
+
+	require.NoError(t, list.Update(&ports.RevocationConfig{SPIFFEIDs: []string{"spiffe://example.org/api"}}))
+	<-changes
+	assert.ErrorIs(t, list.CheckRevocation(peer), domain.ErrRevoked)
+
+	assert.Error(t, list.Update(&ports.RevocationConfig{SPIFFEIDs: []string{"api"}}))
+	assert.ErrorIs(t, list.CheckRevocation(peer), domain.ErrRevoked)
+
+	require.NoError(t, list.Close())
+	require.NoError(t, list.Close())
+	_, ok := <-changes
+	assert.False(t, ok)
+}
+
+func TestNewList_RejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked.yaml")
+
+	_, err := revocation.NewList(revocation.ListConfig{Config: &ports.RevocationConfig{File: path}})
+	assert.Error(t, err)
+
+	writeFile(t, path, "spki_hashes: [\"short\"]\n")
+	_, err = revocation.NewList(revocation.ListConfig{Config: &ports.RevocationConfig{File: path}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,369 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// FederatedBundleSet keeps one X.509 bundle per federated trust domain, fetched from
+// the trust domains' SPIFFE bundle endpoints and refreshed on an interval.
+// Lookups for trust domains that are not federated are served by the local source.
+type FederatedBundleSet struct {
+	local    x509bundle.Source
+	webRoots *x509.CertPool
+	logger   *slog.Logger
+
+	mu         sync.RWMutex
+	interval   time.Duration
+	domains    []federatedDomain
+	bootstraps map[spiffeid.TrustDomain]*x509bundle.Bundle
+	bundles    map[spiffeid.TrustDomain]*x509bundle.Bundle
+	updatedAt  map[spiffeid.TrustDomain]time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// federatedDomain is a parsed federation entry.
+type federatedDomain struct {
+	trustDomain spiffeid.TrustDomain
+	url         string
+	profile     ports.BundleEndpointProfile
+	endpointID  spiffeid.ID
+	bootstrap   string
+}
+
+// FederatedBundleSetConfig provides configuration for the bundle set.
+type FederatedBundleSetConfig struct {
+	// Federation lists the federated trust domains. Required.
+	Federation *ports.FederationConfig
+	// LocalBundles serves the local trust domain and authenticates https_spiffe
+	// endpoints whose SPIFFE ID is in the local trust domain.
+	LocalBundles x509bundle.Source
+	// WebPKIRoots overrides the system roots for https_web endpoints.
+	WebPKIRoots *x509.CertPool
+	Logger      *slog.Logger
+}
+
+// NewFederatedBundleSet creates a bundle set. No bundles are fetched until Start or Refresh.
+func NewFederatedBundleSet(config FederatedBundleSetConfig) (*FederatedBundleSet, error) {
+	if config.Federation == nil {
+		return nil, fmt.Errorf("federation configuration is required")
+	}
+	if err := config.Federation.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid federation configuration: %w", err)
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	domains, bootstraps, err := parseFederatedDomains(config.Federation, config.LocalBundles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FederatedBundleSet{
+		local:      config.LocalBundles,
+		webRoots:   config.WebPKIRoots,
+		interval:   config.Federation.GetRefreshInterval(),
+		logger:     logger,
+		domains:    domains,
+		bootstraps: bootstraps,
+		bundles:    make(map[spiffeid.TrustDomain]*x509bundle.Bundle),
+		updatedAt:  make(map[spiffeid.TrustDomain]time.Time),
+	}, nil
+}
+
+// parseFederatedDomains parses the entries of a validated federation configuration
+// and loads their bootstrap bundles.
+func parseFederatedDomains(
+	federation *ports.FederationConfig,
+	local x509bundle.Source,
+) ([]federatedDomain, map[spiffeid.TrustDomain]*x509bundle.Bundle, error) {
+	domains := make([]federatedDomain, 0, len(federation.TrustDomains))
+	bootstraps := make(map[spiffeid.TrustDomain]*x509bundle.Bundle)
+	for _, entry := range federation.TrustDomains {
+		// Entries were validated by the caller, so parsing cannot fail
+		domain := federatedDomain{
+			trustDomain: spiffeid.RequireTrustDomainFromString(entry.TrustDomain),
+			url:         entry.BundleEndpointURL,
+			profile:     entry.Profile,
+		}
+		if entry.Profile == ports.BundleEndpointProfileHTTPSSPIFFE {
+			domain.endpointID = spiffeid.RequireFromString(entry.EndpointSPIFFEID)
+			domain.bootstrap = entry.BootstrapBundle
+			if local == nil && !domain.endpointID.MemberOf(domain.trustDomain) {
+				return nil, nil, fmt.Errorf("https_spiffe endpoint for %s requires local bundles", entry.TrustDomain)
+			}
+		}
+		if domain.bootstrap != "" {
+			bundle, err := x509bundle.Load(domain.trustDomain, domain.bootstrap)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to load bootstrap bundle for %s: %w", entry.TrustDomain, err)
+			}
+			bootstraps[domain.trustDomain] = bundle
+		}
+		domains = append(domains, domain)
+	}
+	return domains, bootstraps, nil
+}
+
+// Start fetches every federated bundle once and then keeps refreshing them in the
+// background until Close is called. Failures of the initial fetch are returned,
+// but polling continues so unavailable endpoints are retried.
+func (s *FederatedBundleSet) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("federated bundle set already started")
+	}
+	pollCtx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	err := s.Refresh(ctx)
+	go s.poll(pollCtx)
+	return err
+}
+
+// poll refreshes all bundles every interval until the context is cancelled.
+// The interval is read on every round, so updates take effect after the next refresh.
+func (s *FederatedBundleSet) poll(ctx context.Context) {
+	defer close(s.done)
+
+	for {
+		s.mu.RLock()
+		interval := s.interval
+		s.mu.RUnlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Warn("federated bundle refresh failed", "error", err)
+			}
+		}
+	}
+}
+
+// Refresh fetches every federated bundle once. A failed fetch keeps the previous
+// bundle for that trust domain; all failures are returned joined.
+func (s *FederatedBundleSet) Refresh(ctx context.Context) error {
+	s.mu.RLock()
+	domains := s.domains
+	s.mu.RUnlock()
+
+	var errs []error
+	for _, domain := range domains {
+		if err := s.refreshDomain(ctx, domain); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *FederatedBundleSet) refreshDomain(ctx context.Context, domain federatedDomain) error {
+	var options []federation.FetchOption
+	switch domain.profile {
+	case ports.BundleEndpointProfileHTTPSSPIFFE:
+		options = append(options, federation.WithSPIFFEAuth(endpointBundles{set: s, domain: domain.trustDomain}, domain.endpointID))
+	case ports.BundleEndpointProfileHTTPSWeb:
+		if s.webRoots != nil {
+			options = append(options, federation.WithWebPKIRoots(s.webRoots))
+		}
+	}
+
+	bundle, err := federation.FetchBundle(ctx, domain.trustDomain, domain.url, options...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch bundle for %s from %s: %w", domain.trustDomain, domain.url, err)
+	}
+
+	x509Bundle := bundle.X509Bundle()
+	if len(x509Bundle.X509Authorities()) == 0 {
+		return fmt.Errorf("bundle for %s from %s has no X.509 authorities", domain.trustDomain, domain.url)
+	}
+
+	s.mu.Lock()
+	// The domain may have been removed by Update while fetching
+	if !s.isFederatedLocked(domain.trustDomain) {
+		s.mu.Unlock()
+		return nil
+	}
+	s.bundles[domain.trustDomain] = x509Bundle
+	s.updatedAt[domain.trustDomain] = time.Now()
+	s.mu.Unlock()
+
+	s.logger.Debug("federated bundle refreshed",
+		"trust_domain", domain.trustDomain.String(),
+		"ca_count", len(x509Bundle.X509Authorities()))
+	return nil
+}
+
+// Update replaces the federated trust domains and the refresh interval. Bundles of
+// removed trust domains are dropped at once; added trust domains are fetched before
+// Update returns, and a failed fetch is returned but retried while polling.
+func (s *FederatedBundleSet) Update(ctx context.Context, federation *ports.FederationConfig) error {
+	if federation == nil {
+		federation = &ports.FederationConfig{}
+	}
+	if err := federation.Validate(); err != nil {
+		return fmt.Errorf("invalid federation configuration: %w", err)
+	}
+	domains, bootstraps, err := parseFederatedDomains(federation, s.local)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	known := make(map[spiffeid.TrustDomain]federatedDomain, len(s.domains))
+	for _, domain := range s.domains {
+		known[domain.trustDomain] = domain
+	}
+	s.domains = domains
+	s.bootstraps = bootstraps
+	s.interval = federation.GetRefreshInterval()
+
+	var changed []federatedDomain
+	for _, domain := range domains {
+		if previous, ok := known[domain.trustDomain]; !ok || previous != domain {
+			// A bundle from a different endpoint must not be served for the new one
+			delete(s.bundles, domain.trustDomain)
+			delete(s.updatedAt, domain.trustDomain)
+			changed = append(changed, domain)
+		}
+		delete(known, domain.trustDomain)
+	}
+	for trustDomain := range known {
+		delete(s.bundles, trustDomain)
+		delete(s.updatedAt, trustDomain)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, domain := range changed {
+		if err := s.refreshDomain(ctx, domain); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WatchConfig applies changes to the federation section published by the
+// configuration watcher until the returned function is called.
+func (s *FederatedBundleSet) WatchConfig(watcher ports.ConfigWatcherPort) func() {
+	events, unsubscribe := watcher.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range events {
+			if !slices.Contains(event.ChangedFields, "federation.trust_domains") {
+				continue
+			}
+			if err := s.Update(context.Background(), event.Current.Federation); err != nil {
+				s.logger.Warn("failed to apply federation configuration change", "error", err)
+			}
+		}
+	}()
+
+	return func() {
+		unsubscribe()
+		<-done
+	}
+}
+
+// GetX509BundleForTrustDomain implements x509bundle.Source. Federated trust domains
+// are served from the fetched bundles, all others from the local source.
+func (s *FederatedBundleSet) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	s.mu.RLock()
+	federated := s.isFederatedLocked(trustDomain)
+	bundle, ok := s.bundles[trustDomain]
+	s.mu.RUnlock()
+
+	if federated {
+		if !ok {
+			return nil, fmt.Errorf("bundle for federated trust domain %s has not been fetched yet", trustDomain)
+		}
+		return bundle, nil
+	}
+
+	if s.local == nil {
+		return nil, fmt.Errorf("no bundle available for trust domain %s", trustDomain)
+	}
+	return s.local.GetX509BundleForTrustDomain(trustDomain)
+}
+
+// endpointBundles authenticates the https_spiffe bundle endpoint of a federated trust
+// domain. The endpoint presents an SVID of its own trust domain, which is checked
+// against the last bundle fetched from it, or the bootstrap bundle before the first
+// fetch. Endpoint SVIDs of other trust domains are checked against the set.
+type endpointBundles struct {
+	set    *FederatedBundleSet
+	domain spiffeid.TrustDomain
+}
+
+// GetX509BundleForTrustDomain implements x509bundle.Source.
+func (e endpointBundles) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if trustDomain != e.domain {
+		return e.set.GetX509BundleForTrustDomain(trustDomain)
+	}
+
+	e.set.mu.RLock()
+	defer e.set.mu.RUnlock()
+	if bundle, ok := e.set.bundles[trustDomain]; ok {
+		return bundle, nil
+	}
+	if bundle, ok := e.set.bootstraps[trustDomain]; ok {
+		return bundle, nil
+	}
+	return nil, fmt.Errorf("no bundle to authenticate the bundle endpoint of %s: bootstrap_bundle is not configured", trustDomain)
+}
+
+// LastUpdated returns when the bundle for a federated trust domain was last fetched.
+func (s *FederatedBundleSet) LastUpdated(trustDomain spiffeid.TrustDomain) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	updated, ok := s.updatedAt[trustDomain]
+	return updated, ok
+}
+
+// isFederatedLocked reports whether trustDomain is federated. s.mu must be held.
+func (s *FederatedBundleSet) isFederatedLocked(trustDomain spiffeid.TrustDomain) bool {
+	for _, domain := range s.domains {
+		if domain.trustDomain == trustDomain {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops background refreshing. It is safe to call Close multiple times.
+func (s *FederatedBundleSet) Close() error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+	return nil
+}
@@ -0,0 +1,394 @@
+package spiffe_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/adapters/secondary/spiffe"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// newRootCert returns a self-signed CA certificate for use as a bundle authority.
+func newRootCert(t *testing.T, serial int64) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// bundleEndpoint serves the current bundle of a trust domain in SPIFFE bundle format.
+type bundleEndpoint struct {
+	server  *httptest.Server
+	current atomic.Pointer[x509.Certificate]
+	fail    atomic.Bool
+}
+
+func newBundleEndpoint(t *testing.T, td spiffeid.TrustDomain, root *x509.Certificate) *bundleEndpoint {
+	t.Helper()
+	endpoint := &bundleEndpoint{}
+	endpoint.current.Store(root)
+
+	endpoint.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if endpoint.fail.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		bundle := spiffebundle.FromX509Authorities(td, []*x509.Certificate{endpoint.current.Load()})
+		data, err := bundle.Marshal()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(endpoint.server.Close)
+	return endpoint
+}
+
+func (e *bundleEndpoint) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(e.server.Certificate())
+	return pool
+}
+
+func TestFederatedBundleSet(t *testing.T) {
+	partner := spiffeid.RequireTrustDomainFromString("partner.example")
+	local := spiffeid.RequireTrustDomainFromString("example.org")
+
+	partnerRoot := newRootCert(t, 1)
+	localRoot := newRootCert(t, 2)
+	endpoint := newBundleEndpoint(t, partner, partnerRoot)
+
+	set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation: &ports.FederationConfig{
+			TrustDomains: []ports.FederatedTrustDomainConfig{{
+				TrustDomain:       partner.String(),
+				BundleEndpointURL: endpoint.server.URL,
+				Profile:           ports.BundleEndpointProfileHTTPSWeb,
+			}},
+		},
+		LocalBundles: x509bundle.FromX509Authorities(local, []*x509.Certificate{localRoot}),
+		WebPKIRoots:  endpoint.roots(),
+	})
+	require.NoError(t, err)
+	defer set.Close()
+
+	t.Run("federated domain is unavailable before the first fetch", func(t *testing.T) {
+		_, err := set.GetX509BundleForTrustDomain(partner)
+		assert.Error(t, err)
+	})
+
+	require.NoError(t, set.Start(context.Background()))
+
+	t.Run("federated bundle is served per trust domain", func(t *testing.T) {
+		bundle, err := set.GetX509BundleForTrustDomain(partner)
+		require.NoError(t, err)
+		assert.Equal(t, partner, bundle.TrustDomain())
+		assert.True(t, bundle.HasX509Authority(partnerRoot))
+
+		_, ok := set.LastUpdated(partner)
+		assert.True(t, ok)
+	})
+
+	t.Run("local domain is served by the local source", func(t *testing.T) {
+		bundle, err := set.GetX509BundleForTrustDomain(local)
+		require.NoError(t, err)
+		assert.True(t, bundle.HasX509Authority(localRoot))
+	})
+
+	t.Run("refresh picks up rotated authorities", func(t *testing.T) {
+		rotated := newRootCert(t, 3)
+		endpoint.current.Store(rotated)
+
+		require.NoError(t, set.Refresh(context.Background()))
+		bundle, err := set.GetX509BundleForTrustDomain(partner)
+		require.NoError(t, err)
+		assert.True(t, bundle.HasX509Authority(rotated))
+		assert.False(t, bundle.HasX509Authority(partnerRoot))
+	})
+
+	t.Run("failed refresh keeps the previous bundle", func(t *testing.T) {
+		endpoint.fail.Store(true)
+		defer endpoint.fail.Store(false)
+
+		assert.Error(t, set.Refresh(context.Background()))
+		_, err := set.GetX509BundleForTrustDomain(partner)
+		assert.NoError(t, err)
+	})
+}
+
+func TestFederatedBundleSet_Polling(t *testing.T) {
+	partner := spiffeid.RequireTrustDomainFromString("partner.example")
+	endpoint := newBundleEndpoint(t, partner, newRootCert(t, 1))
+
+	set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation: &ports.FederationConfig{
+			RefreshInterval: 10 * time.Millisecond,
+			TrustDomains: []ports.FederatedTrustDomainConfig{{
+				TrustDomain:       partner.String(),
+				BundleEndpointURL: endpoint.server.URL,
+				Profile:           ports.BundleEndpointProfileHTTPSWeb,
+			}},
+		},
+		WebPKIRoots: endpoint.roots(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, set.Start(context.Background()))
+	rotated := newRootCert(t, 2)
+	endpoint.current.Store(rotated)
+
+	assert.Eventually(t, func() bool {
+		bundle, err := set.GetX509BundleForTrustDomain(partner)
+		return err == nil && bundle.HasX509Authority(rotated)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, set.Close())
+	require.NoError(t, set.Close())
+}
+
+func TestFederatedBundleSet_Update(t *testing.T) {
+	partner := spiffeid.RequireTrustDomainFromString("partner.example")
+	vendor := spiffeid.RequireTrustDomainFromString("vendor.example")
+	partnerEndpoint := newBundleEndpoint(t, partner, newRootCert(t, 1))
+	vendorRoot := newRootCert(t, 2)
+	vendorEndpoint := newBundleEndpoint(t, vendor, vendorRoot)
+
+	roots := partnerEndpoint.roots()
+	roots.AddCert(vendorEndpoint.server.Certificate())
+	entry := func(td spiffeid.TrustDomain, endpoint *bundleEndpoint) ports.FederatedTrustDomainConfig {
+		return ports.FederatedTrustDomainConfig{
+			TrustDomain:       td.String(),
+			BundleEndpointURL: endpoint.server.URL,
+			Profile:           ports.BundleEndpointProfileHTTPSWeb,
+		}
+	}
+
+	set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation:  &ports.FederationConfig{TrustDomains: []ports.FederatedTrustDomainConfig{entry(partner, partnerEndpoint)}},
+		WebPKIRoots: roots,
+	})
+	require.NoError(t, err)
+	defer set.Close()
+	require.NoError(t, set.Start(context.Background()))
+
+	err = set.Update(context.Background(), &ports.FederationConfig{
+		TrustDomains: []ports.FederatedTrustDomainConfig{entry(vendor, vendorEndpoint)},
+	})
+	require.NoError(t, err)
+
+	bundle, err := set.GetX509BundleForTrustDomain(vendor)
+	require.NoError(t, err, "added trust domains are fetched by Update")
+	assert.True(t, bundle.HasX509Authority(vendorRoot))
+
+	_, err = set.GetX509BundleForTrustDomain(partner)
+	assert.Error(t, err, "removed trust domains are no longer served")
+	_, ok := set.LastUpdated(partner)
+	assert.False(t, ok)
+
+	t.Run("invalid configuration is rejected", func(t *testing.T) {
+		err := set.Update(context.Background(), &ports.FederationConfig{
+			TrustDomains: []ports.FederatedTrustDomainConfig{{TrustDomain: "not a trust domain"}},
+		})
+		assert.Error(t, err)
+		_, err = set.GetX509BundleForTrustDomain(vendor)
+		assert.NoError(t, err, "the previous trust domains are kept")
+	})
+}
+
+func TestNewFederatedBundleSet_InvalidConfig(t *testing.T) {
+	_, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{})
+	assert.Error(t, err)
+
+	_, err = spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation: &ports.FederationConfig{
+			TrustDomains: []ports.FederatedTrustDomainConfig{{
+				TrustDomain:       "partner.example",
+				BundleEndpointURL: "https://bundle.partner.example",
+				Profile:           ports.BundleEndpointProfileHTTPSSPIFFE,
+				EndpointSPIFFEID:  "spiffe://example.org/spire/server",
+			}},
+		},
+	})
+	assert.Error(t, err, "https_spiffe endpoints of the local trust domain require local bundles")
+
+	_, err = spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation: &ports.FederationConfig{
+			TrustDomains: []ports.FederatedTrustDomainConfig{{
+				TrustDomain:       "partner.example",
+				BundleEndpointURL: "https://bundle.partner.example",
+				Profile:           ports.BundleEndpointProfileHTTPSSPIFFE,
+				EndpointSPIFFEID:  "spiffe://partner.example/spire/server",
+				BootstrapBundle:   filepath.Join(t.TempDir(), "missing.pem"),
+			}},
+		},
+	})
+	assert.Error(t, err, "the bootstrap bundle must be readable")
+}
+
+// spiffeBundleEndpoint serves a trust domain's bundle over TLS with an SVID of that
+// trust domain, as a SPIRE server's https_spiffe bundle endpoint does.
+type spiffeBundleEndpoint struct {
+	server      *httptest.Server
+	svid        atomic.Pointer[x509svid.SVID]
+	authorities atomic.Pointer[[]*x509.Certificate]
+}
+
+func newSPIFFEBundleEndpoint(t *testing.T, svid *x509svid.SVID, authorities ...*x509.Certificate) *spiffeBundleEndpoint {
+	t.Helper()
+	endpoint := &spiffeBundleEndpoint{}
+	endpoint.serve(svid, authorities...)
+
+	endpoint.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		bundle := spiffebundle.FromX509Authorities(svid.ID.TrustDomain(), *endpoint.authorities.Load())
+		data, err := bundle.Marshal()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	// httptest's own TLS setup would serve its test certificate instead of the SVID
+	endpoint.server.Listener = tls.NewListener(endpoint.server.Listener, &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			current := endpoint.svid.Load()
+			certificate := &tls.Certificate{PrivateKey: current.PrivateKey}
+			for _, cert := range current.Certificates {
+				certificate.Certificate = append(certificate.Certificate, cert.Raw)
+			}
+			return certificate, nil
+		},
+	})
+	endpoint.server.Start()
+	endpoint.server.URL = "https://" + endpoint.server.Listener.Addr().String()
+	t.Cleanup(endpoint.server.Close)
+	return endpoint
+}
+
+// serve switches the endpoint SVID and the authorities of the served bundle.
+func (e *spiffeBundleEndpoint) serve(svid *x509svid.SVID, authorities ...*x509.Certificate) {
+	e.svid.Store(svid)
+	e.authorities.Store(&authorities)
+}
+
+func writeBundle(t *testing.T, bundle *x509bundle.Bundle) string {
+	t.Helper()
+	data, err := bundle.Marshal()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "bootstrap.pem")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestFederatedBundleSet_HTTPSSPIFFE(t *testing.T) {
+	partner := spiffeid.RequireTrustDomainFromString("partner.example")
+	endpointID := spiffeid.RequireFromString("spiffe://partner.example/spire/server")
+
+	ca, err := memidentity.NewCA(partner, false)
+	require.NoError(t, err)
+	svid, err := ca.Issue(endpointID, time.Hour)
+	require.NoError(t, err)
+	root := ca.Bundle().X509Authorities()[0]
+	endpoint := newSPIFFEBundleEndpoint(t, svid, root)
+
+	entry := ports.FederatedTrustDomainConfig{
+		TrustDomain:       partner.String(),
+		BundleEndpointURL: endpoint.server.URL,
+		Profile:           ports.BundleEndpointProfileHTTPSSPIFFE,
+		EndpointSPIFFEID:  endpointID.String(),
+		BootstrapBundle:   writeBundle(t, ca.Bundle()),
+	}
+	set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation: &ports.FederationConfig{TrustDomains: []ports.FederatedTrustDomainConfig{entry}},
+	})
+	require.NoError(t, err)
+	defer set.Close()
+
+	// The first fetch is authenticated with the bootstrap bundle
+	require.NoError(t, set.Start(context.Background()))
+	bundle, err := set.GetX509BundleForTrustDomain(partner)
+	require.NoError(t, err)
+	assert.True(t, bundle.HasX509Authority(root))
+
+	// The partner rotates to a new CA, publishing both authorities first
+	rotatedCA, err := memidentity.NewCA(partner, false)
+	require.NoError(t, err)
+	rotatedRoot := rotatedCA.Bundle().X509Authorities()[0]
+	endpoint.serve(svid, root, rotatedRoot)
+	require.NoError(t, set.Refresh(context.Background()))
+
+	// Once the endpoint presents an SVID of the new CA, only the fetched bundle trusts it
+	rotatedSVID, err := rotatedCA.Issue(endpointID, time.Hour)
+	require.NoError(t, err)
+	endpoint.serve(rotatedSVID, rotatedRoot)
+	require.NoError(t, set.Refresh(context.Background()), "later fetches are authenticated with the last fetched bundle")
+	bundle, err = set.GetX509BundleForTrustDomain(partner)
+	require.NoError(t, err)
+	assert.True(t, bundle.HasX509Authority(rotatedRoot))
+	assert.False(t, bundle.HasX509Authority(root))
+
+	t.Run("endpoint with an untrusted SVID is rejected", func(t *testing.T) {
+		other, err := memidentity.NewCA(partner, false)
+		require.NoError(t, err)
+		untrusted := newSPIFFEBundleEndpoint(t, svid, root)
+
+		entry := entry
+		entry.BundleEndpointURL = untrusted.server.URL
+		entry.BootstrapBundle = writeBundle(t, other.Bundle())
+		set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+			Federation: &ports.FederationConfig{TrustDomains: []ports.FederatedTrustDomainConfig{entry}},
+		})
+		require.NoError(t, err)
+		defer set.Close()
+
+		assert.Error(t, set.Refresh(context.Background()))
+		_, err = set.GetX509BundleForTrustDomain(partner)
+		assert.Error(t, err)
+	})
+
+	t.Run("endpoint with another SPIFFE ID is rejected", func(t *testing.T) {
+		entry := entry
+		entry.EndpointSPIFFEID = "spiffe://partner.example/other"
+		set, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+			Federation: &ports.FederationConfig{TrustDomains: []ports.FederatedTrustDomainConfig{entry}},
+		})
+		require.NoError(t, err)
+		defer set.Close()
+
+		assert.Error(t, set.Refresh(context.Background()))
+	})
+}
@@ -0,0 +1,137 @@
+// Package spiffe provides SPIFFE JWT-SVID adapters.
+package spiffe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// JWTSVIDAdapter adapts the SPIFFE Workload API to JWTSVIDProviderPort and JWTBundleProviderPort.
+// The underlying JWTSource is created lazily on first use so that constructing
+// the adapter never blocks on the agent socket.
+type JWTSVIDAdapter struct {
+	socketPath domain.SocketPath
+	logger     *slog.Logger
+
+	mu     sync.Mutex
+	source *workloadapi.JWTSource
+}
+
+// JWTSVIDAdapterConfig provides configuration for the adapter.
+type JWTSVIDAdapterConfig struct {
+	SocketPath domain.SocketPath
+	Logger     *slog.Logger
+}
+
+// NewJWTSVIDAdapter creates a new SPIFFE JWT-SVID adapter.
+func NewJWTSVIDAdapter(config JWTSVIDAdapterConfig) (*JWTSVIDAdapter, error) {
+	if config.SocketPath.IsEmpty() {
+		return nil, fmt.Errorf("SPIFFE socket path must be explicitly configured - no fallback patterns allowed")
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &JWTSVIDAdapter{
+		socketPath: config.SocketPath,
+		logger:     logger,
+	}, nil
+}
+
+// getOrCreateSource ensures a JWTSource is created and returns it.
+func (a *JWTSVIDAdapter) getOrCreateSource(ctx context.Context) (*workloadapi.JWTSource, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.source != nil {
+		return a.source, nil
+	}
+
+	actualSocketPath := a.socketPath.WithUnixPrefix()
+	a.logger.Debug("creating JWT source", "socket_path", actualSocketPath)
+
+	source, err := workloadapi.NewJWTSource(
+		ctx,
+		workloadapi.WithClientOptions(
+			workloadapi.WithAddr(actualSocketPath),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT source: %w", err)
+	}
+
+	a.source = source
+	a.logger.Info("JWT source created successfully")
+	return source, nil
+}
+
+// FetchJWTSVID fetches a JWT-SVID for the given audiences from the Workload API.
+func (a *JWTSVIDAdapter) FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error) {
+	a.logger.Debug("fetching JWT-SVID from SPIFFE", "audience", audience)
+
+	source, err := a.getOrCreateSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	svid, err := source.FetchJWTSVID(ctx, jwtsvid.Params{
+		Audience:       audience,
+		ExtraAudiences: extraAudiences,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWT-SVID: %w", err)
+	}
+
+	a.logger.Debug("JWT-SVID retrieved",
+		"spiffe_id", svid.ID.String(),
+		"expires_at", svid.Expiry)
+
+	return svid, nil
+}
+
+// GetJWTBundleForTrustDomain retrieves the JWT bundle for a trust domain from the Workload API.
+func (a *JWTSVIDAdapter) GetJWTBundleForTrustDomain(ctx context.Context, trustDomain spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	source, err := a.getOrCreateSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := source.GetJWTBundleForTrustDomain(trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get JWT bundle for domain %s: %w", trustDomain, err)
+	}
+
+	return bundle, nil
+}
+
+// Close releases the JWT source if it was created.
+func (a *JWTSVIDAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.source != nil {
+		if err := a.source.Close(); err != nil {
+			return fmt.Errorf("failed to close JWT source: %w", err)
+		}
+		a.source = nil
+	}
+	return nil
+}
+
+// Ensure adapter implements the port interfaces
+var (
+	_ ports.JWTSVIDProviderPort   = (*JWTSVIDAdapter)(nil)
+	_ ports.JWTBundleProviderPort = (*JWTSVIDAdapter)(nil)
+)
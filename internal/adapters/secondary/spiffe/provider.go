@@ -116,6 +116,22 @@ func (p *Provider) GetX509Source() *workloadapi.X509Source {
 	return nil
 }
 
+// X509Source returns the shared Workload API X509Source, creating it if needed.
+// The source keeps the SVID and bundles current, so TLS configs built from it
+// pick up rotated certificates on new handshakes. The context bounds creation.
+func (p *Provider) X509Source(ctx context.Context) (*workloadapi.X509Source, error) {
+	if p.x509SourceProvider == nil {
+		return nil, fmt.Errorf("X509 source provider not initialized")
+	}
+	return p.x509SourceProvider.GetOrCreateSource(ctx)
+}
+
+// BundleProvider returns the trust bundle adapter, which follows bundle rotations
+// streamed by the Workload API.
+func (p *Provider) BundleProvider() ports.BundleProviderPort {
+	return p.bundleAdapter
+}
+
 // GetSocketPath returns path from X509 source provider.
 func (p *Provider) GetSocketPath() string {
 	if p.x509SourceProvider != nil {
@@ -11,18 +11,22 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 
 	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 // TLSAdapter provides SPIFFE-based TLS configuration.
 // This adapter creates TLS configurations using SPIFFE identities and trust bundles.
 type TLSAdapter struct {
 	x509SourceProvider *X509SourceProvider
+	tlsSettings        *ports.TLSConfig
 	logger        *slog.Logger
 }
 
 // TLSAdapterConfig provides configuration for the TLS adapter.
 type TLSAdapterConfig struct {
 	X509SourceProvider *X509SourceProvider
+	// TLS sets the minimum version, cipher suites and curves. Default: TLS 1.3.
+	TLS                *ports.TLSConfig
 	Logger        *slog.Logger
 }
 
@@ -39,6 +43,7 @@ func NewTLSAdapter(config TLSAdapterConfig) (*TLSAdapter, error) {
 
 	return &TLSAdapter{
 		x509SourceProvider: config.X509SourceProvider,
+		tlsSettings:        config.TLS,
 		logger:        logger,
 	}, nil
 }
@@ -60,6 +65,9 @@ func (a *TLSAdapter) CreateClientTLSConfig(ctx context.Context, policy *domain.A
 
 	// Create SPIFFE mTLS client config
 	tlsConfig := tlsconfig.MTLSClientConfig(x509Source, x509Source, authorizer)
+	if err := a.tlsSettings.Apply(tlsConfig); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
 
 	a.logger.Debug("client TLS config created")
 	return tlsConfig, nil
@@ -82,6 +90,9 @@ func (a *TLSAdapter) CreateServerTLSConfig(ctx context.Context, policy *domain.A
 
 	// Create SPIFFE mTLS server config
 	tlsConfig := tlsconfig.MTLSServerConfig(x509Source, x509Source, authorizer)
+	if err := a.tlsSettings.Apply(tlsConfig); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
 
 	a.logger.Debug("server TLS config created")
 	return tlsConfig, nil
@@ -108,6 +119,9 @@ func (a *TLSAdapter) CreateClientTLSConfigWithTarget(ctx context.Context, target
 
 	// Create SPIFFE mTLS client config
 	tlsConfig := tlsconfig.MTLSClientConfig(x509Source, x509Source, authorizer)
+	if err := a.tlsSettings.Apply(tlsConfig); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
 
 	a.logger.Debug("client TLS config created for target",
 		"target", targetSPIFFEID)
@@ -149,6 +163,9 @@ func (a *TLSAdapter) CreateServerTLSConfigWithAllowedClients(ctx context.Context
 
 	// Create SPIFFE mTLS server config
 	tlsConfig := tlsconfig.MTLSServerConfig(x509Source, x509Source, authorizer)
+	if err := a.tlsSettings.Apply(tlsConfig); err != nil {
+		return nil, fmt.Errorf("invalid TLS settings: %w", err)
+	}
 
 	a.logger.Debug("server TLS config created with allowed clients")
 	return tlsConfig, nil
@@ -0,0 +1,72 @@
+package spiffe_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/adapters/secondary/spiffe"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/testing/workloadapitest"
+)
+
+func TestX509SourceProvider_AdaptersAgainstWorkloadAPI(t *testing.T) {
+	id := spiffeid.RequireFromString("spiffe://example.org/api")
+	ca, err := memidentity.NewCA(id.TrustDomain(), true)
+	require.NoError(t, err)
+	svid, err := ca.Issue(id, time.Hour)
+	require.NoError(t, err)
+
+	server := workloadapitest.New(t)
+	server.SetX509Bundles(ca.Bundle())
+	server.SetX509SVIDs(svid)
+
+	sources := spiffe.NewX509SourceProvider(domain.NewSocketPathUnsafe(server.SocketPath()), nil)
+	t.Cleanup(func() { _ = sources.Close() })
+	identity, err := spiffe.NewIdentityDocumentAdapter(spiffe.IdentityDocumentAdapterConfig{X509SourceProvider: sources})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = identity.Close() })
+	bundles, err := spiffe.NewSpiffeBundleAdapter(spiffe.SpiffeBundleAdapterConfig{X509SourceProvider: sources})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bundles.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	got, err := identity.GetServiceIdentity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+	cert, err := identity.GetCertificate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, svid.Certificates[0].SerialNumber, cert.Cert.SerialNumber)
+
+	bundle, err := bundles.GetTrustBundle(ctx)
+	require.NoError(t, err)
+	assert.True(t, bundle.Equal(ca.Bundle()))
+
+	// Both adapters share the provider's single Workload API stream
+	assert.True(t, sources.IsInitialized())
+	assert.Equal(t, 1, server.Calls(workloadapitest.FetchX509SVID))
+
+	// Rotations pushed by the agent reach identity watchers
+	changes, err := identity.WatchIdentityChanges(ctx)
+	require.NoError(t, err)
+	rotated, err := ca.Issue(id, time.Hour)
+	require.NoError(t, err)
+	server.SetX509SVIDs(rotated)
+	for {
+		select {
+		case update := <-changes:
+			if update.Certificates[0].SerialNumber.Cmp(rotated.Certificates[0].SerialNumber) == 0 {
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("rotated SVID not delivered to the identity watcher")
+		}
+	}
+}
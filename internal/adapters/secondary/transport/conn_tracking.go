@@ -0,0 +1,344 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// localLeaf returns the leaf of the chain returned by localChain, if any.
+func localLeaf(localChain func() []*x509.Certificate) *x509.Certificate {
+	if localChain == nil {
+		return nil
+	}
+	if chain := localChain(); len(chain) > 0 {
+		return chain[0]
+	}
+	return nil
+}
+
+// trackedConn unregisters a tracked connection when it is closed.
+type trackedConn struct {
+	net.Conn
+	untrack func()
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		if c.untrack != nil {
+			c.untrack()
+		}
+	})
+	return err
+}
+
+// trackingClientCredentials are client credentials that report every dialed
+// connection to the connection tracker. Closing a tracked connection makes the
+// gRPC client reconnect, which authenticates the server again.
+type trackingClientCredentials struct {
+	credentials.TransportCredentials
+	tracker    ports.ConnectionTrackerPort
+	localChain func() []*x509.Certificate
+}
+
+func (c *trackingClientCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, authInfo, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok {
+		return conn, authInfo, nil
+	}
+
+	tracked := &trackedConn{Conn: conn}
+	tracked.untrack = trackConnection(c.tracker, ports.TrackedConnection{
+		ID:               "grpc-client " + conn.LocalAddr().String(),
+		Transport:        "grpc",
+		RemoteAddr:       conn.RemoteAddr().String(),
+		State:            tlsInfo.State,
+		LocalCertificate: localLeaf(c.localChain),
+		Close:            tracked.Close,
+	})
+	return tracked, authInfo, nil
+}
+
+func (c *trackingClientCredentials) Clone() credentials.TransportCredentials {
+	return &trackingClientCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		tracker:              c.tracker,
+		localChain:           c.localChain,
+	}
+}
+
+// TrackingTLSDialer returns an http.Transport DialTLSContext function that dials
+// with config and reports every connection to tracker. localChain returns the
+// client's current SVID chain. The connections are *tls.Conn, so HTTP/2 is
+// negotiated as usual.
+func TrackingTLSDialer(config *tls.Config, tracker ports.ConnectionTrackerPort, localChain func() []*x509.Certificate) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		raw, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		// Fill in what http.Transport sets when it dials TLS itself
+		cfg := config.Clone()
+		if cfg.ServerName == "" {
+			if host, _, err := net.SplitHostPort(addr); err == nil {
+				cfg.ServerName = host
+			}
+		}
+		if len(cfg.NextProtos) == 0 {
+			cfg.NextProtos = []string{"h2", "http/1.1"}
+		}
+
+		// Closing the TLS connection closes tracked, which unregisters it
+		tracked := &trackedConn{Conn: raw}
+		conn := tls.Client(tracked, cfg)
+		if err := conn.HandshakeContext(ctx); err != nil {
+			_ = raw.Close()
+			return nil, err
+		}
+
+		tracked.untrack = trackConnection(tracker, ports.TrackedConnection{
+			ID:               "http-client " + raw.LocalAddr().String(),
+			Transport:        "http",
+			RemoteAddr:       raw.RemoteAddr().String(),
+			State:            conn.ConnectionState(),
+			LocalCertificate: localLeaf(localChain),
+			Close:            conn.Close,
+		})
+		return conn, nil
+	}
+}
+
+// TrackHTTPServer reports the connections server accepts on listener to tracker and
+// returns the listener to serve on. It sets server.ConnContext and wraps
+// server.Handler, so it must be called before serving. localChain returns the
+// server's current SVID chain.
+//
+// A connection is reported on its first request. When the tracker closes it, the
+// connection is drained: it closes once idle, or at the end of the grace period,
+// and requests starting meanwhile are answered with "Connection: close", which
+// makes HTTP/2 send GOAWAY.
+func TrackHTTPServer(server *http.Server, listener net.Listener, tracker ports.ConnectionTrackerPort, localChain func() []*x509.Certificate, grace time.Duration) net.Listener {
+	tracking := &httpConnTracking{tracker: tracker, localChain: localChain}
+
+	connContext := server.ConnContext
+	server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		if tlsConn, ok := c.(*tls.Conn); ok {
+			if conn, ok := tlsConn.NetConn().(*httpConn); ok {
+				ctx = context.WithValue(ctx, httpConnKey{}, conn)
+			}
+		}
+		return ctx
+	}
+	server.Handler = tracking.handler(server.Handler)
+
+	return &httpTrackingListener{Listener: listener, grace: grace}
+}
+
+// httpConnKey is the context key of the connection of an HTTP request.
+type httpConnKey struct{}
+
+// httpConnTracking reports HTTP connections to the tracker.
+type httpConnTracking struct {
+	tracker    ports.ConnectionTrackerPort
+	localChain func() []*x509.Certificate
+}
+
+// handler tracks the connection of every request.
+func (t *httpConnTracking) handler(next http.Handler) http.Handler {
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _ := r.Context().Value(httpConnKey{}).(*httpConn)
+		if conn == nil || r.TLS == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		conn.trackOnce.Do(func() {
+			untrack := trackConnection(t.tracker, ports.TrackedConnection{
+				ID:               "http-server " + conn.RemoteAddr().String(),
+				Transport:        "http",
+				RemoteAddr:       conn.RemoteAddr().String(),
+				State:            *r.TLS,
+				LocalCertificate: localLeaf(t.localChain),
+				Close:            func() error { conn.drain(); return nil },
+			})
+			conn.setUntrack(untrack)
+		})
+
+		if conn.begin() {
+			w.Header().Set("Connection", "close")
+		}
+		defer conn.end()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpTrackingListener accepts connections that can be drained.
+type httpTrackingListener struct {
+	net.Listener
+	grace time.Duration
+}
+
+func (l *httpTrackingListener) Accept() (net.Conn, error) {
+	raw, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	conn := &httpConn{}
+	conn.drainConn = newDrainConn(raw, l.grace, conn.forget)
+	return conn, nil
+}
+
+// httpConn is an accepted HTTP connection, below TLS.
+type httpConn struct {
+	*drainConn
+	trackOnce sync.Once
+
+	mu      sync.Mutex
+	untrack func()
+	closed  bool
+}
+
+// setUntrack records how to unregister the connection, or unregisters it at once
+// if the connection is already closed.
+func (c *httpConn) setUntrack(untrack func()) {
+	if untrack == nil {
+		return
+	}
+	c.mu.Lock()
+	closed := c.closed
+	if !closed {
+		c.untrack = untrack
+	}
+	c.mu.Unlock()
+	if closed {
+		untrack()
+	}
+}
+
+// forget unregisters the connection once it is closed.
+func (c *httpConn) forget() {
+	c.mu.Lock()
+	c.closed = true
+	untrack := c.untrack
+	c.mu.Unlock()
+	if untrack != nil {
+		untrack()
+	}
+}
+
+// drainConn is a server connection that can be drained: once draining, it closes as
+// soon as its in-flight requests finish, or at the end of the grace period.
+type drainConn struct {
+	net.Conn
+	grace   time.Duration
+	onClose func() // called once after the connection is closed; optional
+
+	mu       sync.Mutex
+	deadline *time.Timer
+	forced   *time.Timer
+	active   int
+	draining bool
+	closed   bool
+}
+
+// newDrainConn wraps conn. grace bounds how long in-flight requests may run once
+// the connection is draining.
+func newDrainConn(conn net.Conn, grace time.Duration, onClose func()) *drainConn {
+	return &drainConn{Conn: conn, grace: grace, onClose: onClose}
+}
+
+// drainAt starts draining the connection at deadline.
+func (c *drainConn) drainAt(deadline time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.deadline = time.AfterFunc(max(time.Until(deadline), 0), c.drain)
+	}
+}
+
+// begin records a request starting on the connection. It reports whether the
+// connection is draining.
+func (c *drainConn) begin() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.active++
+	return c.draining
+}
+
+// end records the end of a request, closing a draining connection once idle.
+func (c *drainConn) end() {
+	c.mu.Lock()
+	c.active--
+	idle := c.draining && c.active == 0
+	c.mu.Unlock()
+
+	if idle {
+		_ = c.Close()
+	}
+}
+
+// drain closes the connection now if it is idle, otherwise once its requests finish
+// or the grace period ends. Draining more than once has no further effect.
+func (c *drainConn) drain() {
+	c.mu.Lock()
+	if c.closed || c.draining {
+		c.mu.Unlock()
+		return
+	}
+	c.draining = true
+	if c.deadline != nil {
+		c.deadline.Stop()
+	}
+	idle := c.active == 0
+	c.forced = time.AfterFunc(c.grace, func() { _ = c.Close() })
+	c.mu.Unlock()
+
+	if idle {
+		_ = c.Close()
+	}
+}
+
+// Close stops the timers and closes the connection.
+func (c *drainConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.deadline != nil {
+		c.deadline.Stop()
+	}
+	if c.forced != nil {
+		c.forced.Stop()
+	}
+	c.mu.Unlock()
+
+	err := c.Conn.Close()
+	if c.onClose != nil {
+		c.onClose()
+	}
+	return err
+}
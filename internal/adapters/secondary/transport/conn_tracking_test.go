@@ -0,0 +1,109 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// svidKeyPair returns a self-signed TLS certificate carrying the SPIFFE ID.
+func svidKeyPair(t *testing.T, spiffeID string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestTrackHTTPServer(t *testing.T) {
+	serverCert := svidKeyPair(t, "spiffe://example.org/server")
+	clientCert := svidKeyPair(t, "spiffe://example.org/client")
+	tracker := newFakeTracker()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = io.WriteString(w, r.Proto) }),
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientAuth:   tls.RequireAnyClientCert,
+			MinVersion:   tls.VersionTLS13,
+		},
+	}
+	serverChain := func() []*x509.Certificate { return []*x509.Certificate{serverCert.Leaf} }
+	tracked := TrackHTTPServer(server, listener, tracker, serverChain, time.Second)
+	go func() { _ = server.ServeTLS(tracked, "", "") }()
+	defer server.Close()
+
+	clientConfig := &tls.Config{
+		Certificates:       []tls.Certificate{clientCert},
+		InsecureSkipVerify: true, // self-signed test certificates
+		MinVersion:         tls.VersionTLS13,
+	}
+	clientChain := func() []*x509.Certificate { return []*x509.Certificate{clientCert.Leaf} }
+	client := &http.Client{Transport: &http.Transport{
+		DialTLSContext:    TrackingTLSDialer(clientConfig, tracker, clientChain),
+		ForceAttemptHTTP2: true,
+	}}
+	defer client.CloseIdleConnections()
+
+	get := func() string {
+		resp, err := client.Get("https://" + listener.Addr().String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, "HTTP/2.0", get(), "tracking must not prevent HTTP/2")
+
+	conns := tracker.tracked()
+	require.Len(t, conns, 2)
+	var serverSide, clientSide int
+	for i, conn := range conns {
+		assert.Equal(t, "http", conn.Transport)
+		if strings.HasPrefix(conn.ID, "http-server ") {
+			serverSide = i
+		} else {
+			clientSide = i
+		}
+	}
+	assert.Same(t, serverCert.Leaf, conns[serverSide].LocalCertificate)
+	assert.Equal(t, clientCert.Leaf.Raw, conns[serverSide].State.PeerCertificates[0].Raw)
+	assert.Same(t, clientCert.Leaf, conns[clientSide].LocalCertificate)
+	assert.Equal(t, serverCert.Leaf.Raw, conns[clientSide].State.PeerCertificates[0].Raw)
+
+	// Closing the server side through the tracker closes the idle connection, and
+	// the client notices and stops tracking its side too
+	require.NoError(t, conns[serverSide].Close())
+	assert.Eventually(t, func() bool { return len(tracker.tracked()) == 0 }, 2*time.Second, 10*time.Millisecond)
+
+	// The client reconnects
+	assert.Equal(t, "HTTP/2.0", get())
+	assert.Len(t, tracker.tracked(), 2)
+}
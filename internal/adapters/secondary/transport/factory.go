@@ -16,6 +16,14 @@ func CreateGRPCProvider(cfg *ports.Configuration, opts ...ProviderOption) (*Rota
 	// Create trust domain provider using dependency injection
 	trustProvider := config.NewTrustDomainAdapter(cfg)
 	provider := NewRotatableGRPCProvider(trustProvider)
+	if cfg != nil {
+		if err := provider.SetTLSConfig(cfg.EffectiveTLS()); err != nil {
+			return nil, err
+		}
+		if err := provider.SetGRPCConfig(cfg.GRPC()); err != nil {
+			return nil, err
+		}
+	}
 
 	// Apply options - collect any errors
 	for _, opt := range opts {
@@ -40,6 +48,38 @@ func WithSources(svidSource x509svid.Source, bundleSource x509bundle.Source, aut
 	}
 }
 
+// WithAuthorization enforces a per-method authorization policy on servers and records
+// the decisions to audit. Either may be nil.
+func WithAuthorization(policy ports.PolicyEvaluatorPort, audit ports.AuditRecorderPort) ProviderOption {
+	return func(provider interface{}) error {
+		if p, ok := provider.(*RotatableGRPCProvider); ok {
+			p.SetAuthorization(policy, audit)
+		}
+		return nil
+	}
+}
+
+// WithRevocation rejects peers on the revocation list at handshake. May be nil.
+func WithRevocation(list ports.RevocationListPort) ProviderOption {
+	return func(provider interface{}) error {
+		if p, ok := provider.(*RotatableGRPCProvider); ok {
+			p.SetRevocation(list)
+		}
+		return nil
+	}
+}
+
+// WithMetrics reports handshakes, connections and authorization decisions to
+// metrics. May be nil.
+func WithMetrics(metrics ports.MTLSMetricsPort) ProviderOption {
+	return func(provider interface{}) error {
+		if p, ok := provider.(*RotatableGRPCProvider); ok {
+			p.SetMetrics(metrics)
+		}
+		return nil
+	}
+}
+
 // WithIdentityProvider creates sources from an identity provider for rotation support.
 // The identity provider must implement the IdentityProvider interface.
 func WithIdentityProvider(identityProvider IdentityProvider) ProviderOption {
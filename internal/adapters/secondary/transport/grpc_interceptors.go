@@ -0,0 +1,305 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// peerIdentityContextKey is the context key for the server-side peer identity.
+type peerIdentityContextKey struct{}
+
+// PeerIdentityFromContext returns the caller identity stored by the server interceptors.
+func PeerIdentityFromContext(ctx context.Context) (*ports.PeerIdentity, bool) {
+	identity, ok := ctx.Value(peerIdentityContextKey{}).(*ports.PeerIdentity)
+	return identity, ok && identity != nil
+}
+
+// peerIdentityFromPeer extracts the SPIFFE identity from the TLS state of a gRPC peer.
+// go-spiffe verifies peers itself and leaves VerifiedChains empty, so the presented
+// chain is used when no verified chain is available.
+func peerIdentityFromPeer(p *peer.Peer) (*ports.PeerIdentity, error) {
+	if p == nil {
+		return nil, fmt.Errorf("no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, fmt.Errorf("connection is not authenticated with TLS")
+	}
+
+	chain := tlsInfo.State.PeerCertificates
+	if len(tlsInfo.State.VerifiedChains) > 0 {
+		chain = tlsInfo.State.VerifiedChains[0]
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("peer presented no certificate")
+	}
+
+	id, err := x509svid.IDFromCert(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("peer certificate has no SPIFFE ID: %w", err)
+	}
+
+	identity := &ports.PeerIdentity{
+		ID:           id.String(),
+		TrustDomain:  id.TrustDomain().String(),
+		Certificates: chain,
+	}
+	if p.Addr != nil {
+		identity.RemoteAddr = p.Addr.String()
+	}
+	return identity, nil
+}
+
+// ServerInterceptorConfig configures the server interceptors.
+type ServerInterceptorConfig struct {
+	// Policy authorizes each call by its full method name. Optional; without a
+	// policy every peer that passed the handshake may call every method.
+	Policy ports.PolicyEvaluatorPort
+	// Audit records authorization decisions. Optional.
+	Audit ports.AuditRecorderPort
+	// Metrics counts authorization decisions by rule. Optional.
+	Metrics ports.MTLSMetricsPort
+	// LocalID is the server SPIFFE ID reported in audit events.
+	LocalID string
+}
+
+// UnaryServerInterceptor stores the caller identity in the context and enforces
+// the authorization policy, failing denied calls with codes.PermissionDenied.
+func UnaryServerInterceptor(config ServerInterceptorConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := config.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(config ServerInterceptorConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := config.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize attaches the caller identity to ctx and evaluates the policy.
+// Calls without a SPIFFE identity are only let through when no policy is configured,
+// which happens in development mode with certificate validation disabled.
+func (c *ServerInterceptorConfig) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	p, _ := peer.FromContext(ctx)
+	identity, err := peerIdentityFromPeer(p)
+	if err != nil {
+		if c.Policy == nil {
+			return ctx, nil
+		}
+		return ctx, status.Errorf(codes.Unauthenticated, "caller identity required: %v", err)
+	}
+	ctx = context.WithValue(ctx, peerIdentityContextKey{}, identity)
+
+	if c.Policy == nil {
+		return ctx, nil
+	}
+
+	decision := c.Policy.Evaluate(ports.AccessRequest{PeerID: identity.ID, GRPCMethod: fullMethod})
+	if c.Audit != nil {
+		c.Audit.RecordAuditEvent(ctx, c.auditEvent(identity, fullMethod, decision))
+	}
+	if c.Metrics != nil {
+		c.Metrics.RecordAuthorization(decision.Rule, decision.Allowed)
+	}
+	if !decision.Allowed {
+		return ctx, status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s (rule %q)",
+			identity.ID, fullMethod, decision.Rule)
+	}
+	return ctx, nil
+}
+
+func (c *ServerInterceptorConfig) auditEvent(identity *ports.PeerIdentity, fullMethod string, decision ports.PolicyDecision) ports.AuditEvent {
+	event := ports.AuditEvent{
+		Kind:       ports.AuditKindAuthorization,
+		Decision:   ports.AuditDecisionAllow,
+		Transport:  "grpc",
+		Operation:  fullMethod,
+		PeerID:     identity.ID,
+		LocalID:    c.LocalID,
+		RemoteAddr: identity.RemoteAddr,
+		Rule:       decision.Rule,
+		Reason:     decision.Reason,
+	}
+	if leaf := identity.Certificates[0]; leaf != nil {
+		if leaf.SerialNumber != nil {
+			event.PeerSerial = leaf.SerialNumber.Text(16)
+		}
+		event.PeerExpiry = leaf.NotAfter
+	}
+	if !decision.Allowed {
+		event.Decision = ports.AuditDecisionDeny
+		event.ErrorClass = ports.AuditErrorPolicyDenied
+	}
+	return event
+}
+
+// identityServerStream overrides the stream context to carry the caller identity.
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// expectedServerOption is a CallOption carrying the expected server identity of one call.
+type expectedServerOption struct {
+	grpc.EmptyCallOption
+	matcher ports.PeerMatcherConfig
+}
+
+// ExpectServerID returns a CallOption requiring the server of this call to have the
+// given SPIFFE ID. It overrides the expected server identity of the interceptors;
+// connections dialed with a SPIFFE ID also check that ID in the handshake.
+func ExpectServerID(id string) grpc.CallOption {
+	return expectedServerOption{matcher: ports.PeerMatcherConfig{ID: id}}
+}
+
+// ClientInterceptorConfig configures the client interceptors.
+type ClientInterceptorConfig struct {
+	// ExpectedServer matches the server SPIFFE ID of every call. With an empty
+	// matcher only calls made with ExpectServerID are checked; other calls rely on
+	// the handshake authorizer alone.
+	ExpectedServer ports.PeerMatcherConfig
+}
+
+// expected returns the matcher for a call, preferring a per-call ExpectServerID.
+func (c *ClientInterceptorConfig) expected(opts []grpc.CallOption) (ports.PeerMatcherConfig, bool) {
+	for _, opt := range opts {
+		if o, ok := opt.(expectedServerOption); ok {
+			return o.matcher, true
+		}
+	}
+	matcher := c.ExpectedServer
+	return matcher, matcher != (ports.PeerMatcherConfig{})
+}
+
+// UnaryClientInterceptor checks the server SPIFFE ID of each call. A response from
+// an unexpected server is discarded and the call fails with codes.Unauthenticated.
+// The request itself is kept from an unexpected server by the handshake check of
+// connections dialed with a SPIFFE ID; a per-call ExpectServerID is only checked
+// once the call completed.
+func UnaryClientInterceptor(config ClientInterceptorConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		matcher, ok := config.expected(opts)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+		if p.AuthInfo == nil {
+			// The call never reached a server
+			return err
+		}
+		if verifyErr := verifyServer(&p, &matcher, method); verifyErr != nil {
+			return verifyErr
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor checks the server SPIFFE ID when a stream is established,
+// before any message is sent, and cancels streams to unexpected servers.
+func StreamClientInterceptor(config ClientInterceptorConfig) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		matcher, ok := config.expected(opts)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		p, _ := peer.FromContext(stream.Context())
+		if verifyErr := verifyServer(p, &matcher, method); verifyErr != nil {
+			cancel()
+			return nil, verifyErr
+		}
+		return &cancelOnDoneClientStream{ClientStream: stream, cancel: cancel}, nil
+	}
+}
+
+// verifyServer checks the server identity of a call against the matcher.
+func verifyServer(p *peer.Peer, matcher *ports.PeerMatcherConfig, method string) error {
+	identity, err := peerIdentityFromPeer(p)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "cannot verify server identity for %s: %v", method, err)
+	}
+	if !matcher.Matches(identity.ID) {
+		return status.Errorf(codes.Unauthenticated, "server %s does not match %s for %s",
+			identity.ID, matcher.String(), method)
+	}
+	return nil
+}
+
+// authorizeServer returns a copy of a client TLS config whose handshakes also require
+// the server SPIFFE ID to match matcher, so no call reaches an unexpected server.
+func authorizeServer(config *tls.Config, matcher ports.PeerMatcherConfig) *tls.Config {
+	authorized := config.Clone()
+	verify := config.VerifyPeerCertificate
+	authorized.VerifyPeerCertificate = func(raw [][]byte, chains [][]*x509.Certificate) error {
+		if verify != nil {
+			if err := verify(raw, chains); err != nil {
+				return err
+			}
+		}
+		if len(raw) == 0 {
+			return fmt.Errorf("%w: server presented no certificate", domain.ErrNoPeerCertificate)
+		}
+		leaf, err := x509.ParseCertificate(raw[0])
+		if err != nil {
+			return fmt.Errorf("%w: %w", domain.ErrInvalidPeerIdentity, err)
+		}
+		id, err := x509svid.IDFromCert(leaf)
+		if err != nil {
+			return fmt.Errorf("%w: %w", domain.ErrInvalidPeerIdentity, err)
+		}
+		if !matcher.Matches(id.String()) {
+			return fmt.Errorf("%w: server %s does not match %s", domain.ErrUnauthorizedPeer, id, matcher.String())
+		}
+		return nil
+	}
+	return authorized
+}
+
+// cancelOnDoneClientStream releases the interceptor's context once the stream ends.
+type cancelOnDoneClientStream struct {
+	grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (s *cancelOnDoneClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.cancel()
+	}
+	return err
+}
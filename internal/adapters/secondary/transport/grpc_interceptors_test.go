@@ -0,0 +1,310 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// svidCert returns a self-signed certificate carrying the SPIFFE ID.
+func svidCert(t *testing.T, spiffeID string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func tlsPeer(t *testing.T, spiffeID string) *peer.Peer {
+	return &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{svidCert(t, spiffeID)},
+		}},
+	}
+}
+
+// staticPolicy allows only the listed peers.
+type staticPolicy map[string]bool
+
+func (p staticPolicy) Evaluate(request ports.AccessRequest) ports.PolicyDecision {
+	if p[request.PeerID] {
+		return ports.PolicyDecision{Allowed: true, Rule: "allow-list"}
+	}
+	return ports.PolicyDecision{Rule: "default", Reason: "not on the allow-list"}
+}
+
+type recordingAudit struct {
+	events []ports.AuditEvent
+}
+
+func (r *recordingAudit) RecordAuditEvent(_ context.Context, event ports.AuditEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	audit := &recordingAudit{}
+	metrics := newRecordingMetrics()
+	interceptor := UnaryServerInterceptor(ServerInterceptorConfig{
+		Policy:  staticPolicy{"spiffe://example.org/allowed": true},
+		Audit:   audit,
+		Metrics: metrics,
+		LocalID: "spiffe://example.org/server",
+	})
+	info := &grpc.UnaryServerInfo{FullMethod: "/billing.v1.Billing/Charge"}
+
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		identity, ok := PeerIdentityFromContext(ctx)
+		require.True(t, ok)
+		return identity.ID, nil
+	}
+
+	t.Run("allowed caller sees its identity", func(t *testing.T) {
+		ctx := peer.NewContext(context.Background(), tlsPeer(t, "spiffe://example.org/allowed"))
+		resp, err := interceptor(ctx, nil, info, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "spiffe://example.org/allowed", resp)
+	})
+
+	t.Run("other caller is denied", func(t *testing.T) {
+		ctx := peer.NewContext(context.Background(), tlsPeer(t, "spiffe://example.org/other"))
+		_, err := interceptor(ctx, nil, info, handler)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Contains(t, err.Error(), `rule "default"`)
+	})
+
+	t.Run("caller without certificate is unauthenticated", func(t *testing.T) {
+		_, err := interceptor(context.Background(), nil, info, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	require.Len(t, audit.events, 2)
+	assert.Equal(t, ports.AuditDecisionAllow, audit.events[0].Decision)
+	assert.Equal(t, ports.AuditDecisionDeny, audit.events[1].Decision)
+	assert.Equal(t, "/billing.v1.Billing/Charge", audit.events[1].Operation)
+	assert.Equal(t, ports.AuditErrorPolicyDenied, audit.events[1].ErrorClass)
+	assert.Equal(t, "2a", audit.events[1].PeerSerial)
+	assert.Equal(t, "spiffe://example.org/server", audit.events[1].LocalID)
+	assert.Equal(t, []string{"allow-list/true", "default/false"}, metrics.authorized)
+}
+
+func TestUnaryServerInterceptor_WithoutPolicy(t *testing.T) {
+	interceptor := UnaryServerInterceptor(ServerInterceptorConfig{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/billing.v1.Billing/Charge"}
+
+	ctx := peer.NewContext(context.Background(), tlsPeer(t, "spiffe://example.org/anyone"))
+	resp, err := interceptor(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		identity, ok := PeerIdentityFromContext(ctx)
+		return ok && identity.TrustDomain == "example.org", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, resp)
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(ServerInterceptorConfig{
+		Policy: staticPolicy{"spiffe://example.org/allowed": true},
+	})
+	info := &grpc.StreamServerInfo{FullMethod: "/billing.v1.Billing/Watch"}
+
+	var seen string
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		identity, _ := PeerIdentityFromContext(stream.Context())
+		seen = identity.ID
+		return nil
+	}
+
+	stream := &fakeServerStream{ctx: peer.NewContext(context.Background(), tlsPeer(t, "spiffe://example.org/allowed"))}
+	require.NoError(t, interceptor(nil, stream, info, handler))
+	assert.Equal(t, "spiffe://example.org/allowed", seen)
+
+	stream = &fakeServerStream{ctx: peer.NewContext(context.Background(), tlsPeer(t, "spiffe://example.org/other"))}
+	assert.Equal(t, codes.PermissionDenied, status.Code(interceptor(nil, stream, info, handler)))
+}
+
+// countingHealth is a health service that counts the calls reaching it.
+type countingHealth struct {
+	*health.Server
+	calls atomic.Int32
+}
+
+func (h *countingHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.calls.Add(1)
+	return h.Server.Check(ctx, req)
+}
+
+func (h *countingHealth) Watch(req *healthpb.HealthCheckRequest, stream grpc.ServerStreamingServer[healthpb.HealthCheckResponse]) error {
+	h.calls.Add(1)
+	return h.Server.Watch(req, stream)
+}
+
+// startHealthServer serves a counting health service over mTLS as source.
+func startHealthServer(t *testing.T, source *memidentity.CAProvider) (string, *countingHealth) {
+	t.Helper()
+	service := &countingHealth{Server: health.NewServer()}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsconfig.MTLSServerConfig(source, source, tlsconfig.AuthorizeAny()))))
+	healthpb.RegisterHealthServer(server, service)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String(), service
+}
+
+func TestClientInterceptors(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.org")
+	ca, err := memidentity.NewCA(td, false)
+	require.NoError(t, err)
+	newSource := func(id string) *memidentity.CAProvider {
+		source, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: spiffeid.RequireFromString(id), CA: ca})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = source.Close() })
+		return source
+	}
+	const billingID = "spiffe://example.org/billing"
+	billingAddr, billing := startHealthServer(t, newSource(billingID))
+	impostorAddr, impostor := startHealthServer(t, newSource("spiffe://example.org/impostor"))
+
+	client := newSource("spiffe://example.org/checkout")
+	provider := NewRotatableGRPCProvider(nil)
+	require.NoError(t, provider.SetSources(client, client, tlsconfig.AuthorizeMemberOf(td)))
+	clientPort, err := provider.CreateClient(nil, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = clientPort.Close() })
+	connect := func(serviceName, address string) healthpb.HealthClient {
+		conn, err := clientPort.Connect(serviceName, address)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		return healthpb.NewHealthClient(conn.GetClientConnection().(*grpc.ClientConn))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := &healthpb.HealthCheckRequest{}
+
+	t.Run("server expected by SPIFFE ID", func(t *testing.T) {
+		health := connect(billingID, billingAddr)
+		_, err := health.Check(ctx, req)
+		require.NoError(t, err)
+
+		stream, err := health.Watch(ctx, req, ExpectServerID(billingID))
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+	})
+
+	t.Run("unexpected server is rejected in the handshake", func(t *testing.T) {
+		health := connect(billingID, impostorAddr)
+		_, err := health.Check(ctx, req)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Zero(t, impostor.calls.Load())
+	})
+
+	t.Run("per-call expectation rejects unexpected servers", func(t *testing.T) {
+		// A plain service name only requires trust domain membership
+		health := connect("billing", impostorAddr)
+		_, err := health.Check(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, int32(1), impostor.calls.Load())
+
+		// The response of a unary call is discarded
+		_, err = health.Check(ctx, req, ExpectServerID(billingID))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Equal(t, int32(2), impostor.calls.Load())
+
+		// A stream is rejected before it reaches the server
+		_, err = health.Watch(ctx, req, ExpectServerID(billingID))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, int32(2), impostor.calls.Load(), "a rejected stream reached the server")
+	})
+
+	t.Run("per-call expectation", func(t *testing.T) {
+		before := billing.calls.Load()
+		health := connect("billing", billingAddr)
+		_, err := health.Check(ctx, req, ExpectServerID(billingID))
+		require.NoError(t, err)
+		assert.Equal(t, before+1, billing.calls.Load())
+	})
+}
+
+func TestUnaryClientInterceptor_RunsLaterInterceptors(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.org")
+	ca, err := memidentity.NewCA(td, false)
+	require.NoError(t, err)
+	newSource := func(id string) *memidentity.CAProvider {
+		source, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: spiffeid.RequireFromString(id), CA: ca})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = source.Close() })
+		return source
+	}
+	const billingID = "spiffe://example.org/billing"
+	billingAddr, _ := startHealthServer(t, newSource(billingID))
+
+	var later atomic.Int32
+	client := newSource("spiffe://example.org/checkout")
+	conn, err := grpc.NewClient(billingAddr,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(client, client, tlsconfig.AuthorizeMemberOf(td)))),
+		grpc.WithChainUnaryInterceptor(
+			UnaryClientInterceptor(ClientInterceptorConfig{}),
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				later.Add(1)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			},
+		))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, ExpectServerID(billingID))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), later.Load())
+}
+
+func TestExpectedServer(t *testing.T) {
+	assert.Equal(t, ports.PeerMatcherConfig{ID: "spiffe://example.org/billing"}, expectedServer("spiffe://example.org/billing"))
+	assert.Equal(t, ports.PeerMatcherConfig{}, expectedServer("billing"))
+}
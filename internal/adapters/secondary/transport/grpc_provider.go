@@ -3,6 +3,7 @@ package transport
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -10,7 +11,6 @@ import (
 	"strings"
 	"sync"
 	"syscall"
-	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -22,9 +22,24 @@ import (
 
 // grpcClient implements ports.ClientPort.
 type grpcClient struct {
-	tlsConfig *tls.Config
-	policy    *domain.AuthenticationPolicy
-	closed    bool // Track if client has been closed
+	tlsConfig  *tls.Config
+	policy     *domain.AuthenticationPolicy
+	settings   *ports.GRPCConfig           // Keepalive settings shared with the servers; nil selects the defaults
+	tracker    ports.ConnectionTrackerPort // Told about every connection; optional
+	localChain func() []*x509.Certificate  // Client's current SVID chain; optional
+	metrics    ports.MTLSMetricsPort       // Told about every handshake; optional
+	closed     bool                        // Track if client has been closed
+}
+
+// expectedServer returns the SPIFFE ID the server must have when serviceName is a
+// SPIFFE ID. A plain service name does not identify a SPIFFE ID, so the server of
+// such a connection is only checked by the handshake authorizer, e.g. for membership
+// of the trust domain or a federated one, and by per-call ExpectServerID options.
+func expectedServer(serviceName string) ports.PeerMatcherConfig {
+	if strings.HasPrefix(serviceName, "spiffe://") {
+		return ports.PeerMatcherConfig{ID: serviceName}
+	}
+	return ports.PeerMatcherConfig{}
 }
 
 // Connect establishes a secure gRPC connection to the specified address.
@@ -47,18 +62,28 @@ func (c *grpcClient) Connect(serviceName, address string) (ports.ConnectionPort,
 		return nil, fmt.Errorf("TLS configuration is required but not provided")
 	}
 
-	// Create credentials
-	creds := credentials.NewTLS(c.tlsConfig)
+	// A server expected by SPIFFE ID is checked in the handshake, before any call is
+	// made, and again by the interceptors for every call
+	interceptors := ClientInterceptorConfig{ExpectedServer: expectedServer(serviceName)}
+	tlsConfig := c.tlsConfig
+	if interceptors.ExpectedServer != (ports.PeerMatcherConfig{}) {
+		tlsConfig = authorizeServer(tlsConfig, interceptors.ExpectedServer)
+	}
+
+	// Create credentials; connections are reported to the tracker for mTLS enforcement
+	creds := withMetrics(credentials.NewTLS(tlsConfig), c.metrics, c.localChain)
+	if c.tracker != nil {
+		creds = &trackingClientCredentials{TransportCredentials: creds, tracker: c.tracker, localChain: c.localChain}
+	}
 
 	// Configure connection options with modern gRPC practices
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(creds),
-		// Enable keepalive for better connection health
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                10 * time.Second, // Send keepalive pings every 10 seconds
-			Timeout:             3 * time.Second,  // Wait 3 seconds for ping ack before considering connection dead
-			PermitWithoutStream: true,             // Send pings even when no active RPCs
-		}),
+		// Ping within the limits the servers enforce
+		grpc.WithKeepaliveParams(clientKeepalive(c.settings)),
+		// Verify the server identity of every call
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(interceptors)),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor(interceptors)),
 		// Set maximum message sizes (4MB default is usually fine, but being explicit)
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(4*1024*1024), // 4MB
@@ -87,6 +112,19 @@ func (c *grpcClient) Connect(serviceName, address string) (ports.ConnectionPort,
 	}, nil
 }
 
+// clientKeepalive returns the client keepalive parameters matching the enforcement
+// policy of servers with the same settings. Pinging at keepalive_min_time could be
+// counted as too frequent after network delays, and servers close connections of
+// clients that ping too often, so clients ping at twice that interval, and only
+// without RPCs if the servers permit it.
+func clientKeepalive(settings *ports.GRPCConfig) keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                2 * settings.GetKeepaliveMinTime(),
+		Timeout:             settings.GetKeepaliveTimeout(),
+		PermitWithoutStream: settings.GetPermitWithoutStream(),
+	}
+}
+
 // isNetworkError checks if the error is network-related using modern error handling.
 func isNetworkError(err error) bool {
 	// Use errors.Is for syscall errors (Go 1.13+ best practice)
@@ -144,12 +182,17 @@ func (c *grpcClient) Close() error {
 
 // grpcServer implements ports.ServerPort.
 type grpcServer struct {
-	tlsConfig   *tls.Config
-	policy      *domain.AuthenticationPolicy
-	server      *grpc.Server
-	initialized bool       // Track initialization state
-	serving     bool       // Track serving state
-	mu          sync.Mutex // Protect concurrent access to state
+	tlsConfig    *tls.Config
+	policy       *domain.AuthenticationPolicy
+	interceptors ServerInterceptorConfig
+	settings     *ports.GRPCConfig           // Keepalive and connection age; nil selects the defaults
+	tracker      ports.ConnectionTrackerPort // Told about every connection; optional
+	localChain   func() []*x509.Certificate  // Server's current SVID chain; optional
+	metrics      ports.MTLSMetricsPort       // Told about every handshake; optional
+	conns        *serverConns
+	initialized  bool       // Track initialization state
+	serving      bool       // Track serving state
+	mu           sync.Mutex // Protect concurrent access to state
 }
 
 // RegisterService registers a service implementation with the gRPC server.
@@ -168,42 +211,50 @@ func (s *grpcServer) RegisterService(registrar ports.ServiceRegistrarPort) error
 	}
 
 	// Initialize server if needed
-	if s.server == nil {
+	if s.conns == nil {
 		if err := s.initializeServer(); err != nil {
 			return fmt.Errorf("failed to initialize server: %w", err)
 		}
 	}
 
 	// Register the service
-	registrar.Register(s.server)
+	if err := s.conns.register(registrar); err != nil {
+		return fmt.Errorf("failed to register service: %w", err)
+	}
 	s.initialized = true
 	return nil
 }
 
-// initializeServer creates and configures the underlying gRPC server.
+// initializeServer configures the gRPC servers of the accepted connections.
 func (s *grpcServer) initializeServer() error {
 	if s.tlsConfig == nil {
 		return fmt.Errorf("TLS configuration is required but not provided")
 	}
 
-	// Create credentials
-	creds := credentials.NewTLS(s.tlsConfig)
+	// Create credentials; connections are replaced or drained before either side's
+	// certificate expires and are reported to the tracker for mTLS enforcement, which
+	// drains them individually
+	conns := newServerConns(s.settings, s.localChain, s.tracker)
+	creds := conns.credentials(withMetrics(credentials.NewTLS(s.tlsConfig), s.metrics, s.localChain))
 
 	// Configure server options with modern gRPC practices
 	opts := []grpc.ServerOption{
 		grpc.Creds(creds),
+		// Expose the caller identity to handlers and enforce per-method authorization
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(s.interceptors)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(s.interceptors)),
 		// Enable keepalive enforcement for better connection health
 		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
-			MinTime:             5 * time.Second, // Minimum time between keepalive pings
-			PermitWithoutStream: true,            // Allow pings even when no active RPCs
+			MinTime:             s.settings.GetKeepaliveMinTime(),    // Minimum time between keepalive pings
+			PermitWithoutStream: s.settings.GetPermitWithoutStream(), // Allow pings even when no active RPCs
 		}),
 		// Configure server keepalive parameters
 		grpc.KeepaliveParams(keepalive.ServerParameters{
-			MaxConnectionIdle:     15 * time.Minute, // Close idle connections after 15 minutes
-			MaxConnectionAge:      30 * time.Minute, // Close connections after 30 minutes regardless of activity
-			MaxConnectionAgeGrace: 5 * time.Second,  // Allow 5 seconds for active RPCs to complete before force-closing
-			Time:                  5 * time.Second,  // Send keepalive pings every 5 seconds
-			Timeout:               1 * time.Second,  // Wait 1 second for ping ack before considering connection dead
+			MaxConnectionIdle:     s.settings.GetMaxConnectionIdle(),     // Close idle connections
+			MaxConnectionAge:      conns.maxAge(),                        // Replace connections before the server SVID expires
+			MaxConnectionAgeGrace: s.settings.GetMaxConnectionAgeGrace(), // Time for active RPCs to complete before force-closing
+			Time:                  s.settings.GetKeepaliveTime(),         // Ping idle connections
+			Timeout:               s.settings.GetKeepaliveTimeout(),      // Wait for ping ack before considering connection dead
 		}),
 		// Set maximum message sizes
 		grpc.MaxRecvMsgSize(4 * 1024 * 1024), // 4MB
@@ -212,12 +263,13 @@ func (s *grpcServer) initializeServer() error {
 		grpc.MaxConcurrentStreams(1000),
 	}
 
-	s.server = grpc.NewServer(opts...)
+	conns.options = opts
+	s.conns = conns
 	return nil
 }
 
 // Start starts the gRPC server on the provided listener.
-func (s *grpcServer) Start(listener ports.NetworkListener) error {
+func (s *grpcServer) Start(listener ports.NetworkListenerPort) error {
 	// Validate inputs first (without lock)
 	if listener == nil {
 		return fmt.Errorf("listener cannot be nil")
@@ -234,7 +286,7 @@ func (s *grpcServer) Start(listener ports.NetworkListener) error {
 	s.mu.Lock()
 
 	// Check initialization state
-	if s.server == nil {
+	if s.conns == nil {
 		s.mu.Unlock()
 		return fmt.Errorf("server not initialized - call RegisterService first")
 	}
@@ -255,7 +307,7 @@ func (s *grpcServer) Start(listener ports.NetworkListener) error {
 	s.mu.Unlock()
 
 	// Start serving (this call blocks until server stops)
-	err = s.server.Serve(netListener)
+	err = s.conns.serve(netListener)
 
 	// Mark as not serving when we return
 	s.mu.Lock()
@@ -270,7 +322,7 @@ func (s *grpcServer) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.server == nil {
+	if s.conns == nil {
 		// Already stopped or never initialized
 		return nil
 	}
@@ -282,7 +334,7 @@ func (s *grpcServer) Stop() error {
 
 	// Gracefully stop the server
 	// Note: This call may block briefly while stopping active connections
-	s.server.GracefulStop()
+	s.conns.stop()
 	s.serving = false
 
 	return nil
@@ -357,11 +409,17 @@ func (a *networkListenerAdapter) Close() error {
 	return a.listener.Close()
 }
 
+// NetListener returns the adapted listener.
+func (a *networkListenerAdapter) NetListener() net.Listener {
+	return a.listener
+}
+
 // extractNetListener extracts the underlying net.Listener from a NetworkListener.
-// This is needed because gRPC server requires net.Listener interface.
-func extractNetListener(listener ports.NetworkListener) (net.Listener, error) {
-	if adapter, ok := listener.(*networkListenerAdapter); ok {
-		return adapter.listener, nil
+// This is needed because gRPC server requires net.Listener interface. The listener
+// adapters of the API server, the factory and the public API expose it.
+func extractNetListener(listener ports.NetworkListenerPort) (net.Listener, error) {
+	if adapter, ok := listener.(interface{ NetListener() net.Listener }); ok {
+		return adapter.NetListener(), nil
 	}
-	return nil, fmt.Errorf("NetworkListener must be a networkListenerAdapter to work with gRPC server")
+	return nil, fmt.Errorf("NetworkListener must wrap a net.Listener to work with gRPC server")
 }
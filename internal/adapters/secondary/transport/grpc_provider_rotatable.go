@@ -14,6 +14,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
 	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
 )
@@ -25,6 +26,13 @@ type RotatableGRPCProvider struct {
 	bundleSource  x509bundle.Source
 	authorizer    tlsconfig.Authorizer
 	trustProvider ports.TrustDomainProvider // Injected capability
+	policy        ports.PolicyEvaluatorPort // Per-method authorization for servers
+	audit         ports.AuditRecorderPort
+	tlsSettings   *ports.TLSConfig  // Minimum version, cipher suites and curves
+	grpcSettings  *ports.GRPCConfig // Server keepalive and connection age
+	tracker       ports.ConnectionTrackerPort
+	revocation    ports.RevocationListPort // Revoked peers rejected at handshake
+	metrics       ports.MTLSMetricsPort    // Handshakes, connections and authorization decisions
 	mu            sync.RWMutex
 }
 
@@ -55,6 +63,114 @@ func (p *RotatableGRPCProvider) SetSources(svidSource x509svid.Source, bundleSou
 	return nil
 }
 
+// SetAuthorization sets the per-method authorization policy and audit recorder used
+// by servers created afterwards. The recorder receives the handshakes as well as the
+// per-method decisions. Either may be nil.
+func (p *RotatableGRPCProvider) SetAuthorization(policy ports.PolicyEvaluatorPort, recorder ports.AuditRecorderPort) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = policy
+	p.audit = recorder
+}
+
+// SetTLSConfig sets the TLS protocol settings applied to clients and servers created
+// afterwards. A nil config selects TLS 1.3 with Go's default curves.
+func (p *RotatableGRPCProvider) SetTLSConfig(settings *ports.TLSConfig) error {
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS settings: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tlsSettings = settings
+	return nil
+}
+
+// SetGRPCConfig sets the keepalive and connection age settings of clients and
+// servers created afterwards. A nil config selects the defaults.
+func (p *RotatableGRPCProvider) SetGRPCConfig(settings *ports.GRPCConfig) error {
+	if err := settings.Validate(); err != nil {
+		return fmt.Errorf("invalid gRPC settings: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grpcSettings = settings
+	return nil
+}
+
+// SetConnectionTracker sets the tracker that clients and servers created afterwards
+// report their connections to. May be nil.
+func (p *RotatableGRPCProvider) SetConnectionTracker(tracker ports.ConnectionTrackerPort) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tracker = tracker
+}
+
+// SetRevocation sets the revocation list that clients and servers created afterwards
+// check peer certificates against at handshake. May be nil.
+func (p *RotatableGRPCProvider) SetRevocation(list ports.RevocationListPort) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.revocation = list
+}
+
+// SetMetrics sets the reporter of the handshakes, connections and authorization
+// decisions of clients and servers created afterwards. May be nil.
+func (p *RotatableGRPCProvider) SetMetrics(metrics ports.MTLSMetricsPort) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metrics = metrics
+}
+
+// effectiveTLS returns the TLS settings including the revocation list.
+// Callers hold p.mu.
+func (p *RotatableGRPCProvider) effectiveTLS() *ports.TLSConfig {
+	if p.revocation == nil {
+		return p.tlsSettings
+	}
+	return p.tlsSettings.WithRevocation(p.revocation)
+}
+
+// localSVIDChain returns a function returning the current SVID chain, or nil
+// without an SVID source. Callers hold p.mu.
+func (p *RotatableGRPCProvider) localSVIDChain() func() []*x509.Certificate {
+	source := p.svidSource
+	if source == nil {
+		return nil
+	}
+	return func() []*x509.Certificate {
+		svid, err := source.GetX509SVID()
+		if err != nil {
+			return nil
+		}
+		return svid.Certificates
+	}
+}
+
+// serverInterceptors returns the interceptor configuration for a new server.
+// Callers hold p.mu.
+func (p *RotatableGRPCProvider) serverInterceptors() ServerInterceptorConfig {
+	config := ServerInterceptorConfig{Policy: p.policy, Audit: p.audit, Metrics: p.metrics}
+	if p.audit != nil {
+		config.LocalID = p.localID()
+	}
+	return config
+}
+
+// localID returns the SPIFFE ID of the current SVID, or an empty string.
+// Callers hold p.mu.
+func (p *RotatableGRPCProvider) localID() string {
+	if p.svidSource == nil {
+		return ""
+	}
+	svid, err := p.svidSource.GetX509SVID()
+	if err != nil {
+		return ""
+	}
+	return svid.ID.String()
+}
+
 // CreateClient creates a gRPC client with rotation-capable SPIFFE mTLS.
 func (p *RotatableGRPCProvider) CreateClient(cert *domain.Certificate, bundle *domain.TrustBundle, policy *domain.AuthenticationPolicy) (ports.ClientPort, error) {
 	p.mu.RLock()
@@ -63,9 +179,14 @@ func (p *RotatableGRPCProvider) CreateClient(cert *domain.Certificate, bundle *d
 	// Check for development mode
 	if p.trustProvider != nil && p.trustProvider.ShouldSkipCertificateValidation() {
 		log.Printf("⚠️  [EPHEMOS] Certificate validation disabled (EPHEMOS_INSECURE_SKIP_VERIFY=true) - development only!")
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if err := p.effectiveTLS().Apply(tlsConfig); err != nil {
+			return nil, err
+		}
 		return &grpcClient{
-			tlsConfig: &tls.Config{InsecureSkipVerify: true},
+			tlsConfig: tlsConfig,
 			policy:    policy,
+			settings:  p.grpcSettings,
 		}, nil
 	}
 
@@ -75,10 +196,17 @@ func (p *RotatableGRPCProvider) CreateClient(cert *domain.Certificate, bundle *d
 			p.svidSource != nil, p.bundleSource != nil)
 	}
 
-	tlsConfig := p.createRotatableClientTLSConfig()
+	tlsConfig, err := p.createRotatableClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	return &grpcClient{
-		tlsConfig: tlsConfig,
-		policy:    policy,
+		tlsConfig:  tlsConfig,
+		policy:     policy,
+		settings:   p.grpcSettings,
+		tracker:    p.tracker,
+		localChain: p.localSVIDChain(),
+		metrics:    p.metrics,
 	}, nil
 }
 
@@ -90,9 +218,15 @@ func (p *RotatableGRPCProvider) CreateServer(cert *domain.Certificate, bundle *d
 	// Check for development mode
 	if p.trustProvider != nil && p.trustProvider.ShouldSkipCertificateValidation() {
 		log.Printf("⚠️  [EPHEMOS] Certificate validation disabled (EPHEMOS_INSECURE_SKIP_VERIFY=true) - development only!")
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if err := p.effectiveTLS().Apply(tlsConfig); err != nil {
+			return nil, err
+		}
 		return &grpcServer{
-			tlsConfig: &tls.Config{InsecureSkipVerify: true},
-			policy:    policy,
+			tlsConfig:    tlsConfig,
+			policy:       policy,
+			interceptors: p.serverInterceptors(),
+			settings:     p.grpcSettings,
 		}, nil
 	}
 
@@ -102,15 +236,23 @@ func (p *RotatableGRPCProvider) CreateServer(cert *domain.Certificate, bundle *d
 			p.svidSource != nil, p.bundleSource != nil)
 	}
 
-	tlsConfig := p.createRotatableServerTLSConfig()
+	tlsConfig, err := p.createRotatableServerTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 	return &grpcServer{
-		tlsConfig: tlsConfig,
-		policy:    policy,
+		tlsConfig:    tlsConfig,
+		policy:       policy,
+		interceptors: p.serverInterceptors(),
+		settings:     p.grpcSettings,
+		tracker:      p.tracker,
+		localChain:   p.localSVIDChain(),
+		metrics:      p.metrics,
 	}, nil
 }
 
 // createRotatableClientTLSConfig creates a TLS config that auto-rotates with source updates.
-func (p *RotatableGRPCProvider) createRotatableClientTLSConfig() *tls.Config {
+func (p *RotatableGRPCProvider) createRotatableClientTLSConfig() (*tls.Config, error) {
 	// Use go-spiffe tlsconfig for automatic rotation support
 	// New handshakes will pick up rotated certificates automatically
 	auth := p.authorizer
@@ -119,11 +261,18 @@ func (p *RotatableGRPCProvider) createRotatableClientTLSConfig() *tls.Config {
 		auth = p.createSecureDefaultAuthorizer()
 	}
 
-	return tlsconfig.MTLSClientConfig(p.svidSource, p.bundleSource, auth)
+	// The sources are wrapped so that rejections carry typed causes for the
+	// handshake metrics
+	tlsConfig := tlsconfig.MTLSClientConfig(p.svidSource, audit.WrapBundleSource(p.bundleSource), audit.WrapAuthorizer(auth))
+	tlsConfig.VerifyPeerCertificate = audit.WrapVerifyPeerCertificate(tlsConfig.VerifyPeerCertificate)
+	if err := p.effectiveTLS().Apply(tlsConfig); err != nil {
+		return nil, err
+	}
+	return tlsConfig, nil
 }
 
 // createRotatableServerTLSConfig creates a TLS config that auto-rotates with source updates.
-func (p *RotatableGRPCProvider) createRotatableServerTLSConfig() *tls.Config {
+func (p *RotatableGRPCProvider) createRotatableServerTLSConfig() (*tls.Config, error) {
 	// Use go-spiffe tlsconfig for automatic rotation support
 	// New handshakes will pick up rotated certificates automatically
 	auth := p.authorizer
@@ -132,7 +281,19 @@ func (p *RotatableGRPCProvider) createRotatableServerTLSConfig() *tls.Config {
 		auth = p.createSecureDefaultAuthorizer()
 	}
 
-	return tlsconfig.MTLSServerConfig(p.svidSource, p.bundleSource, auth)
+	// The sources are wrapped so that rejections carry typed causes for auditing
+	tlsConfig := tlsconfig.MTLSServerConfig(p.svidSource, audit.WrapBundleSource(p.bundleSource), audit.WrapAuthorizer(auth))
+	if err := p.effectiveTLS().Apply(tlsConfig); err != nil {
+		return nil, err
+	}
+	if p.audit != nil {
+		tlsConfig = audit.ServerTLSConfig(tlsConfig, audit.HandshakeAuditConfig{
+			Recorder:  p.audit,
+			Transport: "grpc",
+			LocalID:   p.localID(),
+		})
+	}
+	return tlsConfig, nil
 }
 
 // determineAuthorizer creates an authorizer based on the authentication policy.
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestRotatableGRPCProvider_AuditsHandshakes(t *testing.T) {
+	ca, err := memidentity.NewCA(spiffeid.RequireTrustDomainFromString("example.org"), false)
+	require.NoError(t, err)
+	newProvider := func(id string) *memidentity.CAProvider {
+		provider, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: spiffeid.RequireFromString(id), CA: ca})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = provider.Close() })
+		return provider
+	}
+	serverID := spiffeid.RequireFromString("spiffe://example.org/server")
+	clientID := spiffeid.RequireFromString("spiffe://example.org/client")
+	server := newProvider(serverID.String())
+
+	recorder := &recordingAudit{}
+	provider := NewRotatableGRPCProvider(nil)
+	require.NoError(t, provider.SetSources(server, server, tlsconfig.AuthorizeID(clientID)))
+	provider.SetAuthorization(nil, recorder)
+	serverPort, err := provider.CreateServer(nil, nil, nil)
+	require.NoError(t, err)
+	serverCreds := credentials.NewTLS(serverPort.(*grpcServer).tlsConfig)
+
+	clientCreds := func(source *memidentity.CAProvider) credentials.TransportCredentials {
+		return credentials.NewTLS(tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeID(serverID)))
+	}
+	anonymous := credentials.NewTLS(&tls.Config{
+		InsecureSkipVerify: true, // For testing only
+		MinVersion:         tls.VersionTLS13,
+	})
+
+	for _, client := range []credentials.TransportCredentials{
+		clientCreds(newProvider(clientID.String())),
+		clientCreds(newProvider("spiffe://example.org/stranger")),
+		anonymous,
+	} {
+		clientConn, serverConn, clientErr, _ := handshake(t, client, serverCreds)
+		if clientErr == nil {
+			_ = clientConn.Close()
+		}
+		if serverConn != nil {
+			_ = serverConn.Close()
+		}
+	}
+
+	require.Len(t, recorder.events, 3)
+	for _, event := range recorder.events {
+		assert.Equal(t, ports.AuditKindAuthentication, event.Kind)
+		assert.Equal(t, "grpc", event.Transport)
+		assert.Equal(t, serverID.String(), event.LocalID)
+		assert.NotEmpty(t, event.RemoteAddr)
+	}
+	accepted, unauthorized, noCertificate := recorder.events[0], recorder.events[1], recorder.events[2]
+
+	assert.Equal(t, ports.AuditDecisionAllow, accepted.Decision)
+	assert.Equal(t, clientID.String(), accepted.PeerID)
+
+	assert.Equal(t, ports.AuditDecisionDeny, unauthorized.Decision)
+	assert.Equal(t, "spiffe://example.org/stranger", unauthorized.PeerID)
+	assert.Equal(t, ports.AuditErrorUnauthorized, unauthorized.ErrorClass)
+
+	assert.Equal(t, ports.AuditDecisionDeny, noCertificate.Decision)
+	assert.Empty(t, noCertificate.PeerID)
+	assert.Equal(t, ports.AuditErrorNoCertificate, noCertificate.ErrorClass)
+}
+
+func TestRotatableGRPCProvider_ClientKeepaliveFollowsServerSettings(t *testing.T) {
+	permit := false
+	settings := &ports.GRPCConfig{KeepaliveMinTime: 20 * time.Second, KeepaliveTimeout: 2 * time.Second, PermitWithoutStream: &permit}
+	provider := NewRotatableGRPCProvider(&mockTrustProvider{})
+	require.NoError(t, provider.SetGRPCConfig(settings))
+	source := NewTestRotatableSource(t, "spiffe://example.org/client")
+	require.NoError(t, provider.SetSources(source, source, tlsconfig.AuthorizeAny()))
+
+	clientPort, err := provider.CreateClient(nil, nil, nil)
+	require.NoError(t, err)
+	params := clientKeepalive(clientPort.(*grpcClient).settings)
+	assert.GreaterOrEqual(t, params.Time, settings.KeepaliveMinTime, "pings faster than the server allows")
+	assert.Equal(t, settings.KeepaliveTimeout, params.Timeout)
+	assert.False(t, params.PermitWithoutStream, "pings without RPCs the server rejects")
+
+	defaults := clientKeepalive(nil)
+	assert.GreaterOrEqual(t, defaults.Time, ports.DefaultGRPCKeepaliveMinTime)
+	assert.True(t, defaults.PermitWithoutStream)
+}
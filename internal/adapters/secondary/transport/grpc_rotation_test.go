@@ -212,7 +212,7 @@ func TestGRPCProviderRotation(t *testing.T) {
 	defer listener.Close()
 
 	go func() {
-		serverPort.Start(listener)
+		serverPort.Start(&networkListenerAdapter{listener: listener})
 	}()
 	defer serverPort.Stop()
 
@@ -279,7 +279,8 @@ func TestSourceAdapter(t *testing.T) {
 	svid, err := adapter.GetX509SVID()
 	require.NoError(t, err)
 	assert.NotNil(t, svid)
-	assert.Equal(t, "spiffe://test.example.org/test-service", svid.ID.String())
+	// The SVID carries the SPIFFE ID of the certificate
+	assert.Equal(t, "spiffe://test.example.org/service", svid.ID.String())
 
 	// Test bundle source
 	td, err := spiffeid.TrustDomainFromString("test.example.org")
@@ -64,23 +64,20 @@ func TestRotatableProviderIntegration(t *testing.T) {
 		defer clientPort.Close()
 	})
 
-	// Step 4: Option C - Fallback to static certificates (no rotation)
+	// Step 4: Static certificates without sources are rejected rather than served
+	// without rotation
 	t.Run("with_static_certificates", func(t *testing.T) {
-		// Create provider without sources
 		staticProvider, err := CreateGRPCProvider(config)
 		require.NoError(t, err)
 
-		// Provide certificates directly - will create static adapters
 		cert := &domain.Certificate{
 			Cert:       createMockCert(t, "spiffe://test.example.org/service"),
 			PrivateKey: createMockKey(t),
 		}
 		bundle := mustCreateTrustBundle([]*x509.Certificate{createMockCACert(t)})
 
-		clientPort, err := staticProvider.CreateClient(cert, bundle, nil)
-		require.NoError(t, err)
-		assert.NotNil(t, clientPort)
-		defer clientPort.Close()
+		_, err = staticProvider.CreateClient(cert, bundle, nil)
+		assert.Error(t, err)
 	})
 }
 
@@ -88,15 +85,20 @@ func TestRotatableProviderIntegration(t *testing.T) {
 func TestCreateGRPCProviderFactory(t *testing.T) {
 	config := &ports.Configuration{}
 
-	// Use CreateGRPCProvider for rotation support
-	provider, err := CreateGRPCProvider(config)
-	require.NoError(t, err)
-
 	cert := &domain.Certificate{
 		Cert:       createMockCert(t, "spiffe://test.example.org/service"),
 		PrivateKey: createMockKey(t),
 	}
 	bundle := mustCreateTrustBundle([]*x509.Certificate{createMockCACert(t)})
+	identity := &mockIdentityProvider{
+		cert:     cert,
+		bundle:   bundle,
+		identity: domain.NewServiceIdentity("test-service", "test.example.org"),
+	}
+
+	// Use CreateGRPCProvider for rotation support; the identity provider backs the sources
+	provider, err := CreateGRPCProvider(config, WithIdentityProvider(identity))
+	require.NoError(t, err)
 
 	// Should delegate to the rotatable provider
 	clientPort, err := provider.CreateClient(cert, bundle, nil)
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// metricsCredentials are credentials that record the duration and outcome of every
+// handshake, the remaining lifetime of the SVIDs on both sides, and count the
+// connections they establish as active until they are closed.
+type metricsCredentials struct {
+	credentials.TransportCredentials
+	metrics    ports.MTLSMetricsPort
+	localChain func() []*x509.Certificate
+}
+
+// withMetrics wraps creds so that handshakes and connections are reported to
+// metrics. It returns creds unchanged when metrics is nil.
+func withMetrics(creds credentials.TransportCredentials, metrics ports.MTLSMetricsPort, localChain func() []*x509.Certificate) credentials.TransportCredentials {
+	if metrics == nil {
+		return creds
+	}
+	return &metricsCredentials{TransportCredentials: creds, metrics: metrics, localChain: localChain}
+}
+
+func (c *metricsCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	start := time.Now()
+	conn, authInfo, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	return c.record(ports.HandshakeSideClient, start, conn, authInfo, err)
+}
+
+func (c *metricsCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	start := time.Now()
+	conn, authInfo, err := c.TransportCredentials.ServerHandshake(rawConn)
+	return c.record(ports.HandshakeSideServer, start, conn, authInfo, err)
+}
+
+func (c *metricsCredentials) Clone() credentials.TransportCredentials {
+	return &metricsCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		metrics:              c.metrics,
+		localChain:           c.localChain,
+	}
+}
+
+// record reports a finished handshake and, if it succeeded, wraps the connection so
+// that closing it is reported too.
+func (c *metricsCredentials) record(side string, start time.Time, conn net.Conn, authInfo credentials.AuthInfo, err error) (net.Conn, credentials.AuthInfo, error) {
+	duration := time.Since(start)
+	if err != nil {
+		c.metrics.RecordHandshake(side, handshakeFailureReason(err), duration)
+		return nil, nil, err
+	}
+	c.metrics.RecordHandshake(side, "", duration)
+
+	if leaf := localLeaf(c.localChain); leaf != nil {
+		if id, err := x509svid.IDFromCert(leaf); err == nil {
+			c.metrics.RecordSVIDExpiry(id, leaf.NotAfter)
+		}
+	}
+
+	trustDomain := ""
+	if tlsInfo, ok := authInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+		leaf := tlsInfo.State.PeerCertificates[0]
+		c.metrics.RecordPeerSVIDLifetime(time.Until(leaf.NotAfter))
+		if id, err := x509svid.IDFromCert(leaf); err == nil {
+			trustDomain = id.TrustDomain().String()
+		}
+	}
+
+	c.metrics.RecordConnectionOpened(trustDomain)
+	return &meteredConn{Conn: conn, metrics: c.metrics, trustDomain: trustDomain}, authInfo, nil
+}
+
+// meteredConn reports its closing once.
+type meteredConn struct {
+	net.Conn
+	metrics     ports.MTLSMetricsPort
+	trustDomain string
+	once        sync.Once
+}
+
+func (c *meteredConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.metrics.RecordConnectionClosed(c.trustDomain)
+	})
+	return err
+}
+
+// handshakeFailureReason classifies a handshake error into one of the
+// ports.HandshakeFailure reasons. Certificate and authorization causes are
+// classified by audit.ClassifyError, so the TLS configs must be built with the
+// audit wrappers for go-spiffe rejections to be recognized.
+func handshakeFailureReason(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ports.HandshakeFailureTimeout
+	}
+
+	switch audit.ClassifyError(err) {
+	case ports.AuditErrorRevoked:
+		return ports.HandshakeFailureRevoked
+	case ports.AuditErrorExpired:
+		return ports.HandshakeFailureExpired
+	case ports.AuditErrorUntrusted:
+		return ports.HandshakeFailureUnknownAuthority
+	case ports.AuditErrorUnauthorized:
+		return ports.HandshakeFailureUnauthorized
+	case ports.AuditErrorNoCertificate, ports.AuditErrorInvalidIdentity:
+		return ports.HandshakeFailureBadCertificate
+	}
+
+	// crypto/tls reports an alert received from the peer as a "remote error"
+	// net.OpError, and wraps the alerts it sends in tls.AlertError
+	var opErr *net.OpError
+	var alert tls.AlertError
+	var recordHeader tls.RecordHeaderError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "remote error":
+		return ports.HandshakeFailurePeerRejected
+	case errors.As(err, &alert), errors.As(err, &recordHeader),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ports.HandshakeFailureProtocol
+	default:
+		return ports.HandshakeFailureOther
+	}
+}
@@ -0,0 +1,207 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// recordingMetrics records the calls made through ports.MTLSMetricsPort.
+type recordingMetrics struct {
+	mu          sync.Mutex
+	handshakes  []string // side/reason
+	active      map[string]int
+	peerLife    []time.Duration
+	svidExpiry  map[spiffeid.ID]time.Time
+	authorized  []string
+	closedCount int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{active: make(map[string]int), svidExpiry: make(map[spiffeid.ID]time.Time)}
+}
+
+func (m *recordingMetrics) RecordHandshake(side, reason string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handshakes = append(m.handshakes, side+"/"+reason)
+}
+
+func (m *recordingMetrics) RecordConnectionOpened(trustDomain string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active[trustDomain]++
+}
+
+func (m *recordingMetrics) RecordConnectionClosed(trustDomain string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active[trustDomain]--
+	m.closedCount++
+}
+
+func (m *recordingMetrics) RecordPeerSVIDLifetime(remaining time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peerLife = append(m.peerLife, remaining)
+}
+
+func (m *recordingMetrics) RecordSVIDExpiry(id spiffeid.ID, expiry time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.svidExpiry[id] = expiry
+}
+
+func (m *recordingMetrics) RecordRotation(string) {}
+
+func (m *recordingMetrics) RecordAuthorization(rule string, allowed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.authorized = append(m.authorized, fmt.Sprintf("%s/%t", rule, allowed))
+}
+
+// handshake runs a client and a server handshake over a loopback connection and
+// returns their results.
+func handshake(t *testing.T, client, server credentials.TransportCredentials) (clientConn, serverConn net.Conn, clientErr, serverErr error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serverRaw, err := listener.Accept()
+		if err != nil {
+			serverErr = err
+			return
+		}
+		if serverConn, _, serverErr = server.ServerHandshake(serverRaw); serverErr != nil {
+			_ = serverRaw.Close()
+		}
+	}()
+
+	clientRaw, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if clientConn, _, clientErr = client.ClientHandshake(ctx, "server", clientRaw); clientErr != nil {
+		_ = clientRaw.Close()
+	}
+	<-done
+	return clientConn, serverConn, clientErr, serverErr
+}
+
+func TestMetricsCredentials(t *testing.T) {
+	ca, err := memidentity.NewCA(spiffeid.RequireTrustDomainFromString("example.org"), false)
+	require.NoError(t, err)
+	serverID := spiffeid.RequireFromString("spiffe://example.org/server")
+	clientID := spiffeid.RequireFromString("spiffe://example.org/client")
+	server, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: serverID, CA: ca})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	client, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: clientID, CA: ca})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	chain := func(provider *memidentity.CAProvider) func() []*x509.Certificate {
+		return func() []*x509.Certificate {
+			svid, err := provider.GetX509SVID()
+			if err != nil {
+				return nil
+			}
+			return svid.Certificates
+		}
+	}
+	serverMetrics, clientMetrics := newRecordingMetrics(), newRecordingMetrics()
+	serverCreds := withMetrics(credentials.NewTLS(tlsconfig.MTLSServerConfig(server, audit.WrapBundleSource(server),
+		audit.WrapAuthorizer(tlsconfig.AuthorizeID(clientID)))),
+		serverMetrics, chain(server))
+	clientCreds := withMetrics(credentials.NewTLS(tlsconfig.MTLSClientConfig(client, client, tlsconfig.AuthorizeID(serverID))),
+		clientMetrics, chain(client)).Clone()
+
+	clientConn, serverConn, clientErr, serverErr := handshake(t, clientCreds, serverCreds)
+	require.NoError(t, clientErr)
+	require.NoError(t, serverErr)
+
+	assert.Equal(t, []string{"server/"}, serverMetrics.handshakes)
+	assert.Equal(t, []string{"client/"}, clientMetrics.handshakes)
+	assert.Equal(t, 1, serverMetrics.active["example.org"])
+	require.Len(t, serverMetrics.peerLife, 1)
+	assert.InDelta(t, memidentity.DefaultSVIDTTL.Seconds(), serverMetrics.peerLife[0].Seconds(), 60)
+	assert.Contains(t, serverMetrics.svidExpiry, serverID)
+	assert.Contains(t, clientMetrics.svidExpiry, clientID)
+
+	// Closing is reported once
+	_ = clientConn.Close()
+	_ = serverConn.Close()
+	_ = serverConn.Close()
+	assert.Equal(t, 0, serverMetrics.active["example.org"])
+	assert.Equal(t, 1, serverMetrics.closedCount)
+
+	// A client the server does not authorize
+	strangerID := spiffeid.RequireFromString("spiffe://example.org/stranger")
+	stranger, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{ID: strangerID, CA: ca})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stranger.Close() })
+	strangerCreds := withMetrics(credentials.NewTLS(tlsconfig.MTLSClientConfig(stranger, stranger, tlsconfig.AuthorizeID(serverID))),
+		clientMetrics, chain(stranger))
+
+	// With TLS 1.3 the client finishes its handshake before the server rejects it
+	clientConn, _, clientErr, serverErr = handshake(t, strangerCreds, serverCreds)
+	if clientErr == nil {
+		_ = clientConn.Close()
+	}
+	assert.Error(t, serverErr)
+	assert.Equal(t, "server/"+ports.HandshakeFailureUnauthorized, serverMetrics.handshakes[1])
+	assert.Equal(t, 0, serverMetrics.active["example.org"])
+}
+
+func TestWithMetrics_Nil(t *testing.T) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS13})
+	assert.Same(t, creds, withMetrics(creds, nil, nil))
+}
+
+func TestHandshakeFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{context.DeadlineExceeded, ports.HandshakeFailureTimeout},
+		{fmt.Errorf("read: %w", os.ErrDeadlineExceeded), ports.HandshakeFailureTimeout},
+		{&domain.RevokedError{Match: domain.RevocationMatchSerial, Entry: "0a"}, ports.HandshakeFailureRevoked},
+		{fmt.Errorf("x509svid: could not verify leaf certificate: %w",
+			x509.CertificateInvalidError{Reason: x509.Expired}), ports.HandshakeFailureExpired},
+		{x509.UnknownAuthorityError{}, ports.HandshakeFailureUnknownAuthority},
+		{fmt.Errorf("%w: no X.509 bundle for trust domain", domain.ErrUntrustedPeer), ports.HandshakeFailureUnknownAuthority},
+		{fmt.Errorf("%w: unexpected ID", domain.ErrUnauthorizedPeer), ports.HandshakeFailureUnauthorized},
+		{fmt.Errorf("%w: no URI SAN", domain.ErrInvalidPeerIdentity), ports.HandshakeFailureBadCertificate},
+		{fmt.Errorf("%w: empty certificates chain", domain.ErrNoPeerCertificate), ports.HandshakeFailureBadCertificate},
+		{&net.OpError{Op: "remote error", Err: tls.AlertError(42)}, ports.HandshakeFailurePeerRejected},
+		{fmt.Errorf("handshake failed%.0w", tls.AlertError(40)), ports.HandshakeFailureProtocol},
+		{tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, ports.HandshakeFailureProtocol},
+		{errors.New("tls: bad certificate"), ports.HandshakeFailureOther},
+		{errors.New("connection reset by peer"), ports.HandshakeFailureOther},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, handshakeFailureReason(tt.err), tt.err.Error())
+	}
+}
@@ -0,0 +1,425 @@
+package transport
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// serverConns serves the connections accepted by a gRPC server, each with a gRPC
+// server of its own, so that a single connection can be drained the way gRPC drains
+// a server: the client is sent GOAWAY, in-flight RPCs finish and the connection
+// closes, or it is closed at the end of the grace period.
+//
+// gRPC replaces connections at the server's MaxConnectionAge, which maxAge bounds by
+// the server SVID lifetime. Connections authenticated with a certificate that
+// expires before then, e.g. a client SVID with a shorter lifetime, are drained by the
+// expiry deadline of each connection. Every connection is also reported to the
+// connection tracker, if any, so that mTLS enforcement can drain it too.
+type serverConns struct {
+	settings *ports.GRPCConfig
+	// localChain returns the server's current SVID chain. Optional.
+	localChain func() []*x509.Certificate
+	// tracker is told about every connection. Optional.
+	tracker ports.ConnectionTrackerPort
+	// options configure the gRPC server of every connection.
+	options []grpc.ServerOption
+
+	mu       sync.Mutex
+	services []registeredService
+	listener net.Listener
+	conns    map[*serverConn]struct{}
+	stopped  bool
+}
+
+// registeredService is a service registered with the gRPC server of every connection.
+type registeredService struct {
+	desc *grpc.ServiceDesc
+	impl interface{}
+}
+
+// newServerConns creates the connection serving of a gRPC server.
+func newServerConns(settings *ports.GRPCConfig, localChain func() []*x509.Certificate, tracker ports.ConnectionTrackerPort) *serverConns {
+	return &serverConns{
+		settings:   settings,
+		localChain: localChain,
+		tracker:    tracker,
+		conns:      make(map[*serverConn]struct{}),
+	}
+}
+
+// credentials wraps server credentials so that every accepted connection is tracked.
+func (d *serverConns) credentials(creds credentials.TransportCredentials) credentials.TransportCredentials {
+	return &serverConnsCredentials{TransportCredentials: creds, conns: d}
+}
+
+// maxAge returns the MaxConnectionAge of the server: the configured age, shortened
+// so that a connection accepted just before the server SVID is renewed, at half its
+// lifetime, is replaced expiry_margin before that SVID expires. gRPC adds up to 10%
+// to the age, which the bound allows for.
+func (d *serverConns) maxAge() time.Duration {
+	age := d.settings.GetMaxConnectionAge()
+	leaf := localLeaf(d.localChain)
+	if leaf == nil {
+		return age
+	}
+	bound := (leaf.NotAfter.Sub(leaf.NotBefore)/2 - d.settings.GetExpiryMargin()) * 10 / 11
+	if bound <= 0 {
+		// Too short to bound; the expiry deadline of each connection applies
+		return age
+	}
+	return min(age, bound)
+}
+
+// deadline returns when a connection authenticated with authInfo must be drained: the
+// expiry margin before the earliest certificate expiry on either side, brought
+// forward by a random part of the jitter.
+func (d *serverConns) deadline(authInfo credentials.AuthInfo) (time.Time, bool) {
+	var expiry time.Time
+	if tlsInfo, ok := authInfo.(credentials.TLSInfo); ok {
+		for _, cert := range tlsInfo.State.PeerCertificates {
+			if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+				expiry = cert.NotAfter
+			}
+		}
+	}
+	if d.localChain != nil {
+		for _, cert := range d.localChain() {
+			if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+				expiry = cert.NotAfter
+			}
+		}
+	}
+	if expiry.IsZero() {
+		return time.Time{}, false
+	}
+
+	deadline := expiry.Add(-d.settings.GetExpiryMargin())
+	if remaining := time.Until(deadline); remaining > 0 {
+		deadline = deadline.Add(-time.Duration(rand.Float64() * d.settings.GetExpiryJitter() * float64(remaining)))
+	}
+	return deadline, true
+}
+
+// register records the services registrar registers, for the gRPC server of every
+// connection.
+func (d *serverConns) register(registrar ports.ServiceRegistrarPort) error {
+	recorder := &serviceRecorder{}
+	registrar.Register(recorder)
+	if recorder.err != nil {
+		return recorder.err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, service := range recorder.services {
+		for _, registered := range d.services {
+			if registered.desc.ServiceName == service.desc.ServiceName {
+				return fmt.Errorf("service %q registered twice", service.desc.ServiceName)
+			}
+		}
+		d.services = append(d.services, service)
+	}
+	return nil
+}
+
+// serve accepts connections on listener until it fails or the server is stopped,
+// serving each with a gRPC server of its own. It returns nil once stopped.
+func (d *serverConns) serve(listener net.Listener) error {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		_ = listener.Close()
+		return grpc.ErrServerStopped
+	}
+	d.listener = listener
+	d.mu.Unlock()
+	defer listener.Close()
+
+	var delay time.Duration
+	for {
+		rawConn, err := listener.Accept()
+		if err != nil {
+			if d.isStopped() {
+				return nil
+			}
+			// Back off on temporary errors, e.g. running out of file descriptors, as gRPC does
+			var temporary interface{ Temporary() bool }
+			if errors.As(err, &temporary) && temporary.Temporary() {
+				delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+		d.serveConn(rawConn)
+	}
+}
+
+// serveConn starts a gRPC server for a newly accepted connection.
+func (d *serverConns) serveConn(rawConn net.Conn) {
+	conn := &serverConn{Conn: rawConn, conns: d}
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		_ = rawConn.Close()
+		return
+	}
+	conn.server = grpc.NewServer(d.options...)
+	for _, service := range d.services {
+		conn.server.RegisterService(service.desc, service.impl)
+	}
+	d.conns[conn] = struct{}{}
+	addr := d.listener.Addr()
+	d.mu.Unlock()
+
+	go func() { _ = conn.server.Serve(newConnListener(conn, addr)) }()
+}
+
+// track reports a connection that completed its handshake to the tracker and drains
+// it at its expiry deadline.
+func (d *serverConns) track(conn *serverConn, authInfo credentials.AuthInfo) {
+	var untrack func()
+	if tlsInfo, ok := authInfo.(credentials.TLSInfo); ok {
+		remoteAddr := conn.RemoteAddr().String()
+		untrack = trackConnection(d.tracker, ports.TrackedConnection{
+			ID:               "grpc-server " + remoteAddr,
+			Transport:        "grpc",
+			RemoteAddr:       remoteAddr,
+			State:            tlsInfo.State,
+			LocalCertificate: localLeaf(d.localChain),
+			Close:            func() error { conn.drain(); return nil },
+		})
+	}
+
+	var timer *time.Timer
+	if deadline, ok := d.deadline(authInfo); ok {
+		timer = time.AfterFunc(max(time.Until(deadline), 0), conn.drain)
+	}
+	conn.setTracking(untrack, timer)
+}
+
+// stop stops accepting connections and drains every connection, waiting for their
+// RPCs to finish.
+func (d *serverConns) stop() {
+	d.mu.Lock()
+	d.stopped = true
+	listener := d.listener
+	servers := make([]*grpc.Server, 0, len(d.conns))
+	for conn := range d.conns {
+		servers = append(servers, conn.server)
+	}
+	d.mu.Unlock()
+
+	if listener != nil {
+		_ = listener.Close()
+	}
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server.GracefulStop()
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *serverConns) isStopped() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stopped
+}
+
+func (d *serverConns) remove(conn *serverConn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.conns, conn)
+}
+
+// serviceRecorder is the service registrar handed to registrars. It records the
+// services, checking them as grpc.Server.RegisterService does.
+type serviceRecorder struct {
+	services []registeredService
+	err      error
+}
+
+func (r *serviceRecorder) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	if r.err != nil {
+		return
+	}
+	if impl != nil {
+		handlerType := reflect.TypeOf(desc.HandlerType).Elem()
+		if implType := reflect.TypeOf(impl); !implType.Implements(handlerType) {
+			r.err = fmt.Errorf("service %q: handler of type %v does not satisfy %v", desc.ServiceName, implType, handlerType)
+			return
+		}
+	}
+	for _, service := range r.services {
+		if service.desc.ServiceName == desc.ServiceName {
+			r.err = fmt.Errorf("service %q registered twice", desc.ServiceName)
+			return
+		}
+	}
+	r.services = append(r.services, registeredService{desc: desc, impl: impl})
+}
+
+// serverConnsCredentials are server credentials that track every accepted connection.
+type serverConnsCredentials struct {
+	credentials.TransportCredentials
+	conns *serverConns
+}
+
+func (c *serverConnsCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, authInfo, err := c.TransportCredentials.ServerHandshake(rawConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tracked, ok := rawConn.(*serverConn); ok {
+		c.conns.track(tracked, authInfo)
+	}
+	return conn, authInfo, nil
+}
+
+func (c *serverConnsCredentials) Clone() credentials.TransportCredentials {
+	return &serverConnsCredentials{TransportCredentials: c.TransportCredentials.Clone(), conns: c.conns}
+}
+
+// serverConn is an accepted connection, below TLS, and the gRPC server that serves it.
+type serverConn struct {
+	net.Conn
+	conns  *serverConns
+	server *grpc.Server
+
+	drainOnce sync.Once
+
+	mu       sync.Mutex
+	untrack  func()
+	deadline *time.Timer
+	closed   bool
+}
+
+// setTracking records how to unregister the connection and its deadline timer, or
+// releases both at once if the connection is already closed.
+func (c *serverConn) setTracking(untrack func(), deadline *time.Timer) {
+	c.mu.Lock()
+	closed := c.closed
+	if !closed {
+		c.untrack, c.deadline = untrack, deadline
+	}
+	c.mu.Unlock()
+
+	if closed {
+		if deadline != nil {
+			deadline.Stop()
+		}
+		if untrack != nil {
+			untrack()
+		}
+	}
+}
+
+// drain gracefully stops the gRPC server of the connection: the client is sent
+// GOAWAY and the connection closes once its in-flight RPCs finish, or at the end of
+// the grace period. Draining more than once has no further effect.
+func (c *serverConn) drain() {
+	c.drainOnce.Do(func() {
+		forced := time.AfterFunc(c.conns.settings.GetMaxConnectionAgeGrace(), c.server.Stop)
+		go func() {
+			c.server.GracefulStop()
+			forced.Stop()
+		}()
+	})
+}
+
+// Close closes the connection, stops tracking it and stops its gRPC server.
+func (c *serverConn) Close() error {
+	err := c.Conn.Close()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return err
+	}
+	c.closed = true
+	untrack, deadline := c.untrack, c.deadline
+	c.mu.Unlock()
+
+	if deadline != nil {
+		deadline.Stop()
+	}
+	if untrack != nil {
+		untrack()
+	}
+	c.conns.remove(c)
+	// The connection may be closed by its own server, which Stop waits for
+	go c.server.Stop()
+	return err
+}
+
+// connListener hands a single accepted connection to a gRPC server.
+type connListener struct {
+	conns chan net.Conn
+	addr  net.Addr
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newConnListener(conn net.Conn, addr net.Addr) *connListener {
+	l := &connListener{conns: make(chan net.Conn, 1), addr: addr, done: make(chan struct{})}
+	l.conns <- conn
+	return l
+}
+
+// Accept returns the connection, then blocks until the listener is closed.
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close unblocks Accept, closing the connection if it was never accepted.
+func (l *connListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+		select {
+		case conn := <-l.conns:
+			_ = conn.Close()
+		default:
+		}
+	})
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// trackConnection reports an established connection to tracker and returns the
+// function that unregisters it, or nil if there is no tracker or the tracker cannot
+// check the connection, e.g. because the client presented no certificate.
+func trackConnection(tracker ports.ConnectionTrackerPort, conn ports.TrackedConnection) func() {
+	if tracker == nil {
+		return nil
+	}
+	untrack, err := tracker.TrackConnection(conn)
+	if err != nil {
+		return nil
+	}
+	return untrack
+}
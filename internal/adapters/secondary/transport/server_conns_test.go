@@ -0,0 +1,362 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// expiringAuthInfo returns TLS auth info whose peer chain expires at the given times.
+func expiringAuthInfo(expiries ...time.Time) credentials.AuthInfo {
+	chain := make([]*x509.Certificate, 0, len(expiries))
+	for _, expiry := range expiries {
+		chain = append(chain, &x509.Certificate{NotAfter: expiry})
+	}
+	return credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: chain}}
+}
+
+func noJitter() *ports.GRPCConfig {
+	jitter := 0.0
+	return &ports.GRPCConfig{ExpiryMargin: time.Minute, ExpiryJitter: &jitter}
+}
+
+// fakeTracker records the connections reported to it.
+type fakeTracker struct {
+	mu        sync.Mutex
+	conns     map[string]ports.TrackedConnection
+	untracked []string
+}
+
+func newFakeTracker() *fakeTracker {
+	return &fakeTracker{conns: make(map[string]ports.TrackedConnection)}
+}
+
+func (f *fakeTracker) TrackConnection(conn ports.TrackedConnection) (func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conns[conn.ID] = conn
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.conns, conn.ID)
+		f.untracked = append(f.untracked, conn.ID)
+	}, nil
+}
+
+func (f *fakeTracker) tracked() []ports.TrackedConnection {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	conns := make([]ports.TrackedConnection, 0, len(f.conns))
+	for _, conn := range f.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// authInfoCredentials are insecure credentials that report authInfo for every
+// connection, so that the tests need no certificates.
+type authInfoCredentials struct {
+	credentials.TransportCredentials
+	authInfo credentials.AuthInfo
+}
+
+func (c *authInfoCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, _, err := c.TransportCredentials.ServerHandshake(rawConn)
+	return conn, c.authInfo, err
+}
+
+// registrarFunc adapts a function to ports.ServiceRegistrarPort.
+type registrarFunc func(server interface{})
+
+func (f registrarFunc) Register(server interface{}) { f(server) }
+
+// connsService answers Peer at once and Wait once released, both with the peer
+// address of the RPC. Wait reports that it started on started.
+type connsService struct {
+	started chan string
+	release chan struct{}
+}
+
+func (s *connsService) peer(ctx context.Context) (*wrapperspb.StringValue, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Internal, "no peer")
+	}
+	return wrapperspb.String(p.Addr.String()), nil
+}
+
+func (s *connsService) wait(ctx context.Context) (*wrapperspb.StringValue, error) {
+	addr, err := s.peer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.started <- addr.GetValue()
+	select {
+	case <-s.release:
+		return addr, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func connsMethod(name string, call func(*connsService, context.Context) (*wrapperspb.StringValue, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			if err := dec(&emptypb.Empty{}); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) { return call(srv.(*connsService), ctx) }
+			if interceptor == nil {
+				return handler(ctx, nil)
+			}
+			return interceptor(ctx, nil, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/ephemos.test.Conns/" + name}, handler)
+		},
+	}
+}
+
+var connsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ephemos.test.Conns",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		connsMethod("Peer", (*connsService).peer),
+		connsMethod("Wait", (*connsService).wait),
+	},
+}
+
+// serveConns serves connsService through conns on a local port, each connection
+// authenticated with authInfo, and returns the service and a client of it.
+func serveConns(t *testing.T, conns *serverConns, authInfo credentials.AuthInfo, opts ...grpc.ServerOption) (*connsService, *grpc.ClientConn) {
+	t.Helper()
+	service := &connsService{started: make(chan string, 1), release: make(chan struct{})}
+	conns.options = append([]grpc.ServerOption{
+		grpc.Creds(conns.credentials(&authInfoCredentials{TransportCredentials: insecure.NewCredentials(), authInfo: authInfo})),
+	}, opts...)
+	require.NoError(t, conns.register(registrarFunc(func(server interface{}) {
+		server.(grpc.ServiceRegistrar).RegisterService(&connsServiceDesc, service)
+	})))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() { served <- conns.serve(listener) }()
+	t.Cleanup(func() {
+		conns.stop()
+		assert.NoError(t, <-served)
+	})
+
+	client, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return service, client
+}
+
+// call invokes method on the client and returns the peer address the server saw.
+func call(ctx context.Context, client *grpc.ClientConn, method string) (string, error) {
+	reply := &wrapperspb.StringValue{}
+	err := client.Invoke(ctx, "/ephemos.test.Conns/"+method, &emptypb.Empty{}, reply)
+	return reply.GetValue(), err
+}
+
+func TestServerConns_Deadline(t *testing.T) {
+	now := time.Now()
+	peerExpiry, localExpiry := now.Add(time.Hour), now.Add(30*time.Minute)
+	localChain := func() []*x509.Certificate { return []*x509.Certificate{{NotAfter: localExpiry}} }
+
+	conns := newServerConns(noJitter(), nil, nil)
+	deadline, ok := conns.deadline(expiringAuthInfo(peerExpiry, now.Add(2*time.Hour)))
+	require.True(t, ok)
+	assert.Equal(t, peerExpiry.Add(-time.Minute), deadline, "the earliest peer certificate sets the deadline")
+
+	conns = newServerConns(noJitter(), localChain, nil)
+	deadline, ok = conns.deadline(expiringAuthInfo(peerExpiry))
+	require.True(t, ok)
+	assert.Equal(t, localExpiry.Add(-time.Minute), deadline, "the server SVID expires first")
+
+	deadline, ok = conns.deadline(credentials.TLSInfo{})
+	require.True(t, ok, "clients without a certificate still follow the server SVID")
+	assert.Equal(t, localExpiry.Add(-time.Minute), deadline)
+
+	_, ok = newServerConns(nil, nil, nil).deadline(credentials.TLSInfo{})
+	assert.False(t, ok)
+
+	jitter := 0.5
+	conns = newServerConns(&ports.GRPCConfig{ExpiryMargin: time.Minute, ExpiryJitter: &jitter}, nil, nil)
+	for range 20 {
+		deadline, _ := conns.deadline(expiringAuthInfo(peerExpiry))
+		latest := peerExpiry.Add(-time.Minute)
+		earliest := latest.Add(-time.Duration(jitter * float64(time.Until(latest))))
+		assert.False(t, deadline.After(latest), "deadline %v after %v", deadline, latest)
+		assert.False(t, deadline.Before(earliest.Add(-time.Second)), "deadline %v before %v", deadline, earliest)
+	}
+}
+
+func TestServerConns_DrainsAtDeadline(t *testing.T) {
+	tracker := newFakeTracker()
+	settings := noJitter()
+	settings.MaxConnectionAgeGrace = time.Minute
+	deadline := time.Now().Add(300 * time.Millisecond)
+	service, client := serveConns(t, newServerConns(settings, nil, tracker), expiringAuthInfo(deadline.Add(time.Minute)))
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := call(context.Background(), client, "Wait")
+		waited <- err
+	}()
+	drained := <-service.started
+
+	// The RPC in flight outlives the deadline, then the connection closes
+	time.Sleep(time.Until(deadline) + 200*time.Millisecond)
+	close(service.release)
+	require.NoError(t, <-waited, "RPC in flight cut off at the deadline")
+	assert.Eventually(t, func() bool {
+		for _, conn := range tracker.tracked() {
+			if conn.RemoteAddr == drained {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "connection not closed after its deadline")
+}
+
+func TestServerConns_KeepsPeerAddress(t *testing.T) {
+	// Handlers see the address of the connection as accepted
+	addrs := make(chan net.Addr, 1)
+	recordPeer := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if p, ok := peer.FromContext(ctx); ok {
+			addrs <- p.Addr
+		}
+		return handler(ctx, req)
+	}
+	_, client := serveConns(t, newServerConns(noJitter(), nil, nil), credentials.TLSInfo{}, grpc.UnaryInterceptor(recordPeer))
+
+	addr, err := call(context.Background(), client, "Peer")
+	require.NoError(t, err)
+	tcpAddr, ok := (<-addrs).(*net.TCPAddr)
+	require.True(t, ok, "peer address is not a *net.TCPAddr")
+	assert.Equal(t, addr, tcpAddr.String())
+	assert.True(t, tcpAddr.IP.IsLoopback())
+}
+
+func TestServerConns_MaxAge(t *testing.T) {
+	now := time.Now()
+	chain := func(lifetime time.Duration) func() []*x509.Certificate {
+		return func() []*x509.Certificate { return []*x509.Certificate{{NotBefore: now, NotAfter: now.Add(lifetime)}} }
+	}
+
+	assert.Equal(t, ports.DefaultGRPCMaxConnectionAge, newServerConns(nil, nil, nil).maxAge(), "no SVID to bound the age")
+	assert.Equal(t, ports.DefaultGRPCMaxConnectionAge, newServerConns(noJitter(), chain(24*time.Hour), nil).maxAge(),
+		"long-lived SVIDs do not shorten the configured age")
+
+	// A one-hour SVID is renewed after 30 minutes; connections accepted just before
+	// must be replaced a minute before it expires, jitter included
+	age := newServerConns(noJitter(), chain(time.Hour), nil).maxAge()
+	assert.Equal(t, 29*time.Minute*10/11, age)
+	assert.LessOrEqual(t, age+age/10, 29*time.Minute)
+
+	assert.Equal(t, ports.DefaultGRPCMaxConnectionAge, newServerConns(noJitter(), chain(time.Minute), nil).maxAge(),
+		"SVIDs too short to bound leave it to the expiry deadlines")
+}
+
+func TestServerConns_ReportsConnectionsToTracker(t *testing.T) {
+	tracker := newFakeTracker()
+	local := &x509.Certificate{NotAfter: time.Now().Add(time.Hour)}
+	conns := newServerConns(noJitter(), func() []*x509.Certificate { return []*x509.Certificate{local} }, tracker)
+	_, client := serveConns(t, conns, expiringAuthInfo(time.Now().Add(time.Hour)))
+
+	addr, err := call(context.Background(), client, "Peer")
+	require.NoError(t, err)
+	tracked := tracker.tracked()
+	require.Len(t, tracked, 1)
+	assert.Equal(t, "grpc-server "+addr, tracked[0].ID)
+	assert.Equal(t, "grpc", tracked[0].Transport)
+	assert.Equal(t, addr, tracked[0].RemoteAddr)
+	assert.Same(t, local, tracked[0].LocalCertificate)
+	assert.Len(t, tracked[0].State.PeerCertificates, 1)
+
+	// Closing through the tracker drains the connection, which closes once idle
+	require.NoError(t, tracked[0].Close())
+	assert.Eventually(t, func() bool { return len(tracker.tracked()) == 0 }, 5*time.Second, 10*time.Millisecond,
+		"closed connection still tracked")
+}
+
+func TestServerConns_DrainSendsGoAway(t *testing.T) {
+	tracker := newFakeTracker()
+	settings := noJitter()
+	settings.MaxConnectionAgeGrace = time.Minute
+	service, client := serveConns(t, newServerConns(settings, nil, tracker), expiringAuthInfo(time.Now().Add(time.Hour)))
+
+	type result struct {
+		addr string
+		err  error
+	}
+	waited := make(chan result, 1)
+	go func() {
+		addr, err := call(context.Background(), client, "Wait")
+		waited <- result{addr, err}
+	}()
+	drained := <-service.started
+	tracked := tracker.tracked()
+	require.Len(t, tracked, 1)
+	require.NoError(t, tracked[0].Close())
+
+	// GOAWAY moves new RPCs to another connection while the RPC in flight runs on
+	assert.Eventually(t, func() bool {
+		addr, err := call(context.Background(), client, "Peer")
+		return err == nil && addr != drained
+	}, 5*time.Second, 10*time.Millisecond, "RPCs still sent on the draining connection")
+
+	close(service.release)
+	res := <-waited
+	require.NoError(t, res.err, "RPC in flight cut off")
+	assert.Equal(t, drained, res.addr)
+	assert.Eventually(t, func() bool {
+		for _, conn := range tracker.tracked() {
+			if conn.RemoteAddr == drained {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "drained connection not closed once idle")
+}
+
+func TestServerConns_DrainGracePeriod(t *testing.T) {
+	tracker := newFakeTracker()
+	settings := noJitter()
+	settings.MaxConnectionAgeGrace = 100 * time.Millisecond
+	service, client := serveConns(t, newServerConns(settings, nil, tracker), expiringAuthInfo(time.Now().Add(time.Hour)))
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := call(context.Background(), client, "Wait")
+		waited <- err
+	}()
+	<-service.started
+	tracked := tracker.tracked()
+	require.Len(t, tracked, 1)
+	require.NoError(t, tracked[0].Close())
+
+	select {
+	case err := <-waited:
+		assert.Equal(t, codes.Unavailable, status.Code(err), "RPC not cut off at the end of the grace period")
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed at the end of the grace period")
+	}
+}
@@ -45,10 +45,12 @@ func NewSpireDiagnosticsProvider(config *ports.DiagnosticsConfig) *SpireDiagnost
 
 // GetServerDiagnostics retrieves SPIRE server diagnostic information
 func (d *SpireDiagnosticsProvider) GetServerDiagnostics(ctx context.Context) (*ports.DiagnosticInfo, error) {
+	fipsStatus := ports.CurrentFIPSStatus()
 	info := &ports.DiagnosticInfo{
 		Component:   domain.ComponentSpireServer.String(),
 		CollectedAt: time.Now(),
 		Details:     make(map[string]interface{}),
+		FIPS140:     &fipsStatus,
 	}
 
 	// Get server version using CLI
@@ -96,10 +98,12 @@ func (d *SpireDiagnosticsProvider) GetServerDiagnostics(ctx context.Context) (*p
 
 // GetAgentDiagnostics retrieves SPIRE agent diagnostic information
 func (d *SpireDiagnosticsProvider) GetAgentDiagnostics(ctx context.Context) (*ports.DiagnosticInfo, error) {
+	fipsStatus := ports.CurrentFIPSStatus()
 	info := &ports.DiagnosticInfo{
 		Component:   domain.ComponentSpireAgent.String(),
 		CollectedAt: time.Now(),
 		Details:     make(map[string]interface{}),
+		FIPS140:     &fipsStatus,
 	}
 
 	// Get agent version
@@ -46,13 +46,18 @@ func getDefaultConfig() *ArchConfig {
 			"net/http", // Restricted in core with specific allowances
 		},
 		AllowedHTTPFiles: []string{
+			"pkg/ephemos/bundle_endpoint.go",
+			"pkg/ephemos/policy.go",
 			"pkg/ephemos/http.go",
 			"pkg/ephemos/interfaces.go",
+			"pkg/ephemos/options.go",
+			"pkg/ephemos/peer_identity.go",
 			"pkg/ephemos/public_api.go",
 			"internal/core/ports/client.go",
 		},
 		AllowedHTTPDirs: []string{
 			"internal/adapters/primary/api/",
+			"internal/adapters/primary/bundleendpoint/",
 		},
 		AllowedPaths: []string{
 			"contrib/middleware/",
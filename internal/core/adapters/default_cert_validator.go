@@ -5,6 +5,7 @@
 package adapters
 
 import (
+	"crypto/x509"
 	"fmt"
 
 	"github.com/sufield/ephemos/internal/core/domain"
@@ -18,18 +19,36 @@ import (
 //
 // This adapter follows the hexagonal architecture pattern by implementing a port
 // interface while delegating the actual business logic to domain entities.
-type DefaultCertValidator struct{}
+type DefaultCertValidator struct {
+	revocation ports.RevocationListPort
+}
+
+// DefaultCertValidatorOption configures a DefaultCertValidator.
+type DefaultCertValidatorOption func(*DefaultCertValidator)
+
+// WithRevocationList rejects certificates whose chain, key or SPIFFE ID is on the
+// revocation list, even though they are otherwise valid.
+func WithRevocationList(list ports.RevocationListPort) DefaultCertValidatorOption {
+	return func(v *DefaultCertValidator) {
+		v.revocation = list
+	}
+}
 
 // NewDefaultCertValidator creates a new instance of the default certificate validator.
 // This constructor provides a clear factory method for creating the validator adapter.
-func NewDefaultCertValidator() ports.CertValidatorPort {
-	return &DefaultCertValidator{}
+func NewDefaultCertValidator(opts ...DefaultCertValidatorOption) ports.CertValidatorPort {
+	v := &DefaultCertValidator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
 }
 
 // Validate delegates to the Certificate's Validate method.
 // This implementation provides the standard certificate validation behavior
 // by leveraging the domain entity's validation logic, ensuring that business
 // rules remain in the domain layer while providing a port-compliant interface.
+// A certificate that passes is then checked against the revocation list, if any.
 //
 // Parameters:
 //
@@ -41,5 +60,14 @@ func (v *DefaultCertValidator) Validate(cert *domain.Certificate, opts domain.Ce
 	if cert == nil {
 		return fmt.Errorf("certificate is nil")
 	}
-	return cert.Validate(opts)
+	if err := cert.Validate(opts); err != nil {
+		return err
+	}
+	if v.revocation != nil {
+		chain := append([]*x509.Certificate{cert.Cert}, cert.Chain...)
+		if err := v.revocation.CheckRevocation(chain); err != nil {
+			return fmt.Errorf("certificate validation failed: %w", err)
+		}
+	}
+	return nil
 }
@@ -13,7 +13,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/sufield/ephemos/internal/core/application"
@@ -63,20 +67,21 @@ func TestNewAuthenticationService(t *testing.T) {
 	})
 }
 
-func TestAuthenticationService_GetValidatedIdentity(t *testing.T) {
+func TestAuthenticationService_GetValidatedSVID(t *testing.T) {
 	ctx := context.Background()
 
-	t.Run("successful identity validation", func(t *testing.T) {
-		// Create test identity document
-		identityDoc := createTestIdentityDocument(t, "spiffe://example.org/test-service")
+	t.Run("successful SVID validation", func(t *testing.T) {
+		// Create test SVID
+		svid := createTestSVID(t, "spiffe://example.org/test-service", 24*time.Hour)
 		trustBundle := createTestTrustBundle(t)
 
 		// Setup mocks
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		mockIdentityProvider.On("GetIdentityDocument", ctx).Return(identityDoc, nil)
+		mockIdentityProvider.On("GetSVID", ctx).Return(svid, nil)
 		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
+		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, mock.Anything).Return(nil)
 
 		// Create service
 		config := application.AuthenticationServiceConfig{
@@ -89,10 +94,10 @@ func TestAuthenticationService_GetValidatedIdentity(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test
-		result, err := service.GetValidatedIdentity(ctx)
+		result, err := service.GetValidatedSVID(ctx)
 		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.Equal(t, identityDoc.Subject(), result.Subject())
+		require.NotNil(t, result)
+		assert.Equal(t, svid.ID, result.ID)
 
 		// Verify mock calls
 		mockIdentityProvider.AssertExpectations(t)
@@ -104,7 +109,7 @@ func TestAuthenticationService_GetValidatedIdentity(t *testing.T) {
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		mockIdentityProvider.On("GetIdentityDocument", ctx).Return(nil, errors.New("provider error"))
+		mockIdentityProvider.On("GetSVID", ctx).Return(nil, errors.New("provider error"))
 
 		// Create service
 		config := application.AuthenticationServiceConfig{
@@ -116,33 +121,66 @@ func TestAuthenticationService_GetValidatedIdentity(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test
-		result, err := service.GetValidatedIdentity(ctx)
+		result, err := service.GetValidatedSVID(ctx)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to get identity document")
+		assert.Contains(t, err.Error(), "failed to get SVID")
 		assert.Nil(t, result)
 
 		// Verify mock calls
 		mockIdentityProvider.AssertExpectations(t)
 	})
 
-	t.Run("identity expiring soon triggers refresh", func(t *testing.T) {
-		// Create identity that expires in 3 minutes
-		identityDoc := createTestIdentityDocumentWithExpiry(t, "spiffe://example.org/test-service", 3*time.Minute)
-		refreshedDoc := createTestIdentityDocument(t, "spiffe://example.org/test-service")
+	t.Run("SVID not trusted", func(t *testing.T) {
+		svid := createTestSVID(t, "spiffe://example.org/test-service", 24*time.Hour)
 		trustBundle := createTestTrustBundle(t)
 
 		// Setup mocks
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		// First call returns expiring identity
-		mockIdentityProvider.On("GetIdentityDocument", ctx).Return(identityDoc, nil).Once()
+		mockIdentityProvider.On("GetSVID", ctx).Return(svid, nil)
+		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
+		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, mock.Anything).Return(errors.New("not trusted"))
+
+		// Create service
+		config := application.AuthenticationServiceConfig{
+			IdentityProvider: mockIdentityProvider,
+			BundleProvider:   mockBundleProvider,
+		}
+
+		service, err := application.NewAuthenticationService(config)
+		require.NoError(t, err)
+
+		// Test
+		result, err := service.GetValidatedSVID(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "SVID validation against trust bundle failed")
+		assert.Nil(t, result)
+
+		// Verify mock calls
+		mockIdentityProvider.AssertExpectations(t)
+		mockBundleProvider.AssertExpectations(t)
+	})
+
+	t.Run("SVID expiring soon triggers refresh", func(t *testing.T) {
+		// Create SVID that expires in 3 minutes
+		svid := createTestSVID(t, "spiffe://example.org/test-service", 3*time.Minute)
+		refreshed := createTestSVID(t, "spiffe://example.org/test-service", 24*time.Hour)
+		trustBundle := createTestTrustBundle(t)
+
+		// Setup mocks
+		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
+		mockBundleProvider := mocks.NewMockBundleProviderPort()
+
+		// First call returns expiring SVID
+		mockIdentityProvider.On("GetSVID", ctx).Return(svid, nil).Once()
 		// Refresh is triggered
 		mockIdentityProvider.On("RefreshIdentity", ctx).Return(nil).Once()
-		// Second call returns refreshed identity
-		mockIdentityProvider.On("GetIdentityDocument", ctx).Return(refreshedDoc, nil).Once()
+		// Second call returns refreshed SVID
+		mockIdentityProvider.On("GetSVID", ctx).Return(refreshed, nil).Once()
 		// Trust bundle for validation
 		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
+		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, mock.Anything).Return(nil)
 
 		// Create service with 5 minute threshold
 		config := application.AuthenticationServiceConfig{
@@ -156,11 +194,10 @@ func TestAuthenticationService_GetValidatedIdentity(t *testing.T) {
 		require.NoError(t, err)
 
 		// Test
-		result, err := service.GetValidatedIdentity(ctx)
+		result, err := service.GetValidatedSVID(ctx)
 		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		// Should return the refreshed identity
-		assert.Equal(t, refreshedDoc.Subject(), result.Subject())
+		// Should return the refreshed SVID
+		assert.Same(t, refreshed, result)
 
 		// Verify mock calls
 		mockIdentityProvider.AssertExpectations(t)
@@ -174,13 +211,11 @@ func TestAuthenticationService_ValidatePeerIdentity(t *testing.T) {
 	t.Run("successful peer validation", func(t *testing.T) {
 		// Create test certificate with specific SPIFFE ID
 		peerCert := createTestCertificate(t, "spiffe://example.org/peer-service")
-		trustBundle := createTestTrustBundle(t)
 
 		// Setup mocks
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
 		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, peerCert).Return(nil)
 
 		// Create service
@@ -223,13 +258,11 @@ func TestAuthenticationService_ValidatePeerIdentity(t *testing.T) {
 	t.Run("identity mismatch", func(t *testing.T) {
 		// Create test certificate with different SPIFFE ID
 		peerCert := createTestCertificate(t, "spiffe://example.org/wrong-service")
-		trustBundle := createTestTrustBundle(t)
 
 		// Setup mocks
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
 		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, peerCert).Return(nil)
 
 		// Create service
@@ -253,13 +286,11 @@ func TestAuthenticationService_ValidatePeerIdentity(t *testing.T) {
 	t.Run("certificate not trusted", func(t *testing.T) {
 		// Create test certificate
 		peerCert := createTestCertificate(t, "spiffe://example.org/peer-service")
-		trustBundle := createTestTrustBundle(t)
 
 		// Setup mocks
 		mockIdentityProvider := mocks.NewMockIdentityProviderPort()
 		mockBundleProvider := mocks.NewMockBundleProviderPort()
 
-		mockBundleProvider.On("GetTrustBundle", ctx).Return(trustBundle, nil)
 		mockBundleProvider.On("ValidateCertificateAgainstBundle", ctx, peerCert).Return(errors.New("not trusted"))
 
 		// Create service
@@ -340,47 +371,17 @@ func TestAuthenticationService_RefreshCredentials(t *testing.T) {
 
 // Helper functions
 
-func createTestIdentityDocument(t *testing.T, spiffeID string) *domain.IdentityDocument {
-	cert, key := createTestCertAndKey(t, spiffeID)
+func createTestSVID(t *testing.T, spiffeID string, timeUntilExpiry time.Duration) *x509svid.SVID {
+	cert, key := createTestCertAndKeyWithExpiry(t, spiffeID, timeUntilExpiry)
 
-	doc, err := domain.NewIdentityDocument([]*x509.Certificate{cert}, key, nil)
+	id, err := spiffeid.FromString(spiffeID)
 	require.NoError(t, err)
 
-	return doc
-}
-
-func createTestIdentityDocumentWithExpiry(t *testing.T, spiffeID string, timeUntilExpiry time.Duration) *domain.IdentityDocument {
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	require.NoError(t, err)
-
-	template := &x509.Certificate{
-		SerialNumber: big.NewInt(1),
-		Subject: pkix.Name{
-			CommonName: "test-service",
-		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(timeUntilExpiry),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-		BasicConstraintsValid: true,
-	}
-
-	if spiffeID != "" {
-		spiffeURI, err := url.Parse(spiffeID)
-		require.NoError(t, err)
-		template.URIs = []*url.URL{spiffeURI}
+	return &x509svid.SVID{
+		ID:           id,
+		Certificates: []*x509.Certificate{cert},
+		PrivateKey:   key,
 	}
-
-	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
-	require.NoError(t, err)
-
-	cert, err := x509.ParseCertificate(certDER)
-	require.NoError(t, err)
-
-	doc, err := domain.NewIdentityDocument([]*x509.Certificate{cert}, key, nil)
-	require.NoError(t, err)
-
-	return doc
 }
 
 func createTestCertificate(t *testing.T, spiffeID string) *domain.Certificate {
@@ -393,6 +394,10 @@ func createTestCertificate(t *testing.T, spiffeID string) *domain.Certificate {
 }
 
 func createTestCertAndKey(t *testing.T, spiffeID string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	return createTestCertAndKeyWithExpiry(t, spiffeID, 24*time.Hour)
+}
+
+func createTestCertAndKeyWithExpiry(t *testing.T, spiffeID string, timeUntilExpiry time.Duration) (*x509.Certificate, *ecdsa.PrivateKey) {
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	require.NoError(t, err)
 
@@ -402,7 +407,7 @@ func createTestCertAndKey(t *testing.T, spiffeID string) (*x509.Certificate, *ec
 			CommonName: "test-service",
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(24 * time.Hour),
+		NotAfter:              time.Now().Add(timeUntilExpiry),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: true,
@@ -423,7 +428,7 @@ func createTestCertAndKey(t *testing.T, spiffeID string) (*x509.Certificate, *ec
 	return cert, key
 }
 
-func createTestTrustBundle(t *testing.T) *domain.TrustBundle {
+func createTestTrustBundle(t *testing.T) *x509bundle.Bundle {
 	// Create a test CA certificate
 	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	require.NoError(t, err)
@@ -446,8 +451,5 @@ func createTestTrustBundle(t *testing.T) *domain.TrustBundle {
 	cert, err := x509.ParseCertificate(certDER)
 	require.NoError(t, err)
 
-	bundle, err := domain.NewTrustBundle([]*x509.Certificate{cert})
-	require.NoError(t, err)
-
-	return bundle
+	return x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString("example.org"), []*x509.Certificate{cert})
 }
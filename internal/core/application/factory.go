@@ -5,6 +5,7 @@ package application
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/sufield/ephemos/internal/core/ports"
 	"github.com/sufield/ephemos/internal/core/services"
@@ -17,6 +18,26 @@ type UseCaseFactory struct {
 	identityProvider      ports.IdentityProvider
 	transportProvider     ports.TransportProvider
 	configurationProvider ports.ConfigurationProvider
+	healthComponents      ports.HealthComponentProvider
+	logger                *slog.Logger
+}
+
+// FactoryOption configures optional dependencies of a UseCaseFactory.
+type FactoryOption func(*UseCaseFactory)
+
+// WithHealthComponents sets the provider of the health checkers and reporters
+// the health use case monitors with.
+func WithHealthComponents(provider ports.HealthComponentProvider) FactoryOption {
+	return func(f *UseCaseFactory) {
+		f.healthComponents = provider
+	}
+}
+
+// WithLogger sets the logger of the services created by the factory.
+func WithLogger(logger *slog.Logger) FactoryOption {
+	return func(f *UseCaseFactory) {
+		f.logger = logger
+	}
 }
 
 // NewUseCaseFactory creates a new use case factory with the required dependencies.
@@ -25,6 +46,7 @@ func NewUseCaseFactory(
 	identityProvider ports.IdentityProvider,
 	transportProvider ports.TransportProvider,
 	configurationProvider ports.ConfigurationProvider,
+	opts ...FactoryOption,
 ) (*UseCaseFactory, error) {
 	if config == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
@@ -36,12 +58,16 @@ func NewUseCaseFactory(
 		return nil, fmt.Errorf("transport provider cannot be nil")
 	}
 
-	return &UseCaseFactory{
+	factory := &UseCaseFactory{
 		config:                config,
 		identityProvider:      identityProvider,
 		transportProvider:     transportProvider,
 		configurationProvider: configurationProvider,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(factory)
+	}
+	return factory, nil
 }
 
 // CreateIdentityUseCase creates a configured identity use case.
@@ -62,20 +88,46 @@ func (f *UseCaseFactory) CreateIdentityUseCase(ctx context.Context) (IdentityUse
 	return NewIdentityUseCase(identityService), nil
 }
 
-// CreateHealthUseCase creates a configured health monitoring use case.
-//
-// Future implementation will provide:
-// - Service health status monitoring
-// - Certificate expiry tracking
-// - Connection health validation
-// - System resource monitoring
-//
-// This use case is designed to integrate with external monitoring systems
-// and provide comprehensive health insights for mTLS-enabled services.
+// CreateHealthUseCase creates a health monitoring use case with the checkers and
+// reporters selected by the health section of the configuration. Without a
+// health section nothing is checked and the service reports healthy.
 func (f *UseCaseFactory) CreateHealthUseCase(ctx context.Context) (HealthUseCase, error) {
-	// Health monitoring can be implemented when specific health requirements are defined.
-	// The interface is ready for implementation with monitoring providers.
-	return nil, fmt.Errorf("health use case implementation pending - interface defined for future extension")
+	if f.healthComponents == nil {
+		return nil, fmt.Errorf("health component provider is required for health use case")
+	}
+
+	config := f.config.Health
+	if config == nil {
+		config = &ports.HealthConfig{}
+	}
+
+	checkers, err := f.healthComponents.CreateCheckers(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health checkers: %w", err)
+	}
+	reporters, err := f.healthComponents.CreateReporters(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health reporters: %w", err)
+	}
+
+	monitor, err := services.NewHealthMonitorService(config, f.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health monitor: %w", err)
+	}
+	for _, checker := range checkers {
+		if err := monitor.RegisterChecker(checker); err != nil {
+			_ = monitor.Close()
+			return nil, fmt.Errorf("failed to register health checker: %w", err)
+		}
+	}
+	for _, reporter := range reporters {
+		if err := monitor.RegisterReporter(reporter); err != nil {
+			_ = monitor.Close()
+			return nil, fmt.Errorf("failed to register health reporter: %w", err)
+		}
+	}
+
+	return NewHealthUseCase(monitor, config), nil
 }
 
 // CreateConfigurationUseCase creates a configured configuration management use case.
@@ -0,0 +1,159 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// defaultHealthCheckTimeout bounds a round of checks when the configuration sets
+// no timeout, matching the monitor's own default.
+const defaultHealthCheckTimeout = 10 * time.Second
+
+// HealthUseCaseImpl implements the HealthUseCase interface on top of the
+// HealthMonitorService, tying periodic monitoring to the lifetime of a context.
+type HealthUseCaseImpl struct {
+	monitor *services.HealthMonitorService
+	timeout time.Duration
+
+	mu      sync.Mutex
+	session *monitoringSession
+}
+
+// monitoringSession is one run of periodic monitoring.
+type monitoringSession struct {
+	cancel context.CancelFunc
+	stop   func() bool
+}
+
+// NewHealthUseCase creates a new health use case implementation.
+func NewHealthUseCase(monitor *services.HealthMonitorService, config *ports.HealthConfig) HealthUseCase {
+	timeout := defaultHealthCheckTimeout
+	if config != nil && config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+	return &HealthUseCaseImpl{monitor: monitor, timeout: timeout}
+}
+
+// Monitor returns the underlying monitor, e.g. to serve its results from
+// health endpoints.
+func (u *HealthUseCaseImpl) Monitor() *services.HealthMonitorService {
+	return u.monitor
+}
+
+// StartMonitoring runs a first round of checks, so that the status is known at
+// once, and then checks periodically until StopMonitoring is called or the
+// context is cancelled. Periodic checks only run while the health configuration
+// is enabled.
+func (u *HealthUseCaseImpl) StartMonitoring(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.session != nil {
+		return fmt.Errorf("health monitoring is already running")
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, u.timeout)
+	_, err := u.monitor.CheckAll(checkCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("initial health check failed: %w", err)
+	}
+
+	monitorCtx, cancel := context.WithCancel(ctx)
+	if err := u.monitor.StartMonitoring(monitorCtx); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start health monitoring: %w", err)
+	}
+
+	// The monitoring loop ends with the context, but the monitor only forgets
+	// that it is running when it is stopped
+	session := &monitoringSession{cancel: cancel}
+	session.stop = context.AfterFunc(monitorCtx, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		if u.session == session {
+			u.endLocked()
+		}
+	})
+	u.session = session
+	return nil
+}
+
+// StopMonitoring stops periodic checks. It is safe to call when monitoring is
+// not running.
+func (u *HealthUseCaseImpl) StopMonitoring(_ context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.session == nil {
+		return nil
+	}
+	u.session.stop()
+	u.endLocked()
+	return nil
+}
+
+// endLocked stops the monitor and ends the current session.
+func (u *HealthUseCaseImpl) endLocked() {
+	// A monitor that is not running reports an error, which is fine
+	_ = u.monitor.StopMonitoring()
+	u.session.cancel()
+	u.session = nil
+}
+
+// Close stops monitoring and closes the reporters.
+func (u *HealthUseCaseImpl) Close() error {
+	if err := u.StopMonitoring(context.Background()); err != nil {
+		return err
+	}
+	return u.monitor.Close()
+}
+
+// GetHealthStatus returns the health of every component from the latest round
+// of checks, running one if there is none yet. A component is healthy only if
+// its check reported it healthy.
+func (u *HealthUseCaseImpl) GetHealthStatus(ctx context.Context) (*domain.HealthStatus, error) {
+	results := u.monitor.GetResults()
+	if len(results) == 0 {
+		checkCtx, cancel := context.WithTimeout(ctx, u.timeout)
+		defer cancel()
+
+		var err error
+		results, err = u.monitor.CheckAll(checkCtx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check health: %w", err)
+		}
+	}
+
+	status := domain.NewHealthStatus()
+	if len(results) == 0 {
+		status.Message = "No health checks are configured"
+		return status, nil
+	}
+
+	for name, result := range results {
+		metadata := make(map[string]interface{}, len(result.Details)+2)
+		for key, value := range result.Details {
+			metadata[key] = value
+		}
+		metadata["status"] = string(result.Status)
+		metadata["response_time"] = result.ResponseTime.String()
+
+		component := domain.ComponentHealth{
+			Healthy:   result.Status == ports.HealthStatusHealthy,
+			LastCheck: result.CheckedAt,
+			Metadata:  metadata,
+		}
+		if !component.Healthy {
+			component.ErrorMessage = result.Message
+		}
+		status.AddComponent(name, component)
+	}
+	return status, nil
+}
@@ -0,0 +1,171 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// countingChecker reports a fixed status and counts its checks.
+type countingChecker struct {
+	name   string
+	status ports.HealthStatus
+	checks atomic.Int32
+}
+
+func (c *countingChecker) GetComponentName() string { return c.name }
+
+func (c *countingChecker) result() *ports.HealthResult {
+	return &ports.HealthResult{
+		Component: c.name,
+		Status:    c.status,
+		Message:   string(c.status),
+		CheckedAt: time.Now(),
+	}
+}
+
+func (c *countingChecker) CheckHealth(context.Context) (*ports.HealthResult, error) {
+	c.checks.Add(1)
+	return c.result(), nil
+}
+
+func (c *countingChecker) CheckLiveness(context.Context) (*ports.HealthResult, error) {
+	return c.result(), nil
+}
+
+func (c *countingChecker) CheckReadiness(context.Context) (*ports.HealthResult, error) {
+	return c.result(), nil
+}
+
+// staticHealthComponents serves fixed checkers.
+type staticHealthComponents struct {
+	checkers []ports.HealthCheckerPort
+	err      error
+}
+
+func (s *staticHealthComponents) CreateCheckers(*ports.HealthConfig) ([]ports.HealthCheckerPort, error) {
+	return s.checkers, s.err
+}
+
+func (s *staticHealthComponents) CreateReporters(*ports.HealthConfig) ([]ports.HealthReporterPort, error) {
+	return nil, nil
+}
+
+func newTestHealthUseCase(t *testing.T, config *ports.HealthConfig, checkers ...ports.HealthCheckerPort) *HealthUseCaseImpl {
+	t.Helper()
+	monitor, err := services.NewHealthMonitorService(config, nil)
+	require.NoError(t, err)
+	for _, checker := range checkers {
+		require.NoError(t, monitor.RegisterChecker(checker))
+	}
+	useCase := NewHealthUseCase(monitor, config).(*HealthUseCaseImpl)
+	t.Cleanup(func() { _ = useCase.Close() })
+	return useCase
+}
+
+func TestHealthUseCase_StartStopMonitoring(t *testing.T) {
+	checker := &countingChecker{name: "svid", status: ports.HealthStatusHealthy}
+	useCase := newTestHealthUseCase(t, &ports.HealthConfig{Enabled: true, Interval: 10 * time.Millisecond}, checker)
+
+	ctx := context.Background()
+	require.NoError(t, useCase.StartMonitoring(ctx))
+	assert.Error(t, useCase.StartMonitoring(ctx), "monitoring is already running")
+
+	// The first round runs before StartMonitoring returns
+	assert.NotNil(t, useCase.Monitor().GetResults()["svid"])
+	assert.Eventually(t, func() bool { return checker.checks.Load() > 2 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, useCase.StopMonitoring(ctx))
+	require.NoError(t, useCase.StopMonitoring(ctx), "stopping twice is harmless")
+
+	// Monitoring can be restarted
+	require.NoError(t, useCase.StartMonitoring(ctx))
+	require.NoError(t, useCase.StopMonitoring(ctx))
+}
+
+func TestHealthUseCase_MonitoringEndsWithContext(t *testing.T) {
+	useCase := newTestHealthUseCase(t, &ports.HealthConfig{Enabled: true, Interval: time.Hour},
+		&countingChecker{name: "svid", status: ports.HealthStatusHealthy})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, useCase.StartMonitoring(ctx))
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return useCase.StartMonitoring(context.Background()) == nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHealthUseCase_GetHealthStatus(t *testing.T) {
+	t.Run("no checkers", func(t *testing.T) {
+		useCase := newTestHealthUseCase(t, &ports.HealthConfig{})
+
+		status, err := useCase.GetHealthStatus(context.Background())
+		require.NoError(t, err)
+		assert.True(t, status.Overall)
+		assert.Empty(t, status.Components)
+		assert.NotEmpty(t, status.Message)
+	})
+
+	t.Run("components", func(t *testing.T) {
+		healthy := &countingChecker{name: "svid", status: ports.HealthStatusHealthy}
+		unknown := &countingChecker{name: "trust-bundle", status: ports.HealthStatusUnknown}
+		useCase := newTestHealthUseCase(t, &ports.HealthConfig{}, healthy, unknown)
+
+		status, err := useCase.GetHealthStatus(context.Background())
+		require.NoError(t, err)
+		assert.False(t, status.Overall, "only healthy components count as healthy")
+		require.Len(t, status.Components, 2)
+
+		assert.True(t, status.Components["svid"].Healthy)
+		assert.Empty(t, status.Components["svid"].ErrorMessage)
+		assert.False(t, status.Components["trust-bundle"].Healthy)
+		assert.Equal(t, string(ports.HealthStatusUnknown), status.Components["trust-bundle"].ErrorMessage)
+		assert.Equal(t, string(ports.HealthStatusUnknown), status.Components["trust-bundle"].Metadata["status"])
+
+		// Later calls serve the latest results instead of checking again
+		_, err = useCase.GetHealthStatus(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), healthy.checks.Load())
+	})
+}
+
+func TestUseCaseFactory_CreateHealthUseCase(t *testing.T) {
+	config := &ports.Configuration{
+		Health: &ports.HealthConfig{Enabled: true, Interval: time.Hour},
+	}
+	// The health use case does not use the identity and transport providers
+	identityProvider := struct{ ports.IdentityProvider }{}
+	transportProvider := struct{ ports.TransportProvider }{}
+	newFactory := func(opts ...FactoryOption) *UseCaseFactory {
+		factory, err := NewUseCaseFactory(config, identityProvider, transportProvider, nil, opts...)
+		require.NoError(t, err)
+		return factory
+	}
+
+	_, err := newFactory().CreateHealthUseCase(context.Background())
+	assert.Error(t, err, "a health component provider is required")
+
+	_, err = newFactory(WithHealthComponents(&staticHealthComponents{err: errors.New("no agent socket")})).
+		CreateHealthUseCase(context.Background())
+	assert.Error(t, err)
+
+	useCase, err := newFactory(WithHealthComponents(&staticHealthComponents{
+		checkers: []ports.HealthCheckerPort{&countingChecker{name: "svid", status: ports.HealthStatusUnhealthy}},
+	})).CreateHealthUseCase(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = useCase.Close() })
+
+	status, err := useCase.GetHealthStatus(context.Background())
+	require.NoError(t, err)
+	assert.False(t, status.Overall)
+	assert.Contains(t, status.Components, "svid")
+}
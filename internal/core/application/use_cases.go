@@ -60,6 +60,9 @@ type HealthUseCase interface {
 
 	// GetHealthStatus retrieves current health status.
 	GetHealthStatus(ctx context.Context) (*domain.HealthStatus, error)
+
+	// Close stops monitoring and releases the reporters.
+	Close() error
 }
 
 // ConfigurationUseCase defines the application-level operations for configuration management.
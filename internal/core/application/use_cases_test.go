@@ -4,6 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -17,7 +20,7 @@ import (
 func TestUseCaseFactoryCreation(t *testing.T) {
 	config := &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "test-service",
+			Name:   domain.NewServiceNameUnsafe("test-service"),
 			Domain: "test.local",
 		},
 	}
@@ -55,7 +58,7 @@ func TestUseCaseFactoryValidation(t *testing.T) {
 		{
 			name: "nil identity provider",
 			config: &ports.Configuration{
-				Service: ports.ServiceConfig{Name: "test", Domain: "test.local"},
+				Service: ports.ServiceConfig{Name: domain.NewServiceNameUnsafe("test"), Domain: "test.local"},
 			},
 			identityProvider:  nil,
 			transportProvider: &mockTransportProvider{},
@@ -66,7 +69,7 @@ func TestUseCaseFactoryValidation(t *testing.T) {
 		{
 			name: "nil transport provider",
 			config: &ports.Configuration{
-				Service: ports.ServiceConfig{Name: "test", Domain: "test.local"},
+				Service: ports.ServiceConfig{Name: domain.NewServiceNameUnsafe("test"), Domain: "test.local"},
 			},
 			identityProvider:  &mockIdentityProvider{},
 			transportProvider: nil,
@@ -97,7 +100,7 @@ func TestIdentityUseCaseImpl(t *testing.T) {
 	// Create a test identity service
 	config := &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "test-service",
+			Name:   domain.NewServiceNameUnsafe("test-service"),
 			Domain: "test.local",
 		},
 	}
@@ -122,19 +125,19 @@ func TestIdentityUseCaseImpl(t *testing.T) {
 
 type mockIdentityProvider struct{}
 
-func (m *mockIdentityProvider) GetServiceIdentity() (*domain.ServiceIdentity, error) {
-	return domain.NewServiceIdentity("mock-service", "mock.local"), nil
+func (m *mockIdentityProvider) GetServiceIdentity() (spiffeid.ID, error) {
+	return spiffeid.RequireFromString("spiffe://mock.local/mock-service"), nil
 }
 
 func (m *mockIdentityProvider) GetCertificate() (*domain.Certificate, error) {
 	return nil, assert.AnError
 }
 
-func (m *mockIdentityProvider) GetTrustBundle() (*domain.TrustBundle, error) {
+func (m *mockIdentityProvider) GetTrustBundle() (*x509bundle.Bundle, error) {
 	return nil, assert.AnError
 }
 
-func (m *mockIdentityProvider) GetIdentityDocument() (*domain.IdentityDocument, error) {
+func (m *mockIdentityProvider) GetSVID() (*x509svid.SVID, error) {
 	return nil, assert.AnError
 }
 
@@ -157,7 +160,7 @@ type mockConfigProvider struct{}
 func (m *mockConfigProvider) LoadConfiguration(ctx context.Context, path string) (*ports.Configuration, error) {
 	return &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "mock-service",
+			Name:   domain.NewServiceNameUnsafe("mock-service"),
 			Domain: "mock.local",
 		},
 	}, nil
@@ -166,7 +169,7 @@ func (m *mockConfigProvider) LoadConfiguration(ctx context.Context, path string)
 func (m *mockConfigProvider) GetDefaultConfiguration(ctx context.Context) *ports.Configuration {
 	return &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "default-service",
+			Name:   domain.NewServiceNameUnsafe("default-service"),
 			Domain: "default.local",
 		},
 	}
@@ -30,6 +30,8 @@ type CertValidationOptions struct {
 	SkipExpiry       bool             // Optional: Skip expiry checks (testing only)
 	SkipChainVerify  bool             // Optional: Skip chain cryptographic verification
 	Logger           *slog.Logger     // Optional: Logger for warnings and info (uses default if nil)
+	FIPS             bool             // Optional: Reject keys and signatures that are not FIPS 140-3 approved
+	KeyPolicy        *KeyPolicy       // Optional: Restrict key algorithms, curves and RSA sizes of the leaf and chain
 }
 
 // NewCertificate creates a new Certificate with validation.
@@ -68,6 +70,18 @@ func (c *Certificate) Validate(opts CertValidationOptions) error {
 		return fmt.Errorf("private key cannot be nil")
 	}
 
+	if opts.FIPS {
+		if err := c.validateFIPS(opts.TrustBundle); err != nil {
+			return err
+		}
+	}
+
+	if opts.KeyPolicy != nil {
+		if err := c.validateKeyPolicy(*opts.KeyPolicy); err != nil {
+			return err
+		}
+	}
+
 	// Use go-spiffe SDK validation when trust bundle is provided
 	if opts.TrustBundle != nil && !opts.SkipChainVerify {
 		// Convert TrustBundle to x509bundle.Source
@@ -149,6 +163,38 @@ func (c *Certificate) verifyPrivateKeyWithID(spiffeID spiffeid.ID) error {
 	return nil
 }
 
+// validateFIPS checks the leaf, its chain and the trust bundle for algorithms
+// that are not FIPS 140-3 approved.
+func (c *Certificate) validateFIPS(trustBundle *TrustBundle) error {
+	if err := ValidateFIPSCertificate(c.Cert); err != nil {
+		return fmt.Errorf("FIPS validation failed: %w", err)
+	}
+	for _, cert := range c.Chain {
+		if err := ValidateFIPSCertificate(cert); err != nil {
+			return fmt.Errorf("FIPS validation failed for chain: %w", err)
+		}
+	}
+	if trustBundle != nil {
+		if err := trustBundle.ValidateFIPS(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateKeyPolicy checks the leaf and its chain against the key policy.
+func (c *Certificate) validateKeyPolicy(policy KeyPolicy) error {
+	if err := policy.ValidateCertificate(c.Cert); err != nil {
+		return fmt.Errorf("key policy validation failed: %w", err)
+	}
+	for _, cert := range c.Chain {
+		if err := policy.ValidateCertificate(cert); err != nil {
+			return fmt.Errorf("key policy validation failed for chain: %w", err)
+		}
+	}
+	return nil
+}
+
 // validateBasicWithoutTrust performs basic validation when no trust bundle is available
 func (c *Certificate) validateBasicWithoutTrust(opts CertValidationOptions) error {
 	// Basic private key matching
@@ -13,6 +13,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/stretchr/testify/require"
 
 	"github.com/sufield/ephemos/internal/core/adapters"
@@ -486,10 +488,7 @@ func TestTrustBundle_CreateCertPool(t *testing.T) {
 // Test static trust bundle provider
 func TestStaticTrustBundleProvider(t *testing.T) {
 	validCA, _ := createTestCACertificate(t)
-	bundle, err := domain.NewTrustBundle([]*x509.Certificate{validCA})
-	if err != nil {
-		t.Fatalf("Failed to create test bundle: %v", err)
-	}
+	bundle := x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString("example.org"), []*x509.Certificate{validCA})
 
 	t.Run("NewStaticTrustBundleProvider", func(t *testing.T) {
 		provider := adapters.NewStaticTrustBundleProvider(bundle)
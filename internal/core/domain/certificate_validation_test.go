@@ -1,11 +1,15 @@
 package domain_test
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"io"
 	"math/big"
 	"net/url"
 	"testing"
@@ -237,3 +241,127 @@ func TestValidateWithEmptyOptions(t *testing.T) {
 	validationErr := validCert.Validate(domain.CertValidationOptions{})
 	assert.NoError(t, validationErr)
 }
+
+// TestCertificateValidate_FIPS tests that FIPS mode rejects non-approved curves
+func TestCertificateValidate_FIPS(t *testing.T) {
+	newCert := func(t *testing.T, curve elliptic.Curve) *domain.Certificate {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{Organization: []string{"Test"}},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(certDER)
+		require.NoError(t, err)
+		return &domain.Certificate{Cert: cert, PrivateKey: key}
+	}
+
+	t.Run("P-256 passes", func(t *testing.T) {
+		err := newCert(t, elliptic.P256()).Validate(domain.CertValidationOptions{FIPS: true})
+		assert.NoError(t, err)
+	})
+
+	t.Run("P-224 fails only in FIPS mode", func(t *testing.T) {
+		cert := newCert(t, elliptic.P224())
+		assert.NoError(t, cert.Validate(domain.CertValidationOptions{}))
+
+		err := cert.Validate(domain.CertValidationOptions{FIPS: true})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not FIPS approved")
+	})
+
+	t.Run("non-approved chain certificate fails", func(t *testing.T) {
+		cert := newCert(t, elliptic.P256())
+		cert.Chain = []*x509.Certificate{newCert(t, elliptic.P224()).Cert}
+
+		err := cert.Validate(domain.CertValidationOptions{FIPS: true})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "chain")
+	})
+}
+
+// TestValidateFIPSPublicKey tests the FIPS approved key types
+func TestValidateFIPSPublicKey(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	assert.NoError(t, domain.ValidateFIPSPublicKey(&p384.PublicKey))
+	assert.NoError(t, domain.ValidateFIPSPublicKey(&rsa2048.PublicKey))
+	assert.NoError(t, domain.ValidateFIPSPublicKey(edKey))
+	assert.Error(t, domain.ValidateFIPSPublicKey(&p224.PublicKey))
+	assert.Error(t, domain.ValidateFIPSPublicKey(&rsa1024.PublicKey))
+	assert.Error(t, domain.ValidateFIPSPublicKey("not a key"))
+
+	assert.NoError(t, domain.ValidateFIPSSignatureAlgorithm(x509.ECDSAWithSHA256))
+	assert.Error(t, domain.ValidateFIPSSignatureAlgorithm(x509.SHA1WithRSA))
+	assert.Error(t, domain.ValidateFIPSSignatureAlgorithm(x509.ECDSAWithSHA1))
+}
+
+// TestCertificateValidate_KeyPolicy tests key algorithm, curve and RSA size restrictions
+func TestCertificateValidate_KeyPolicy(t *testing.T) {
+	selfSigned := func(t *testing.T, key interface {
+		Public() crypto.PublicKey
+		Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error)
+	}) *domain.Certificate {
+		t.Helper()
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{Organization: []string{"Test"}},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(certDER)
+		require.NoError(t, err)
+		return &domain.Certificate{Cert: cert, PrivateKey: key}
+	}
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaCert, rsaCert, edCert := selfSigned(t, p256), selfSigned(t, rsa2048), selfSigned(t, edKey)
+
+	defaultPolicy := &domain.KeyPolicy{}
+	for _, cert := range []*domain.Certificate{ecdsaCert, rsaCert, edCert} {
+		assert.NoError(t, cert.Validate(domain.CertValidationOptions{KeyPolicy: defaultPolicy}))
+	}
+
+	strict := &domain.KeyPolicy{MinRSAKeyBits: 3072, Curves: []string{"P384"}}
+	assert.ErrorContains(t, rsaCert.Validate(domain.CertValidationOptions{KeyPolicy: strict}), "below the key policy minimum")
+	assert.ErrorContains(t, ecdsaCert.Validate(domain.CertValidationOptions{KeyPolicy: strict}), "curve P-256 is not allowed")
+	assert.NoError(t, edCert.Validate(domain.CertValidationOptions{KeyPolicy: strict}))
+
+	ed25519Only := &domain.KeyPolicy{Algorithms: []string{"ed25519"}}
+	assert.NoError(t, edCert.Validate(domain.CertValidationOptions{KeyPolicy: ed25519Only}))
+	assert.ErrorContains(t, rsaCert.Validate(domain.CertValidationOptions{KeyPolicy: ed25519Only}), "RSA keys are not allowed")
+
+	// Chain certificates are checked too
+	withChain := *edCert
+	withChain.Chain = []*x509.Certificate{rsaCert.Cert}
+	assert.ErrorContains(t, withChain.Validate(domain.CertValidationOptions{KeyPolicy: strict}), "chain")
+
+	assert.Error(t, domain.KeyPolicy{Algorithms: []string{"DSA"}}.Validate())
+	assert.Error(t, domain.KeyPolicy{Curves: []string{"secp256k1"}}.Validate())
+	assert.Error(t, domain.KeyPolicy{MinRSAKeyBits: 1024}.ValidateFIPS())
+	assert.Error(t, domain.KeyPolicy{Curves: []string{"P-224"}}.ValidateFIPS())
+	assert.NoError(t, domain.KeyPolicy{MinRSAKeyBits: 3072, Curves: []string{"P-384"}}.ValidateFIPS())
+}
@@ -1,75 +1,94 @@
-// Package domain contains cryptographic validation focused on ECDSA keys used in SPIFFE.
+// Package domain contains cryptographic validation for the ECDSA, RSA and Ed25519 keys used in SPIFFE.
 package domain
 
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 )
 
-// SupportsKeyType checks if a key is a supported ECDSA key type.
-// For MVP, we only support ECDSA keys as they are the standard for SPIFFE.
+// FIPSMinRSAKeyBits is the smallest RSA modulus FIPS mode accepts.
+const FIPSMinRSAKeyBits = 2048
+
+// SupportsKeyType checks if a key is a supported ECDSA, RSA or Ed25519 key type.
+// These are the key types X.509-SVIDs may use.
 func SupportsKeyType(key interface{}) bool {
 	switch key.(type) {
-	case *ecdsa.PublicKey, *ecdsa.PrivateKey:
+	case *ecdsa.PublicKey, *ecdsa.PrivateKey,
+		*rsa.PublicKey, *rsa.PrivateKey,
+		ed25519.PublicKey, ed25519.PrivateKey, *ed25519.PrivateKey:
 		return true
 	default:
 		return false
 	}
 }
 
+// KeyAlgorithm returns the algorithm name of a supported key: "ECDSA", "RSA" or
+// "Ed25519". It returns an empty string for unsupported keys.
+func KeyAlgorithm(key interface{}) string {
+	switch key.(type) {
+	case *ecdsa.PublicKey, *ecdsa.PrivateKey:
+		return KeyAlgorithmECDSA
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return KeyAlgorithmRSA
+	case ed25519.PublicKey, ed25519.PrivateKey, *ed25519.PrivateKey:
+		return KeyAlgorithmEd25519
+	default:
+		return ""
+	}
+}
+
 // ValidateKeyPairMatching validates that a private key matches a certificate's public key.
 // This replaces mechanical comparison operations with domain intent validation.
 func ValidateKeyPairMatching(certPublicKey interface{}, privateKeyPublic interface{}) error {
-	// Express intent: verify this is a supported ECDSA key type
+	// Express intent: verify this is a supported key type
 	if !SupportsKeyType(certPublicKey) {
-		return fmt.Errorf("certificate public key must be ECDSA for SPIFFE - unsupported key type: %T", certPublicKey)
+		return fmt.Errorf("certificate public key must be ECDSA, RSA or Ed25519 for SPIFFE - unsupported key type: %T", certPublicKey)
 	}
 
 	if !SupportsKeyType(privateKeyPublic) {
-		return fmt.Errorf("private key must be ECDSA for SPIFFE - unsupported key type: %T", privateKeyPublic)
+		return fmt.Errorf("private key must be ECDSA, RSA or Ed25519 for SPIFFE - unsupported key type: %T", privateKeyPublic)
 	}
 
 	// Express intent: verify the keys match cryptographically
 	if !KeysMatch(certPublicKey, privateKeyPublic) {
-		return fmt.Errorf("ECDSA key pair does not match")
+		return fmt.Errorf("%s key pair does not match", KeyAlgorithm(certPublicKey))
 	}
 
 	return nil
 }
 
-// KeysMatch determines if two ECDSA cryptographic keys represent the same key pair.
+// KeysMatch determines if two public keys represent the same key pair.
+// Keys of different algorithms never match.
 // This replaces mechanical equal operations with domain intent.
 func KeysMatch(certPublicKey, privateKeyPublic interface{}) bool {
-	// Both keys must be ECDSA for SPIFFE
-	ecdsaCertKey, ok1 := certPublicKey.(*ecdsa.PublicKey)
-	ecdsaPrivKey, ok2 := privateKeyPublic.(*ecdsa.PublicKey)
-
-	if !ok1 || !ok2 {
+	// Every supported public key type implements Equal, which compares the
+	// algorithm parameters (curve, modulus) as well as the key material
+	key, ok := certPublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !SupportsKeyType(certPublicKey) {
 		return false
 	}
-
-	// Compare ECDSA public key components: curve and coordinates (X, Y)
-	return ecdsaCertKey.Curve == ecdsaPrivKey.Curve &&
-		ecdsaCertKey.X.Cmp(ecdsaPrivKey.X) == 0 &&
-		ecdsaCertKey.Y.Cmp(ecdsaPrivKey.Y) == 0
+	return key.Equal(privateKeyPublic)
 }
 
-// ValidateSignerKeyType validates that a crypto.Signer is ECDSA.
+// ValidateSignerKeyType validates that a crypto.Signer is ECDSA, RSA or Ed25519.
 // This replaces mechanical type switches with domain intent validation.
 func ValidateSignerKeyType(signer crypto.Signer) error {
 	if signer == nil {
 		return fmt.Errorf("signer cannot be nil")
 	}
 
-	if !SupportsKeyType(signer) {
-		return fmt.Errorf("signer must be ECDSA for SPIFFE - unsupported signer type: %T", signer)
+	if !SupportsKeyType(signer) && !SupportsKeyType(signer.Public()) {
+		return fmt.Errorf("signer must be ECDSA, RSA or Ed25519 for SPIFFE - unsupported signer type: %T", signer)
 	}
 
 	return nil
 }
 
-// ExtractPublicKeyFromSigner safely extracts the public key from an ECDSA crypto.Signer.
+// ExtractPublicKeyFromSigner safely extracts the public key from a crypto.Signer.
 // This wraps the mechanical Public() call with domain intent and validation.
 func ExtractPublicKeyFromSigner(signer crypto.Signer) (interface{}, error) {
 	if err := ValidateSignerKeyType(signer); err != nil {
@@ -81,65 +100,110 @@ func ExtractPublicKeyFromSigner(signer crypto.Signer) (interface{}, error) {
 		return nil, fmt.Errorf("signer returned nil public key")
 	}
 
-	// Verify the extracted public key is ECDSA
+	// Verify the extracted public key is a supported type
 	if !SupportsKeyType(publicKey) {
-		return nil, fmt.Errorf("signer returned non-ECDSA public key: %T", publicKey)
+		return nil, fmt.Errorf("signer returned unsupported public key: %T", publicKey)
 	}
 
 	return publicKey, nil
 }
 
-// ValidatedKeyPair represents an ECDSA key pair that has been validated to match.
+// ValidatedKeyPair represents a key pair that has been validated to match.
 // This value object guarantees that the public and private keys are cryptographically paired,
 // eliminating the need for repeated validation checks in consuming code.
 type ValidatedKeyPair struct {
-	publicKey  *ecdsa.PublicKey
-	privateKey *ecdsa.PrivateKey
+	publicKey  crypto.PublicKey
+	privateKey crypto.Signer
 }
 
-// NewValidatedKeyPair creates a new ValidatedKeyPair after validating that the ECDSA keys match.
-// This constructor ensures that only valid ECDSA key pairs can be created.
+// NewValidatedKeyPair creates a new ValidatedKeyPair after validating that the keys match.
+// This constructor ensures that only valid ECDSA, RSA or Ed25519 key pairs can be created.
 func NewValidatedKeyPair(publicKey interface{}, privateKey crypto.Signer) (*ValidatedKeyPair, error) {
-	// Validate that we have ECDSA keys
-	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("public key must be ECDSA, got: %T", publicKey)
-	}
-
-	ecdsaPrivateKey, ok := privateKey.(*ecdsa.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("private key must be ECDSA, got: %T", privateKey)
+	if !SupportsKeyType(publicKey) {
+		return nil, fmt.Errorf("public key must be ECDSA, RSA or Ed25519, got: %T", publicKey)
 	}
 
 	// Extract public key from private key for comparison
-	privateKeyPublic := ecdsaPrivateKey.Public()
+	privateKeyPublic, err := ExtractPublicKeyFromSigner(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("private key must be ECDSA, RSA or Ed25519: %w", err)
+	}
 
 	// Validate that the key pair matches
-	if err := ValidateKeyPairMatching(ecdsaPublicKey, privateKeyPublic); err != nil {
-		return nil, fmt.Errorf("ECDSA key pair validation failed: %w", err)
+	if err := ValidateKeyPairMatching(publicKey, privateKeyPublic); err != nil {
+		return nil, fmt.Errorf("key pair validation failed: %w", err)
 	}
 
 	return &ValidatedKeyPair{
-		publicKey:  ecdsaPublicKey,
-		privateKey: ecdsaPrivateKey,
+		publicKey:  publicKey,
+		privateKey: privateKey,
 	}, nil
 }
 
-// PublicKey returns the validated ECDSA public key.
-func (vkp *ValidatedKeyPair) PublicKey() *ecdsa.PublicKey {
+// PublicKey returns the validated public key.
+func (vkp *ValidatedKeyPair) PublicKey() crypto.PublicKey {
 	return vkp.publicKey
 }
 
-// PrivateKey returns the validated ECDSA private key.
-func (vkp *ValidatedKeyPair) PrivateKey() *ecdsa.PrivateKey {
+// PrivateKey returns the validated private key.
+func (vkp *ValidatedKeyPair) PrivateKey() crypto.Signer {
 	return vkp.privateKey
 }
 
-// ValidateAgainstCertificate verifies that this ECDSA key pair matches the given certificate.
+// Algorithm returns the key algorithm: "ECDSA", "RSA" or "Ed25519".
+func (vkp *ValidatedKeyPair) Algorithm() string {
+	return KeyAlgorithm(vkp.publicKey)
+}
+
+// ValidateAgainstCertificate verifies that this key pair matches the given certificate.
 func (vkp *ValidatedKeyPair) ValidateAgainstCertificate(cert interface{ PublicKey() interface{} }) error {
 	certPublicKey := cert.PublicKey()
 	if !KeysMatch(certPublicKey, vkp.publicKey) {
-		return fmt.Errorf("validated ECDSA key pair does not match certificate public key")
+		return fmt.Errorf("validated %s key pair does not match certificate public key", vkp.Algorithm())
+	}
+	return nil
+}
+
+// fipsKeyPolicy accepts only FIPS 140-3 approved keys, whatever the configured policy.
+var fipsKeyPolicy = KeyPolicy{
+	MinRSAKeyBits: FIPSMinRSAKeyBits,
+	Curves:        []string{"P-256", "P-384", "P-521"},
+}
+
+// ValidateFIPSPublicKey checks that a public key uses a FIPS 140-3 approved algorithm:
+// ECDSA on P-256, P-384 or P-521, RSA of at least 2048 bits, or Ed25519.
+func ValidateFIPSPublicKey(key interface{}) error {
+	if err := fipsKeyPolicy.ValidatePublicKey(key); err != nil {
+		return fmt.Errorf("key is not FIPS approved: %w", err)
+	}
+	return nil
+}
+
+// ValidateFIPSSignatureAlgorithm checks that a certificate signature algorithm uses
+// a FIPS 140-3 approved key type with SHA-256 or stronger.
+func ValidateFIPSSignatureAlgorithm(algorithm x509.SignatureAlgorithm) error {
+	switch algorithm {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512,
+		x509.PureEd25519:
+		return nil
+	default:
+		return fmt.Errorf("signature algorithm %s is not FIPS approved", algorithm)
+	}
+}
+
+// ValidateFIPSCertificate checks that a certificate's public key and signature
+// algorithm are both FIPS 140-3 approved.
+func ValidateFIPSCertificate(cert *x509.Certificate) error {
+	if cert == nil {
+		return fmt.Errorf("certificate cannot be nil")
+	}
+	if err := ValidateFIPSPublicKey(cert.PublicKey); err != nil {
+		return fmt.Errorf("certificate %q: %w", cert.Subject.String(), err)
+	}
+	if err := ValidateFIPSSignatureAlgorithm(cert.SignatureAlgorithm); err != nil {
+		return fmt.Errorf("certificate %q: %w", cert.Subject.String(), err)
 	}
 	return nil
 }
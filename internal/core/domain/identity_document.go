@@ -321,8 +321,16 @@ func (doc *IdentityDocument) RequiresPrivateKey() bool {
 // SupportsKeyType returns true if the given private key type is supported.
 // This method expresses domain intent rather than mechanical type assertions.
 func (doc *IdentityDocument) SupportsKeyType(key crypto.Signer) bool {
-	// For MVP, we only support ECDSA keys as they are the standard for SPIFFE
-	return SupportsKeyType(key)
+	// ECDSA, RSA and Ed25519 keys are supported, as for any X.509-SVID
+	return key != nil && ValidateSignerKeyType(key) == nil
+}
+
+// KeyAlgorithm returns the algorithm of the document's key: "ECDSA", "RSA" or "Ed25519".
+func (doc *IdentityDocument) KeyAlgorithm() string {
+	if doc.certificate == nil || doc.certificate.Cert == nil {
+		return ""
+	}
+	return KeyAlgorithm(doc.certificate.Cert.PublicKey)
 }
 
 // String returns a string representation of the identity document for debugging.
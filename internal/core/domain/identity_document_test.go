@@ -1,9 +1,12 @@
 package domain_test
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"math/big"
@@ -229,6 +232,73 @@ func TestIdentityDocument_SupportsKeyType(t *testing.T) {
 	assert.True(t, doc.RequiresPrivateKey())
 }
 
+func TestNewIdentityDocument_KeyAlgorithms(t *testing.T) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RSA Upstream CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(48 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	tests := []struct {
+		algorithm string
+		newKey    func() (crypto.Signer, error)
+	}{
+		{domain.KeyAlgorithmECDSA, func() (crypto.Signer, error) { return ecdsa.GenerateKey(elliptic.P384(), rand.Reader) }},
+		{domain.KeyAlgorithmRSA, func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) }},
+		{domain.KeyAlgorithmEd25519, func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			return key, err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			key, err := tt.newKey()
+			require.NoError(t, err)
+			spiffeURI, err := url.Parse("spiffe://example.org/test-service")
+			require.NoError(t, err)
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      pkix.Name{CommonName: "test-service"},
+				NotBefore:    time.Now().Add(-time.Hour),
+				NotAfter:     time.Now().Add(24 * time.Hour),
+				KeyUsage:     x509.KeyUsageDigitalSignature,
+				URIs:         []*url.URL{spiffeURI},
+			}
+			certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+			require.NoError(t, err)
+			cert, err := x509.ParseCertificate(certDER)
+			require.NoError(t, err)
+
+			doc, err := domain.NewIdentityDocument([]*x509.Certificate{cert}, key, caCert)
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, doc.KeyAlgorithm())
+			assert.True(t, doc.SupportsKeyType(key))
+
+			keyPair, err := domain.NewValidatedKeyPair(cert.PublicKey, key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.algorithm, keyPair.Algorithm())
+
+			// A key of the same algorithm that is not the certificate's must not match
+			otherKey, err := tt.newKey()
+			require.NoError(t, err)
+			_, err = domain.NewIdentityDocument([]*x509.Certificate{cert}, otherKey, caCert)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "does not match")
+		})
+	}
+}
+
 func TestNewIdentityDocumentFromCertificate(t *testing.T) {
 	cert, key := createValidTestCertificate(t, "spiffe://example.org/test-service")
 
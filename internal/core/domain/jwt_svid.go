@@ -0,0 +1,106 @@
+// Package domain provides JWT-SVID claim value objects.
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// DefaultJWTRefreshFraction is the fraction of a JWT-SVID lifetime after which
+// a cached token is considered stale and must be re-fetched. SPIRE itself
+// rotates JWT-SVIDs at half of their lifetime, so we follow the same rule.
+const DefaultJWTRefreshFraction = 0.5
+
+// JWTClaims holds the validated claims of a JWT-SVID.
+// This is a domain value object that abstracts the JWT wire format so that
+// core services and public APIs do not depend on a specific JOSE library.
+type JWTClaims struct {
+	// Subject is the SPIFFE ID carried in the 'sub' claim.
+	Subject string
+
+	// Audience is the list of intended recipients from the 'aud' claim.
+	Audience []string
+
+	// ExpiresAt is the expiry time from the 'exp' claim.
+	ExpiresAt time.Time
+
+	// IssuedAt is the issue time from the 'iat' claim, zero if absent.
+	IssuedAt time.Time
+
+	// Claims contains all raw claims of the token, including custom ones.
+	Claims map[string]interface{}
+}
+
+// SPIFFEID parses the subject claim as a SPIFFE ID.
+func (c *JWTClaims) SPIFFEID() (spiffeid.ID, error) {
+	if c == nil {
+		return spiffeid.ID{}, fmt.Errorf("JWT claims cannot be nil")
+	}
+	id, err := spiffeid.FromString(c.Subject)
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("invalid JWT-SVID subject %q: %w", c.Subject, err)
+	}
+	return id, nil
+}
+
+// HasAudience returns true if the given audience is present in the 'aud' claim.
+func (c *JWTClaims) HasAudience(audience string) bool {
+	if c == nil {
+		return false
+	}
+	for _, aud := range c.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpiredAt returns true if the token is expired at the given time.
+func (c *JWTClaims) IsExpiredAt(now time.Time) bool {
+	if c == nil {
+		return true
+	}
+	return !now.Before(c.ExpiresAt)
+}
+
+// JWTRefreshPolicy decides when a cached JWT-SVID must be re-fetched.
+// A token is stale once the configured fraction of its lifetime has elapsed
+// or its remaining lifetime drops below MinRemaining, whichever comes first.
+type JWTRefreshPolicy struct {
+	// RefreshFraction is the fraction (0, 1] of the token lifetime after which
+	// the token is refreshed. Defaults to DefaultJWTRefreshFraction.
+	RefreshFraction float64
+
+	// MinRemaining forces a refresh when less than this much lifetime is left.
+	MinRemaining time.Duration
+}
+
+// DefaultJWTRefreshPolicy returns the default JWT-SVID refresh policy.
+func DefaultJWTRefreshPolicy() JWTRefreshPolicy {
+	return JWTRefreshPolicy{
+		RefreshFraction: DefaultJWTRefreshFraction,
+		MinRemaining:    30 * time.Second,
+	}
+}
+
+// NeedsRefreshAt returns true if a token fetched at fetchedAt and expiring at
+// expiresAt should be replaced at the given time.
+func (p JWTRefreshPolicy) NeedsRefreshAt(fetchedAt, expiresAt, now time.Time) bool {
+	if !now.Before(expiresAt) {
+		return true
+	}
+	if p.MinRemaining > 0 && expiresAt.Sub(now) < p.MinRemaining {
+		return true
+	}
+
+	fraction := p.RefreshFraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = DefaultJWTRefreshFraction
+	}
+	lifetime := expiresAt.Sub(fetchedAt)
+	refreshAt := fetchedAt.Add(time.Duration(float64(lifetime) * fraction))
+	return !now.Before(refreshAt)
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJWTRefreshPolicy_NeedsRefreshAt(t *testing.T) {
+	fetchedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := fetchedAt.Add(10 * time.Minute)
+
+	tests := []struct {
+		name   string
+		policy JWTRefreshPolicy
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "fresh token",
+			policy: DefaultJWTRefreshPolicy(),
+			now:    fetchedAt.Add(time.Minute),
+			want:   false,
+		},
+		{
+			name:   "past half lifetime",
+			policy: DefaultJWTRefreshPolicy(),
+			now:    fetchedAt.Add(5 * time.Minute),
+			want:   true,
+		},
+		{
+			name:   "expired token",
+			policy: JWTRefreshPolicy{RefreshFraction: 1},
+			now:    expiresAt,
+			want:   true,
+		},
+		{
+			name:   "below minimum remaining lifetime",
+			policy: JWTRefreshPolicy{RefreshFraction: 1, MinRemaining: 2 * time.Minute},
+			now:    expiresAt.Add(-time.Minute),
+			want:   true,
+		},
+		{
+			name:   "invalid fraction falls back to default",
+			policy: JWTRefreshPolicy{RefreshFraction: 3},
+			now:    fetchedAt.Add(4 * time.Minute),
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.NeedsRefreshAt(fetchedAt, expiresAt, tt.now); got != tt.want {
+				t.Errorf("NeedsRefreshAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTClaims(t *testing.T) {
+	claims := &JWTClaims{
+		Subject:   "spiffe://example.org/api",
+		Audience:  []string{"backend", "frontend"},
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	if !claims.HasAudience("frontend") {
+		t.Error("HasAudience(frontend) = false, want true")
+	}
+	if claims.HasAudience("other") {
+		t.Error("HasAudience(other) = true, want false")
+	}
+	if claims.IsExpiredAt(time.Now()) {
+		t.Error("IsExpiredAt(now) = true, want false")
+	}
+	if !claims.IsExpiredAt(claims.ExpiresAt) {
+		t.Error("IsExpiredAt(exp) = false, want true")
+	}
+
+	id, err := claims.SPIFFEID()
+	if err != nil {
+		t.Fatalf("SPIFFEID() error = %v", err)
+	}
+	if id.TrustDomain().String() != "example.org" {
+		t.Errorf("SPIFFEID() trust domain = %s, want example.org", id.TrustDomain())
+	}
+
+	if _, err := (&JWTClaims{Subject: "not-a-spiffe-id"}).SPIFFEID(); err == nil {
+		t.Error("SPIFFEID() with invalid subject should return error")
+	}
+}
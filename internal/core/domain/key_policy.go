@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Key algorithm names used by KeyPolicy.
+const (
+	KeyAlgorithmECDSA   = "ECDSA"
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmEd25519 = "Ed25519"
+)
+
+// DefaultMinRSAKeyBits is the smallest RSA modulus accepted when a KeyPolicy does not set one.
+const DefaultMinRSAKeyBits = 2048
+
+// defaultCurves are the ECDSA curves accepted when a KeyPolicy does not list any.
+var defaultCurves = []string{"P-256", "P-384", "P-521"}
+
+// KeyPolicy restricts the public keys accepted in SVIDs and their certificate chains.
+// The zero value accepts ECDSA on P-256, P-384 or P-521, RSA of at least 2048 bits
+// and Ed25519.
+type KeyPolicy struct {
+	// Algorithms lists the accepted key algorithms: "ECDSA", "RSA" and "Ed25519".
+	// Empty accepts all three.
+	Algorithms []string
+	// MinRSAKeyBits is the smallest accepted RSA modulus. Zero means 2048.
+	MinRSAKeyBits int
+	// Curves lists the accepted ECDSA curves: "P-224", "P-256", "P-384" or "P-521".
+	// Empty accepts P-256, P-384 and P-521.
+	Curves []string
+}
+
+// parseKeyAlgorithm normalizes a key algorithm name, case-insensitively.
+func parseKeyAlgorithm(name string) (string, bool) {
+	for _, algorithm := range []string{KeyAlgorithmECDSA, KeyAlgorithmRSA, KeyAlgorithmEd25519} {
+		if strings.EqualFold(strings.TrimSpace(name), algorithm) {
+			return algorithm, true
+		}
+	}
+	return "", false
+}
+
+// parseCurveName normalizes a curve name, accepting "P256", "P-256" and "CurveP256".
+func parseCurveName(name string) (string, bool) {
+	normalized := strings.ToUpper(strings.TrimSpace(name))
+	normalized = strings.TrimPrefix(normalized, "CURVE")
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		if curveName := curve.Params().Name; strings.ReplaceAll(curveName, "-", "") == normalized {
+			return curveName, true
+		}
+	}
+	return "", false
+}
+
+// Validate checks that every algorithm and curve name is known and that the
+// RSA minimum is not negative.
+func (p KeyPolicy) Validate() error {
+	for _, name := range p.Algorithms {
+		if _, ok := parseKeyAlgorithm(name); !ok {
+			return fmt.Errorf("unknown key algorithm %q: must be ECDSA, RSA or Ed25519", name)
+		}
+	}
+	for _, name := range p.Curves {
+		if _, ok := parseCurveName(name); !ok {
+			return fmt.Errorf("unknown curve %q: must be P-224, P-256, P-384 or P-521", name)
+		}
+	}
+	if p.MinRSAKeyBits < 0 {
+		return fmt.Errorf("minimum RSA key size cannot be negative: %d", p.MinRSAKeyBits)
+	}
+	return nil
+}
+
+// ValidateFIPS checks that the policy accepts no key that FIPS 140-3 rejects.
+func (p KeyPolicy) ValidateFIPS() error {
+	if p.GetMinRSAKeyBits() < FIPSMinRSAKeyBits {
+		return fmt.Errorf("FIPS mode requires RSA keys of at least %d bits, policy allows %d", FIPSMinRSAKeyBits, p.GetMinRSAKeyBits())
+	}
+	for _, name := range p.Curves {
+		if curve, ok := parseCurveName(name); ok && !fipsKeyPolicy.allowsCurveName(curve) {
+			return fmt.Errorf("curve %s is not FIPS approved", curve)
+		}
+	}
+	return nil
+}
+
+// GetMinRSAKeyBits returns the minimum RSA key size or the default.
+func (p KeyPolicy) GetMinRSAKeyBits() int {
+	if p.MinRSAKeyBits <= 0 {
+		return DefaultMinRSAKeyBits
+	}
+	return p.MinRSAKeyBits
+}
+
+// allowsAlgorithm reports whether the policy accepts the key algorithm.
+func (p KeyPolicy) allowsAlgorithm(algorithm string) bool {
+	if len(p.Algorithms) == 0 {
+		return true
+	}
+	for _, name := range p.Algorithms {
+		if allowed, ok := parseKeyAlgorithm(name); ok && allowed == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsCurveName reports whether the policy accepts the ECDSA curve, by canonical name.
+func (p KeyPolicy) allowsCurveName(curveName string) bool {
+	curves := p.Curves
+	if len(curves) == 0 {
+		curves = defaultCurves
+	}
+	for _, name := range curves {
+		if allowed, ok := parseCurveName(name); ok && allowed == curveName {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePublicKey checks that a public key is of an accepted algorithm, curve and size.
+func (p KeyPolicy) ValidatePublicKey(key interface{}) error {
+	algorithm := KeyAlgorithm(key)
+	if algorithm == "" {
+		return fmt.Errorf("unsupported key type %T: must be ECDSA, RSA or Ed25519", key)
+	}
+	if !p.allowsAlgorithm(algorithm) {
+		return fmt.Errorf("%s keys are not allowed by the key policy", algorithm)
+	}
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !p.allowsCurveName(k.Curve.Params().Name) {
+			return fmt.Errorf("ECDSA curve %s is not allowed by the key policy", k.Curve.Params().Name)
+		}
+	case *rsa.PublicKey:
+		if bits := k.N.BitLen(); bits < p.GetMinRSAKeyBits() {
+			return fmt.Errorf("RSA key size %d is below the key policy minimum of %d bits", bits, p.GetMinRSAKeyBits())
+		}
+	case ed25519.PublicKey:
+		// Ed25519 has a single fixed key size
+	default:
+		return fmt.Errorf("expected a public key, got %T", key)
+	}
+	return nil
+}
+
+// ValidateCertificate checks the certificate's public key against the policy.
+func (p KeyPolicy) ValidateCertificate(cert *x509.Certificate) error {
+	if cert == nil {
+		return fmt.Errorf("certificate cannot be nil")
+	}
+	if err := p.ValidatePublicKey(cert.PublicKey); err != nil {
+		return fmt.Errorf("certificate %q: %w", cert.Subject.String(), err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "errors"
+
+// Causes of peer certificate rejections. Verifiers wrap them so that audit and
+// metrics classify rejections with errors.Is rather than by error message.
+// Expired and untrusted chains are reported by crypto/x509's typed errors and
+// revoked certificates by ErrRevoked.
+var (
+	// ErrNoPeerCertificate is wrapped when the peer presented no certificate.
+	ErrNoPeerCertificate = errors.New("peer presented no certificate")
+	// ErrInvalidPeerIdentity is wrapped when the peer certificate is not a valid X.509-SVID.
+	ErrInvalidPeerIdentity = errors.New("peer certificate is not a valid X.509-SVID")
+	// ErrUntrustedPeer is wrapped when there is no trust bundle for the peer's trust domain.
+	ErrUntrustedPeer = errors.New("peer trust domain is not trusted")
+	// ErrUnauthorizedPeer is wrapped when an authorizer rejected the peer's SPIFFE ID.
+	ErrUnauthorizedPeer = errors.New("peer not authorized")
+)
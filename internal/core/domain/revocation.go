@@ -0,0 +1,252 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// Kinds of revocation list entries, reported by RevokedError.
+const (
+	RevocationMatchSPIFFEID = "spiffe_id"
+	RevocationMatchIDPrefix = "id_prefix"
+	RevocationMatchSerial   = "serial"
+	RevocationMatchSPKIHash = "spki_hash"
+)
+
+// ErrRevoked is wrapped by the errors of certificates on a revocation list.
+var ErrRevoked = errors.New("certificate revoked")
+
+// RevokedError reports the revocation list entry a certificate matched.
+type RevokedError struct {
+	// Match is the kind of entry, e.g. RevocationMatchSerial.
+	Match string
+	// Entry is the matched entry in normalized form.
+	Entry string
+}
+
+func (e *RevokedError) Error() string {
+	return fmt.Sprintf("certificate revoked: %s %s is on the revocation list", e.Match, e.Entry)
+}
+
+// Unwrap returns ErrRevoked.
+func (e *RevokedError) Unwrap() error {
+	return ErrRevoked
+}
+
+// RevocationList is a deny-list of workloads whose certificates must no longer be
+// accepted, even though they have not expired. SPIFFE has no CRLs, so compromised
+// workloads are cut off by SPIFFE ID, by SPIFFE ID prefix, by certificate serial
+// number within a trust domain or by the SHA-256 hash of the certificate's
+// SubjectPublicKeyInfo.
+//
+// A RevocationList is immutable. The nil list revokes nothing.
+type RevocationList struct {
+	ids      map[string]struct{}
+	prefixes []string
+	serials  map[string]struct{} // by trust domain and serial, see serialKey
+	spki     map[string]struct{}
+}
+
+// NewRevocationList builds a revocation list:
+//   - ids are SPIFFE IDs, e.g. "spiffe://example.org/payments"
+//   - prefixes revoke every ID at or below them; "spiffe://example.org/ns/dev"
+//     matches "spiffe://example.org/ns/dev/api" but not "spiffe://example.org/ns/devtools",
+//     and a bare trust domain revokes all of its workloads
+//   - serials are hexadecimal, optionally colon separated as printed by openssl, and
+//     qualified by the trust domain whose CA issued them, e.g. "example.org/3c:81:0f:9a";
+//     serial numbers are only unique per issuer, so a bare serial could revoke
+//     another trust domain's workload
+//   - spkiHashes are SHA-256 hashes of the SubjectPublicKeyInfo, in hex or base64
+func NewRevocationList(ids, prefixes, serials, spkiHashes []string) (*RevocationList, error) {
+	list := &RevocationList{
+		ids:     make(map[string]struct{}, len(ids)),
+		serials: make(map[string]struct{}, len(serials)),
+		spki:    make(map[string]struct{}, len(spkiHashes)),
+	}
+
+	for _, entry := range ids {
+		id, err := spiffeid.FromString(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, fmt.Errorf("invalid revoked SPIFFE ID %q: %w", entry, err)
+		}
+		list.ids[id.String()] = struct{}{}
+	}
+
+	for _, entry := range prefixes {
+		id, err := spiffeid.FromString(strings.TrimSuffix(strings.TrimSpace(entry), "/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid revoked SPIFFE ID prefix %q: %w", entry, err)
+		}
+		list.prefixes = append(list.prefixes, id.String())
+	}
+
+	for _, entry := range serials {
+		key, err := normalizeSerialEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		list.serials[key] = struct{}{}
+	}
+
+	for _, entry := range spkiHashes {
+		hash, err := normalizeSPKIHash(entry)
+		if err != nil {
+			return nil, err
+		}
+		list.spki[hash] = struct{}{}
+	}
+
+	return list, nil
+}
+
+// normalizeSerialEntry returns the serial key of a "<trust domain>/<serial>" entry.
+// Neither part can contain a slash, so the last one separates them; the trust
+// domain may also be given as "spiffe://example.org".
+func normalizeSerialEntry(entry string) (string, error) {
+	i := strings.LastIndex(entry, "/")
+	if i < 0 {
+		return "", fmt.Errorf("invalid revoked serial number %q: expected <trust domain>/<serial>, e.g. example.org/3c:81:0f:9a", entry)
+	}
+	td, err := spiffeid.TrustDomainFromString(strings.TrimSpace(entry[:i]))
+	if err != nil {
+		return "", fmt.Errorf("invalid trust domain of revoked serial number %q: %w", entry, err)
+	}
+	serial, err := normalizeSerial(entry[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("invalid revoked serial number %q: expected hexadecimal", entry)
+	}
+	return serialKey(td, serial), nil
+}
+
+// serialKey returns the key of a serial number issued in a trust domain.
+func serialKey(td spiffeid.TrustDomain, serial string) string {
+	return td.Name() + "/" + serial
+}
+
+// normalizeSerial returns the serial number in lowercase hex without leading zeros,
+// the form Certificate.SerialNumber.Text(16) produces.
+func normalizeSerial(entry string) (string, error) {
+	digits := strings.ReplaceAll(strings.TrimSpace(entry), ":", "")
+	digits = strings.TrimPrefix(strings.ToLower(digits), "0x")
+	serial, ok := new(big.Int).SetString(digits, 16)
+	if !ok || digits == "" {
+		return "", fmt.Errorf("invalid revoked serial number %q: expected hexadecimal", entry)
+	}
+	return serial.Text(16), nil
+}
+
+// normalizeSPKIHash returns the hash in lowercase hex.
+func normalizeSPKIHash(entry string) (string, error) {
+	value := strings.TrimPrefix(strings.TrimSpace(entry), "sha256:")
+	if hash, err := hex.DecodeString(strings.ReplaceAll(value, ":", "")); err == nil && len(hash) == sha256.Size {
+		return hex.EncodeToString(hash), nil
+	}
+	if hash, err := base64.StdEncoding.DecodeString(value); err == nil && len(hash) == sha256.Size {
+		return hex.EncodeToString(hash), nil
+	}
+	return "", fmt.Errorf("invalid revoked SPKI hash %q: expected a SHA-256 hash in hex or base64", entry)
+}
+
+// SPKIHash returns the hex SHA-256 hash of the certificate's SubjectPublicKeyInfo,
+// as listed in a RevocationList.
+func SPKIHash(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(hash[:])
+}
+
+// Len returns the number of entries.
+func (l *RevocationList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.ids) + len(l.prefixes) + len(l.serials) + len(l.spki)
+}
+
+// Check returns a *RevokedError if the certificate, its key or its SPIFFE ID is
+// on the list. Its serial number is looked up in the trust domain of its SPIFFE
+// ID; a certificate without one is not checked by serial.
+func (l *RevocationList) Check(cert *x509.Certificate) error {
+	if cert == nil {
+		return nil
+	}
+	return l.check(cert, certTrustDomain(cert))
+}
+
+// check checks cert, looking its serial number up in td, if not zero.
+func (l *RevocationList) check(cert *x509.Certificate, td spiffeid.TrustDomain) error {
+	if l.Len() == 0 || cert == nil {
+		return nil
+	}
+
+	if cert.SerialNumber != nil && !td.IsZero() {
+		if key := serialKey(td, cert.SerialNumber.Text(16)); inSet(l.serials, key) {
+			return &RevokedError{Match: RevocationMatchSerial, Entry: key}
+		}
+	}
+	if len(l.spki) > 0 {
+		if hash := SPKIHash(cert); inSet(l.spki, hash) {
+			return &RevokedError{Match: RevocationMatchSPKIHash, Entry: hash}
+		}
+	}
+
+	for _, uri := range cert.URIs {
+		id, err := spiffeid.FromURI(uri)
+		if err != nil {
+			continue
+		}
+		if inSet(l.ids, id.String()) {
+			return &RevokedError{Match: RevocationMatchSPIFFEID, Entry: id.String()}
+		}
+		for _, prefix := range l.prefixes {
+			if matchesIDPrefix(id.String(), prefix) {
+				return &RevokedError{Match: RevocationMatchIDPrefix, Entry: prefix}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckChain checks every certificate of a chain, leaf first, so that a revoked
+// intermediate revokes the workloads it signed. Serial numbers are looked up in
+// the trust domain of the leaf, whose CAs issued the chain.
+func (l *RevocationList) CheckChain(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	td := certTrustDomain(chain[0])
+	for _, cert := range chain {
+		if err := l.check(cert, td); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// certTrustDomain returns the trust domain of the certificate's SPIFFE ID, or the
+// zero trust domain if it has none.
+func certTrustDomain(cert *x509.Certificate) spiffeid.TrustDomain {
+	for _, uri := range cert.URIs {
+		if id, err := spiffeid.FromURI(uri); err == nil {
+			return id.TrustDomain()
+		}
+	}
+	return spiffeid.TrustDomain{}
+}
+
+// matchesIDPrefix reports whether id is prefix or lies below it in the path hierarchy.
+func matchesIDPrefix(id, prefix string) bool {
+	return id == prefix || strings.HasPrefix(id, prefix+"/")
+}
+
+func inSet(set map[string]struct{}, key string) bool {
+	_, ok := set[key]
+	return ok
+}
@@ -0,0 +1,134 @@
+package domain_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+)
+
+func createRevocationTestCert(t *testing.T, serial int64, id string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestRevocationList_Check(t *testing.T) {
+	t.Parallel()
+
+	cert := createRevocationTestCert(t, 0x1a2b, "spiffe://example.org/ns/dev/api")
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	tests := []struct {
+		name      string
+		ids       []string
+		prefixes  []string
+		serials   []string
+		hashes    []string
+		wantMatch string
+	}{
+		{name: "empty list"},
+		{name: "SPIFFE ID", ids: []string{"spiffe://example.org/ns/dev/api"}, wantMatch: domain.RevocationMatchSPIFFEID},
+		{name: "other SPIFFE ID", ids: []string{"spiffe://example.org/ns/dev"}},
+		{name: "ID prefix", prefixes: []string{"spiffe://example.org/ns/dev/"}, wantMatch: domain.RevocationMatchIDPrefix},
+		{name: "trust domain prefix", prefixes: []string{"spiffe://example.org"}, wantMatch: domain.RevocationMatchIDPrefix},
+		{name: "prefix matches path segments only", prefixes: []string{"spiffe://example.org/ns/de"}},
+		{name: "serial with colons and leading zeros", serials: []string{"example.org/00:1A:2B"}, wantMatch: domain.RevocationMatchSerial},
+		{name: "serial with 0x prefix", serials: []string{"spiffe://example.org/0x1a2b"}, wantMatch: domain.RevocationMatchSerial},
+		{name: "other serial", serials: []string{"example.org/1a2c"}},
+		{name: "serial of another trust domain", serials: []string{"other.org/1a2b"}},
+		{name: "SPKI hash in base64", hashes: []string{base64.StdEncoding.EncodeToString(spki[:])}, wantMatch: domain.RevocationMatchSPKIHash},
+		{name: "SPKI hash in hex", hashes: []string{"sha256:" + domain.SPKIHash(cert)}, wantMatch: domain.RevocationMatchSPKIHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			list, err := domain.NewRevocationList(tt.ids, tt.prefixes, tt.serials, tt.hashes)
+			require.NoError(t, err)
+
+			err = list.Check(cert)
+			if tt.wantMatch == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, domain.ErrRevoked)
+			var revoked *domain.RevokedError
+			require.ErrorAs(t, err, &revoked)
+			assert.Equal(t, tt.wantMatch, revoked.Match)
+		})
+	}
+}
+
+func TestRevocationList_CheckChain(t *testing.T) {
+	t.Parallel()
+
+	leaf := createRevocationTestCert(t, 1, "spiffe://example.org/api")
+	intermediate := createRevocationTestCert(t, 2, "spiffe://example.org")
+
+	list, err := domain.NewRevocationList(nil, nil, []string{"example.org/2"}, nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, list.Check(leaf))
+	assert.ErrorIs(t, list.CheckChain([]*x509.Certificate{leaf, intermediate}), domain.ErrRevoked)
+
+	// The same serial issued by another trust domain's CA is not revoked
+	federated := createRevocationTestCert(t, 1, "spiffe://other.org/api")
+	federatedCA := createRevocationTestCert(t, 2, "spiffe://other.org")
+	assert.NoError(t, list.CheckChain([]*x509.Certificate{federated, federatedCA}))
+
+	var nilList *domain.RevocationList
+	assert.NoError(t, nilList.CheckChain([]*x509.Certificate{leaf, intermediate}))
+	assert.Equal(t, 0, nilList.Len())
+}
+
+func TestNewRevocationList_InvalidEntries(t *testing.T) {
+	t.Parallel()
+
+	_, err := domain.NewRevocationList([]string{"https://example.org/api"}, nil, nil, nil)
+	assert.Error(t, err)
+
+	_, err = domain.NewRevocationList(nil, []string{"example.org"}, nil, nil)
+	assert.Error(t, err)
+
+	_, err = domain.NewRevocationList(nil, nil, []string{""}, nil)
+	assert.Error(t, err)
+
+	_, err = domain.NewRevocationList(nil, nil, []string{"3c:81:0f:9a"}, nil)
+	assert.Error(t, err, "serial without a trust domain")
+
+	_, err = domain.NewRevocationList(nil, nil, []string{"Example Org/3c"}, nil)
+	assert.Error(t, err)
+
+	_, err = domain.NewRevocationList(nil, nil, nil, []string{"not-a-hash"})
+	assert.Error(t, err)
+}
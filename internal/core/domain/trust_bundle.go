@@ -66,6 +66,20 @@ func NewTrustBundleWithValidation(rawCerts []*x509.Certificate, validate bool) (
 	return tb, nil
 }
 
+// ValidateFIPS checks that every CA certificate in the bundle uses FIPS 140-3
+// approved key and signature algorithms.
+func (tb *TrustBundle) ValidateFIPS() error {
+	for i, ca := range tb.Certificates {
+		if ca == nil || ca.Cert == nil {
+			continue
+		}
+		if err := ValidateFIPSCertificate(ca.Cert); err != nil {
+			return fmt.Errorf("FIPS validation failed for CA certificate at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
 // Validate checks that the trust bundle is valid and contains valid certificates.
 func (tb *TrustBundle) Validate() error {
 	// Use domain predicate instead of primitive length check
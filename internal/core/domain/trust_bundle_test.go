@@ -235,6 +235,36 @@ func TestTrustBundle_Validate(t *testing.T) {
 	})
 }
 
+func TestTrustBundle_ValidateFIPS(t *testing.T) {
+	t.Parallel()
+
+	bundle, err := domain.NewTrustBundle([]*x509.Certificate{createValidCACert(t)})
+	require.NoError(t, err)
+	assert.NoError(t, bundle.ValidateFIPS())
+
+	key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "P-224 CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(48 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	weakCA, err := x509.ParseCertificate(certDER)
+	require.NoError(t, err)
+
+	bundle, err = domain.NewTrustBundle([]*x509.Certificate{createValidCACert(t), weakCA})
+	require.NoError(t, err)
+	err = bundle.ValidateFIPS()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "index 1")
+}
+
 func TestRootCACertificate(t *testing.T) {
 	t.Parallel()
 
@@ -22,6 +22,13 @@ var (
 	ErrInsecureSkipVerify = errors.New("certificate validation disabled")
 	ErrWildcardClients    = errors.New("wildcard authorized clients")
 	ErrInsecureSocketPath = errors.New("socket path not in secure directory")
+	ErrWeakTLSVersion     = errors.New("TLS minimum version below 1.2")
+	ErrWeakCipherSuite    = errors.New("weak TLS cipher suite allowed")
+	ErrFIPSModuleDisabled = errors.New("FIPS mode configured but the Go FIPS 140-3 module is not enabled")
+	ErrDevelopmentCA      = errors.New("SVIDs issued by the in-process development CA")
+
+	// Authentication errors
+	ErrAuthenticationNotRequired = errors.New("client authentication not required")
 
 	// Environment errors
 	ErrVerboseLogging = errors.New("verbose logging enabled")
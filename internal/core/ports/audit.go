@@ -0,0 +1,95 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// AuditEventKind distinguishes authentication from authorization decisions.
+type AuditEventKind string
+
+const (
+	// AuditKindAuthentication records the verification of a peer certificate during the handshake.
+	AuditKindAuthentication AuditEventKind = "authentication"
+	// AuditKindAuthorization records a per-request or per-call access decision.
+	AuditKindAuthorization AuditEventKind = "authorization"
+	// AuditKindTermination records a live connection closed because it stopped
+	// satisfying the mTLS invariants.
+	AuditKindTermination AuditEventKind = "termination"
+)
+
+// AuditDecision is the outcome recorded in an audit event.
+type AuditDecision string
+
+const (
+	// AuditDecisionAllow records an accepted peer or request.
+	AuditDecisionAllow AuditDecision = "allow"
+	// AuditDecisionDeny records a rejected peer or request.
+	AuditDecisionDeny AuditDecision = "deny"
+)
+
+// Audit error classes group rejection causes for reporting and alerting.
+const (
+	// AuditErrorNoCertificate means the peer presented no certificate.
+	AuditErrorNoCertificate = "no_certificate"
+	// AuditErrorInvalidIdentity means the peer certificate carries no valid SPIFFE ID.
+	AuditErrorInvalidIdentity = "invalid_identity"
+	// AuditErrorUntrusted means the peer certificate does not chain to a trusted bundle.
+	AuditErrorUntrusted = "untrusted"
+	// AuditErrorExpired means the peer certificate is expired or not yet valid.
+	AuditErrorExpired = "expired"
+	// AuditErrorUnauthorized means a handshake authorizer rejected the peer.
+	AuditErrorUnauthorized = "unauthorized"
+	// AuditErrorPolicyDenied means an authorization policy rejected the request.
+	AuditErrorPolicyDenied = "policy_denied"
+	// AuditErrorRevoked means the peer certificate, key or SPIFFE ID is on the
+	// revocation list.
+	AuditErrorRevoked = "revoked"
+	// AuditErrorInvariantViolation means a live connection failed an mTLS invariant.
+	AuditErrorInvariantViolation = "invariant_violation"
+	// AuditErrorOther covers causes that fit no other class.
+	AuditErrorOther = "other"
+)
+
+// AuditEvent is a single entry of the audit trail.
+//
+// Sequence, PrevHash and Hash are assigned by the trail: each Hash covers the event
+// including PrevHash, so deleting, reordering or editing entries breaks the chain.
+type AuditEvent struct {
+	Time     time.Time      `json:"time"`
+	Sequence uint64         `json:"seq"`
+	Kind     AuditEventKind `json:"kind"`
+	Decision AuditDecision  `json:"decision"`
+
+	// Transport is "http" or "grpc"; Operation is "METHOD /path" or the gRPC full method name.
+	Transport string `json:"transport,omitempty"`
+	Operation string `json:"operation,omitempty"`
+
+	PeerID     string    `json:"peer_id,omitempty"`
+	PeerSerial string    `json:"peer_serial,omitempty"`
+	PeerExpiry time.Time `json:"peer_expiry,omitzero"`
+	LocalID    string    `json:"local_id,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+
+	// Rule names the authorizer or policy rule that decided.
+	Rule       string `json:"rule,omitempty"`
+	ErrorClass string `json:"error_class,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// AuditSinkPort stores or forwards audit events.
+type AuditSinkPort interface {
+	// WriteAuditEvent stores a chained event.
+	WriteAuditEvent(ctx context.Context, event AuditEvent) error
+	// Close flushes and releases the sink.
+	Close() error
+}
+
+// AuditRecorderPort records authentication and authorization decisions.
+// Recording never fails the decision; sink errors are reported by the recorder.
+type AuditRecorderPort interface {
+	RecordAuditEvent(ctx context.Context, event AuditEvent)
+}
@@ -27,6 +27,11 @@ type ConnPort interface {
 	// The client will automatically include authentication credentials in requests.
 	HTTPClient() (HTTPClientPort, error)
 
+	// ClientConnection returns the underlying RPC client connection.
+	// For the SPIFFE transport this is a *grpc.ClientConn sharing the connection's
+	// rotation-aware mTLS credentials; the value is untyped to keep ports framework-free.
+	ClientConnection() (interface{}, error)
+
 	// Close closes the connection and releases associated resources.
 	// Must be safe to call multiple times.
 	Close() error
@@ -35,6 +40,10 @@ type ConnPort interface {
 // AuthenticatedServerPort provides authenticated server hosting capabilities.
 // Implementations handle the underlying authentication protocol (e.g., SPIFFE/SPIRE).
 type AuthenticatedServerPort interface {
+	// RegisterService registers an RPC service implementation with the server.
+	// Must be called before Serve; the registrar receives the underlying server.
+	RegisterService(ctx context.Context, registrar ServiceRegistrarPort) error
+
 	// Serve starts serving requests on the provided listener.
 	// The server will automatically verify client authentication.
 	// Blocks until the context is cancelled or an error occurs.
@@ -0,0 +1,33 @@
+package ports
+
+import "time"
+
+// ConfigChangeEvent describes a configuration change applied at runtime.
+type ConfigChangeEvent struct {
+	// Previous is the configuration before the change.
+	Previous *Configuration
+	// Current is the configuration now in effect.
+	Current *Configuration
+	// ChangedFields lists the applied sections, e.g. "service.cache" or "health".
+	ChangedFields []string
+	// RestartRequired lists changed sections that were not applied because they
+	// only take effect after a restart, e.g. "service.name" or "agent".
+	RestartRequired []string
+	// Time is when the change was applied.
+	Time time.Time
+}
+
+// ConfigWatcherPort provides the current configuration and notifies subscribers
+// when a validated change is applied.
+type ConfigWatcherPort interface {
+	// Current returns the configuration in effect. Callers must not modify it.
+	Current() *Configuration
+
+	// Subscribe returns a channel of applied changes and a function that ends the
+	// subscription. Slow subscribers may miss intermediate events but always
+	// receive the latest one.
+	Subscribe() (<-chan ConfigChangeEvent, func())
+
+	// Close stops watching and closes all subscription channels.
+	Close() error
+}
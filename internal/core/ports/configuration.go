@@ -3,6 +3,7 @@ package ports
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"strings"
 
@@ -32,9 +33,53 @@ type Configuration struct {
 	// If nil, default agent settings will be used.
 	Agent *AgentConfig `yaml:"agent,omitempty"`
 
+	// Identity selects where the SVID and trust bundle come from. If nil, they are
+	// fetched from the Workload API at the agent socket.
+	Identity *IdentitySourceConfig `yaml:"identity,omitempty" mapstructure:"identity"`
+
 	// Health contains the health monitoring configuration.
 	// If nil, health monitoring is disabled.
 	Health *HealthConfig `yaml:"health,omitempty"`
+
+	// Federation lists federated trust domains and their bundle endpoints.
+	// If nil, only the local trust domain is trusted.
+	Federation *FederationConfig `yaml:"federation,omitempty"`
+
+	// Policy holds ordered allow/deny rules deciding which peers may call which
+	// HTTP routes and gRPC methods. If nil, every authenticated peer is allowed.
+	Policy *PolicyConfig `yaml:"policy,omitempty"`
+
+	// TLS holds the minimum version, cipher suites and curve preferences for every
+	// TLS connection. If nil, TLS 1.3 with Go's default curves is used.
+	TLS *TLSConfig `yaml:"tls,omitempty" mapstructure:"tls"`
+
+	// FIPSMode restricts key types, signature algorithms, curves and cipher suites
+	// to the FIPS 140-3 approved sets and rejects SVIDs and bundles that use anything
+	// else. Production readiness also requires Go's FIPS 140-3 module to be enabled.
+	FIPSMode bool `yaml:"fips_mode,omitempty" mapstructure:"fips_mode"`
+
+	// Keys restricts the key algorithms, ECDSA curves and RSA sizes accepted in this
+	// service's SVID and in peer SVIDs. If nil, ECDSA on P-256/P-384/P-521, RSA of at
+	// least 2048 bits and Ed25519 are accepted.
+	Keys *KeyPolicyConfig `yaml:"keys,omitempty" mapstructure:"keys"`
+
+	// Server holds the listen address of servers. If nil, ":8443" is used unless
+	// EPHEMOS_BIND_ADDRESS is set.
+	Server *ServerConfig `yaml:"server,omitempty" mapstructure:"server"`
+
+	// Logging selects the log level and format. If nil, info level text logs are used.
+	Logging *LoggingConfig `yaml:"logging,omitempty" mapstructure:"logging"`
+
+	// Auth controls whether servers require client certificates. If nil, they do.
+	Auth *AuthConfig `yaml:"auth,omitempty" mapstructure:"auth"`
+
+	// Transport holds the gRPC keepalive and connection age settings.
+	// If nil, the defaults are used.
+	Transport *TransportConfig `yaml:"transport,omitempty" mapstructure:"transport"`
+
+	// Revocation lists workloads whose certificates are rejected before they
+	// expire. If nil, no certificate is revoked.
+	Revocation *RevocationConfig `yaml:"revocation,omitempty" mapstructure:"revocation"`
 }
 
 // ServiceConfig contains the core service identification settings.
@@ -66,12 +111,12 @@ type CacheConfig struct {
 	// TTLMinutes specifies the time-to-live for cached certificates and trust bundles in minutes.
 	// Default: 30 minutes (half of typical 1-hour SPIFFE certificate lifetime).
 	// Must be between 1 and 60 minutes for security and performance reasons.
-	TTLMinutes int `yaml:"ttl_minutes,omitempty" validate:"omitempty,min=1,max=60"`
+	TTLMinutes int `yaml:"ttl_minutes,omitempty" mapstructure:"ttl_minutes" validate:"omitempty,min=1,max=60"`
 
 	// ProactiveRefreshMinutes specifies when to proactively refresh certificates before expiry.
 	// Default: 10 minutes before expiry.
 	// Must be less than TTLMinutes and greater than 0.
-	ProactiveRefreshMinutes int `yaml:"proactive_refresh_minutes,omitempty" validate:"omitempty,min=1"`
+	ProactiveRefreshMinutes int `yaml:"proactive_refresh_minutes,omitempty" mapstructure:"proactive_refresh_minutes" validate:"omitempty,min=1"`
 }
 
 // Validate checks if the configuration is valid using go-playground/validator.
@@ -112,9 +157,110 @@ func (c *Configuration) Validate() error {
 		}
 	}
 
+	if err := c.Federation.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Policy.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.EffectiveTLS().Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Keys.Validate(); err != nil {
+		return err
+	}
+	if c.FIPSMode {
+		if err := c.Keys.validateFIPS(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Server.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Logging.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.GRPC().Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Revocation.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Identity.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Health.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// GRPC returns the gRPC server settings, or nil for the defaults.
+func (c *Configuration) GRPC() *GRPCConfig {
+	if c == nil || c.Transport == nil {
+		return nil
+	}
+	return c.Transport.GRPC
+}
+
+// KeyPolicy returns the key policy SVIDs and peer certificates are checked against.
+func (c *Configuration) KeyPolicy() domain.KeyPolicy {
+	if c == nil {
+		return domain.KeyPolicy{}
+	}
+	return c.Keys.Policy()
+}
+
+// EffectiveTLS returns the TLS settings to apply: TLS with the key policy for peer
+// certificates, optional client certificates when authentication is not required,
+// plus the FIPS mode restrictions and defaults when FIPSMode is set.
+func (c *Configuration) EffectiveTLS() *TLSConfig {
+	if c == nil {
+		return nil
+	}
+	optionalClientAuth := !c.RequiresAuthentication()
+	if !c.FIPSMode && c.Keys == nil && !optionalClientAuth {
+		return c.TLS
+	}
+
+	settings := TLSConfig{}
+	if c.TLS != nil {
+		settings = *c.TLS
+	}
+	settings.OptionalClientAuth = optionalClientAuth
+	if c.Keys != nil || c.FIPSMode {
+		policy := c.KeyPolicy()
+		settings.KeyPolicy = &policy
+	}
+	if !c.FIPSMode {
+		return &settings
+	}
+
+	settings.FIPS = true
+	if len(settings.CurvePreferences) == 0 {
+		settings.CurvePreferences = []string{"P256", "P384", "P521"}
+	}
+	if len(settings.CipherSuites) == 0 && settings.GetMinVersion() == tls.VersionTLS12 {
+		settings.CipherSuites = []string{
+			tls.CipherSuiteName(tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256),
+			tls.CipherSuiteName(tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384),
+			tls.CipherSuiteName(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256),
+			tls.CipherSuiteName(tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384),
+		}
+	}
+	return &settings
+}
+
 // validateCacheConstraints validates cross-field constraints for cache configuration
 // that cannot be expressed with simple validation tags.
 func (c *Configuration) validateCacheConstraints() error {
@@ -148,8 +294,12 @@ const (
 	EnvInsecureSkipVerify  = "EPHEMOS_INSECURE_SKIP_VERIFY"
 	EnvRequireAuth         = "EPHEMOS_REQUIRE_AUTHENTICATION"
 	EnvLogLevel            = "EPHEMOS_LOG_LEVEL"
+	EnvLogFormat           = "EPHEMOS_LOG_FORMAT"
 	EnvBindAddress         = "EPHEMOS_BIND_ADDRESS"
 	EnvTLSMinVersion       = "EPHEMOS_TLS_MIN_VERSION"
+	EnvTLSCipherSuites     = "EPHEMOS_TLS_CIPHER_SUITES"
+	EnvTLSCurves           = "EPHEMOS_TLS_CURVE_PREFERENCES"
+	EnvFIPSMode            = "EPHEMOS_FIPS_MODE"
 	EnvDebugEnabled        = "EPHEMOS_DEBUG_ENABLED"
 	EnvCacheTTLMinutes     = "EPHEMOS_CACHE_TTL_MINUTES"
 	EnvCacheRefreshMinutes = "EPHEMOS_CACHE_REFRESH_MINUTES"
@@ -232,6 +382,14 @@ func LoadFromEnvironment() (*Configuration, error) {
 		config.Agent.SocketPath = domain.NewSocketPathUnsafe(socketPath)
 	}
 
+	config.mergeTLSEnvironment(v)
+	if v.IsSet("fips_mode") {
+		config.FIPSMode = v.GetBool("fips_mode")
+	}
+	if err := config.mergeRuntimeEnvironment(); err != nil {
+		return nil, err
+	}
+
 	// Validate the configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("environment configuration validation failed: %w", err)
@@ -275,9 +433,45 @@ func (c *Configuration) MergeWithEnvironment() error {
 		c.Agent.SocketPath = socketPath
 	}
 
+	c.mergeTLSEnvironment(v)
+
+	// Override FIPS mode if set via environment
+	if v.IsSet("fips_mode") {
+		c.FIPSMode = v.GetBool("fips_mode")
+	}
+
+	// Override server address, logging and auth settings if set via environment
+	if err := c.mergeRuntimeEnvironment(); err != nil {
+		return err
+	}
+
 	return c.Validate()
 }
 
+// mergeTLSEnvironment overrides the TLS settings with EPHEMOS_TLS_* variables.
+// Cipher suites and curves are comma-separated lists.
+func (c *Configuration) mergeTLSEnvironment(v *viper.Viper) {
+	minVersion := v.GetString("tls_min_version")
+	cipherSuites := parseCommaSeparatedList(v.GetString("tls_cipher_suites"))
+	curves := parseCommaSeparatedList(v.GetString("tls_curve_preferences"))
+	if minVersion == "" && cipherSuites == nil && curves == nil {
+		return
+	}
+
+	if c.TLS == nil {
+		c.TLS = &TLSConfig{}
+	}
+	if minVersion != "" {
+		c.TLS.MinVersion = minVersion
+	}
+	if cipherSuites != nil {
+		c.TLS.CipherSuites = cipherSuites
+	}
+	if curves != nil {
+		c.TLS.CurvePreferences = curves
+	}
+}
+
 // parseCommaSeparatedList parses a comma-separated string into a slice,
 // trimming whitespace and filtering empty values.
 func parseCommaSeparatedList(value string) []string {
@@ -311,6 +505,24 @@ func validateProductionSecurity(config *Configuration) error {
 		}
 	}
 
+	// The development CA's root is generated in memory and trusted by nothing else
+	if config.Identity.UsesDevCA() {
+		validationErrors = append(validationErrors, errors.ErrDevelopmentCA)
+	}
+
+	// Check TLS protocol settings
+	validationErrors = append(validationErrors, config.EffectiveTLS().ProductionErrors()...)
+
+	// FIPS mode only restricts algorithms; the validated module must be enabled too
+	if config.FIPSMode && !CurrentFIPSStatus().Enabled {
+		validationErrors = append(validationErrors, errors.ErrFIPSModuleDisabled)
+	}
+
+	// Servers must reject clients that present no SVID
+	if !config.RequiresAuthentication() {
+		validationErrors = append(validationErrors, errors.ErrAuthenticationNotRequired)
+	}
+
 	// Use viper for security checks
 	v := viper.New()
 	v.SetEnvPrefix("EPHEMOS")
@@ -328,7 +540,7 @@ func validateProductionSecurity(config *Configuration) error {
 	}
 
 	// Check for verbose logging
-	if logLevel := strings.ToLower(v.GetString("log_level")); logLevel == "debug" || logLevel == "trace" {
+	if logLevel := config.LogLevel().Value; logLevel == "debug" || logLevel == "trace" {
 		validationErrors = append(validationErrors, errors.ErrVerboseLogging)
 	}
 
@@ -1,6 +1,7 @@
 package ports_test
 
 import (
+	"crypto/fips140"
 	"os"
 	"strings"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/errors"
 	"github.com/sufield/ephemos/internal/core/ports"
 )
 
@@ -160,6 +162,126 @@ func TestMergeWithEnvironment(t *testing.T) {
 	assert.Equal(t, "/tmp/file/socket", config.Agent.SocketPath.Value())
 }
 
+func TestMergeWithEnvironment_TLS(t *testing.T) {
+	t.Setenv(ports.EnvServiceName, "")
+	t.Setenv(ports.EnvTrustDomain, "")
+	t.Setenv(ports.EnvTLSMinVersion, "1.2")
+	t.Setenv(ports.EnvTLSCipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	t.Setenv(ports.EnvTLSCurves, "X25519MLKEM768,X25519")
+
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("file-service"),
+			Domain: "file.domain.com",
+		},
+		TLS: &ports.TLSConfig{MinVersion: "1.3", CurvePreferences: []string{"P256"}},
+	}
+
+	assert.NoError(t, config.MergeWithEnvironment())
+	assert.Equal(t, &ports.TLSConfig{
+		MinVersion:       "1.2",
+		CipherSuites:     []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		CurvePreferences: []string{"X25519MLKEM768", "X25519"},
+	}, config.TLS)
+}
+
+func TestValidateProductionSecurity_TLS(t *testing.T) {
+	newConfig := func(settings *ports.TLSConfig) *ports.Configuration {
+		return &ports.Configuration{
+			Service: ports.ServiceConfig{
+				Name:   domain.NewServiceNameUnsafe("payment-service"),
+				Domain: "prod.company.com",
+			},
+			Agent: &ports.AgentConfig{
+				SocketPath: domain.NewSocketPathUnsafe("/run/spire/sockets/api.sock"),
+			},
+			TLS: settings,
+		}
+	}
+
+	assert.NoError(t, newConfig(&ports.TLSConfig{
+		MinVersion:       "1.2",
+		CipherSuites:     []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		CurvePreferences: []string{"X25519MLKEM768", "X25519"},
+	}).IsProductionReady())
+
+	err := newConfig(&ports.TLSConfig{MinVersion: "1.1"}).IsProductionReady()
+	assert.ErrorIs(t, err, errors.ErrWeakTLSVersion)
+
+	for _, suite := range []string{
+		"TLS_RSA_WITH_AES_128_GCM_SHA256",         // no forward secrecy
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",      // not AEAD
+		"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",     // insecure
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256", // insecure, not AEAD
+	} {
+		err := newConfig(&ports.TLSConfig{MinVersion: "1.2", CipherSuites: []string{suite}}).IsProductionReady()
+		assert.ErrorIs(t, err, errors.ErrWeakCipherSuite, suite)
+	}
+}
+
+func TestValidateProductionSecurity_FIPS(t *testing.T) {
+	t.Setenv(ports.EnvFIPSMode, "true")
+
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("payment-service"),
+			Domain: "prod.company.com",
+		},
+		Agent: &ports.AgentConfig{
+			SocketPath: domain.NewSocketPathUnsafe("/run/spire/sockets/api.sock"),
+		},
+	}
+	assert.NoError(t, config.MergeWithEnvironment())
+	assert.True(t, config.FIPSMode)
+
+	err := config.IsProductionReady()
+	if fips140.Enabled() {
+		assert.NoError(t, err)
+	} else {
+		assert.ErrorIs(t, err, errors.ErrFIPSModuleDisabled)
+	}
+}
+
+func TestValidateProductionSecurity_RuntimeSettings(t *testing.T) {
+	t.Setenv(ports.EnvRequireAuth, "false")
+	t.Setenv(ports.EnvLogLevel, "")
+
+	require := true
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("payment-service"),
+			Domain: "prod.company.com",
+		},
+		Agent: &ports.AgentConfig{
+			SocketPath: domain.NewSocketPathUnsafe("/run/spire/sockets/api.sock"),
+		},
+		Logging: &ports.LoggingConfig{Level: "debug"},
+		Auth:    &ports.AuthConfig{Require: &require},
+	}
+	assert.NoError(t, config.MergeWithEnvironment())
+
+	err := config.IsProductionReady()
+	assert.ErrorIs(t, err, errors.ErrAuthenticationNotRequired)
+	assert.ErrorIs(t, err, errors.ErrVerboseLogging)
+}
+
+func TestValidateProductionSecurity_DevelopmentCA(t *testing.T) {
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("payment-service"),
+			Domain: "prod.company.com",
+		},
+		Identity: &ports.IdentitySourceConfig{Source: ports.IdentitySourceDevCA},
+	}
+	assert.ErrorIs(t, config.IsProductionReady(), errors.ErrDevelopmentCA)
+
+	config.Identity.Source = ports.IdentitySourceWorkloadAPI
+	config.Agent = &ports.AgentConfig{
+		SocketPath: domain.NewSocketPathUnsafe("/run/spire/sockets/api.sock"),
+	}
+	assert.NoError(t, config.IsProductionReady())
+}
+
 func TestValidateProductionSecurity(t *testing.T) {
 	tests := []struct {
 		name          string
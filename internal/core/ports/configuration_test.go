@@ -1,8 +1,18 @@
 package ports_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"maps"
+	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
@@ -234,6 +244,892 @@ func TestAgentConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestFederationConfig_Validate(t *testing.T) {
+	web := ports.FederatedTrustDomainConfig{
+		TrustDomain:       "partner.example",
+		BundleEndpointURL: "https://bundle.partner.example/bundle",
+		Profile:           ports.BundleEndpointProfileHTTPSWeb,
+	}
+
+	tests := []struct {
+		name    string
+		domains func() []ports.FederatedTrustDomainConfig
+		wantErr bool
+	}{
+		{
+			name:    "https_web endpoint",
+			domains: func() []ports.FederatedTrustDomainConfig { return []ports.FederatedTrustDomainConfig{web} },
+		},
+		{
+			name: "https_spiffe endpoint",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.Profile = ports.BundleEndpointProfileHTTPSSPIFFE
+				d.EndpointSPIFFEID = "spiffe://partner.example/spire/server"
+				d.BootstrapBundle = "/etc/ephemos/partner.example.pem"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+		},
+		{
+			name: "https_spiffe endpoint without bootstrap bundle",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.Profile = ports.BundleEndpointProfileHTTPSSPIFFE
+				d.EndpointSPIFFEID = "spiffe://partner.example/spire/server"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+			wantErr: true,
+		},
+		{
+			name: "https_spiffe endpoint in another trust domain",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.Profile = ports.BundleEndpointProfileHTTPSSPIFFE
+				d.EndpointSPIFFEID = "spiffe://example.org/spire/server"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+		},
+		{
+			name: "https_spiffe without endpoint ID",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.Profile = ports.BundleEndpointProfileHTTPSSPIFFE
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+			wantErr: true,
+		},
+		{
+			name: "plain http endpoint",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.BundleEndpointURL = "http://bundle.partner.example/bundle"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown profile",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.Profile = "https"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trust domain",
+			domains: func() []ports.FederatedTrustDomainConfig {
+				d := web
+				d.TrustDomain = "Partner Example"
+				return []ports.FederatedTrustDomainConfig{d}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate trust domain",
+			domains: func() []ports.FederatedTrustDomainConfig { return []ports.FederatedTrustDomainConfig{web, web} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ports.Configuration{
+				Service: ports.ServiceConfig{
+					Name:   domain.NewServiceNameUnsafe("test-service"),
+					Domain: "example.org",
+				},
+				Federation: &ports.FederationConfig{TrustDomains: tt.domains()},
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBundleEndpointConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint ports.BundleEndpointConfig
+		wantErr  bool
+	}{
+		{
+			name:     "https_spiffe by default",
+			endpoint: ports.BundleEndpointConfig{Address: ":8443"},
+		},
+		{
+			name: "https_web with certificate",
+			endpoint: ports.BundleEndpointConfig{
+				Address:  ":8443",
+				Profile:  ports.BundleEndpointProfileHTTPSWeb,
+				CertFile: "/etc/ephemos/bundle.crt",
+				KeyFile:  "/etc/ephemos/bundle.key",
+			},
+		},
+		{
+			name:     "https_web without certificate",
+			endpoint: ports.BundleEndpointConfig{Address: ":8443", Profile: ports.BundleEndpointProfileHTTPSWeb},
+			wantErr:  true,
+		},
+		{
+			name:     "missing address",
+			endpoint: ports.BundleEndpointConfig{},
+			wantErr:  true,
+		},
+		{
+			name:     "negative refresh hint",
+			endpoint: ports.BundleEndpointConfig{Address: ":8443", RefreshHint: -time.Second},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown profile",
+			endpoint: ports.BundleEndpointConfig{Address: ":8443", Profile: "http"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			federation := &ports.FederationConfig{BundleEndpoint: &tt.endpoint}
+			err := federation.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ports.PolicyConfig
+		wantErr bool
+	}{
+		{
+			name: "valid rules",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{
+				{
+					Action:  ports.PolicyActionAllow,
+					Peers:   []ports.PeerMatcherConfig{{ID: "spiffe://example.org/admin"}},
+					Methods: []string{"GET"},
+					Paths:   []string{"/admin/*"},
+				},
+				{
+					Action:      ports.PolicyActionDeny,
+					Peers:       []ports.PeerMatcherConfig{{Glob: "spiffe://example.org/ns/*/sa/reporter"}},
+					GRPCMethods: []string{"/billing.v1.Billing/*"},
+				},
+			}},
+		},
+		{
+			name:    "unknown default",
+			policy:  ports.PolicyConfig{Default: "permit"},
+			wantErr: true,
+		},
+		{
+			name:    "missing action",
+			policy:  ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{Paths: []string{"/"}}}},
+			wantErr: true,
+		},
+		{
+			name: "peer with two matchers",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{
+				Action: ports.PolicyActionAllow,
+				Peers:  []ports.PeerMatcherConfig{{ID: "spiffe://example.org/a", Prefix: "spiffe://example.org/"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "prefix without scheme",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{
+				Action: ports.PolicyActionAllow,
+				Peers:  []ports.PeerMatcherConfig{{Prefix: "example.org/"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "relative path",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{
+				Action: ports.PolicyActionAllow,
+				Paths:  []string{"api/*"},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "malformed gRPC method pattern",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{
+				Action:      ports.PolicyActionAllow,
+				GRPCMethods: []string{"/billing.v1.Billing/[Charge"},
+			}}},
+			wantErr: true,
+		},
+		{
+			name: "lower case method",
+			policy: ports.PolicyConfig{Rules: []ports.PolicyRuleConfig{{
+				Action:  ports.PolicyActionAllow,
+				Methods: []string{"get"},
+			}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     ports.TLSConfig
+		wantErr bool
+	}{
+		{
+			name: "post-quantum hybrid curves",
+			tls:  ports.TLSConfig{MinVersion: "1.3", CurvePreferences: []string{"X25519MLKEM768", "X25519", "P-256"}},
+		},
+		{
+			name: "TLS 1.2 cipher suites",
+			tls: ports.TLSConfig{
+				MinVersion:   "TLSv1.2",
+				CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+		{
+			name:    "unknown version",
+			tls:     ports.TLSConfig{MinVersion: "1.4"},
+			wantErr: true,
+		},
+		{
+			name:    "cipher suites with TLS 1.3 minimum",
+			tls:     ports.TLSConfig{CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}},
+			wantErr: true,
+		},
+		{
+			name:    "TLS 1.3 cipher suite",
+			tls:     ports.TLSConfig{MinVersion: "1.2", CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown cipher suite",
+			tls:     ports.TLSConfig{MinVersion: "1.2", CipherSuites: []string{"TLS_NULL_WITH_NULL_NULL"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown curve",
+			tls:     ports.TLSConfig{CurvePreferences: []string{"P224"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tls.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_Apply(t *testing.T) {
+	var defaults tls.Config
+	if err := (*ports.TLSConfig)(nil).Apply(&defaults); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if defaults.MinVersion != tls.VersionTLS13 || defaults.CurvePreferences != nil {
+		t.Errorf("nil settings: MinVersion = %x, CurvePreferences = %v", defaults.MinVersion, defaults.CurvePreferences)
+	}
+
+	settings := &ports.TLSConfig{
+		MinVersion:       "1.2",
+		CipherSuites:     []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+		CurvePreferences: []string{"X25519MLKEM768", "CurveP384"},
+	}
+	var config tls.Config
+	if err := settings.Apply(&config); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if config.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", config.MinVersion)
+	}
+	if len(config.CipherSuites) != 1 || config.CipherSuites[0] != tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256 {
+		t.Errorf("CipherSuites = %v", config.CipherSuites)
+	}
+	if len(config.CurvePreferences) != 2 || config.CurvePreferences[0] != tls.X25519MLKEM768 || config.CurvePreferences[1] != tls.CurveP384 {
+		t.Errorf("CurvePreferences = %v", config.CurvePreferences)
+	}
+}
+
+func TestConfiguration_EffectiveTLS_FIPS(t *testing.T) {
+	newConfig := func(settings *ports.TLSConfig) *ports.Configuration {
+		return &ports.Configuration{
+			Service: ports.ServiceConfig{
+				Name:   domain.NewServiceNameUnsafe("test-service"),
+				Domain: "example.com",
+			},
+			TLS:      settings,
+			FIPSMode: true,
+		}
+	}
+
+	effective := newConfig(nil).EffectiveTLS()
+	if !effective.FIPS || effective.GetMinVersion() != tls.VersionTLS13 {
+		t.Errorf("EffectiveTLS() = %+v, want FIPS with TLS 1.3", effective)
+	}
+	if len(effective.CurvePreferences) != 3 || len(effective.CipherSuites) != 0 {
+		t.Errorf("EffectiveTLS() defaults = %v, %v", effective.CurvePreferences, effective.CipherSuites)
+	}
+
+	settings := &ports.TLSConfig{MinVersion: "1.2"}
+	effective = newConfig(settings).EffectiveTLS()
+	if len(effective.CipherSuites) != 4 || settings.FIPS || settings.CipherSuites != nil {
+		t.Errorf("EffectiveTLS() must add AES-GCM suites to a copy: %+v, original %+v", effective, settings)
+	}
+	if err := newConfig(settings).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	for _, settings := range []*ports.TLSConfig{
+		{CurvePreferences: []string{"X25519MLKEM768"}},
+		{MinVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"}},
+		{MinVersion: "1.1"},
+	} {
+		if err := newConfig(settings).Validate(); err == nil {
+			t.Errorf("Validate() accepted %+v in FIPS mode", settings)
+		}
+		settings.FIPS = false
+		if err := (&ports.Configuration{Service: newConfig(nil).Service, TLS: settings}).Validate(); err != nil {
+			t.Errorf("Validate() without FIPS mode error = %v", err)
+		}
+	}
+}
+
+func TestTLSConfig_Apply_RejectsPeerCertificates(t *testing.T) {
+	newCert := func(curve elliptic.Curve) []byte {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "peer"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	verified := false
+	config := &tls.Config{
+		VerifyPeerCertificate: func([][]byte, [][]*x509.Certificate) error {
+			verified = true
+			return nil
+		},
+	}
+	if err := (&ports.TLSConfig{FIPS: true}).Apply(config); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if err := config.VerifyPeerCertificate([][]byte{newCert(elliptic.P224())}, nil); err == nil {
+		t.Error("VerifyPeerCertificate() accepted a P-224 certificate")
+	}
+	if verified {
+		t.Error("existing verifier ran after the FIPS check failed")
+	}
+	if err := config.VerifyPeerCertificate([][]byte{newCert(elliptic.P384())}, nil); err != nil {
+		t.Errorf("VerifyPeerCertificate() error = %v", err)
+	}
+	if !verified {
+		t.Error("existing verifier was not called")
+	}
+
+	config = &tls.Config{}
+	if err := (&ports.TLSConfig{KeyPolicy: &domain.KeyPolicy{Curves: []string{"P-256"}}}).Apply(config); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if err := config.VerifyPeerCertificate([][]byte{newCert(elliptic.P384())}, nil); err == nil {
+		t.Error("VerifyPeerCertificate() accepted a curve the key policy does not allow")
+	}
+}
+
+func TestConfiguration_KeyPolicy(t *testing.T) {
+	newConfig := func(keys *ports.KeyPolicyConfig, fips bool) *ports.Configuration {
+		return &ports.Configuration{
+			Service: ports.ServiceConfig{
+				Name:   domain.NewServiceNameUnsafe("test-service"),
+				Domain: "example.com",
+			},
+			Keys:     keys,
+			FIPSMode: fips,
+		}
+	}
+
+	if effective := newConfig(nil, false).EffectiveTLS(); effective != nil {
+		t.Errorf("EffectiveTLS() without keys or FIPS mode = %+v, want nil", effective)
+	}
+
+	keys := &ports.KeyPolicyConfig{Algorithms: []string{"RSA", "Ed25519"}, MinRSAKeyBits: 3072}
+	config := newConfig(keys, false)
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	effective := config.EffectiveTLS()
+	if effective == nil || effective.KeyPolicy == nil || effective.KeyPolicy.GetMinRSAKeyBits() != 3072 || effective.FIPS {
+		t.Errorf("EffectiveTLS() = %+v, want the key policy without FIPS", effective)
+	}
+
+	if err := newConfig(&ports.KeyPolicyConfig{Algorithms: []string{"DSA"}}, false).Validate(); err == nil {
+		t.Error("Validate() accepted an unknown key algorithm")
+	}
+	loose := &ports.KeyPolicyConfig{MinRSAKeyBits: 1024, Curves: []string{"P-224", "P-256"}}
+	if err := newConfig(loose, false).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := newConfig(loose, true).Validate(); err == nil {
+		t.Error("Validate() accepted a key policy looser than FIPS mode allows")
+	}
+}
+
+// staticRevocationList checks certificates against a fixed domain list.
+type staticRevocationList struct {
+	list *domain.RevocationList
+}
+
+func (l staticRevocationList) CheckRevocation(chain []*x509.Certificate) error {
+	return l.list.CheckChain(chain)
+}
+
+func (l staticRevocationList) Subscribe() (<-chan struct{}, func()) {
+	return make(chan struct{}), func() {}
+}
+
+func TestTLSConfig_Apply_RejectsRevokedPeers(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCert := func(serial int64) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	list, err := domain.NewRevocationList(nil, nil, []string{"2a"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &tls.Config{}
+	if err := (*ports.TLSConfig)(nil).WithRevocation(staticRevocationList{list}).Apply(config); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if err := config.VerifyPeerCertificate([][]byte{newCert(42)}, nil); !errors.Is(err, domain.ErrRevoked) {
+		t.Errorf("VerifyPeerCertificate() error = %v, want ErrRevoked", err)
+	}
+	if err := config.VerifyPeerCertificate([][]byte{newCert(43)}, nil); err != nil {
+		t.Errorf("VerifyPeerCertificate() error = %v", err)
+	}
+}
+
+func TestRevocationConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *ports.RevocationConfig
+		wantErr bool
+	}{
+		{name: "nil", config: nil},
+		{
+			name: "valid entries",
+			config: &ports.RevocationConfig{
+				SPIFFEIDs:  []string{"spiffe://example.org/payments"},
+				IDPrefixes: []string{"spiffe://example.org/ns/dev"},
+				Serials:    []string{"example.org/01:a2:ff"},
+				SPKIHashes: []string{strings.Repeat("ab", 32)},
+			},
+		},
+		{name: "invalid SPIFFE ID", config: &ports.RevocationConfig{SPIFFEIDs: []string{"payments"}}, wantErr: true},
+		{name: "invalid serial", config: &ports.RevocationConfig{Serials: []string{"example.org/xyz"}}, wantErr: true},
+		{name: "serial without trust domain", config: &ports.RevocationConfig{Serials: []string{"01:a2:ff"}}, wantErr: true},
+		{name: "short SPKI hash", config: &ports.RevocationConfig{SPKIHashes: []string{"abcd"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIdentitySourceConfig_Validate(t *testing.T) {
+	files := &ports.IdentityFilesConfig{
+		CertFile:   "/var/run/secrets/svid.pem",
+		KeyFile:    "/var/run/secrets/svid_key.pem",
+		BundleFile: "/var/run/secrets/bundle.pem",
+	}
+	tests := []struct {
+		name    string
+		config  *ports.IdentitySourceConfig
+		wantErr bool
+	}{
+		{name: "nil", config: nil},
+		{name: "default source", config: &ports.IdentitySourceConfig{}},
+		{name: "workload API", config: &ports.IdentitySourceConfig{Source: ports.IdentitySourceWorkloadAPI}},
+		{name: "files", config: &ports.IdentitySourceConfig{Source: ports.IdentitySourceFiles, Files: files}},
+		{name: "files without paths", config: &ports.IdentitySourceConfig{Source: ports.IdentitySourceFiles}, wantErr: true},
+		{
+			name: "files without bundle",
+			config: &ports.IdentitySourceConfig{
+				Source: ports.IdentitySourceFiles,
+				Files:  &ports.IdentityFilesConfig{CertFile: files.CertFile, KeyFile: files.KeyFile},
+			},
+			wantErr: true,
+		},
+		{name: "dev CA", config: &ports.IdentitySourceConfig{Source: ports.IdentitySourceDevCA}},
+		{
+			name: "dev CA with settings",
+			config: &ports.IdentitySourceConfig{
+				Source: ports.IdentitySourceDevCA,
+				DevCA:  &ports.IdentityDevCAConfig{SPIFFEID: "spiffe://example.org/api", TTL: time.Minute, Intermediate: true},
+			},
+		},
+		{
+			name: "dev CA with invalid SPIFFE ID",
+			config: &ports.IdentitySourceConfig{
+				Source: ports.IdentitySourceDevCA,
+				DevCA:  &ports.IdentityDevCAConfig{SPIFFEID: "api"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dev CA with negative TTL",
+			config: &ports.IdentitySourceConfig{
+				Source: ports.IdentitySourceDevCA,
+				DevCA:  &ports.IdentityDevCAConfig{TTL: -time.Minute},
+			},
+			wantErr: true,
+		},
+		{name: "unknown source", config: &ports.IdentitySourceConfig{Source: "vault"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfiguration_Settings(t *testing.T) {
+	for _, name := range []string{ports.EnvBindAddress, ports.EnvLogLevel, ports.EnvLogFormat, ports.EnvRequireAuth} {
+		t.Setenv(name, "")
+	}
+
+	sources := func(config *ports.Configuration) map[string]string {
+		result := make(map[string]string)
+		for _, setting := range config.Settings() {
+			result[setting.Name] = setting.Value + " (" + setting.Source + ")"
+		}
+		return result
+	}
+
+	var unset *ports.Configuration
+	want := map[string]string{
+		"server.address": ":8443 (default)",
+		"logging.level":  "info (default)",
+		"logging.format": "text (default)",
+		"auth.require":   "true (default)",
+	}
+	if got := sources(unset); !maps.Equal(got, want) {
+		t.Errorf("Settings() of nil configuration = %v, want %v", got, want)
+	}
+
+	require := false
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("test-service"),
+			Domain: "example.com",
+		},
+		Server:  &ports.ServerConfig{Address: "127.0.0.1:9443"},
+		Logging: &ports.LoggingConfig{Level: "warn"},
+		Auth:    &ports.AuthConfig{Require: &require},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	want = map[string]string{
+		"server.address": "127.0.0.1:9443 (file)",
+		"logging.level":  "warn (file)",
+		"logging.format": "text (default)",
+		"auth.require":   "false (file)",
+	}
+	if got := sources(config); !maps.Equal(got, want) {
+		t.Errorf("Settings() = %v, want %v", got, want)
+	}
+	if config.RequiresAuthentication() {
+		t.Error("RequiresAuthentication() = true, want false from auth.require")
+	}
+
+	t.Setenv(ports.EnvBindAddress, ":9000")
+	t.Setenv(ports.EnvLogFormat, "JSON")
+	t.Setenv(ports.EnvRequireAuth, "true")
+	if err := config.MergeWithEnvironment(); err != nil {
+		t.Fatalf("MergeWithEnvironment() error = %v", err)
+	}
+	want = map[string]string{
+		"server.address": ":9000 (env)",
+		"logging.level":  "warn (file)",
+		"logging.format": "json (env)",
+		"auth.require":   "true (env)",
+	}
+	if got := sources(config); !maps.Equal(got, want) {
+		t.Errorf("Settings() with environment = %v, want %v", got, want)
+	}
+	if config.Server.Address != ":9000" || config.Auth.Require == nil || !*config.Auth.Require {
+		t.Errorf("MergeWithEnvironment() did not apply the environment: server %+v, auth %+v", config.Server, config.Auth)
+	}
+
+	t.Setenv(ports.EnvRequireAuth, "sometimes")
+	if err := config.MergeWithEnvironment(); err == nil {
+		t.Error("MergeWithEnvironment() accepted an invalid EPHEMOS_REQUIRE_AUTHENTICATION")
+	}
+}
+
+func TestConfiguration_RequireAuth_Environment(t *testing.T) {
+	required, notRequired := true, false
+	tests := []struct {
+		name   string
+		env    string
+		auth   *ports.AuthConfig
+		want   string
+		source string
+	}{
+		{name: "env cannot weaken the default", env: "false", want: "true", source: ports.SettingSourceDefault},
+		{name: "env cannot weaken auth.require", env: "false", auth: &ports.AuthConfig{Require: &required}, want: "true", source: ports.SettingSourceFile},
+		{name: "env merged into auth.require", env: "false", auth: &ports.AuthConfig{Require: &notRequired}, want: "false", source: ports.SettingSourceEnv},
+		{name: "env raises auth.require", env: "true", auth: &ports.AuthConfig{Require: &notRequired}, want: "true", source: ports.SettingSourceEnv},
+		{name: "invalid env requires authentication", env: "sometimes", auth: &ports.AuthConfig{Require: &notRequired}, want: "true", source: ports.SettingSourceEnv},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ports.EnvRequireAuth, tt.env)
+			config := &ports.Configuration{Auth: tt.auth}
+			got := config.RequireAuth()
+			if got.Value != tt.want || got.Source != tt.source {
+				t.Errorf("RequireAuth() = %s (%s), want %s (%s)", got.Value, got.Source, tt.want, tt.source)
+			}
+		})
+	}
+}
+
+func TestSetting_WithOption(t *testing.T) {
+	t.Setenv(ports.EnvBindAddress, ":9000")
+	config := &ports.Configuration{Server: &ports.ServerConfig{Address: ":9443"}}
+
+	if got := config.BindAddress().WithOption(""); got.Value != ":9000" || got.Source != ports.SettingSourceEnv {
+		t.Errorf("WithOption(\"\") = %s (%s), want :9000 (env)", got.Value, got.Source)
+	}
+	if got := config.BindAddress().WithOption(":8000"); got.Value != ":8000" || got.Source != ports.SettingSourceOption {
+		t.Errorf("WithOption(\":8000\") = %s (%s), want :8000 (option)", got.Value, got.Source)
+	}
+}
+
+func TestConfiguration_Validate_RuntimeSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  *ports.ServerConfig
+		logging *ports.LoggingConfig
+		wantErr string
+	}{
+		{name: "valid", server: &ports.ServerConfig{Address: "[::1]:8443"}, logging: &ports.LoggingConfig{Level: "ERROR", Format: "json"}},
+		{name: "address without port", server: &ports.ServerConfig{Address: "localhost"}, wantErr: "server.address"},
+		{name: "unknown level", logging: &ports.LoggingConfig{Level: "verbose"}, wantErr: "logging.level"},
+		{name: "unknown format", logging: &ports.LoggingConfig{Format: "logfmt"}, wantErr: "logging.format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ports.Configuration{
+				Service: ports.ServiceConfig{
+					Name:   domain.NewServiceNameUnsafe("test-service"),
+					Domain: "example.com",
+				},
+				Server:  tt.server,
+				Logging: tt.logging,
+			}
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGRPCConfig_Validate(t *testing.T) {
+	half, tooMuch := 0.5, 1.5
+	tests := []struct {
+		name    string
+		grpc    *ports.GRPCConfig
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "valid", grpc: &ports.GRPCConfig{MaxConnectionAge: time.Hour, ExpiryMargin: 2 * time.Minute, ExpiryJitter: &half}},
+		{name: "negative age", grpc: &ports.GRPCConfig{MaxConnectionAge: -time.Second}, wantErr: "transport.grpc.max_connection_age"},
+		{name: "negative keepalive", grpc: &ports.GRPCConfig{KeepaliveTime: -time.Second}, wantErr: "transport.grpc.keepalive_time"},
+		{name: "jitter above one", grpc: &ports.GRPCConfig{ExpiryJitter: &tooMuch}, wantErr: "transport.grpc.expiry_jitter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ports.Configuration{
+				Service: ports.ServiceConfig{
+					Name:   domain.NewServiceNameUnsafe("test-service"),
+					Domain: "example.com",
+				},
+				Transport: &ports.TransportConfig{GRPC: tt.grpc},
+			}
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		health  *ports.HealthConfig
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "valid", health: &ports.HealthConfig{
+			Interval: time.Minute,
+			SVID:     &ports.SVIDHealthConfig{Enabled: true, Warning: 30 * time.Minute, Critical: 10 * time.Minute},
+			Bundle:   &ports.BundleHealthConfig{Enabled: true, MaxAge: 24 * time.Hour},
+		}},
+		{name: "negative interval", health: &ports.HealthConfig{Interval: -time.Second}, wantErr: "health.interval"},
+		{name: "negative max age", health: &ports.HealthConfig{Bundle: &ports.BundleHealthConfig{MaxAge: -time.Hour}}, wantErr: "health.bundle.max_age"},
+		{name: "critical above warning", health: &ports.HealthConfig{SVID: &ports.SVIDHealthConfig{Warning: time.Minute, Critical: 2 * time.Minute}}, wantErr: "health.svid.critical"},
+		{name: "critical above default warning", health: &ports.HealthConfig{SVID: &ports.SVIDHealthConfig{Critical: time.Hour}}, wantErr: "health.svid.critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &ports.Configuration{
+				Service: ports.ServiceConfig{
+					Name:   domain.NewServiceNameUnsafe("test-service"),
+					Domain: "example.com",
+				},
+				Health: tt.health,
+			}
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGRPCConfig_Defaults(t *testing.T) {
+	var unset *ports.Configuration
+	grpc := unset.GRPC()
+	if got := grpc.GetMaxConnectionAge(); got != ports.DefaultGRPCMaxConnectionAge {
+		t.Errorf("GetMaxConnectionAge() = %v, want %v", got, ports.DefaultGRPCMaxConnectionAge)
+	}
+	if got := grpc.GetExpiryJitter(); got != ports.DefaultGRPCExpiryJitter {
+		t.Errorf("GetExpiryJitter() = %v, want %v", got, ports.DefaultGRPCExpiryJitter)
+	}
+	if !grpc.GetPermitWithoutStream() {
+		t.Error("GetPermitWithoutStream() = false, want true")
+	}
+
+	noJitter, noPings := 0.0, false
+	grpc = &ports.GRPCConfig{KeepaliveTime: time.Minute, ExpiryJitter: &noJitter, PermitWithoutStream: &noPings}
+	if got := grpc.GetKeepaliveTime(); got != time.Minute {
+		t.Errorf("GetKeepaliveTime() = %v, want 1m", got)
+	}
+	if got := grpc.GetExpiryJitter(); got != 0 {
+		t.Errorf("GetExpiryJitter() = %v, want 0", got)
+	}
+	if grpc.GetPermitWithoutStream() {
+		t.Error("GetPermitWithoutStream() = true, want false")
+	}
+}
+
+func TestTLSConfig_Apply_OptionalClientAuth(t *testing.T) {
+	verified := false
+	config := &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			verified = true
+			return nil
+		},
+	}
+	if err := (&ports.TLSConfig{OptionalClientAuth: true}).Apply(config); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if config.ClientAuth != tls.RequestClientCert {
+		t.Errorf("ClientAuth = %v, want %v", config.ClientAuth, tls.RequestClientCert)
+	}
+	if err := config.VerifyPeerCertificate(nil, nil); err != nil || verified {
+		t.Errorf("VerifyPeerCertificate() without certificates = %v, verified = %v; want it accepted unverified", err, verified)
+	}
+	if err := config.VerifyPeerCertificate([][]byte{{0}}, nil); err != nil || !verified {
+		t.Errorf("VerifyPeerCertificate() with a certificate = %v, verified = %v; want it verified", err, verified)
+	}
+
+	// Servers that do not ask for client certificates are left alone
+	plain := &tls.Config{}
+	if err := (&ports.TLSConfig{OptionalClientAuth: true}).Apply(plain); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if plain.ClientAuth != tls.NoClientCert {
+		t.Errorf("ClientAuth = %v, want %v", plain.ClientAuth, tls.NoClientCert)
+	}
+}
+
 func TestConfiguration_DefaultValues(t *testing.T) {
 	// Test that configuration provides reasonable defaults where appropriate
 	config := &ports.Configuration{
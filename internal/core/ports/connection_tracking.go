@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// TrackedConnection describes an established mTLS connection reported by a transport.
+type TrackedConnection struct {
+	// ID identifies the connection, e.g. "grpc-server 10.0.0.2:51234".
+	ID string
+	// Transport is "grpc" or "http".
+	Transport string
+	// RemoteAddr is the network address of the peer.
+	RemoteAddr string
+	// State is the TLS state of the completed handshake.
+	State tls.ConnectionState
+	// LocalCertificate is the leaf certificate the local side presented.
+	LocalCertificate *x509.Certificate
+	// Close closes the connection once its in-flight requests finish, or after a
+	// grace period. Close may be called more than once.
+	Close func() error
+}
+
+// ConnectionTrackerPort keeps track of live mTLS connections so that connections
+// which stop satisfying the mTLS invariants can be closed.
+type ConnectionTrackerPort interface {
+	// TrackConnection registers an established connection. The returned function
+	// unregisters it and must be called once the connection is closed.
+	TrackConnection(conn TrackedConnection) (untrack func(), err error)
+}
+
+// ConnectionTrackingProvider is implemented by transport providers that report
+// the connections they accept and dial to a tracker.
+type ConnectionTrackingProvider interface {
+	// SetConnectionTracker sets the tracker of servers and clients created afterwards.
+	SetConnectionTracker(tracker ConnectionTrackerPort)
+}
@@ -0,0 +1,213 @@
+package ports
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// BundleEndpointProfile selects how a SPIFFE bundle endpoint is authenticated.
+type BundleEndpointProfile string
+
+const (
+	// BundleEndpointProfileHTTPSWeb authenticates the endpoint with Web PKI.
+	BundleEndpointProfileHTTPSWeb BundleEndpointProfile = "https_web"
+	// BundleEndpointProfileHTTPSSPIFFE authenticates the endpoint with its X509-SVID.
+	BundleEndpointProfileHTTPSSPIFFE BundleEndpointProfile = "https_spiffe"
+)
+
+// DefaultFederationRefreshInterval is how often federated bundles are refetched
+// when no refresh interval is configured.
+const DefaultFederationRefreshInterval = 5 * time.Minute
+
+// FederationConfig lists the trust domains this service federates with.
+type FederationConfig struct {
+	// RefreshInterval is how often bundle endpoints are polled.
+	// Default: 5 minutes.
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty" mapstructure:"refresh_interval"`
+
+	// TrustDomains are the federated trust domains and their bundle endpoints.
+	TrustDomains []FederatedTrustDomainConfig `yaml:"trust_domains" mapstructure:"trust_domains"`
+
+	// BundleEndpoint publishes the local trust bundle to federated trust domains.
+	// Optional: when nil, no bundle endpoint is served.
+	BundleEndpoint *BundleEndpointConfig `yaml:"bundle_endpoint,omitempty" mapstructure:"bundle_endpoint"`
+}
+
+// FederatedTrustDomainConfig describes the bundle endpoint of one federated trust domain.
+type FederatedTrustDomainConfig struct {
+	// TrustDomain is the federated trust domain name, e.g. "partner.example".
+	TrustDomain string `yaml:"trust_domain" mapstructure:"trust_domain"`
+
+	// BundleEndpointURL is the HTTPS URL serving the trust domain's SPIFFE bundle.
+	BundleEndpointURL string `yaml:"bundle_endpoint_url" mapstructure:"bundle_endpoint_url"`
+
+	// Profile is the endpoint authentication profile: "https_web" or "https_spiffe".
+	Profile BundleEndpointProfile `yaml:"profile" mapstructure:"profile"`
+
+	// EndpointSPIFFEID is the SPIFFE ID the endpoint must present.
+	// Required for the https_spiffe profile, ignored otherwise.
+	EndpointSPIFFEID string `yaml:"endpoint_spiffe_id,omitempty" mapstructure:"endpoint_spiffe_id"`
+
+	// BootstrapBundle is a PEM file with the trust domain's X.509 authorities. It
+	// authenticates an https_spiffe endpoint until the first bundle is fetched; later
+	// fetches are authenticated with the last fetched bundle. Required for the
+	// https_spiffe profile when the endpoint SPIFFE ID is in this trust domain.
+	BootstrapBundle string `yaml:"bootstrap_bundle,omitempty" mapstructure:"bootstrap_bundle"`
+
+	// AuthorizeMembers adds every member of this trust domain to the default authorizer.
+	// Default: false, so federated peers are only accepted by an explicit authorizer
+	// or policy.
+	AuthorizeMembers bool `yaml:"authorize_members,omitempty" mapstructure:"authorize_members"`
+}
+
+// BundleEndpointConfig configures the endpoint that serves the local trust bundle.
+type BundleEndpointConfig struct {
+	// Address is the address the endpoint listens on, e.g. ":8443".
+	Address string `yaml:"address" mapstructure:"address"`
+
+	// Profile is the endpoint authentication profile: "https_web" or "https_spiffe".
+	// Default: https_spiffe, which authenticates the endpoint with the service's X509-SVID.
+	Profile BundleEndpointProfile `yaml:"profile,omitempty" mapstructure:"profile"`
+
+	// RefreshHint is advertised to federated peers as spiffe_refresh_hint.
+	// Default: 5 minutes.
+	RefreshHint time.Duration `yaml:"refresh_hint,omitempty" mapstructure:"refresh_hint"`
+
+	// CertFile and KeyFile hold the Web PKI certificate for the https_web profile.
+	CertFile string `yaml:"cert_file,omitempty" mapstructure:"cert_file"`
+	KeyFile  string `yaml:"key_file,omitempty" mapstructure:"key_file"`
+}
+
+// GetRefreshInterval returns the configured refresh interval or the default.
+func (f *FederationConfig) GetRefreshInterval() time.Duration {
+	if f == nil || f.RefreshInterval <= 0 {
+		return DefaultFederationRefreshInterval
+	}
+	return f.RefreshInterval
+}
+
+// Validate checks the federation settings.
+func (f *FederationConfig) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	if f.RefreshInterval < 0 {
+		return &errors.ValidationError{
+			Field:   "federation.refresh_interval",
+			Value:   f.RefreshInterval,
+			Message: "refresh interval cannot be negative",
+		}
+	}
+
+	seen := make(map[string]bool, len(f.TrustDomains))
+	for i := range f.TrustDomains {
+		td := &f.TrustDomains[i]
+		if err := td.Validate(); err != nil {
+			return err
+		}
+		if seen[td.TrustDomain] {
+			return &errors.ValidationError{
+				Field:   "federation.trust_domains",
+				Value:   td.TrustDomain,
+				Message: "trust domain is listed more than once",
+			}
+		}
+		seen[td.TrustDomain] = true
+	}
+	return f.BundleEndpoint.Validate()
+}
+
+// Validate checks the bundle endpoint settings.
+func (b *BundleEndpointConfig) Validate() error {
+	if b == nil {
+		return nil
+	}
+
+	if b.Address == "" {
+		return &errors.ValidationError{
+			Field:   "federation.bundle_endpoint.address",
+			Value:   b.Address,
+			Message: "bundle endpoint address is required",
+		}
+	}
+
+	if b.RefreshHint < 0 {
+		return &errors.ValidationError{
+			Field:   "federation.bundle_endpoint.refresh_hint",
+			Value:   b.RefreshHint,
+			Message: "refresh hint cannot be negative",
+		}
+	}
+
+	switch b.Profile {
+	case "", BundleEndpointProfileHTTPSSPIFFE:
+	case BundleEndpointProfileHTTPSWeb:
+		if b.CertFile == "" || b.KeyFile == "" {
+			return &errors.ValidationError{
+				Field:   "federation.bundle_endpoint.cert_file",
+				Value:   b.CertFile,
+				Message: "https_web profile requires cert_file and key_file",
+			}
+		}
+	default:
+		return &errors.ValidationError{
+			Field:   "federation.bundle_endpoint.profile",
+			Value:   b.Profile,
+			Message: "profile must be https_web or https_spiffe",
+		}
+	}
+	return nil
+}
+
+// Validate checks a single federated trust domain entry.
+func (t *FederatedTrustDomainConfig) Validate() error {
+	if _, err := spiffeid.TrustDomainFromString(t.TrustDomain); err != nil {
+		return &errors.ValidationError{
+			Field:   "federation.trust_domains.trust_domain",
+			Value:   t.TrustDomain,
+			Message: fmt.Sprintf("invalid trust domain: %v", err),
+		}
+	}
+
+	endpoint, err := url.Parse(t.BundleEndpointURL)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return &errors.ValidationError{
+			Field:   "federation.trust_domains.bundle_endpoint_url",
+			Value:   t.BundleEndpointURL,
+			Message: "bundle endpoint URL must be an absolute https URL",
+		}
+	}
+
+	switch t.Profile {
+	case BundleEndpointProfileHTTPSWeb:
+	case BundleEndpointProfileHTTPSSPIFFE:
+		endpointID, err := spiffeid.FromString(t.EndpointSPIFFEID)
+		if err != nil {
+			return &errors.ValidationError{
+				Field:   "federation.trust_domains.endpoint_spiffe_id",
+				Value:   t.EndpointSPIFFEID,
+				Message: fmt.Sprintf("https_spiffe profile requires a valid endpoint SPIFFE ID: %v", err),
+			}
+		}
+		if endpointID.TrustDomain().String() == t.TrustDomain && t.BootstrapBundle == "" {
+			return &errors.ValidationError{
+				Field:   "federation.trust_domains.bootstrap_bundle",
+				Value:   t.BootstrapBundle,
+				Message: "https_spiffe endpoint in the federated trust domain requires a bootstrap bundle",
+			}
+		}
+	default:
+		return &errors.ValidationError{
+			Field:   "federation.trust_domains.profile",
+			Value:   t.Profile,
+			Message: "profile must be https_web or https_spiffe",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package ports
+
+import (
+	"crypto/fips140"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// FIPSStatus reports the state of Go's FIPS 140-3 cryptographic module.
+type FIPSStatus struct {
+	// Enabled reports whether the module is operating in FIPS 140-3 mode.
+	Enabled bool `json:"enabled"`
+	// Mode is the GODEBUG fips140 setting: "off", "on" or "only". Empty when unset.
+	Mode string `json:"mode,omitempty"`
+	// Module is the GOFIPS140 module version the binary was built with, e.g.
+	// "v1.0.0" or "latest". Empty when the build setting is unavailable.
+	Module string `json:"module,omitempty"`
+}
+
+// String returns a one-line summary such as "enabled (mode=on, module=v1.0.0)".
+func (s FIPSStatus) String() string {
+	state := "disabled"
+	if s.Enabled {
+		state = "enabled"
+	}
+	var details []string
+	if s.Mode != "" {
+		details = append(details, "mode="+s.Mode)
+	}
+	if s.Module != "" {
+		details = append(details, "module="+s.Module)
+	}
+	if len(details) == 0 {
+		return state
+	}
+	return state + " (" + strings.Join(details, ", ") + ")"
+}
+
+// CurrentFIPSStatus returns the FIPS 140-3 status of the running binary.
+func CurrentFIPSStatus() FIPSStatus {
+	status := FIPSStatus{Enabled: fips140.Enabled()}
+
+	for _, setting := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(setting), "fips140="); ok {
+			status.Mode = value
+		}
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "GOFIPS140":
+				status.Module = setting.Value
+			case "DefaultGODEBUG":
+				if status.Mode != "" {
+					continue
+				}
+				for _, value := range strings.Split(setting.Value, ",") {
+					if mode, ok := strings.CutPrefix(value, "fips140="); ok {
+						status.Mode = mode
+					}
+				}
+			}
+		}
+	}
+	return status
+}
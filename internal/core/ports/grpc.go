@@ -0,0 +1,177 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// Defaults for gRPC server keepalive and connection age.
+const (
+	DefaultGRPCMaxConnectionIdle     = 15 * time.Minute
+	DefaultGRPCMaxConnectionAge      = 30 * time.Minute
+	DefaultGRPCMaxConnectionAgeGrace = 5 * time.Second
+	DefaultGRPCKeepaliveTime         = 5 * time.Second
+	DefaultGRPCKeepaliveTimeout      = 1 * time.Second
+	DefaultGRPCKeepaliveMinTime      = 5 * time.Second
+	DefaultGRPCExpiryMargin          = 1 * time.Minute
+	DefaultGRPCExpiryJitter          = 0.1
+)
+
+// TransportConfig groups the transport-specific settings.
+type TransportConfig struct {
+	// GRPC holds the keepalive and connection age settings of gRPC servers.
+	// If nil, the defaults are used.
+	GRPC *GRPCConfig `yaml:"grpc,omitempty" mapstructure:"grpc"`
+}
+
+// GRPCConfig holds the keepalive and connection age settings of gRPC servers.
+// Zero durations select the defaults.
+type GRPCConfig struct {
+	// MaxConnectionIdle closes connections without RPCs for this long. Default: 15m.
+	MaxConnectionIdle time.Duration `yaml:"max_connection_idle,omitempty" mapstructure:"max_connection_idle"`
+
+	// MaxConnectionAge closes connections this old, whatever the certificate
+	// lifetimes. gRPC adds ±10% jitter. Default: 30m.
+	MaxConnectionAge time.Duration `yaml:"max_connection_age,omitempty" mapstructure:"max_connection_age"`
+
+	// MaxConnectionAgeGrace is how long in-flight RPCs may run once a connection
+	// has reached its maximum age or certificate deadline. Default: 5s.
+	MaxConnectionAgeGrace time.Duration `yaml:"max_connection_age_grace,omitempty" mapstructure:"max_connection_age_grace"`
+
+	// KeepaliveTime is how long the server waits on an idle connection before
+	// pinging the client. Default: 5s.
+	KeepaliveTime time.Duration `yaml:"keepalive_time,omitempty" mapstructure:"keepalive_time"`
+
+	// KeepaliveTimeout is how long the server waits for a ping ack before closing
+	// the connection. Default: 1s.
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout,omitempty" mapstructure:"keepalive_timeout"`
+
+	// KeepaliveMinTime is the shortest ping interval clients may use; clients
+	// pinging more often are disconnected. Default: 5s.
+	KeepaliveMinTime time.Duration `yaml:"keepalive_min_time,omitempty" mapstructure:"keepalive_min_time"`
+
+	// PermitWithoutStream allows client pings on connections without RPCs.
+	// Default: true.
+	PermitWithoutStream *bool `yaml:"permit_without_stream,omitempty" mapstructure:"permit_without_stream"`
+
+	// ExpiryMargin is how long before the earlier of the client and server
+	// certificate expiry a connection is closed. Default: 1m.
+	ExpiryMargin time.Duration `yaml:"expiry_margin,omitempty" mapstructure:"expiry_margin"`
+
+	// ExpiryJitter spreads certificate deadlines over this fraction of the remaining
+	// connection lifetime, so that connections authenticated with the same SVID do
+	// not all close at once. Must be between 0 and 1. Default: 0.1.
+	ExpiryJitter *float64 `yaml:"expiry_jitter,omitempty" mapstructure:"expiry_jitter"`
+}
+
+// Validate checks that no duration is negative and that the jitter is a fraction.
+// A nil config is valid.
+func (g *GRPCConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+
+	durations := []struct {
+		field string
+		value time.Duration
+	}{
+		{"max_connection_idle", g.MaxConnectionIdle},
+		{"max_connection_age", g.MaxConnectionAge},
+		{"max_connection_age_grace", g.MaxConnectionAgeGrace},
+		{"keepalive_time", g.KeepaliveTime},
+		{"keepalive_timeout", g.KeepaliveTimeout},
+		{"keepalive_min_time", g.KeepaliveMinTime},
+		{"expiry_margin", g.ExpiryMargin},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return &errors.ValidationError{
+				Field:   "transport.grpc." + d.field,
+				Value:   d.value,
+				Message: "duration cannot be negative",
+			}
+		}
+	}
+
+	if g.ExpiryJitter != nil && (*g.ExpiryJitter < 0 || *g.ExpiryJitter > 1) {
+		return &errors.ValidationError{
+			Field:   "transport.grpc.expiry_jitter",
+			Value:   *g.ExpiryJitter,
+			Message: "jitter must be between 0 and 1",
+		}
+	}
+	return nil
+}
+
+// GetMaxConnectionIdle returns the idle timeout or the default.
+func (g *GRPCConfig) GetMaxConnectionIdle() time.Duration {
+	if g == nil || g.MaxConnectionIdle <= 0 {
+		return DefaultGRPCMaxConnectionIdle
+	}
+	return g.MaxConnectionIdle
+}
+
+// GetMaxConnectionAge returns the maximum connection age or the default.
+func (g *GRPCConfig) GetMaxConnectionAge() time.Duration {
+	if g == nil || g.MaxConnectionAge <= 0 {
+		return DefaultGRPCMaxConnectionAge
+	}
+	return g.MaxConnectionAge
+}
+
+// GetMaxConnectionAgeGrace returns the grace period or the default.
+func (g *GRPCConfig) GetMaxConnectionAgeGrace() time.Duration {
+	if g == nil || g.MaxConnectionAgeGrace <= 0 {
+		return DefaultGRPCMaxConnectionAgeGrace
+	}
+	return g.MaxConnectionAgeGrace
+}
+
+// GetKeepaliveTime returns the ping interval or the default.
+func (g *GRPCConfig) GetKeepaliveTime() time.Duration {
+	if g == nil || g.KeepaliveTime <= 0 {
+		return DefaultGRPCKeepaliveTime
+	}
+	return g.KeepaliveTime
+}
+
+// GetKeepaliveTimeout returns the ping ack timeout or the default.
+func (g *GRPCConfig) GetKeepaliveTimeout() time.Duration {
+	if g == nil || g.KeepaliveTimeout <= 0 {
+		return DefaultGRPCKeepaliveTimeout
+	}
+	return g.KeepaliveTimeout
+}
+
+// GetKeepaliveMinTime returns the minimum client ping interval or the default.
+func (g *GRPCConfig) GetKeepaliveMinTime() time.Duration {
+	if g == nil || g.KeepaliveMinTime <= 0 {
+		return DefaultGRPCKeepaliveMinTime
+	}
+	return g.KeepaliveMinTime
+}
+
+// GetPermitWithoutStream returns whether pings without RPCs are allowed; default true.
+func (g *GRPCConfig) GetPermitWithoutStream() bool {
+	if g == nil || g.PermitWithoutStream == nil {
+		return true
+	}
+	return *g.PermitWithoutStream
+}
+
+// GetExpiryMargin returns the certificate expiry margin or the default.
+func (g *GRPCConfig) GetExpiryMargin() time.Duration {
+	if g == nil || g.ExpiryMargin <= 0 {
+		return DefaultGRPCExpiryMargin
+	}
+	return g.ExpiryMargin
+}
+
+// GetExpiryJitter returns the certificate deadline jitter or the default.
+func (g *GRPCConfig) GetExpiryJitter() float64 {
+	if g == nil || g.ExpiryJitter == nil {
+		return DefaultGRPCExpiryJitter
+	}
+	return *g.ExpiryJitter
+}
@@ -6,6 +6,15 @@ package ports
 import (
 	"context"
 	"time"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// Defaults for the built-in health checks.
+const (
+	DefaultSVIDHealthWarning  = 15 * time.Minute
+	DefaultSVIDHealthCritical = 5 * time.Minute
+	DefaultBundleHealthMaxAge = 48 * time.Hour
 )
 
 // HealthStatus represents the health status of a component
@@ -48,6 +57,111 @@ type HealthConfig struct {
 	Server *SpireServerHealthConfig `json:"server,omitempty"`
 	// Agent configuration for SPIRE agent health checks
 	Agent *SpireAgentHealthConfig `json:"agent,omitempty"`
+	// SVID checks the remaining lifetime of this workload's X.509 SVID
+	SVID *SVIDHealthConfig `json:"svid,omitempty" mapstructure:"svid"`
+	// Bundle checks how long ago the trust bundle was last updated
+	Bundle *BundleHealthConfig `json:"bundle,omitempty" mapstructure:"bundle"`
+	// WorkloadAPI checks that the agent socket answers Workload API calls
+	WorkloadAPI *WorkloadAPIHealthConfig `json:"workload_api,omitempty" mapstructure:"workload_api"`
+}
+
+// Validate checks that no duration is negative and that the SVID thresholds are
+// ordered. A nil config is valid.
+func (c *HealthConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	type duration struct {
+		field string
+		value time.Duration
+	}
+	durations := []duration{
+		{"timeout", c.Timeout},
+		{"interval", c.Interval},
+	}
+	if c.SVID != nil {
+		durations = append(durations,
+			duration{"svid.warning", c.SVID.Warning},
+			duration{"svid.critical", c.SVID.Critical})
+	}
+	if c.Bundle != nil {
+		durations = append(durations, duration{"bundle.max_age", c.Bundle.MaxAge})
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return &errors.ValidationError{
+				Field:   "health." + d.field,
+				Value:   d.value,
+				Message: "duration cannot be negative",
+			}
+		}
+	}
+
+	if c.SVID != nil && c.SVID.GetCritical() > c.SVID.GetWarning() {
+		return &errors.ValidationError{
+			Field:   "health.svid.critical",
+			Value:   c.SVID.Critical,
+			Message: "critical threshold cannot exceed the warning threshold",
+		}
+	}
+	return nil
+}
+
+// SVIDHealthConfig configures the check of the remaining lifetime of this
+// workload's X.509 SVID.
+type SVIDHealthConfig struct {
+	// Enabled registers the check with the health monitor
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Warning is the remaining lifetime below which the check reports a warning
+	// while the SVID is still considered healthy. Default: 15m.
+	Warning time.Duration `json:"warning,omitempty" mapstructure:"warning"`
+	// Critical is the remaining lifetime below which the SVID is unhealthy,
+	// because rotation has stalled. Default: 5m.
+	Critical time.Duration `json:"critical,omitempty" mapstructure:"critical"`
+}
+
+// GetWarning returns the warning threshold or the default.
+func (c *SVIDHealthConfig) GetWarning() time.Duration {
+	if c == nil || c.Warning <= 0 {
+		return DefaultSVIDHealthWarning
+	}
+	return c.Warning
+}
+
+// GetCritical returns the critical threshold or the default.
+func (c *SVIDHealthConfig) GetCritical() time.Duration {
+	if c == nil || c.Critical <= 0 {
+		return DefaultSVIDHealthCritical
+	}
+	return c.Critical
+}
+
+// BundleHealthConfig configures the check of how long ago the trust bundle of
+// the local trust domain was last updated.
+type BundleHealthConfig struct {
+	// Enabled registers the check with the health monitor
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// MaxAge is how long the bundle may go without an update before it is
+	// unhealthy. It should exceed the CA TTL of the SPIRE server. Default: 48h.
+	MaxAge time.Duration `json:"max_age,omitempty" mapstructure:"max_age"`
+}
+
+// GetMaxAge returns the maximum bundle age or the default.
+func (c *BundleHealthConfig) GetMaxAge() time.Duration {
+	if c == nil || c.MaxAge <= 0 {
+		return DefaultBundleHealthMaxAge
+	}
+	return c.MaxAge
+}
+
+// WorkloadAPIHealthConfig configures the check that the agent socket accepts
+// Workload API calls.
+type WorkloadAPIHealthConfig struct {
+	// Enabled registers the check with the health monitor
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// SocketPath is the Workload API socket to call. Default: agent.socket_path.
+	SocketPath string `json:"socket_path,omitempty" mapstructure:"socket_path"`
 }
 
 // SpireServerHealthConfig configures SPIRE server health monitoring
@@ -55,11 +169,11 @@ type SpireServerHealthConfig struct {
 	// Address of the SPIRE server health endpoint (e.g., "localhost:8080")
 	Address string `json:"address" validate:"required"`
 	// LivePath is the liveness check endpoint path (default: "/live")
-	LivePath string `json:"live_path" validate:"omitempty,startswith=/"`
+	LivePath string `json:"live_path" mapstructure:"live_path" validate:"omitempty,startswith=/"`
 	// ReadyPath is the readiness check endpoint path (default: "/ready")
-	ReadyPath string `json:"ready_path" validate:"omitempty,startswith=/"`
+	ReadyPath string `json:"ready_path" mapstructure:"ready_path" validate:"omitempty,startswith=/"`
 	// UseHTTPS enables HTTPS for health check requests
-	UseHTTPS bool `json:"use_https"`
+	UseHTTPS bool `json:"use_https" mapstructure:"use_https"`
 	// Headers are additional HTTP headers to include in health check requests
 	Headers map[string]string `json:"headers,omitempty"`
 }
@@ -69,11 +183,11 @@ type SpireAgentHealthConfig struct {
 	// Address of the SPIRE agent health endpoint (e.g., "localhost:8080")
 	Address string `json:"address" validate:"required"`
 	// LivePath is the liveness check endpoint path (default: "/live")
-	LivePath string `json:"live_path" validate:"omitempty,startswith=/"`
+	LivePath string `json:"live_path" mapstructure:"live_path" validate:"omitempty,startswith=/"`
 	// ReadyPath is the readiness check endpoint path (default: "/ready")
-	ReadyPath string `json:"ready_path" validate:"omitempty,startswith=/"`
+	ReadyPath string `json:"ready_path" mapstructure:"ready_path" validate:"omitempty,startswith=/"`
 	// UseHTTPS enables HTTPS for health check requests
-	UseHTTPS bool `json:"use_https"`
+	UseHTTPS bool `json:"use_https" mapstructure:"use_https"`
 	// Headers are additional HTTP headers to include in health check requests
 	Headers map[string]string `json:"headers,omitempty"`
 }
@@ -126,3 +240,13 @@ type SpireHealthClientPort interface {
 	// UpdateConfig updates the health check configuration
 	UpdateConfig(config *HealthConfig) error
 }
+
+// HealthComponentProvider creates the health checkers and reporters selected by a
+// health configuration, so that the application layer can assemble monitoring
+// without knowing the adapters that implement it.
+type HealthComponentProvider interface {
+	// CreateCheckers creates the checkers enabled in the configuration
+	CreateCheckers(config *HealthConfig) ([]HealthCheckerPort, error)
+	// CreateReporters creates the reporters health results are sent to
+	CreateReporters(config *HealthConfig) ([]HealthReporterPort, error)
+}
@@ -0,0 +1,143 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// Identity sources selected by IdentitySourceConfig.Source.
+const (
+	IdentitySourceWorkloadAPI = "workload_api"
+	IdentitySourceFiles       = "files"
+	IdentitySourceDevCA       = "dev_ca"
+)
+
+// IdentitySourceConfig selects where the service's X.509 SVID and trust bundle
+// come from.
+type IdentitySourceConfig struct {
+	// Source is "workload_api" to fetch them from the SPIFFE Workload API at the
+	// agent socket, "files" to read them from PEM files written by a sidecar such
+	// as spiffe-helper, cert-manager's csi-driver-spiffe or a Vault agent, or
+	// "dev_ca" to issue them from an in-process development CA.
+	// Default: "workload_api".
+	Source string `yaml:"source,omitempty" mapstructure:"source"`
+
+	// Files locates the PEM files of the files source.
+	Files *IdentityFilesConfig `yaml:"files,omitempty" mapstructure:"files"`
+
+	// DevCA configures the dev_ca source. Optional.
+	DevCA *IdentityDevCAConfig `yaml:"dev_ca,omitempty" mapstructure:"dev_ca"`
+}
+
+// IdentityFilesConfig locates the PEM files of an SVID and its trust bundle.
+// The files are watched, and rewritten files are picked up without a restart.
+type IdentityFilesConfig struct {
+	// CertFile holds the SVID certificate followed by its intermediates.
+	CertFile string `yaml:"cert_file" mapstructure:"cert_file"`
+
+	// KeyFile holds the SVID private key in PKCS#8, PKCS#1 or SEC 1 form.
+	KeyFile string `yaml:"key_file" mapstructure:"key_file"`
+
+	// BundleFile holds the CA certificates of the trust domain.
+	BundleFile string `yaml:"bundle_file" mapstructure:"bundle_file"`
+}
+
+// IdentityDevCAConfig configures the in-process development CA. It issues real,
+// short-lived SVIDs without SPIRE, for tests and local development only;
+// IsProductionReady rejects it.
+type IdentityDevCAConfig struct {
+	// SPIFFEID is the ID of the issued SVIDs.
+	// Default: spiffe://<service.domain>/<service.name>.
+	SPIFFEID string `yaml:"spiffe_id,omitempty" mapstructure:"spiffe_id"`
+
+	// TTL is the lifetime of issued SVIDs. A new SVID is issued when half of it has
+	// passed. Default: 10m.
+	TTL time.Duration `yaml:"ttl,omitempty" mapstructure:"ttl"`
+
+	// Intermediate signs SVIDs with an intermediate CA below the root, so that
+	// chain handling is exercised as well.
+	Intermediate bool `yaml:"intermediate,omitempty" mapstructure:"intermediate"`
+}
+
+// UsesWorkloadAPI reports whether the SVID and bundle come from the Workload API.
+func (c *IdentitySourceConfig) UsesWorkloadAPI() bool {
+	return c == nil || c.Source == "" || c.Source == IdentitySourceWorkloadAPI
+}
+
+// UsesFiles reports whether the SVID and bundle are read from files.
+func (c *IdentitySourceConfig) UsesFiles() bool {
+	return c != nil && c.Source == IdentitySourceFiles
+}
+
+// UsesDevCA reports whether the SVID is issued by the development CA.
+func (c *IdentitySourceConfig) UsesDevCA() bool {
+	return c != nil && c.Source == IdentitySourceDevCA
+}
+
+// Validate checks the source and the settings it requires.
+func (c *IdentitySourceConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	switch c.Source {
+	case "", IdentitySourceWorkloadAPI:
+		return nil
+	case IdentitySourceFiles:
+	case IdentitySourceDevCA:
+		return c.DevCA.Validate()
+	default:
+		return &errors.ValidationError{
+			Field:   "identity.source",
+			Value:   c.Source,
+			Message: "must be workload_api, files or dev_ca",
+		}
+	}
+
+	if c.Files == nil {
+		return &errors.ValidationError{
+			Field:   "identity.files",
+			Message: "is required for the files source",
+		}
+	}
+	for _, file := range []struct{ field, path string }{
+		{"identity.files.cert_file", c.Files.CertFile},
+		{"identity.files.key_file", c.Files.KeyFile},
+		{"identity.files.bundle_file", c.Files.BundleFile},
+	} {
+		if file.path == "" {
+			return &errors.ValidationError{
+				Field:   file.field,
+				Message: "is required for the files source",
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the SPIFFE ID and TTL. A nil config is valid.
+func (c *IdentityDevCAConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.SPIFFEID != "" {
+		if _, err := spiffeid.FromString(c.SPIFFEID); err != nil {
+			return &errors.ValidationError{
+				Field:   "identity.dev_ca.spiffe_id",
+				Value:   c.SPIFFEID,
+				Message: err.Error(),
+			}
+		}
+	}
+	if c.TTL < 0 {
+		return &errors.ValidationError{
+			Field:   "identity.dev_ca.ttl",
+			Value:   c.TTL,
+			Message: "duration cannot be negative",
+		}
+	}
+	return nil
+}
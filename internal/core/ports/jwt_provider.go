@@ -0,0 +1,44 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+)
+
+// JWTSVIDProviderPort defines the contract for JWT-SVID provisioning operations.
+// JWT-SVIDs carry workload identity as bearer tokens and are used where mTLS
+// identity is not available end to end, e.g. behind TLS-terminating L7 proxies.
+//
+// Implementations must be thread-safe as they may be called concurrently.
+type JWTSVIDProviderPort interface {
+	// FetchJWTSVID fetches a JWT-SVID for the given audience.
+	// Additional audiences are added to the 'aud' claim of the token.
+	//
+	// Returns:
+	//   - A jwtsvid.SVID holding the signed token and its parsed claims
+	//   - An error if the token cannot be fetched
+	FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error)
+
+	// Close releases any resources held by the provider.
+	Close() error
+}
+
+// JWTBundleProviderPort defines the contract for JWT bundle (JWKS) retrieval.
+// JWT bundles hold the public keys used to verify JWT-SVID signatures, one
+// bundle per trust domain.
+//
+// Implementations must be thread-safe as they may be called concurrently.
+type JWTBundleProviderPort interface {
+	// GetJWTBundleForTrustDomain retrieves the JWT bundle for a trust domain.
+	//
+	// Returns:
+	//   - A jwtbundle.Bundle containing the JWT authorities of the trust domain
+	//   - An error if no bundle is available for the trust domain
+	GetJWTBundleForTrustDomain(ctx context.Context, trustDomain spiffeid.TrustDomain) (*jwtbundle.Bundle, error)
+
+	// Close releases any resources held by the provider.
+	Close() error
+}
@@ -0,0 +1,64 @@
+package ports
+
+import (
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// KeyPolicyConfig restricts the key algorithms accepted in this service's SVID, in
+// peer SVIDs and in their certificate chains.
+type KeyPolicyConfig struct {
+	// Algorithms lists the accepted key algorithms: "ECDSA", "RSA" and "Ed25519".
+	// Default: all three.
+	Algorithms []string `yaml:"algorithms,omitempty" mapstructure:"algorithms"`
+
+	// MinRSAKeyBits is the smallest accepted RSA modulus.
+	// Default: 2048.
+	MinRSAKeyBits int `yaml:"min_rsa_key_bits,omitempty" mapstructure:"min_rsa_key_bits"`
+
+	// Curves lists the accepted ECDSA curves: "P-224", "P-256", "P-384" or "P-521".
+	// Default: P-256, P-384 and P-521.
+	Curves []string `yaml:"curves,omitempty" mapstructure:"curves"`
+}
+
+// Policy returns the domain key policy. A nil config returns the default policy.
+func (c *KeyPolicyConfig) Policy() domain.KeyPolicy {
+	if c == nil {
+		return domain.KeyPolicy{}
+	}
+	return domain.KeyPolicy{
+		Algorithms:    c.Algorithms,
+		MinRSAKeyBits: c.MinRSAKeyBits,
+		Curves:        c.Curves,
+	}
+}
+
+// Validate checks that every algorithm and curve name is known.
+func (c *KeyPolicyConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if err := c.Policy().Validate(); err != nil {
+		return &errors.ValidationError{
+			Field:   "keys",
+			Value:   c,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// validateFIPS rejects RSA minimums and curves looser than FIPS 140-3 allows.
+func (c *KeyPolicyConfig) validateFIPS() error {
+	if c == nil {
+		return nil
+	}
+	if err := c.Policy().ValidateFIPS(); err != nil {
+		return &errors.ValidationError{
+			Field:   "keys",
+			Value:   c,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+)
+
+// Handshake sides reported to MTLSMetricsPort.
+const (
+	HandshakeSideClient = "client"
+	HandshakeSideServer = "server"
+)
+
+// Handshake failure reasons reported to MTLSMetricsPort.
+const (
+	HandshakeFailureTimeout          = "timeout"
+	HandshakeFailureUnknownAuthority = "unknown_authority"
+	HandshakeFailureExpired          = "expired_certificate"
+	HandshakeFailureBadCertificate   = "bad_certificate"
+	HandshakeFailureRevoked          = "revoked"
+	HandshakeFailureUnauthorized     = "unauthorized"
+	HandshakeFailurePeerRejected     = "peer_rejected"
+	HandshakeFailureProtocol         = "protocol"
+	HandshakeFailureOther            = "other"
+)
+
+// Rotation outcomes reported to MTLSMetricsPort.
+const (
+	RotationOutcomeSuccess = "success"
+	RotationOutcomeFailure = "failure"
+)
+
+// MTLSMetricsPort records the health of mTLS: handshakes, live connections, SVID
+// rotations, authorization decisions and how long the SVIDs on both sides remain
+// valid. Implementations must be safe for concurrent use.
+type MTLSMetricsPort interface {
+	// RecordHandshake records a completed or failed handshake on the given side.
+	// reason is empty for a successful handshake and one of the HandshakeFailure
+	// reasons otherwise.
+	RecordHandshake(side, reason string, duration time.Duration)
+	// RecordConnectionOpened counts a connection established with a peer of the
+	// given trust domain as active.
+	RecordConnectionOpened(trustDomain string)
+	// RecordConnectionClosed counts a connection opened by RecordConnectionOpened
+	// as no longer active.
+	RecordConnectionClosed(trustDomain string)
+	// RecordPeerSVIDLifetime records the remaining lifetime of a peer SVID presented
+	// in a handshake.
+	RecordPeerSVIDLifetime(remaining time.Duration)
+	// RecordSVIDExpiry records when the local SVID with the given ID expires.
+	RecordSVIDExpiry(id spiffeid.ID, expiry time.Time)
+	// RecordRotation counts an SVID rotation with the given outcome.
+	RecordRotation(outcome string)
+	// RecordAuthorization counts a policy decision made by the named rule.
+	RecordAuthorization(rule string, allowed bool)
+}
@@ -0,0 +1,262 @@
+package ports
+
+import (
+	"crypto/x509"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// PolicyAction is the effect of an authorization rule.
+type PolicyAction string
+
+const (
+	// PolicyActionAllow permits matching requests.
+	PolicyActionAllow PolicyAction = "allow"
+	// PolicyActionDeny rejects matching requests.
+	PolicyActionDeny PolicyAction = "deny"
+)
+
+// PolicyConfig is an ordered list of authorization rules. The first rule that
+// matches a request decides it; requests matching no rule get the default action.
+type PolicyConfig struct {
+	// Default is the action for requests that match no rule. Default: deny.
+	Default PolicyAction `yaml:"default,omitempty" mapstructure:"default"`
+
+	// Rules are evaluated in order.
+	Rules []PolicyRuleConfig `yaml:"rules" mapstructure:"rules"`
+}
+
+// PolicyRuleConfig matches peers and operations.
+//
+// A rule without peers matches every authenticated peer. A rule without operation
+// constraints matches every request. A rule with only HTTP constraints never matches
+// gRPC calls, and a rule with only gRPC constraints never matches HTTP requests.
+// Path, method and SPIFFE ID globs use path.Match syntax, so '*' does not cross '/'.
+type PolicyRuleConfig struct {
+	// Name identifies the rule in decisions and logs. Default: "rule[<index>]".
+	Name string `yaml:"name,omitempty" mapstructure:"name"`
+
+	// Action is "allow" or "deny".
+	Action PolicyAction `yaml:"action" mapstructure:"action"`
+
+	// Peers match the caller's SPIFFE ID. Any matching entry is sufficient.
+	Peers []PeerMatcherConfig `yaml:"peers,omitempty" mapstructure:"peers"`
+
+	// Methods are HTTP methods, e.g. "GET". Empty matches any method.
+	Methods []string `yaml:"methods,omitempty" mapstructure:"methods"`
+
+	// Paths are HTTP path globs, e.g. "/api/v1/*". Empty matches any path.
+	Paths []string `yaml:"paths,omitempty" mapstructure:"paths"`
+
+	// GRPCMethods are gRPC full method name globs, e.g. "/billing.v1.Billing/*".
+	GRPCMethods []string `yaml:"grpc_methods,omitempty" mapstructure:"grpc_methods"`
+}
+
+// PeerMatcherConfig matches a SPIFFE ID. Exactly one field must be set.
+type PeerMatcherConfig struct {
+	// ID matches one SPIFFE ID exactly.
+	ID string `yaml:"id,omitempty" mapstructure:"id"`
+	// Prefix matches the SPIFFE ID and the IDs below it at a path segment boundary,
+	// e.g. "spiffe://prod.example/ns/billing" matches ".../ns/billing/sa/api" but not
+	// ".../ns/billing-admin". A trailing '/' is ignored.
+	Prefix string `yaml:"prefix,omitempty" mapstructure:"prefix"`
+	// Glob matches SPIFFE IDs against a pattern, e.g. "spiffe://prod.example/ns/*/sa/reporter".
+	Glob string `yaml:"glob,omitempty" mapstructure:"glob"`
+}
+
+// AccessRequest is an operation to authorize. Either the HTTP fields or
+// GRPCMethod are set.
+type AccessRequest struct {
+	// PeerID is the caller's SPIFFE ID.
+	PeerID string
+	// Method and Path describe an HTTP request.
+	Method string
+	Path   string
+	// GRPCMethod is the gRPC full method name, e.g. "/billing.v1.Billing/Charge".
+	GRPCMethod string
+}
+
+// PeerIdentity is the authenticated SPIFFE identity of the other side of an mTLS
+// connection, for HTTP requests and gRPC calls alike.
+type PeerIdentity struct {
+	// ID is the peer SPIFFE ID, e.g. "spiffe://prod.company.com/payment-service".
+	ID string
+	// TrustDomain is the trust domain of ID.
+	TrustDomain string
+	// Certificates is the peer certificate chain, leaf first.
+	Certificates []*x509.Certificate
+	// RemoteAddr is the peer network address.
+	RemoteAddr string
+}
+
+// PolicyDecision is the outcome of evaluating a policy.
+type PolicyDecision struct {
+	// Allowed reports whether the request is permitted.
+	Allowed bool
+	// Rule is the name of the matching rule, or "default" when no rule matched.
+	Rule string
+	// Reason explains the decision.
+	Reason string
+}
+
+// PolicyEvaluatorPort decides whether a peer may perform an operation.
+type PolicyEvaluatorPort interface {
+	Evaluate(request AccessRequest) PolicyDecision
+}
+
+// GetDefault returns the default action, deny unless configured otherwise.
+func (p *PolicyConfig) GetDefault() PolicyAction {
+	if p == nil || p.Default == "" {
+		return PolicyActionDeny
+	}
+	return p.Default
+}
+
+// RuleName returns the rule name or a positional name for unnamed rules.
+func (r *PolicyRuleConfig) RuleName(index int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return fmt.Sprintf("rule[%d]", index)
+}
+
+// Validate checks the policy settings.
+func (p *PolicyConfig) Validate() error {
+	if p == nil {
+		return nil
+	}
+
+	if err := validatePolicyAction("policy.default", p.GetDefault()); err != nil {
+		return err
+	}
+
+	for i := range p.Rules {
+		if err := p.Rules[i].validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PolicyRuleConfig) validate(index int) error {
+	prefix := fmt.Sprintf("policy.rules[%d]", index)
+
+	if err := validatePolicyAction(prefix+".action", r.Action); err != nil {
+		return err
+	}
+
+	for _, peer := range r.Peers {
+		if err := peer.validate(prefix + ".peers"); err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, r.Paths...), r.GRPCMethods...) {
+		if !strings.HasPrefix(pattern, "/") {
+			return &errors.ValidationError{
+				Field:   prefix,
+				Value:   pattern,
+				Message: "paths and gRPC methods must start with '/'",
+			}
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &errors.ValidationError{
+				Field:   prefix,
+				Value:   pattern,
+				Message: fmt.Sprintf("invalid pattern: %v", err),
+			}
+		}
+	}
+
+	for _, method := range r.Methods {
+		if method == "" || strings.ToUpper(method) != method {
+			return &errors.ValidationError{
+				Field:   prefix + ".methods",
+				Value:   method,
+				Message: "HTTP methods must be upper case, e.g. GET",
+			}
+		}
+	}
+	return nil
+}
+
+func (m *PeerMatcherConfig) validate(field string) error {
+	set := 0
+	for _, value := range []string{m.ID, m.Prefix, m.Glob} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return &errors.ValidationError{
+			Field:   field,
+			Value:   *m,
+			Message: "exactly one of id, prefix or glob must be set",
+		}
+	}
+
+	switch {
+	case m.ID != "":
+		if _, err := spiffeid.FromString(m.ID); err != nil {
+			return &errors.ValidationError{Field: field + ".id", Value: m.ID, Message: fmt.Sprintf("invalid SPIFFE ID: %v", err)}
+		}
+	case m.Prefix != "":
+		if !strings.HasPrefix(m.Prefix, "spiffe://") {
+			return &errors.ValidationError{Field: field + ".prefix", Value: m.Prefix, Message: "prefix must start with spiffe://"}
+		}
+	case m.Glob != "":
+		if !strings.HasPrefix(m.Glob, "spiffe://") {
+			return &errors.ValidationError{Field: field + ".glob", Value: m.Glob, Message: "glob must start with spiffe://"}
+		}
+		if _, err := path.Match(m.Glob, ""); err != nil {
+			return &errors.ValidationError{Field: field + ".glob", Value: m.Glob, Message: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the SPIFFE ID satisfies the matcher.
+func (m *PeerMatcherConfig) Matches(id string) bool {
+	switch {
+	case m.ID != "":
+		return id == m.ID
+	case m.Prefix != "":
+		prefix := strings.TrimSuffix(m.Prefix, "/")
+		return id == prefix || strings.HasPrefix(id, prefix+"/")
+	case m.Glob != "":
+		ok, _ := path.Match(m.Glob, id)
+		return ok
+	default:
+		return false
+	}
+}
+
+// String describes the matcher, e.g. `prefix("spiffe://prod.example/")`.
+func (m *PeerMatcherConfig) String() string {
+	switch {
+	case m.ID != "":
+		return fmt.Sprintf("id(%q)", m.ID)
+	case m.Prefix != "":
+		return fmt.Sprintf("prefix(%q)", m.Prefix)
+	case m.Glob != "":
+		return fmt.Sprintf("glob(%q)", m.Glob)
+	default:
+		return "none()"
+	}
+}
+
+func validatePolicyAction(field string, action PolicyAction) error {
+	if action != PolicyActionAllow && action != PolicyActionDeny {
+		return &errors.ValidationError{
+			Field:   field,
+			Value:   action,
+			Message: "action must be allow or deny",
+		}
+	}
+	return nil
+}
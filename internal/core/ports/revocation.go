@@ -0,0 +1,109 @@
+package ports
+
+import (
+	"crypto/x509"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// RevocationEntries lists revoked workloads. It is the format of the revocation
+// file as well as part of the revocation configuration section.
+type RevocationEntries struct {
+	// SPIFFEIDs are revoked SPIFFE IDs, e.g. "spiffe://example.org/payments".
+	SPIFFEIDs []string `yaml:"spiffe_ids,omitempty" json:"spiffe_ids,omitempty" mapstructure:"spiffe_ids"`
+
+	// IDPrefixes revoke every SPIFFE ID at or below them in the path hierarchy.
+	// A bare trust domain, e.g. "spiffe://example.org", revokes all of its workloads.
+	IDPrefixes []string `yaml:"id_prefixes,omitempty" json:"id_prefixes,omitempty" mapstructure:"id_prefixes"`
+
+	// Serials are revoked certificate serial numbers in hex, optionally colon
+	// separated, qualified by the issuing trust domain, e.g. "example.org/3c:81:0f:9a".
+	Serials []string `yaml:"serials,omitempty" json:"serials,omitempty" mapstructure:"serials"`
+
+	// SPKIHashes are SHA-256 hashes of revoked public keys (SubjectPublicKeyInfo),
+	// in hex or base64.
+	SPKIHashes []string `yaml:"spki_hashes,omitempty" json:"spki_hashes,omitempty" mapstructure:"spki_hashes"`
+}
+
+// List builds the domain revocation list.
+func (e RevocationEntries) List() (*domain.RevocationList, error) {
+	return domain.NewRevocationList(e.SPIFFEIDs, e.IDPrefixes, e.Serials, e.SPKIHashes)
+}
+
+// Merge returns the entries of both e and other.
+func (e RevocationEntries) Merge(other RevocationEntries) RevocationEntries {
+	return RevocationEntries{
+		SPIFFEIDs:  append(append([]string(nil), e.SPIFFEIDs...), other.SPIFFEIDs...),
+		IDPrefixes: append(append([]string(nil), e.IDPrefixes...), other.IDPrefixes...),
+		Serials:    append(append([]string(nil), e.Serials...), other.Serials...),
+		SPKIHashes: append(append([]string(nil), e.SPKIHashes...), other.SPKIHashes...),
+	}
+}
+
+// RevocationConfig configures the deny-list of workloads whose certificates are
+// rejected although they have not expired.
+type RevocationConfig struct {
+	// SPIFFEIDs are revoked SPIFFE IDs.
+	SPIFFEIDs []string `yaml:"spiffe_ids,omitempty" mapstructure:"spiffe_ids"`
+
+	// IDPrefixes revoke every SPIFFE ID at or below them.
+	IDPrefixes []string `yaml:"id_prefixes,omitempty" mapstructure:"id_prefixes"`
+
+	// Serials are revoked certificate serial numbers in hex, qualified by the
+	// issuing trust domain.
+	Serials []string `yaml:"serials,omitempty" mapstructure:"serials"`
+
+	// SPKIHashes are SHA-256 hashes of revoked public keys, in hex or base64.
+	SPKIHashes []string `yaml:"spki_hashes,omitempty" mapstructure:"spki_hashes"`
+
+	// File is a YAML or JSON file with further entries, in the same format as this
+	// section. It is reloaded when it changes; an invalid edit keeps the last good list.
+	File string `yaml:"file,omitempty" mapstructure:"file"`
+
+	// EnforceExisting also closes established connections whose peer is revoked,
+	// at once and whenever the list changes. Without it only new handshakes are
+	// rejected.
+	EnforceExisting bool `yaml:"enforce_existing,omitempty" mapstructure:"enforce_existing"`
+}
+
+// Entries returns the entries listed in the configuration itself.
+func (c *RevocationConfig) Entries() RevocationEntries {
+	if c == nil {
+		return RevocationEntries{}
+	}
+	return RevocationEntries{
+		SPIFFEIDs:  c.SPIFFEIDs,
+		IDPrefixes: c.IDPrefixes,
+		Serials:    c.Serials,
+		SPKIHashes: c.SPKIHashes,
+	}
+}
+
+// Validate checks that every listed entry is well formed. The file is checked
+// when it is loaded.
+func (c *RevocationConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if _, err := c.Entries().List(); err != nil {
+		return &errors.ValidationError{
+			Field:   "revocation",
+			Value:   c,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// RevocationListPort checks certificates against the current revocation list,
+// which may change at runtime.
+type RevocationListPort interface {
+	// CheckRevocation returns an error wrapping domain.ErrRevoked if any certificate
+	// of the chain, its key or its SPIFFE ID is revoked.
+	CheckRevocation(chain []*x509.Certificate) error
+
+	// Subscribe returns a channel that receives a value after the list changes, and
+	// a function that ends the subscription.
+	Subscribe() (<-chan struct{}, func())
+}
@@ -0,0 +1,251 @@
+package ports
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// Defaults for the server, logging and auth settings.
+const (
+	DefaultBindAddress = ":8443"
+	DefaultLogLevel    = "info"
+	DefaultLogFormat   = "text"
+)
+
+// Log formats accepted in logging.format.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Setting sources, from lowest to highest precedence.
+const (
+	SettingSourceDefault = "default"
+	SettingSourceFile    = "file"
+	SettingSourceEnv     = "env"
+	SettingSourceOption  = "option"
+)
+
+// logLevels maps the accepted logging.level values to slog levels.
+// trace is accepted for compatibility and logs everything debug does.
+var logLevels = map[string]slog.Level{
+	"trace": slog.LevelDebug - 4,
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// ServerConfig contains the settings of servers created from this configuration.
+type ServerConfig struct {
+	// Address is the host:port servers listen on. Default: ":8443".
+	// WithAddress and WithListener override it.
+	Address string `yaml:"address,omitempty" mapstructure:"address"`
+}
+
+// LoggingConfig selects the level and format of the library's logs.
+type LoggingConfig struct {
+	// Level is trace, debug, info, warn or error. Default: info.
+	Level string `yaml:"level,omitempty" mapstructure:"level"`
+	// Format is text or json. Default: text.
+	Format string `yaml:"format,omitempty" mapstructure:"format"`
+}
+
+// AuthConfig controls client authentication on servers.
+type AuthConfig struct {
+	// Require rejects clients that present no X.509-SVID. Default: true.
+	// When false, clients without a certificate may connect; certificates that are
+	// presented are still verified and authorized.
+	Require *bool `yaml:"require,omitempty" mapstructure:"require"`
+}
+
+// Setting is the effective value of a setting and the configuration layer it came from.
+type Setting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Validate checks that the address has a host:port form. A nil config is valid.
+func (s *ServerConfig) Validate() error {
+	if s == nil || s.Address == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return &errors.ValidationError{
+			Field:   "server.address",
+			Value:   s.Address,
+			Message: fmt.Sprintf("address must be host:port: %v", err),
+		}
+	}
+	return nil
+}
+
+// Validate checks the level and format names. A nil config is valid.
+func (l *LoggingConfig) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.Level != "" {
+		if _, err := ParseLogLevel(l.Level); err != nil {
+			return &errors.ValidationError{
+				Field:   "logging.level",
+				Value:   l.Level,
+				Message: err.Error(),
+			}
+		}
+	}
+	switch strings.ToLower(l.Format) {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return &errors.ValidationError{
+			Field:   "logging.format",
+			Value:   l.Format,
+			Message: "log format must be text or json",
+		}
+	}
+	return nil
+}
+
+// ParseLogLevel converts a logging.level name, case-insensitively, to a slog level.
+func ParseLogLevel(level string) (slog.Level, error) {
+	if parsed, ok := logLevels[strings.ToLower(strings.TrimSpace(level))]; ok {
+		return parsed, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q: must be trace, debug, info, warn or error", level)
+}
+
+// BindAddress returns the address servers listen on: EPHEMOS_BIND_ADDRESS, then
+// server.address, then ":8443".
+func (c *Configuration) BindAddress() Setting {
+	var fileValue string
+	if c != nil && c.Server != nil {
+		fileValue = c.Server.Address
+	}
+	return resolveSetting("server.address", EnvBindAddress, fileValue, DefaultBindAddress)
+}
+
+// LogLevel returns the log level: EPHEMOS_LOG_LEVEL, then logging.level, then info.
+func (c *Configuration) LogLevel() Setting {
+	var fileValue string
+	if c != nil && c.Logging != nil {
+		fileValue = c.Logging.Level
+	}
+	setting := resolveSetting("logging.level", EnvLogLevel, fileValue, DefaultLogLevel)
+	setting.Value = strings.ToLower(setting.Value)
+	return setting
+}
+
+// LogFormat returns the log format: EPHEMOS_LOG_FORMAT, then logging.format, then text.
+func (c *Configuration) LogFormat() Setting {
+	var fileValue string
+	if c != nil && c.Logging != nil {
+		fileValue = c.Logging.Format
+	}
+	setting := resolveSetting("logging.format", EnvLogFormat, fileValue, DefaultLogFormat)
+	setting.Value = strings.ToLower(setting.Value)
+	return setting
+}
+
+// RequireAuth returns whether servers require client certificates: auth.require,
+// then true. Loading merges EPHEMOS_REQUIRE_AUTHENTICATION into auth.require; here
+// the variable can only raise the requirement, so that it cannot weaken a
+// configuration built in code. A value that does not parse as a boolean, which
+// loading rejects, requires authentication.
+func (c *Configuration) RequireAuth() Setting {
+	const name = "auth.require"
+	configured := Setting{Name: name, Value: "true", Source: SettingSourceDefault}
+	if c != nil && c.Auth != nil && c.Auth.Require != nil {
+		configured = Setting{Name: name, Value: strconv.FormatBool(*c.Auth.Require), Source: SettingSourceFile}
+	}
+
+	value := strings.TrimSpace(os.Getenv(EnvRequireAuth))
+	if value == "" {
+		return configured
+	}
+	if require, err := strconv.ParseBool(value); err != nil || require {
+		return Setting{Name: name, Value: "true", Source: SettingSourceEnv}
+	}
+	if configured.Value == "false" {
+		configured.Source = SettingSourceEnv
+	}
+	return configured
+}
+
+// RequiresAuthentication reports whether servers reject clients without an X.509-SVID.
+func (c *Configuration) RequiresAuthentication() bool {
+	return c.RequireAuth().Value != "false"
+}
+
+// Settings returns the effective server, logging and auth settings with their sources.
+func (c *Configuration) Settings() []Setting {
+	return []Setting{c.BindAddress(), c.LogLevel(), c.LogFormat(), c.RequireAuth()}
+}
+
+// WithOption returns the setting overridden by value, if not empty, for settings
+// given in code such as the WithAddress server option.
+func (s Setting) WithOption(value string) Setting {
+	if value == "" {
+		return s
+	}
+	return Setting{Name: s.Name, Value: value, Source: SettingSourceOption}
+}
+
+// resolveSetting picks the environment value, then the file value, then the default.
+func resolveSetting(name, envName, fileValue, defaultValue string) Setting {
+	if value := strings.TrimSpace(os.Getenv(envName)); value != "" {
+		return Setting{Name: name, Value: value, Source: SettingSourceEnv}
+	}
+	if fileValue != "" {
+		return Setting{Name: name, Value: fileValue, Source: SettingSourceFile}
+	}
+	return Setting{Name: name, Value: defaultValue, Source: SettingSourceDefault}
+}
+
+// mergeRuntimeEnvironment overrides the server, logging and auth sections with
+// EPHEMOS_BIND_ADDRESS, EPHEMOS_LOG_LEVEL, EPHEMOS_LOG_FORMAT and
+// EPHEMOS_REQUIRE_AUTHENTICATION.
+func (c *Configuration) mergeRuntimeEnvironment() error {
+	if address := strings.TrimSpace(os.Getenv(EnvBindAddress)); address != "" {
+		if c.Server == nil {
+			c.Server = &ServerConfig{}
+		}
+		c.Server.Address = address
+	}
+
+	level := strings.TrimSpace(os.Getenv(EnvLogLevel))
+	format := strings.TrimSpace(os.Getenv(EnvLogFormat))
+	if level != "" || format != "" {
+		if c.Logging == nil {
+			c.Logging = &LoggingConfig{}
+		}
+		if level != "" {
+			c.Logging.Level = level
+		}
+		if format != "" {
+			c.Logging.Format = format
+		}
+	}
+
+	if value, ok := os.LookupEnv(EnvRequireAuth); ok && strings.TrimSpace(value) != "" {
+		require, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return &errors.ValidationError{
+				Field:   EnvRequireAuth,
+				Value:   value,
+				Message: "must be true or false",
+			}
+		}
+		if c.Auth == nil {
+			c.Auth = &AuthConfig{}
+		}
+		c.Auth.Require = &require
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package ports
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/errors"
+)
+
+// DefaultTLSMinVersion is the minimum TLS version used when none is configured.
+const DefaultTLSMinVersion = tls.VersionTLS13
+
+// TLSConfig holds the TLS protocol settings applied to every client and server
+// TLS configuration Ephemos builds.
+type TLSConfig struct {
+	// MinVersion is the minimum accepted TLS version: "1.2" or "1.3". "1.0" and
+	// "1.1" are accepted for legacy peers but fail IsProductionReady.
+	// Default: "1.3".
+	MinVersion string `yaml:"min_version,omitempty" mapstructure:"min_version"`
+
+	// CipherSuites restricts the TLS 1.2 cipher suites, by Go name, e.g.
+	// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256". TLS 1.3 suites are not
+	// configurable, so this requires min_version "1.2".
+	// Default: Go's default cipher suites.
+	CipherSuites []string `yaml:"cipher_suites,omitempty" mapstructure:"cipher_suites"`
+
+	// CurvePreferences lists the key exchange groups in preference order:
+	// "X25519MLKEM768", "X25519", "P256", "P384" or "P521". X25519MLKEM768 is the
+	// post-quantum hybrid key exchange and is only negotiated over TLS 1.3.
+	// Default: Go's default preferences.
+	CurvePreferences []string `yaml:"curve_preferences,omitempty" mapstructure:"curve_preferences"`
+
+	// FIPS restricts versions, cipher suites and curves to the FIPS 140-3 approved
+	// sets and rejects peer certificates using other algorithms. It is set from the
+	// top-level fips_mode by Configuration.EffectiveTLS.
+	FIPS bool `yaml:"-" mapstructure:"-"`
+
+	// KeyPolicy, when set, rejects peer certificates whose key algorithm, curve or
+	// RSA size it does not accept. It is set from the top-level keys section by
+	// Configuration.EffectiveTLS.
+	KeyPolicy *domain.KeyPolicy `yaml:"-" mapstructure:"-"`
+
+	// OptionalClientAuth lets clients that present no certificate complete the
+	// handshake on servers that otherwise require one. Presented certificates are
+	// still verified. It is set from the top-level auth.require by
+	// Configuration.EffectiveTLS.
+	OptionalClientAuth bool `yaml:"-" mapstructure:"-"`
+
+	// Revocation, when set, rejects peers whose certificate chain, key or SPIFFE ID
+	// it lists. It is set at runtime with WithRevocation.
+	Revocation RevocationListPort `yaml:"-" mapstructure:"-"`
+}
+
+// WithRevocation returns a copy of the settings that also rejects peers on the
+// revocation list. A nil TLSConfig yields the defaults plus the list.
+func (c *TLSConfig) WithRevocation(list RevocationListPort) *TLSConfig {
+	settings := TLSConfig{}
+	if c != nil {
+		settings = *c
+	}
+	settings.Revocation = list
+	return &settings
+}
+
+// fipsCurves are the FIPS 140-3 approved key exchange groups.
+var fipsCurves = map[tls.CurveID]bool{
+	tls.CurveP256: true,
+	tls.CurveP384: true,
+	tls.CurveP521: true,
+}
+
+// fipsCipherSuites are the FIPS 140-3 approved TLS 1.2 cipher suites.
+var fipsCipherSuites = map[uint16]bool{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: true,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:   true,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:   true,
+}
+
+// tlsVersions maps accepted min_version spellings to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsCurves maps accepted curve_preferences names to key exchange groups.
+var tlsCurves = map[string]tls.CurveID{
+	"x25519mlkem768": tls.X25519MLKEM768,
+	"x25519":         tls.X25519,
+	"p256":           tls.CurveP256,
+	"p384":           tls.CurveP384,
+	"p521":           tls.CurveP521,
+}
+
+// parseTLSVersion parses "1.2", "TLS1.2" or "TLSv1.2" style versions.
+func parseTLSVersion(value string) (uint16, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	normalized = strings.TrimPrefix(normalized, "tls")
+	normalized = strings.TrimPrefix(normalized, "v")
+	version, ok := tlsVersions[normalized]
+	return version, ok
+}
+
+// parseCurve parses a curve name, accepting Go names such as "CurveP256" and "P-256".
+func parseCurve(value string) (tls.CurveID, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	normalized = strings.TrimPrefix(normalized, "curve")
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	curve, ok := tlsCurves[normalized]
+	return curve, ok
+}
+
+// lookupCipherSuite finds a cipher suite by Go name, including insecure ones.
+func lookupCipherSuite(name string) (*tls.CipherSuite, bool) {
+	name = strings.TrimSpace(name)
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite, false
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return suite, true
+		}
+	}
+	return nil, false
+}
+
+// GetMinVersion returns the configured minimum TLS version or the default.
+// Invalid versions are reported by Validate.
+func (c *TLSConfig) GetMinVersion() uint16 {
+	if c == nil || c.MinVersion == "" {
+		return DefaultTLSMinVersion
+	}
+	if version, ok := parseTLSVersion(c.MinVersion); ok {
+		return version
+	}
+	return DefaultTLSMinVersion
+}
+
+// Validate checks that every version, cipher suite and curve name is known.
+func (c *TLSConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.MinVersion != "" {
+		if _, ok := parseTLSVersion(c.MinVersion); !ok {
+			return &errors.ValidationError{
+				Field:   "tls.min_version",
+				Value:   c.MinVersion,
+				Message: "min version must be 1.0, 1.1, 1.2 or 1.3",
+			}
+		}
+	}
+
+	if len(c.CipherSuites) > 0 && c.GetMinVersion() >= tls.VersionTLS13 {
+		return &errors.ValidationError{
+			Field:   "tls.cipher_suites",
+			Value:   c.CipherSuites,
+			Message: "cipher suites only apply to TLS 1.2; set min_version to 1.2",
+		}
+	}
+	for _, name := range c.CipherSuites {
+		suite, _ := lookupCipherSuite(name)
+		if suite == nil {
+			return &errors.ValidationError{
+				Field:   "tls.cipher_suites",
+				Value:   name,
+				Message: "unknown cipher suite",
+			}
+		}
+		if !supportsTLS12(suite) {
+			return &errors.ValidationError{
+				Field:   "tls.cipher_suites",
+				Value:   name,
+				Message: "TLS 1.3 cipher suites are not configurable",
+			}
+		}
+	}
+
+	for _, name := range c.CurvePreferences {
+		if _, ok := parseCurve(name); !ok {
+			return &errors.ValidationError{
+				Field:   "tls.curve_preferences",
+				Value:   name,
+				Message: "curve must be one of X25519MLKEM768, X25519, P256, P384, P521",
+			}
+		}
+	}
+
+	if c.FIPS {
+		return c.validateFIPS()
+	}
+	return nil
+}
+
+// validateFIPS rejects versions, cipher suites and curves outside the FIPS 140-3
+// approved sets. Names are already known to be valid.
+func (c *TLSConfig) validateFIPS() error {
+	if c.GetMinVersion() < tls.VersionTLS12 {
+		return &errors.ValidationError{
+			Field:   "tls.min_version",
+			Value:   c.MinVersion,
+			Message: "FIPS mode requires TLS 1.2 or later",
+		}
+	}
+	for _, name := range c.CipherSuites {
+		if suite, _ := lookupCipherSuite(name); !fipsCipherSuites[suite.ID] {
+			return &errors.ValidationError{
+				Field:   "tls.cipher_suites",
+				Value:   name,
+				Message: "cipher suite is not FIPS approved; use an ECDHE AES-GCM suite",
+			}
+		}
+	}
+	for _, name := range c.CurvePreferences {
+		if curve, _ := parseCurve(name); !fipsCurves[curve] {
+			return &errors.ValidationError{
+				Field:   "tls.curve_preferences",
+				Value:   name,
+				Message: "curve is not FIPS approved; use P256, P384 or P521",
+			}
+		}
+	}
+	return nil
+}
+
+// ProductionErrors reports settings too weak for production: versions below
+// TLS 1.2 and cipher suites that are insecure, lack forward secrecy or are not AEAD.
+func (c *TLSConfig) ProductionErrors() []error {
+	if c == nil {
+		return nil
+	}
+
+	var errs []error
+	if c.GetMinVersion() < tls.VersionTLS12 {
+		errs = append(errs, fmt.Errorf("%w: %s", errors.ErrWeakTLSVersion, c.MinVersion))
+	}
+	for _, name := range c.CipherSuites {
+		suite, insecure := lookupCipherSuite(name)
+		if suite == nil {
+			continue
+		}
+		if insecure || !strings.Contains(suite.Name, "_ECDHE_") || strings.Contains(suite.Name, "_CBC_") {
+			errs = append(errs, fmt.Errorf("%w: %s", errors.ErrWeakCipherSuite, suite.Name))
+		}
+	}
+	return errs
+}
+
+// Apply sets the minimum version, cipher suites and curve preferences on config.
+// A nil TLSConfig applies the defaults. It also rejects peer certificates that are
+// revoked, that the key policy does not accept or, in FIPS mode, whose key or
+// signature algorithm is not FIPS approved, and makes client certificates
+// optional when OptionalClientAuth is set.
+func (c *TLSConfig) Apply(config *tls.Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	config.MinVersion = c.GetMinVersion()
+	if c == nil {
+		return nil
+	}
+
+	if len(c.CipherSuites) > 0 {
+		suites := make([]uint16, 0, len(c.CipherSuites))
+		for _, name := range c.CipherSuites {
+			suite, _ := lookupCipherSuite(name)
+			suites = append(suites, suite.ID)
+		}
+		config.CipherSuites = suites
+	}
+
+	if len(c.CurvePreferences) > 0 {
+		curves := make([]tls.CurveID, 0, len(c.CurvePreferences))
+		for _, name := range c.CurvePreferences {
+			curve, _ := parseCurve(name)
+			curves = append(curves, curve)
+		}
+		config.CurvePreferences = curves
+	}
+
+	if c.FIPS || c.KeyPolicy != nil || c.Revocation != nil {
+		verify := config.VerifyPeerCertificate
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if err := c.verifyPeer(rawCerts); err != nil {
+				return err
+			}
+			if verify != nil {
+				return verify(rawCerts, verifiedChains)
+			}
+			return nil
+		}
+	}
+
+	if c.OptionalClientAuth {
+		optionalClientAuth(config)
+	}
+	return nil
+}
+
+// optionalClientAuth lets clients without a certificate connect to a server config
+// that requires one. SPIFFE server configs verify certificates themselves, so only
+// the check for a missing certificate is skipped.
+func optionalClientAuth(config *tls.Config) {
+	if config.ClientAuth != tls.RequireAnyClientCert && config.ClientAuth != tls.RequireAndVerifyClientCert {
+		return
+	}
+	if config.ClientAuth == tls.RequireAnyClientCert {
+		config.ClientAuth = tls.RequestClientCert
+	} else {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	verify := config.VerifyPeerCertificate
+	if verify == nil {
+		return
+	}
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return nil
+		}
+		return verify(rawCerts, verifiedChains)
+	}
+}
+
+// verifyPeer checks the peer's certificate chain against the revocation list, the
+// key policy and, in FIPS mode, the FIPS approved algorithms.
+func (c *TLSConfig) verifyPeer(rawCerts [][]byte) error {
+	chain := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse peer certificate: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	if c.Revocation != nil {
+		if err := c.Revocation.CheckRevocation(chain); err != nil {
+			return fmt.Errorf("peer rejected: %w", err)
+		}
+	}
+
+	for _, cert := range chain {
+		if c.KeyPolicy != nil {
+			if err := c.KeyPolicy.ValidateCertificate(cert); err != nil {
+				return fmt.Errorf("peer certificate rejected by key policy: %w", err)
+			}
+		}
+		if c.FIPS {
+			if err := domain.ValidateFIPSCertificate(cert); err != nil {
+				return fmt.Errorf("peer certificate rejected in FIPS mode: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, version := range suite.SupportedVersions {
+		if version == tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
@@ -90,6 +90,8 @@ type DiagnosticInfo struct {
 	CollectedAt time.Time `json:"collected_at"`
 	// Details contains component-specific diagnostic data
 	Details map[string]interface{} `json:"details,omitempty"`
+	// FIPS140 is the FIPS 140-3 status of the Go cryptographic module in this process
+	FIPS140 *FIPSStatus `json:"fips140,omitempty"`
 }
 
 // RegistrationEntryInfo contains information about registration entries
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// PolicyEvaluator evaluates ordered allow/deny rules against access requests.
+// The first matching rule decides; unmatched requests get the default action.
+// It is immutable after construction and safe for concurrent use.
+type PolicyEvaluator struct {
+	defaultAllow bool
+	rules        []compiledRule
+}
+
+var _ ports.PolicyEvaluatorPort = (*PolicyEvaluator)(nil)
+
+// compiledRule is a validated rule with its resolved name.
+type compiledRule struct {
+	name        string
+	allow       bool
+	peers       []ports.PeerMatcherConfig
+	methods     []string
+	paths       []string
+	grpcMethods []string
+}
+
+// NewPolicyEvaluator creates an evaluator from a validated policy configuration.
+// A nil configuration allows every request.
+func NewPolicyEvaluator(config *ports.PolicyConfig) (*PolicyEvaluator, error) {
+	if config == nil {
+		return &PolicyEvaluator{defaultAllow: true}, nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid authorization policy: %w", err)
+	}
+
+	rules := make([]compiledRule, 0, len(config.Rules))
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		rules = append(rules, compiledRule{
+			name:        rule.RuleName(i),
+			allow:       rule.Action == ports.PolicyActionAllow,
+			peers:       rule.Peers,
+			methods:     rule.Methods,
+			paths:       rule.Paths,
+			grpcMethods: rule.GRPCMethods,
+		})
+	}
+
+	return &PolicyEvaluator{
+		defaultAllow: config.GetDefault() == ports.PolicyActionAllow,
+		rules:        rules,
+	}, nil
+}
+
+// Evaluate decides the request and reports which rule matched.
+func (e *PolicyEvaluator) Evaluate(request ports.AccessRequest) ports.PolicyDecision {
+	for _, rule := range e.rules {
+		if !rule.matchesPeer(request.PeerID) || !rule.matchesOperation(request) {
+			continue
+		}
+		return ports.PolicyDecision{
+			Allowed: rule.allow,
+			Rule:    rule.name,
+			Reason:  fmt.Sprintf("%s %s by rule %q", describe(request), verdict(rule.allow), rule.name),
+		}
+	}
+
+	return ports.PolicyDecision{
+		Allowed: e.defaultAllow,
+		Rule:    "default",
+		Reason:  fmt.Sprintf("%s %s by default: no rule matched", describe(request), verdict(e.defaultAllow)),
+	}
+}
+
+func (r *compiledRule) matchesPeer(peerID string) bool {
+	if len(r.peers) == 0 {
+		return peerID != ""
+	}
+	for i := range r.peers {
+		if r.peers[i].Matches(peerID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *compiledRule) matchesOperation(request ports.AccessRequest) bool {
+	hasHTTP := len(r.methods) > 0 || len(r.paths) > 0
+	hasGRPC := len(r.grpcMethods) > 0
+	if !hasHTTP && !hasGRPC {
+		return true
+	}
+
+	if request.GRPCMethod != "" {
+		return hasGRPC && matchesAnyPattern(r.grpcMethods, request.GRPCMethod)
+	}
+
+	if !hasHTTP {
+		return false
+	}
+	if len(r.methods) > 0 && !containsString(r.methods, strings.ToUpper(request.Method)) {
+		return false
+	}
+	return len(r.paths) == 0 || matchesAnyPattern(r.paths, cleanPath(request.Path))
+}
+
+// cleanPath resolves "." and ".." segments and duplicate slashes, so that requests
+// like "/public/../admin" are matched as the path they are routed to.
+func cleanPath(p string) string {
+	if p == "" {
+		return p
+	}
+	return path.Clean(p)
+}
+
+func matchesAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// describe renders the request for decision reasons.
+func describe(request ports.AccessRequest) string {
+	peer := request.PeerID
+	if peer == "" {
+		peer = "unauthenticated peer"
+	}
+	if request.GRPCMethod != "" {
+		return fmt.Sprintf("%s calling %s", peer, request.GRPCMethod)
+	}
+	return fmt.Sprintf("%s calling %s %s", peer, request.Method, request.Path)
+}
+
+func verdict(allow bool) string {
+	if allow {
+		return "allowed"
+	}
+	return "denied"
+}
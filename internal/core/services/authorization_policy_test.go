@@ -0,0 +1,161 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+func TestPolicyEvaluator_Evaluate(t *testing.T) {
+	evaluator, err := services.NewPolicyEvaluator(&ports.PolicyConfig{
+		Rules: []ports.PolicyRuleConfig{
+			{
+				Name:   "block-reporters",
+				Action: ports.PolicyActionDeny,
+				Peers:  []ports.PeerMatcherConfig{{Glob: "spiffe://example.org/ns/*/sa/reporter"}},
+			},
+			{
+				Name:   "admin-only",
+				Action: ports.PolicyActionAllow,
+				Peers:  []ports.PeerMatcherConfig{{ID: "spiffe://example.org/admin"}},
+				Paths:  []string{"/admin/*"},
+			},
+			{
+				Name:    "read-api",
+				Action:  ports.PolicyActionAllow,
+				Peers:   []ports.PeerMatcherConfig{{Prefix: "spiffe://example.org/"}},
+				Methods: []string{"GET"},
+				Paths:   []string{"/api/*"},
+			},
+			{
+				Action:      ports.PolicyActionAllow,
+				Peers:       []ports.PeerMatcherConfig{{ID: "spiffe://example.org/billing"}},
+				GRPCMethods: []string{"/billing.v1.Billing/*"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		request     ports.AccessRequest
+		wantAllowed bool
+		wantRule    string
+	}{
+		{
+			name:        "first matching rule wins",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/ns/prod/sa/reporter", Method: "GET", Path: "/api/reports"},
+			wantAllowed: false,
+			wantRule:    "block-reporters",
+		},
+		{
+			name:        "exact peer and path glob",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/admin", Method: "POST", Path: "/admin/users"},
+			wantAllowed: true,
+			wantRule:    "admin-only",
+		},
+		{
+			name:        "path glob does not cross slashes",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/admin", Method: "POST", Path: "/admin/users/1"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "prefix peer with method",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/web", Method: "get", Path: "/api/orders"},
+			wantAllowed: true,
+			wantRule:    "read-api",
+		},
+		{
+			name:        "method mismatch falls through to default",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/web", Method: "DELETE", Path: "/api/orders"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "unnamed gRPC rule",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/billing", GRPCMethod: "/billing.v1.Billing/Charge"},
+			wantAllowed: true,
+			wantRule:    "rule[3]",
+		},
+		{
+			name:        "HTTP rules do not match gRPC calls",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/admin", GRPCMethod: "/admin/users"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "gRPC rules do not match HTTP requests",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/billing", Method: "GET", Path: "/billing.v1.Billing/Charge"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "other trust domain",
+			request:     ports.AccessRequest{PeerID: "spiffe://other.org/web", Method: "GET", Path: "/api/orders"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "prefix matches at a segment boundary only",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org.evil/web", Method: "GET", Path: "/api/orders"},
+			wantAllowed: false,
+			wantRule:    "default",
+		},
+		{
+			name:        "dot segments are resolved before matching paths",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/web", Method: "GET", Path: "/admin/../api/orders"},
+			wantAllowed: true,
+			wantRule:    "read-api",
+		},
+		{
+			name:        "duplicate slashes are resolved before matching paths",
+			request:     ports.AccessRequest{PeerID: "spiffe://example.org/admin", Method: "POST", Path: "//admin//users"},
+			wantAllowed: true,
+			wantRule:    "admin-only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := evaluator.Evaluate(tt.request)
+			assert.Equal(t, tt.wantAllowed, decision.Allowed, decision.Reason)
+			assert.Equal(t, tt.wantRule, decision.Rule)
+			assert.Contains(t, decision.Reason, tt.wantRule)
+		})
+	}
+}
+
+func TestPolicyEvaluator_Default(t *testing.T) {
+	allowAll, err := services.NewPolicyEvaluator(&ports.PolicyConfig{Default: ports.PolicyActionAllow})
+	require.NoError(t, err)
+
+	decision := allowAll.Evaluate(ports.AccessRequest{PeerID: "spiffe://example.org/web", Method: "GET", Path: "/"})
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "default", decision.Rule)
+
+	unconfigured, err := services.NewPolicyEvaluator(nil)
+	require.NoError(t, err)
+	assert.True(t, unconfigured.Evaluate(ports.AccessRequest{PeerID: "spiffe://example.org/web"}).Allowed)
+}
+
+func TestPolicyEvaluator_RuleWithoutPeersRequiresAuthenticatedPeer(t *testing.T) {
+	evaluator, err := services.NewPolicyEvaluator(&ports.PolicyConfig{
+		Rules: []ports.PolicyRuleConfig{{Name: "health", Action: ports.PolicyActionAllow, Paths: []string{"/healthz"}}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, evaluator.Evaluate(ports.AccessRequest{PeerID: "spiffe://example.org/probe", Method: "GET", Path: "/healthz"}).Allowed)
+	assert.False(t, evaluator.Evaluate(ports.AccessRequest{Method: "GET", Path: "/healthz"}).Allowed)
+}
+
+func TestNewPolicyEvaluator_InvalidConfig(t *testing.T) {
+	_, err := services.NewPolicyEvaluator(&ports.PolicyConfig{
+		Rules: []ports.PolicyRuleConfig{{Action: "permit"}},
+	})
+	require.Error(t, err)
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/sufield/ephemos/internal/core/ports"
 )
 
+// DefaultHealthInterval is the interval of periodic health monitoring when none is configured.
+const DefaultHealthInterval = 30 * time.Second
+
 // HealthMonitorService implements comprehensive health monitoring for SPIRE infrastructure
 type HealthMonitorService struct {
 	config     *ports.HealthConfig
@@ -99,18 +102,61 @@ func (h *HealthMonitorService) RegisterReporter(reporter ports.HealthReporterPor
 
 // CheckAll performs health checks on all registered components
 func (h *HealthMonitorService) CheckAll(ctx context.Context) (map[string]*ports.HealthResult, error) {
-	h.mu.RLock()
-	checkers := make(map[string]ports.HealthCheckerPort)
-	for name, checker := range h.checkers {
-		checkers[name] = checker
-	}
-	h.mu.RUnlock()
-
+	checkers := h.snapshotCheckers()
 	if len(checkers) == 0 {
 		h.logger.Warn("No health checkers registered")
 		return make(map[string]*ports.HealthResult), nil
 	}
 
+	results, err := h.runChecks(ctx, checkers, ports.HealthCheckerPort.CheckHealth)
+	if err != nil {
+		return results, err
+	}
+
+	// Update stored results
+	h.mu.Lock()
+	for name, result := range results {
+		h.results[name] = result
+	}
+	h.mu.Unlock()
+
+	// Report results to all registered reporters
+	h.reportToAll(results)
+
+	return results, nil
+}
+
+// CheckLiveness performs liveness checks on all registered components.
+// Unlike CheckAll, the results are neither stored nor reported.
+func (h *HealthMonitorService) CheckLiveness(ctx context.Context) (map[string]*ports.HealthResult, error) {
+	return h.runChecks(ctx, h.snapshotCheckers(), ports.HealthCheckerPort.CheckLiveness)
+}
+
+// CheckReadiness performs readiness checks on all registered components.
+// Unlike CheckAll, the results are neither stored nor reported.
+func (h *HealthMonitorService) CheckReadiness(ctx context.Context) (map[string]*ports.HealthResult, error) {
+	return h.runChecks(ctx, h.snapshotCheckers(), ports.HealthCheckerPort.CheckReadiness)
+}
+
+// snapshotCheckers returns a copy of the registered checkers
+func (h *HealthMonitorService) snapshotCheckers() map[string]ports.HealthCheckerPort {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	checkers := make(map[string]ports.HealthCheckerPort, len(h.checkers))
+	for name, checker := range h.checkers {
+		checkers[name] = checker
+	}
+	return checkers
+}
+
+// runChecks runs check on every checker concurrently. A check that fails is
+// reported as a result with unknown status.
+func (h *HealthMonitorService) runChecks(
+	ctx context.Context,
+	checkers map[string]ports.HealthCheckerPort,
+	check func(ports.HealthCheckerPort, context.Context) (*ports.HealthResult, error),
+) (map[string]*ports.HealthResult, error) {
 	// Perform checks concurrently for better performance
 	results := make(map[string]*ports.HealthResult)
 	resultsCh := make(chan struct {
@@ -122,7 +168,7 @@ func (h *HealthMonitorService) CheckAll(ctx context.Context) (map[string]*ports.
 	// Start all health checks concurrently
 	for name, checker := range checkers {
 		go func(name string, checker ports.HealthCheckerPort) {
-			result, err := checker.CheckHealth(ctx)
+			result, err := check(checker, ctx)
 			if err != nil {
 				// Create error result
 				result = &ports.HealthResult{
@@ -159,44 +205,76 @@ func (h *HealthMonitorService) CheckAll(ctx context.Context) (map[string]*ports.
 		}
 	}
 
-	// Update stored results
-	h.mu.Lock()
-	for name, result := range results {
-		h.results[name] = result
-	}
-	h.mu.Unlock()
-
-	// Report results to all registered reporters
-	h.reportToAll(results)
-
 	return results, nil
 }
 
-// StartMonitoring begins periodic health monitoring
+// StartMonitoring begins periodic health monitoring. While the configuration is
+// disabled no checks run, but monitoring follows a later Reconfigure that enables it.
 func (h *HealthMonitorService) StartMonitoring(ctx context.Context) error {
-	if !h.config.Enabled {
-		h.logger.Info("Health monitoring is disabled")
-		return nil
-	}
-
 	h.mu.Lock()
 	if h.monitoring {
 		h.mu.Unlock()
 		return fmt.Errorf("health monitoring is already running")
 	}
 	h.monitoring = true
+	stopCh := h.stopCh
+	enabled := h.config.Enabled
+	checkers := len(h.checkers)
 	h.mu.Unlock()
 
-	interval := h.config.Interval
-	if interval == 0 {
-		interval = 30 * time.Second // Default interval
+	if enabled {
+		h.logger.Info("Starting health monitoring",
+			"interval", h.GetInterval(),
+			"checkers", checkers)
+	} else {
+		h.logger.Info("Health monitoring is disabled")
 	}
 
-	h.logger.Info("Starting health monitoring",
-		"interval", interval,
-		"checkers", len(h.checkers))
+	go h.monitoringLoop(ctx, stopCh)
+
+	return nil
+}
+
+// GetInterval returns the interval of periodic health monitoring.
+func (h *HealthMonitorService) GetInterval() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 
-	go h.monitoringLoop(ctx, interval)
+	if h.config.Interval <= 0 {
+		return DefaultHealthInterval
+	}
+	return h.config.Interval
+}
+
+// Reconfigure replaces the health configuration and the registered checkers, e.g.
+// after the health section changed at runtime. Results of components that are no
+// longer checked are dropped; the interval applies from the next round.
+func (h *HealthMonitorService) Reconfigure(config *ports.HealthConfig, checkers []ports.HealthCheckerPort) error {
+	if config == nil {
+		return fmt.Errorf("health config cannot be nil")
+	}
+
+	registered := make(map[string]ports.HealthCheckerPort, len(checkers))
+	for _, checker := range checkers {
+		if checker == nil || checker.GetComponentName() == "" {
+			return fmt.Errorf("health checker must have a valid component name")
+		}
+		registered[checker.GetComponentName()] = checker
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.config = config
+	h.checkers = registered
+	for name := range h.results {
+		if _, ok := registered[name]; !ok {
+			delete(h.results, name)
+		}
+	}
+	h.logger.Info("Health monitoring reconfigured",
+		"enabled", config.Enabled,
+		"checkers", len(registered))
 
 	return nil
 }
@@ -285,23 +363,29 @@ func (h *HealthMonitorService) Close() error {
 	return nil
 }
 
-// monitoringLoop runs the periodic health checking
-func (h *HealthMonitorService) monitoringLoop(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// monitoringLoop runs the periodic health checking until stopCh is closed.
+// StopMonitoring replaces the monitor's channel, so the loop is given its own.
+// The interval and whether checks run are read every round, so Reconfigure
+// applies to a running loop.
+func (h *HealthMonitorService) monitoringLoop(ctx context.Context, stopCh <-chan struct{}) {
+	timer := time.NewTimer(h.GetInterval())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			checkCtx, cancel := context.WithTimeout(ctx, h.getCheckTimeout())
-			_, err := h.CheckAll(checkCtx)
-			cancel()
-
-			if err != nil {
-				h.logger.Error("Periodic health check failed", "error", err)
+		case <-timer.C:
+			if h.isEnabled() {
+				checkCtx, cancel := context.WithTimeout(ctx, h.getCheckTimeout())
+				_, err := h.CheckAll(checkCtx)
+				cancel()
+
+				if err != nil {
+					h.logger.Error("Periodic health check failed", "error", err)
+				}
 			}
+			timer.Reset(h.GetInterval())
 
-		case <-h.stopCh:
+		case <-stopCh:
 			h.logger.Debug("Health monitoring loop stopped")
 			return
 
@@ -312,8 +396,18 @@ func (h *HealthMonitorService) monitoringLoop(ctx context.Context, interval time
 	}
 }
 
+// isEnabled reports whether periodic checks are enabled
+func (h *HealthMonitorService) isEnabled() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.config.Enabled
+}
+
 // getCheckTimeout returns the timeout for health checks
 func (h *HealthMonitorService) getCheckTimeout() time.Duration {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
 	if h.config.Timeout > 0 {
 		return h.config.Timeout
 	}
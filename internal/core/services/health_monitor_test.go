@@ -396,7 +396,62 @@ func TestHealthMonitorService_MonitoringDisabled(t *testing.T) {
 
 	ctx := context.Background()
 	err = service.StartMonitoring(ctx)
-	assert.NoError(t, err) // Should succeed but not actually start monitoring
+	assert.NoError(t, err) // Should succeed but not run any checks
+	require.NoError(t, service.StopMonitoring())
+}
+
+func TestHealthMonitorService_ReconfigureEnablesMonitoring(t *testing.T) {
+	service, err := NewHealthMonitorService(&ports.HealthConfig{Interval: 10 * time.Millisecond}, slog.Default())
+	require.NoError(t, err)
+
+	checker := &MockHealthChecker{}
+	checker.On("GetComponentName").Return("comp1")
+	checker.On("CheckHealth", mock.Anything).Return(&ports.HealthResult{
+		Status:    ports.HealthStatusHealthy,
+		Component: "comp1",
+	}, nil)
+
+	require.NoError(t, service.StartMonitoring(context.Background()))
+	defer func() { _ = service.StopMonitoring() }()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, service.GetResults(), "disabled monitoring must not run checks")
+
+	err = service.Reconfigure(&ports.HealthConfig{Enabled: true, Interval: 10 * time.Millisecond},
+		[]ports.HealthCheckerPort{checker})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return service.GetResults()["comp1"] != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHealthMonitorService_Reconfigure(t *testing.T) {
+	service, err := NewHealthMonitorService(&ports.HealthConfig{Enabled: true}, slog.Default())
+	require.NoError(t, err)
+
+	service.mu.Lock()
+	service.results = map[string]*ports.HealthResult{
+		"comp1": {Status: ports.HealthStatusHealthy, Component: "comp1"},
+		"comp2": {Status: ports.HealthStatusHealthy, Component: "comp2"},
+	}
+	service.mu.Unlock()
+
+	checker := &MockHealthChecker{}
+	checker.On("GetComponentName").Return("comp2")
+
+	config := &ports.HealthConfig{Enabled: true, Interval: time.Minute, Timeout: time.Second}
+	require.NoError(t, service.Reconfigure(config, []ports.HealthCheckerPort{checker}))
+
+	assert.Equal(t, time.Minute, service.GetInterval())
+	assert.Equal(t, time.Second, service.getCheckTimeout())
+	assert.Equal(t, map[string]ports.HealthCheckerPort{"comp2": checker}, service.snapshotCheckers())
+	results := service.GetResults()
+	assert.NotContains(t, results, "comp1")
+	assert.Contains(t, results, "comp2")
+
+	assert.Error(t, service.Reconfigure(nil, nil))
+	assert.Equal(t, time.Minute, service.GetInterval(), "a rejected configuration must be ignored")
 }
 
 func TestHealthMonitorService_Close(t *testing.T) {
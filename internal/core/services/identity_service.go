@@ -108,7 +108,9 @@ type IdentityService struct {
 	config            *ports.Configuration
 	cachedIdentity    spiffeid.ID
 	validator         ports.CertValidatorPort // Certificate validator
+	defaultValidator  bool                    // validator is the default one
 	metrics           MetricsReporter         // Metrics reporter (Prometheus or NoOp)
+	logger            *slog.Logger
 
 	// Certificate caching for rotation support
 	cachedCert       *domain.Certificate
@@ -116,11 +118,13 @@ type IdentityService struct {
 	cachedBundle     *domain.TrustBundle
 	bundleCacheEntry *domain.CacheEntry
 	cacheTTL         time.Duration
+	refreshThreshold time.Duration
 
 	// Enhanced mTLS connection tracking and enforcement
 	connectionRegistry *MTLSConnectionRegistry
 	enforcementService *MTLSEnforcementService
 	continuityService  *RotationContinuityService
+	logObserver        *LogRotationObserver
 
 	mu sync.RWMutex
 }
@@ -164,21 +168,18 @@ func NewIdentityService(
 	}
 
 	// Use default validator if none provided
-	if validator == nil {
+	defaultValidator := validator == nil
+	if defaultValidator {
 		validator = adapters.NewDefaultCertValidator()
 	}
 
 	// Use NoOp metrics if none provided
-	if metrics == nil {
+	withMetrics := metrics != nil
+	if !withMetrics {
 		metrics = &NoOpMetrics{}
 	}
 
-	// Determine cache TTL from configuration or use default
-	cacheTTL := 30 * time.Minute // Default: 30 minutes (half of typical 1-hour SPIFFE cert lifetime)
-	// Extract intermediate to avoid deep selector chains
-	if serviceConfig.Cache != nil && serviceConfig.Cache.TTLMinutes > 0 {
-		cacheTTL = time.Duration(serviceConfig.Cache.TTLMinutes) * time.Minute
-	}
+	cacheTTL, refreshThreshold := cacheSettings(serviceConfig.Cache)
 
 	service := &IdentityService{
 		identityProvider:  identityProvider,
@@ -186,8 +187,11 @@ func NewIdentityService(
 		config:            config,
 		cachedIdentity:    identity,
 		validator:         validator,
+		defaultValidator:  defaultValidator,
 		metrics:           metrics,
+		logger:            slog.Default(),
 		cacheTTL:          cacheTTL,
+		refreshThreshold:  refreshThreshold,
 	}
 
 	// Initialize enhanced mTLS components
@@ -195,9 +199,17 @@ func NewIdentityService(
 	service.enforcementService = NewMTLSEnforcementService(service, service.connectionRegistry)
 	service.continuityService = NewRotationContinuityService(service, transportProvider)
 
+	// Transports that report their connections let enforcement close them on the network
+	if tracking, ok := transportProvider.(ports.ConnectionTrackingProvider); ok {
+		tracking.SetConnectionTracker(service.connectionRegistry)
+	}
+
 	// Add logging observer for rotation events
-	logObserver := NewLogRotationObserver(slog.Default())
-	service.connectionRegistry.AddRotationObserver(logObserver)
+	service.logObserver = NewLogRotationObserver(service.logger)
+	service.connectionRegistry.AddRotationObserver(service.logObserver)
+	if withMetrics {
+		service.connectionRegistry.AddRotationObserver(NewMetricsRotationObserver(metrics))
+	}
 
 	return service, nil
 }
@@ -305,21 +317,13 @@ func (s *IdentityService) getCertificate() (*domain.Certificate, error) {
 	if s.cachedCert != nil && s.certCacheEntry != nil && s.certCacheEntry.IsFresh() {
 		// Validate the cached certificate is not expired
 		if err := s.validateCertificateExpiry(s.cachedCert); err == nil {
-			// Determine proactive refresh threshold from configuration or use default
-			refreshThreshold := 10 * time.Minute // Default: 10 minutes before expiry
-			// Extract intermediates to avoid deep selector chains
-			service := s.config.Service
-			if service.Cache != nil && service.Cache.ProactiveRefreshMinutes > 0 {
-				refreshThreshold = time.Duration(service.Cache.ProactiveRefreshMinutes) * time.Minute
-			}
-
 			// Proactive refresh if certificate expires soon
 			// This aligns with SPIFFE short-lived cert best practices
-			if s.cachedCert.IsExpiringWithin(refreshThreshold) {
-				slog.Info("Proactively refreshing certificate expiring soon",
+			if s.cachedCert.IsExpiringWithin(s.refreshThreshold) {
+				s.logger.Info("Proactively refreshing certificate expiring soon",
 					"service_name", s.cachedIdentity.Path()[1:],
 					"cert_expires_at", s.cachedCert.ExpiresAt(),
-					"refresh_threshold", refreshThreshold.String(),
+					"refresh_threshold", s.refreshThreshold.String(),
 					"expires_in", s.cachedCert.TimeToExpiry().String(),
 				)
 				// Clear cache to force refresh
@@ -360,6 +364,7 @@ func (s *IdentityService) getCertificate() (*domain.Certificate, error) {
 	// Update certificate expiry metric
 	if cert != nil && cert.Cert != nil {
 		s.metrics.UpdateCertExpiry(s.cachedIdentity.Path()[1:], float64(cert.Cert.NotAfter.Unix()))
+		s.metrics.RecordSVIDExpiry(s.cachedIdentity, cert.Cert.NotAfter)
 	}
 
 	// Cache the new certificate
@@ -373,9 +378,12 @@ func (s *IdentityService) getCertificate() (*domain.Certificate, error) {
 // This is a lightweight check used for cache validation without full validation overhead.
 func (s *IdentityService) validateCertificateExpiry(cert *domain.Certificate) error {
 	// Use centralized validation with minimal options for quick expiry check
+	keyPolicy := s.config.KeyPolicy()
 	opts := domain.CertValidationOptions{
 		SkipChainVerify: true, // Skip chain verification for performance
 		// Other checks like expiry and basic structure are still performed
+		FIPS:      s.config.FIPSMode, // Cached certificates must still use approved algorithms
+		KeyPolicy: &keyPolicy,
 	}
 
 	// Use centralized validator for consistency
@@ -464,14 +472,17 @@ func (s *IdentityService) ValidateServiceIdentity(cert *domain.Certificate) erro
 
 	// Configure validation options
 	// Convert spiffeid.ID to domain.ServiceIdentity for validation
+	keyPolicy := s.config.KeyPolicy()
 	expectedServiceIdentity := domain.NewServiceIdentity(expectedIdentity.Path()[1:], expectedIdentity.TrustDomain().String())
 	opts := domain.CertValidationOptions{
 		ExpectedIdentity: expectedServiceIdentity,
-		WarningThreshold: time.Hour,      // Warn when certificate expires within 1 hour
-		TrustBundle:      trustBundle,    // Use cached trust bundle if available
-		SkipExpiry:       false,          // Always check expiry in production
-		SkipChainVerify:  false,          // Always verify chain in production
-		Logger:           slog.Default(), // Use default logger for warnings
+		WarningThreshold: time.Hour,         // Warn when certificate expires within 1 hour
+		TrustBundle:      trustBundle,       // Use cached trust bundle if available
+		SkipExpiry:       false,             // Always check expiry in production
+		SkipChainVerify:  false,             // Always verify chain in production
+		Logger:           s.logger,          // Use the service logger for warnings
+		FIPS:             s.config.FIPSMode, // Reject non-approved algorithms in FIPS mode
+		KeyPolicy:        &keyPolicy,        // Enforce configured key algorithms, curves and RSA sizes
 	}
 
 	// Use centralized validator
@@ -481,7 +492,7 @@ func (s *IdentityService) ValidateServiceIdentity(cert *domain.Certificate) erro
 
 	// Log successful validation for observability
 	if spiffeID, err := cert.ToSPIFFEID(); err == nil {
-		slog.Debug("Certificate SPIFFE ID validation successful",
+		s.logger.Debug("Certificate SPIFFE ID validation successful",
 			"service_name", expectedIdentity.Path()[1:],
 			"spiffe_id", spiffeID.String(),
 			"trust_domain", spiffeID.TrustDomain().String(),
@@ -510,12 +521,15 @@ func (s *IdentityService) validateCertificateChain(cert *domain.Certificate) err
 	// Use centralized validation with comprehensive options
 	// Convert spiffeid.ID to domain.ServiceIdentity for validation
 	serviceIdentity := domain.NewServiceIdentity(identity.Path()[1:], identity.TrustDomain().String())
+	keyPolicy := s.config.KeyPolicy()
 	opts := domain.CertValidationOptions{
-		ExpectedIdentity: serviceIdentity,  // Verify SPIFFE ID matches our identity
-		WarningThreshold: 30 * time.Minute, // Warn if expires within 30 minutes
-		TrustBundle:      trustBundle,      // Verify chain against trust bundle
-		SkipExpiry:       false,            // Always check expiry in production
-		SkipChainVerify:  false,            // Always verify chain cryptographically
+		ExpectedIdentity: serviceIdentity,   // Verify SPIFFE ID matches our identity
+		WarningThreshold: 30 * time.Minute,  // Warn if expires within 30 minutes
+		TrustBundle:      trustBundle,       // Verify chain against trust bundle
+		SkipExpiry:       false,             // Always check expiry in production
+		SkipChainVerify:  false,             // Always verify chain cryptographically
+		FIPS:             s.config.FIPSMode, // Reject non-approved algorithms in FIPS mode
+		KeyPolicy:        &keyPolicy,        // Enforce configured key algorithms, curves and RSA sizes
 	}
 
 	// Perform validation through the validator port
@@ -523,7 +537,7 @@ func (s *IdentityService) validateCertificateChain(cert *domain.Certificate) err
 		return fmt.Errorf("certificate validation failed: %w", err)
 	}
 
-	slog.Debug("Certificate chain validation successful",
+	s.logger.Debug("Certificate chain validation successful",
 		"service_name", serviceName,
 		"subject", cert.Cert.Subject.String(),
 		"issuer", cert.Cert.Issuer.String(),
@@ -538,10 +552,8 @@ func (s *IdentityService) validateCertificateChain(cert *domain.Certificate) err
 
 // fetchCertificateWithRetry retrieves certificate from identity provider with retry logic for transient failures.
 func (s *IdentityService) fetchCertificateWithRetry() (*domain.Certificate, error) {
-	// Get service name with proper locking for thread safety
-	s.mu.RLock()
+	// The caller holds s.mu; cachedIdentity is set once at construction
 	serviceName := s.cachedIdentity.Path()[1:] // Remove leading slash from path
-	s.mu.RUnlock()
 
 	maxRetries := 3
 	baseDelay := 100 * time.Millisecond
@@ -559,7 +571,7 @@ func (s *IdentityService) fetchCertificateWithRetry() (*domain.Certificate, erro
 		// Log retry attempts with structured logging
 		if attempt < maxRetries-1 {
 			delay := baseDelay * time.Duration(1<<attempt) // Exponential backoff
-			slog.Warn("Certificate fetch failed, retrying",
+			s.logger.Warn("Certificate fetch failed, retrying",
 				"service_name", serviceName,
 				"attempt", attempt+1,
 				"max_retries", maxRetries,
@@ -570,7 +582,7 @@ func (s *IdentityService) fetchCertificateWithRetry() (*domain.Certificate, erro
 		}
 	}
 
-	slog.Error("Certificate fetch failed after all retries",
+	s.logger.Error("Certificate fetch failed after all retries",
 		"service_name", serviceName,
 		"max_retries", maxRetries,
 		"final_error", lastErr.Error(),
@@ -581,10 +593,8 @@ func (s *IdentityService) fetchCertificateWithRetry() (*domain.Certificate, erro
 
 // fetchTrustBundleWithRetry retrieves trust bundle from identity provider with retry logic for transient failures.
 func (s *IdentityService) fetchTrustBundleWithRetry() (*domain.TrustBundle, error) {
-	// Get service name with proper locking for thread safety
-	s.mu.RLock()
+	// The caller holds s.mu; cachedIdentity is set once at construction
 	serviceName := s.cachedIdentity.Path()[1:] // Remove leading slash from path
-	s.mu.RUnlock()
 
 	maxRetries := 3
 	baseDelay := 100 * time.Millisecond
@@ -606,7 +616,7 @@ func (s *IdentityService) fetchTrustBundleWithRetry() (*domain.TrustBundle, erro
 		// Log retry attempts with structured logging
 		if attempt < maxRetries-1 {
 			delay := baseDelay * time.Duration(1<<attempt) // Exponential backoff
-			slog.Warn("Trust bundle fetch failed, retrying",
+			s.logger.Warn("Trust bundle fetch failed, retrying",
 				"service_name", serviceName,
 				"attempt", attempt+1,
 				"max_retries", maxRetries,
@@ -617,7 +627,7 @@ func (s *IdentityService) fetchTrustBundleWithRetry() (*domain.TrustBundle, erro
 		}
 	}
 
-	slog.Error("Trust bundle fetch failed after all retries",
+	s.logger.Error("Trust bundle fetch failed after all retries",
 		"service_name", serviceName,
 		"max_retries", maxRetries,
 		"final_error", lastErr.Error(),
@@ -713,6 +723,89 @@ func (s *IdentityService) SetEnforcementPolicy(policy *EnforcementPolicy) {
 	s.enforcementService.SetEnforcementPolicy(policy)
 }
 
+// ConnectionTracker returns the tracker that adapters report established connections
+// to, so that invariant enforcement can close them.
+func (s *IdentityService) ConnectionTracker() ports.ConnectionTrackerPort {
+	return s.connectionRegistry
+}
+
+// cacheSettings returns the certificate and bundle cache TTL and the proactive
+// refresh threshold for cache, falling back to the defaults for unset values.
+func cacheSettings(cache *ports.CacheConfig) (ttl, refreshThreshold time.Duration) {
+	ttl = 30 * time.Minute              // Default: half of a typical 1-hour SPIFFE cert lifetime
+	refreshThreshold = 10 * time.Minute // Default: 10 minutes before expiry
+	if cache != nil && cache.TTLMinutes > 0 {
+		ttl = time.Duration(cache.TTLMinutes) * time.Minute
+	}
+	if cache != nil && cache.ProactiveRefreshMinutes > 0 {
+		refreshThreshold = time.Duration(cache.ProactiveRefreshMinutes) * time.Minute
+	}
+	return ttl, refreshThreshold
+}
+
+// SetCacheConfig applies new cache settings at runtime. The cached certificate
+// and trust bundle are fetched again on next use so the new TTL takes effect.
+func (s *IdentityService) SetCacheConfig(cache *ports.CacheConfig) {
+	ttl, refreshThreshold := cacheSettings(cache)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cacheTTL = ttl
+	s.refreshThreshold = refreshThreshold
+	s.certCacheEntry = nil
+	s.bundleCacheEntry = nil
+}
+
+// SetRevocationList makes the default certificate validator reject this service's
+// own certificate once it is revoked and, with enforceExisting, closes established
+// connections to revoked peers. New handshakes are checked by the transport.
+// Call it before the service is used.
+func (s *IdentityService) SetRevocationList(list ports.RevocationListPort, enforceExisting bool) {
+	s.mu.Lock()
+	if s.defaultValidator {
+		s.validator = adapters.NewDefaultCertValidator(adapters.WithRevocationList(list))
+	}
+	s.mu.Unlock()
+
+	if enforceExisting {
+		s.enforcementService.EnforceRevocation(list)
+	}
+}
+
+// SetMetrics replaces the metrics reporter given to NewIdentityService and counts
+// connection certificate rotations with it. Call it once, before the service is used.
+func (s *IdentityService) SetMetrics(metrics MetricsReporter) {
+	if metrics == nil {
+		return
+	}
+	s.mu.Lock()
+	s.metrics = metrics
+	s.mu.Unlock()
+
+	s.connectionRegistry.AddRotationObserver(NewMetricsRotationObserver(metrics))
+}
+
+// SetLogger replaces the default logger of the service and of its connection
+// tracking, enforcement and rotation. Call it before the service is used.
+func (s *IdentityService) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		return
+	}
+	s.mu.Lock()
+	s.logger = logger
+	s.logObserver.logger = logger
+	s.mu.Unlock()
+
+	s.connectionRegistry.SetLogger(logger)
+	s.enforcementService.SetLogger(logger)
+	s.continuityService.SetLogger(logger)
+}
+
+// SetAuditRecorder sets the recorder of connections closed by invariant enforcement.
+func (s *IdentityService) SetAuditRecorder(audit ports.AuditRecorderPort) {
+	s.enforcementService.SetAuditRecorder(audit)
+}
+
 // == ROTATION CONTINUITY MANAGEMENT ==
 
 // RotateServerWithContinuity performs server rotation with zero downtime
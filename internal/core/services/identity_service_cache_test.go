@@ -12,6 +12,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -35,28 +39,24 @@ func (m *CacheMockIdentityProvider) GetCertificate() (*domain.Certificate, error
 	return args.Get(0).(*domain.Certificate), args.Error(1)
 }
 
-func (m *CacheMockIdentityProvider) GetTrustBundle() (*domain.TrustBundle, error) {
+func (m *CacheMockIdentityProvider) GetTrustBundle() (*x509bundle.Bundle, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.TrustBundle), args.Error(1)
+	return args.Get(0).(*x509bundle.Bundle), args.Error(1)
 }
 
-func (m *CacheMockIdentityProvider) GetServiceIdentity() (*domain.ServiceIdentity, error) {
-	args := m.Called()
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*domain.ServiceIdentity), args.Error(1)
+func (m *CacheMockIdentityProvider) GetServiceIdentity() (spiffeid.ID, error) {
+	return spiffeid.RequireFromString("spiffe://example.com/test-service"), nil
 }
 
-func (m *CacheMockIdentityProvider) GetIdentityDocument() (*domain.IdentityDocument, error) {
+func (m *CacheMockIdentityProvider) GetSVID() (*x509svid.SVID, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*domain.IdentityDocument), args.Error(1)
+	return args.Get(0).(*x509svid.SVID), args.Error(1)
 }
 
 func (m *CacheMockIdentityProvider) Close() error {
@@ -104,6 +104,11 @@ func createTestCertificate(notBefore, notAfter time.Time, withSPIFFE bool) (*dom
 	if withSPIFFE {
 		spiffeURI, _ := url.Parse("spiffe://example.com/test-service")
 		template.URIs = []*url.URL{spiffeURI}
+	} else {
+		// Without a SPIFFE ID the certificate serves as a trust anchor
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
 	}
 
 	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
@@ -235,6 +240,42 @@ func TestCertificateCacheExpiry(t *testing.T) {
 	}
 }
 
+// TestSetCacheConfig tests that new cache settings apply to the next fetch
+func TestSetCacheConfig(t *testing.T) {
+	mockProvider := new(CacheMockIdentityProvider)
+	mockTransport := new(CacheMockTransportProvider)
+
+	// Valid for 20 minutes: outside the default refresh threshold, inside 30 minutes
+	cert, err := createTestCertificate(time.Now().Add(-time.Hour), time.Now().Add(20*time.Minute), true)
+	require.NoError(t, err)
+	mockProvider.On("GetCertificate").Return(cert, nil)
+
+	serviceName, _ := domain.NewServiceName("test-service")
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   serviceName,
+			Domain: "example.com",
+		},
+	}
+	service, err := services.NewIdentityService(mockProvider, mockTransport, config, nil, nil)
+	require.NoError(t, err)
+
+	_, err = service.GetCertificate()
+	require.NoError(t, err)
+	_, err = service.GetCertificate()
+	require.NoError(t, err)
+	mockProvider.AssertNumberOfCalls(t, "GetCertificate", 1)
+
+	// The cached certificate is dropped, and now expires within the refresh threshold
+	service.SetCacheConfig(&ports.CacheConfig{TTLMinutes: 5, ProactiveRefreshMinutes: 30})
+	_, err = service.GetCertificate()
+	require.NoError(t, err)
+	mockProvider.AssertNumberOfCalls(t, "GetCertificate", 2)
+	_, err = service.GetCertificate()
+	require.NoError(t, err)
+	mockProvider.AssertNumberOfCalls(t, "GetCertificate", 3)
+}
+
 // TestConcurrentCacheAccess tests thread safety of cache operations
 func TestConcurrentCacheAccess(t *testing.T) {
 	// Setup mocks
@@ -257,8 +298,10 @@ func TestConcurrentCacheAccess(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	trustBundle, err := domain.NewTrustBundle([]*x509.Certificate{rootCert.Cert})
-	require.NoError(t, err)
+	trustBundle := x509bundle.FromX509Authorities(
+		spiffeid.RequireTrustDomainFromString("example.com"),
+		[]*x509.Certificate{rootCert.Cert},
+	)
 
 	// Setup mock to return certificate and trust bundle
 	mockProvider.On("GetCertificate").Return(cert, nil)
@@ -444,7 +487,8 @@ func TestCacheMetricsAccuracy(t *testing.T) {
 	}
 
 	// Create identity service with metrics
-	metrics := metricsadapter.NewPrometheusMetrics()
+	metrics, err := metricsadapter.NewPrometheusMetrics(metricsadapter.Config{Registerer: prometheus.NewRegistry()})
+	require.NoError(t, err)
 	service, err := services.NewIdentityService(
 		mockProvider,
 		mockTransport,
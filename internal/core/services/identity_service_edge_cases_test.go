@@ -1,6 +1,7 @@
 package services_test
 
 import (
+	"crypto/x509"
 	"fmt"
 	"io"
 	"sync"
@@ -8,6 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
 	metricsadapter "github.com/sufield/ephemos/internal/adapters/metrics"
 	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
@@ -28,7 +34,10 @@ func TestIdentityService_CacheMetrics_EdgeCases(t *testing.T) {
 	mockTransport := &MockTransportProvider{}
 
 	// Create service with Prometheus metrics for testing
-	metrics := metricsadapter.NewPrometheusMetrics()
+	metrics, err := metricsadapter.NewPrometheusMetrics(metricsadapter.Config{Registerer: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
 	service, err := services.NewIdentityService(mockProvider, mockTransport, config, nil, metrics)
 	if err != nil {
 		t.Fatalf("Failed to create IdentityService: %v", err)
@@ -353,24 +362,43 @@ func TestIdentityService_ValidationFailures_EdgeCases(t *testing.T) {
 // Mock implementations for testing
 type MockIdentityProvider struct{}
 
-func (m *MockIdentityProvider) GetServiceIdentity() (*domain.ServiceIdentity, error) {
-	return domain.NewServiceIdentity("test-service", "example.com"), nil
+var (
+	mockCertOnce sync.Once
+	mockCert     *domain.Certificate
+	mockRoot     *domain.Certificate
+	mockCertErr  error
+)
+
+func initMockCertificates() {
+	validFrom, validUntil := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	if mockCert, mockCertErr = createTestCertificate(validFrom, validUntil, true); mockCertErr != nil {
+		return
+	}
+	mockRoot, mockCertErr = createTestCertificate(validFrom, validUntil, false)
+}
+
+func (m *MockIdentityProvider) GetServiceIdentity() (spiffeid.ID, error) {
+	return spiffeid.RequireFromString("spiffe://example.com/test-service"), nil
 }
 
 func (m *MockIdentityProvider) GetCertificate() (*domain.Certificate, error) {
-	// Return an error to simulate the expected behavior during concurrent testing
-	// This prevents the service from hanging trying to validate nil certificates
-	return nil, fmt.Errorf("mock certificate error for testing")
+	// A valid certificate keeps concurrent callers on the cache instead of
+	// sleeping through the retry backoff one after another
+	mockCertOnce.Do(initMockCertificates)
+	return mockCert, mockCertErr
 }
 
-func (m *MockIdentityProvider) GetTrustBundle() (*domain.TrustBundle, error) {
-	// Return an error to simulate the expected behavior during concurrent testing
-	// This prevents the service from hanging trying to validate nil trust bundles
-	return nil, fmt.Errorf("mock trust bundle error for testing")
+func (m *MockIdentityProvider) GetTrustBundle() (*x509bundle.Bundle, error) {
+	mockCertOnce.Do(initMockCertificates)
+	if mockCertErr != nil {
+		return nil, mockCertErr
+	}
+	return x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString("example.com"),
+		[]*x509.Certificate{mockRoot.Cert}), nil
 }
 
-func (m *MockIdentityProvider) GetIdentityDocument() (*domain.IdentityDocument, error) {
-	return nil, fmt.Errorf("mock identity document error for testing")
+func (m *MockIdentityProvider) GetSVID() (*x509svid.SVID, error) {
+	return nil, fmt.Errorf("mock SVID error for testing")
 }
 
 func (m *MockIdentityProvider) Close() error {
@@ -393,7 +421,7 @@ func (m *MockServerPort) RegisterService(registrar ports.ServiceRegistrarPort) e
 	return nil
 }
 
-func (m *MockServerPort) Start(listener ports.NetworkListener) error {
+func (m *MockServerPort) Start(listener ports.NetworkListenerPort) error {
 	return nil
 }
 
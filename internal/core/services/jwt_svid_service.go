@@ -0,0 +1,289 @@
+// Package services provides core business logic services.
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// jwtCacheType is the cache type label used for JWT-SVID cache metrics.
+const jwtCacheType = "jwt_svid"
+
+// DefaultJWTFetchTimeout bounds a shared JWT-SVID fetch, which does not end when
+// one of its callers gives up.
+const DefaultJWTFetchTimeout = 30 * time.Second
+
+// JWTSVIDService fetches, caches and validates JWT-SVIDs.
+//
+// Fetched tokens are cached per audience set and served from the cache until
+// the refresh policy says they are close to expiry. Concurrent fetches for the
+// same audience set share one provider call, and a slow fetch for one audience
+// set does not hold up the others. Incoming tokens are
+// validated against the JWT bundle of the trust domain named in their subject.
+type JWTSVIDService struct {
+	svidProvider   ports.JWTSVIDProviderPort
+	bundleProvider ports.JWTBundleProviderPort
+	policy         domain.JWTRefreshPolicy
+	metrics        MetricsReporter
+	logger         *slog.Logger
+	now            func() time.Time
+	fetchTimeout   time.Duration
+
+	fetches singleflight.Group
+
+	mu         sync.Mutex
+	cache      map[string]*cachedJWTSVID
+	generation uint64 // incremented by InvalidateCache
+}
+
+// cachedJWTSVID is a JWT-SVID together with the time it was fetched.
+type cachedJWTSVID struct {
+	svid      *jwtsvid.SVID
+	fetchedAt time.Time
+}
+
+// JWTSVIDServiceOption configures a JWTSVIDService.
+type JWTSVIDServiceOption func(*JWTSVIDService)
+
+// WithJWTRefreshPolicy overrides the default JWT-SVID refresh policy.
+func WithJWTRefreshPolicy(policy domain.JWTRefreshPolicy) JWTSVIDServiceOption {
+	return func(s *JWTSVIDService) {
+		s.policy = policy
+	}
+}
+
+// WithJWTMetrics sets the metrics reporter used for cache hits and misses.
+func WithJWTMetrics(metrics MetricsReporter) JWTSVIDServiceOption {
+	return func(s *JWTSVIDService) {
+		if metrics != nil {
+			s.metrics = metrics
+		}
+	}
+}
+
+// WithJWTLogger sets the logger used by the service.
+func WithJWTLogger(logger *slog.Logger) JWTSVIDServiceOption {
+	return func(s *JWTSVIDService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithJWTFetchTimeout overrides the default timeout of a shared JWT-SVID fetch.
+func WithJWTFetchTimeout(timeout time.Duration) JWTSVIDServiceOption {
+	return func(s *JWTSVIDService) {
+		if timeout > 0 {
+			s.fetchTimeout = timeout
+		}
+	}
+}
+
+// NewJWTSVIDService creates a JWT-SVID service.
+// Either provider may be nil if the service is only used for fetching or only
+// for validation; the corresponding operations then return an error.
+func NewJWTSVIDService(
+	svidProvider ports.JWTSVIDProviderPort,
+	bundleProvider ports.JWTBundleProviderPort,
+	opts ...JWTSVIDServiceOption,
+) (*JWTSVIDService, error) {
+	if svidProvider == nil && bundleProvider == nil {
+		return nil, fmt.Errorf("at least one of JWT-SVID provider or JWT bundle provider is required")
+	}
+
+	s := &JWTSVIDService{
+		svidProvider:   svidProvider,
+		bundleProvider: bundleProvider,
+		policy:         domain.DefaultJWTRefreshPolicy(),
+		metrics:        &NoOpMetrics{},
+		logger:         slog.Default(),
+		now:            time.Now,
+		fetchTimeout:   DefaultJWTFetchTimeout,
+		cache:          make(map[string]*cachedJWTSVID),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// FetchJWTSVID returns a JWT-SVID for the given audiences.
+// A cached token is returned while it is still fresh according to the refresh policy.
+func (s *JWTSVIDService) FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error) {
+	if s.svidProvider == nil {
+		return nil, fmt.Errorf("no JWT-SVID provider configured")
+	}
+	if strings.TrimSpace(audience) == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+
+	key := jwtCacheKey(audience, extraAudiences)
+	if svid, ok := s.cached(key); ok {
+		s.metrics.RecordCacheHit(jwtCacheType)
+		return svid, nil
+	}
+	s.metrics.RecordCacheMiss(jwtCacheType)
+
+	// The fetch is shared by every caller waiting on key, so it must not be
+	// cancelled by the caller that happened to start it. Each caller stops
+	// waiting when its own context ends.
+	result := s.fetches.DoChan(key, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.fetchTimeout)
+		defer cancel()
+		return s.fetch(fetchCtx, key, audience, extraAudiences)
+	})
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*jwtsvid.SVID), nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to fetch JWT-SVID for audience %q: %w", audience, ctx.Err())
+	}
+}
+
+// cached returns the cached JWT-SVID for key if it is still fresh.
+func (s *JWTSVIDService) cached(key string) (*jwtsvid.SVID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if s.policy.NeedsRefreshAt(entry.fetchedAt, entry.svid.Expiry, s.now()) {
+		delete(s.cache, key)
+		return nil, false
+	}
+	return entry.svid, true
+}
+
+// fetch fetches a JWT-SVID from the provider and caches it. The lock is not
+// held during the call, so other audience sets are served meanwhile.
+func (s *JWTSVIDService) fetch(ctx context.Context, key, audience string, extraAudiences []string) (*jwtsvid.SVID, error) {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	fetchedAt := s.now()
+	svid, err := s.svidProvider.FetchJWTSVID(ctx, audience, extraAudiences...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWT-SVID for audience %q: %w", audience, err)
+	}
+
+	s.mu.Lock()
+	// A token fetched before the cache was invalidated is returned but not kept
+	if s.generation == generation {
+		s.cache[key] = &cachedJWTSVID{svid: svid, fetchedAt: fetchedAt}
+	}
+	s.mu.Unlock()
+
+	s.logger.Debug("JWT-SVID fetched",
+		"spiffe_id", svid.ID.String(),
+		"audience", svid.Audience,
+		"expires_at", svid.Expiry)
+
+	return svid, nil
+}
+
+// ValidateJWTSVID parses and validates a JWT-SVID token for the given audience.
+// The signature is verified against the JWT bundle of the token's trust domain.
+func (s *JWTSVIDService) ValidateJWTSVID(ctx context.Context, token, audience string) (*domain.JWTClaims, error) {
+	if s.bundleProvider == nil {
+		return nil, fmt.Errorf("no JWT bundle provider configured")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("token cannot be empty")
+	}
+	if strings.TrimSpace(audience) == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+
+	source := &jwtBundleSource{ctx: ctx, provider: s.bundleProvider}
+	svid, err := jwtsvid.ParseAndValidate(token, source, []string{audience})
+	if err != nil {
+		s.metrics.RecordValidation(false)
+		return nil, fmt.Errorf("JWT-SVID validation failed: %w", err)
+	}
+	s.metrics.RecordValidation(true)
+
+	return ClaimsFromJWTSVID(svid), nil
+}
+
+// InvalidateCache drops all cached JWT-SVIDs, forcing the next fetch to hit the provider.
+func (s *JWTSVIDService) InvalidateCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]*cachedJWTSVID)
+	s.generation++
+}
+
+// Close releases the underlying providers.
+func (s *JWTSVIDService) Close() error {
+	s.InvalidateCache()
+
+	var firstErr error
+	if s.svidProvider != nil {
+		if err := s.svidProvider.Close(); err != nil {
+			firstErr = fmt.Errorf("failed to close JWT-SVID provider: %w", err)
+		}
+	}
+	// Avoid closing the same adapter twice when it implements both ports.
+	if s.bundleProvider != nil && any(s.bundleProvider) != any(s.svidProvider) {
+		if err := s.bundleProvider.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close JWT bundle provider: %w", err)
+		}
+	}
+	return firstErr
+}
+
+// ClaimsFromJWTSVID converts a go-spiffe JWT-SVID into domain claims.
+func ClaimsFromJWTSVID(svid *jwtsvid.SVID) *domain.JWTClaims {
+	if svid == nil {
+		return nil
+	}
+
+	claims := &domain.JWTClaims{
+		Subject:   svid.ID.String(),
+		Audience:  append([]string(nil), svid.Audience...),
+		ExpiresAt: svid.Expiry,
+		Claims:    svid.Claims,
+	}
+	if iat, ok := svid.Claims["iat"].(float64); ok {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	return claims
+}
+
+// jwtCacheKey builds an order-independent cache key for an audience set.
+func jwtCacheKey(audience string, extraAudiences []string) string {
+	extras := append([]string(nil), extraAudiences...)
+	sort.Strings(extras)
+	return strings.Join(append([]string{audience}, extras...), "\x00")
+}
+
+// jwtBundleSource adapts JWTBundleProviderPort to jwtbundle.Source.
+type jwtBundleSource struct {
+	ctx      context.Context
+	provider ports.JWTBundleProviderPort
+}
+
+// GetJWTBundleForTrustDomain implements jwtbundle.Source.
+func (b *jwtBundleSource) GetJWTBundleForTrustDomain(td spiffeid.TrustDomain) (*jwtbundle.Bundle, error) {
+	return b.provider.GetJWTBundleForTrustDomain(b.ctx, td)
+}
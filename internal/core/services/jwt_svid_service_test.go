@@ -0,0 +1,167 @@
+package services_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// gatedJWTProvider holds fetches for gated audiences until they are released.
+type gatedJWTProvider struct {
+	*memidentity.JWTProvider
+
+	mu    sync.Mutex
+	gates map[string]chan struct{}
+}
+
+func (p *gatedJWTProvider) gate(audience string) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	gate := make(chan struct{})
+	p.gates[audience] = gate
+	return gate
+}
+
+func (p *gatedJWTProvider) FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error) {
+	p.mu.Lock()
+	gate := p.gates[audience]
+	p.mu.Unlock()
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.JWTProvider.FetchJWTSVID(ctx, audience, extraAudiences...)
+}
+
+func newGatedJWTService(t *testing.T) (*services.JWTSVIDService, *gatedJWTProvider) {
+	t.Helper()
+	inner, err := memidentity.NewJWTProvider(spiffeid.RequireFromString("spiffe://example.org/client"))
+	require.NoError(t, err)
+	provider := &gatedJWTProvider{JWTProvider: inner, gates: make(map[string]chan struct{})}
+
+	service, err := services.NewJWTSVIDService(provider, nil)
+	require.NoError(t, err)
+	return service, provider
+}
+
+func TestJWTSVIDService_FetchDoesNotBlockOtherAudiences(t *testing.T) {
+	service, provider := newGatedJWTService(t)
+	gate := provider.gate("slow")
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := service.FetchJWTSVID(context.Background(), "slow")
+		slow <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	svid, err := service.FetchJWTSVID(ctx, "fast")
+	require.NoError(t, err, "a fetch for another audience waits for the slow one")
+	assert.Equal(t, []string{"fast"}, svid.Audience)
+
+	close(gate)
+	require.NoError(t, <-slow)
+}
+
+func TestJWTSVIDService_ConcurrentFetchesShareOneCall(t *testing.T) {
+	service, provider := newGatedJWTService(t)
+	gate := provider.gate("backend")
+
+	const callers = 10
+	results := make(chan *jwtsvid.SVID, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svid, err := service.FetchJWTSVID(context.Background(), "backend")
+			assert.NoError(t, err)
+			results <- svid
+		}()
+	}
+
+	// Let the callers pile up on the gated fetch before releasing it
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	close(results)
+
+	first := <-results
+	for svid := range results {
+		assert.Same(t, first, svid)
+	}
+	assert.Equal(t, 1, provider.FetchCount())
+
+	// The shared result is cached
+	_, err := service.FetchJWTSVID(context.Background(), "backend")
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.FetchCount())
+}
+
+func TestJWTSVIDService_WaitingFetchHonoursContext(t *testing.T) {
+	service, provider := newGatedJWTService(t)
+	gate := provider.gate("backend")
+	defer close(gate)
+
+	go func() { _, _ = service.FetchJWTSVID(context.Background(), "backend") }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := service.FetchJWTSVID(ctx, "backend")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestJWTSVIDService_CancelledCallerDoesNotFailSharedFetch(t *testing.T) {
+	service, provider := newGatedJWTService(t)
+	gate := provider.gate("backend")
+
+	// The first caller starts the shared fetch and gives up
+	first, cancel := context.WithCancel(context.Background())
+	started := make(chan error, 1)
+	go func() {
+		_, err := service.FetchJWTSVID(first, "backend")
+		started <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := service.FetchJWTSVID(context.Background(), "backend")
+		waiting <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-started, context.Canceled)
+
+	close(gate)
+	require.NoError(t, <-waiting, "the other caller gets the first caller's cancellation")
+	assert.Equal(t, 1, provider.FetchCount())
+}
+
+func TestJWTSVIDService_SharedFetchIsBounded(t *testing.T) {
+	inner, err := memidentity.NewJWTProvider(spiffeid.RequireFromString("spiffe://example.org/client"))
+	require.NoError(t, err)
+	provider := &gatedJWTProvider{JWTProvider: inner, gates: make(map[string]chan struct{})}
+	gate := provider.gate("backend")
+	defer close(gate)
+
+	service, err := services.NewJWTSVIDService(provider, nil, services.WithJWTFetchTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = service.FetchJWTSVID(context.Background(), "backend")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -1,8 +1,19 @@
 // Package services provides core business logic services.
 package services
 
+import (
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
 // MetricsReporter defines contract for reporting identity service metrics.
 type MetricsReporter interface {
+	ports.MTLSMetricsPort
+
 	RecordCacheHit(cacheType string)
 	RecordCacheMiss(cacheType string)
 	RecordRefresh(reason string, duration float64)
@@ -31,3 +42,47 @@ func (m *NoOpMetrics) RecordValidation(success bool) {}
 
 // RecordRetry no-op implementation.
 func (m *NoOpMetrics) RecordRetry(providerType string, attempt int) {}
+
+// RecordHandshake no-op implementation.
+func (m *NoOpMetrics) RecordHandshake(side, reason string, duration time.Duration) {}
+
+// RecordConnectionOpened no-op implementation.
+func (m *NoOpMetrics) RecordConnectionOpened(trustDomain string) {}
+
+// RecordConnectionClosed no-op implementation.
+func (m *NoOpMetrics) RecordConnectionClosed(trustDomain string) {}
+
+// RecordPeerSVIDLifetime no-op implementation.
+func (m *NoOpMetrics) RecordPeerSVIDLifetime(remaining time.Duration) {}
+
+// RecordSVIDExpiry no-op implementation.
+func (m *NoOpMetrics) RecordSVIDExpiry(id spiffeid.ID, expiry time.Time) {}
+
+// RecordRotation no-op implementation.
+func (m *NoOpMetrics) RecordRotation(outcome string) {}
+
+// RecordAuthorization no-op implementation.
+func (m *NoOpMetrics) RecordAuthorization(rule string, allowed bool) {}
+
+// MetricsRotationObserver counts connection certificate rotations by outcome.
+type MetricsRotationObserver struct {
+	metrics ports.MTLSMetricsPort
+}
+
+// NewMetricsRotationObserver creates a rotation observer reporting to metrics.
+func NewMetricsRotationObserver(metrics ports.MTLSMetricsPort) *MetricsRotationObserver {
+	return &MetricsRotationObserver{metrics: metrics}
+}
+
+// OnRotationStarted is a no-op; rotations are counted once they end.
+func (o *MetricsRotationObserver) OnRotationStarted(connID, reason string) {}
+
+// OnRotationCompleted counts a successful rotation.
+func (o *MetricsRotationObserver) OnRotationCompleted(connID string, oldCert, newCert *domain.Certificate) {
+	o.metrics.RecordRotation(ports.RotationOutcomeSuccess)
+}
+
+// OnRotationFailed counts a failed rotation.
+func (o *MetricsRotationObserver) OnRotationFailed(connID string, err error) {
+	o.metrics.RecordRotation(ports.RotationOutcomeFailure)
+}
@@ -4,12 +4,16 @@ package services
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
 	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 // ConnectionState represents the state of an mTLS connection
@@ -48,7 +52,13 @@ type MTLSConnection struct {
 	LastRotated    time.Time
 	Cert           *domain.Certificate
 	TLSState       *tls.ConnectionState
-	mu             sync.RWMutex
+	// Transport and RemoteAddr are set for connections reported by a transport.
+	Transport  string
+	RemoteAddr string
+
+	closer     func() error // closes the underlying connection; nil for records only
+	violations int          // invariant failures over consecutive checks
+	mu         sync.RWMutex
 }
 
 // GetState safely returns the current connection state
@@ -99,7 +109,9 @@ func (c *MTLSConnection) GetExpiryInfo() (time.Time, bool) {
 	return c.Cert.ExpiresAt(), c.Cert.IsExpiringWithin(time.Hour)
 }
 
-// MTLSConnectionRegistry tracks and maintains active mTLS connections with rotation support
+// MTLSConnectionRegistry tracks and maintains active mTLS connections with rotation support.
+// It implements ports.ConnectionTrackerPort, so transports can report the connections
+// they accept and dial; closing such a connection closes the underlying network connection.
 type MTLSConnectionRegistry struct {
 	identityService *IdentityService
 	connections     map[string]*MTLSConnection
@@ -109,6 +121,8 @@ type MTLSConnectionRegistry struct {
 	mu              sync.RWMutex
 }
 
+var _ ports.ConnectionTrackerPort = (*MTLSConnectionRegistry)(nil)
+
 // RotationPolicy defines when and how certificate rotation occurs
 type RotationPolicy struct {
 	// PreRotationThreshold triggers rotation before certificate expiry
@@ -156,6 +170,13 @@ func (r *MTLSConnectionRegistry) SetRotationPolicy(policy *RotationPolicy) {
 	r.rotationPolicy = policy
 }
 
+// SetLogger replaces the default logger. Call it before connections are tracked.
+func (r *MTLSConnectionRegistry) SetLogger(logger *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logger = logger
+}
+
 // AddRotationObserver adds an observer for rotation events
 func (r *MTLSConnectionRegistry) AddRotationObserver(observer RotationObserver) {
 	r.mu.Lock()
@@ -195,6 +216,92 @@ func (r *MTLSConnectionRegistry) EstablishConnection(ctx context.Context, connID
 	return conn, nil
 }
 
+// TrackConnection registers a live connection reported by a transport. The record is
+// built from the completed handshake: the remote identity from the peer's SPIFFE ID
+// and the certificate from the leaf the local side presented. Tracked connections
+// are not rotated; a connection whose certificates expire fails the invariants and
+// is closed by the enforcement service. Connections without a peer certificate
+// cannot be checked and are rejected.
+func (r *MTLSConnectionRegistry) TrackConnection(tracked ports.TrackedConnection) (func(), error) {
+	if len(tracked.State.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("connection %s: peer presented no certificate", tracked.ID)
+	}
+	if tracked.LocalCertificate == nil {
+		return nil, fmt.Errorf("connection %s: local certificate is required", tracked.ID)
+	}
+
+	peer := tracked.State.PeerCertificates[0]
+	remoteIdentity, err := identityFromCertificate(peer)
+	if err != nil {
+		return nil, fmt.Errorf("connection %s: peer: %w", tracked.ID, err)
+	}
+	localIdentity, err := identityFromCertificate(tracked.LocalCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("connection %s: local: %w", tracked.ID, err)
+	}
+	cert, err := domain.NewCertificateWithValidation(tracked.LocalCertificate, nil, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("connection %s: %w", tracked.ID, err)
+	}
+
+	state := tracked.State
+	now := time.Now()
+	conn := &MTLSConnection{
+		ID:             tracked.ID,
+		RemoteIdentity: remoteIdentity,
+		LocalIdentity:  localIdentity,
+		State:          ConnectionActive,
+		EstablishedAt:  now,
+		LastRotated:    now,
+		Cert:           cert,
+		TLSState:       &state,
+		Transport:      tracked.Transport,
+		RemoteAddr:     tracked.RemoteAddr,
+		closer:         tracked.Close,
+	}
+
+	r.mu.Lock()
+	r.connections[conn.ID] = conn
+	r.mu.Unlock()
+
+	r.logger.Debug("mTLS connection tracked",
+		"connection_id", conn.ID,
+		"transport", conn.Transport,
+		"remote_identity", remoteIdentity.URI(),
+		"peer_cert_expires", peer.NotAfter,
+	)
+
+	return func() { r.untrackConnection(conn) }, nil
+}
+
+// untrackConnection removes a tracked connection whose network connection ended.
+func (r *MTLSConnectionRegistry) untrackConnection(conn *MTLSConnection) {
+	r.mu.Lock()
+	if r.connections[conn.ID] == conn {
+		delete(r.connections, conn.ID)
+	}
+	r.mu.Unlock()
+	conn.SetState(ConnectionClosed)
+}
+
+// identityFromCertificate returns the identity of the certificate's SPIFFE ID.
+func identityFromCertificate(cert *x509.Certificate) (*domain.ServiceIdentity, error) {
+	for _, uri := range cert.URIs {
+		if uri.Scheme != "spiffe" {
+			continue
+		}
+		id, err := spiffeid.FromURI(uri)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE ID: %w", err)
+		}
+		if _, err := domain.NewTrustDomain(id.TrustDomain().String()); err != nil {
+			return nil, fmt.Errorf("invalid trust domain: %w", err)
+		}
+		return domain.NewServiceIdentityFromSPIFFEID(id), nil
+	}
+	return nil, fmt.Errorf("certificate has no SPIFFE ID")
+}
+
 // GetConnection retrieves an active connection by ID
 func (r *MTLSConnectionRegistry) GetConnection(connID string) (*MTLSConnection, bool) {
 	r.mu.RLock()
@@ -215,7 +322,8 @@ func (r *MTLSConnectionRegistry) ListConnections() []*MTLSConnection {
 	return connections
 }
 
-// CloseConnection closes and removes a connection
+// CloseConnection closes and removes a connection. Connections reported by a
+// transport are closed on the network as well.
 func (r *MTLSConnectionRegistry) CloseConnection(connID string) error {
 	r.mu.Lock()
 	conn, exists := r.connections[connID]
@@ -225,14 +333,21 @@ func (r *MTLSConnectionRegistry) CloseConnection(connID string) error {
 	}
 	r.mu.Unlock()
 
-	if exists {
-		r.logger.Info("mTLS connection closed",
-			"connection_id", connID,
-			"local_identity", conn.LocalIdentity.Name(),
-			"remote_identity", conn.RemoteIdentity.Name(),
-		)
+	if !exists {
+		return nil
 	}
 
+	r.logger.Info("mTLS connection closed",
+		"connection_id", connID,
+		"local_identity", conn.LocalIdentity.Name(),
+		"remote_identity", conn.RemoteIdentity.Name(),
+	)
+
+	if conn.closer != nil {
+		if err := conn.closer(); err != nil {
+			return fmt.Errorf("failed to close connection %s: %w", connID, err)
+		}
+	}
 	return nil
 }
 
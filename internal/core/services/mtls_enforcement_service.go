@@ -5,10 +5,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/sufield/ephemos/internal/core/errors"
+	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 // MTLSInvariant represents a security invariant that must be enforced
@@ -21,12 +23,22 @@ type MTLSInvariant interface {
 	Description() string
 }
 
+// CriticalInvariant is implemented by invariants whose violation is acted on at the
+// first failed check instead of after MaxViolations.
+type CriticalInvariant interface {
+	MTLSInvariant
+	// Critical reports whether a single violation warrants the ViolationAction.
+	Critical() bool
+}
+
 // MTLSEnforcementService enforces mTLS invariants across all service communication
 type MTLSEnforcementService struct {
 	identityService    *IdentityService
 	connectionRegistry *MTLSConnectionRegistry
 	invariants         []MTLSInvariant
 	policy             *EnforcementPolicy
+	audit              ports.AuditRecorderPort
+	recheck            chan struct{} // requests a check before the next interval
 	logger             *slog.Logger
 	mu                 sync.RWMutex
 }
@@ -37,7 +49,8 @@ type EnforcementPolicy struct {
 	FailOnViolation bool
 	// CheckInterval defines how often invariants are checked
 	CheckInterval time.Duration
-	// MaxViolations before taking action
+	// MaxViolations is how many invariant failures a connection may accumulate over
+	// consecutive checks before ViolationAction is taken. A passing check resets the count.
 	MaxViolations int
 	// ViolationAction defines what to do when max violations is reached
 	ViolationAction ViolationAction
@@ -89,6 +102,7 @@ func NewMTLSEnforcementService(
 		connectionRegistry: connectionRegistry,
 		invariants:         make([]MTLSInvariant, 0),
 		policy:             DefaultEnforcementPolicy(),
+		recheck:            make(chan struct{}, 1),
 		logger:             slog.Default(),
 	}
 
@@ -105,6 +119,20 @@ func (s *MTLSEnforcementService) SetEnforcementPolicy(policy *EnforcementPolicy)
 	s.policy = policy
 }
 
+// SetAuditRecorder sets the recorder of connections closed for violations. May be nil.
+func (s *MTLSEnforcementService) SetAuditRecorder(audit ports.AuditRecorderPort) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = audit
+}
+
+// SetLogger replaces the default logger. Call it before enforcement starts.
+func (s *MTLSEnforcementService) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
 // AddInvariant adds a new invariant to be enforced
 func (s *MTLSEnforcementService) AddInvariant(invariant MTLSInvariant) {
 	s.mu.Lock()
@@ -147,10 +175,45 @@ func (s *MTLSEnforcementService) invariantCheckingLoop(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.checkAllInvariants(ctx)
+		case <-s.recheck:
+			s.checkAllInvariants(ctx)
 		}
 	}
 }
 
+// RequestCheck makes a running enforcement loop check all connections now rather
+// than at the next interval.
+func (s *MTLSEnforcementService) RequestCheck() {
+	select {
+	case s.recheck <- struct{}{}:
+	default:
+	}
+}
+
+// EnforceRevocation closes connections whose peer is on the revocation list at
+// the first check, and checks all connections again whenever the list changes.
+func (s *MTLSEnforcementService) EnforceRevocation(list ports.RevocationListPort) {
+	s.AddInvariant(&RevocationInvariant{List: list})
+
+	// The subscription ends when the list is closed
+	changes, _ := list.Subscribe()
+	go func() {
+		for range changes {
+			s.RequestCheck()
+		}
+	}()
+}
+
+// violation is an invariant a connection failed.
+type violation struct {
+	invariant string
+	err       error
+}
+
+func (v violation) String() string {
+	return fmt.Sprintf("%s: %s", v.invariant, v.err.Error())
+}
+
 // checkAllInvariants checks all invariants against all connections
 func (s *MTLSEnforcementService) checkAllInvariants(ctx context.Context) {
 	connections := s.connectionRegistry.ListConnections()
@@ -164,16 +227,17 @@ func (s *MTLSEnforcementService) checkAllInvariants(ctx context.Context) {
 	policy := s.policy
 	s.mu.RUnlock()
 
-	violations := make(map[string][]string) // connID -> violation list
+	violations := make(map[*MTLSConnection][]violation)
 
 	for _, conn := range connections {
+		var failed []violation
+		critical := false
 		for _, invariant := range invariants {
 			if err := invariant.Check(ctx, conn); err != nil {
-				if violations[conn.ID] == nil {
-					violations[conn.ID] = make([]string, 0)
+				failed = append(failed, violation{invariant: invariant.Name(), err: err})
+				if c, ok := invariant.(CriticalInvariant); ok && c.Critical() {
+					critical = true
 				}
-				violation := fmt.Sprintf("%s: %s", invariant.Name(), err.Error())
-				violations[conn.ID] = append(violations[conn.ID], violation)
 
 				s.logger.Warn("mTLS invariant violation",
 					"connection_id", conn.ID,
@@ -184,6 +248,19 @@ func (s *MTLSEnforcementService) checkAllInvariants(ctx context.Context) {
 				)
 			}
 		}
+
+		// Failures accumulate until a check passes
+		conn.mu.Lock()
+		if len(failed) == 0 {
+			conn.violations = 0
+		} else {
+			conn.violations += len(failed)
+			if critical {
+				conn.violations = max(conn.violations, policy.MaxViolations)
+			}
+			violations[conn] = failed
+		}
+		conn.mu.Unlock()
 	}
 
 	// Handle violations according to policy
@@ -191,12 +268,22 @@ func (s *MTLSEnforcementService) checkAllInvariants(ctx context.Context) {
 }
 
 // handleViolations processes invariant violations according to policy
-func (s *MTLSEnforcementService) handleViolations(ctx context.Context, violations map[string][]string, policy *EnforcementPolicy) {
-	for connID, violationList := range violations {
-		if len(violationList) >= policy.MaxViolations {
+func (s *MTLSEnforcementService) handleViolations(ctx context.Context, violations map[*MTLSConnection][]violation, policy *EnforcementPolicy) {
+	for conn, failed := range violations {
+		connID := conn.ID
+		violationList := make([]string, len(failed))
+		for i, v := range failed {
+			violationList[i] = v.String()
+		}
+
+		conn.mu.RLock()
+		violationCount := conn.violations
+		conn.mu.RUnlock()
+
+		if violationCount >= policy.MaxViolations {
 			s.logger.Error("maximum violations exceeded for connection",
 				"connection_id", connID,
-				"violation_count", len(violationList),
+				"violation_count", violationCount,
 				"max_violations", policy.MaxViolations,
 				"action", policy.ViolationAction.String(),
 			)
@@ -209,6 +296,7 @@ func (s *MTLSEnforcementService) handleViolations(ctx context.Context, violation
 						"error", err,
 					)
 				}
+				s.recordTermination(ctx, conn, failed)
 			case ActionLog:
 				// Already logged above
 			case ActionAlertOnly:
@@ -234,13 +322,57 @@ func (s *MTLSEnforcementService) handleViolations(ctx context.Context, violation
 			// Log violations but don't take action yet
 			s.logger.Error("mTLS invariant violations detected",
 				"connection_id", connID,
-				"violation_count", len(violationList),
+				"violation_count", violationCount,
 				"violations", violationList,
 			)
 		}
 	}
 }
 
+// recordTermination records a connection closed for failing invariants to the audit trail.
+func (s *MTLSEnforcementService) recordTermination(ctx context.Context, conn *MTLSConnection, failed []violation) {
+	s.mu.RLock()
+	audit := s.audit
+	s.mu.RUnlock()
+	if audit == nil {
+		return
+	}
+
+	reasons := make([]string, len(failed))
+	errorClass := ports.AuditErrorInvariantViolation
+	for i, v := range failed {
+		reasons[i] = v.String()
+		switch {
+		case v.invariant == revocationName:
+			errorClass = ports.AuditErrorRevoked
+		case v.invariant == certificateValidityName && errorClass != ports.AuditErrorRevoked:
+			errorClass = ports.AuditErrorExpired
+		}
+	}
+
+	event := ports.AuditEvent{
+		Kind:       ports.AuditKindTermination,
+		Decision:   ports.AuditDecisionDeny,
+		Transport:  conn.Transport,
+		RemoteAddr: conn.RemoteAddr,
+		Rule:       failed[0].invariant,
+		ErrorClass: errorClass,
+		Reason:     strings.Join(reasons, "; "),
+	}
+	if conn.RemoteIdentity != nil {
+		event.PeerID = conn.RemoteIdentity.URI()
+	}
+	if conn.LocalIdentity != nil {
+		event.LocalID = conn.LocalIdentity.URI()
+	}
+	if conn.TLSState != nil && len(conn.TLSState.PeerCertificates) > 0 {
+		leaf := conn.TLSState.PeerCertificates[0]
+		event.PeerSerial = leaf.SerialNumber.Text(16)
+		event.PeerExpiry = leaf.NotAfter
+	}
+	audit.RecordAuditEvent(ctx, event)
+}
+
 // ValidateConnection validates all invariants for a specific connection
 func (s *MTLSEnforcementService) ValidateConnection(ctx context.Context, connID string) error {
 	conn, exists := s.connectionRegistry.GetConnection(connID)
@@ -336,15 +468,18 @@ type InvariantResult struct {
 
 // == DEFAULT INVARIANTS ==
 
+// certificateValidityName is the name of CertificateValidityInvariant.
+const certificateValidityName = "certificate_validity"
+
 // CertificateValidityInvariant ensures certificates are valid and not expired
 type CertificateValidityInvariant struct{}
 
 func (i *CertificateValidityInvariant) Name() string {
-	return "certificate_validity"
+	return certificateValidityName
 }
 
 func (i *CertificateValidityInvariant) Description() string {
-	return "Ensures all certificates are valid and not expired"
+	return "Ensures all certificates, including the peer's, are valid and not expired"
 }
 
 func (i *CertificateValidityInvariant) Check(ctx context.Context, conn *MTLSConnection) error {
@@ -361,9 +496,49 @@ func (i *CertificateValidityInvariant) Check(ctx context.Context, conn *MTLSConn
 		return fmt.Errorf("certificate expired (not after: %s)", conn.Cert.Cert.NotAfter)
 	}
 
+	// The peer's chain was verified at the handshake, but stays in use until the connection closes
+	if conn.TLSState != nil {
+		for _, peer := range conn.TLSState.PeerCertificates {
+			if now.Before(peer.NotBefore) {
+				return fmt.Errorf("peer certificate not yet valid (not before: %s)", peer.NotBefore)
+			}
+			if now.After(peer.NotAfter) {
+				return fmt.Errorf("peer certificate expired (not after: %s)", peer.NotAfter)
+			}
+		}
+	}
+
 	return nil
 }
 
+// revocationName is the name of RevocationInvariant.
+const revocationName = "revocation"
+
+// RevocationInvariant ensures no connection stays open to a revoked peer. It is
+// critical: a revoked peer is disconnected at the first check.
+type RevocationInvariant struct {
+	List ports.RevocationListPort
+}
+
+func (i *RevocationInvariant) Name() string {
+	return revocationName
+}
+
+func (i *RevocationInvariant) Description() string {
+	return "Ensures the peer's certificate chain, key and SPIFFE ID are not revoked"
+}
+
+func (i *RevocationInvariant) Critical() bool {
+	return true
+}
+
+func (i *RevocationInvariant) Check(ctx context.Context, conn *MTLSConnection) error {
+	if i.List == nil || conn.TLSState == nil {
+		return nil
+	}
+	return i.List.CheckRevocation(conn.TLSState.PeerCertificates)
+}
+
 // MutualAuthInvariant ensures both client and server authenticate each other
 type MutualAuthInvariant struct{}
 
@@ -419,7 +594,9 @@ func (i *TrustDomainInvariant) Check(ctx context.Context, conn *MTLSConnection)
 	return nil
 }
 
-// CertificateRotationInvariant ensures certificates are rotated appropriately
+// CertificateRotationInvariant ensures certificates are rotated appropriately. It
+// skips connections reported by a transport: they are never rotated in place, and
+// CertificateValidityInvariant closes them once a certificate expires.
 type CertificateRotationInvariant struct{}
 
 func (i *CertificateRotationInvariant) Name() string {
@@ -431,6 +608,10 @@ func (i *CertificateRotationInvariant) Description() string {
 }
 
 func (i *CertificateRotationInvariant) Check(ctx context.Context, conn *MTLSConnection) error {
+	if conn.Transport != "" {
+		return nil
+	}
+
 	if conn.Cert == nil || conn.Cert.Cert == nil {
 		return fmt.Errorf("no certificate present")
 	}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// svidCertificate creates a self-signed certificate for id valid until notAfter.
+func svidCertificate(t *testing.T, id string, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		URIs:         []*url.URL{uri},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestMTLSEnforcementService_KeepsHealthyOldConnections(t *testing.T) {
+	registry := NewMTLSConnectionRegistry(nil)
+	enforcement := NewMTLSEnforcementService(nil, registry)
+
+	// The local SVID expires within minutes, but has not expired yet
+	local := svidCertificate(t, "spiffe://example.org/server", time.Now().Add(10*time.Minute))
+	remote := svidCertificate(t, "spiffe://example.org/client", time.Now().Add(time.Hour))
+	var closed atomic.Bool
+	_, err := registry.TrackConnection(ports.TrackedConnection{
+		ID:               "old",
+		Transport:        "grpc",
+		State:            tls.ConnectionState{HandshakeComplete: true, PeerCertificates: []*x509.Certificate{remote}},
+		LocalCertificate: local,
+		Close:            func() error { closed.Store(true); return nil },
+	})
+	require.NoError(t, err)
+
+	conn, ok := registry.GetConnection("old")
+	require.True(t, ok)
+	conn.mu.Lock()
+	conn.EstablishedAt = time.Now().Add(-2 * time.Hour)
+	conn.LastRotated = conn.EstablishedAt
+	conn.mu.Unlock()
+
+	for range DefaultEnforcementPolicy().MaxViolations + 1 {
+		enforcement.checkAllInvariants(context.Background())
+	}
+	assert.False(t, closed.Load(), "healthy connection older than an hour closed")
+	require.NoError(t, enforcement.ValidateConnection(context.Background(), "old"))
+}
+
+func TestCertificateRotationInvariant_SkipsTransportConnections(t *testing.T) {
+	invariant := &CertificateRotationInvariant{}
+	cert := &domain.Certificate{Cert: svidCertificate(t, "spiffe://example.org/server", time.Now().Add(time.Hour))}
+	old := time.Now().Add(-2 * time.Hour)
+
+	conn := &MTLSConnection{ID: "registered", Cert: cert, State: ConnectionActive, LastRotated: old}
+	assert.Error(t, invariant.Check(context.Background(), conn), "connection never rotated passed")
+
+	conn = &MTLSConnection{ID: "tracked", Transport: "grpc", Cert: cert, State: ConnectionActive, LastRotated: old}
+	assert.NoError(t, invariant.Check(context.Background(), conn))
+}
@@ -151,6 +151,13 @@ func (s *RotationContinuityService) SetContinuityPolicy(policy *ContinuityPolicy
 	s.continuityPolicy = policy
 }
 
+// SetLogger replaces the default logger. Call it before rotating.
+func (s *RotationContinuityService) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
 // RotateServerWithContinuity performs server rotation with zero downtime
 func (s *RotationContinuityService) RotateServerWithContinuity(ctx context.Context, serverID string, oldServer ports.ServerPort) error {
 	rotationID := fmt.Sprintf("%s-rotation-%d", serverID, time.Now().UnixNano())
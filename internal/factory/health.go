@@ -0,0 +1,139 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/adapters/primary/healthendpoint"
+	"github.com/sufield/ephemos/internal/adapters/secondary/health"
+	"github.com/sufield/ephemos/internal/core/application"
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// WithHealthEndpoint monitors the components selected by the health section and
+// serves the /livez, /readyz and /healthz probes on listener, a plain listener for
+// kubelet probes, while the server is serving. The grpc.health.v1.Health service
+// is registered on the server itself. Closing the server closes the listener.
+func WithHealthEndpoint(listener net.Listener) ServerOption {
+	return func(opts *serverOptions) {
+		opts.healthListener = listener
+	}
+}
+
+// healthEndpoint is the health use case of a server and the endpoint serving its
+// results.
+type healthEndpoint struct {
+	useCase    application.HealthUseCase
+	monitor    *services.HealthMonitorService
+	components ports.HealthComponentProvider
+	server     *healthendpoint.Server
+	listener   net.Listener
+	logger     *slog.Logger
+}
+
+// createHealthEndpoint creates the health use case with the checkers of the health
+// section, whose built-in checks inspect source and bundles, and the endpoint that
+// serves its results on listener.
+func createHealthEndpoint(
+	ctx context.Context,
+	cfg *ports.Configuration,
+	identityProvider ports.IdentityProvider,
+	transportProvider ports.TransportProvider,
+	sources health.BuiltinSources,
+	listener net.Listener,
+	logger *slog.Logger,
+) (*healthEndpoint, error) {
+	components := health.NewComponentProvider(sources, logger)
+	useCases, err := application.NewUseCaseFactory(cfg, identityProvider, transportProvider, nil,
+		application.WithHealthComponents(components),
+		application.WithLogger(logger))
+	if err != nil {
+		return nil, err
+	}
+	useCase, err := useCases.CreateHealthUseCase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	monitored, ok := useCase.(interface {
+		Monitor() *services.HealthMonitorService
+	})
+	if !ok {
+		_ = useCase.Close()
+		return nil, fmt.Errorf("health use case does not expose its monitor")
+	}
+	config := healthendpoint.Config{Monitor: monitored.Monitor(), Logger: logger}
+	if cfg.Health != nil {
+		config.Timeout = cfg.Health.Timeout
+	}
+	server, err := healthendpoint.New(config)
+	if err != nil {
+		_ = useCase.Close()
+		return nil, err
+	}
+	return &healthEndpoint{
+		useCase:    useCase,
+		monitor:    monitored.Monitor(),
+		components: components,
+		server:     server,
+		listener:   listener,
+		logger:     logger,
+	}, nil
+}
+
+// builtinSources returns what the built-in health checks of a server inspect.
+func builtinSources(cfg *ports.Configuration, source x509svid.Source, bundles x509bundle.Source) health.BuiltinSources {
+	sources := health.BuiltinSources{SVIDs: source, Bundles: bundles}
+	if td, err := spiffeid.TrustDomainFromString(cfg.Service.Domain); err == nil {
+		sources.TrustDomain = td
+	}
+	if cfg.Agent != nil {
+		sources.SocketPath = cfg.Agent.SocketPath.Value()
+	}
+	return sources
+}
+
+// serve monitors the components and serves the probes until ctx is cancelled.
+// Periodic checks only run when the health section is enabled.
+func (e *healthEndpoint) serve(ctx context.Context) error {
+	if err := e.useCase.StartMonitoring(ctx); err != nil {
+		return fmt.Errorf("failed to start health monitoring: %w", err)
+	}
+	defer func() {
+		_ = e.useCase.StopMonitoring(context.Background())
+	}()
+	return e.server.Serve(ctx, e.listener)
+}
+
+// reconfigure applies a changed health section: the checkers it selects, the
+// monitoring interval and timeout, and the probe timeout. A section whose
+// checkers cannot be created is logged and the previous one kept.
+func (e *healthEndpoint) reconfigure(config *ports.HealthConfig) {
+	if config == nil {
+		config = &ports.HealthConfig{}
+	}
+	checkers, err := e.components.CreateCheckers(config)
+	if err == nil {
+		err = e.monitor.Reconfigure(config, checkers)
+	}
+	if err != nil {
+		e.logger.Warn("Failed to apply health configuration change, keeping the previous one", "error", err)
+		return
+	}
+	e.server.SetTimeout(config.Timeout)
+}
+
+// close stops monitoring, closes the reporters and the listener.
+func (e *healthEndpoint) close() error {
+	err := e.useCase.Close()
+	// The listener is already closed if the probes were served
+	_ = e.listener.Close()
+	return err
+}
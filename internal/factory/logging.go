@@ -0,0 +1,39 @@
+package factory
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// Logger creates a logger at the configured level and in the configured format,
+// writing to w, or to standard error if w is nil. When neither the level nor the
+// format is configured, slog.Default() is returned so that the application's own
+// logging setup is kept.
+func Logger(cfg *ports.Configuration, w io.Writer) (*slog.Logger, error) {
+	level, format := cfg.LogLevel(), cfg.LogFormat()
+	if level.Source == ports.SettingSourceDefault && format.Source == ports.SettingSourceDefault {
+		return slog.Default(), nil
+	}
+
+	parsed, err := ports.ParseLogLevel(level.Value)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		w = os.Stderr
+	}
+
+	options := &slog.HandlerOptions{Level: parsed}
+	switch format.Value {
+	case ports.LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case ports.LogFormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q: must be text or json", format.Value)
+	}
+}
@@ -14,21 +14,51 @@ package factory
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+
+	"github.com/sufield/ephemos/internal/adapters/metrics"
 	"github.com/sufield/ephemos/internal/adapters/primary/api"
+	"github.com/sufield/ephemos/internal/adapters/primary/bundleendpoint"
 	"github.com/sufield/ephemos/internal/adapters/secondary/config"
+	"github.com/sufield/ephemos/internal/adapters/secondary/fileidentity"
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/adapters/secondary/revocation"
 	"github.com/sufield/ephemos/internal/adapters/secondary/spiffe"
 	"github.com/sufield/ephemos/internal/adapters/secondary/transport"
+	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
 )
 
+// DialerOption configures a dialer created by SPIFFEDialer.
+type DialerOption func(*dialerOptions)
+
+type dialerOptions struct {
+	metrics *metrics.PrometheusMetrics
+}
+
+// WithDialerMetrics reports the dialer's handshakes, connections and rotations to
+// metrics instead of the default Prometheus registry.
+func WithDialerMetrics(reporter *metrics.PrometheusMetrics) DialerOption {
+	return func(opts *dialerOptions) {
+		opts.metrics = reporter
+	}
+}
+
 // SPIFFEDialer creates a new SPIFFE/SPIRE-backed Dialer implementation.
 // The configuration must be valid and contain the necessary SPIFFE settings.
-func SPIFFEDialer(ctx context.Context, cfg *ports.Configuration) (ports.Dialer, error) {
+func SPIFFEDialer(ctx context.Context, cfg *ports.Configuration, opts ...DialerOption) (ports.DialerPort, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
@@ -37,25 +67,104 @@ func SPIFFEDialer(ctx context.Context, cfg *ports.Configuration) (ports.Dialer,
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	options := &dialerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	reporter := metricsReporter(options.metrics)
+
+	logger, err := Logger(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging configuration: %w", err)
+	}
+
+	trustDomain, err := spiffeid.TrustDomainFromString(cfg.Service.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust domain %q: %w", cfg.Service.Domain, err)
+	}
+
 	// Create identity provider
-	identityProvider, err := createIdentityProvider(cfg)
+	identityProvider, source, err := createIdentitySource(ctx, cfg, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create identity provider: %w", err)
 	}
 
+	revoked, err := RevocationList(cfg, reporter, logger, false)
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, err
+	}
+
+	// Create transport provider with rotation support
+	transportOpts := append([]transport.ProviderOption{transport.WithMetrics(reporter)},
+		revocationOptions(revoked)...)
+	transportProvider, federated, err := createTransportProvider(ctx, cfg, source, logger, transportOpts...)
+	if err != nil {
+		closeRevocation(revoked)
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to create transport provider: %w", err)
+	}
+
 	// Create the internal client adapter using the proper constructor
 	// Note: This is the only place where we directly depend on the adapter
-	internalClient, err := api.NewClient(identityProvider, cfg)
+	internalClient, err := api.NewClient(identityProvider, cfg,
+		api.WithTransportProvider(transportProvider),
+		api.WithTrustDomain(trustDomain),
+		api.WithTLSDialer(transport.TrackingTLSDialer),
+	)
 	if err != nil {
+		closeFederation(federated)
+		closeRevocation(revoked)
+		_ = identityProvider.Close()
 		return nil, fmt.Errorf("failed to create SPIFFE dialer: %w", err)
 	}
+	internalClient.SetMetrics(reporter)
+	if revoked != nil {
+		internalClient.SetRevocationList(revoked, enforceExisting(cfg))
+	}
+
+	return &spiffeDialerAdapter{client: internalClient, federation: federated, revocation: revoked}, nil
+}
+
+// ServerOption configures a server created by SPIFFEServer.
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	audit          ports.AuditRecorderPort
+	metrics        *metrics.PrometheusMetrics
+	watcher        ports.ConfigWatcherPort
+	healthListener net.Listener
+}
+
+// WithAuditRecorder records the server's handshakes, its per-method authorization
+// decisions and the connections it closes for failing the mTLS invariants.
+func WithAuditRecorder(recorder ports.AuditRecorderPort) ServerOption {
+	return func(opts *serverOptions) {
+		opts.audit = recorder
+	}
+}
+
+// WithMetrics reports the server's handshakes, connections, rotations and
+// authorization decisions to metrics instead of the default Prometheus registry.
+func WithMetrics(reporter *metrics.PrometheusMetrics) ServerOption {
+	return func(opts *serverOptions) {
+		opts.metrics = reporter
+	}
+}
 
-	return &spiffeDialerAdapter{client: internalClient}, nil
+// WithConfigWatcher applies the runtime-safe changes published by watcher, such as
+// the federated trust domains, the revocation entries, the certificate cache and
+// the health section, until the server is closed. The watcher is owned by the caller.
+func WithConfigWatcher(watcher ports.ConfigWatcherPort) ServerOption {
+	return func(opts *serverOptions) {
+		opts.watcher = watcher
+	}
 }
 
 // SPIFFEServer creates a new SPIFFE/SPIRE-backed AuthenticatedServer implementation.
 // The configuration must be valid and contain the necessary SPIFFE settings.
-func SPIFFEServer(ctx context.Context, cfg *ports.Configuration) (ports.AuthenticatedServer, error) {
+// Calls are authorized per method by the policy section of the configuration, if any.
+func SPIFFEServer(ctx context.Context, cfg *ports.Configuration, opts ...ServerOption) (ports.AuthenticatedServerPort, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
@@ -64,18 +173,50 @@ func SPIFFEServer(ctx context.Context, cfg *ports.Configuration) (ports.Authenti
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	options := &serverOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	reporter := metricsReporter(options.metrics)
+
+	logger, err := Logger(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging configuration: %w", err)
+	}
+
+	var policy ports.PolicyEvaluatorPort
+	if cfg.Policy != nil {
+		evaluator, err := services.NewPolicyEvaluator(cfg.Policy)
+		if err != nil {
+			return nil, err
+		}
+		policy = evaluator
+	}
+
 	// Create identity provider
-	identityProvider, err := createIdentityProvider(cfg)
+	identityProvider, source, err := createIdentitySource(ctx, cfg, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create identity provider: %w", err)
 	}
 
+	revoked, err := RevocationList(cfg, reporter, logger, options.watcher != nil)
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, err
+	}
+
 	// Create configuration provider
 	configProvider := config.NewFileProvider()
 
 	// Create transport provider with rotation support
-	transportProvider, err := transport.CreateGRPCProvider(cfg)
+	transportOpts := append([]transport.ProviderOption{
+		transport.WithAuthorization(policy, options.audit),
+		transport.WithMetrics(reporter),
+	}, revocationOptions(revoked)...)
+	transportProvider, federated, err := createTransportProvider(ctx, cfg, source, logger, transportOpts...)
 	if err != nil {
+		closeRevocation(revoked)
+		_ = identityProvider.Close()
 		return nil, fmt.Errorf("failed to create transport provider: %w", err)
 	}
 
@@ -83,15 +224,284 @@ func SPIFFEServer(ctx context.Context, cfg *ports.Configuration) (ports.Authenti
 	// This factory is the appropriate place for this wiring, keeping the API package clean
 	internalServer, err := api.WorkloadServer(identityProvider, transportProvider, configProvider, cfg)
 	if err != nil {
+		closeFederation(federated)
+		closeRevocation(revoked)
+		_ = identityProvider.Close()
 		return nil, fmt.Errorf("failed to create SPIFFE server: %w", err)
 	}
+	internalServer.SetLogger(logger)
+	internalServer.SetAuditRecorder(options.audit)
+	internalServer.SetMetrics(reporter)
+	if revoked != nil {
+		internalServer.SetRevocationList(revoked, enforceExisting(cfg))
+	}
+
+	var healthEndpoint *healthEndpoint
+	if options.healthListener != nil {
+		var bundles x509bundle.Source = source
+		if federated != nil {
+			bundles = federated
+		}
+		healthEndpoint, err = createHealthEndpoint(ctx, cfg, identityProvider, transportProvider,
+			builtinSources(cfg, source, bundles), options.healthListener, logger)
+		if err == nil {
+			err = internalServer.RegisterService(ctx, api.NewGRPCServiceRegistrar(healthEndpoint.server.RegisterGRPC))
+		}
+		if err != nil {
+			if healthEndpoint != nil {
+				_ = healthEndpoint.close()
+			}
+			_ = internalServer.Close()
+			closeFederation(federated)
+			closeRevocation(revoked)
+			_ = identityProvider.Close()
+			return nil, fmt.Errorf("failed to create health endpoint: %w", err)
+		}
+	}
+
+	return &spiffeServerAdapter{
+		server:     internalServer,
+		federation: federated,
+		revocation: revoked,
+		health:     healthEndpoint,
+		unwatch:    watchConfig(options.watcher, federated, revoked, internalServer, healthEndpoint),
+	}, nil
+}
+
+// watchConfig applies configuration changes to the federated bundle set, the
+// revocation list, the certificate cache of server and the health endpoint, where
+// present, and returns a function that stops watching.
+func watchConfig(
+	watcher ports.ConfigWatcherPort,
+	federated *spiffe.FederatedBundleSet,
+	revoked *revocation.List,
+	server *api.Server,
+	health *healthEndpoint,
+) func() {
+	if watcher == nil {
+		return func() {}
+	}
+	var unwatch []func()
+	if federated != nil {
+		unwatch = append(unwatch, federated.WatchConfig(watcher))
+	}
+	if revoked != nil {
+		unwatch = append(unwatch, revoked.WatchConfig(watcher))
+	}
+	if server != nil || health != nil {
+		unwatch = append(unwatch, watchServerConfig(watcher, server, health))
+	}
+	return func() {
+		for _, stop := range unwatch {
+			stop()
+		}
+	}
+}
+
+// watchServerConfig applies changes of the cache section to the identity service
+// of server and changes of the health section to the health endpoint.
+func watchServerConfig(watcher ports.ConfigWatcherPort, server *api.Server, health *healthEndpoint) func() {
+	events, unsubscribe := watcher.Subscribe()
+	go func() {
+		for event := range events {
+			for _, field := range event.ChangedFields {
+				switch {
+				case field == "service.cache" && server != nil:
+					server.SetCacheConfig(event.Current.Service.Cache)
+				case field == "health" && health != nil:
+					health.reconfigure(event.Current.Health)
+				}
+			}
+		}
+	}()
+	return unsubscribe
+}
+
+// SPIFFEJWTService creates a JWT-SVID service backed by the SPIFFE Workload API.
+// The same Workload API adapter serves both JWT-SVID fetches and JWT bundles.
+func SPIFFEJWTService(ctx context.Context, cfg *ports.Configuration) (*services.JWTSVIDService, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Agent == nil {
+		return nil, fmt.Errorf("agent configuration must be provided - no fallback patterns allowed")
+	}
+
+	logger, err := Logger(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging configuration: %w", err)
+	}
+
+	adapter, err := spiffe.NewJWTSVIDAdapter(spiffe.JWTSVIDAdapterConfig{
+		SocketPath: cfg.Agent.SocketPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT-SVID adapter: %w", err)
+	}
+
+	return services.NewJWTSVIDService(adapter, adapter, services.WithJWTLogger(logger))
+}
+
+// SPIFFEIdentityProvider creates an identity provider that serves the live X.509 SVID
+// and trust bundle from the Workload API, or from the files of the identity section.
+// It is used to terminate SPIFFE mTLS in front of plain net/http handlers. The
+// context bounds the initial SVID fetch. Of the server options, only WithConfigWatcher
+// applies.
+func SPIFFEIdentityProvider(ctx context.Context, cfg *ports.Configuration, opts ...ServerOption) (ports.IdentityProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	options := &serverOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	logger, err := Logger(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging configuration: %w", err)
+	}
 
-	return &spiffeServerAdapter{server: internalServer}, nil
+	identityProvider, source, err := createIdentitySource(ctx, cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity provider: %w", err)
+	}
+
+	federated, err := createFederatedBundles(ctx, cfg, source, logger)
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to create federated bundle set: %w", err)
+	}
+
+	adapter := &x509SourceIdentityAdapter{
+		provider: identityProvider,
+		source:   source,
+		bundles:  source,
+		unwatch:  watchConfig(options.watcher, federated, nil, nil, nil),
+	}
+	if federated != nil {
+		adapter.bundles = federated
+		adapter.federation = federated
+	}
+	return adapter, nil
+}
+
+// BundleEndpoint is a bundle endpoint server that owns its identity and JWT bundle providers.
+type BundleEndpoint struct {
+	*bundleendpoint.Server
+	provider *spiffe.Provider
+	jwt      *spiffe.JWTSVIDAdapter
+}
+
+// Close stops the endpoint and releases the providers.
+func (e *BundleEndpoint) Close() error {
+	return errors.Join(e.Server.Close(), e.jwt.Close(), e.provider.Close())
+}
+
+// SPIFFEBundleEndpoint creates a started bundle endpoint that publishes the local X.509
+// and JWT authorities from the Workload API, following bundle rotations.
+// The configuration must contain a federation.bundle_endpoint section.
+func SPIFFEBundleEndpoint(ctx context.Context, cfg *ports.Configuration) (*BundleEndpoint, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("configuration cannot be nil")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Federation == nil || cfg.Federation.BundleEndpoint == nil {
+		return nil, fmt.Errorf("bundle endpoint configuration must be provided")
+	}
+	if !cfg.Identity.UsesWorkloadAPI() {
+		return nil, fmt.Errorf("the bundle endpoint requires the workload_api identity source")
+	}
+	endpointCfg := cfg.Federation.BundleEndpoint
+
+	trustDomain, err := spiffeid.TrustDomainFromString(cfg.Service.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust domain %q: %w", cfg.Service.Domain, err)
+	}
+
+	// Bundle endpoint clients present no certificate, so the key policy alone is
+	// no reason to override the profile's TLS defaults
+	var endpointTLS *ports.TLSConfig
+	if cfg.TLS != nil || cfg.FIPSMode {
+		endpointTLS = cfg.EffectiveTLS()
+	}
+
+	logger, err := Logger(cfg, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging configuration: %w", err)
+	}
+
+	var webTLS *tls.Config
+	if endpointCfg.Profile == ports.BundleEndpointProfileHTTPSWeb {
+		cert, err := tls.LoadX509KeyPair(endpointCfg.CertFile, endpointCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load bundle endpoint certificate: %w", err)
+		}
+		webTLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	identityProvider, err := createIdentityProvider(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity provider: %w", err)
+	}
+
+	source, err := identityProvider.X509Source(ctx)
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to obtain X509 source: %w", err)
+	}
+
+	jwtBundles, err := spiffe.NewJWTSVIDAdapter(spiffe.JWTSVIDAdapterConfig{
+		SocketPath: cfg.Agent.SocketPath,
+		Logger:     logger,
+	})
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to create JWT bundle adapter: %w", err)
+	}
+
+	server, err := bundleendpoint.New(bundleendpoint.Config{
+		TrustDomain:  trustDomain,
+		Bundles:      identityProvider.BundleProvider(),
+		JWTBundles:   jwtBundles,
+		RefreshHint:  endpointCfg.RefreshHint,
+		Profile:      endpointCfg.Profile,
+		SVIDSource:   source,
+		WebTLSConfig: webTLS,
+		TLS:          endpointTLS,
+		Logger:       logger,
+	})
+	if err != nil {
+		_ = jwtBundles.Close()
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to create bundle endpoint: %w", err)
+	}
+
+	if err := server.Start(ctx); err != nil {
+		_ = jwtBundles.Close()
+		_ = identityProvider.Close()
+		return nil, fmt.Errorf("failed to start bundle endpoint: %w", err)
+	}
+
+	return &BundleEndpoint{Server: server, provider: identityProvider, jwt: jwtBundles}, nil
 }
 
 // createIdentityProvider creates a SPIFFE identity provider from configuration
 // This function now uses the new adapter architecture internally through the refactored Provider.
-func createIdentityProvider(cfg *ports.Configuration) (ports.IdentityProvider, error) {
+func createIdentityProvider(cfg *ports.Configuration) (*spiffe.Provider, error) {
 	// Create identity provider using the refactored Provider that delegates to new adapters
 	identityProvider, err := spiffe.NewProvider(cfg.Agent)
 	if err != nil {
@@ -101,6 +511,233 @@ func createIdentityProvider(cfg *ports.Configuration) (ports.IdentityProvider, e
 	return identityProvider, nil
 }
 
+// identitySource serves the live SVID and trust bundle of an identity provider.
+type identitySource interface {
+	x509svid.Source
+	x509bundle.Source
+}
+
+// createIdentitySource creates the identity provider selected by the identity section
+// and the source of its live SVID and bundle: the Workload API X509Source by default,
+// or the provider itself for PEM files, which it watches for changes, and for the
+// development CA, which rotates its SVIDs.
+func createIdentitySource(ctx context.Context, cfg *ports.Configuration, logger *slog.Logger) (ports.IdentityProvider, identitySource, error) {
+	if cfg.Identity.UsesDevCA() {
+		provider, err := createDevCAProvider(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return provider, provider, nil
+	}
+
+	if cfg.Identity.UsesFiles() {
+		files := cfg.Identity.Files
+		keyPolicy := cfg.KeyPolicy()
+		provider, err := fileidentity.New(fileidentity.Config{
+			CertFile:   files.CertFile,
+			KeyFile:    files.KeyFile,
+			BundleFile: files.BundleFile,
+			KeyPolicy:  &keyPolicy,
+			FIPS:       cfg.FIPSMode,
+			Logger:     logger,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load identity files: %w", err)
+		}
+		if err := provider.Start(); err != nil {
+			_ = provider.Close()
+			return nil, nil, fmt.Errorf("failed to watch identity files: %w", err)
+		}
+		return provider, provider, nil
+	}
+
+	identityProvider, err := createIdentityProvider(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	source, err := identityProvider.X509Source(ctx)
+	if err != nil {
+		_ = identityProvider.Close()
+		return nil, nil, fmt.Errorf("failed to obtain X509 source: %w", err)
+	}
+	return identityProvider, source, nil
+}
+
+// createDevCAProvider creates an identity provider whose SVIDs are issued by the
+// process-wide development CA of the trust domain, so that clients and servers in
+// the same process trust each other.
+func createDevCAProvider(cfg *ports.Configuration, logger *slog.Logger) (*memidentity.CAProvider, error) {
+	var devCA ports.IdentityDevCAConfig
+	if cfg.Identity.DevCA != nil {
+		devCA = *cfg.Identity.DevCA
+	}
+
+	id, err := devCASPIFFEID(cfg, devCA.SPIFFEID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid development CA SPIFFE ID: %w", err)
+	}
+	ca, err := memidentity.SharedCA(id.TrustDomain(), devCA.Intermediate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create development CA: %w", err)
+	}
+
+	provider, err := memidentity.NewCAProvider(memidentity.CAProviderConfig{
+		ID:     id,
+		CA:     ca,
+		TTL:    devCA.TTL,
+		Logger: logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue development SVID: %w", err)
+	}
+	if err := provider.Start(); err != nil {
+		_ = provider.Close()
+		return nil, fmt.Errorf("failed to start development CA: %w", err)
+	}
+
+	logger.Warn("using the in-process development CA; its SVIDs are not trusted outside this process",
+		"spiffe_id", id.String())
+	return provider, nil
+}
+
+// devCASPIFFEID returns the configured ID, or spiffe://<service.domain>/<service.name>.
+func devCASPIFFEID(cfg *ports.Configuration, configured string) (spiffeid.ID, error) {
+	if configured != "" {
+		return spiffeid.FromString(configured)
+	}
+	trustDomain, err := spiffeid.TrustDomainFromString(cfg.Service.Domain)
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("set identity.dev_ca.spiffe_id or service.domain: %w", err)
+	}
+	return spiffeid.FromSegments(trustDomain, cfg.Service.Name.String())
+}
+
+// createTransportProvider creates a gRPC transport provider backed by the identity source.
+// Handshakes read the SVID and bundle from the live source, so rotated certificates are
+// picked up without rebuilding connections or servers.
+//
+// When federation is configured, peers are verified against the bundle of their own
+// trust domain, and the returned bundle set must be closed by the caller.
+func createTransportProvider(
+	ctx context.Context,
+	cfg *ports.Configuration,
+	source identitySource,
+	logger *slog.Logger,
+	opts ...transport.ProviderOption,
+) (*transport.RotatableGRPCProvider, *spiffe.FederatedBundleSet, error) {
+	federated, err := createFederatedBundles(ctx, cfg, source, logger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create federated bundle set: %w", err)
+	}
+
+	var bundles x509bundle.Source = source
+	if federated != nil {
+		bundles = federated
+	}
+
+	// A nil authorizer selects the provider's trust-domain based secure default
+	opts = append([]transport.ProviderOption{transport.WithSources(source, bundles, nil)}, opts...)
+	provider, err := transport.CreateGRPCProvider(cfg, opts...)
+	if err != nil {
+		closeFederation(federated)
+		return nil, nil, err
+	}
+	return provider, federated, nil
+}
+
+// createFederatedBundles starts a federated bundle set layered over the local bundle
+// source. It returns nil when no federated trust domains are configured.
+// Unreachable bundle endpoints do not fail startup; they are retried while polling.
+func createFederatedBundles(
+	ctx context.Context,
+	cfg *ports.Configuration,
+	local x509bundle.Source,
+	logger *slog.Logger,
+) (*spiffe.FederatedBundleSet, error) {
+	if cfg.Federation == nil || len(cfg.Federation.TrustDomains) == 0 {
+		return nil, nil
+	}
+
+	federated, err := spiffe.NewFederatedBundleSet(spiffe.FederatedBundleSetConfig{
+		Federation:   cfg.Federation,
+		LocalBundles: local,
+		Logger:       logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := federated.Start(ctx); err != nil {
+		logger.Warn("initial federated bundle fetch failed", "error", err)
+	}
+	return federated, nil
+}
+
+// closeFederation stops a federated bundle set, if any.
+func closeFederation(federated *spiffe.FederatedBundleSet) {
+	if federated != nil {
+		_ = federated.Close()
+	}
+}
+
+// RevocationList loads the revocation list and starts watching its file, reporting
+// rejected peers and reloads to reporter, or to the default Prometheus registry if
+// nil, and reload failures to logger. It returns nil when no revocation section is
+// configured, unless the configuration is watched, since a watched configuration
+// may add revocation entries at runtime. The caller must close the list.
+func RevocationList(cfg *ports.Configuration, reporter *metrics.PrometheusMetrics, logger *slog.Logger, watched bool) (*revocation.List, error) {
+	section := cfg.Revocation
+	if section == nil {
+		if !watched {
+			return nil, nil
+		}
+		section = &ports.RevocationConfig{}
+	}
+
+	list, err := revocation.NewList(revocation.ListConfig{
+		Config:  section,
+		Metrics: metricsReporter(reporter),
+		Logger:  logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revocation list: %w", err)
+	}
+	if err := list.Start(); err != nil {
+		_ = list.Close()
+		return nil, fmt.Errorf("failed to watch revocation list: %w", err)
+	}
+	return list, nil
+}
+
+// enforceExisting reports whether connections to newly revoked peers are closed.
+func enforceExisting(cfg *ports.Configuration) bool {
+	return cfg.Revocation != nil && cfg.Revocation.EnforceExisting
+}
+
+// metricsReporter returns reporter, or the reporter of the default Prometheus
+// registry if nil.
+func metricsReporter(reporter *metrics.PrometheusMetrics) *metrics.PrometheusMetrics {
+	if reporter == nil {
+		return metrics.Default()
+	}
+	return reporter
+}
+
+// revocationOptions returns the transport options that reject peers on the list, if any.
+func revocationOptions(list *revocation.List) []transport.ProviderOption {
+	if list == nil {
+		return nil
+	}
+	return []transport.ProviderOption{transport.WithRevocation(list)}
+}
+
+// closeRevocation stops watching a revocation list, if any.
+func closeRevocation(list *revocation.List) {
+	if list != nil {
+		_ = list.Close()
+	}
+}
+
 // createIdentityProviderWithAdapters creates a SPIFFE identity provider using the new adapter architecture directly.
 // This provides fine-grained control over adapter configuration and allows for adapter composition.
 func createIdentityProviderWithAdapters(cfg *ports.Configuration) (ports.IdentityProvider, error) {
@@ -125,7 +762,7 @@ type AdapterConfig struct {
 }
 
 // SPIFFEDialerWithAdapters creates a new SPIFFE/SPIRE-backed Dialer with adapter configuration options.
-func SPIFFEDialerWithAdapters(ctx context.Context, cfg *ports.Configuration, adapterCfg *AdapterConfig) (ports.Dialer, error) {
+func SPIFFEDialerWithAdapters(ctx context.Context, cfg *ports.Configuration, adapterCfg *AdapterConfig) (ports.DialerPort, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
@@ -140,7 +777,7 @@ func SPIFFEDialerWithAdapters(ctx context.Context, cfg *ports.Configuration, ada
 }
 
 // SPIFFEServerWithAdapters creates a new SPIFFE/SPIRE-backed AuthenticatedServer with adapter configuration options.
-func SPIFFEServerWithAdapters(ctx context.Context, cfg *ports.Configuration, adapterCfg *AdapterConfig) (ports.AuthenticatedServer, error) {
+func SPIFFEServerWithAdapters(ctx context.Context, cfg *ports.Configuration, adapterCfg *AdapterConfig) (ports.AuthenticatedServerPort, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
@@ -156,10 +793,12 @@ func SPIFFEServerWithAdapters(ctx context.Context, cfg *ports.Configuration, ada
 
 // spiffeDialerAdapter adapts the internal API client to the Dialer port
 type spiffeDialerAdapter struct {
-	client *api.Client
+	client     *api.Client
+	federation *spiffe.FederatedBundleSet
+	revocation *revocation.List
 }
 
-func (d *spiffeDialerAdapter) Connect(ctx context.Context, serviceName, address string) (ports.Conn, error) {
+func (d *spiffeDialerAdapter) Connect(ctx context.Context, serviceName, address string) (ports.ConnPort, error) {
 	internalConn, err := d.client.Connect(ctx, serviceName, address)
 	if err != nil {
 		return nil, err
@@ -169,6 +808,8 @@ func (d *spiffeDialerAdapter) Connect(ctx context.Context, serviceName, address
 }
 
 func (d *spiffeDialerAdapter) Close() error {
+	closeFederation(d.federation)
+	closeRevocation(d.revocation)
 	return d.client.Close()
 }
 
@@ -177,7 +818,7 @@ type spiffeConnAdapter struct {
 	conn *api.ClientConnection
 }
 
-func (c *spiffeConnAdapter) HTTPClient() (ports.HTTPClient, error) {
+func (c *spiffeConnAdapter) HTTPClient() (ports.HTTPClientPort, error) {
 	httpClient, err := c.conn.HTTPClient()
 	if err != nil {
 		return nil, err
@@ -185,27 +826,72 @@ func (c *spiffeConnAdapter) HTTPClient() (ports.HTTPClient, error) {
 	return &httpClientAdapter{client: httpClient}, nil
 }
 
+func (c *spiffeConnAdapter) ClientConnection() (interface{}, error) {
+	conn := c.conn.GetClientConnection()
+	if conn == nil {
+		return nil, fmt.Errorf("no gRPC client connection available")
+	}
+	return conn, nil
+}
+
 func (c *spiffeConnAdapter) Close() error {
 	return c.conn.Close()
 }
 
 // spiffeServerAdapter adapts the internal API server to the AuthenticatedServer
 type spiffeServerAdapter struct {
-	server *api.Server
+	server     *api.Server
+	federation *spiffe.FederatedBundleSet
+	revocation *revocation.List
+	health     *healthEndpoint
+	unwatch    func()
 }
 
-func (s *spiffeServerAdapter) Serve(ctx context.Context, listener ports.NetworkListener) error {
-	// The underlying api.Server expects net.Listener, so we need to extract it
-	// Similar to the approach used in the transport layer
-	if adapter, ok := listener.(*networkListenerAdapter); ok {
-		return s.server.Serve(ctx, adapter.listener)
+func (s *spiffeServerAdapter) RegisterService(ctx context.Context, registrar ports.ServiceRegistrarPort) error {
+	if registrar == nil {
+		return fmt.Errorf("service registrar cannot be nil")
 	}
-	
-	// If it's not our adapter, we can't extract the net.Listener
-	return fmt.Errorf("NetworkListener must wrap a net.Listener to work with SPIFFE server")
+	return s.server.RegisterService(ctx, api.NewGRPCServiceRegistrar(func(server grpc.ServiceRegistrar) {
+		registrar.Register(server)
+	}))
+}
+
+func (s *spiffeServerAdapter) Serve(ctx context.Context, listener ports.NetworkListenerPort) error {
+	// The underlying api.Server expects net.Listener, so we need to extract it.
+	// Listeners adapted here and by the public API expose it.
+	adapter, ok := listener.(interface{ NetListener() net.Listener })
+	if !ok {
+		return fmt.Errorf("NetworkListener must wrap a net.Listener to work with SPIFFE server")
+	}
+	if s.health == nil {
+		return s.server.Serve(ctx, adapter.NetListener())
+	}
+
+	// The probes are served for as long as the server is
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	healthDone := make(chan struct{})
+	go func() {
+		defer close(healthDone)
+		if err := s.health.serve(healthCtx); err != nil {
+			s.health.logger.Error("health endpoint stopped", "error", err)
+		}
+	}()
+	defer func() {
+		stopHealth()
+		<-healthDone
+	}()
+	return s.server.Serve(ctx, adapter.NetListener())
 }
 
 func (s *spiffeServerAdapter) Close() error {
+	if s.unwatch != nil {
+		s.unwatch()
+	}
+	closeFederation(s.federation)
+	closeRevocation(s.revocation)
+	if s.health != nil {
+		_ = s.health.close()
+	}
 	return s.server.Close()
 }
 
@@ -215,7 +901,71 @@ func (s *spiffeServerAdapter) Addr() string {
 	return ""
 }
 
-// httpClientAdapter adapts net/http.Client to ports.HTTPClient
+// x509SourceIdentityAdapter adapts an identity source to ports.IdentityProvider.
+// Every call reads the current SVID from the source, so rotations are visible immediately.
+// Bundles for other trust domains come from bundles, which includes federated trust domains.
+type x509SourceIdentityAdapter struct {
+	provider   ports.IdentityProvider
+	source     identitySource
+	bundles    x509bundle.Source
+	federation *spiffe.FederatedBundleSet
+	unwatch    func()
+}
+
+func (a *x509SourceIdentityAdapter) GetServiceIdentity() (spiffeid.ID, error) {
+	svid, err := a.GetSVID()
+	if err != nil {
+		return spiffeid.ID{}, err
+	}
+	return svid.ID, nil
+}
+
+func (a *x509SourceIdentityAdapter) GetCertificate() (*domain.Certificate, error) {
+	svid, err := a.GetSVID()
+	if err != nil {
+		return nil, err
+	}
+	if len(svid.Certificates) == 0 {
+		return nil, fmt.Errorf("X509-SVID has no certificates")
+	}
+	return &domain.Certificate{
+		Cert:       svid.Certificates[0],
+		PrivateKey: svid.PrivateKey,
+		Chain:      svid.Certificates[1:],
+	}, nil
+}
+
+func (a *x509SourceIdentityAdapter) GetTrustBundle() (*x509bundle.Bundle, error) {
+	svid, err := a.GetSVID()
+	if err != nil {
+		return nil, err
+	}
+	return a.source.GetX509BundleForTrustDomain(svid.ID.TrustDomain())
+}
+
+// GetTrustBundleForDomain returns the bundle of the local or a federated trust domain,
+// as ports.BundleProviderPort does.
+func (a *x509SourceIdentityAdapter) GetTrustBundleForDomain(_ context.Context, trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return a.bundles.GetX509BundleForTrustDomain(trustDomain)
+}
+
+func (a *x509SourceIdentityAdapter) GetSVID() (*x509svid.SVID, error) {
+	svid, err := a.source.GetX509SVID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get X509-SVID: %w", err)
+	}
+	return svid, nil
+}
+
+func (a *x509SourceIdentityAdapter) Close() error {
+	if a.unwatch != nil {
+		a.unwatch()
+	}
+	closeFederation(a.federation)
+	return a.provider.Close()
+}
+
+// httpClientAdapter adapts net/http.Client to ports.HTTPClientPort
 type httpClientAdapter struct {
 	client *http.Client
 }
@@ -261,7 +1011,7 @@ func (a *httpClientAdapter) Close() error {
 	return nil
 }
 
-// networkListenerAdapter adapts net.Listener to ports.NetworkListener.
+// networkListenerAdapter adapts net.Listener to ports.NetworkListenerPort.
 type networkListenerAdapter struct {
 	listener net.Listener
 }
@@ -281,3 +1031,8 @@ func (a *networkListenerAdapter) Addr() string {
 func (a *networkListenerAdapter) Close() error {
 	return a.listener.Close()
 }
+
+// NetListener returns the adapted listener.
+func (a *networkListenerAdapter) NetListener() net.Listener {
+	return a.listener
+}
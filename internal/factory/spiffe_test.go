@@ -3,10 +3,15 @@ package factory
 import (
 	"context"
 	"net"
-	"net/http"
 	"testing"
+	"time"
 
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/testing/workloadapitest"
 )
 
 // mockIdentityProvider implements a test identity provider
@@ -35,15 +40,23 @@ func (m *mockIdentityProvider) Close() error {
 
 // mockConn implements a test connection
 type mockConn struct {
-	httpClientFunc func() (*http.Client, error)
-	closeFunc      func() error
+	httpClientFunc       func() (ports.HTTPClientPort, error)
+	clientConnectionFunc func() (interface{}, error)
+	closeFunc            func() error
 }
 
-func (m *mockConn) HTTPClient() (*http.Client, error) {
+func (m *mockConn) HTTPClient() (ports.HTTPClientPort, error) {
 	if m.httpClientFunc != nil {
 		return m.httpClientFunc()
 	}
-	return &http.Client{}, nil
+	return nil, nil
+}
+
+func (m *mockConn) ClientConnection() (interface{}, error) {
+	if m.clientConnectionFunc != nil {
+		return m.clientConnectionFunc()
+	}
+	return nil, nil
 }
 
 func (m *mockConn) Close() error {
@@ -55,11 +68,11 @@ func (m *mockConn) Close() error {
 
 // mockDialer implements a test dialer
 type mockDialer struct {
-	connectFunc func(context.Context, string, string) (ports.Conn, error)
+	connectFunc func(context.Context, string, string) (ports.ConnPort, error)
 	closeFunc   func() error
 }
 
-func (m *mockDialer) Connect(ctx context.Context, serviceName, address string) (ports.Conn, error) {
+func (m *mockDialer) Connect(ctx context.Context, serviceName, address string) (ports.ConnPort, error) {
 	if m.connectFunc != nil {
 		return m.connectFunc(ctx, serviceName, address)
 	}
@@ -101,8 +114,31 @@ func (m *mockServer) Addr() net.Addr {
 	return nil
 }
 
+// newWorkloadAPI serves an SVID for spiffe://test.domain/test-service from a fake
+// Workload API and returns its socket path.
+func newWorkloadAPI(t *testing.T) string {
+	t.Helper()
+
+	id := spiffeid.RequireFromString("spiffe://test.domain/test-service")
+	ca, err := memidentity.NewCA(id.TrustDomain(), true)
+	if err != nil {
+		t.Fatalf("NewCA() error = %v", err)
+	}
+	svid, err := ca.Issue(id, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	server := workloadapitest.New(t)
+	server.SetX509Bundles(ca.Bundle())
+	server.SetX509SVIDs(svid)
+	return server.SocketPath()
+}
+
 func TestSPIFFEDialer(t *testing.T) {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	socketPath := newWorkloadAPI(t)
 
 	tests := []struct {
 		name    string
@@ -120,8 +156,8 @@ func TestSPIFFEDialer(t *testing.T) {
 			name: "invalid configuration",
 			config: &ports.Configuration{
 				Service: ports.ServiceConfig{
-					Name:   "", // Invalid: empty name
-					Domain: "test.domain",
+					Name:   domain.NewServiceNameUnsafe("test-service"),
+					Domain: "not a domain", // Invalid: malformed trust domain
 				},
 			},
 			wantErr: true,
@@ -131,11 +167,11 @@ func TestSPIFFEDialer(t *testing.T) {
 			name: "valid configuration",
 			config: &ports.Configuration{
 				Service: ports.ServiceConfig{
-					Name:   "test-service",
+					Name:   domain.NewServiceNameUnsafe("test-service"),
 					Domain: "test.domain",
 				},
 				Agent: &ports.AgentConfig{
-					SocketPath: "/tmp/test.sock",
+					SocketPath: domain.NewSocketPathUnsafe(socketPath),
 				},
 			},
 			wantErr: false,
@@ -145,6 +181,9 @@ func TestSPIFFEDialer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dialer, err := SPIFFEDialer(ctx, tt.config)
+			if dialer != nil {
+				t.Cleanup(func() { _ = dialer.Close() })
+			}
 
 			if tt.wantErr {
 				if err == nil {
@@ -165,7 +204,9 @@ func TestSPIFFEDialer(t *testing.T) {
 }
 
 func TestSPIFFEServer(t *testing.T) {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	socketPath := newWorkloadAPI(t)
 
 	tests := []struct {
 		name    string
@@ -183,7 +224,7 @@ func TestSPIFFEServer(t *testing.T) {
 			name: "invalid configuration",
 			config: &ports.Configuration{
 				Service: ports.ServiceConfig{
-					Name:   "test-service",
+					Name:   domain.NewServiceNameUnsafe("test-service"),
 					Domain: "", // Invalid: empty domain
 				},
 			},
@@ -194,11 +235,11 @@ func TestSPIFFEServer(t *testing.T) {
 			name: "valid configuration",
 			config: &ports.Configuration{
 				Service: ports.ServiceConfig{
-					Name:   "test-service",
+					Name:   domain.NewServiceNameUnsafe("test-service"),
 					Domain: "test.domain",
 				},
 				Agent: &ports.AgentConfig{
-					SocketPath: "/tmp/test.sock",
+					SocketPath: domain.NewSocketPathUnsafe(socketPath),
 				},
 			},
 			wantErr: false,
@@ -208,6 +249,9 @@ func TestSPIFFEServer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			server, err := SPIFFEServer(ctx, tt.config)
+			if server != nil {
+				t.Cleanup(func() { _ = server.Close() })
+			}
 
 			if tt.wantErr {
 				if err == nil {
@@ -274,10 +318,10 @@ func TestSpiffeServerAdapter(t *testing.T) {
 		t.Skip("Integration test required")
 	})
 
-	t.Run("Addr returns nil", func(t *testing.T) {
+	t.Run("Addr returns empty", func(t *testing.T) {
 		adapter := &spiffeServerAdapter{}
-		if addr := adapter.Addr(); addr != nil {
-			t.Errorf("Addr() = %v, want nil", addr)
+		if addr := adapter.Addr(); addr != "" {
+			t.Errorf("Addr() = %q, want empty", addr)
 		}
 	})
 }
@@ -9,10 +9,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/spiffe/go-spiffe/v2/spiffeid"
-
 	"github.com/sufield/ephemos/internal/core/domain"
-	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 // FakeSPIREClient is a test double for SPIRE client behavior.
@@ -147,6 +144,9 @@ func (f *fakeSPIREClientImpl) WatchX509Context(ctx context.Context) (<-chan *dom
 
 // Close implements FakeSPIREClient
 func (f *fakeSPIREClientImpl) Close() error {
+	if f.closed {
+		return nil
+	}
 	f.closed = true
 	close(f.watchUpdates)
 	close(f.watchErrors)
@@ -0,0 +1,483 @@
+// Package workloadapitest runs an in-process SPIFFE Workload API server on a
+// temporary unix socket, so that adapters using the Workload API can be tested
+// without a SPIRE agent.
+//
+// The server speaks the real Workload API protocol, so go-spiffe clients,
+// X509Sources and JWTSources connect to it exactly as they would to an agent.
+// Test code sets the SVIDs and bundles it serves, pushes rotations to open
+// streams, injects errors and delays, and stops and restarts it to simulate the
+// agent going away.
+package workloadapitest
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/jwtbundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// RPC names a Workload API method, for error injection and call counts.
+type RPC string
+
+// Workload API methods.
+const (
+	FetchX509SVID    RPC = "FetchX509SVID"
+	FetchX509Bundles RPC = "FetchX509Bundles"
+	FetchJWTSVID     RPC = "FetchJWTSVID"
+	FetchJWTBundles  RPC = "FetchJWTBundles"
+	ValidateJWTSVID  RPC = "ValidateJWTSVID"
+)
+
+// JWTIssuer mints JWT-SVIDs for the server, e.g. a memidentity.JWTProvider.
+type JWTIssuer interface {
+	FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*jwtsvid.SVID, error)
+}
+
+// Server is a fake SPIFFE Workload API. It is safe for concurrent use.
+//
+// Until SVIDs are set, X.509-SVID fetches fail with PermissionDenied, as they do
+// for a workload that no SPIRE registration entry matches.
+type Server struct {
+	socketPath string
+	dir        string
+
+	mu          sync.Mutex
+	svids       []*x509svid.SVID
+	x509Bundles map[spiffeid.TrustDomain]*x509bundle.Bundle
+	jwtBundles  map[spiffeid.TrustDomain]*jwtbundle.Bundle
+	jwtIssuer   JWTIssuer
+	errs        map[RPC]error
+	delay       time.Duration
+	calls       map[RPC]int
+	changed     chan struct{}
+	grpcServer  *grpc.Server
+	serveDone   chan struct{}
+	closed      bool
+}
+
+// New starts a server on a unix socket in a new temporary directory. The server
+// is closed when the test ends.
+func New(tb testing.TB) *Server {
+	tb.Helper()
+
+	// Unix socket paths are limited to about 100 bytes, which t.TempDir paths
+	// can exceed, so the socket lives in a short directory of its own
+	dir, err := os.MkdirTemp("", "workloadapi")
+	if err != nil {
+		tb.Fatalf("failed to create socket directory: %v", err)
+	}
+
+	s := &Server{
+		socketPath:  filepath.Join(dir, "agent.sock"),
+		dir:         dir,
+		x509Bundles: make(map[spiffeid.TrustDomain]*x509bundle.Bundle),
+		jwtBundles:  make(map[spiffeid.TrustDomain]*jwtbundle.Bundle),
+		errs:        make(map[RPC]error),
+		calls:       make(map[RPC]int),
+		changed:     make(chan struct{}),
+	}
+	tb.Cleanup(s.Close)
+
+	if err := s.Start(); err != nil {
+		tb.Fatalf("failed to start Workload API server: %v", err)
+	}
+	return s
+}
+
+// SocketPath returns the path of the unix socket.
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// Addr returns the socket address in the unix:// form expected by go-spiffe.
+func (s *Server) Addr() string {
+	return "unix://" + s.socketPath
+}
+
+// Start listens on the socket again after Stop.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("workload API server is closed")
+	}
+	if s.grpcServer != nil {
+		return fmt.Errorf("workload API server already started")
+	}
+
+	// A socket left behind by a previous listener would make Listen fail
+	_ = os.Remove(s.socketPath)
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, &handler{server: s})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = server.Serve(listener)
+	}()
+
+	s.grpcServer, s.serveDone = server, done
+	return nil
+}
+
+// Stop simulates the agent going away: open streams are broken, and new
+// connections are refused until Start is called.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	server, done := s.grpcServer, s.serveDone
+	s.grpcServer, s.serveDone = nil, nil
+	s.mu.Unlock()
+
+	if server == nil {
+		return
+	}
+	server.Stop()
+	<-done
+	_ = os.Remove(s.socketPath)
+}
+
+// Close stops the server and removes its socket directory.
+// It is safe to call Close multiple times.
+func (s *Server) Close() {
+	s.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	_ = os.RemoveAll(s.dir)
+}
+
+// SetX509SVIDs sets the SVIDs served to the workload, the first being its default
+// SVID, and pushes them to open X.509-SVID streams, as the agent does on rotation.
+// The bundle of each SVID's trust domain must be set with SetX509Bundles.
+func (s *Server) SetX509SVIDs(svids ...*x509svid.SVID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.svids = svids
+	s.notifyLocked()
+}
+
+// SetX509Bundles sets the X.509 bundles of the workload's and federated trust
+// domains, replacing bundles of the same trust domains, and pushes them to open
+// streams.
+func (s *Server) SetX509Bundles(bundles ...*x509bundle.Bundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, bundle := range bundles {
+		s.x509Bundles[bundle.TrustDomain()] = bundle
+	}
+	s.notifyLocked()
+}
+
+// SetJWTBundles sets the JWT bundles of trust domains, replacing bundles of the
+// same trust domains, and pushes them to open streams. ValidateJWTSVID verifies
+// tokens against them.
+func (s *Server) SetJWTBundles(bundles ...*jwtbundle.Bundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, bundle := range bundles {
+		s.jwtBundles[bundle.TrustDomain()] = bundle
+	}
+	s.notifyLocked()
+}
+
+// SetJWTIssuer sets the issuer of JWT-SVIDs. Until it is set, JWT-SVID fetches
+// fail with PermissionDenied.
+func (s *Server) SetJWTIssuer(issuer JWTIssuer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jwtIssuer = issuer
+}
+
+// SetError makes calls of rpc fail with err, or succeed again if err is nil.
+// Open streams of rpc end with err. Use a status error to choose the gRPC code;
+// other errors are reported as Unknown.
+func (s *Server) SetError(rpc RPC, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, rpc)
+	} else {
+		s.errs[rpc] = err
+	}
+	s.notifyLocked()
+}
+
+// SetDelay delays every response, including updates on open streams.
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// Calls returns how many times rpc has been called.
+func (s *Server) Calls(rpc RPC) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[rpc]
+}
+
+// notifyLocked wakes open streams so that they send the current state.
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// begin records a call of rpc, checks the security header, and applies the
+// injected delay and error.
+func (s *Server) begin(ctx context.Context, rpc RPC) error {
+	s.mu.Lock()
+	s.calls[rpc]++
+	s.mu.Unlock()
+
+	// go-spiffe sends this header on every call and SPIRE agents require it
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("workload.spiffe.io")) == 0 || md.Get("workload.spiffe.io")[0] != "true" {
+		return status.Error(codes.InvalidArgument, "security header missing from request")
+	}
+	return s.wait(ctx, rpc)
+}
+
+// wait applies the delay and returns the error injected for rpc.
+func (s *Server) wait(ctx context.Context, rpc RPC) error {
+	s.mu.Lock()
+	delay := s.delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs[rpc]
+}
+
+// stream calls send for the current state and again after every change, until the
+// client goes away, send fails, or an error is injected for rpc.
+func (s *Server) stream(ctx context.Context, rpc RPC, send func() error) error {
+	if err := s.begin(ctx, rpc); err != nil {
+		return err
+	}
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if err := send(); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+		if err := s.wait(ctx, rpc); err != nil {
+			return err
+		}
+	}
+}
+
+// x509SVIDResponse builds a response from the current SVIDs and bundles.
+func (s *Server) x509SVIDResponse() (*workload.X509SVIDResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.svids) == 0 {
+		return nil, status.Error(codes.PermissionDenied, "no identity issued")
+	}
+
+	resp := &workload.X509SVIDResponse{FederatedBundles: make(map[string][]byte)}
+	own := make(map[spiffeid.TrustDomain]bool)
+	for _, svid := range s.svids {
+		certs, key, err := svid.MarshalRaw()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal SVID %s: %v", svid.ID, err)
+		}
+		bundle, ok := s.x509Bundles[svid.ID.TrustDomain()]
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "no bundle set for trust domain %s", svid.ID.TrustDomain())
+		}
+		resp.Svids = append(resp.Svids, &workload.X509SVID{
+			SpiffeId:    svid.ID.String(),
+			X509Svid:    certs,
+			X509SvidKey: key,
+			Bundle:      concatRaw(bundle.X509Authorities()),
+			Hint:        svid.Hint,
+		})
+		own[svid.ID.TrustDomain()] = true
+	}
+	for td, bundle := range s.x509Bundles {
+		if !own[td] {
+			resp.FederatedBundles[td.IDString()] = concatRaw(bundle.X509Authorities())
+		}
+	}
+	return resp, nil
+}
+
+// x509BundlesResponse builds a response from the current X.509 bundles.
+func (s *Server) x509BundlesResponse() (*workload.X509BundlesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.x509Bundles) == 0 {
+		return nil, status.Error(codes.PermissionDenied, "no identity issued")
+	}
+	resp := &workload.X509BundlesResponse{Bundles: make(map[string][]byte)}
+	for td, bundle := range s.x509Bundles {
+		resp.Bundles[td.IDString()] = concatRaw(bundle.X509Authorities())
+	}
+	return resp, nil
+}
+
+// jwtBundlesResponse builds a response from the current JWT bundles.
+func (s *Server) jwtBundlesResponse() (*workload.JWTBundlesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.jwtBundles) == 0 {
+		return nil, status.Error(codes.PermissionDenied, "no identity issued")
+	}
+	resp := &workload.JWTBundlesResponse{Bundles: make(map[string][]byte)}
+	for td, bundle := range s.jwtBundles {
+		jwks, err := bundle.Marshal()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal JWT bundle of %s: %v", td, err)
+		}
+		resp.Bundles[td.IDString()] = jwks
+	}
+	return resp, nil
+}
+
+// concatRaw concatenates the DER of the certificates, as the Workload API sends them.
+func concatRaw(certs []*x509.Certificate) []byte {
+	var raw []byte
+	for _, cert := range certs {
+		raw = append(raw, cert.Raw...)
+	}
+	return raw
+}
+
+// handler implements the Workload API gRPC service.
+type handler struct {
+	workload.UnsafeSpiffeWorkloadAPIServer
+	server *Server
+}
+
+func (h *handler) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	return h.server.stream(stream.Context(), FetchX509SVID, func() error {
+		resp, err := h.server.x509SVIDResponse()
+		if err != nil {
+			return err
+		}
+		return stream.Send(resp)
+	})
+}
+
+func (h *handler) FetchX509Bundles(_ *workload.X509BundlesRequest, stream workload.SpiffeWorkloadAPI_FetchX509BundlesServer) error {
+	return h.server.stream(stream.Context(), FetchX509Bundles, func() error {
+		resp, err := h.server.x509BundlesResponse()
+		if err != nil {
+			return err
+		}
+		return stream.Send(resp)
+	})
+}
+
+func (h *handler) FetchJWTBundles(_ *workload.JWTBundlesRequest, stream workload.SpiffeWorkloadAPI_FetchJWTBundlesServer) error {
+	return h.server.stream(stream.Context(), FetchJWTBundles, func() error {
+		resp, err := h.server.jwtBundlesResponse()
+		if err != nil {
+			return err
+		}
+		return stream.Send(resp)
+	})
+}
+
+func (h *handler) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest) (*workload.JWTSVIDResponse, error) {
+	if err := h.server.begin(ctx, FetchJWTSVID); err != nil {
+		return nil, err
+	}
+	if len(req.Audience) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "audience must be specified")
+	}
+
+	h.server.mu.Lock()
+	issuer := h.server.jwtIssuer
+	h.server.mu.Unlock()
+	if issuer == nil {
+		return nil, status.Error(codes.PermissionDenied, "no identity issued")
+	}
+
+	svid, err := issuer.FetchJWTSVID(ctx, req.Audience[0], req.Audience[1:]...)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to mint JWT-SVID: %v", err)
+	}
+	if req.SpiffeId != "" && req.SpiffeId != svid.ID.String() {
+		return nil, status.Errorf(codes.PermissionDenied, "no identity issued for %s", req.SpiffeId)
+	}
+	return &workload.JWTSVIDResponse{
+		Svids: []*workload.JWTSVID{{SpiffeId: svid.ID.String(), Svid: svid.Marshal()}},
+	}, nil
+}
+
+func (h *handler) ValidateJWTSVID(ctx context.Context, req *workload.ValidateJWTSVIDRequest) (*workload.ValidateJWTSVIDResponse, error) {
+	if err := h.server.begin(ctx, ValidateJWTSVID); err != nil {
+		return nil, err
+	}
+	if req.Audience == "" {
+		return nil, status.Error(codes.InvalidArgument, "audience must be specified")
+	}
+	if req.Svid == "" {
+		return nil, status.Error(codes.InvalidArgument, "svid must be specified")
+	}
+
+	h.server.mu.Lock()
+	bundles := jwtbundle.NewSet()
+	for _, bundle := range h.server.jwtBundles {
+		bundles.Add(bundle)
+	}
+	h.server.mu.Unlock()
+
+	svid, err := jwtsvid.ParseAndValidate(req.Svid, bundles, []string{req.Audience})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	claims, err := structpb.NewStruct(svid.Claims)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode claims: %v", err)
+	}
+	return &workload.ValidateJWTSVIDResponse{SpiffeId: svid.ID.String(), Claims: claims}, nil
+}
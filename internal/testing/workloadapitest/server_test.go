@@ -0,0 +1,195 @@
+package workloadapitest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/jwtsvid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+	"github.com/sufield/ephemos/internal/adapters/secondary/spiffe"
+	"github.com/sufield/ephemos/internal/adapters/secondary/verification"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/testing/workloadapitest"
+)
+
+var workloadID = spiffeid.RequireFromString("spiffe://example.org/api")
+
+// newServer starts a server that serves an SVID for workloadID.
+func newServer(t *testing.T) (*workloadapitest.Server, *memidentity.CA, *x509svid.SVID) {
+	t.Helper()
+
+	ca, err := memidentity.NewCA(workloadID.TrustDomain(), true)
+	require.NoError(t, err)
+	svid, err := ca.Issue(workloadID, time.Hour)
+	require.NoError(t, err)
+
+	server := workloadapitest.New(t)
+	server.SetX509Bundles(ca.Bundle())
+	server.SetX509SVIDs(svid)
+	return server, ca, svid
+}
+
+func newClient(t *testing.T, server *workloadapitest.Server) *workloadapi.Client {
+	t.Helper()
+
+	client, err := workloadapi.New(context.Background(), workloadapi.WithAddr(server.Addr()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestServer_SPIFFEProvider(t *testing.T) {
+	server, ca, svid := newServer(t)
+
+	provider, err := spiffe.NewProvider(&ports.AgentConfig{
+		SocketPath: domain.NewSocketPathUnsafe(server.SocketPath()),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = provider.Close() })
+
+	id, err := provider.GetServiceIdentity()
+	require.NoError(t, err)
+	assert.Equal(t, workloadID, id)
+
+	cert, err := provider.GetCertificate()
+	require.NoError(t, err)
+	assert.Equal(t, svid.Certificates[0].SerialNumber, cert.Cert.SerialNumber)
+	assert.Len(t, cert.Chain, 1)
+
+	bundle, err := provider.GetTrustBundle()
+	require.NoError(t, err)
+	assert.True(t, bundle.Equal(ca.Bundle()))
+
+	// Rotations are pushed to the open stream
+	source, err := provider.X509Source(context.Background())
+	require.NoError(t, err)
+	rotated, err := ca.Issue(workloadID, time.Hour)
+	require.NoError(t, err)
+	server.SetX509SVIDs(rotated)
+	require.Eventually(t, func() bool {
+		current, err := source.GetX509SVID()
+		return err == nil && current.Certificates[0].SerialNumber.Cmp(rotated.Certificates[0].SerialNumber) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServer_IdentityVerifier(t *testing.T) {
+	server, _, _ := newServer(t)
+
+	verifier, err := verification.NewSpireIdentityVerifier(&ports.VerificationConfig{
+		WorkloadAPISocket: server.Addr(),
+		Timeout:           5 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = verifier.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := verifier.VerifyIdentity(ctx, workloadID)
+	require.NoError(t, err)
+	assert.True(t, result.Valid, result.Message)
+
+	result, err = verifier.VerifyIdentity(ctx, spiffeid.RequireFromString("spiffe://example.org/other"))
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+}
+
+func TestServer_JWT(t *testing.T) {
+	server := workloadapitest.New(t)
+	issuer, err := memidentity.NewJWTProvider(workloadID)
+	require.NoError(t, err)
+	server.SetJWTIssuer(issuer)
+	server.SetJWTBundles(issuer.Bundle())
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svid, err := client.FetchJWTSVID(ctx, jwtsvid.Params{Audience: "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, workloadID, svid.ID)
+
+	validated, err := client.ValidateJWTSVID(ctx, svid.Marshal(), "payments")
+	require.NoError(t, err)
+	assert.Equal(t, workloadID, validated.ID)
+
+	_, err = client.ValidateJWTSVID(ctx, svid.Marshal(), "billing")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	bundles, err := client.FetchJWTBundles(ctx)
+	require.NoError(t, err)
+	_, err = bundles.GetJWTBundleForTrustDomain(workloadID.TrustDomain())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, server.Calls(workloadapitest.FetchJWTSVID))
+	assert.Equal(t, 2, server.Calls(workloadapitest.ValidateJWTSVID))
+}
+
+func TestServer_InjectedErrorsAndDelays(t *testing.T) {
+	server, _, _ := newServer(t)
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	server.SetError(workloadapitest.FetchX509Bundles, status.Error(codes.Unavailable, "agent overloaded"))
+	_, err := client.FetchX509Bundles(ctx)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	server.SetError(workloadapitest.FetchX509Bundles, nil)
+	_, err = client.FetchX509Bundles(ctx)
+	assert.NoError(t, err)
+
+	server.SetDelay(time.Second)
+	shortCtx, shortCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shortCancel()
+	_, err = client.FetchX509SVID(shortCtx)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	server.SetDelay(0)
+
+	// A workload without a registration entry gets no SVID
+	server.SetX509SVIDs()
+	_, err = client.FetchX509SVID(ctx)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_StopAndStart(t *testing.T) {
+	server, ca, _ := newServer(t)
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(server.Addr())))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = source.Close() })
+
+	server.Stop()
+	shortCtx, shortCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer shortCancel()
+	_, err = client.FetchX509SVID(shortCtx)
+	assert.Error(t, err)
+
+	// The source keeps its last SVID while the agent is away and picks up
+	// rotations once it is back
+	_, err = source.GetX509SVID()
+	require.NoError(t, err)
+
+	rotated, err := ca.Issue(workloadID, time.Hour)
+	require.NoError(t, err)
+	server.SetX509SVIDs(rotated)
+	require.NoError(t, server.Start())
+
+	require.Eventually(t, func() bool {
+		current, err := source.GetX509SVID()
+		return err == nil && current.Certificates[0].SerialNumber.Cmp(rotated.Certificates[0].SerialNumber) == 0
+	}, 8*time.Second, 20*time.Millisecond)
+}
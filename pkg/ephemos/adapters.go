@@ -1,6 +1,12 @@
 package ephemos
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
 	"github.com/sufield/ephemos/internal/core/ports"
 )
 
@@ -38,8 +44,62 @@ func GetInternalConfig(config Configuration) (*ports.Configuration, error) {
 	if config == nil {
 		return nil, ErrConfigInvalid
 	}
-	if adapter, ok := config.(*configAdapter); ok && adapter.internal != nil {
-		return adapter.internal, nil
+	switch c := config.(type) {
+	case *configAdapter:
+		if c.internal != nil {
+			return c.internal, nil
+		}
+	case *ports.Configuration:
+		if c != nil {
+			return c, nil
+		}
 	}
 	return nil, ErrConfigInvalid
 }
+
+// identityProviderAdapter adapts an internal ports.IdentityProvider to the public IdentityService.
+type identityProviderAdapter struct {
+	provider ports.IdentityProvider
+}
+
+func (a *identityProviderAdapter) GetCertificate() (*Certificate, error) {
+	cert, err := a.provider.GetCertificate()
+	if err != nil {
+		return nil, err
+	}
+	return &Certificate{Cert: cert.Cert, Chain: cert.Chain, PrivateKey: cert.PrivateKey}, nil
+}
+
+func (a *identityProviderAdapter) GetTrustBundle() (*TrustBundle, error) {
+	bundle, err := a.provider.GetTrustBundle()
+	if err != nil {
+		return nil, err
+	}
+	return &TrustBundle{Certificates: bundle.X509Authorities()}, nil
+}
+
+// GetTrustBundleForTrustDomain returns the bundle for a local or federated trust domain.
+// Providers that do not offer the per-domain lookup of ports.BundleProviderPort serve
+// their single trust bundle.
+func (a *identityProviderAdapter) GetTrustBundleForTrustDomain(trustDomain string) (*TrustBundle, error) {
+	bundles, ok := a.provider.(interface {
+		GetTrustBundleForDomain(ctx context.Context, trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error)
+	})
+	if !ok {
+		return a.GetTrustBundle()
+	}
+
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIdentity, err)
+	}
+	bundle, err := bundles.GetTrustBundleForDomain(context.Background(), td)
+	if err != nil {
+		return nil, err
+	}
+	return &TrustBundle{Certificates: bundle.X509Authorities()}, nil
+}
+
+func (a *identityProviderAdapter) Close() error {
+	return a.provider.Close()
+}
@@ -3,22 +3,26 @@ package ephemos
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 func TestPublicAPI(t *testing.T) {
-	// Test that the public API compiles and basic interfaces work
-	ctx := context.Background()
+	// Test that the public API compiles and basic interfaces work.
+	// Without a SPIRE agent the Workload API dial blocks until the context ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
 
 	// Create a test configuration
 	config := &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "test-service",
+			Name:   domain.NewServiceNameUnsafe("test-service"),
 			Domain: "test.domain",
 		},
 		Agent: &ports.AgentConfig{
-			SocketPath: "/run/sockets/agent.sock",
+			SocketPath: domain.NewSocketPathUnsafe("/run/sockets/agent.sock"),
 		},
 	}
 
@@ -28,19 +32,17 @@ func TestPublicAPI(t *testing.T) {
 		t.Logf("IdentityClient returned error (expected without real SPIFFE setup): %v", err)
 	}
 
+	// Test Configuration struct
+	if config.Service.Name.Value() != "test-service" {
+		t.Error("Configuration struct not working properly")
+	}
+
 	// Test IdentityServer creation with configuration and address options
 	_, err = IdentityServer(ctx, WithServerConfig(config), WithAddress("localhost:0"))
 	if err != nil {
 		t.Logf("IdentityServer returned error (expected without real SPIFFE setup): %v", err)
 	}
 
-	// Note: Service registration is now CLI-only, not part of public API
-
-	// Test Configuration struct
-	if config.Service.Name.Value() != "test-service" {
-		t.Error("Configuration struct not working properly")
-	}
-
 	t.Log("Public API structure is working correctly")
 }
 
@@ -0,0 +1,197 @@
+package ephemos
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// AuditEvent is one entry of the audit trail: who connected, as whom, what was
+// decided and why. Sequence, PrevHash and Hash chain the entries together.
+type AuditEvent = ports.AuditEvent
+
+// AuditSink stores or forwards audit events.
+type AuditSink = ports.AuditSinkPort
+
+// AuditRingBuffer is an AuditSink that keeps the most recent events in memory.
+type AuditRingBuffer = audit.RingBuffer
+
+// Audit error classes reported in AuditEvent.ErrorClass.
+const (
+	AuditErrorNoCertificate   = ports.AuditErrorNoCertificate
+	AuditErrorInvalidIdentity = ports.AuditErrorInvalidIdentity
+	AuditErrorUntrusted       = ports.AuditErrorUntrusted
+	AuditErrorExpired         = ports.AuditErrorExpired
+	AuditErrorUnauthorized    = ports.AuditErrorUnauthorized
+	AuditErrorPolicyDenied    = ports.AuditErrorPolicyDenied
+	AuditErrorOther           = ports.AuditErrorOther
+
+	AuditErrorInvariantViolation = ports.AuditErrorInvariantViolation
+	AuditErrorRevoked            = ports.AuditErrorRevoked
+)
+
+// ErrAuditChainBroken is returned by VerifyAuditTrail when events were altered,
+// removed or reordered.
+var ErrAuditChainBroken = audit.ErrChainBroken
+
+// AuditLog records authentication and authorization decisions to its sinks as a
+// tamper-evident chain keyed with HMAC-SHA256. Events are written to the sinks in
+// the background, so handshakes and requests do not wait for a sink unless
+// AuditLogOptions.BlockTimeout is set. It is safe for concurrent use.
+type AuditLog struct {
+	trail *audit.Trail
+}
+
+// MinAuditKeySize is the minimum length of the audit chain key.
+const MinAuditKeySize = audit.MinKeySize
+
+// NewAuditLog creates an audit log writing to sinks. The key, at least
+// MinAuditKeySize bytes, authenticates the chain: without it an attacker able to
+// edit the audit files could rewrite and rehash every event. Keep it outside the
+// audit storage, e.g. in a secret store; VerifyAuditTrail needs the same key.
+// When a file sink holds events from an earlier run written with the key, the log
+// continues its chain.
+//
+// Example:
+//
+//	file, err := ephemos.NewFileAuditSink("/var/log/ephemos/audit.jsonl", 0, 0)
+//	if err != nil {
+//	    return err
+//	}
+//	auditLog, err := ephemos.NewAuditLog(key, file, ephemos.NewSlogAuditSink(nil))
+//	if err != nil {
+//	    return err
+//	}
+//	defer auditLog.Close()
+//	server, err := ephemos.IdentityServer(ctx, ephemos.WithAuditLog(auditLog), ...)
+func NewAuditLog(key []byte, sinks ...AuditSink) (*AuditLog, error) {
+	return NewAuditLogWithOptions(key, AuditLogOptions{}, sinks...)
+}
+
+// AuditLogOptions tunes the queue between recording and the sinks of an audit log.
+type AuditLogOptions struct {
+	// QueueSize is the number of events buffered while the sinks are written.
+	// Default: 1024.
+	QueueSize int
+	// BlockTimeout is how long recording waits for room in a full queue, delaying
+	// the handshake or request, before the event is dropped. Default: 0, events
+	// are dropped at once.
+	BlockTimeout time.Duration
+	// Metrics counts dropped events in ephemos_audit_events_dropped_total.
+	// Default: the default Prometheus registry.
+	Metrics *Metrics
+}
+
+// NewAuditLogWithOptions creates an audit log like NewAuditLog, with the queue
+// tuned by options.
+func NewAuditLogWithOptions(key []byte, options AuditLogOptions, sinks ...AuditSink) (*AuditLog, error) {
+	trail, err := audit.NewTrail(audit.TrailConfig{
+		Sinks:        sinks,
+		Key:          key,
+		QueueSize:    options.QueueSize,
+		BlockTimeout: options.BlockTimeout,
+		Metrics:      options.Metrics.prometheusMetrics(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return &AuditLog{trail: trail}, nil
+}
+
+// Record appends an event to the trail.
+func (l *AuditLog) Record(ctx context.Context, event AuditEvent) {
+	l.trail.RecordAuditEvent(ctx, event)
+}
+
+// Flush waits until the events recorded so far have been written to the sinks,
+// or until ctx is done.
+func (l *AuditLog) Flush(ctx context.Context) error {
+	return l.trail.Flush(ctx)
+}
+
+// Close writes the pending events and closes the sinks.
+func (l *AuditLog) Close() error {
+	return l.trail.Close()
+}
+
+// NewSlogAuditSink returns a sink that logs events with logger, or slog.Default() if nil.
+func NewSlogAuditSink(logger *slog.Logger) AuditSink {
+	return audit.NewSlogSink(logger)
+}
+
+// NewFileAuditSink returns a sink that appends events to path as JSON lines. The file
+// is rotated to path.1 ... path.N when it reaches maxSizeBytes. Zero values select
+// 100 MiB and 5 backups.
+func NewFileAuditSink(path string, maxSizeBytes int64, maxBackups int) (AuditSink, error) {
+	sink, err := audit.NewFileSink(audit.FileSinkConfig{
+		Path:         path,
+		MaxSizeBytes: maxSizeBytes,
+		MaxBackups:   maxBackups,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return sink, nil
+}
+
+// NewAuditRingBuffer returns a sink holding the newest capacity events, for tests.
+func NewAuditRingBuffer(capacity int) *AuditRingBuffer {
+	return audit.NewRingBuffer(capacity)
+}
+
+// ReadAuditFile reads the events of a JSON-lines audit file.
+func ReadAuditFile(path string) ([]AuditEvent, error) {
+	return audit.ReadFile(path)
+}
+
+// VerifyAuditTrail checks that events, oldest first, form an unbroken chain under the
+// key the audit log was created with.
+// To verify rotated files, concatenate them from the oldest backup to the active file.
+func VerifyAuditTrail(events []AuditEvent, key []byte) error {
+	return audit.VerifyChain(events, key)
+}
+
+// auditTLSConfig returns a copy of a server TLS config that records every client
+// certificate verification, accepted or rejected, including clients presenting no
+// certificate, as an authentication event.
+func auditTLSConfig(base *tls.Config, auditLog *AuditLog, authorizer Authorizer, localID string) *tls.Config {
+	return audit.ServerTLSConfig(base, audit.HandshakeAuditConfig{
+		Recorder:  auditLog.trail,
+		Transport: "http",
+		Rule:      ruleName(authorizer),
+		LocalID:   localID,
+	})
+}
+
+// certificateChain returns a function returning the current certificate chain of
+// identityService, or nil when it cannot be fetched.
+func certificateChain(identityService IdentityService) func() []*x509.Certificate {
+	return func() []*x509.Certificate {
+		cert, err := identityService.GetCertificate()
+		if err != nil || cert == nil || cert.Cert == nil {
+			return nil
+		}
+		return append([]*x509.Certificate{cert.Cert}, cert.Chain...)
+	}
+}
+
+// localIdentity returns the SPIFFE ID of the identity service certificate, if available.
+func localIdentity(identityService IdentityService) string {
+	cert, err := identityService.GetCertificate()
+	if err != nil || cert == nil || cert.Cert == nil {
+		return ""
+	}
+	id, err := x509svid.IDFromCert(cert.Cert)
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,141 @@
+package ephemos
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAuditKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIdentityServerHTTPMode_AuditsDecisions(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config, err := ParseConfiguration(context.Background(), []byte(policyYAML))
+	require.NoError(t, err)
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+
+	buffer := NewAuditRingBuffer(16)
+	auditLog, err := NewAuditLog(testAuditKey, buffer)
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithServerConfig(internal),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+		WithAuditLog(auditLog),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	baseURL := "https://" + listener.Addr().String()
+
+	client, err := NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/web"),
+		Authorizer:      AuthorizeID("spiffe://example.org/server"),
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Get(baseURL + "/admin/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	outsider, err := NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://partner.org/client"),
+	})
+	require.NoError(t, err)
+	_, err = outsider.Get(baseURL + "/api/orders")
+	require.Error(t, err)
+
+	// A client without a certificate is rejected during the handshake and audited
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true, // For testing only
+		MinVersion:         tls.VersionTLS13,
+	})
+	if err == nil {
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	require.Error(t, err)
+
+	require.NoError(t, auditLog.Flush(context.Background()))
+	events := buffer.Events()
+	require.Len(t, events, 4)
+	require.NoError(t, VerifyAuditTrail(events, testAuditKey))
+
+	accepted, denied, rejected, anonymous := events[0], events[1], events[2], events[3]
+
+	assert.Equal(t, "authentication", string(accepted.Kind))
+	assert.Equal(t, "allow", string(accepted.Decision))
+	assert.Equal(t, "spiffe://example.org/web", accepted.PeerID)
+	assert.Equal(t, "spiffe://example.org/server", accepted.LocalID)
+	assert.NotEmpty(t, accepted.PeerSerial)
+	assert.False(t, accepted.PeerExpiry.IsZero())
+	assert.NotEmpty(t, accepted.RemoteAddr)
+	assert.Equal(t, `member_of("example.org")`, accepted.Rule)
+
+	assert.Equal(t, "authorization", string(denied.Kind))
+	assert.Equal(t, "deny", string(denied.Decision))
+	assert.Equal(t, "GET /admin/users", denied.Operation)
+	assert.Equal(t, "default", denied.Rule)
+	assert.Equal(t, AuditErrorPolicyDenied, denied.ErrorClass)
+
+	assert.Equal(t, "authentication", string(rejected.Kind))
+	assert.Equal(t, "deny", string(rejected.Decision))
+	assert.Equal(t, "spiffe://partner.org/client", rejected.PeerID)
+	assert.Equal(t, AuditErrorUnauthorized, rejected.ErrorClass)
+
+	assert.Equal(t, "authentication", string(anonymous.Kind))
+	assert.Equal(t, "deny", string(anonymous.Decision))
+	assert.Empty(t, anonymous.PeerID)
+	assert.Equal(t, AuditErrorNoCertificate, anonymous.ErrorClass)
+}
+
+func TestFileAuditSink_VerifiesAfterReading(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileAuditSink(path, 0, 0)
+	require.NoError(t, err)
+	auditLog, err := NewAuditLog(testAuditKey, sink)
+	require.NoError(t, err)
+
+	auditLog.Record(context.Background(), AuditEvent{Kind: "authorization", Decision: "allow", PeerID: "spiffe://example.org/a"})
+	auditLog.Record(context.Background(), AuditEvent{Kind: "authorization", Decision: "deny", PeerID: "spiffe://example.org/b"})
+	require.NoError(t, auditLog.Close())
+
+	events, err := ReadAuditFile(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.NoError(t, VerifyAuditTrail(events, testAuditKey))
+	assert.ErrorIs(t, VerifyAuditTrail(events, []byte("another key of thirty-two bytes!")), ErrAuditChainBroken)
+
+	events[0].Decision = "allow"
+	events[1].Decision = "allow"
+	assert.ErrorIs(t, VerifyAuditTrail(events, testAuditKey), ErrAuditChainBroken)
+}
+
+func TestNewAuditLog_RequiresSinkAndKey(t *testing.T) {
+	_, err := NewAuditLog(testAuditKey)
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+
+	_, err = NewAuditLog(nil, NewAuditRingBuffer(1))
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+}
@@ -0,0 +1,368 @@
+package ephemos
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+)
+
+// AuthorizationError is returned when a peer fails an authorization rule.
+// Rule names the rule that rejected the peer, e.g. `member_of("prod.company.com")`.
+// It matches ErrUnauthorized with errors.Is.
+type AuthorizationError struct {
+	// Rule is the rule that rejected the peer.
+	Rule string
+	// PeerID is the SPIFFE ID of the peer, empty if it could not be determined.
+	PeerID string
+	// Reason explains why the rule rejected the peer.
+	Reason string
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+func (e *AuthorizationError) Error() string {
+	msg := fmt.Sprintf("authorization rule %s rejected peer", e.Rule)
+	if e.PeerID != "" {
+		msg = fmt.Sprintf("%s %q", msg, e.PeerID)
+	}
+	if e.Reason != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Reason)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Is reports whether the target is ErrUnauthorized.
+func (e *AuthorizationError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// Unwrap returns the underlying cause.
+func (e *AuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// reasonInvalidRule is the AuthorizationError reason for rules that cannot be evaluated.
+const reasonInvalidRule = "invalid rule"
+
+// isRejection reports whether err is a rule rejecting an identified peer, as opposed
+// to a failure to evaluate the rule. Only rejections may be negated.
+func isRejection(err error) bool {
+	var authErr *AuthorizationError
+	return errors.As(err, &authErr) && authErr.PeerID != "" && authErr.Reason != reasonInvalidRule
+}
+
+// ruleAuthorizer is an Authorizer that checks the peer SPIFFE ID against a single named rule.
+// Malformed rule arguments are recorded in invalid so that the authorizer fails closed.
+type ruleAuthorizer struct {
+	rule    string
+	invalid error
+	check   func(id spiffeid.ID) (reason string, ok bool)
+}
+
+func (r *ruleAuthorizer) Authorize(certificates []*x509.Certificate) error {
+	if r.invalid != nil {
+		return &AuthorizationError{Rule: r.rule, Reason: reasonInvalidRule, Err: r.invalid}
+	}
+
+	id, err := peerID(certificates)
+	if err != nil {
+		return &AuthorizationError{Rule: r.rule, Err: err}
+	}
+
+	if reason, ok := r.check(id); !ok {
+		return &AuthorizationError{Rule: r.rule, PeerID: id.String(), Reason: reason}
+	}
+	return nil
+}
+
+// String returns the rule description.
+func (r *ruleAuthorizer) String() string {
+	return r.rule
+}
+
+// AuthorizeID returns an Authorizer that only accepts the given SPIFFE ID.
+// A malformed ID produces an Authorizer that rejects every peer.
+//
+// Example:
+//
+//	authorizer := ephemos.AuthorizeID("spiffe://prod.company.com/payment-service")
+func AuthorizeID(spiffeID string) Authorizer {
+	rule := fmt.Sprintf("id(%q)", spiffeID)
+	expected, err := spiffeid.FromString(spiffeID)
+	if err != nil {
+		return &ruleAuthorizer{rule: rule, invalid: err}
+	}
+
+	return &ruleAuthorizer{rule: rule, check: func(id spiffeid.ID) (string, bool) {
+		return "peer ID does not match", id == expected
+	}}
+}
+
+// AuthorizeOneOf returns an Authorizer that accepts any of the given SPIFFE IDs.
+// An empty set or any malformed ID produces an Authorizer that rejects every peer.
+func AuthorizeOneOf(spiffeIDs ...string) Authorizer {
+	rule := fmt.Sprintf("one_of(%s)", quoteList(spiffeIDs))
+	if len(spiffeIDs) == 0 {
+		return &ruleAuthorizer{rule: rule, invalid: fmt.Errorf("at least one SPIFFE ID is required")}
+	}
+
+	allowed := make(map[spiffeid.ID]struct{}, len(spiffeIDs))
+	for _, s := range spiffeIDs {
+		id, err := spiffeid.FromString(s)
+		if err != nil {
+			return &ruleAuthorizer{rule: rule, invalid: fmt.Errorf("invalid SPIFFE ID %q: %w", s, err)}
+		}
+		allowed[id] = struct{}{}
+	}
+
+	return &ruleAuthorizer{rule: rule, check: func(id spiffeid.ID) (string, bool) {
+		_, ok := allowed[id]
+		return "peer ID is not in the allowed set", ok
+	}}
+}
+
+// AuthorizeMemberOf returns an Authorizer that accepts any SPIFFE ID in the given trust domain.
+// A malformed trust domain produces an Authorizer that rejects every peer.
+//
+// Example:
+//
+//	authorizer := ephemos.AuthorizeMemberOf("prod.company.com")
+func AuthorizeMemberOf(trustDomain string) Authorizer {
+	rule := fmt.Sprintf("member_of(%q)", trustDomain)
+	td, err := spiffeid.TrustDomainFromString(trustDomain)
+	if err != nil {
+		return &ruleAuthorizer{rule: rule, invalid: err}
+	}
+
+	return &ruleAuthorizer{rule: rule, check: func(id spiffeid.ID) (string, bool) {
+		return "peer is not a member of the trust domain", id.MemberOf(td)
+	}}
+}
+
+// AuthorizePathPrefix returns an Authorizer that accepts the given SPIFFE ID and
+// every ID below it in the path hierarchy. Matching follows path segments, so
+// "spiffe://example.org/payments" accepts "spiffe://example.org/payments/api"
+// but not "spiffe://example.org/payments-legacy".
+// A malformed prefix produces an Authorizer that rejects every peer.
+func AuthorizePathPrefix(spiffeIDPrefix string) Authorizer {
+	rule := fmt.Sprintf("path_prefix(%q)", spiffeIDPrefix)
+	parent, err := domain.NewIdentityNamespaceFromString(spiffeIDPrefix)
+	if err != nil {
+		return &ruleAuthorizer{rule: rule, invalid: err}
+	}
+
+	return &ruleAuthorizer{rule: rule, check: func(id spiffeid.ID) (string, bool) {
+		ns, err := domain.NewIdentityNamespaceFromString(id.String())
+		if err != nil {
+			return fmt.Sprintf("peer ID is not a valid identity namespace: %v", err), false
+		}
+		return "peer ID is outside the path prefix", ns.Equals(parent) || ns.IsChildOf(parent)
+	}}
+}
+
+// AuthorizeGlob returns an Authorizer that accepts SPIFFE IDs matching any of the
+// given glob patterns. Patterns use path.Match syntax against the full SPIFFE ID,
+// so '*' matches within a single path segment:
+//
+//	ephemos.AuthorizeGlob("spiffe://prod.company.com/ns/*/sa/frontend")
+//
+// An empty pattern list or any malformed pattern produces an Authorizer that rejects every peer.
+func AuthorizeGlob(patterns ...string) Authorizer {
+	rule := fmt.Sprintf("glob(%s)", quoteList(patterns))
+	if len(patterns) == 0 {
+		return &ruleAuthorizer{rule: rule, invalid: fmt.Errorf("at least one pattern is required")}
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ruleAuthorizer{rule: rule, invalid: fmt.Errorf("invalid pattern %q: %w", pattern, err)}
+		}
+	}
+
+	return &ruleAuthorizer{rule: rule, check: func(id spiffeid.ID) (string, bool) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, id.String()); ok {
+				return "", true
+			}
+		}
+		return "peer ID matches no pattern", false
+	}}
+}
+
+// AuthorizeAllOf returns an Authorizer that accepts a peer only if every given
+// Authorizer accepts it. The error of the first rejecting Authorizer is returned.
+func AuthorizeAllOf(authorizers ...Authorizer) Authorizer {
+	return &combinedAuthorizer{op: "all_of", authorizers: authorizers}
+}
+
+// AuthorizeAnyOf returns an Authorizer that accepts a peer if at least one of the
+// given Authorizers accepts it. If none accepts it and one of them could not be
+// evaluated, that error is returned.
+func AuthorizeAnyOf(authorizers ...Authorizer) Authorizer {
+	return &combinedAuthorizer{op: "any_of", authorizers: authorizers}
+}
+
+// AuthorizeNot returns an Authorizer that accepts exactly the peers the given Authorizer rejects.
+// Peers whose SPIFFE ID cannot be determined are always rejected, and errors other than
+// a rejection of the peer, such as an invalid rule, are returned unchanged.
+//
+// Only the Authorizers of this package and their combinations can be negated, because
+// a custom Authorizer's error does not tell a rejection from a failure to decide.
+// Negating any other Authorizer produces an Authorizer that rejects every peer.
+func AuthorizeNot(authorizer Authorizer) Authorizer {
+	n := &notAuthorizer{authorizer: authorizer}
+	if authorizer != nil && !negatable(authorizer) {
+		n.invalid = fmt.Errorf("%s authorizer cannot be negated, only the authorizers of this package can", ruleName(authorizer))
+	}
+	return n
+}
+
+// negatable reports whether authorizer reports every rejection as an AuthorizationError
+// for the peer, which holds for the authorizers built by this package.
+func negatable(authorizer Authorizer) bool {
+	switch a := authorizer.(type) {
+	case *ruleAuthorizer, *notAuthorizer, *authorizerAdapter:
+		return true
+	case *combinedAuthorizer:
+		for _, inner := range a.authorizers {
+			if inner != nil && !negatable(inner) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// combinedAuthorizer implements the AND and OR combinators.
+type combinedAuthorizer struct {
+	op          string
+	authorizers []Authorizer
+}
+
+func (c *combinedAuthorizer) Authorize(certificates []*x509.Certificate) error {
+	if len(c.authorizers) == 0 {
+		return &AuthorizationError{Rule: c.String(), Reason: reasonInvalidRule, Err: fmt.Errorf("no authorizers given")}
+	}
+
+	var reasons []string
+	var failure error
+	for _, authorizer := range c.authorizers {
+		if authorizer == nil {
+			return &AuthorizationError{Rule: c.String(), Reason: reasonInvalidRule, Err: fmt.Errorf("nil authorizer")}
+		}
+
+		err := authorizer.Authorize(certificates)
+		switch {
+		case c.op == "all_of" && err != nil:
+			return err
+		case c.op == "any_of" && err == nil:
+			return nil
+		case err != nil && !isRejection(err) && failure == nil:
+			failure = err
+		case err != nil:
+			reasons = append(reasons, err.Error())
+		}
+	}
+
+	if c.op == "all_of" {
+		return nil
+	}
+	if failure != nil {
+		return failure
+	}
+
+	authErr := &AuthorizationError{Rule: c.String(), Reason: strings.Join(reasons, "; ")}
+	if id, err := peerID(certificates); err == nil {
+		authErr.PeerID = id.String()
+	}
+	return authErr
+}
+
+// String returns the rule description.
+func (c *combinedAuthorizer) String() string {
+	names := make([]string, len(c.authorizers))
+	for i, authorizer := range c.authorizers {
+		names[i] = ruleName(authorizer)
+	}
+	return fmt.Sprintf("%s(%s)", c.op, strings.Join(names, ", "))
+}
+
+// notAuthorizer implements the NOT combinator.
+type notAuthorizer struct {
+	authorizer Authorizer
+	invalid    error
+}
+
+func (n *notAuthorizer) Authorize(certificates []*x509.Certificate) error {
+	if n.authorizer == nil {
+		return &AuthorizationError{Rule: n.String(), Reason: reasonInvalidRule, Err: fmt.Errorf("nil authorizer")}
+	}
+	if n.invalid != nil {
+		return &AuthorizationError{Rule: n.String(), Reason: reasonInvalidRule, Err: n.invalid}
+	}
+
+	// Never turn "could not identify the peer" into an acceptance.
+	id, err := peerID(certificates)
+	if err != nil {
+		return &AuthorizationError{Rule: n.String(), Err: err}
+	}
+
+	err = n.authorizer.Authorize(certificates)
+	switch {
+	case err == nil:
+		return &AuthorizationError{Rule: n.String(), PeerID: id.String(), Reason: "peer matches excluded rule"}
+	case isRejection(err):
+		return nil
+	default:
+		return err
+	}
+}
+
+// String returns the rule description.
+func (n *notAuthorizer) String() string {
+	return fmt.Sprintf("not(%s)", ruleName(n.authorizer))
+}
+
+// peerID extracts the SPIFFE ID from the leaf of a peer certificate chain.
+func peerID(certificates []*x509.Certificate) (spiffeid.ID, error) {
+	if len(certificates) == 0 || certificates[0] == nil {
+		return spiffeid.ID{}, fmt.Errorf("no peer certificates presented")
+	}
+	id, err := x509svid.IDFromCert(certificates[0])
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("failed to extract SPIFFE ID from peer certificate: %w", err)
+	}
+	return id, nil
+}
+
+// ruleName describes an Authorizer for error messages.
+func ruleName(authorizer Authorizer) string {
+	if s, ok := authorizer.(fmt.Stringer); ok {
+		return s.String()
+	}
+	if authorizer == nil {
+		return "nil"
+	}
+	return "custom"
+}
+
+// quoteList formats values as a comma-separated list of quoted strings.
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
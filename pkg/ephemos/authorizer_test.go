@@ -0,0 +1,146 @@
+package ephemos
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peerChain returns a certificate chain whose leaf carries the given SPIFFE ID.
+// Authorizers only inspect the URI SAN, so the certificate does not need to be signed.
+func peerChain(t *testing.T, spiffeID string) []*x509.Certificate {
+	t.Helper()
+	u, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+	return []*x509.Certificate{{URIs: []*url.URL{u}}}
+}
+
+// authorizerFunc is a custom Authorizer.
+type authorizerFunc func([]*x509.Certificate) error
+
+func (f authorizerFunc) Authorize(certificates []*x509.Certificate) error { return f(certificates) }
+
+func TestAuthorizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		authorizer Authorizer
+		peer       string
+		wantErr    bool
+		wantRule   string
+	}{
+		{"id match", AuthorizeID("spiffe://example.org/api"), "spiffe://example.org/api", false, ""},
+		{"id mismatch", AuthorizeID("spiffe://example.org/api"), "spiffe://example.org/web", true, `id("spiffe://example.org/api")`},
+		{"one of match", AuthorizeOneOf("spiffe://example.org/a", "spiffe://example.org/b"), "spiffe://example.org/b", false, ""},
+		{"one of mismatch", AuthorizeOneOf("spiffe://example.org/a"), "spiffe://example.org/c", true, `one_of("spiffe://example.org/a")`},
+		{"member of", AuthorizeMemberOf("example.org"), "spiffe://example.org/anything", false, ""},
+		{"member of foreign", AuthorizeMemberOf("example.org"), "spiffe://partner.org/api", true, `member_of("example.org")`},
+		{"path prefix itself", AuthorizePathPrefix("spiffe://example.org/payments"), "spiffe://example.org/payments", false, ""},
+		{"path prefix child", AuthorizePathPrefix("spiffe://example.org/payments"), "spiffe://example.org/payments/api", false, ""},
+		{"path prefix sibling", AuthorizePathPrefix("spiffe://example.org/payments"), "spiffe://example.org/payments-legacy", true, `path_prefix("spiffe://example.org/payments")`},
+		{"path prefix other domain", AuthorizePathPrefix("spiffe://example.org/payments"), "spiffe://partner.org/payments/api", true, `path_prefix("spiffe://example.org/payments")`},
+		{"glob match", AuthorizeGlob("spiffe://example.org/ns/*/sa/web"), "spiffe://example.org/ns/prod/sa/web", false, ""},
+		{"glob does not cross segments", AuthorizeGlob("spiffe://example.org/ns/*"), "spiffe://example.org/ns/prod/sa/web", true, `glob("spiffe://example.org/ns/*")`},
+		{"invalid id fails closed", AuthorizeID("not-a-spiffe-id"), "spiffe://example.org/api", true, `id("not-a-spiffe-id")`},
+		{"invalid glob fails closed", AuthorizeGlob("spiffe://example.org/["), "spiffe://example.org/api", true, `glob("spiffe://example.org/[")`},
+		{"empty set fails closed", AuthorizeOneOf(), "spiffe://example.org/api", true, "one_of()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.authorizer.Authorize(peerChain(t, tt.peer))
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrUnauthorized))
+			var authErr *AuthorizationError
+			require.True(t, errors.As(err, &authErr))
+			assert.Equal(t, tt.wantRule, authErr.Rule)
+		})
+	}
+}
+
+func TestAuthorizerCombinators(t *testing.T) {
+	inDomain := AuthorizeMemberOf("example.org")
+	admin := AuthorizePathPrefix("spiffe://example.org/admin")
+
+	t.Run("all of", func(t *testing.T) {
+		authorizer := AuthorizeAllOf(inDomain, AuthorizeNot(admin))
+		assert.NoError(t, authorizer.Authorize(peerChain(t, "spiffe://example.org/api")))
+
+		err := authorizer.Authorize(peerChain(t, "spiffe://example.org/admin/root"))
+		var authErr *AuthorizationError
+		require.True(t, errors.As(err, &authErr))
+		assert.Equal(t, `not(path_prefix("spiffe://example.org/admin"))`, authErr.Rule)
+		assert.Equal(t, "spiffe://example.org/admin/root", authErr.PeerID)
+	})
+
+	t.Run("any of", func(t *testing.T) {
+		authorizer := AuthorizeAnyOf(AuthorizeID("spiffe://partner.org/api"), admin)
+		assert.NoError(t, authorizer.Authorize(peerChain(t, "spiffe://partner.org/api")))
+		assert.NoError(t, authorizer.Authorize(peerChain(t, "spiffe://example.org/admin/ops")))
+
+		err := authorizer.Authorize(peerChain(t, "spiffe://example.org/api"))
+		var authErr *AuthorizationError
+		require.True(t, errors.As(err, &authErr))
+		assert.Equal(t, `any_of(id("spiffe://partner.org/api"), path_prefix("spiffe://example.org/admin"))`, authErr.Rule)
+	})
+
+	t.Run("empty combinators fail closed", func(t *testing.T) {
+		assert.True(t, errors.Is(AuthorizeAllOf().Authorize(peerChain(t, "spiffe://example.org/api")), ErrUnauthorized))
+		assert.True(t, errors.Is(AuthorizeAnyOf().Authorize(peerChain(t, "spiffe://example.org/api")), ErrUnauthorized))
+		assert.True(t, errors.Is(AuthorizeNot(nil).Authorize(peerChain(t, "spiffe://example.org/api")), ErrUnauthorized))
+	})
+
+	t.Run("not rejects unidentifiable peers", func(t *testing.T) {
+		err := AuthorizeNot(admin).Authorize([]*x509.Certificate{{}})
+		assert.True(t, errors.Is(err, ErrUnauthorized))
+	})
+	t.Run("not passes through errors other than rejections", func(t *testing.T) {
+		peer := peerChain(t, "spiffe://example.org/api")
+
+		err := AuthorizeNot(AuthorizeID("not-a-spiffe-id")).Authorize(peer)
+		var authErr *AuthorizationError
+		require.True(t, errors.As(err, &authErr))
+		assert.Equal(t, `id("not-a-spiffe-id")`, authErr.Rule)
+
+		// A rule that could not be evaluated is not hidden by another rule's rejection
+		err = AuthorizeNot(AuthorizeAnyOf(AuthorizeID("spiffe://example.org/web"), AuthorizeID("not-a-spiffe-id"))).Authorize(peer)
+		require.True(t, errors.As(err, &authErr))
+		assert.Equal(t, `id("not-a-spiffe-id")`, authErr.Rule)
+	})
+
+	t.Run("not rejects every peer for custom authorizers", func(t *testing.T) {
+		api := peerChain(t, "spiffe://example.org/api")
+		admin := peerChain(t, "spiffe://example.org/admin")
+		onlyAdmin := authorizerFunc(func(certificates []*x509.Certificate) error {
+			if id, err := peerID(certificates); err == nil && id.Path() == "/admin" {
+				return nil
+			}
+			return errors.New("not the admin")
+		})
+
+		for _, authorizer := range []Authorizer{
+			AuthorizeNot(onlyAdmin),
+			AuthorizeNot(AuthorizeAnyOf(AuthorizeID("spiffe://example.org/web"), onlyAdmin)),
+		} {
+			for _, peer := range [][]*x509.Certificate{api, admin} {
+				err := authorizer.Authorize(peer)
+				var authErr *AuthorizationError
+				require.True(t, errors.As(err, &authErr))
+				assert.Equal(t, reasonInvalidRule, authErr.Reason)
+			}
+		}
+	})
+
+	t.Run("rule descriptions", func(t *testing.T) {
+		assert.Equal(t, "not(any())", ruleName(AuthorizeNot(AuthorizeAny())))
+		assert.Error(t, AuthorizeNot(AuthorizeAny()).Authorize(peerChain(t, "spiffe://example.org/api")))
+	})
+}
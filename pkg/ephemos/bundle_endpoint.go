@@ -0,0 +1,95 @@
+package ephemos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/sufield/ephemos/internal/factory"
+)
+
+// BundleEndpoint publishes this service's trust bundle in SPIFFE bundle format, so
+// partner trust domains can federate with it. The bundle follows rotations from the
+// Workload API and carries spiffe_sequence and spiffe_refresh_hint.
+//
+// A BundleEndpoint is an http.Handler and can be mounted on an existing server,
+// or it can serve standalone with ListenAndServe.
+type BundleEndpoint interface {
+	http.Handler
+
+	// ListenAndServe serves the bundle over HTTPS using the configured profile
+	// until the context is cancelled.
+	ListenAndServe(ctx context.Context) error
+
+	// Close stops following bundle updates and releases resources.
+	Close() error
+}
+
+// IdentityBundleEndpoint creates a bundle endpoint from the federation.bundle_endpoint
+// configuration section. WithListener and WithAddress override the configured address.
+//
+// Example:
+//
+//	endpoint, err := ephemos.IdentityBundleEndpoint(ctx, ephemos.WithServerConfig(config))
+//	if err != nil { return err }
+//	defer endpoint.Close()
+//
+//	return endpoint.ListenAndServe(ctx)
+func IdentityBundleEndpoint(ctx context.Context, opts ...ServerOption) (BundleEndpoint, error) {
+	options := &serverOpts{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	config, err := loadServerConfig(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+
+	federation := config.Federation
+	if federation == nil || federation.BundleEndpoint == nil {
+		return nil, fmt.Errorf("%w: federation.bundle_endpoint is not configured", ErrConfigInvalid)
+	}
+
+	address := options.Address
+	if address == "" {
+		address = federation.BundleEndpoint.Address
+	}
+
+	endpoint, err := factory.SPIFFEBundleEndpoint(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle endpoint: %w", err)
+	}
+
+	return &bundleEndpointWrapper{endpoint: endpoint, listener: options.Listener, address: address}, nil
+}
+
+// bundleEndpointWrapper adapts the factory bundle endpoint to the public interface.
+type bundleEndpointWrapper struct {
+	endpoint *factory.BundleEndpoint
+	listener net.Listener
+	address  string
+}
+
+func (w *bundleEndpointWrapper) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	w.endpoint.ServeHTTP(rw, r)
+}
+
+func (w *bundleEndpointWrapper) ListenAndServe(ctx context.Context) error {
+	listener := w.listener
+	if listener == nil {
+		var err error
+		listener, err = net.Listen("tcp", w.address)
+		if err != nil {
+			return fmt.Errorf("%w: failed to listen on %s: %v", ErrInvalidAddress, w.address, err)
+		}
+	}
+	defer listener.Close()
+
+	return w.endpoint.Serve(ctx, listener)
+}
+
+func (w *bundleEndpointWrapper) Close() error {
+	return w.endpoint.Close()
+}
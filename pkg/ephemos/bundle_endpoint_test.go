@@ -0,0 +1,26 @@
+package ephemos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestIdentityBundleEndpoint_RequiresConfiguration(t *testing.T) {
+	_, err := IdentityBundleEndpoint(context.Background())
+	assert.True(t, errors.Is(err, ErrConfigInvalid))
+
+	config := &ports.Configuration{
+		Service: ports.ServiceConfig{
+			Name:   domain.NewServiceNameUnsafe("test-service"),
+			Domain: "example.org",
+		},
+	}
+	_, err = IdentityBundleEndpoint(context.Background(), WithServerConfig(config))
+	assert.True(t, errors.Is(err, ErrConfigInvalid))
+}
@@ -0,0 +1,85 @@
+package ephemos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/config"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// ConfigLoaderFunc adapts an ordinary function to the ConfigLoader interface.
+//
+// Example:
+//
+//	loader := ephemos.ConfigLoaderFunc(func(ctx context.Context, source string) (ephemos.Configuration, error) {
+//	    data, err := configService.Fetch(ctx, source)
+//	    if err != nil { return nil, err }
+//	    return ephemos.ParseConfiguration(ctx, data)
+//	})
+type ConfigLoaderFunc func(ctx context.Context, source string) (Configuration, error)
+
+// LoadConfiguration calls f(ctx, source).
+func (f ConfigLoaderFunc) LoadConfiguration(ctx context.Context, source string) (Configuration, error) {
+	return f(ctx, source)
+}
+
+// FileConfigLoader returns a loader that reads a YAML configuration file.
+// The source is the file path. EPHEMOS_* environment variables override file values.
+func FileConfigLoader() ConfigLoader {
+	return ConfigLoaderFunc(func(ctx context.Context, source string) (Configuration, error) {
+		cfg, err := config.NewFileProvider().LoadConfiguration(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return NewConfiguration(cfg), nil
+	})
+}
+
+// EnvConfigLoader returns a loader that builds the configuration from EPHEMOS_*
+// environment variables only. The source is ignored.
+func EnvConfigLoader() ConfigLoader {
+	return ConfigLoaderFunc(func(ctx context.Context, _ string) (Configuration, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cfg, err := ports.LoadFromEnvironment()
+		if err != nil {
+			return nil, err
+		}
+		return NewConfiguration(cfg), nil
+	})
+}
+
+// YAMLConfigLoader returns a loader for inline configuration.
+// The source is the YAML document itself, not a path.
+func YAMLConfigLoader() ConfigLoader {
+	return ConfigLoaderFunc(func(ctx context.Context, source string) (Configuration, error) {
+		return ParseConfiguration(ctx, []byte(source))
+	})
+}
+
+// ParseConfiguration parses and validates a YAML configuration document.
+// Third-party loaders that fetch configuration from elsewhere use it to build
+// the Configuration they return.
+func ParseConfiguration(ctx context.Context, data []byte) (Configuration, error) {
+	cfg, err := config.NewFileProvider().LoadConfigurationFromBytes(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return NewConfiguration(cfg), nil
+}
+
+// loadFromLoader runs a ConfigLoader and unwraps the result for the factories.
+func loadFromLoader(ctx context.Context, loader ConfigLoader, source string) (*ports.Configuration, error) {
+	loaded, err := loader.LoadConfiguration(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("config loader failed: %w", err)
+	}
+
+	cfg, err := GetInternalConfig(loaded)
+	if err != nil {
+		return nil, fmt.Errorf("config loader returned a configuration not built by ParseConfiguration or a built-in loader: %w", err)
+	}
+	return cfg, nil
+}
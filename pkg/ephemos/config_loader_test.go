@@ -0,0 +1,100 @@
+package ephemos
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inlineConfig = `
+service:
+  name: "loader-service"
+  domain: "example.org"
+`
+
+func TestBuiltInConfigLoaders(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(inlineConfig), 0o600))
+
+		cfg, err := loadClientConfig(ctx, &clientOpts{Loader: FileConfigLoader(), ConfigSource: path})
+		require.NoError(t, err)
+		assert.Equal(t, "loader-service", cfg.Service.Name.Value())
+	})
+
+	t.Run("inline yaml", func(t *testing.T) {
+		cfg, err := loadServerConfig(ctx, &serverOpts{Loader: YAMLConfigLoader(), ConfigSource: inlineConfig})
+		require.NoError(t, err)
+		assert.Equal(t, "example.org", cfg.Service.Domain)
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("EPHEMOS_SERVICE_NAME", "env-service")
+		t.Setenv("EPHEMOS_TRUST_DOMAIN", "env.example")
+
+		cfg, err := loadClientConfig(ctx, &clientOpts{Loader: EnvConfigLoader()})
+		require.NoError(t, err)
+		assert.Equal(t, "env-service", cfg.Service.Name.Value())
+		assert.Equal(t, "env.example", cfg.Service.Domain)
+	})
+
+	t.Run("loader errors are reported", func(t *testing.T) {
+		_, err := loadClientConfig(ctx, &clientOpts{Loader: FileConfigLoader()})
+		assert.Error(t, err)
+	})
+}
+
+func TestThirdPartyConfigLoader(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("configuration built with ParseConfiguration", func(t *testing.T) {
+		var gotSource string
+		loader := ConfigLoaderFunc(func(ctx context.Context, source string) (Configuration, error) {
+			gotSource = source
+			return ParseConfiguration(ctx, []byte(inlineConfig))
+		})
+
+		options := &clientOpts{}
+		WithConfigSource(loader, "configsvc://payments")(options)
+		cfg, err := loadClientConfig(ctx, options)
+		require.NoError(t, err)
+		assert.Equal(t, "configsvc://payments", gotSource)
+		assert.Equal(t, "loader-service", cfg.Service.Name.Value())
+	})
+
+	t.Run("foreign configuration is rejected", func(t *testing.T) {
+		loader := ConfigLoaderFunc(func(context.Context, string) (Configuration, error) {
+			return foreignConfiguration{}, nil
+		})
+
+		_, err := IdentityClient(ctx, WithConfigSource(loader, "anything"))
+		assert.True(t, errors.Is(err, ErrConfigInvalid))
+	})
+
+	t.Run("explicit configuration wins", func(t *testing.T) {
+		explicit, err := ParseConfiguration(ctx, []byte(inlineConfig))
+		require.NoError(t, err)
+		internal, err := GetInternalConfig(explicit)
+		require.NoError(t, err)
+
+		failing := ConfigLoaderFunc(func(context.Context, string) (Configuration, error) {
+			return nil, errors.New("should not be called")
+		})
+		cfg, err := loadServerConfig(ctx, &serverOpts{Config: internal, Loader: failing})
+		require.NoError(t, err)
+		assert.Same(t, internal, cfg)
+	})
+}
+
+// foreignConfiguration implements Configuration without being loadable.
+type foreignConfiguration struct{}
+
+func (foreignConfiguration) Validate() error          { return nil }
+func (foreignConfiguration) IsProductionReady() error { return nil }
@@ -7,27 +7,28 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sufield/ephemos/internal/core/domain"
 	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 // Compile-time interface conformance checks
 var (
-	_ Client                    = (*clientWrapper)(nil)
-	_ Server                    = (*serverWrapper)(nil)
-	_ ports.Dialer              = (*mockDialer)(nil)
-	_ ports.Conn                = (*mockConn)(nil)
-	_ ports.AuthenticatedServer = (*mockServerPort)(nil)
-	_ ConfigLoader              = (*mockConfigLoader)(nil)
+	_ Client                        = (*clientWrapper)(nil)
+	_ Server                        = (*serverWrapper)(nil)
+	_ ports.DialerPort              = (*mockDialer)(nil)
+	_ ports.ConnPort                = (*mockConn)(nil)
+	_ ports.AuthenticatedServerPort = (*mockServerPort)(nil)
+	_ ConfigLoader                  = (*mockConfigLoader)(nil)
 )
 
 // Mock implementations for testing
 
 type mockDialer struct {
-	connectFunc func(ctx context.Context, serviceName, address string) (ports.Conn, error)
+	connectFunc func(ctx context.Context, serviceName, address string) (ports.ConnPort, error)
 	closeFunc   func() error
 }
 
-func (m *mockDialer) Connect(ctx context.Context, serviceName, address string) (ports.Conn, error) {
+func (m *mockDialer) Connect(ctx context.Context, serviceName, address string) (ports.ConnPort, error) {
 	if m.connectFunc != nil {
 		return m.connectFunc(ctx, serviceName, address)
 	}
@@ -42,15 +43,23 @@ func (m *mockDialer) Close() error {
 }
 
 type mockConn struct {
-	httpClientFunc func() (*http.Client, error)
-	closeFunc      func() error
+	httpClientFunc       func() (ports.HTTPClientPort, error)
+	clientConnectionFunc func() (interface{}, error)
+	closeFunc            func() error
 }
 
-func (m *mockConn) HTTPClient() (*http.Client, error) {
+func (m *mockConn) HTTPClient() (ports.HTTPClientPort, error) {
 	if m.httpClientFunc != nil {
 		return m.httpClientFunc()
 	}
-	return &http.Client{}, nil
+	return &mockHTTPClientPort{}, nil
+}
+
+func (m *mockConn) ClientConnection() (interface{}, error) {
+	if m.clientConnectionFunc != nil {
+		return m.clientConnectionFunc()
+	}
+	return nil, nil
 }
 
 func (m *mockConn) Close() error {
@@ -60,13 +69,29 @@ func (m *mockConn) Close() error {
 	return nil
 }
 
+type mockHTTPClientPort struct{}
+
+func (m *mockHTTPClientPort) Do(_ context.Context, _ *ports.HTTPRequest) (*ports.HTTPResponse, error) {
+	return &ports.HTTPResponse{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func (m *mockHTTPClientPort) Close() error { return nil }
+
 type mockServerPort struct {
-	serveFunc func(ctx context.Context, lis net.Listener) error
-	closeFunc func() error
-	addrFunc  func() net.Addr
+	registerFunc func(ctx context.Context, registrar ports.ServiceRegistrarPort) error
+	serveFunc    func(ctx context.Context, lis ports.NetworkListenerPort) error
+	closeFunc    func() error
+	addrFunc     func() string
+}
+
+func (m *mockServerPort) RegisterService(ctx context.Context, registrar ports.ServiceRegistrarPort) error {
+	if m.registerFunc != nil {
+		return m.registerFunc(ctx, registrar)
+	}
+	return nil
 }
 
-func (m *mockServerPort) Serve(ctx context.Context, lis net.Listener) error {
+func (m *mockServerPort) Serve(ctx context.Context, lis ports.NetworkListenerPort) error {
 	if m.serveFunc != nil {
 		return m.serveFunc(ctx, lis)
 	}
@@ -80,11 +105,11 @@ func (m *mockServerPort) Close() error {
 	return nil
 }
 
-func (m *mockServerPort) Addr() net.Addr {
+func (m *mockServerPort) Addr() string {
 	if m.addrFunc != nil {
 		return m.addrFunc()
 	}
-	return nil
+	return ""
 }
 
 type mockConfigLoader struct {
@@ -97,7 +122,7 @@ func (m *mockConfigLoader) LoadConfiguration(ctx context.Context, source string)
 	}
 	return &ports.Configuration{
 		Service: ports.ServiceConfig{
-			Name:   "test-service",
+			Name:   domain.NewServiceNameUnsafe("test-service"),
 			Domain: "test.local",
 		},
 	}, nil
@@ -1,6 +1,10 @@
 package ephemos
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/sufield/ephemos/internal/core/domain"
+)
 
 // Sentinel errors for stable programmatic error handling.
 // These errors can be used with errors.Is() for reliable error detection.
@@ -34,6 +38,14 @@ var (
 	// This includes malformed host:port combinations or unsupported address formats.
 	ErrInvalidAddress = errors.New("invalid network address")
 
+	// ErrUnauthorized indicates that a peer was rejected by an authorization rule.
+	// Errors returned by the Authorize* constructors carry an *AuthorizationError naming the rule.
+	ErrUnauthorized = domain.ErrUnauthorizedPeer
+
+	// ErrInvalidToken indicates that a presented JWT-SVID failed validation.
+	// This includes bad signatures, unknown trust domains, expiry and audience mismatches.
+	ErrInvalidToken = errors.New("invalid JWT-SVID")
+
 	// ErrTimeout indicates that an operation exceeded its configured timeout.
 	// This can occur during connection establishment, authentication, or request processing.
 	ErrTimeout = errors.New("operation timeout")
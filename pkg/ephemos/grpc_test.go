@@ -0,0 +1,216 @@
+package ephemos
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+
+	"github.com/sufield/ephemos/internal/adapters/primary/healthendpoint"
+	"github.com/sufield/ephemos/internal/adapters/secondary/transport"
+	"github.com/sufield/ephemos/internal/core/domain"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestServerRegisterService(t *testing.T) {
+	ctx := context.Background()
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+
+	impl := &mockServerPort{
+		registerFunc: func(_ context.Context, registrar ports.ServiceRegistrarPort) error {
+			registrar.Register(grpcServer)
+			return nil
+		},
+	}
+
+	server, err := IdentityServer(ctx, WithServerImpl(impl))
+	require.NoError(t, err)
+
+	var got interface{}
+	err = server.RegisterService(ctx, func(s interface{}) error {
+		got = s
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Same(t, grpcServer, got)
+
+	t.Run("register errors are returned", func(t *testing.T) {
+		errWrongTransport := errors.New("wrong transport")
+		err := server.RegisterService(ctx, func(interface{}) error { return errWrongTransport })
+		assert.ErrorIs(t, err, errWrongTransport)
+	})
+
+	t.Run("nil register function is rejected", func(t *testing.T) {
+		err := server.RegisterService(ctx, nil)
+		assert.True(t, errors.Is(err, ErrConfigInvalid))
+	})
+
+	t.Run("closed server is rejected", func(t *testing.T) {
+		require.NoError(t, server.Close())
+		err := server.RegisterService(ctx, func(interface{}) error { return nil })
+		assert.True(t, errors.Is(err, ErrServerClosed))
+	})
+}
+
+func TestClientConnectionTransportConn(t *testing.T) {
+	ctx := context.Background()
+
+	grpcConn, err := grpc.NewClient("passthrough:///localhost:0",
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer grpcConn.Close()
+
+	dialer := &mockDialer{
+		connectFunc: func(context.Context, string, string) (ports.ConnPort, error) {
+			return &mockConn{
+				clientConnectionFunc: func() (interface{}, error) { return grpcConn, nil },
+			}, nil
+		},
+	}
+
+	client, err := IdentityClient(ctx, WithDialer(dialer))
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := client.Connect(ctx, "localhost:0")
+	require.NoError(t, err)
+
+	got, err := conn.TransportConn()
+	require.NoError(t, err)
+	assert.Same(t, grpcConn, got)
+
+	require.NoError(t, conn.Close())
+	_, err = conn.TransportConn()
+	assert.True(t, errors.Is(err, ErrServerClosed))
+}
+
+func TestClientConnectionTransportConn_Unsupported(t *testing.T) {
+	conn := &clientConnectionImpl{conn: &mockConn{}}
+
+	_, err := conn.TransportConn()
+	assert.True(t, errors.Is(err, ErrConnectionFailed))
+}
+
+func TestPeerIdentityFromContext_GRPC(t *testing.T) {
+	ca := newTestCA(t)
+	cert, err := ca.issue(t, "spiffe://example.org/caller").GetCertificate()
+	require.NoError(t, err)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 5000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert.Cert},
+		}},
+	})
+
+	interceptor := transport.UnaryServerInterceptor(transport.ServerInterceptorConfig{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/billing.v1.Billing/Charge"}
+	resp, err := interceptor(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		identity, ok := PeerIdentityFromContext(ctx)
+		if !ok {
+			return nil, errors.New("no peer identity")
+		}
+		return identity, nil
+	})
+	require.NoError(t, err)
+	identity := resp.(*PeerIdentity)
+	assert.Equal(t, "spiffe://example.org/caller", identity.ID)
+	assert.Equal(t, "10.0.0.7:5000", identity.RemoteAddr)
+}
+
+func TestClientConnect_WithServerID(t *testing.T) {
+	ctx := context.Background()
+	var serviceNames []string
+	dialer := &mockDialer{
+		connectFunc: func(_ context.Context, serviceName, _ string) (ports.ConnPort, error) {
+			serviceNames = append(serviceNames, serviceName)
+			return &mockConn{}, nil
+		},
+	}
+	client, err := IdentityClient(ctx, WithDialer(dialer))
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := client.Connect(ctx, "billing:443", WithServerID("spiffe://example.org/billing"))
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, []string{"spiffe://example.org/billing"}, serviceNames)
+
+	_, err = client.Connect(ctx, "billing:443", WithServerID("billing"))
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+	assert.Len(t, serviceNames, 1)
+}
+
+func TestIdentityServer_HealthEndpoint(t *testing.T) {
+	config := &ports.Configuration{
+		Service:  ports.ServiceConfig{Name: domain.NewServiceNameUnsafe("health-api"), Domain: "example.org"},
+		Identity: &ports.IdentitySourceConfig{Source: ports.IdentitySourceDevCA},
+		Health: &ports.HealthConfig{
+			Enabled: true,
+			SVID:    &ports.SVIDHealthConfig{Enabled: true, Warning: 2 * time.Minute, Critical: time.Minute},
+		},
+	}
+	probes, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server, err := IdentityServer(context.Background(),
+		WithServerConfig(config), WithListener(listener), WithHealthEndpoint(probes))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-served
+	})
+
+	base := "http://" + probes.Addr().String()
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get(base + healthendpoint.HealthPath)
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Components map[string]*ports.HealthResult `json:"components"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Contains(t, body.Components, "svid")
+	assert.Equal(t, ports.HealthStatusHealthy, body.Components["svid"].Status)
+
+	for _, path := range []string{healthendpoint.LivenessPath, healthendpoint.ReadinessPath} {
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+}
+
+func TestIdentityServer_HealthEndpointRejectedInHTTPMode(t *testing.T) {
+	probes, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = probes.Close() })
+
+	_, err = IdentityServer(context.Background(),
+		WithHTTPHandler(http.NotFoundHandler()), WithHealthEndpoint(probes))
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+}
@@ -2,17 +2,21 @@
 package ephemos
 
 import (
+	"context"
 	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
 )
 
 // Authorizer validates peer certificates during mTLS handshake.
@@ -21,22 +25,55 @@ type Authorizer interface {
 	Authorize(certificates []*x509.Certificate) error
 }
 
-// authorizerAdapter adapts tlsconfig.Authorizer to our public interface
+// authorizerAdapter adapts tlsconfig.Authorizer to our public interface.
+// The rule names the wrapped authorizer in errors and rule descriptions.
 type authorizerAdapter struct {
+	rule       string
 	authorizer tlsconfig.Authorizer
 }
 
 func (a *authorizerAdapter) Authorize(certificates []*x509.Certificate) error {
-	return a.authorizer(certificates)
+	id, err := peerID(certificates)
+	if err != nil {
+		return &AuthorizationError{Rule: a.String(), Err: err}
+	}
+	if err := a.authorizer(id, [][]*x509.Certificate{certificates}); err != nil {
+		return &AuthorizationError{Rule: a.String(), PeerID: id.String(), Reason: err.Error()}
+	}
+	return nil
+}
+
+// String returns the rule description.
+func (a *authorizerAdapter) String() string {
+	if a.rule == "" {
+		return "custom"
+	}
+	return a.rule
+}
+
+// toTLSConfigAuthorizer adapts a public Authorizer to the go-spiffe tlsconfig.Authorizer
+// signature. The first verified chain is handed to the public Authorizer.
+func toTLSConfigAuthorizer(authorizer Authorizer) tlsconfig.Authorizer {
+	if authorizer == nil {
+		return tlsconfig.AuthorizeAny()
+	}
+	if adapter, ok := authorizer.(*authorizerAdapter); ok {
+		return adapter.authorizer
+	}
+	return func(_ spiffeid.ID, verifiedChains [][]*x509.Certificate) error {
+		if len(verifiedChains) == 0 {
+			return fmt.Errorf("no verified certificate chains")
+		}
+		return authorizer.Authorize(verifiedChains[0])
+	}
 }
 
 // AuthorizeAny returns an Authorizer that accepts any valid SPIFFE certificate.
 // This provides basic SPIFFE identity validation for authentication.
 func AuthorizeAny() Authorizer {
-	return &authorizerAdapter{authorizer: tlsconfig.AuthorizeAny()}
+	return &authorizerAdapter{rule: "any()", authorizer: tlsconfig.AuthorizeAny()}
 }
 
-
 // HTTPClientConfig configures an HTTP client with SPIFFE mTLS.
 type HTTPClientConfig struct {
 	// IdentityService provides certificates and trust bundles.
@@ -61,6 +98,10 @@ type HTTPClientConfig struct {
 	// IdleConnTimeout is the maximum amount of time an idle connection will remain idle.
 	// If zero, 90 seconds is used.
 	IdleConnTimeout time.Duration
+
+	// TLS selects the TLS version, cipher suites and curves.
+	// If nil, TLS 1.3 with Go's default curves is used.
+	TLS *TLSSettings
 }
 
 // NewHTTPClient creates an HTTP client configured with SPIFFE mTLS.
@@ -98,7 +139,7 @@ func NewHTTPClient(config *HTTPClientConfig) (*http.Client, error) {
 	}
 
 	// Create TLS config
-	tlsConfig, err := NewTLSConfig(config.IdentityService, config.Authorizer, config.TrustDomain)
+	tlsConfig, err := NewTLSConfig(config.IdentityService, config.Authorizer, config.TrustDomain, config.TLS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TLS config: %w", err)
 	}
@@ -130,6 +171,8 @@ func NewHTTPClient(config *HTTPClientConfig) (*http.Client, error) {
 
 // NewTLSConfig creates a TLS configuration for SPIFFE mTLS.
 // This is a lower-level function used when you need direct control over TLS configuration.
+// The TLS settings, if given, are applied; without them the defaults, including the
+// TLS 1.3 minimum, are.
 //
 // Example:
 //
@@ -142,7 +185,7 @@ func NewHTTPClient(config *HTTPClientConfig) (*http.Client, error) {
 //	    return err
 //	}
 //	transport := &http.Transport{TLSClientConfig: tlsConfig}
-func NewTLSConfig(identityService IdentityService, authorizer Authorizer, trustDomain string) (*tls.Config, error) {
+func NewTLSConfig(identityService IdentityService, authorizer Authorizer, trustDomain string, settings ...*TLSSettings) (*tls.Config, error) {
 	if identityService == nil {
 		return nil, fmt.Errorf("identity service is required")
 	}
@@ -169,10 +212,11 @@ func NewTLSConfig(identityService IdentityService, authorizer Authorizer, trustD
 	}
 
 	// Use go-spiffe to create mTLS config
-	tlsConfig := tlsconfig.MTLSClientConfig(svidSource, bundleSource, authorizer)
-
-	// Ensure TLS 1.3 minimum
-	tlsConfig.MinVersion = tls.VersionTLS13
+	tlsConfig := tlsconfig.MTLSClientConfig(svidSource, audit.WrapBundleSource(bundleSource),
+		audit.WrapAuthorizer(toTLSConfigAuthorizer(authorizer)))
+	if err := applyTLSSettings(tlsConfig, settings); err != nil {
+		return nil, err
+	}
 
 	return tlsConfig, nil
 }
@@ -190,7 +234,7 @@ func (w *svidWrapper) toX509SVID() (*x509svid.SVID, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid SPIFFE ID: %w", err)
 	}
-	
+
 	return &x509svid.SVID{
 		ID:           id,
 		Certificates: w.certificates,
@@ -255,7 +299,14 @@ func (b *bundleSourceAdapter) GetX509BundleForTrustDomain(td spiffeid.TrustDomai
 		return nil, fmt.Errorf("trust domain %s not allowed, restricted to %s", td, b.restrictedTrustDomain)
 	}
 
-	trustBundle, err := b.identityService.GetTrustBundle()
+	// Federated services hold one bundle per trust domain; others serve a single bundle
+	var trustBundle *TrustBundle
+	var err error
+	if federated, ok := b.identityService.(FederatedIdentityService); ok {
+		trustBundle, err = federated.GetTrustBundleForTrustDomain(td.String())
+	} else {
+		trustBundle, err = b.identityService.GetTrustBundle()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trust bundle: %w", err)
 	}
@@ -331,7 +382,7 @@ func NewHTTPTransport(config *HTTPTransportConfig) (*http.Transport, error) {
 	}
 
 	// Create TLS config
-	tlsConfig, err := NewTLSConfig(config.IdentityService, config.Authorizer, config.TrustDomain)
+	tlsConfig, err := NewTLSConfig(config.IdentityService, config.Authorizer, config.TrustDomain, config.TLS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TLS config: %w", err)
 	}
@@ -355,6 +406,8 @@ func NewHTTPTransport(config *HTTPTransportConfig) (*http.Transport, error) {
 
 // NewServerTLSConfig creates a TLS configuration for HTTPS servers with SPIFFE mTLS.
 // This enables servers to authenticate clients using SPIFFE identities.
+// The TLS settings, if given, are applied; without them the defaults, including the
+// TLS 1.3 minimum, are.
 // Note: This is for authentication only. Authorization is out of scope.
 //
 // Example:
@@ -372,7 +425,7 @@ func NewHTTPTransport(config *HTTPTransportConfig) (*http.Transport, error) {
 //	    TLSConfig: tlsConfig,
 //	}
 //	server.ListenAndServeTLS("", "")
-func NewServerTLSConfig(identityService IdentityService, authorizer Authorizer) (*tls.Config, error) {
+func NewServerTLSConfig(identityService IdentityService, authorizer Authorizer, settings ...*TLSSettings) (*tls.Config, error) {
 	if identityService == nil {
 		return nil, fmt.Errorf("identity service is required")
 	}
@@ -390,11 +443,98 @@ func NewServerTLSConfig(identityService IdentityService, authorizer Authorizer)
 		identityService: identityService,
 	}
 
-	// Use go-spiffe to create mTLS server config
-	tlsConfig := tlsconfig.MTLSServerConfig(svidSource, bundleSource, authorizer)
-
-	// Ensure TLS 1.3 minimum
-	tlsConfig.MinVersion = tls.VersionTLS13
+	// Use go-spiffe to create mTLS server config. The sources are wrapped so that
+	// rejections carry typed causes for auditing
+	tlsConfig := tlsconfig.MTLSServerConfig(svidSource, audit.WrapBundleSource(bundleSource),
+		audit.WrapAuthorizer(toTLSConfigAuthorizer(authorizer)))
+	if err := applyTLSSettings(tlsConfig, settings); err != nil {
+		return nil, err
+	}
 
 	return tlsConfig, nil
 }
+
+// jwtClaimsContextKey is the context key for validated JWT-SVID claims.
+type jwtClaimsContextKey struct{}
+
+// JWTClaimsFromContext returns the JWT-SVID claims stored by RequireJWTSVID.
+func JWTClaimsFromContext(ctx context.Context) (*JWTClaims, bool) {
+	claims, ok := ctx.Value(jwtClaimsContextKey{}).(*JWTClaims)
+	return claims, ok && claims != nil
+}
+
+// NewJWTBearerTransport returns an http.RoundTripper that attaches a JWT-SVID
+// for the given audience as a bearer token to every outgoing request.
+// If base is nil, http.DefaultTransport is used.
+//
+// Example:
+//
+//	client := &http.Client{
+//	    Transport: ephemos.NewJWTBearerTransport(jwtService, "payment-service", nil),
+//	}
+func NewJWTBearerTransport(jwtService JWTService, audience string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &jwtBearerTransport{jwtService: jwtService, audience: audience, base: base}
+}
+
+// jwtBearerTransport injects JWT-SVID bearer tokens into requests.
+type jwtBearerTransport struct {
+	jwtService JWTService
+	audience   string
+	base       http.RoundTripper
+}
+
+func (t *jwtBearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	svid, err := t.jwtService.FetchJWTSVID(req.Context(), t.audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain JWT-SVID: %w", err)
+	}
+
+	// RoundTrippers must not modify the original request
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+svid.Token)
+	return t.base.RoundTrip(clone)
+}
+
+// RequireJWTSVID returns HTTP middleware that rejects requests without a valid
+// JWT-SVID bearer token for the given audience. Validated claims are stored in
+// the request context and can be read with JWTClaimsFromContext.
+//
+// Example:
+//
+//	handler := ephemos.RequireJWTSVID(jwtService, "payment-service")(mux)
+func RequireJWTSVID(jwtService JWTService, audience string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="spiffe"`)
+				http.Error(w, "missing bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := jwtService.ValidateJWTSVID(r.Context(), token, audience)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="spiffe", error="invalid_token"`)
+				http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), jwtClaimsContextKey{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, token != ""
+}
@@ -0,0 +1,372 @@
+package ephemos
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues X.509-SVIDs for in-process mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns an IdentityService holding an SVID for spiffeID signed by the CA.
+func (ca *testCA) issue(t *testing.T, spiffeID string) IdentityService {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	u, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{u},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &staticIdentityService{
+		cert:   &Certificate{Cert: cert, PrivateKey: key},
+		bundle: &TrustBundle{Certificates: []*x509.Certificate{ca.cert}},
+	}
+}
+
+// staticIdentityService is an IdentityService with fixed material.
+type staticIdentityService struct {
+	cert   *Certificate
+	bundle *TrustBundle
+}
+
+func (s *staticIdentityService) GetCertificate() (*Certificate, error) { return s.cert, nil }
+func (s *staticIdentityService) GetTrustBundle() (*TrustBundle, error) { return s.bundle, nil }
+
+func TestIdentityServerHTTPMode(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := PeerIdentityFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no identity", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, identity.ID+" "+identity.TrustDomain)
+	})
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithHTTPHandler(handler),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+		WithClientAuthorizer(AuthorizeMemberOf("example.org")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe(ctx) }()
+
+	baseURL := "https://" + listener.Addr().String()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, listener.Addr().String(), server.Addr().String())
+
+	t.Run("peer identity is injected into the request context", func(t *testing.T) {
+		client, err := NewHTTPClient(&HTTPClientConfig{
+			IdentityService: ca.issue(t, "spiffe://example.org/client"),
+			Authorizer:      AuthorizeID("spiffe://example.org/server"),
+		})
+		require.NoError(t, err)
+
+		resp, err := client.Get(baseURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "spiffe://example.org/client example.org", string(body))
+	})
+
+	t.Run("unauthorized client fails the handshake", func(t *testing.T) {
+		client, err := NewHTTPClient(&HTTPClientConfig{
+			IdentityService: ca.issue(t, "spiffe://partner.org/client"),
+		})
+		require.NoError(t, err)
+
+		_, err = client.Get(baseURL)
+		assert.Error(t, err)
+	})
+
+	t.Run("service registration is rejected", func(t *testing.T) {
+		err := server.RegisterService(context.Background(), func(interface{}) error { return nil })
+		assert.True(t, errors.Is(err, ErrConfigInvalid))
+	})
+
+	cancel()
+	select {
+	case err := <-serveErr:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not stop after context cancellation")
+	}
+	require.NoError(t, server.Close())
+}
+
+func TestIdentityServerHTTPMode_LoadedConfigDefaultsAuthorizer(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// The trust domain of the loaded configuration restricts clients by default
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+		WithServerConfigSource(YAMLConfigLoader(), inlineConfig),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+	go func() { _ = server.ListenAndServe(context.Background()) }()
+
+	get := func(spiffeID string) error {
+		client, err := NewHTTPClient(&HTTPClientConfig{IdentityService: ca.issue(t, spiffeID)})
+		require.NoError(t, err)
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.NoError(t, get("spiffe://example.org/client"))
+	assert.Error(t, get("spiffe://partner.example/client"))
+}
+
+func TestIdentityServerFromFile_ReloadsConfiguration(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(inlineConfig), 0o600))
+
+	registry := prometheus.NewRegistry()
+	metrics, err := NewMetrics(registry, nil)
+	require.NoError(t, err)
+
+	server, err := IdentityServerFromFile(context.Background(), path,
+		WithListener(listener),
+		WithHTTPHandler(http.NotFoundHandler()),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+		WithMetrics(metrics),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+
+	reloads := func(result string) float64 {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		for _, family := range families {
+			if family.GetName() != "ephemos_config_reload_total" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "result" && label.GetValue() == result {
+						return metric.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	edited := inlineConfig + "  cache:\n    ttl_minutes: 30\n    proactive_refresh_minutes: 5\n"
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0o600))
+	assert.Eventually(t, func() bool { return reloads("applied") == 1 }, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte("service: ["), 0o600))
+	assert.Eventually(t, func() bool { return reloads("rejected") == 1 }, 5*time.Second, 20*time.Millisecond)
+
+	// Closing the server stops watching the file
+	require.NoError(t, server.Close())
+	require.NoError(t, os.WriteFile(path, []byte(inlineConfig), 0o600))
+	time.Sleep(500 * time.Millisecond)
+	assert.Equal(t, float64(1), reloads("applied"))
+}
+
+func TestIdentityServerFromFile_AppliesRevocationEdits(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	revokedPath := filepath.Join(dir, "revoked.yaml")
+	require.NoError(t, os.WriteFile(revokedPath, []byte("spiffe_ids: []\n"), 0o600))
+	path := filepath.Join(dir, "config.yaml")
+	withRevocation := func(ids ...string) string {
+		return inlineConfig + fmt.Sprintf("revocation:\n  file: %q\n  spiffe_ids: %q\n", revokedPath, ids)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(withRevocation()), 0o600))
+
+	server, err := IdentityServerFromFile(context.Background(), path,
+		WithListener(listener),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+	go func() { _ = server.ListenAndServe(context.Background()) }()
+
+	get := func(spiffeID string) error {
+		client, err := NewHTTPClient(&HTTPClientConfig{IdentityService: ca.issue(t, spiffeID)})
+		require.NoError(t, err)
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	require.NoError(t, get("spiffe://example.org/compromised"))
+	require.NoError(t, get("spiffe://example.org/stolen"))
+
+	// An edit of the revocation file rejects new handshakes of the listed client
+	require.NoError(t, os.WriteFile(revokedPath, []byte("spiffe_ids: [\"spiffe://example.org/compromised\"]\n"), 0o600))
+	assert.Eventually(t, func() bool { return get("spiffe://example.org/compromised") != nil }, 5*time.Second, 50*time.Millisecond)
+
+	// So does an edit of the revocation section of the configuration file
+	require.NoError(t, os.WriteFile(path, []byte(withRevocation("spiffe://example.org/stolen")), 0o600))
+	assert.Eventually(t, func() bool { return get("spiffe://example.org/stolen") != nil }, 5*time.Second, 50*time.Millisecond)
+	assert.Error(t, get("spiffe://example.org/compromised"))
+	assert.NoError(t, get("spiffe://example.org/client"))
+}
+
+func TestIdentityServerHTTPMode_RequiresAddress(t *testing.T) {
+	_, err := IdentityServer(context.Background(), WithHTTPHandler(http.NotFoundHandler()))
+	assert.True(t, errors.Is(err, ErrConfigInvalid))
+}
+
+func TestPeerIdentityMiddleware_RejectsPlaintext(t *testing.T) {
+	called := false
+	handler := PeerIdentityMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, called)
+}
+
+// federatedIdentityService serves a separate trust bundle per trust domain.
+type federatedIdentityService struct {
+	IdentityService
+	bundles map[string]*TrustBundle
+}
+
+func (f *federatedIdentityService) GetTrustBundleForTrustDomain(trustDomain string) (*TrustBundle, error) {
+	bundle, ok := f.bundles[trustDomain]
+	if !ok {
+		return nil, errors.New("unknown trust domain")
+	}
+	return bundle, nil
+}
+
+func TestIdentityServerHTTPMode_FederatedPeers(t *testing.T) {
+	localCA, partnerCA := newTestCA(t), newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	identity := &federatedIdentityService{
+		IdentityService: localCA.issue(t, "spiffe://example.org/server"),
+		bundles: map[string]*TrustBundle{
+			"example.org":     {Certificates: []*x509.Certificate{localCA.cert}},
+			"partner.example": {Certificates: []*x509.Certificate{partnerCA.cert}},
+		},
+	}
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(identity),
+		WithClientAuthorizer(AuthorizeAny()),
+	)
+	require.NoError(t, err)
+	defer server.Close()
+	go func() { _ = server.ListenAndServe(context.Background()) }()
+
+	// The partner client trusts the local CA for the server's trust domain
+	partner := partnerCA.issue(t, "spiffe://partner.example/client").(*staticIdentityService)
+	partner.bundle = &TrustBundle{Certificates: []*x509.Certificate{localCA.cert}}
+	client, err := NewHTTPClient(&HTTPClientConfig{IdentityService: partner})
+	require.NoError(t, err)
+
+	resp, err := client.Get("https://" + listener.Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	t.Run("peer signed by another domain's CA is rejected", func(t *testing.T) {
+		impostor := localCA.issue(t, "spiffe://partner.example/client")
+		client, err := NewHTTPClient(&HTTPClientConfig{IdentityService: impostor})
+		require.NoError(t, err)
+
+		_, err = client.Get("https://" + listener.Addr().String())
+		assert.Error(t, err)
+	})
+}
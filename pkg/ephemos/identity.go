@@ -32,3 +32,14 @@ type IdentityService interface {
 	// This bundle is used to verify peer certificates during mTLS.
 	GetTrustBundle() (*TrustBundle, error)
 }
+
+// FederatedIdentityService is implemented by identity services that hold trust bundles
+// for federated trust domains. TLS configs created by this package use it to verify
+// each peer against the bundle of the peer's own trust domain.
+type FederatedIdentityService interface {
+	IdentityService
+
+	// GetTrustBundleForTrustDomain returns the trust bundle for the given trust domain,
+	// e.g. "partner.example". It returns an error for unknown trust domains.
+	GetTrustBundleForTrustDomain(trustDomain string) (*TrustBundle, error)
+}
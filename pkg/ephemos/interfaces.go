@@ -30,6 +30,12 @@ type ClientConnection interface {
 	// HTTPClient returns an HTTP client for this connection.
 	HTTPClient() (*http.Client, error)
 
+	// TransportConn returns the authenticated RPC client connection, a
+	// *grpc.ClientConn for the built-in transport. Use ephemosgrpc.ClientConn to
+	// get it typed for generated stubs. The connection is owned by the
+	// ClientConnection and is closed by Close.
+	TransportConn() (interface{}, error)
+
 	// Close closes the connection.
 	Close() error
 }
@@ -51,6 +57,12 @@ type AuthenticatedServer interface {
 
 // ConfigLoader defines an interface for loading configuration from various sources.
 // This allows for custom configuration loading strategies beyond simple file paths.
+//
+// Built-in loaders are FileConfigLoader, EnvConfigLoader and YAMLConfigLoader.
+// Third-party loaders must return a Configuration created by ParseConfiguration or
+// by a built-in loader; other implementations of Configuration are rejected with
+// ErrConfigInvalid. Loaders should honor context cancellation and should not
+// include secrets from the source in returned errors.
 type ConfigLoader interface {
 	// LoadConfiguration loads configuration from the specified source.
 	// The source parameter can be a file path, URL, or other identifier
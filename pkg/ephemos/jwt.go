@@ -0,0 +1,134 @@
+// Package ephemos provides identity-based authentication for backend services.
+package ephemos
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sufield/ephemos/internal/core/services"
+	"github.com/sufield/ephemos/internal/factory"
+)
+
+// JWTSVID is a signed JWT-SVID bearer token for a set of audiences.
+// JWT-SVIDs carry service identity where mTLS identity is lost, e.g. behind
+// L7 load balancers that terminate TLS.
+type JWTSVID struct {
+	// Token is the serialized, signed JWT. Send it as "Authorization: Bearer <Token>".
+	Token string
+	// SPIFFEID is the identity of the workload the token was issued to.
+	SPIFFEID string
+	// Audience is the list of intended recipients of the token.
+	Audience []string
+	// ExpiresAt is when the token stops being valid.
+	ExpiresAt time.Time
+}
+
+// JWTClaims holds the claims of a validated JWT-SVID.
+type JWTClaims struct {
+	// SPIFFEID is the identity of the caller, taken from the 'sub' claim.
+	SPIFFEID string
+	// Audience is the list of intended recipients from the 'aud' claim.
+	Audience []string
+	// ExpiresAt is the expiry time from the 'exp' claim.
+	ExpiresAt time.Time
+	// IssuedAt is the issue time from the 'iat' claim, zero if absent.
+	IssuedAt time.Time
+	// Claims contains all raw claims of the token.
+	Claims map[string]interface{}
+}
+
+// JWTService fetches JWT-SVIDs for this service and validates JWT-SVIDs presented by peers.
+// All methods are safe for concurrent use by multiple goroutines.
+type JWTService interface {
+	// FetchJWTSVID returns a JWT-SVID for the given audience.
+	// Tokens are cached and only re-fetched when they approach expiry.
+	FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*JWTSVID, error)
+
+	// ValidateJWTSVID validates a token against the JWT bundle of its trust domain
+	// and checks that the given audience is present.
+	ValidateJWTSVID(ctx context.Context, token, audience string) (*JWTClaims, error)
+
+	// Close releases any resources held by the service.
+	Close() error
+}
+
+// IdentityJWTService creates a JWT-SVID service for this workload.
+// Configuration is provided with the same options as IdentityClient.
+//
+// Example:
+//
+//	jwts, err := ephemos.IdentityJWTService(ctx, ephemos.WithConfig(config))
+//	if err != nil { return err }
+//	defer jwts.Close()
+//
+//	svid, err := jwts.FetchJWTSVID(ctx, "payment-service")
+//	if err != nil { return err }
+//	req.Header.Set("Authorization", "Bearer "+svid.Token)
+func IdentityJWTService(ctx context.Context, opts ...ClientOption) (JWTService, error) {
+	options := &clientOpts{
+		Timeout: DefaultClientTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// If direct providers are injected (for testing), use them
+	if options.JWTProvider != nil || options.JWTBundles != nil {
+		svc, err := services.NewJWTSVIDService(options.JWTProvider, options.JWTBundles)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+		}
+		return &jwtServiceWrapper{svc: svc}, nil
+	}
+
+	config, err := loadClientConfig(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+
+	svc, err := factory.SPIFFEJWTService(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT service: %w", err)
+	}
+
+	return &jwtServiceWrapper{svc: svc}, nil
+}
+
+// jwtServiceWrapper adapts the core JWT-SVID service to the public JWTService interface.
+type jwtServiceWrapper struct {
+	svc *services.JWTSVIDService
+}
+
+func (w *jwtServiceWrapper) FetchJWTSVID(ctx context.Context, audience string, extraAudiences ...string) (*JWTSVID, error) {
+	svid, err := w.svc.FetchJWTSVID(ctx, audience, extraAudiences...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoAuth, err)
+	}
+
+	return &JWTSVID{
+		Token:     svid.Marshal(),
+		SPIFFEID:  svid.ID.String(),
+		Audience:  append([]string(nil), svid.Audience...),
+		ExpiresAt: svid.Expiry,
+	}, nil
+}
+
+func (w *jwtServiceWrapper) ValidateJWTSVID(ctx context.Context, token, audience string) (*JWTClaims, error) {
+	claims, err := w.svc.ValidateJWTSVID(ctx, token, audience)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return &JWTClaims{
+		SPIFFEID:  claims.Subject,
+		Audience:  claims.Audience,
+		ExpiresAt: claims.ExpiresAt,
+		IssuedAt:  claims.IssuedAt,
+		Claims:    claims.Claims,
+	}, nil
+}
+
+func (w *jwtServiceWrapper) Close() error {
+	return w.svc.Close()
+}
@@ -0,0 +1,115 @@
+package ephemos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/memidentity"
+)
+
+func newTestJWTService(t *testing.T, id string) (JWTService, *memidentity.JWTProvider) {
+	t.Helper()
+
+	provider, err := memidentity.NewJWTProvider(spiffeid.RequireFromString(id))
+	require.NoError(t, err)
+
+	svc, err := IdentityJWTService(context.Background(), WithJWTProviders(provider, provider))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = svc.Close() })
+
+	return svc, provider
+}
+
+func TestJWTService_FetchAndValidate(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestJWTService(t, "spiffe://example.org/client")
+
+	svid, err := svc.FetchJWTSVID(ctx, "backend", "audit")
+	require.NoError(t, err)
+	assert.NotEmpty(t, svid.Token)
+	assert.Equal(t, "spiffe://example.org/client", svid.SPIFFEID)
+	assert.ElementsMatch(t, []string{"backend", "audit"}, svid.Audience)
+
+	claims, err := svc.ValidateJWTSVID(ctx, svid.Token, "audit")
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/client", claims.SPIFFEID)
+	assert.False(t, claims.IssuedAt.IsZero())
+
+	_, err = svc.ValidateJWTSVID(ctx, svid.Token, "someone-else")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestJWTService_CachesTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestJWTService(t, "spiffe://example.org/client")
+
+	first, err := svc.FetchJWTSVID(ctx, "backend", "b", "a")
+	require.NoError(t, err)
+	second, err := svc.FetchJWTSVID(ctx, "backend", "a", "b")
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Token, second.Token)
+	assert.Equal(t, 1, provider.FetchCount())
+
+	_, err = svc.FetchJWTSVID(ctx, "other")
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.FetchCount())
+}
+
+func TestJWTService_RejectsUnknownTrustDomain(t *testing.T) {
+	ctx := context.Background()
+	validator, _ := newTestJWTService(t, "spiffe://example.org/server")
+	foreign, _ := newTestJWTService(t, "spiffe://partner.org/client")
+
+	svid, err := foreign.FetchJWTSVID(ctx, "backend")
+	require.NoError(t, err)
+
+	_, err = validator.ValidateJWTSVID(ctx, svid.Token, "backend")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestJWTBearerRoundTrip(t *testing.T) {
+	svc, _ := newTestJWTService(t, "spiffe://example.org/client")
+
+	handler := RequireJWTSVID(svc, "backend")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := JWTClaimsFromContext(r.Context())
+		if !ok {
+			http.Error(w, "no claims", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(claims.SPIFFEID))
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	t.Run("missing token is rejected", func(t *testing.T) {
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Bearer")
+	})
+
+	t.Run("bearer transport is accepted", func(t *testing.T) {
+		client := &http.Client{Transport: NewJWTBearerTransport(svc, "backend", nil)}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("wrong audience is rejected", func(t *testing.T) {
+		client := &http.Client{Transport: NewJWTBearerTransport(svc, "frontend", nil)}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
@@ -0,0 +1,36 @@
+package ephemos
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/factory"
+)
+
+// NewLogger creates a logger from the logging section of the configuration, with
+// EPHEMOS_LOG_LEVEL and EPHEMOS_LOG_FORMAT taking precedence. It writes to w, or to
+// standard error if w is nil. The configuration may be nil to use the environment
+// only. When no level or format is configured, slog.Default() is returned.
+//
+// Pass the logger to slog.SetDefault to apply the settings to the library's own logs:
+//
+//	logger, err := ephemos.NewLogger(config, nil)
+//	if err != nil { return err }
+//	slog.SetDefault(logger)
+func NewLogger(config Configuration, w io.Writer) (*slog.Logger, error) {
+	var internal *ports.Configuration
+	if config != nil {
+		var err error
+		if internal, err = GetInternalConfig(config); err != nil {
+			return nil, err
+		}
+	}
+
+	logger, err := factory.Logger(internal, w)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return logger, nil
+}
@@ -0,0 +1,99 @@
+package ephemos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Setenv(ports.EnvLogLevel, "")
+	t.Setenv(ports.EnvLogFormat, "")
+
+	logger, err := NewLogger(nil, nil)
+	require.NoError(t, err)
+	assert.Same(t, slog.Default(), logger, "unconfigured logging keeps the default logger")
+
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: logging-test
+logging:
+  level: warn
+  format: json
+`))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	logger, err = NewLogger(config, &out)
+	require.NoError(t, err)
+	logger.Info("dropped")
+	logger.Warn("kept")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record), "want a single JSON record, got %q", out.String())
+	assert.Equal(t, "kept", record["msg"])
+
+	t.Setenv(ports.EnvLogLevel, "info")
+	t.Setenv(ports.EnvLogFormat, "text")
+	out.Reset()
+	logger, err = NewLogger(config, &out)
+	require.NoError(t, err)
+	logger.Info("from env")
+	assert.Contains(t, out.String(), "level=INFO msg=\"from env\"")
+}
+
+func TestIdentityServerHTTPMode_AppliesLoggingSettings(t *testing.T) {
+	t.Setenv(ports.EnvLogLevel, "")
+	t.Setenv(ports.EnvLogFormat, "")
+
+	// Configured loggers write to standard error
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	original := os.Stderr
+	os.Stderr = stderr
+	t.Cleanup(func() { os.Stderr = original })
+
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: logging-test
+  domain: example.org
+logging:
+  level: debug
+  format: json
+`))
+	require.NoError(t, err)
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithServerConfig(internal),
+		WithHTTPHandler(http.NotFoundHandler()),
+		WithServerIdentityService(newTestCA(t).issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+
+	assert.Eventually(t, func() bool {
+		logged, err := os.ReadFile(stderr.Name())
+		return err == nil && strings.Contains(string(logged), `"msg":"starting mTLS invariant enforcement"`)
+	}, 2*time.Second, 10*time.Millisecond, "the server did not log in the configured JSON format")
+}
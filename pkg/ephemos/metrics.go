@@ -0,0 +1,54 @@
+package ephemos
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/sufield/ephemos/internal/adapters/metrics"
+)
+
+// Metrics reports ephemos metrics to a Prometheus registry: mTLS handshake latency
+// and failure reasons, active connections by peer trust domain, SVID rotations,
+// authorization decisions by policy rule, the remaining lifetime of the local and
+// peer SVIDs, and certificate cache, revocation, reload and dropped audit event
+// counters.
+//
+// Servers and clients created without WithMetrics or WithClientMetrics report to
+// the default Prometheus registry.
+type Metrics struct {
+	reporter *metrics.PrometheusMetrics
+}
+
+// NewMetrics registers the ephemos collectors with registerer, or the default
+// Prometheus registry if nil. constLabels are added to every metric, so that several
+// services embedded in one process can share a registry. Metrics created with the
+// same registerer and labels share their collectors.
+//
+// Example:
+//
+//	registry := prometheus.NewRegistry()
+//	m, err := ephemos.NewMetrics(registry, map[string]string{"service": "payments"})
+//	if err != nil {
+//	    return err
+//	}
+//	server, err := ephemos.IdentityServer(ctx, ephemos.WithMetrics(m), ...)
+//	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+func NewMetrics(registerer prometheus.Registerer, constLabels map[string]string) (*Metrics, error) {
+	reporter, err := metrics.NewPrometheusMetrics(metrics.Config{
+		Registerer:  registerer,
+		ConstLabels: constLabels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return &Metrics{reporter: reporter}, nil
+}
+
+// prometheusMetrics returns the reporter of m, or of the default registry if m is nil.
+func (m *Metrics) prometheusMetrics() *metrics.PrometheusMetrics {
+	if m == nil {
+		return metrics.Default()
+	}
+	return m.reporter
+}
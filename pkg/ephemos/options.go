@@ -2,6 +2,7 @@ package ephemos
 
 import (
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/sufield/ephemos/internal/core/ports"
@@ -12,10 +13,17 @@ type ClientOption func(*clientOpts)
 
 // clientOpts holds the configuration for client creation.
 type clientOpts struct {
-	Config  *ports.Configuration
-	Loader  ConfigLoader
-	Impl    ports.Dialer // direct injection for tests
-	Timeout time.Duration
+	Config       *ports.Configuration
+	Loader       ConfigLoader
+	ConfigSource string
+	Impl         ports.DialerPort // direct injection for tests
+	Timeout      time.Duration
+
+	// JWT-SVID providers, direct injection for tests
+	JWTProvider ports.JWTSVIDProviderPort
+	JWTBundles  ports.JWTBundleProviderPort
+
+	Metrics *Metrics
 }
 
 // WithConfig provides an in-memory configuration for the client.
@@ -29,7 +37,8 @@ func WithConfig(config *ports.Configuration) ClientOption {
 }
 
 // WithConfigLoader provides a custom configuration loader.
-// This allows for loading configuration from non-standard sources.
+// The loader is called with an empty source, which suits loaders such as
+// EnvConfigLoader. Use WithConfigSource for loaders that need a source.
 func WithConfigLoader(loader ConfigLoader) ClientOption {
 	return func(opts *clientOpts) {
 		if loader != nil {
@@ -38,9 +47,25 @@ func WithConfigLoader(loader ConfigLoader) ClientOption {
 	}
 }
 
+// WithConfigSource loads the client configuration with loader from source.
+// A configuration provided with WithConfig takes precedence.
+//
+// Example:
+//
+//	client, err := ephemos.IdentityClient(ctx,
+//	    ephemos.WithConfigSource(ephemos.FileConfigLoader(), "/etc/ephemos/config.yaml"))
+func WithConfigSource(loader ConfigLoader, source string) ClientOption {
+	return func(opts *clientOpts) {
+		if loader != nil {
+			opts.Loader = loader
+			opts.ConfigSource = source
+		}
+	}
+}
+
 // WithDialer provides a custom Dialer implementation.
 // This is primarily used for testing with mock implementations.
-func WithDialer(dialer ports.Dialer) ClientOption {
+func WithDialer(dialer ports.DialerPort) ClientOption {
 	return func(opts *clientOpts) {
 		if dialer != nil {
 			opts.Impl = dialer
@@ -48,6 +73,29 @@ func WithDialer(dialer ports.Dialer) ClientOption {
 	}
 }
 
+// WithJWTProviders provides custom JWT-SVID and JWT bundle providers for IdentityJWTService.
+// This is primarily used for testing with in-memory providers.
+func WithJWTProviders(svids ports.JWTSVIDProviderPort, bundles ports.JWTBundleProviderPort) ClientOption {
+	return func(opts *clientOpts) {
+		if svids != nil {
+			opts.JWTProvider = svids
+		}
+		if bundles != nil {
+			opts.JWTBundles = bundles
+		}
+	}
+}
+
+// WithClientMetrics reports the client's metrics to m instead of the default
+// Prometheus registry.
+func WithClientMetrics(m *Metrics) ClientOption {
+	return func(opts *clientOpts) {
+		if m != nil {
+			opts.Metrics = m
+		}
+	}
+}
+
 // WithClientTimeout sets the default timeout for client operations.
 // If not specified, a reasonable default timeout will be used.
 func WithClientTimeout(timeout time.Duration) ClientOption {
@@ -61,12 +109,28 @@ type ServerOption func(*serverOpts)
 
 // serverOpts holds the configuration for server creation.
 type serverOpts struct {
-	Config   *ports.Configuration
-	Loader   ConfigLoader
-	Listener net.Listener
-	Address  string
-	Impl     ports.AuthenticatedServer // direct injection for tests
-	Timeout  time.Duration
+	Config       *ports.Configuration
+	Loader       ConfigLoader
+	ConfigSource string
+	Listener     net.Listener
+	Address      string
+	Impl         ports.AuthenticatedServerPort // direct injection for tests
+	Timeout      time.Duration
+
+	// HTTP mode settings
+	HTTPHandler     http.Handler
+	IdentityService IdentityService
+	Authorizer      Authorizer
+	Policy          *Policy
+	AuditLog        *AuditLog
+	Metrics         *Metrics
+
+	// HealthListener serves the health probes of gRPC servers
+	HealthListener net.Listener
+
+	// configWatcher is set by IdentityServerFromFile; the server applies its
+	// runtime-safe changes and closes it.
+	configWatcher ports.ConfigWatcherPort
 }
 
 // WithServerConfig provides an in-memory configuration for the server.
@@ -80,7 +144,8 @@ func WithServerConfig(config *ports.Configuration) ServerOption {
 }
 
 // WithServerConfigLoader provides a custom configuration loader for the server.
-// This allows for loading configuration from non-standard sources.
+// The loader is called with an empty source, which suits loaders such as
+// EnvConfigLoader. Use WithServerConfigSource for loaders that need a source.
 func WithServerConfigLoader(loader ConfigLoader) ServerOption {
 	return func(opts *serverOpts) {
 		if loader != nil {
@@ -89,6 +154,17 @@ func WithServerConfigLoader(loader ConfigLoader) ServerOption {
 	}
 }
 
+// WithServerConfigSource loads the server configuration with loader from source.
+// A configuration provided with WithServerConfig takes precedence.
+func WithServerConfigSource(loader ConfigLoader, source string) ServerOption {
+	return func(opts *serverOpts) {
+		if loader != nil {
+			opts.Loader = loader
+			opts.ConfigSource = source
+		}
+	}
+}
+
 // WithListener provides a specific network listener for the server.
 // This is useful for tests and when you need precise control over the listening socket.
 func WithListener(listener net.Listener) ServerOption {
@@ -101,7 +177,7 @@ func WithListener(listener net.Listener) ServerOption {
 
 // WithAddress specifies the network address for the server to listen on.
 // The address should be in the format "host:port".
-// If not provided, the server will need a listener via WithListener.
+// It overrides server.address and EPHEMOS_BIND_ADDRESS.
 func WithAddress(address string) ServerOption {
 	return func(opts *serverOpts) {
 		opts.Address = address
@@ -110,7 +186,7 @@ func WithAddress(address string) ServerOption {
 
 // WithServerImpl provides a custom AuthenticatedServer implementation.
 // This is primarily used for testing with mock implementations.
-func WithServerImpl(impl ports.AuthenticatedServer) ServerOption {
+func WithServerImpl(impl ports.AuthenticatedServerPort) ServerOption {
 	return func(opts *serverOpts) {
 		if impl != nil {
 			opts.Impl = impl
@@ -118,6 +194,106 @@ func WithServerImpl(impl ports.AuthenticatedServer) ServerOption {
 	}
 }
 
+// WithHTTPHandler switches the server to HTTP mode: ListenAndServe serves the handler
+// over SPIFFE mTLS instead of gRPC. The verified peer identity is available to the
+// handler through PeerIdentityFromContext.
+//
+// Example:
+//
+//	server, err := ephemos.IdentityServer(ctx,
+//	    ephemos.WithServerConfig(config),
+//	    ephemos.WithAddress(":8443"),
+//	    ephemos.WithHTTPHandler(mux),
+//	)
+func WithHTTPHandler(handler http.Handler) ServerOption {
+	return func(opts *serverOpts) {
+		if handler != nil {
+			opts.HTTPHandler = handler
+		}
+	}
+}
+
+// WithServerIdentityService provides the certificates and trust bundle used in HTTP mode.
+// If not specified, the identity is obtained from the SPIFFE Workload API.
+func WithServerIdentityService(identityService IdentityService) ServerOption {
+	return func(opts *serverOpts) {
+		if identityService != nil {
+			opts.IdentityService = identityService
+		}
+	}
+}
+
+// WithClientAuthorizer sets the Authorizer applied to clients in HTTP mode.
+// If not specified, clients must be members of the configured trust domain,
+// or any SPIFFE identity is accepted when no configuration is provided.
+func WithClientAuthorizer(authorizer Authorizer) ServerOption {
+	return func(opts *serverOpts) {
+		if authorizer != nil {
+			opts.Authorizer = authorizer
+		}
+	}
+}
+
+// WithPolicy enforces an authorization policy on requests in HTTP mode.
+// If not specified, the policy section of the server configuration is used, if any.
+func WithPolicy(policy *Policy) ServerOption {
+	return func(opts *serverOpts) {
+		if policy != nil {
+			opts.Policy = policy
+		}
+	}
+}
+
+// WithAuditLog records authentication and authorization decisions: every client
+// certificate verification, including clients presenting no certificate, and every
+// decision of an enforced policy. The caller owns the audit log and closes it.
+func WithAuditLog(auditLog *AuditLog) ServerOption {
+	return func(opts *serverOpts) {
+		if auditLog != nil {
+			opts.AuditLog = auditLog
+		}
+	}
+}
+
+// WithMetrics reports the server's metrics to m instead of the default Prometheus
+// registry. Handshake, connection and rotation metrics cover gRPC servers; in HTTP
+// mode policy decisions and revocation are reported.
+func WithMetrics(m *Metrics) ServerOption {
+	return func(opts *serverOpts) {
+		if m != nil {
+			opts.Metrics = m
+		}
+	}
+}
+
+// WithHealthEndpoint monitors the components selected by the health section of the
+// configuration and serves the /livez, /readyz and /healthz probes on listener, a
+// plain listener for kubelet probes, while the server is serving. The
+// grpc.health.v1.Health service is registered on the server itself, so it is also
+// available to mTLS clients. Enable health.enabled to keep /healthz current; its
+// results are reported as unknown once they are older than twice health.interval.
+// Closing the server closes the listener.
+//
+// Only gRPC servers serve health; in HTTP mode IdentityServer rejects the option.
+//
+// Example:
+//
+//	probes, err := net.Listen("tcp", ":8081")
+//	if err != nil {
+//	    return err
+//	}
+//	server, err := ephemos.IdentityServer(ctx,
+//	    ephemos.WithServerConfig(config),
+//	    ephemos.WithHealthEndpoint(probes),
+//	)
+func WithHealthEndpoint(listener net.Listener) ServerOption {
+	return func(opts *serverOpts) {
+		if listener != nil {
+			opts.HealthListener = listener
+		}
+	}
+}
+
 // WithServerTimeout sets the default timeout for server operations.
 // If not specified, a reasonable default timeout will be used.
 func WithServerTimeout(timeout time.Duration) ServerOption {
@@ -131,7 +307,18 @@ type DialOption func(*dialOpts)
 
 // dialOpts holds the configuration for connection establishment.
 type dialOpts struct {
-	Timeout time.Duration
+	Timeout  time.Duration
+	ServerID string
+}
+
+// WithServerID requires the server to have the given SPIFFE ID. It is checked during
+// the handshake and for every gRPC call on the connection. Without it the server is
+// only required to belong to the configured trust domain, or to a federated trust
+// domain the client authorizes.
+func WithServerID(id string) DialOption {
+	return func(opts *dialOpts) {
+		opts.ServerID = id
+	}
 }
 
 // WithDialTimeout sets the timeout for connection establishment.
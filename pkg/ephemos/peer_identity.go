@@ -0,0 +1,75 @@
+package ephemos
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/transport"
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// PeerIdentity describes the authenticated peer of an mTLS connection: its SPIFFE ID
+// and trust domain, its certificate chain, leaf first, and its network address.
+type PeerIdentity = ports.PeerIdentity
+
+// peerIdentityContextKey is the context key for the authenticated peer identity.
+type peerIdentityContextKey struct{}
+
+// PeerIdentityFromContext returns the peer identity stored by an identity server,
+// for HTTP handlers and gRPC service methods alike, or by PeerIdentityMiddleware.
+func PeerIdentityFromContext(ctx context.Context) (*PeerIdentity, bool) {
+	if identity, ok := ctx.Value(peerIdentityContextKey{}).(*PeerIdentity); ok && identity != nil {
+		return identity, true
+	}
+	return transport.PeerIdentityFromContext(ctx)
+}
+
+// PeerIdentityMiddleware returns HTTP middleware that stores the SPIFFE identity of the
+// TLS client in the request context. Requests without a client certificate carrying a
+// SPIFFE ID are rejected with 401. Use it with an http.Server configured by
+// NewServerTLSConfig; IdentityServer applies it automatically in HTTP mode.
+//
+// Example:
+//
+//	server := &http.Server{
+//	    Handler:   ephemos.PeerIdentityMiddleware(mux),
+//	    TLSConfig: tlsConfig,
+//	}
+func PeerIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := peerIdentityFromRequest(r)
+		if err != nil {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), peerIdentityContextKey{}, identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// peerIdentityFromRequest extracts the peer identity from the TLS connection state.
+// The verified chain is preferred; go-spiffe verifies peers itself and leaves
+// VerifiedChains empty, in which case the presented chain is used.
+func peerIdentityFromRequest(r *http.Request) (*PeerIdentity, error) {
+	if r.TLS == nil {
+		return nil, ErrNoAuth
+	}
+
+	chain := r.TLS.PeerCertificates
+	if len(r.TLS.VerifiedChains) > 0 {
+		chain = r.TLS.VerifiedChains[0]
+	}
+
+	id, err := peerID(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PeerIdentity{
+		ID:           id.String(),
+		TrustDomain:  id.TrustDomain().String(),
+		Certificates: chain,
+		RemoteAddr:   r.RemoteAddr,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package ephemos
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/audit"
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
+)
+
+// PolicyDecision is the outcome of evaluating an authorization policy.
+type PolicyDecision struct {
+	// Allowed reports whether the request is permitted.
+	Allowed bool
+	// Rule is the name of the rule that decided the request, or "default" when no rule matched.
+	Rule string
+	// Reason explains the decision, e.g.
+	// `spiffe://prod.company.com/web calling GET /admin denied by rule "admin-only"`.
+	Reason string
+}
+
+// Policy is a declarative authorization policy: ordered allow and deny rules that
+// match a peer SPIFFE ID against an HTTP method and path or a gRPC full method name.
+// The first matching rule decides; requests that match no rule get the default action.
+// A Policy is safe for concurrent use.
+type Policy struct {
+	evaluator *services.PolicyEvaluator
+}
+
+// NewPolicy creates a policy from the policy section of the configuration.
+// A configuration without a policy section yields a policy that allows every
+// authenticated peer.
+//
+// Example configuration:
+//
+//	policy:
+//	  default: deny
+//	  rules:
+//	    - name: admin-only
+//	      action: allow
+//	      peers:
+//	        - id: spiffe://prod.company.com/admin
+//	      paths: ["/admin/*"]
+//	    - name: read-api
+//	      action: allow
+//	      peers:
+//	        - prefix: spiffe://prod.company.com/
+//	      methods: [GET]
+//	      paths: ["/api/*"]
+func NewPolicy(config Configuration) (*Policy, error) {
+	cfg, err := GetInternalConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return newPolicy(cfg.Policy)
+}
+
+func newPolicy(config *ports.PolicyConfig) (*Policy, error) {
+	evaluator, err := services.NewPolicyEvaluator(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return &Policy{evaluator: evaluator}, nil
+}
+
+// AuthorizeHTTP decides whether peerID may call method on path.
+func (p *Policy) AuthorizeHTTP(peerID, method, path string) PolicyDecision {
+	return p.evaluate(ports.AccessRequest{PeerID: peerID, Method: method, Path: path})
+}
+
+// AuthorizeGRPC decides whether peerID may call the gRPC full method name,
+// e.g. "/billing.v1.Billing/Charge".
+func (p *Policy) AuthorizeGRPC(peerID, fullMethod string) PolicyDecision {
+	return p.evaluate(ports.AccessRequest{PeerID: peerID, GRPCMethod: fullMethod})
+}
+
+func (p *Policy) evaluate(request ports.AccessRequest) PolicyDecision {
+	decision := p.evaluator.Evaluate(request)
+	return PolicyDecision{
+		Allowed: decision.Allowed,
+		Rule:    decision.Rule,
+		Reason:  decision.Reason,
+	}
+}
+
+// Err returns nil for an allowed decision and an *AuthorizationError naming the
+// deciding rule otherwise.
+func (d PolicyDecision) Err(peerID string) error {
+	if d.Allowed {
+		return nil
+	}
+	return &AuthorizationError{Rule: d.Rule, PeerID: peerID, Reason: d.Reason}
+}
+
+// Middleware returns HTTP middleware that enforces the policy on the peer identity
+// stored by PeerIdentityMiddleware. Requests without a peer identity are rejected
+// with 401 and denied requests with 403. IdentityServer applies the policy
+// automatically in HTTP mode when one is configured.
+//
+// Example:
+//
+//	handler := ephemos.PeerIdentityMiddleware(policy.Middleware(mux))
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return p.middleware(next, nil)
+}
+
+// middleware enforces the policy and reports each decision to onDecision, if set.
+func (p *Policy) middleware(next http.Handler, onDecision func(*http.Request, *PeerIdentity, PolicyDecision)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := PeerIdentityFromContext(r.Context())
+		if !ok {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		decision := p.AuthorizeHTTP(identity.ID, r.Method, r.URL.Path)
+		if onDecision != nil {
+			onDecision(r, identity, decision)
+		}
+		if !decision.Allowed {
+			http.Error(w, fmt.Sprintf("forbidden by policy rule %q", decision.Rule), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpPolicyAuditEvent describes a policy decision on an HTTP request.
+func httpPolicyAuditEvent(r *http.Request, identity *PeerIdentity, decision PolicyDecision, localID string) AuditEvent {
+	event := AuditEvent{
+		Kind:       ports.AuditKindAuthorization,
+		Decision:   ports.AuditDecisionAllow,
+		Transport:  "http",
+		Operation:  r.Method + " " + r.URL.Path,
+		PeerID:     identity.ID,
+		LocalID:    localID,
+		RemoteAddr: r.RemoteAddr,
+		Rule:       decision.Rule,
+		Reason:     decision.Reason,
+	}
+	if len(identity.Certificates) > 0 {
+		audit.SetPeerCertificate(&event, identity.Certificates[0])
+	}
+	if !decision.Allowed {
+		event.Decision = ports.AuditDecisionDeny
+		event.ErrorClass = AuditErrorPolicyDenied
+	}
+	return event
+}
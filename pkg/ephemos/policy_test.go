@@ -0,0 +1,159 @@
+package ephemos
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const policyYAML = `
+service:
+  name: policy-test
+  domain: example.org
+policy:
+  default: deny
+  rules:
+    - name: admin-only
+      action: allow
+      peers:
+        - id: spiffe://example.org/admin
+      paths: ["/admin/*"]
+    - name: read-api
+      action: allow
+      peers:
+        - prefix: spiffe://example.org/
+      methods: [GET]
+      paths: ["/api/*"]
+    - name: billing
+      action: allow
+      peers:
+        - id: spiffe://example.org/billing
+      grpc_methods: ["/billing.v1.Billing/*"]
+`
+
+func newTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+	config, err := ParseConfiguration(context.Background(), []byte(policyYAML))
+	require.NoError(t, err)
+	policy, err := NewPolicy(config)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestPolicy_Authorize(t *testing.T) {
+	policy := newTestPolicy(t)
+
+	decision := policy.AuthorizeHTTP("spiffe://example.org/web", http.MethodGet, "/api/orders")
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "read-api", decision.Rule)
+	assert.NoError(t, decision.Err("spiffe://example.org/web"))
+
+	decision = policy.AuthorizeHTTP("spiffe://example.org/web", http.MethodGet, "/admin/users")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "default", decision.Rule)
+
+	err := decision.Err("spiffe://example.org/web")
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	var authErr *AuthorizationError
+	require.True(t, errors.As(err, &authErr))
+	assert.Equal(t, "default", authErr.Rule)
+
+	assert.True(t, policy.AuthorizeGRPC("spiffe://example.org/billing", "/billing.v1.Billing/Charge").Allowed)
+	assert.False(t, policy.AuthorizeGRPC("spiffe://example.org/web", "/billing.v1.Billing/Charge").Allowed)
+}
+
+func TestNewPolicy_InvalidPolicy(t *testing.T) {
+	config, err := ParseConfiguration(context.Background(), []byte(policyYAML))
+	require.NoError(t, err)
+
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+	internal.Policy.Rules[0].Paths = []string{"admin/*"}
+
+	_, err = NewPolicy(config)
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+}
+
+func TestPolicy_Middleware(t *testing.T) {
+	policy := newTestPolicy(t)
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		peerID     string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "allowed", peerID: "spiffe://example.org/admin", method: http.MethodPost, path: "/admin/users", wantStatus: http.StatusNoContent},
+		{name: "denied", peerID: "spiffe://example.org/web", method: http.MethodPost, path: "/admin/users", wantStatus: http.StatusForbidden},
+		{name: "no peer identity", method: http.MethodGet, path: "/api/orders", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.peerID != "" {
+				identity := &PeerIdentity{ID: tt.peerID, TrustDomain: "example.org"}
+				req = req.WithContext(context.WithValue(req.Context(), peerIdentityContextKey{}, identity))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestIdentityServerHTTPMode_EnforcesConfiguredPolicy(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config, err := ParseConfiguration(context.Background(), []byte(policyYAML))
+	require.NoError(t, err)
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithServerConfig(internal),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+
+	client, err := NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/web"),
+		Authorizer:      AuthorizeID("spiffe://example.org/server"),
+	})
+	require.NoError(t, err)
+	baseURL := "https://" + listener.Addr().String()
+
+	resp, err := client.Get(baseURL + "/api/orders")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = client.Get(baseURL + "/admin/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
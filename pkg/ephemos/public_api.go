@@ -43,11 +43,37 @@
 //
 //	if err := server.ListenAndServe(ctx); err != nil { return err }
 //
+// gRPC services are registered on the server before serving, and clients obtain
+// an authenticated *grpc.ClientConn from the connection. The typed helpers live in
+// package ephemosgrpc so that this package stays free of protocol dependencies:
+//
+//	err = ephemosgrpc.RegisterService(ctx, server, func(s grpc.ServiceRegistrar) {
+//	    paymentpb.RegisterPaymentServer(s, &paymentImpl{})
+//	})
+//
+//	grpcConn, err := ephemosgrpc.ClientConn(conn)
+//	if err != nil { return err }
+//	payments := paymentpb.NewPaymentClient(grpcConn)
+//
+// Plain net/http handlers can be hosted over SPIFFE mTLS instead of gRPC. The
+// verified client identity is available from the request context:
+//
+//	server, err := IdentityServer(ctx, WithServerConfig(config),
+//	    WithAddress(":8443"), WithHTTPHandler(mux))
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//	    peer, ok := ephemos.PeerIdentityFromContext(r.Context())
+//	    ...
+//	}
+//
 // Service registration and management are handled by CLI tools, not the public API.
 package ephemos
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -55,8 +81,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
 	"github.com/sufield/ephemos/internal/adapters/secondary/config"
+	"github.com/sufield/ephemos/internal/adapters/secondary/revocation"
+	"github.com/sufield/ephemos/internal/adapters/secondary/transport"
 	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/internal/core/services"
 	"github.com/sufield/ephemos/internal/factory"
 )
 
@@ -82,7 +113,8 @@ type Client interface {
 
 // clientConn is the minimal behavior we need from the internal connection.
 type clientConn interface {
-	HTTPClient() (ports.HTTPClient, error)
+	HTTPClient() (ports.HTTPClientPort, error)
+	ClientConnection() (interface{}, error)
 	Close() error
 }
 
@@ -114,10 +146,35 @@ func (c *clientConnectionImpl) HTTPClient() (*http.Client, error) {
 		return nil, err
 	}
 
-	// Convert ports.HTTPClient back to *http.Client for public API
+	// Convert ports.HTTPClientPort back to *http.Client for public API
 	return newHTTPClientFromPort(portClient), nil
 }
 
+// TransportConn returns the underlying RPC client connection.
+// The connection uses the same rotation-aware mTLS credentials as the HTTP client.
+func (c *clientConnectionImpl) TransportConn() (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, ErrServerClosed
+	}
+
+	if c.conn == nil {
+		return nil, ErrNoSPIFFEAuth
+	}
+
+	raw, err := c.conn.ClientConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		return nil, fmt.Errorf("%w: connection does not provide a transport connection", ErrConnectionFailed)
+	}
+	return raw, nil
+}
+
 // Close closes the connection and releases resources.
 // It is safe to call Close multiple times.
 func (c *clientConnectionImpl) Close() error {
@@ -138,6 +195,13 @@ func (c *clientConnectionImpl) Close() error {
 // Server provides identity-based server functionality for hosting services.
 // All methods are safe for concurrent use by multiple goroutines.
 type Server interface {
+	// RegisterService registers service implementations on the server.
+	// The register function receives the underlying transport server, a
+	// grpc.ServiceRegistrar for the built-in transport, and its error is returned.
+	// Use ephemosgrpc.RegisterService for typed gRPC registration. Must be called
+	// before ListenAndServe.
+	RegisterService(ctx context.Context, register func(server interface{}) error) error
+
 	// ListenAndServe starts the server and serves requests.
 	// Blocks until the context is cancelled or an error occurs.
 	ListenAndServe(ctx context.Context) error
@@ -171,13 +235,14 @@ func IdentityClient(ctx context.Context, opts ...ClientOption) (Client, error) {
 	}
 
 	// Load configuration from options
-	config, err := loadClientConfig(options)
+	config, err := loadClientConfig(ctx, options)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
 	}
 
 	// Create SPIFFE/SPIRE-backed dialer via factory
-	dialer, err := factory.SPIFFEDialer(ctx, config)
+	dialer, err := factory.SPIFFEDialer(ctx, config,
+		factory.WithDialerMetrics(options.Metrics.prometheusMetrics()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
@@ -189,7 +254,9 @@ func IdentityClient(ctx context.Context, opts ...ClientOption) (Client, error) {
 }
 
 // IdentityServer creates a new identity server for hosting services.
-// Configuration can be provided via options, with explicit networking required.
+// Configuration can be provided via options. The server listens on the WithListener
+// listener or the WithAddress address if given, otherwise on EPHEMOS_BIND_ADDRESS,
+// server.address or ":8443", in that order.
 func IdentityServer(ctx context.Context, opts ...ServerOption) (Server, error) {
 	// Apply options to build configuration
 	options := &serverOpts{
@@ -199,38 +266,204 @@ func IdentityServer(ctx context.Context, opts ...ServerOption) (Server, error) {
 		opt(options)
 	}
 
+	// HTTP mode terminates SPIFFE mTLS in front of a plain http.Handler
+	if options.HTTPHandler != nil {
+		if options.HealthListener != nil {
+			return nil, fmt.Errorf("%w: the health endpoint is only served by gRPC servers", ErrConfigInvalid)
+		}
+		return newHTTPModeServer(ctx, options)
+	}
+
 	// If a direct implementation is provided (for testing), use it
 	if options.Impl != nil {
 		return &serverWrapper{
-			impl:     options.Impl,
-			listener: options.Listener,
-			address:  options.Address,
-			timeout:  options.Timeout,
+			impl:          options.Impl,
+			listener:      options.Listener,
+			address:       serverAddress(options, options.Config),
+			timeout:       options.Timeout,
+			configWatcher: options.configWatcher,
 		}, nil
 	}
 
-	// Validate networking configuration
-	if options.Listener == nil && options.Address == "" {
-		return nil, fmt.Errorf("%w: either WithListener or WithAddress must be specified", ErrConfigInvalid)
-	}
-
 	// Load configuration from options
-	config, err := loadServerConfig(options)
+	config, err := loadServerConfig(ctx, options)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
 	}
 
 	// Create SPIFFE/SPIRE-backed server via factory
-	impl, err := factory.SPIFFEServer(ctx, config)
+	serverOptions := []factory.ServerOption{factory.WithMetrics(options.Metrics.prometheusMetrics())}
+	if options.AuditLog != nil {
+		serverOptions = append(serverOptions, factory.WithAuditRecorder(options.AuditLog.trail))
+	}
+	if options.configWatcher != nil {
+		serverOptions = append(serverOptions, factory.WithConfigWatcher(options.configWatcher))
+	}
+	if options.HealthListener != nil {
+		serverOptions = append(serverOptions, factory.WithHealthEndpoint(options.HealthListener))
+	}
+	impl, err := factory.SPIFFEServer(ctx, config, serverOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create server: %w", err)
 	}
 
 	return &serverWrapper{
-		impl:     impl,
-		listener: options.Listener,
-		address:  options.Address,
-		timeout:  options.Timeout,
+		impl:          impl,
+		listener:      options.Listener,
+		address:       serverAddress(options, config),
+		timeout:       options.Timeout,
+		configWatcher: options.configWatcher,
+	}, nil
+}
+
+// serverAddress resolves the listen address. WithListener and WithAddress take
+// precedence over EPHEMOS_BIND_ADDRESS, which takes precedence over server.address;
+// the default is ":8443".
+func serverAddress(options *serverOpts, config *ports.Configuration) string {
+	if options.Listener != nil {
+		return options.Address
+	}
+	return config.BindAddress().WithOption(options.Address).Value
+}
+
+// newHTTPModeServer creates a server that serves options.HTTPHandler over SPIFFE mTLS.
+// The identity comes from options.IdentityService or, by default, the Workload API.
+// Configuration is optional with an injected identity service.
+func newHTTPModeServer(ctx context.Context, options *serverOpts) (Server, error) {
+	identityService := options.IdentityService
+	var config *ports.Configuration
+	if identityService == nil || options.Config != nil || options.Loader != nil {
+		var err error
+		config, err = loadServerConfig(ctx, options)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+		}
+	}
+
+	// Without a configuration, EPHEMOS_LOG_LEVEL and EPHEMOS_LOG_FORMAT still apply
+	logger, err := factory.Logger(config, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+
+	var identityCloser io.Closer
+	if identityService == nil {
+		var providerOptions []factory.ServerOption
+		if options.configWatcher != nil {
+			providerOptions = append(providerOptions, factory.WithConfigWatcher(options.configWatcher))
+		}
+		provider, err := factory.SPIFFEIdentityProvider(ctx, config, providerOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create server: %w", err)
+		}
+		adapter := &identityProviderAdapter{provider: provider}
+		identityService, identityCloser = adapter, adapter
+	}
+
+	// Default to the configured trust domain, matching the gRPC transport's secure default
+	authorizer := options.Authorizer
+	if authorizer == nil {
+		if config != nil && config.Service.Domain != "" {
+			authorizer = AuthorizeMemberOf(config.Service.Domain)
+		} else {
+			authorizer = AuthorizeAny()
+		}
+	}
+
+	handler := options.HTTPHandler
+	policy := options.Policy
+	if policy == nil && config != nil && config.Policy != nil {
+		var err error
+		if policy, err = newPolicy(config.Policy); err != nil {
+			if identityCloser != nil {
+				_ = identityCloser.Close()
+			}
+			return nil, err
+		}
+	}
+	auditLog := options.AuditLog
+	localID := ""
+	if auditLog != nil {
+		localID = localIdentity(identityService)
+	}
+	reporter := options.Metrics.prometheusMetrics()
+	if policy != nil {
+		onDecision := func(r *http.Request, identity *PeerIdentity, decision PolicyDecision) {
+			reporter.RecordAuthorization(decision.Rule, decision.Allowed)
+			if auditLog != nil {
+				auditLog.Record(r.Context(), httpPolicyAuditEvent(r, identity, decision, localID))
+			}
+		}
+		handler = policy.middleware(handler, onDecision)
+	}
+
+	var revoked *revocation.List
+	if config != nil {
+		var err error
+		if revoked, err = factory.RevocationList(config, reporter, logger, options.configWatcher != nil); err != nil {
+			if identityCloser != nil {
+				_ = identityCloser.Close()
+			}
+			return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+		}
+	}
+
+	// Without a configuration, e.g. with an injected identity service, the TLS
+	// defaults apply
+	var settings *TLSSettings
+	if config != nil {
+		settings = config.EffectiveTLS()
+	}
+	if revoked != nil {
+		settings = settings.WithRevocation(revoked)
+	}
+	tlsConfig, err := NewServerTLSConfig(identityService, authorizer, settings)
+	if err != nil {
+		if revoked != nil {
+			_ = revoked.Close()
+		}
+		if identityCloser != nil {
+			_ = identityCloser.Close()
+		}
+		return nil, fmt.Errorf("failed to create server: %w", err)
+	}
+	if auditLog != nil {
+		tlsConfig = auditTLSConfig(tlsConfig, auditLog, authorizer, localID)
+	}
+
+	// Connections are tracked so that those failing the mTLS invariants, e.g. because
+	// the peer certificate expired, are closed
+	connections := services.NewMTLSConnectionRegistry(nil)
+	connections.SetLogger(logger)
+	enforcement := services.NewMTLSEnforcementService(nil, connections)
+	enforcement.SetLogger(logger)
+	if auditLog != nil {
+		enforcement.SetAuditRecorder(auditLog.trail)
+	}
+	var revocationCloser io.Closer
+	if revoked != nil {
+		if config.Revocation != nil && config.Revocation.EnforceExisting {
+			enforcement.EnforceRevocation(revoked)
+		}
+		// Closing the configuration watcher ends the subscription
+		if options.configWatcher != nil {
+			revoked.WatchConfig(options.configWatcher)
+		}
+		revocationCloser = revoked
+	}
+
+	return &serverWrapper{
+		listener:       options.Listener,
+		address:        serverAddress(options, config),
+		timeout:        options.Timeout,
+		httpHandler:    handler,
+		tlsConfig:      tlsConfig,
+		identityCloser: identityCloser,
+		revocation:     revocationCloser,
+		configWatcher:  options.configWatcher,
+		connections:    connections,
+		enforcement:    enforcement,
+		localChain:     certificateChain(identityService),
 	}, nil
 }
 
@@ -249,22 +482,51 @@ func IdentityClientFromFile(ctx context.Context, path string, opts ...ClientOpti
 }
 
 // IdentityServerFromFile creates a new identity server from a configuration file.
-// This is a convenience function that loads configuration from a file.
+// This is a convenience function that loads configuration from a file. EPHEMOS_*
+// environment variables override the file, and options override both.
+//
+// The file is watched until the server is closed. Valid edits to sections that are
+// safe to change at runtime, such as the federated trust domains, are applied;
+// invalid edits are logged and the last good configuration is kept. Reloads are
+// reported to the WithMetrics metrics.
 func IdentityServerFromFile(ctx context.Context, path string, opts ...ServerOption) (Server, error) {
-	provider := config.NewFileProvider()
-	cfg, err := provider.LoadConfiguration(ctx, path)
+	options := &serverOpts{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	watcher, err := config.NewWatchingProvider(ctx, config.WatchingProviderConfig{
+		Path:    path,
+		Metrics: options.Metrics.prometheusMetrics(),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load configuration from %s: %w", path, err)
 	}
+	logger, err := factory.Logger(watcher.Current(), nil)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	watcher.SetLogger(logger)
+	if err := watcher.Start(); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch configuration file %s: %w", path, err)
+	}
 
 	// Prepend the file-based config option
-	allOpts := append([]ServerOption{WithServerConfig(cfg)}, opts...)
-	return IdentityServer(ctx, allOpts...)
+	allOpts := append([]ServerOption{WithServerConfig(watcher.Current())}, opts...)
+	allOpts = append(allOpts, func(opts *serverOpts) { opts.configWatcher = watcher })
+	server, err := IdentityServer(ctx, allOpts...)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return server, nil
 }
 
 // clientWrapper adapts a Dialer to the public Client interface
 type clientWrapper struct {
-	dialer  ports.Dialer
+	dialer  ports.DialerPort
 	timeout time.Duration
 	mu      sync.RWMutex
 	closed  bool
@@ -298,9 +560,17 @@ func (c *clientWrapper) Connect(ctx context.Context, target string, opts ...Dial
 		defer cancel()
 	}
 
+	// The dialer treats a SPIFFE ID as the exact server identity to expect
+	serviceName := "default"
+	if dialOpts.ServerID != "" {
+		if _, err := spiffeid.FromString(dialOpts.ServerID); err != nil {
+			return nil, fmt.Errorf("%w: invalid server SPIFFE ID %q: %w", ErrConfigInvalid, dialOpts.ServerID, err)
+		}
+		serviceName = dialOpts.ServerID
+	}
+
 	// Establish connection using the dialer
-	// For now, use a default service name - this could be enhanced with service discovery
-	conn, err := c.dialer.Connect(dialCtx, "default", target)
+	conn, err := c.dialer.Connect(dialCtx, serviceName, target)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrConnectionFailed, err)
 	}
@@ -323,14 +593,54 @@ func (c *clientWrapper) Close() error {
 	return nil
 }
 
-// serverWrapper adapts an AuthenticatedServer to the public Server interface
+// serverWrapper adapts an AuthenticatedServer to the public Server interface.
+// When httpHandler is set the wrapper runs in HTTP mode and serves it directly.
 type serverWrapper struct {
-	impl     ports.AuthenticatedServer
+	impl     ports.AuthenticatedServerPort
 	listener net.Listener
 	address  string
 	timeout  time.Duration
 	mu       sync.RWMutex
 	closed   bool
+
+	// HTTP mode state
+	httpHandler    http.Handler
+	tlsConfig      *tls.Config
+	identityCloser io.Closer
+	revocation     io.Closer
+	configWatcher  io.Closer
+	httpServer     *http.Server
+	httpAddr       net.Addr
+	connections    *services.MTLSConnectionRegistry
+	enforcement    *services.MTLSEnforcementService
+	localChain     func() []*x509.Certificate
+}
+
+func (s *serverWrapper) RegisterService(ctx context.Context, register func(server interface{}) error) error {
+	if register == nil {
+		return fmt.Errorf("%w: register function cannot be nil", ErrConfigInvalid)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return ErrServerClosed
+	}
+
+	if s.httpHandler != nil {
+		return fmt.Errorf("%w: services cannot be registered on a server in HTTP mode", ErrConfigInvalid)
+	}
+
+	if s.impl == nil {
+		return fmt.Errorf("%w: server implementation is nil", ErrConfigInvalid)
+	}
+
+	registrar := &serviceRegistrarAdapter{register: register}
+	if err := s.impl.RegisterService(ctx, registrar); err != nil {
+		return err
+	}
+	return registrar.err
 }
 
 func (s *serverWrapper) ListenAndServe(ctx context.Context) error {
@@ -345,9 +655,10 @@ func (s *serverWrapper) ListenAndServe(ctx context.Context) error {
 	address := s.address
 	timeout := s.timeout
 	impl := s.impl
+	httpMode := s.httpHandler != nil
 	s.mu.RUnlock()
 
-	if impl == nil {
+	if impl == nil && !httpMode {
 		return fmt.Errorf("%w: server implementation is nil", ErrConfigInvalid)
 	}
 
@@ -361,6 +672,10 @@ func (s *serverWrapper) ListenAndServe(ctx context.Context) error {
 		defer listener.Close()
 	}
 
+	if httpMode {
+		return s.serveHTTP(ctx, listener)
+	}
+
 	// Apply timeout to context if configured
 	serverCtx := ctx
 	if timeout > 0 {
@@ -374,6 +689,61 @@ func (s *serverWrapper) ListenAndServe(ctx context.Context) error {
 	return impl.Serve(serverCtx, networkListener)
 }
 
+// serveHTTP serves the HTTP handler over SPIFFE mTLS until ctx is cancelled or the
+// server is closed. On cancellation in-flight requests are given the server timeout
+// to complete. The timeout also bounds reading request headers.
+func (s *serverWrapper) serveHTTP(ctx context.Context, listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	timeout := s.timeout
+	httpServer := &http.Server{
+		Handler:           PeerIdentityMiddleware(s.httpHandler),
+		TLSConfig:         s.tlsConfig,
+		ReadHeaderTimeout: timeout,
+	}
+	s.httpServer = httpServer
+	s.httpAddr = listener.Addr()
+	s.mu.Unlock()
+
+	// Connections closed by mTLS enforcement get the server timeout to finish
+	grace := timeout
+	if grace <= 0 {
+		grace = DefaultServerTimeout
+	}
+	listener = transport.TrackHTTPServer(httpServer, listener, s.connections, s.localChain, grace)
+	enforceCtx, stopEnforcement := context.WithCancel(ctx)
+	defer stopEnforcement()
+	if err := s.enforcement.StartEnforcement(enforceCtx); err != nil {
+		return fmt.Errorf("failed to start mTLS enforcement: %w", err)
+	}
+
+	shutdownDone := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(shutdownDone)
+		shutdownCtx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			shutdownCtx, cancel = context.WithTimeout(shutdownCtx, timeout)
+			defer cancel()
+		}
+		_ = httpServer.Shutdown(shutdownCtx)
+	})
+
+	// Certificates come from TLSConfig, so no files are passed
+	err := httpServer.ServeTLS(listener, "", "")
+	if !stop() {
+		<-shutdownDone
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
 func (s *serverWrapper) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -383,17 +753,38 @@ func (s *serverWrapper) Close() error {
 	}
 
 	s.closed = true
+	var errs []error
+	if s.configWatcher != nil {
+		errs = append(errs, s.configWatcher.Close())
+	}
+	if s.httpServer != nil {
+		errs = append(errs, s.httpServer.Close())
+	}
+	if s.identityCloser != nil {
+		errs = append(errs, s.identityCloser.Close())
+	}
+	if s.revocation != nil {
+		errs = append(errs, s.revocation.Close())
+	}
 	if s.impl != nil {
-		return s.impl.Close()
+		errs = append(errs, s.impl.Close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (s *serverWrapper) Addr() net.Addr {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if s.closed || s.impl == nil {
+	if s.closed {
+		return nil
+	}
+
+	if s.httpHandler != nil {
+		return s.httpAddr
+	}
+
+	if s.impl == nil {
 		return nil
 	}
 
@@ -402,7 +793,7 @@ func (s *serverWrapper) Addr() net.Addr {
 	if addrStr == "" {
 		return nil
 	}
-	
+
 	// Parse the address string back to net.TCPAddr
 	// This is a simple conversion - could be enhanced for other address types
 	addr, err := net.ResolveTCPAddr("tcp", addrStr)
@@ -412,8 +803,9 @@ func (s *serverWrapper) Addr() net.Addr {
 	return addr
 }
 
-// loadClientConfig loads configuration from client options
-func loadClientConfig(opts *clientOpts) (*ports.Configuration, error) {
+// loadClientConfig loads configuration from client options.
+// An explicit configuration takes precedence over a loader.
+func loadClientConfig(ctx context.Context, opts *clientOpts) (*ports.Configuration, error) {
 	if opts == nil {
 		return nil, fmt.Errorf("nil client options")
 	}
@@ -423,15 +815,15 @@ func loadClientConfig(opts *clientOpts) (*ports.Configuration, error) {
 	}
 
 	if opts.Loader != nil {
-		// Custom loader would need a source - this is a placeholder
-		return nil, fmt.Errorf("custom loader specified but no source provided")
+		return loadFromLoader(ctx, opts.Loader, opts.ConfigSource)
 	}
 
 	return nil, fmt.Errorf("no configuration provided")
 }
 
-// loadServerConfig loads configuration from server options
-func loadServerConfig(opts *serverOpts) (*ports.Configuration, error) {
+// loadServerConfig loads configuration from server options.
+// An explicit configuration takes precedence over a loader.
+func loadServerConfig(ctx context.Context, opts *serverOpts) (*ports.Configuration, error) {
 	if opts == nil {
 		return nil, fmt.Errorf("nil server options")
 	}
@@ -441,25 +833,24 @@ func loadServerConfig(opts *serverOpts) (*ports.Configuration, error) {
 	}
 
 	if opts.Loader != nil {
-		// Custom loader would need a source - this is a placeholder
-		return nil, fmt.Errorf("custom loader specified but no source provided")
+		return loadFromLoader(ctx, opts.Loader, opts.ConfigSource)
 	}
 
 	return nil, fmt.Errorf("no configuration provided")
 }
 
-// newHTTPClientFromPort creates an *http.Client that delegates to a ports.HTTPClient.
-// This allows the public API to maintain its *http.Client interface while using 
+// newHTTPClientFromPort creates an *http.Client that delegates to a ports.HTTPClientPort.
+// This allows the public API to maintain its *http.Client interface while using
 // the abstracted ports internally.
-func newHTTPClientFromPort(portClient ports.HTTPClient) *http.Client {
+func newHTTPClientFromPort(portClient ports.HTTPClientPort) *http.Client {
 	return &http.Client{
 		Transport: &portClientTransport{portClient: portClient},
 	}
 }
 
-// portClientTransport implements http.RoundTripper by delegating to ports.HTTPClient.
+// portClientTransport implements http.RoundTripper by delegating to ports.HTTPClientPort.
 type portClientTransport struct {
-	portClient ports.HTTPClient
+	portClient ports.HTTPClientPort
 }
 
 func (t *portClientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -476,7 +867,7 @@ func (t *portClientTransport) RoundTrip(req *http.Request) (*http.Response, erro
 		Body:    req.Body,
 	}
 
-	// Execute request via ports.HTTPClient
+	// Execute request via ports.HTTPClientPort
 	portResp, err := t.portClient.Do(req.Context(), portReq)
 	if err != nil {
 		return nil, err
@@ -498,7 +889,21 @@ func (t *portClientTransport) RoundTrip(req *http.Request) (*http.Response, erro
 	return httpResp, nil
 }
 
-// networkListenerAdapter adapts net.Listener to ports.NetworkListener.
+// serviceRegistrarAdapter adapts a registration function to ports.ServiceRegistrarPort.
+// The port's Register has no error result, so the function's error is kept for
+// RegisterService to return.
+type serviceRegistrarAdapter struct {
+	register func(server interface{}) error
+	err      error
+}
+
+func (a *serviceRegistrarAdapter) Register(server interface{}) {
+	if err := a.register(server); err != nil {
+		a.err = fmt.Errorf("failed to register service: %w", err)
+	}
+}
+
+// networkListenerAdapter adapts net.Listener to ports.NetworkListenerPort.
 type networkListenerAdapter struct {
 	listener net.Listener
 }
@@ -518,3 +923,8 @@ func (a *networkListenerAdapter) Addr() string {
 func (a *networkListenerAdapter) Close() error {
 	return a.listener.Close()
 }
+
+// NetListener returns the adapted listener, which the SPIFFE server serves on.
+func (a *networkListenerAdapter) NetListener() net.Listener {
+	return a.listener
+}
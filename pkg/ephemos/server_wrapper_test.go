@@ -6,11 +6,13 @@ import (
 	"net/http"
 	"testing"
 	"time"
+
+	"github.com/sufield/ephemos/internal/core/ports"
 )
 
 type mockAuthenticatedServer struct {
 	started chan struct{}
-	addr    net.Addr
+	addr    string
 }
 
 func newMockAuthenticatedServer() *mockAuthenticatedServer {
@@ -21,7 +23,11 @@ func (m *mockAuthenticatedServer) HTTPHandler() http.Handler {
 	return http.NewServeMux()
 }
 
-func (m *mockAuthenticatedServer) Serve(ctx context.Context, l net.Listener) error {
+func (m *mockAuthenticatedServer) RegisterService(context.Context, ports.ServiceRegistrarPort) error {
+	return nil
+}
+
+func (m *mockAuthenticatedServer) Serve(ctx context.Context, l ports.NetworkListenerPort) error {
 	m.addr = l.Addr()
 	close(m.started)
 	<-ctx.Done()
@@ -30,7 +36,7 @@ func (m *mockAuthenticatedServer) Serve(ctx context.Context, l net.Listener) err
 
 func (m *mockAuthenticatedServer) Close() error { return nil }
 
-func (m *mockAuthenticatedServer) Addr() net.Addr { return m.addr }
+func (m *mockAuthenticatedServer) Addr() string { return m.addr }
 
 func TestServerWrapperListenAndServeReleasesLock(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -83,3 +89,45 @@ func TestServerWrapperListenAndServeReleasesLock(t *testing.T) {
 		t.Fatalf("ListenAndServe did not return")
 	}
 }
+
+func TestIdentityServerAddressPrecedence(t *testing.T) {
+	t.Setenv(ports.EnvBindAddress, "")
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: address-test
+  domain: example.org
+server:
+  address: "127.0.0.1:9443"
+`))
+	if err != nil {
+		t.Fatalf("ParseConfiguration() error = %v", err)
+	}
+	internal, err := GetInternalConfig(config)
+	if err != nil {
+		t.Fatalf("GetInternalConfig() error = %v", err)
+	}
+
+	address := func(opts ...ServerOption) string {
+		t.Helper()
+		opts = append([]ServerOption{WithServerImpl(newMockAuthenticatedServer())}, opts...)
+		srv, err := IdentityServer(context.Background(), opts...)
+		if err != nil {
+			t.Fatalf("IdentityServer() error = %v", err)
+		}
+		return srv.(*serverWrapper).address
+	}
+
+	if got := address(); got != ":8443" {
+		t.Errorf("default address = %q, want %q", got, ":8443")
+	}
+	if got := address(WithServerConfig(internal)); got != "127.0.0.1:9443" {
+		t.Errorf("configured address = %q, want %q", got, "127.0.0.1:9443")
+	}
+	t.Setenv(ports.EnvBindAddress, "127.0.0.1:9444")
+	if got := address(WithServerConfig(internal)); got != "127.0.0.1:9444" {
+		t.Errorf("environment address = %q, want %q", got, "127.0.0.1:9444")
+	}
+	if got := address(WithServerConfig(internal), WithAddress("127.0.0.1:9445")); got != "127.0.0.1:9445" {
+		t.Errorf("option address = %q, want %q", got, "127.0.0.1:9445")
+	}
+}
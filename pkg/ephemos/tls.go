@@ -0,0 +1,54 @@
+package ephemos
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+)
+
+// TLSSettings selects the minimum TLS version, the TLS 1.2 cipher suites and the key
+// exchange curves, including the X25519MLKEM768 post-quantum hybrid. It is the tls
+// section of the configuration.
+type TLSSettings = ports.TLSConfig
+
+// ApplyTLSSettings applies the tls section of config to tlsConfig, for TLS configs
+// built with NewTLSConfig or NewServerTLSConfig without settings. Without a tls
+// section the TLS 1.3 default is kept. With fips_mode set, the FIPS 140-3
+// restrictions are applied too.
+//
+// Example:
+//
+//	tlsConfig, err := ephemos.NewServerTLSConfig(identityService, authorizer)
+//	if err != nil {
+//	    return err
+//	}
+//	if err := ephemos.ApplyTLSSettings(tlsConfig, config); err != nil {
+//	    return err
+//	}
+func ApplyTLSSettings(tlsConfig *tls.Config, config Configuration) error {
+	cfg, err := GetInternalConfig(config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.EffectiveTLS().Apply(tlsConfig); err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return nil
+}
+
+// applyTLSSettings applies the optional TLS settings of NewTLSConfig and
+// NewServerTLSConfig, or the defaults without them.
+func applyTLSSettings(tlsConfig *tls.Config, settings []*TLSSettings) error {
+	if len(settings) > 1 {
+		return fmt.Errorf("%w: at most one TLS settings value may be given", ErrConfigInvalid)
+	}
+	var effective *TLSSettings
+	if len(settings) == 1 {
+		effective = settings[0]
+	}
+	if err := effective.Apply(tlsConfig); err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package ephemos
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityServerHTTPMode_AppliesTLSSettings(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: tls-test
+  domain: example.org
+tls:
+  min_version: "1.3"
+  curve_preferences: ["X25519MLKEM768", "X25519"]
+`))
+	require.NoError(t, err)
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithServerConfig(internal),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS.Version != tls.VersionTLS13 {
+				w.WriteHeader(http.StatusUpgradeRequired)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	url := "https://" + listener.Addr().String() + "/"
+
+	hybrid, err := NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/client"),
+		TLS:             &TLSSettings{CurvePreferences: []string{"X25519MLKEM768"}},
+	})
+	require.NoError(t, err)
+	resp, err := hybrid.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// The server only offers X25519MLKEM768 and X25519
+	p384Only, err := NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/client"),
+		TLS:             &TLSSettings{CurvePreferences: []string{"P384"}},
+	})
+	require.NoError(t, err)
+	_, err = p384Only.Get(url)
+	assert.Error(t, err)
+
+	_, err = NewHTTPClient(&HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/client"),
+		TLS:             &TLSSettings{MinVersion: "1.4"},
+	})
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+}
+
+func TestApplyTLSSettings(t *testing.T) {
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: tls-test
+tls:
+  min_version: "1.2"
+  cipher_suites: ["TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"]
+`))
+	require.NoError(t, err)
+
+	tlsConfig, err := NewServerTLSConfig(newTestCA(t).issue(t, "spiffe://example.org/server"), AuthorizeAny())
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+
+	require.NoError(t, ApplyTLSSettings(tlsConfig, config))
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, tlsConfig.CipherSuites)
+}
+
+func TestNewServerTLSConfig_AppliesSettings(t *testing.T) {
+	identity := newTestCA(t).issue(t, "spiffe://example.org/server")
+
+	tlsConfig, err := NewServerTLSConfig(identity, AuthorizeAny(), &TLSSettings{
+		MinVersion:       "1.2",
+		CipherSuites:     []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+		CurvePreferences: []string{"P384"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, tlsConfig.CipherSuites)
+	assert.Equal(t, []tls.CurveID{tls.CurveP384}, tlsConfig.CurvePreferences)
+
+	_, err = NewServerTLSConfig(identity, AuthorizeAny(), &TLSSettings{MinVersion: "1.4"})
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+
+	_, err = NewTLSConfig(identity, AuthorizeAny(), "", &TLSSettings{CurvePreferences: []string{"P192"}})
+	assert.ErrorIs(t, err, ErrConfigInvalid)
+}
+
+func TestIdentityServerHTTPMode_DefaultTLSSettingsWithoutConfig(t *testing.T) {
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	url := "https://" + listener.Addr().String() + "/"
+
+	transport, err := NewHTTPTransport(&HTTPTransportConfig{HTTPClientConfig: HTTPClientConfig{
+		IdentityService: ca.issue(t, "spiffe://example.org/client"),
+		TLS:             &TLSSettings{MinVersion: "1.2"},
+	}})
+	require.NoError(t, err)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// The server keeps the TLS 1.3 minimum
+	transport.TLSClientConfig.MaxVersion = tls.VersionTLS12
+	transport.CloseIdleConnections()
+	_, err = client.Get(url)
+	assert.Error(t, err)
+}
+
+func TestIdentityServerHTTPMode_AuthNotRequired(t *testing.T) {
+	t.Setenv("EPHEMOS_REQUIRE_AUTHENTICATION", "")
+	ca := newTestCA(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	config, err := ParseConfiguration(context.Background(), []byte(`
+service:
+  name: auth-test
+  domain: example.org
+auth:
+  require: false
+`))
+	require.NoError(t, err)
+	internal, err := GetInternalConfig(config)
+	require.NoError(t, err)
+
+	server, err := IdentityServer(context.Background(),
+		WithListener(listener),
+		WithServerConfig(internal),
+		WithHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) > 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		})),
+		WithServerIdentityService(ca.issue(t, "spiffe://example.org/server")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.ListenAndServe(ctx) }()
+	defer server.Close()
+	require.Eventually(t, func() bool { return server.Addr() != nil }, time.Second, 10*time.Millisecond)
+	url := "https://" + listener.Addr().String() + "/"
+
+	// A client without a certificate completes the handshake
+	anonymous := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // the test only checks client authentication
+	}}
+	resp, err := anonymous.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Clients with an SVID are still authenticated
+	authenticated, err := NewHTTPClient(&HTTPClientConfig{IdentityService: ca.issue(t, "spiffe://example.org/client")})
+	require.NoError(t, err)
+	resp, err = authenticated.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
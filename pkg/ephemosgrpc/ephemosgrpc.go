@@ -0,0 +1,89 @@
+// Package ephemosgrpc connects generated gRPC code to ephemos servers and clients.
+//
+// Package ephemos is kept free of protocol dependencies; this package provides the
+// typed gRPC entry points on top of its transport-agnostic hooks.
+//
+// Server example:
+//
+//	server, err := ephemos.IdentityServer(ctx, ephemos.WithServerConfig(config))
+//	if err != nil { return err }
+//
+//	err = ephemosgrpc.RegisterService(ctx, server, func(s grpc.ServiceRegistrar) {
+//	    paymentpb.RegisterPaymentServer(s, &paymentImpl{})
+//	})
+//
+// Client example:
+//
+//	conn, err := client.Connect(ctx, "spiffe://prod.company.com/payment")
+//	if err != nil { return err }
+//	grpcConn, err := ephemosgrpc.ClientConn(conn)
+//	if err != nil { return err }
+//	payments := paymentpb.NewPaymentClient(grpcConn)
+package ephemosgrpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/sufield/ephemos/internal/adapters/secondary/transport"
+	"github.com/sufield/ephemos/pkg/ephemos"
+)
+
+// RegisterService registers gRPC service implementations on an identity server.
+// The register function receives the underlying gRPC server, so generated
+// RegisterXxxServer functions can be called directly. Must be called before
+// ListenAndServe.
+func RegisterService(ctx context.Context, server ephemos.Server, register func(grpc.ServiceRegistrar)) error {
+	if server == nil {
+		return fmt.Errorf("%w: server cannot be nil", ephemos.ErrConfigInvalid)
+	}
+	if register == nil {
+		return fmt.Errorf("%w: register function cannot be nil", ephemos.ErrConfigInvalid)
+	}
+
+	return server.RegisterService(ctx, func(s interface{}) error {
+		registrar, ok := s.(grpc.ServiceRegistrar)
+		if !ok || registrar == nil {
+			return fmt.Errorf("%w: server transport %T is not a gRPC server", ephemos.ErrConfigInvalid, s)
+		}
+		register(registrar)
+		return nil
+	})
+}
+
+// ClientConn returns the authenticated gRPC client connection of an ephemos connection.
+// The gRPC connection uses the same rotation-aware mTLS credentials as the HTTP client,
+// is owned by conn and is closed by conn.Close.
+func ClientConn(conn ephemos.ClientConnection) (*grpc.ClientConn, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("%w: connection cannot be nil", ephemos.ErrConfigInvalid)
+	}
+
+	raw, err := conn.TransportConn()
+	if err != nil {
+		return nil, err
+	}
+
+	grpcConn, ok := raw.(*grpc.ClientConn)
+	if !ok || grpcConn == nil {
+		return nil, fmt.Errorf("%w: connection does not provide a gRPC client connection", ephemos.ErrConnectionFailed)
+	}
+	return grpcConn, nil
+}
+
+// ExpectServerID returns a gRPC call option that fails the call with codes.Unauthenticated
+// unless the server has the given SPIFFE ID. Streams are checked before their first
+// message is sent; a unary call is checked once it completed, so its request may
+// reach another server and only the response is discarded. Connections made with
+// ephemos.WithServerID check their server in the handshake, before any request is
+// sent; this option sets the expected server of one call.
+//
+// Example:
+//
+//	resp, err := client.Charge(ctx, req,
+//	    ephemosgrpc.ExpectServerID("spiffe://prod.company.com/billing"))
+func ExpectServerID(id string) grpc.CallOption {
+	return transport.ExpectServerID(id)
+}
@@ -0,0 +1,127 @@
+package ephemosgrpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/sufield/ephemos/internal/core/ports"
+	"github.com/sufield/ephemos/pkg/ephemos"
+	"github.com/sufield/ephemos/pkg/ephemosgrpc"
+)
+
+// registeringServer hands a fixed transport server to registrars.
+type registeringServer struct {
+	transport interface{}
+}
+
+func (s *registeringServer) RegisterService(_ context.Context, registrar ports.ServiceRegistrarPort) error {
+	registrar.Register(s.transport)
+	return nil
+}
+
+func (s *registeringServer) Serve(context.Context, ports.NetworkListenerPort) error { return nil }
+func (s *registeringServer) Close() error                                           { return nil }
+func (s *registeringServer) Addr() string                                           { return "" }
+
+// fixedDialer returns connections with a fixed underlying client connection.
+type fixedDialer struct {
+	conn interface{}
+}
+
+func (d *fixedDialer) Connect(context.Context, string, string) (ports.ConnPort, error) {
+	return &fixedConn{conn: d.conn}, nil
+}
+
+func (d *fixedDialer) Close() error { return nil }
+
+type fixedConn struct {
+	conn interface{}
+}
+
+func (c *fixedConn) HTTPClient() (ports.HTTPClientPort, error) {
+	return nil, errors.New("not supported")
+}
+func (c *fixedConn) ClientConnection() (interface{}, error) { return c.conn, nil }
+func (c *fixedConn) Close() error                           { return nil }
+
+func TestRegisterService(t *testing.T) {
+	ctx := context.Background()
+	grpcServer := grpc.NewServer()
+	defer grpcServer.Stop()
+
+	server, err := ephemos.IdentityServer(ctx, ephemos.WithServerImpl(&registeringServer{transport: grpcServer}))
+	require.NoError(t, err)
+	defer server.Close()
+
+	err = ephemosgrpc.RegisterService(ctx, server, func(s grpc.ServiceRegistrar) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+	})
+	require.NoError(t, err)
+	assert.Contains(t, grpcServer.GetServiceInfo(), healthpb.Health_ServiceDesc.ServiceName)
+
+	t.Run("nil arguments are rejected", func(t *testing.T) {
+		err := ephemosgrpc.RegisterService(ctx, nil, func(grpc.ServiceRegistrar) {})
+		assert.ErrorIs(t, err, ephemos.ErrConfigInvalid)
+		err = ephemosgrpc.RegisterService(ctx, server, nil)
+		assert.ErrorIs(t, err, ephemos.ErrConfigInvalid)
+	})
+}
+
+func TestRegisterService_NotGRPCServer(t *testing.T) {
+	ctx := context.Background()
+	server, err := ephemos.IdentityServer(ctx, ephemos.WithServerImpl(&registeringServer{transport: "not a gRPC server"}))
+	require.NoError(t, err)
+	defer server.Close()
+
+	called := false
+	err = ephemosgrpc.RegisterService(ctx, server, func(grpc.ServiceRegistrar) { called = true })
+	assert.ErrorIs(t, err, ephemos.ErrConfigInvalid)
+	assert.False(t, called)
+}
+
+func TestClientConn(t *testing.T) {
+	ctx := context.Background()
+
+	grpcConn, err := grpc.NewClient("passthrough:///localhost:0",
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer grpcConn.Close()
+
+	client, err := ephemos.IdentityClient(ctx, ephemos.WithDialer(&fixedDialer{conn: grpcConn}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := client.Connect(ctx, "localhost:0")
+	require.NoError(t, err)
+
+	got, err := ephemosgrpc.ClientConn(conn)
+	require.NoError(t, err)
+	assert.Same(t, grpcConn, got)
+
+	require.NoError(t, conn.Close())
+	_, err = ephemosgrpc.ClientConn(conn)
+	assert.ErrorIs(t, err, ephemos.ErrServerClosed)
+}
+
+func TestClientConn_NotGRPCConnection(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := ephemos.IdentityClient(ctx, ephemos.WithDialer(&fixedDialer{conn: "not a gRPC connection"}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := client.Connect(ctx, "localhost:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = ephemosgrpc.ClientConn(conn)
+	assert.ErrorIs(t, err, ephemos.ErrConnectionFailed)
+}